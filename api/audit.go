@@ -0,0 +1,38 @@
+package api
+
+import (
+	"aspire-assignment/pkg/db"
+	"aspire-assignment/pkg/service/v1/audit"
+	"context"
+	"fmt"
+	"log"
+)
+
+// VerifyAudit checks the hash chain of the audit log without starting the server. command is verify
+func VerifyAudit(command string) error {
+	if command != "verify" {
+		return fmt.Errorf("unknown audit command %s. use verify", command)
+	}
+	if err := requireSqlDatabase("audit"); err != nil {
+		return err
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		log.Printf("Failed to connect database. Error:%s", err.Error())
+		return err
+	}
+	defer func() {
+		if sqlDb, _ := conn.DB(); sqlDb != nil {
+			sqlDb.Close()
+		}
+	}()
+
+	servObj := audit.NewAuditService(db.NewDBObject(conn).GetV1DBLayer())
+	verification, err := servObj.VerifyAuditLog(context.Background())
+	if err != nil {
+		return err
+	}
+	log.Printf("audit chain intact: %d entries, head %s", verification.Entries, verification.Head)
+	return nil
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/notifier"
+	auditservice "aspire-assignment/pkg/service/v1/audit"
+	"aspire-assignment/pkg/service/v1/usermanagement"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// Bootstrap creates the first admin account without starting the server
+func Bootstrap(request usermanagement.BootstrapAdminRequest) error {
+	//error initialization
+	e.ErrorInit()
+
+	if err := requireSqlDatabase("bootstrap"); err != nil {
+		return err
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		log.Printf("Failed to connect database. Error:%s", err.Error())
+		return err
+	}
+	defer func() {
+		if sqlDb, _ := conn.DB(); sqlDb != nil {
+			sqlDb.Close()
+		}
+	}()
+
+	notifierObj, err := notifier.NewNotifier()
+	if err != nil {
+		log.Printf("Failed to init notifier. Error:%s", err.Error())
+		return err
+	}
+
+	dbObj := db.NewDBObject(conn)
+	servObj := usermanagement.NewUserManagementService(dbObj.GetV1DBLayer(), notifierObj)
+
+	userId, err := servObj.BootstrapAdmin(context.Background(), request)
+	if err != nil {
+		return err
+	}
+	log.Printf("created admin %s with UserId: %d", request.UserName, userId)
+
+	after, _ := json.Marshal(map[string]interface{}{"username": request.UserName, "email": request.Email, "mobile": request.Mobile, "userType": config.ADMIN})
+	err = auditservice.NewAuditService(dbObj.GetV1DBLayer()).RecordAudit(context.Background(), audit.Entry{
+		ActorType:  audit.ACTOR_SYSTEM,
+		Actor:      "bootstrap",
+		Action:     audit.ADMIN_BOOTSTRAP,
+		Outcome:    audit.SUCCESS,
+		TargetType: audit.TARGET_USER,
+		TargetId:   fmt.Sprint(userId),
+		After:      string(after),
+	})
+	if err != nil {
+		log.Printf("failed to write audit entry for %s. Error: %s", audit.ADMIN_BOOTSTRAP, err.Error())
+	}
+	return nil
+}
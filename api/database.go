@@ -0,0 +1,86 @@
+package api
+
+import (
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db"
+	"aspire-assignment/pkg/service/v1/usermanagement"
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"gorm.io/gorm"
+)
+
+// openDatabase returns the db layer selected by databases.driver in config. the connection is nil for the memory store
+func openDatabase(ctx context.Context) (db.DBLayer, *gorm.DB, error) {
+	c := config.GetConfig()
+
+	driver := c.GetString("databases.driver")
+	if driver == "" {
+		driver = db.POSTGRES
+	}
+	switch driver {
+	case db.POSTGRES, db.SQLITE:
+		conn, err := db.Connect()
+		if err != nil {
+			log.Printf("Failed to connect %s database. Error:%s", driver, err.Error())
+			return nil, nil, err
+		}
+
+		//bring the schema up to date before anything reads it
+		if c.GetBool("databases." + driver + ".auto_migrate") {
+			if err := migrateUp(ctx, conn); err != nil {
+				log.Printf("Failed to migrate %s database. Error:%s", driver, err.Error())
+				sqlDb, _ := conn.DB()
+				if sqlDb != nil {
+					sqlDb.Close()
+				}
+				return nil, nil, err
+			}
+		}
+		return db.NewDBObject(conn), conn, nil
+	case db.MEMORY:
+		log.Println("In-memory database initialized. all data is lost on shutdown")
+		return db.NewMemoryDBObject(), nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported database driver %s", driver)
+	}
+}
+
+// bootstrapMemoryAdmin creates the admin from databases.memory.admin, as the memory store starts empty on every run.
+// the password is read from the config or the ASPIRE_ADMIN_PASSWORD environment variable
+func bootstrapMemoryAdmin(ctx context.Context, servObj usermanagement.UserManagementInterface) error {
+	c := config.GetConfig()
+	if c.GetString("databases.driver") != db.MEMORY || c.GetString("databases.memory.admin.username") == "" {
+		return nil
+	}
+
+	request := usermanagement.BootstrapAdminRequest{
+		UserName: c.GetString("databases.memory.admin.username"),
+		Password: c.GetString("databases.memory.admin.password"),
+		Email:    c.GetString("databases.memory.admin.email"),
+		Mobile:   c.GetString("databases.memory.admin.mobile"),
+	}
+	if request.Password == "" {
+		request.Password = os.Getenv("ASPIRE_ADMIN_PASSWORD")
+	}
+	if request.Password == "" {
+		log.Println("no admin created in the in-memory database. set ASPIRE_ADMIN_PASSWORD or databases.memory.admin.password")
+		return nil
+	}
+	userId, err := servObj.BootstrapAdmin(ctx, request)
+	if err != nil {
+		return err
+	}
+	log.Printf("created admin %s with UserId: %d", request.UserName, userId)
+	return nil
+}
+
+// requireSqlDatabase stops the commands which only make sense against a persistent database
+func requireSqlDatabase(command string) error {
+	if config.GetConfig().GetString("databases.driver") == db.MEMORY {
+		return fmt.Errorf("%s needs the postgres or sqlite database driver", command)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"aspire-assignment/pkg/db"
+	"aspire-assignment/pkg/db/migrations"
+	"context"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// Migrate runs the schema migrations without starting the server. command is one of up, down, status or baseline,
+// which adopts a schema built before the migrations as being at version
+func Migrate(command string, steps int, version int64) error {
+	if err := requireSqlDatabase("migrate"); err != nil {
+		return err
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		log.Printf("Failed to connect database. Error:%s", err.Error())
+		return err
+	}
+	defer func() {
+		if sqlDb, _ := conn.DB(); sqlDb != nil {
+			sqlDb.Close()
+		}
+	}()
+
+	migrator, err := migrations.NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "up":
+		count, err := migrator.Up(context.Background())
+		if err != nil {
+			return err
+		}
+		log.Printf("applied %d migrations", count)
+	case "down":
+		count, err := migrator.Down(context.Background(), steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("reverted %d migrations", count)
+	case "baseline":
+		count, err := migrator.Baseline(context.Background(), version)
+		if err != nil {
+			return err
+		}
+		log.Printf("recorded %d migrations as applied", count)
+	case "status":
+		list, err := migrator.Status(context.Background())
+		if err != nil {
+			return err
+		}
+		for _, status := range list {
+			appliedAt := "pending"
+			if status.Applied() {
+				appliedAt = "applied at " + status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		return fmt.Errorf("unknown migrate command %s. use up, down, status or baseline", command)
+	}
+	return nil
+}
+
+func migrateUp(ctx context.Context, conn *gorm.DB) error {
+	migrator, err := migrations.NewMigrator(conn)
+	if err != nil {
+		return err
+	}
+	count, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("applied %d migrations", count)
+	return nil
+}
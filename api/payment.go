@@ -0,0 +1,37 @@
+package api
+
+import (
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/payment"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+// SimulatePayment plays the payment provider against a running server: it notifies a payment of amount quoting
+// the reference of the loan, signed with the configured secret. command is simulate
+func SimulatePayment(command string, loanId int64, amount float64, url string) error {
+	if command != "simulate" {
+		return fmt.Errorf("unknown payment command %s. use simulate", command)
+	}
+	secret := config.GetConfig().GetString("payments.webhook.secret")
+	if secret == "" {
+		return payment.ErrWebhookDisabled
+	}
+	if url == "" {
+		url = fmt.Sprintf("http://localhost:%d/webhooks/payments", config.GetConfig().GetInt("server.port"))
+	}
+
+	provider := payment.NewFakeProvider(secret)
+	event := provider.Payment(loanId, amount)
+	code, err := provider.Send(context.Background(), url, event)
+	if err != nil {
+		return err
+	}
+	if code != http.StatusOK {
+		return fmt.Errorf("payment %s answered %d", event.Data.PaymentId, code)
+	}
+	log.Printf("payment %s of %.2f for %s notified as event %s", event.Data.PaymentId, amount, event.Data.Reference, event.Id)
+	return nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"aspire-assignment/pkg/audit"
 	"aspire-assignment/pkg/auth"
 	"aspire-assignment/pkg/service"
 
@@ -9,19 +10,48 @@ import (
 
 func getRouter(obj service.ServiceGroupLayer) *gin.Engine {
 	router := gin.New()
-	router.Use(gin.Recovery())
+	router.Use(gin.Recovery(), audit.RequestId())
+
+	//admin and money actions are written to the audit log with the outcome of the request
+	audited := func(action string) gin.HandlerFunc {
+		return audit.Record(obj.GetV1Service(), action)
+	}
 
 	// Health check API can be used for the Kubernetes pod health
 	router.GET("/health", obj.Health)
 
+	// public keys for services verifying our access tokens
+	router.GET("/.well-known/jwks.json", obj.GetV1Service().GetJWKS)
+
+	// payment notifications of the payment provider, authenticated by their signature
+	router.POST("/webhooks/payments", audited(audit.PAYMENT_RECEIVE), obj.GetV1Service().ReceivePayment)
+
 	//cred APIs
 	credGroup := router.Group("cred")
 	{
-		credGroup.POST("signup", obj.GetV1Service().UserSignup) //signup as customer or admin
-		credGroup.POST("login", obj.GetV1Service().UserLogin)   //login for cutomer / admin
+		credGroup.POST("signup", audited(audit.USER_SIGNUP), obj.GetV1Service().UserSignup)         //signup as customer
+		credGroup.POST("login", audited(audit.USER_LOGIN), obj.GetV1Service().UserLogin)            //login for cutomer / admin
+		credGroup.POST("signup/admin", audited(audit.ADMIN_SIGNUP), obj.GetV1Service().AdminSignup) //signup as admin with an invite code
+
+		credGroup.POST("password/forgot", obj.GetV1Service().ForgotPassword)                                                                      //send a password reset token to the registered email
+		credGroup.POST("password/reset", audited(audit.PASSWORD_RESET), obj.GetV1Service().ResetPassword)                                         //reset password with the token, logs out all sessions
+		credGroup.PUT("password", auth.UserAuthMiddleware(obj.GetV1Service()), audited(audit.PASSWORD_CHANGE), obj.GetV1Service().ChangePassword) //change password of logged in customer / admin
+
+		credGroup.POST("refresh", obj.GetV1Service().RefreshSession)                                     //exchange a refresh token for a new access and refresh token
+		credGroup.POST("logout", auth.UserAuthMiddleware(obj.GetV1Service()), obj.GetV1Service().Logout) //revoke the access token and the refresh token of the session
+
+		credGroup.POST("login/2fa", audited(audit.USER_LOGIN_2FA), obj.GetV1Service().VerifyLoginChallenge)                                        //complete a login challenge with a totp or recovery code
+		credGroup.POST("login/2fa/enroll", audited(audit.TOTP_ENROLL), obj.GetV1Service().EnrollTotpChallenge)                                     //enroll totp during a login challenge when it is mandatory
+		credGroup.POST("2fa/enroll", auth.UserAuthMiddleware(obj.GetV1Service()), audited(audit.TOTP_ENROLL), obj.GetV1Service().EnrollTotp)       //start totp enrollment for the logged in user
+		credGroup.POST("2fa/activate", auth.UserAuthMiddleware(obj.GetV1Service()), audited(audit.TOTP_ACTIVATE), obj.GetV1Service().ActivateTotp) //activate totp with a first code and get recovery codes
 	}
 
-	router.Use(auth.AuthMiddleware())
+	router.Use(auth.AuthMiddleware(obj.GetV1Service()))
+
+	//every v1 route declares the permission it requires
+	permit := func(permissions ...string) gin.HandlerFunc {
+		return auth.Authorize(obj.GetV1Service(), permissions...)
+	}
 
 	//v1 APIs
 	v1Group := router.Group("v1")
@@ -29,21 +59,74 @@ func getRouter(obj service.ServiceGroupLayer) *gin.Engine {
 		//loan group
 		loanGroup := v1Group.Group("loan")
 		{
-			loanGroup.POST("", obj.GetV1Service().CreateLoan)                 //create loan for a user id
-			loanGroup.PUT("", obj.GetV1Service().ModifyLoan)                  //update the loan requested amount
-			loanGroup.DELETE("", obj.GetV1Service().CancelLoan)               //cancel the loan requested amount
-			loanGroup.GET("status", obj.GetV1Service().GetLoans)              // fetch loans against user, approved, rejected, pending amount
-			loanGroup.GET("installments", obj.GetV1Service().GetInstallments) //transactions against the loan
-			loanGroup.POST("repay", obj.GetV1Service().ProcessLoanPayment)    //payments made
+			loanGroup.POST("", audited(audit.LOAN_APPLY), permit(auth.LOAN_WRITE_OWN), obj.GetV1Service().CreateLoan)                                           //create loan for a user id
+			loanGroup.PUT("", audited(audit.LOAN_MODIFY), permit(auth.LOAN_WRITE_OWN), obj.GetV1Service().ModifyLoan)                                           //update the loan requested amount
+			loanGroup.DELETE("", audited(audit.LOAN_CANCEL), permit(auth.LOAN_WRITE_OWN), obj.GetV1Service().CancelLoan)                                        //cancel the loan requested amount
+			loanGroup.GET("status", permit(auth.LOAN_READ_OWN), obj.GetV1Service().GetLoans)                                                                    // fetch loans against user, approved, rejected, pending amount
+			loanGroup.GET("installments", permit(auth.LOAN_READ_OWN), obj.GetV1Service().GetInstallments)                                                       //transactions against the loan
+			loanGroup.GET("timeline", permit(auth.LOAN_READ_ANY, auth.LOAN_READ_OWN), obj.GetV1Service().GetLoanTimeline)                                       //status changes of the loan, own loans unless the user can read any loan
+			loanGroup.POST("repay", audited(audit.LOAN_REPAY), permit(auth.PAYMENT_CREATE_ANY, auth.PAYMENT_CREATE_OWN), obj.GetV1Service().ProcessLoanPayment) //payments made, by the customer or a service account
+			loanGroup.POST("autodebit", audited(audit.AUTODEBIT_ENABLE), permit(auth.PAYMENT_CREATE_OWN), obj.GetV1Service().EnableAutoDebit)                   //debit the installments of the loan from the account balance on their due date
+			loanGroup.DELETE("autodebit", audited(audit.AUTODEBIT_DISABLE), permit(auth.PAYMENT_CREATE_OWN), obj.GetV1Service().DisableAutoDebit)               //stop the auto debit of the loan and cancel its pending debits
+			loanGroup.GET("autodebit", permit(auth.LOAN_READ_OWN), obj.GetV1Service().GetAutoDebits)                                                            //auto debit mandates of the user and their debits
 			// loanGroup.PUT("offer", v1.ApplyLoan)                    //pre-approved offers based on monthly salary or bank account balance
 		}
 
+		//profile group
+		profileGroup := v1Group.Group("profile")
+		{
+			profileGroup.GET("", permit(auth.PROFILE_READ_OWN), obj.GetV1Service().GetProfile)               //fetch profile of the logged in user
+			profileGroup.PUT("", permit(auth.PROFILE_WRITE_OWN), obj.GetV1Service().UpdateProfile)           //update salary/balance, start email/mobile change
+			profileGroup.POST("verify", permit(auth.PROFILE_WRITE_OWN), obj.GetV1Service().VerifyContact)    //verify email/mobile change with the OTP sent
+			profileGroup.GET("history", permit(auth.PROFILE_READ_OWN), obj.GetV1Service().GetProfileHistory) //profile change history
+		}
+
+		//customer notification group
+		notificationGroup := v1Group.Group("notifications")
+		{
+			notificationGroup.GET("", permit(auth.PROFILE_READ_OWN), obj.GetV1Service().GetNotifications)                          //in-app notifications of the logged in user, latest first
+			notificationGroup.POST("read", permit(auth.PROFILE_WRITE_OWN), obj.GetV1Service().MarkNotificationsRead)               //mark in-app notifications read, all of them without ids
+			notificationGroup.GET("preferences", permit(auth.PROFILE_READ_OWN), obj.GetV1Service().GetNotificationPreferences)     //channels the user is notified on
+			notificationGroup.PUT("preferences", permit(auth.PROFILE_WRITE_OWN), obj.GetV1Service().UpdateNotificationPreferences) //turn email, sms and in-app notifications on or off
+		}
+
+		//kyc document group
+		documentGroup := v1Group.Group("document")
+		{
+			documentGroup.POST("", permit(auth.DOCUMENT_WRITE_OWN), obj.GetV1Service().UploadDocument) //upload id/income proof as multipart form
+			documentGroup.GET("", permit(auth.DOCUMENT_READ_OWN), obj.GetV1Service().GetDocuments)     //list uploaded documents and their verification status
+		}
+
 		//admin group
 		adminGroup := v1Group.Group("admin")
 		{
-			adminGroup.GET("applications", obj.GetV1Service().GetPendingLoans)         //fetch all applications which are unapproved
-			adminGroup.POST("update", obj.GetV1Service().ApproveRejectLoanApplication) //update the loan status for assigned applications
+			adminGroup.GET("applications", permit(auth.LOAN_READ_ANY), obj.GetV1Service().GetPendingLoans)                                      //fetch all applications which are unapproved
+			adminGroup.POST("update", audited(audit.LOAN_DECISION), permit(auth.LOAN_APPROVE), obj.GetV1Service().ApproveRejectLoanApplication) //update the loan status for assigned applications
 			// adminGroup.GET("assign", v1.GetPendingLoans)       //assign a loan application to an approver
+			adminGroup.GET("documents", permit(auth.DOCUMENT_READ_ANY), obj.GetV1Service().GetPendingDocuments)                                                  //fetch all documents pending verification
+			adminGroup.GET("document", permit(auth.DOCUMENT_READ_ANY), obj.GetV1Service().DownloadDocument)                                                      //download a document for review
+			adminGroup.POST("document/verify", audited(audit.DOCUMENT_VERIFY), permit(auth.DOCUMENT_VERIFY), obj.GetV1Service().VerifyDocument)                  //verify/reject an uploaded document
+			adminGroup.POST("invite", audited(audit.ADMIN_INVITE), permit(auth.ADMIN_INVITE), obj.GetV1Service().CreateAdminInvite)                              //invite a new admin by email
+			adminGroup.GET("roles", permit(auth.ROLE_READ), obj.GetV1Service().GetRoles)                                                                         //list roles and the permissions they grant
+			adminGroup.PUT("user/roles", audited(audit.ROLE_ASSIGN), permit(auth.ROLE_ASSIGN), obj.GetV1Service().AssignUserRoles)                               //replace the roles of a user
+			adminGroup.POST("user/unlock", audited(audit.USER_UNLOCK), permit(auth.USER_UNLOCK), obj.GetV1Service().UnlockUser)                                  //clear failed logins of a username or ip
+			adminGroup.POST("service-account", audited(audit.SERVICE_ACCOUNT_CREATE), permit(auth.SERVICE_ACCT_MANAGE), obj.GetV1Service().CreateServiceAccount) //create a service account for machine access
+			adminGroup.GET("service-accounts", permit(auth.SERVICE_ACCT_MANAGE), obj.GetV1Service().GetServiceAccounts)                                          //list service accounts and their api keys
+			adminGroup.POST("service-account/key", audited(audit.API_KEY_CREATE), permit(auth.SERVICE_ACCT_MANAGE), obj.GetV1Service().CreateAPIKey)             //issue a scoped api key for a service account
+			adminGroup.DELETE("service-account/key", audited(audit.API_KEY_REVOKE), permit(auth.SERVICE_ACCT_MANAGE), obj.GetV1Service().RevokeAPIKey)           //revoke an api key
+			adminGroup.GET("audit", permit(auth.AUDIT_READ), obj.GetV1Service().GetAuditLog)                                                                     //filter the audit log
+			adminGroup.POST("webhook", audited(audit.WEBHOOK_CREATE), permit(auth.WEBHOOK_MANAGE), obj.GetV1Service().CreateWebhook)                             //subscribe a partner url to loan events
+			adminGroup.GET("webhooks", permit(auth.WEBHOOK_MANAGE), obj.GetV1Service().GetWebhooks)                                                              //list webhook subscriptions
+			adminGroup.PUT("webhook", audited(audit.WEBHOOK_UPDATE), permit(auth.WEBHOOK_MANAGE), obj.GetV1Service().UpdateWebhook)                              //change, pause or resume a subscription, or rotate its secret
+			adminGroup.DELETE("webhook", audited(audit.WEBHOOK_DELETE), permit(auth.WEBHOOK_MANAGE), obj.GetV1Service().DeleteWebhook)                           //remove a subscription and its delivery log
+			adminGroup.GET("webhook/deliveries", permit(auth.WEBHOOK_MANAGE), obj.GetV1Service().GetWebhookDeliveries)                                           //filter the webhook delivery log
+			adminGroup.POST("webhook/redeliver", audited(audit.WEBHOOK_REDELIVER), permit(auth.WEBHOOK_MANAGE), obj.GetV1Service().RedeliverWebhook)             //send a delivered or dead lettered delivery again
+			adminGroup.GET("payments", permit(auth.LOAN_READ_ANY), obj.GetV1Service().GetPaymentEvents)                                                          //filter the payment notifications of the provider and their outcome
+			adminGroup.GET("autodebits", permit(auth.LOAN_READ_ANY), obj.GetV1Service().GetAutoDebitsByStatus)                                                   //the auto debits of every loan in a status, like the REFUND_FAILED ones to refund by hand
+			adminGroup.POST("reconciliation/statement", audited(audit.STATEMENT_IMPORT), permit(auth.RECONCILE_MANAGE), obj.GetV1Service().ImportStatement)      //import a csv, camt.053 or mt940 bank statement as multipart form and apply its credits to loans
+			adminGroup.GET("reconciliation/statements", permit(auth.RECONCILE_MANAGE), obj.GetV1Service().GetBankStatements)                                     //list imported statements with their lines counted by status
+			adminGroup.GET("reconciliation/lines", permit(auth.RECONCILE_MANAGE), obj.GetV1Service().GetStatementLines)                                          //the exceptions queue, or the lines of any status
+			adminGroup.POST("reconciliation/resolve", audited(audit.STATEMENT_RESOLVE), permit(auth.RECONCILE_MANAGE), obj.GetV1Service().ResolveStatementLine)  //apply an exception to a loan or dismiss it
 		}
 	}
 
@@ -2,10 +2,18 @@ package api
 
 import (
 	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/autodebit"
 	"aspire-assignment/pkg/config"
-	"aspire-assignment/pkg/db"
 	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/notification"
+	"aspire-assignment/pkg/notifier"
+	"aspire-assignment/pkg/outbox"
+	"aspire-assignment/pkg/payment"
 	"aspire-assignment/pkg/service"
+	"aspire-assignment/pkg/service/v1/loan"
+	"aspire-assignment/pkg/service/v1/usermanagement"
+	"aspire-assignment/pkg/storage"
+	"aspire-assignment/pkg/webhook"
 	"context"
 	"fmt"
 	"log"
@@ -18,6 +26,12 @@ import (
 var srv *http.Server
 var ctx context.Context
 var databases []*gorm.DB
+var stopKeyRotation context.CancelFunc
+var stopRelay func()
+var stopDispatcher func()
+var stopScheduler func()
+var stopNotifications func()
+var stopReminder func()
 
 func Start() error {
 	ctx = context.Background()
@@ -28,18 +42,93 @@ func Start() error {
 	//init auth
 	auth.InitAuth()
 
+	//init loan approval policy
+	loan.InitLoanPolicy()
+
+	//init login throttling policy
+	usermanagement.InitLoginPolicy()
+
+	//init outbox retry policy
+	outbox.InitRelayPolicy()
+
+	//init webhook retry policy
+	webhook.InitDispatchPolicy()
+
+	//init payment provider webhook
+	payment.InitGateway()
+
+	//init auto debit retry policy
+	autodebit.InitDebitPolicy()
+
+	//init customer notification policy
+	notification.InitNotificationPolicy()
+
 	databases = make([]*gorm.DB, 0)
-	postgresConn, err := db.PsqlConnect()
+	dbObj, conn, err := openDatabase(ctx)
+	if err != nil {
+		log.Printf("Failed to init database. Error:%s", err.Error())
+		return err
+	}
+	if conn != nil {
+		databases = append(databases, conn)
+	}
+
+	store, err := storage.NewBlobStore()
+	if err != nil {
+		log.Printf("Failed to init blob store. Error:%s", err.Error())
+		return err
+	}
+
+	notifierObj, err := notifier.NewNotifier()
 	if err != nil {
-		log.Printf("Failed to connect psql database. Error:%s", err.Error())
+		log.Printf("Failed to init notifier. Error:%s", err.Error())
 		return err
 	}
 
-	databases = append(databases, postgresConn)
+	serviceObj := service.NewServiceGroupObject(dbObj, store, notifierObj)
 
-	dbObj := db.NewDBObject(postgresConn)
+	if err := bootstrapMemoryAdmin(ctx, serviceObj.GetV1Service()); err != nil {
+		log.Printf("Failed to bootstrap admin. Error:%s", err.Error())
+		return err
+	}
 
-	serviceObj := service.NewServiceGroupObject(dbObj)
+	//load the signing keys and keep rotating them in the background
+	if err := auth.InitKeys(ctx, serviceObj.GetV1Service()); err != nil {
+		log.Printf("Failed to init signing keys. Error:%s", err.Error())
+		return err
+	}
+	stopKeyRotation = auth.StartKeyRotation(serviceObj.GetV1Service())
+
+	//customers are notified of the events concerning them through queued notifications
+	templates, err := notification.NewTemplates()
+	if err != nil {
+		log.Printf("Failed to load notification templates. Error:%s", err.Error())
+		return err
+	}
+	notifications := notification.NewNotifications(dbObj.GetV1DBLayer(), templates)
+
+	//publish the events of the outbox in the background. the webhook sink queues them for the subscriptions
+	//and the dispatcher sends them, the notification sink queues the notifications of the customers
+	sinks, err := outbox.NewSinks()
+	if err != nil {
+		log.Printf("Failed to init outbox sinks. Error:%s", err.Error())
+		return err
+	}
+	sinks = append(sinks, webhook.NewSink(dbObj.GetV1DBLayer()), notification.NewSink(notifications))
+	stopRelay = outbox.NewRelay(dbObj.GetV1DBLayer(), sinks).Start()
+	stopDispatcher = webhook.NewDispatcher(dbObj.GetV1DBLayer()).Start()
+
+	//send the queued notifications and remind customers of their installments in the background
+	stopNotifications = notification.NewDispatcher(dbObj.GetV1DBLayer(), notifierObj).Start()
+	stopReminder = notification.NewReminder(dbObj.GetV1DBLayer(), notifications).Start()
+
+	//debit the installments falling due under the mandates of the customers in the background
+	account, err := autodebit.NewAccountProvider(dbObj.GetV1DBLayer())
+	if err != nil {
+		log.Printf("Failed to init account provider. Error:%s", err.Error())
+		return err
+	}
+	stopScheduler = autodebit.NewScheduler(dbObj.GetV1DBLayer(), account, notifications).Start()
 
 	startRouter(serviceObj)
 	return nil
@@ -64,6 +153,24 @@ func ShutdownRouter() {
 	defer cancel()
 	log.Println("Shutting down router START")
 	defer log.Println("Shutting down router END")
+	if stopKeyRotation != nil {
+		stopKeyRotation()
+	}
+	if stopRelay != nil {
+		stopRelay()
+	}
+	if stopDispatcher != nil {
+		stopDispatcher()
+	}
+	if stopScheduler != nil {
+		stopScheduler()
+	}
+	if stopNotifications != nil {
+		stopNotifications()
+	}
+	if stopReminder != nil {
+		stopReminder()
+	}
 	if err := srv.Shutdown(timeoutCtx); err != nil {
 		log.Fatalf("Server forced to shutdown. Error: %s", err.Error())
 	}
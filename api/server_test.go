@@ -0,0 +1,620 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/notifier"
+	"aspire-assignment/pkg/payment"
+	"aspire-assignment/pkg/service"
+	"aspire-assignment/pkg/service/v1/audit"
+	"aspire-assignment/pkg/service/v1/loan"
+	"aspire-assignment/pkg/service/v1/usermanagement"
+	"aspire-assignment/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+// testServer starts the app on the database driver the way Start does, with its own empty database
+func testServer(t *testing.T, driver string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	config.Load("local", "..")
+	c := config.GetConfig()
+	c.Set("databases.driver", driver)
+	c.Set("databases.memory.admin.username", "")
+	c.Set("auth.totp.required_for_admin", false)
+	c.Set("storage.local.path", t.TempDir())
+
+	switch driver {
+	case db.POSTGRES:
+		server, err := db.PsqlConnect()
+		if err != nil {
+			t.Skipf("postgres is not available: %s", err.Error())
+		}
+		name := fmt.Sprintf("aspire_test_%d", time.Now().UnixNano())
+		if err := server.Exec("CREATE DATABASE " + name).Error; err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			server.Exec("DROP DATABASE " + name)
+			if sqlDb, _ := server.DB(); sqlDb != nil {
+				sqlDb.Close()
+			}
+		})
+		c.Set("databases.postgres.db", name)
+		c.Set("databases.postgres.auto_migrate", true)
+	case db.SQLITE:
+		c.Set("databases.sqlite.path", filepath.Join(t.TempDir(), "aspire.db"))
+		c.Set("databases.sqlite.auto_migrate", true)
+	}
+
+	e.ErrorInit()
+	auth.InitAuth()
+	loan.InitLoanPolicy()
+	usermanagement.InitLoginPolicy()
+	payment.InitGateway()
+
+	ctx := context.Background()
+	dbObj, conn, err := openDatabase(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn != nil {
+		t.Cleanup(func() {
+			if sqlDb, _ := conn.DB(); sqlDb != nil {
+				sqlDb.Close()
+			}
+		})
+	}
+	store, err := storage.NewBlobStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	notifierObj, err := notifier.NewNotifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serviceObj := service.NewServiceGroupObject(dbObj, store, notifierObj)
+	if err := auth.InitKeys(ctx, serviceObj.GetV1Service()); err != nil {
+		t.Fatal(err)
+	}
+	_, err = serviceObj.GetV1Service().BootstrapAdmin(ctx, usermanagement.BootstrapAdminRequest{
+		UserName: "admin",
+		Password: "Admin@1234",
+		Email:    "admin@example.com",
+		Mobile:   "9999999999",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return getRouter(serviceObj)
+}
+
+type testResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// call sends the request to the router and decodes the data of the response into data
+func call(t *testing.T, router *gin.Engine, request *http.Request, token string, data interface{}) int {
+	if token != "" {
+		request.Header.Set(config.AUTHORIZATION, "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+
+	var response testResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%s %s: %s", request.Method, request.URL, recorder.Body.String())
+	}
+	if data != nil && len(response.Data) != 0 {
+		if err := json.Unmarshal(response.Data, data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return recorder.Code
+}
+
+func jsonRequest(method string, path string, body interface{}) *http.Request {
+	payload, _ := json.Marshal(body)
+	request := httptest.NewRequest(method, path, bytes.NewReader(payload))
+	request.Header.Set("Content-Type", "application/json")
+	return request
+}
+
+func uploadRequest(docType string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	writer.WriteField("type", docType)
+	file, _ := writer.CreateFormFile("file", "proof.pdf")
+	file.Write([]byte("%PDF-1.4\n%proof\n"))
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/document", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func login(t *testing.T, router *gin.Engine, userName string, password string) string {
+	var user struct {
+		Token string `json:"token"`
+	}
+	code := call(t, router, jsonRequest(http.MethodPost, "/cred/login", map[string]string{"username": userName, "password": password}), "", &user)
+	assert.Equal(t, http.StatusOK, code)
+	return user.Token
+}
+
+func Test_Server_LoanLifecycle(t *testing.T) {
+	for _, driver := range []string{db.POSTGRES, db.SQLITE, db.MEMORY} {
+		t.Run(driver, func(t *testing.T) {
+			router := testServer(t, driver)
+
+			code := call(t, router, jsonRequest(http.MethodPost, "/cred/signup", map[string]interface{}{
+				"username":    "john",
+				"password":    "John@12345",
+				"email":       "john@example.com",
+				"mobile":      "9876543210",
+				"salary":      10000,
+				"bankBalance": 500,
+			}), "", nil)
+			assert.Equal(t, http.StatusOK, code)
+			customer := login(t, router, "john", "John@12345")
+			admin := login(t, router, "admin", "Admin@1234")
+
+			//the documents required for a loan are uploaded and verified
+			for _, docType := range []string{"ID_PROOF", "INCOME_PROOF"} {
+				code = call(t, router, uploadRequest(docType), customer, nil)
+				assert.Equal(t, http.StatusOK, code)
+			}
+			var pending []struct {
+				DocumentId int64 `json:"documentId"`
+			}
+			call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/documents", nil), admin, &pending)
+			assert.Equal(t, 2, len(pending))
+			for _, doc := range pending {
+				code = call(t, router, jsonRequest(http.MethodPost, "/v1/admin/document/verify", map[string]interface{}{"documentId": doc.DocumentId, "verdict": "VERIFY"}), admin, nil)
+				assert.Equal(t, http.StatusOK, code)
+			}
+
+			var created struct {
+				LoanId int64  `json:"loanId"`
+				Status string `json:"status"`
+			}
+			code = call(t, router, jsonRequest(http.MethodPost, "/v1/loan", map[string]interface{}{"amount": 3000, "tenure": 3}), customer, &created)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "PENDING", created.Status)
+
+			code = call(t, router, jsonRequest(http.MethodPost, "/v1/admin/update", map[string]interface{}{"loanId": created.LoanId, "approval": "APPROVE"}), admin, nil)
+			assert.Equal(t, http.StatusOK, code)
+			//an approved loan can neither be approved again nor cancelled
+			code = call(t, router, jsonRequest(http.MethodPost, "/v1/admin/update", map[string]interface{}{"loanId": created.LoanId, "approval": "APPROVE"}), admin, nil)
+			assert.Equal(t, http.StatusConflict, code)
+			code = call(t, router, jsonRequest(http.MethodDelete, "/v1/loan", map[string]interface{}{"loanId": created.LoanId}), customer, nil)
+			assert.Equal(t, http.StatusConflict, code)
+
+			var detail struct {
+				Status       string `json:"status"`
+				Installments []struct {
+					Status string `json:"status"`
+				} `json:"installments"`
+			}
+			installmentsPath := fmt.Sprintf("/v1/loan/installments?loanId=%d", created.LoanId)
+			call(t, router, httptest.NewRequest(http.MethodGet, installmentsPath, nil), customer, &detail)
+			assert.Equal(t, "APPROVED", detail.Status)
+			assert.Equal(t, 3, len(detail.Installments))
+
+			//the first repayment covers one installment, the second covers the rest and closes the loan
+			code = call(t, router, jsonRequest(http.MethodPost, "/v1/loan/repay", map[string]interface{}{"loanId": created.LoanId, "amount": 1000, "transactionId": "txn-1"}), customer, nil)
+			assert.Equal(t, http.StatusOK, code)
+			code = call(t, router, jsonRequest(http.MethodPost, "/v1/loan/repay", map[string]interface{}{"loanId": created.LoanId, "amount": 2000, "transactionId": "txn-2"}), customer, nil)
+			assert.Equal(t, http.StatusOK, code)
+
+			var loans []struct {
+				LoanId int64  `json:"loanId"`
+				Status string `json:"status"`
+			}
+			call(t, router, httptest.NewRequest(http.MethodGet, "/v1/loan/status", nil), customer, &loans)
+			assert.Equal(t, 1, len(loans))
+			assert.Equal(t, "PAID", loans[0].Status)
+			call(t, router, httptest.NewRequest(http.MethodGet, installmentsPath, nil), customer, &detail)
+			statuses := make([]string, 0)
+			for _, installment := range detail.Installments {
+				statuses = append(statuses, installment.Status)
+			}
+			assert.Equal(t, []string{"PAID", "PAID", "CANCELLED"}, statuses)
+
+			//the customer and the admin see when the loan was applied for, approved and paid
+			var timeline []struct {
+				FromStatus string `json:"fromStatus"`
+				Status     string `json:"status"`
+				Actor      string `json:"actor"`
+			}
+			timelinePath := fmt.Sprintf("/v1/loan/timeline?loanId=%d", created.LoanId)
+			code = call(t, router, httptest.NewRequest(http.MethodGet, timelinePath, nil), customer, &timeline)
+			assert.Equal(t, http.StatusOK, code)
+			events := make([]string, 0)
+			for _, event := range timeline {
+				events = append(events, event.FromStatus+">"+event.Status+" by "+event.Actor)
+			}
+			assert.Equal(t, []string{">PENDING by CUSTOMER", "PENDING>APPROVED by ADMIN", "APPROVED>PAID by SYSTEM"}, events)
+			code = call(t, router, httptest.NewRequest(http.MethodGet, timelinePath, nil), admin, &timeline)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, 3, len(timeline))
+		})
+	}
+}
+
+func Test_Server_AuditLog(t *testing.T) {
+	for _, driver := range []string{db.POSTGRES, db.SQLITE, db.MEMORY} {
+		t.Run(driver, func(t *testing.T) {
+			router := testServer(t, driver)
+
+			code := call(t, router, jsonRequest(http.MethodPost, "/cred/signup", map[string]interface{}{
+				"username":    "john",
+				"password":    "John@12345",
+				"email":       "john@example.com",
+				"mobile":      "9876543210",
+				"salary":      10000,
+				"bankBalance": 500,
+			}), "", nil)
+			assert.Equal(t, http.StatusOK, code)
+			customer := login(t, router, "john", "John@12345")
+
+			//the request id of the caller is echoed and kept with the entry
+			request := jsonRequest(http.MethodPost, "/v1/loan", map[string]interface{}{"amount": 3000, "tenure": 3})
+			request.Header.Set(config.REQUESTHEADER, "req-loan-1")
+			recorder := httptest.NewRecorder()
+			request.Header.Set(config.AUTHORIZATION, "Bearer "+customer)
+			router.ServeHTTP(recorder, request)
+			assert.Equal(t, "req-loan-1", recorder.Header().Get(config.REQUESTHEADER))
+
+			//customers cannot read the audit log, admins can
+			code = call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/audit", nil), customer, nil)
+			assert.Equal(t, http.StatusForbidden, code)
+			admin := login(t, router, "admin", "Admin@1234")
+			code = call(t, router, jsonRequest(http.MethodPost, "/cred/login", map[string]string{"username": "john", "password": "Wrong@12345"}), "", nil)
+			assert.Equal(t, http.StatusUnauthorized, code)
+
+			var entries []struct {
+				Action    string          `json:"action"`
+				Outcome   string          `json:"outcome"`
+				ActorType string          `json:"actorType"`
+				Actor     string          `json:"actor"`
+				TargetId  string          `json:"targetId"`
+				After     json.RawMessage `json:"after"`
+				RequestId string          `json:"requestId"`
+				PrevHash  string          `json:"prevHash"`
+				Hash      string          `json:"hash"`
+			}
+			code = call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/audit", nil), admin, &entries)
+			assert.Equal(t, http.StatusOK, code)
+			actions := make([]string, 0)
+			for i, entry := range entries {
+				actions = append(actions, entry.Action+" "+entry.Outcome+" by "+entry.ActorType)
+				if i > 0 {
+					assert.Equal(t, entries[i-1].Hash, entry.PrevHash)
+				}
+			}
+			assert.Equal(t, []string{
+				"user.signup SUCCESS by ANONYMOUS",
+				"user.login SUCCESS by CUSTOMER",
+				"loan.apply SUCCESS by CUSTOMER",
+				"user.login SUCCESS by ADMIN",
+				"user.login FAILURE by ANONYMOUS",
+			}, actions)
+			assert.Equal(t, `{"email":"john@example.com","mobile":"9876543210","userType":"CUSTOMER","username":"john"}`, string(entries[0].After))
+			assert.Equal(t, "req-loan-1", entries[2].RequestId)
+			assert.Equal(t, "john", entries[2].Actor)
+			assert.Equal(t, "john", entries[4].TargetId)
+
+			code = call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/audit?action=user.login&limit=1&afterId=4", nil), admin, &entries)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, 1, len(entries))
+			assert.Equal(t, "FAILURE", entries[0].Outcome)
+
+			if driver == db.MEMORY {
+				return
+			}
+			assert.Equal(t, nil, VerifyAudit("verify"))
+			if driver == db.SQLITE {
+				//an entry changed behind the back of the app, with the append-only triggers dropped, breaks the chain
+				conn, err := db.Connect()
+				if err != nil {
+					t.Fatal(err)
+				}
+				conn.Exec("drop trigger audit_log_no_update")
+				conn.Exec("update audit_log set outcome = 'SUCCESS' where action = 'user.login' and outcome = 'FAILURE'")
+				if sqlDb, _ := conn.DB(); sqlDb != nil {
+					sqlDb.Close()
+				}
+				err = VerifyAudit("verify")
+				var chainErr *audit.ChainError
+				assert.Equal(t, true, errors.As(err, &chainErr))
+				assert.Equal(t, int64(5), chainErr.EntryId)
+			}
+		})
+	}
+}
+
+// approvedLoan signs up a customer with verified documents and gets a loan of 3000 over 3 installments approved
+func approvedLoan(t *testing.T, router *gin.Engine) int64 {
+	code := call(t, router, jsonRequest(http.MethodPost, "/cred/signup", map[string]interface{}{
+		"username":    "john",
+		"password":    "John@12345",
+		"email":       "john@example.com",
+		"mobile":      "9876543210",
+		"salary":      10000,
+		"bankBalance": 500,
+	}), "", nil)
+	assert.Equal(t, http.StatusOK, code)
+	customer := login(t, router, "john", "John@12345")
+	admin := login(t, router, "admin", "Admin@1234")
+
+	for _, docType := range []string{"ID_PROOF", "INCOME_PROOF"} {
+		call(t, router, uploadRequest(docType), customer, nil)
+	}
+	var pending []struct {
+		DocumentId int64 `json:"documentId"`
+	}
+	call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/documents", nil), admin, &pending)
+	for _, doc := range pending {
+		call(t, router, jsonRequest(http.MethodPost, "/v1/admin/document/verify", map[string]interface{}{"documentId": doc.DocumentId, "verdict": "VERIFY"}), admin, nil)
+	}
+
+	var created struct {
+		LoanId int64 `json:"loanId"`
+	}
+	call(t, router, jsonRequest(http.MethodPost, "/v1/loan", map[string]interface{}{"amount": 3000, "tenure": 3}), customer, &created)
+	code = call(t, router, jsonRequest(http.MethodPost, "/v1/admin/update", map[string]interface{}{"loanId": created.LoanId, "approval": "APPROVE"}), admin, nil)
+	assert.Equal(t, http.StatusOK, code)
+	return created.LoanId
+}
+
+func Test_Server_PaymentWebhook(t *testing.T) {
+	for _, driver := range []string{db.POSTGRES, db.SQLITE, db.MEMORY} {
+		t.Run(driver, func(t *testing.T) {
+			router := testServer(t, driver)
+			loanId := approvedLoan(t, router)
+			customer := login(t, router, "john", "John@12345")
+			admin := login(t, router, "admin", "Admin@1234")
+
+			//the customer is told where to pay
+			var detail struct {
+				Status           string `json:"status"`
+				PaymentReference string `json:"paymentReference"`
+				VirtualAccount   string `json:"virtualAccount"`
+				Installments     []struct {
+					Status        string `json:"status"`
+					TransactionId string `json:"transactionId"`
+				} `json:"installments"`
+			}
+			installmentsPath := fmt.Sprintf("/v1/loan/installments?loanId=%d", loanId)
+			call(t, router, httptest.NewRequest(http.MethodGet, installmentsPath, nil), customer, &detail)
+			assert.Equal(t, payment.Reference(loanId), detail.PaymentReference)
+			assert.Equal(t, payment.VirtualAccount(loanId), detail.VirtualAccount)
+
+			ctx := context.Background()
+			provider := payment.NewFakeProvider(config.GetConfig().GetString("payments.webhook.secret"))
+			notify := func(fake *payment.FakeProvider, event payment.Event) (int, string) {
+				request, err := fake.Request(ctx, "/webhooks/payments", event)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var outcome struct {
+					Status string `json:"status"`
+				}
+				code := call(t, router, request, "", &outcome)
+				return code, outcome.Status
+			}
+
+			//a payment quoting the reference repays an installment once, however often it is notified
+			first := provider.Payment(loanId, 1000)
+			code, status := notify(provider, first)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "APPLIED", status)
+			code, status = notify(provider, first)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "APPLIED", status)
+			call(t, router, httptest.NewRequest(http.MethodGet, installmentsPath, nil), customer, &detail)
+			assert.Equal(t, "PAID", detail.Installments[0].Status)
+			assert.Equal(t, first.Data.PaymentId, detail.Installments[0].TransactionId)
+			assert.Equal(t, "PENDING", detail.Installments[1].Status)
+
+			//notifications not signed with the shared secret are refused
+			code, _ = notify(payment.NewFakeProvider("not-the-shared-secret"), provider.Payment(loanId, 2000))
+			assert.Equal(t, http.StatusUnauthorized, code)
+
+			//a payment naming no loan is kept for an admin to sort out
+			unknown := provider.Payment(loanId, 2000)
+			unknown.Data.Reference = "invoice 42"
+			code, status = notify(provider, unknown)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "REJECTED", status)
+
+			//a payment into the virtual account of the loan repays the rest and closes it
+			rest := provider.Payment(loanId, 2000)
+			rest.Data.Reference = ""
+			rest.Data.VirtualAccount = detail.VirtualAccount
+			code, status = notify(provider, rest)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, "APPLIED", status)
+			call(t, router, httptest.NewRequest(http.MethodGet, installmentsPath, nil), customer, &detail)
+			assert.Equal(t, "PAID", detail.Status)
+
+			var events []struct {
+				ProviderEventId string `json:"providerEventId"`
+				Status          string `json:"status"`
+				Error           string `json:"error"`
+			}
+			code = call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/payments", nil), customer, nil)
+			assert.Equal(t, http.StatusForbidden, code)
+			code = call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/payments?status=REJECTED", nil), admin, &events)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, 1, len(events))
+			assert.Equal(t, unknown.Id, events[0].ProviderEventId)
+			assert.Equal(t, "payment does not name a loan", events[0].Error)
+
+			//every notification is audited, the signed ones as the payment provider
+			var entries []struct {
+				Outcome   string `json:"outcome"`
+				ActorType string `json:"actorType"`
+				Actor     string `json:"actor"`
+			}
+			call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/audit?action=payment.receive", nil), admin, &entries)
+			actors := make([]string, 0)
+			for _, entry := range entries {
+				actors = append(actors, entry.Outcome+" by "+entry.ActorType+" "+entry.Actor)
+			}
+			assert.Equal(t, []string{
+				"SUCCESS by PAYMENT_PROVIDER fakepay",
+				"SUCCESS by PAYMENT_PROVIDER fakepay",
+				"FAILURE by ANONYMOUS ",
+				"SUCCESS by PAYMENT_PROVIDER fakepay",
+				"SUCCESS by PAYMENT_PROVIDER fakepay",
+			}, actors)
+		})
+	}
+}
+
+func statementRequest(fileName string, content string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	file, _ := writer.CreateFormFile("file", fileName)
+	file.Write([]byte(content))
+	writer.Close()
+
+	request := httptest.NewRequest(http.MethodPost, "/v1/admin/reconciliation/statement", &body)
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	return request
+}
+
+func Test_Server_Reconciliation(t *testing.T) {
+	for _, driver := range []string{db.POSTGRES, db.SQLITE, db.MEMORY} {
+		t.Run(driver, func(t *testing.T) {
+			router := testServer(t, driver)
+			loanId := approvedLoan(t, router)
+			customer := login(t, router, "john", "John@12345")
+			admin := login(t, router, "admin", "Admin@1234")
+
+			type summary struct {
+				StatementId int64 `json:"statementId"`
+				Credits     int   `json:"credits"`
+				Skipped     int   `json:"skipped"`
+				Applied     int   `json:"applied"`
+				Exceptions  int   `json:"exceptions"`
+				Lines       []struct {
+					LineId    int64  `json:"lineId"`
+					Status    string `json:"status"`
+					MatchRule string `json:"matchRule"`
+				} `json:"lines"`
+			}
+			statement := "date,amount,reference,bank reference\n" +
+				"2024-05-02,1000,REPAYMENT " + payment.Reference(loanId) + ",B1\n" +
+				"2024-05-02,-15,fees,B2\n" +
+				"2024-05-03,400,rent,B3\n"
+
+			//only admins reconcile
+			code := call(t, router, statementRequest("may.csv", statement), customer, nil)
+			assert.Equal(t, http.StatusForbidden, code)
+
+			//the credit quoting the reference is applied, the other is an exception
+			var imported summary
+			code = call(t, router, statementRequest("may.csv", statement), admin, &imported)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, 2, imported.Credits)
+			assert.Equal(t, 1, imported.Applied)
+			assert.Equal(t, 1, imported.Exceptions)
+			code = call(t, router, statementRequest("may-again.csv", statement), admin, nil)
+			assert.Equal(t, http.StatusConflict, code)
+
+			var detail struct {
+				Status       string `json:"status"`
+				Installments []struct {
+					Status        string `json:"status"`
+					TransactionId string `json:"transactionId"`
+				} `json:"installments"`
+			}
+			installmentsPath := fmt.Sprintf("/v1/loan/installments?loanId=%d", loanId)
+			call(t, router, httptest.NewRequest(http.MethodGet, installmentsPath, nil), customer, &detail)
+			assert.Equal(t, "PAID", detail.Installments[0].Status)
+			assert.Equal(t, "B1", detail.Installments[0].TransactionId)
+
+			//a credit of the amount due next is only suggested for the one loan due for it, and a credit seen before is skipped
+			var second summary
+			code = call(t, router, statementRequest("june.csv", "date,amount,reference,bank reference\n2024-05-03,400,rent,B3\n2024-05-10,1000,transfer,B4\n"), admin, &second)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, 1, second.Skipped)
+			assert.Equal(t, 0, second.Applied)
+			assert.Equal(t, 1, second.Exceptions)
+			assert.Equal(t, "EXCEPTION", second.Lines[0].Status)
+			assert.Equal(t, "AMOUNT", second.Lines[0].MatchRule)
+
+			var exceptions []struct {
+				LineId int64  `json:"lineId"`
+				LoanId int64  `json:"loanId"`
+				Reason string `json:"reason"`
+			}
+			code = call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/reconciliation/lines", nil), admin, &exceptions)
+			assert.Equal(t, http.StatusOK, code)
+			assert.Equal(t, 2, len(exceptions))
+			assert.Equal(t, "no loan matches the reference or the amount", exceptions[0].Reason)
+			assert.Equal(t, loanId, exceptions[1].LoanId)
+
+			//the admin confirms the suggested loan
+			code = call(t, router, jsonRequest(http.MethodPost, "/v1/admin/reconciliation/resolve", map[string]interface{}{
+				"lineId": exceptions[1].LineId,
+				"action": "APPLY",
+			}), admin, nil)
+			assert.Equal(t, http.StatusOK, code)
+			call(t, router, httptest.NewRequest(http.MethodGet, installmentsPath, nil), customer, &detail)
+			assert.Equal(t, "PAID", detail.Installments[1].Status)
+			assert.Equal(t, "B4", detail.Installments[1].TransactionId)
+			exceptions = nil
+			call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/reconciliation/lines", nil), admin, &exceptions)
+			assert.Equal(t, 1, len(exceptions))
+
+			//an exception the loan rules refuse stays in the queue, and can be dismissed
+			resolve := func(action string) int {
+				return call(t, router, jsonRequest(http.MethodPost, "/v1/admin/reconciliation/resolve", map[string]interface{}{
+					"lineId": exceptions[0].LineId,
+					"action": action,
+					"loanId": loanId,
+					"note":   "not a repayment",
+				}), admin, nil)
+			}
+			assert.Equal(t, http.StatusBadRequest, resolve("APPLY"))
+			call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/reconciliation/lines", nil), admin, &exceptions)
+			assert.Equal(t, 1, len(exceptions))
+			assert.Equal(t, "amount payable is less than installment amount", exceptions[0].Reason)
+			assert.Equal(t, http.StatusOK, resolve("DISMISS"))
+			assert.Equal(t, http.StatusConflict, resolve("DISMISS"))
+			exceptions = nil
+			call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/reconciliation/lines", nil), admin, &exceptions)
+			assert.Equal(t, 0, len(exceptions))
+
+			var statements []struct {
+				StatementId int64            `json:"statementId"`
+				Lines       map[string]int64 `json:"lines"`
+			}
+			call(t, router, httptest.NewRequest(http.MethodGet, "/v1/admin/reconciliation/statements", nil), admin, &statements)
+			assert.Equal(t, 2, len(statements))
+			assert.Equal(t, map[string]int64{"APPLIED": 1, "DISMISSED": 1}, statements[1].Lines)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/db"
+	reconciliationdb "aspire-assignment/pkg/db/v1/reconciliation"
+	auditservice "aspire-assignment/pkg/service/v1/audit"
+	"aspire-assignment/pkg/service/v1/reconciliation"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// ImportStatement imports a bank statement file and reconciles its credits against the loans without starting the
+// server, the way the admin upload does. command is import and format is detected from the file when empty
+func ImportStatement(command string, path string, format string) error {
+	if command != "import" {
+		return fmt.Errorf("unknown statement command %s. use import", command)
+	}
+	if path == "" {
+		return fmt.Errorf("statement file is required")
+	}
+	if err := requireSqlDatabase("statement"); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Connect()
+	if err != nil {
+		log.Printf("Failed to connect database. Error:%s", err.Error())
+		return err
+	}
+	defer func() {
+		if sqlDb, _ := conn.DB(); sqlDb != nil {
+			sqlDb.Close()
+		}
+	}()
+
+	dbObj := db.NewDBObject(conn)
+	reconciler := reconciliation.NewReconciler(dbObj.GetV1DBLayer())
+	summary, err := reconciler.Import(context.Background(), filepath.Base(path), format, data, 0)
+	if err != nil {
+		return err
+	}
+	//the loans repaid by the import are kept in the audit entry, as no admin request records them
+	repaid := map[string]int64{}
+	for _, line := range summary.Lines {
+		log.Printf("line %d: %.2f %s loan %d %s", line.LineNum.Int64, line.Amount.Float64, line.Status.String, line.LoanId.Int64, line.Reason.String)
+		if line.Status.String == reconciliationdb.APPLIED {
+			repaid[fmt.Sprint(line.LineNum.Int64)] = line.LoanId.Int64
+		}
+	}
+
+	after, _ := json.Marshal(map[string]interface{}{
+		"fileName":   filepath.Base(path),
+		"format":     summary.Format,
+		"credits":    summary.Credits,
+		"skipped":    summary.Skipped,
+		"applied":    summary.Applied,
+		"exceptions": summary.Exceptions,
+		"repaid":     repaid,
+	})
+	err = auditservice.NewAuditService(dbObj.GetV1DBLayer()).RecordAudit(context.Background(), audit.Entry{
+		ActorType:  audit.ACTOR_SYSTEM,
+		Actor:      "statement import",
+		Action:     audit.STATEMENT_IMPORT,
+		Outcome:    audit.SUCCESS,
+		TargetType: audit.TARGET_STATEMENT,
+		TargetId:   fmt.Sprint(summary.StatementId),
+		After:      string(after),
+	})
+	if err != nil {
+		log.Printf("failed to write audit entry for %s. Error: %s", audit.STATEMENT_IMPORT, err.Error())
+	}
+	log.Printf("statement %d imported as %s: %d credits, %d applied, %d exceptions, %d imported before", summary.StatementId, summary.Format, summary.Credits, summary.Applied, summary.Exceptions, summary.Skipped)
+	return nil
+}
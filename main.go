@@ -3,6 +3,8 @@ package main
 import (
 	"aspire-assignment/api"
 	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/service/v1/usermanagement"
+	"flag"
 	"log"
 	"os"
 	"os/signal"
@@ -13,6 +15,26 @@ func main() {
 	var (
 		environment string
 	)
+	if len(os.Args) > 1 && os.Args[1] == "bootstrap" {
+		bootstrap(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		verifyAudit(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "payment" {
+		simulatePayment(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "statement" {
+		importStatement(os.Args[2:])
+		return
+	}
 	if len(os.Args) == 2 {
 		environment = os.Args[1] // developer custom file
 	} else {
@@ -28,6 +50,99 @@ func main() {
 	addShutdownHook()
 }
 
+// bootstrap creates the first admin. usage: aspire bootstrap -username admin -email admin@example.com -mobile 9999999999 [-env local]
+// the password is read from -password or the ASPIRE_ADMIN_PASSWORD environment variable
+func bootstrap(args []string) {
+	var request usermanagement.BootstrapAdminRequest
+	flags := flag.NewFlagSet("bootstrap", flag.ExitOnError)
+	environment := flags.String("env", "local", "config file name")
+	flags.StringVar(&request.UserName, "username", "", "admin username")
+	flags.StringVar(&request.Password, "password", os.Getenv("ASPIRE_ADMIN_PASSWORD"), "admin password")
+	flags.StringVar(&request.Email, "email", "", "admin email")
+	flags.StringVar(&request.Mobile, "mobile", "", "admin mobile")
+	flags.Parse(args)
+
+	config.Load(*environment)
+
+	if err := api.Bootstrap(request); err != nil {
+		log.Fatal("Failed to bootstrap admin, err:", err)
+	}
+}
+
+// migrate runs the schema migrations. usage: aspire migrate up|down|status|baseline [-env local] [-steps 1] [-version 1]
+// down reverts the last -steps migrations and baseline records the migrations up to -version as applied
+func migrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: aspire migrate up|down|status|baseline [-env local] [-steps 1] [-version 1]")
+	}
+	flags := flag.NewFlagSet("migrate", flag.ExitOnError)
+	environment := flags.String("env", "local", "config file name")
+	steps := flags.Int("steps", 1, "migrations to revert with down")
+	version := flags.Int64("version", 1, "last migration the existing schema matches, with baseline")
+	flags.Parse(args[1:])
+
+	config.Load(*environment)
+
+	if err := api.Migrate(args[0], *steps, *version); err != nil {
+		log.Fatal("Failed to migrate, err:", err)
+	}
+}
+
+// verifyAudit checks that no audit log entry was changed, removed or inserted. usage: aspire audit verify [-env local]
+func verifyAudit(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: aspire audit verify [-env local]")
+	}
+	flags := flag.NewFlagSet("audit", flag.ExitOnError)
+	environment := flags.String("env", "local", "config file name")
+	flags.Parse(args[1:])
+
+	config.Load(*environment)
+
+	if err := api.VerifyAudit(args[0]); err != nil {
+		log.Fatal("Failed to verify audit log, err:", err)
+	}
+}
+
+// simulatePayment notifies a running server of a payment the way the payment provider does.
+// usage: aspire payment simulate -loan 3 -amount 1000 [-url http://localhost:8001/webhooks/payments] [-env local]
+func simulatePayment(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: aspire payment simulate -loan 3 -amount 1000 [-url http://localhost:8001/webhooks/payments] [-env local]")
+	}
+	flags := flag.NewFlagSet("payment", flag.ExitOnError)
+	environment := flags.String("env", "local", "config file name")
+	loanId := flags.Int64("loan", 0, "loan to pay")
+	amount := flags.Float64("amount", 0, "amount paid")
+	url := flags.String("url", "", "payment webhook of the server, on the configured port of localhost by default")
+	flags.Parse(args[1:])
+
+	config.Load(*environment)
+
+	if err := api.SimulatePayment(args[0], *loanId, *amount, *url); err != nil {
+		log.Fatal("Failed to simulate payment, err:", err)
+	}
+}
+
+// importStatement imports a bank statement and applies the credits it matches to loans, leaving the others as
+// exceptions for the admins. usage: aspire statement import -file statement.csv [-format CSV|CAMT053|MT940] [-env local]
+func importStatement(args []string) {
+	if len(args) == 0 {
+		log.Fatal("usage: aspire statement import -file statement.csv [-format CSV|CAMT053|MT940] [-env local]")
+	}
+	flags := flag.NewFlagSet("statement", flag.ExitOnError)
+	environment := flags.String("env", "local", "config file name")
+	file := flags.String("file", "", "statement file to import")
+	format := flags.String("format", "", "format of the statement, detected from the file by default")
+	flags.Parse(args[1:])
+
+	config.Load(*environment)
+
+	if err := api.ImportStatement(args[0], *file, *format); err != nil {
+		log.Fatal("Failed to import statement, err:", err)
+	}
+}
+
 func addShutdownHook() {
 	// when receive interruption from system shutdown server and scheduler
 	quit := make(chan os.Signal, 1)
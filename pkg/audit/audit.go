@@ -0,0 +1,221 @@
+package audit
+
+import (
+	"aspire-assignment/pkg/config"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// actions written to the audit log. a route declares its action with Record
+const (
+	USER_SIGNUP            = "user.signup"
+	USER_LOGIN             = "user.login"
+	USER_LOGIN_2FA         = "user.login.2fa"
+	USER_UNLOCK            = "user.unlock"
+	PASSWORD_CHANGE        = "password.change"
+	PASSWORD_RESET         = "password.reset"
+	TOTP_ENROLL            = "totp.enroll"
+	TOTP_ACTIVATE          = "totp.activate"
+	ADMIN_INVITE           = "admin.invite"
+	ADMIN_SIGNUP           = "admin.signup"
+	ADMIN_BOOTSTRAP        = "admin.bootstrap"
+	ROLE_ASSIGN            = "role.assign"
+	SERVICE_ACCOUNT_CREATE = "service_account.create"
+	API_KEY_CREATE         = "api_key.create"
+	API_KEY_REVOKE         = "api_key.revoke"
+	DOCUMENT_VERIFY        = "document.verify"
+	LOAN_APPLY             = "loan.apply"
+	LOAN_MODIFY            = "loan.modify"
+	LOAN_CANCEL            = "loan.cancel"
+	LOAN_DECISION          = "loan.decision"
+	LOAN_REPAY             = "loan.repay"
+	WEBHOOK_CREATE         = "webhook.create"
+	WEBHOOK_UPDATE         = "webhook.update"
+	WEBHOOK_DELETE         = "webhook.delete"
+	WEBHOOK_REDELIVER      = "webhook.redeliver"
+	PAYMENT_RECEIVE        = "payment.receive"
+	STATEMENT_IMPORT       = "statement.import"
+	STATEMENT_RESOLVE      = "statement.resolve"
+	AUTODEBIT_ENABLE       = "autodebit.enable"
+	AUTODEBIT_DISABLE      = "autodebit.disable"
+	AUTODEBIT_DEBIT        = "autodebit.debit"
+	AUTODEBIT_REFUND       = "autodebit.refund"
+)
+
+// outcome of an audited request, from its HTTP status
+const (
+	SUCCESS = "SUCCESS"
+	FAILURE = "FAILURE"
+)
+
+// actor types besides the user types. an anonymous actor made a request without credentials, like a failed login,
+// and the payment provider authenticates its notifications with a signature
+const (
+	ACTOR_ANONYMOUS        = "ANONYMOUS"
+	ACTOR_SERVICE_ACCOUNT  = "SERVICE_ACCOUNT"
+	ACTOR_SYSTEM           = "SYSTEM"
+	ACTOR_PAYMENT_PROVIDER = "PAYMENT_PROVIDER"
+)
+
+// targets of the audited actions. a username is the target while the user is not known by id
+const (
+	TARGET_USER            = "user"
+	TARGET_USERNAME        = "username"
+	TARGET_IP              = "ip"
+	TARGET_INVITE          = "admin_invite"
+	TARGET_SERVICE_ACCOUNT = "service_account"
+	TARGET_API_KEY         = "api_key"
+	TARGET_DOCUMENT        = "document"
+	TARGET_LOAN            = "loan"
+	TARGET_WEBHOOK         = "webhook"
+	TARGET_DELIVERY        = "webhook_delivery"
+	TARGET_STATEMENT       = "bank_statement"
+	TARGET_STATEMENT_LINE  = "statement_line"
+	TARGET_AUTODEBIT       = "auto_debit"
+)
+
+// keys of the audit details a handler adds to the request
+const (
+	targetKey   = "auditTarget"
+	beforeKey   = "auditBefore"
+	afterKey    = "auditAfter"
+	identityKey = "auditIdentity"
+)
+
+// Entry is an action to append to the audit log. Before and After are JSON snapshots of the target
+type Entry struct {
+	ActorType  string
+	ActorId    int64
+	Actor      string
+	Roles      []string
+	Action     string
+	Outcome    string
+	TargetType string
+	TargetId   string
+	Before     string
+	After      string
+	RequestId  string
+	Ip         string
+}
+
+// Recorder appends entries to the audit log
+type Recorder interface {
+	RecordAudit(context.Context, Entry) error
+}
+
+type target struct {
+	Type string
+	Id   string
+}
+
+type identity struct {
+	UserType string
+	UserId   int64
+	UserName string
+}
+
+// RequestId tags every request with the X-Request-ID of the caller, or a new one, and echoes it in the response
+func RequestId() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(config.REQUESTHEADER)
+		if requestId == "" || len(requestId) > 64 {
+			requestId = newRequestId()
+		}
+		c.Set(config.REQUESTID, requestId)
+		c.Header(config.REQUESTHEADER, requestId)
+		c.Next()
+	}
+}
+
+// Record writes the action to the audit log once the handler is done, successful or not. it runs before
+// Authorize so refused requests are kept too. a failed write is logged and does not change the response
+func Record(recorder Recorder, action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if err := recorder.RecordAudit(c, NewEntry(c, action)); err != nil {
+			log.Printf("failed to write audit entry for %s. Error: %s", action, err.Error())
+		}
+	}
+}
+
+// Target names what the action of the request is applied to
+func Target(c *gin.Context, targetType string, targetId interface{}) {
+	c.Set(targetKey, target{Type: targetType, Id: fmt.Sprint(targetId)})
+}
+
+// Change keeps snapshots of the target before and after the action. either can be nil, and they must not hold secrets
+func Change(c *gin.Context, before interface{}, after interface{}) {
+	if before != nil {
+		c.Set(beforeKey, snapshot(before))
+	}
+	if after != nil {
+		c.Set(afterKey, snapshot(after))
+	}
+}
+
+// Identify names the user of a request which authenticates on its own, like a login
+func Identify(c *gin.Context, userType string, userId int64, userName string) {
+	c.Set(identityKey, identity{UserType: userType, UserId: userId, UserName: userName})
+}
+
+// NewEntry builds the entry of the action from the request: who made it, from where, and what the handler recorded
+func NewEntry(c *gin.Context, action string) Entry {
+	entry := Entry{
+		ActorType: ACTOR_ANONYMOUS,
+		Action:    action,
+		Outcome:   SUCCESS,
+		RequestId: c.GetString(config.REQUESTID),
+		Ip:        c.ClientIP(),
+		Before:    c.GetString(beforeKey),
+		After:     c.GetString(afterKey),
+	}
+	if c.Writer.Status() >= http.StatusBadRequest {
+		entry.Outcome = FAILURE
+	}
+	if value, ok := c.Get(targetKey); ok {
+		entry.TargetType = value.(target).Type
+		entry.TargetId = value.(target).Id
+	}
+
+	switch value, identified := c.Get(identityKey); {
+	case c.GetInt64(config.APIKEYID) != 0:
+		entry.ActorType = ACTOR_SERVICE_ACCOUNT
+		entry.ActorId = c.GetInt64(config.APIKEYID)
+		entry.Actor = fmt.Sprintf("%s (%s)", c.GetString(config.SERVICEACCT), c.GetString(config.APIKEY))
+		entry.Roles = c.GetStringSlice(config.SCOPES)
+	case c.GetInt64(config.USERID) != 0:
+		entry.ActorType = c.GetString(config.USERTYPE)
+		entry.ActorId = c.GetInt64(config.USERID)
+		entry.Actor = c.GetString(config.USERNAME)
+		entry.Roles = c.GetStringSlice(config.ROLES)
+	case identified:
+		entry.ActorType = value.(identity).UserType
+		entry.ActorId = value.(identity).UserId
+		entry.Actor = value.(identity).UserName
+	}
+	return entry
+}
+
+func snapshot(state interface{}) string {
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("failed to snapshot audit state. Error: %s", err.Error())
+		return ""
+	}
+	return string(data)
+}
+
+func newRequestId() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		log.Printf("failed to generate request id. Error: %s", err.Error())
+	}
+	return hex.EncodeToString(id)
+}
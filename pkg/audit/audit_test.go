@@ -0,0 +1,156 @@
+package audit
+
+import (
+	"aspire-assignment/pkg/config"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+// captureRecorder keeps the entries it is asked to record
+type captureRecorder struct {
+	entries []Entry
+	err     error
+}
+
+func (r *captureRecorder) RecordAudit(ctx context.Context, entry Entry) error {
+	r.entries = append(r.entries, entry)
+	return r.err
+}
+
+func Test_RequestId(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestId())
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(config.REQUESTID))
+	})
+
+	//the id of the caller is kept
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(config.REQUESTHEADER, "req-1")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	assert.Equal(t, "req-1", recorder.Body.String())
+	assert.Equal(t, "req-1", recorder.Header().Get(config.REQUESTHEADER))
+
+	//requests without one get a new id
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, 32, len(recorder.Body.String()))
+	assert.Equal(t, recorder.Body.String(), recorder.Header().Get(config.REQUESTHEADER))
+}
+
+func Test_Record(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		setup    func(c *gin.Context)
+		status   int
+		expected Entry
+	}{
+		{
+			name: "User",
+			setup: func(c *gin.Context) {
+				c.Set(config.USERID, int64(7))
+				c.Set(config.USERNAME, "admin")
+				c.Set(config.USERTYPE, config.ADMIN)
+				c.Set(config.ROLES, []string{"ADMIN"})
+				Target(c, TARGET_LOAN, int64(3))
+				Change(c, map[string]string{"status": "PENDING"}, map[string]string{"status": "APPROVED"})
+			},
+			status: http.StatusOK,
+			expected: Entry{
+				ActorType:  config.ADMIN,
+				ActorId:    7,
+				Actor:      "admin",
+				Roles:      []string{"ADMIN"},
+				Action:     LOAN_DECISION,
+				Outcome:    SUCCESS,
+				TargetType: TARGET_LOAN,
+				TargetId:   "3",
+				Before:     `{"status":"PENDING"}`,
+				After:      `{"status":"APPROVED"}`,
+			},
+		},
+		{
+			name: "ServiceAccount",
+			setup: func(c *gin.Context) {
+				c.Set(config.APIKEYID, int64(4))
+				c.Set(config.APIKEY, "ak_0a1b2c3d")
+				c.Set(config.SERVICEACCT, "collections")
+				c.Set(config.SCOPES, []string{"payment:create:any"})
+			},
+			status: http.StatusNotAcceptable,
+			expected: Entry{
+				ActorType: ACTOR_SERVICE_ACCOUNT,
+				ActorId:   4,
+				Actor:     "collections (ak_0a1b2c3d)",
+				Roles:     []string{"payment:create:any"},
+				Action:    LOAN_DECISION,
+				Outcome:   FAILURE,
+			},
+		},
+		{
+			name: "IdentifiedLogin",
+			setup: func(c *gin.Context) {
+				Target(c, TARGET_USERNAME, "john")
+				Identify(c, config.CUSTOMER, 2, "john")
+			},
+			status: http.StatusOK,
+			expected: Entry{
+				ActorType:  config.CUSTOMER,
+				ActorId:    2,
+				Actor:      "john",
+				Action:     LOAN_DECISION,
+				Outcome:    SUCCESS,
+				TargetType: TARGET_USERNAME,
+				TargetId:   "john",
+			},
+		},
+		{
+			name: "Anonymous",
+			setup: func(c *gin.Context) {
+				Target(c, TARGET_USERNAME, "john")
+			},
+			status: http.StatusUnauthorized,
+			expected: Entry{
+				ActorType:  ACTOR_ANONYMOUS,
+				Action:     LOAN_DECISION,
+				Outcome:    FAILURE,
+				TargetType: TARGET_USERNAME,
+				TargetId:   "john",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &captureRecorder{err: errors.New("db down")}
+			router := gin.New()
+			router.Use(RequestId())
+			router.POST("/", Record(recorder, LOAN_DECISION), func(c *gin.Context) {
+				tt.setup(c)
+				c.Status(tt.status)
+			})
+
+			request := httptest.NewRequest(http.MethodPost, "/", nil)
+			request.Header.Set(config.REQUESTHEADER, "req-1")
+			request.RemoteAddr = "10.0.0.1:1234"
+			response := httptest.NewRecorder()
+			router.ServeHTTP(response, request)
+
+			//a failed audit write does not change the response
+			assert.Equal(t, tt.status, response.Code)
+			assert.Equal(t, 1, len(recorder.entries))
+			tt.expected.RequestId = "req-1"
+			tt.expected.Ip = "10.0.0.1"
+			assert.Equal(t, tt.expected, recorder.entries[0])
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"aspire-assignment/pkg/config"
+	e "aspire-assignment/pkg/errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKey is the service account an API key authenticated as
+type APIKey struct {
+	KeyId            int64
+	Prefix           string
+	ServiceAccountId int64
+	ServiceAccount   string
+	Scopes           []string
+}
+
+// APIKeyAuthenticator resolves an API key. unknown, expired and revoked keys are reported as not valid
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(*gin.Context, string) (APIKey, bool, error)
+}
+
+// Authenticator accepts both user sessions and service account API keys
+type Authenticator interface {
+	SessionValidator
+	APIKeyAuthenticator
+}
+
+// IsServiceAccount tells whether the request was authenticated with an API key instead of a user token
+func IsServiceAccount(c *gin.Context) bool {
+	return c.GetInt64(config.APIKEYID) != 0
+}
+
+// Actor names who performed the request for logs: the user id, or the API key and its service account
+func Actor(c *gin.Context) string {
+	if IsServiceAccount(c) {
+		return fmt.Sprintf("ApiKey: %s (ServiceAccount: %s)", c.GetString(config.APIKEY), c.GetString(config.SERVICEACCT))
+	}
+	return fmt.Sprintf("UserId: %d", c.GetInt64(config.USERID))
+}
+
+// authenticateAPIKey serves the request as the service account of the key and logs it against the key
+func authenticateAPIKey(c *gin.Context, authenticator APIKeyAuthenticator, key string) {
+	var (
+		response AuthResponse
+	)
+	apiKey, valid, err := authenticator.AuthenticateAPIKey(c, key)
+	if err != nil {
+		log.Printf("failed to validate api key. Error: %s", err.Error())
+		response.Status = false
+		response.Message = "failed to validate api key"
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		c.JSON(http.StatusInternalServerError, response)
+		c.Abort()
+		return
+	}
+	if !valid {
+		log.Println("invalid, expired or revoked api key")
+		response.Status = false
+		response.Message = "Authentication failed"
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid api key"))
+		c.JSON(http.StatusUnauthorized, response)
+		c.Abort()
+		return
+	}
+
+	c.Set(config.APIKEYID, apiKey.KeyId)
+	c.Set(config.APIKEY, apiKey.Prefix)
+	c.Set(config.SERVICEACCT, apiKey.ServiceAccount)
+	c.Set(config.SCOPES, apiKey.Scopes)
+
+	c.Next()
+
+	log.Printf("%s %s %s -> %d", Actor(c), c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+}
@@ -0,0 +1,95 @@
+package auth
+
+import (
+	e "aspire-assignment/pkg/errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+)
+
+// keyAuthenticator knows a single API key and fails every JWT
+type keyAuthenticator struct {
+	key    string
+	apiKey APIKey
+}
+
+func (a *keyAuthenticator) IsSessionValid(c *gin.Context, token Token) (bool, error) {
+	return false, nil
+}
+
+func (a *keyAuthenticator) AuthenticateAPIKey(c *gin.Context, key string) (APIKey, bool, error) {
+	return a.apiKey, key == a.key, nil
+}
+
+// denyAll grants no permission to any role
+type denyAll struct{}
+
+func (denyAll) HasPermission(c *gin.Context, roles []string, permission string) (bool, error) {
+	return false, nil
+}
+
+func Test_AuthMiddlewareAPIKey(t *testing.T) {
+	e.ErrorInit()
+	gin.SetMode(gin.TestMode)
+
+	authenticator := &keyAuthenticator{
+		key: "ak_0a1b2c3d_secret",
+		apiKey: APIKey{
+			KeyId:          1,
+			Prefix:         "0a1b2c3d",
+			ServiceAccount: "collections-batch",
+			Scopes:         []string{PAYMENT_CREATE_ANY},
+		},
+	}
+	var (
+		actor     string
+		collector bool
+	)
+	router := gin.New()
+	router.Use(AuthMiddleware(authenticator))
+	router.POST("/repay", Authorize(denyAll{}, PAYMENT_CREATE_ANY, PAYMENT_CREATE_OWN), func(c *gin.Context) {
+		actor = Actor(c)
+		collector = Granted(c, PAYMENT_CREATE_ANY)
+		c.Status(http.StatusOK)
+	})
+	router.GET("/loans", Authorize(denyAll{}, LOAN_APPROVE), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		key        string
+		httpStatus int
+	}{
+		{name: "ScopedKey", method: http.MethodPost, path: "/repay", key: "ak_0a1b2c3d_secret", httpStatus: http.StatusOK},
+		{name: "KeyWithoutScope", method: http.MethodGet, path: "/loans", key: "ak_0a1b2c3d_secret", httpStatus: http.StatusForbidden},
+		{name: "UnknownKey", method: http.MethodPost, path: "/repay", key: "ak_0a1b2c3d_wrong", httpStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-API-Key", tt.key)
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.httpStatus, w.Code)
+		})
+	}
+	assert.Equal(t, "ApiKey: 0a1b2c3d (ServiceAccount: collections-batch)", actor)
+	assert.Equal(t, true, collector)
+
+	//routes acting on the logged in user refuse API keys
+	userRouter := gin.New()
+	userRouter.POST("/logout", UserAuthMiddleware(authenticator), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodPost, "/logout", nil)
+	req.Header.Set("X-API-Key", "ak_0a1b2c3d_secret")
+	userRouter.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -3,8 +3,11 @@ package auth
 import (
 	"aspire-assignment/pkg/config"
 	e "aspire-assignment/pkg/errors"
+	"crypto/sha256"
+	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"net/http"
 
@@ -13,12 +16,58 @@ import (
 )
 
 var (
-	jwtKey []byte
+	jwtKey          []byte
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
+)
+
+const (
+	DEFAULT_ACCESS_TOKEN_TTL  = 60 * time.Minute
+	DEFAULT_REFRESH_TOKEN_TTL = 30 * 24 * time.Hour
 )
 
 func InitAuth() {
 	confi := config.GetConfig()
 	jwtKey = []byte(confi.GetString("auth.key"))
+
+	accessTokenTTL = confi.GetDuration("auth.access_token_ttl")
+	if accessTokenTTL <= 0 {
+		accessTokenTTL = DEFAULT_ACCESS_TOKEN_TTL
+	}
+	refreshTokenTTL = confi.GetDuration("auth.refresh_token_ttl")
+	if refreshTokenTTL <= 0 {
+		refreshTokenTTL = DEFAULT_REFRESH_TOKEN_TTL
+	}
+
+	//totp secrets and private signing keys are encrypted with a key of their own. the jwt key is used when none is configured
+	if secretKey := confi.GetString("auth.totp.secret_key"); secretKey != "" {
+		sum := sha256.Sum256([]byte(secretKey))
+		totpKey = sum[:]
+	}
+
+	//asymmetric keys are stored through a KeyStore and loaded by InitKeys once the db is up
+	if algorithm := confi.GetString("auth.signing.algorithm"); algorithm != "" {
+		signingAlgorithm = algorithm
+	}
+	keyRotationInterval = confi.GetDuration("auth.signing.rotation_interval")
+	keyRefreshInterval = confi.GetDuration("auth.signing.refresh_interval")
+	acceptLegacyTokens = confi.GetBool("auth.signing.accept_hs256")
+}
+
+// AccessTokenTTL is the lifetime of the JWTs issued on login and refresh
+func AccessTokenTTL() time.Duration {
+	if accessTokenTTL <= 0 {
+		return DEFAULT_ACCESS_TOKEN_TTL
+	}
+	return accessTokenTTL
+}
+
+// RefreshTokenTTL is the lifetime of a refresh token. rotation issues a new token with a fresh lifetime
+func RefreshTokenTTL() time.Duration {
+	if refreshTokenTTL <= 0 {
+		return DEFAULT_REFRESH_TOKEN_TTL
+	}
+	return refreshTokenTTL
 }
 
 func GenerateJWT(payload Token) (string, error) {
@@ -26,93 +75,151 @@ func GenerateJWT(payload Token) (string, error) {
 	claims := &Claims{
 		Payload: payload,
 		StandardClaims: jwt.StandardClaims{
+			Id:        payload.TokenId,
+			IssuedAt:  time.Now().Unix(),
 			ExpiresAt: expirationTime.Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(jwtKey)
-	if err != nil {
-		return "", err
+	key := keys.signingKey()
+	if signingAlgorithm == ALG_HS256 || key == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString(jwtKey)
 	}
 
-	return tokenString, nil
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.keyId
+	return token.SignedString(key.privateKey)
 }
 
-func AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		var (
-			response AuthResponse
-		)
-		tokenString := c.Request.Header.Get("Authorization")
-
-		if tokenString == "" || !strings.Contains(tokenString, "Bearer") {
-			log.Println("auth token incorrect")
-			response.Status = false
-			response.Message = "invalid jwt token format"
-			response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid auth token"))
-			c.JSON(http.StatusUnauthorized, response)
-			c.Abort()
-			return
+// parseJWT verifies the token with the key named by its kid. tokens without a kid are the HS256 tokens signed with auth.key,
+// accepted while HS256 is in use or while switching over with auth.signing.accept_hs256
+func parseJWT(tokenString string, claims *Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		keyId, _ := token.Header["kid"].(string)
+		if keyId == "" {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok || len(jwtKey) == 0 || !acceptHS256() {
+				return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+			}
+			return jwtKey, nil
 		}
 
-		tokenString = strings.TrimPrefix(tokenString, "Bearer ")
+		key, ok := keys.verificationKey(keyId)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %s", keyId)
+		}
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+		}
+		return key.publicKey, nil
+	})
+}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
-		})
+// SessionValidator checks a parsed token against server side state so tokens can be invalidated before expiry
+type SessionValidator interface {
+	IsSessionValid(*gin.Context, Token) (bool, error)
+}
 
-		if claims.Payload.UserId == 0 {
-			log.Println("claims are unavailable from the token")
-			response.Status = false
-			response.Message = "Authentication failed"
-			response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid auth token"))
-			c.JSON(http.StatusForbidden, response)
-			c.Abort()
+// AuthMiddleware authenticates a bearer JWT of a user or an API key of a service account sent in X-API-Key
+func AuthMiddleware(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key := c.Request.Header.Get(config.APIKEYHEADER); key != "" {
+			authenticateAPIKey(c, authenticator, key)
 			return
 		}
+		authenticateSession(c, authenticator)
+	}
+}
 
-		if err != nil {
-			if err == jwt.ErrSignatureInvalid {
-				response.Status = false
-				response.Message = "invalid auth token signature"
-				response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid auth token signature"))
-				c.JSON(http.StatusUnauthorized, response)
-				c.Abort()
-				return
-			}
-			response.Status = false
-			response.Message = "invalid jwt token"
-			response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid jwt token"))
-			c.JSON(http.StatusUnauthorized, response)
-			c.Abort()
-			return
-		}
+// UserAuthMiddleware authenticates only bearer JWTs, for routes acting on the logged in user
+func UserAuthMiddleware(validator SessionValidator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authenticateSession(c, validator)
+	}
+}
 
-		if !token.Valid {
-			response.Status = false
-			response.Message = "expired jwt token"
-			response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("expired token"))
-			c.JSON(http.StatusUnauthorized, response)
-			c.Abort()
-			return
-		}
+func authenticateSession(c *gin.Context, validator SessionValidator) {
+	var (
+		response AuthResponse
+	)
+	tokenString := c.Request.Header.Get("Authorization")
+
+	if tokenString == "" || !strings.Contains(tokenString, "Bearer") {
+		log.Println("auth token incorrect")
+		response.Status = false
+		response.Message = "invalid jwt token format"
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid auth token"))
+		c.JSON(http.StatusUnauthorized, response)
+		c.Abort()
+		return
+	}
+
+	tokenString = strings.TrimPrefix(tokenString, "Bearer ")
 
-		c.Set(config.USERID, claims.Payload.UserId)
-		c.Set(config.USERNAME, claims.Payload.UserName)
-		c.Set(config.USERTYPE, claims.Payload.UserType)
+	claims := &Claims{}
+	token, err := parseJWT(tokenString, claims)
 
-		//block all non admin/ path calls for ADMIN and admin/ path calls for CUSTOMER
-		if (claims.Payload.UserType == config.ADMIN && !strings.Contains(c.FullPath(), "/admin/")) ||
-			(claims.Payload.UserType == config.CUSTOMER && strings.Contains(c.FullPath(), "/admin/")) {
+	if claims.Payload.UserId == 0 {
+		log.Println("claims are unavailable from the token")
+		response.Status = false
+		response.Message = "Authentication failed"
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid auth token"))
+		c.JSON(http.StatusForbidden, response)
+		c.Abort()
+		return
+	}
+
+	if err != nil {
+		if err == jwt.ErrSignatureInvalid {
 			response.Status = false
-			response.Message = "access not allowed"
-			response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("access not allowed"))
+			response.Message = "invalid auth token signature"
+			response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid auth token signature"))
 			c.JSON(http.StatusUnauthorized, response)
 			c.Abort()
 			return
 		}
-		c.Next()
+		response.Status = false
+		response.Message = "invalid jwt token"
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid jwt token"))
+		c.JSON(http.StatusUnauthorized, response)
+		c.Abort()
+		return
 	}
+
+	if !token.Valid {
+		response.Status = false
+		response.Message = "expired jwt token"
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("expired token"))
+		c.JSON(http.StatusUnauthorized, response)
+		c.Abort()
+		return
+	}
+
+	valid, err := validator.IsSessionValid(c, claims.Payload)
+	if err != nil {
+		log.Printf("failed to validate session. Error: %s", err.Error())
+		response.Status = false
+		response.Message = "failed to validate session"
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		c.JSON(http.StatusInternalServerError, response)
+		c.Abort()
+		return
+	}
+	if !valid {
+		response.Status = false
+		response.Message = "session expired"
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("token invalidated, login again"))
+		c.JSON(http.StatusUnauthorized, response)
+		c.Abort()
+		return
+	}
+
+	c.Set(config.USERID, claims.Payload.UserId)
+	c.Set(config.USERNAME, claims.Payload.UserName)
+	c.Set(config.USERTYPE, claims.Payload.UserType)
+	c.Set(config.TOKENID, claims.Payload.TokenId)
+	c.Set(config.TOKENEXPIRY, claims.Payload.Exp)
+	c.Set(config.ROLES, claims.Payload.Roles)
+
+	c.Next()
 }
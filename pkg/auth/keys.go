@@ -0,0 +1,347 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// supported signing algorithms. HS256 signs with auth.key and publishes no keys
+const (
+	ALG_HS256 = "HS256"
+	ALG_RS256 = "RS256"
+	ALG_EDDSA = "EdDSA"
+)
+
+const (
+	DEFAULT_KEY_ROTATION_INTERVAL = 30 * 24 * time.Hour
+	DEFAULT_KEY_REFRESH_INTERVAL  = time.Minute
+	RSA_KEY_BITS                  = 2048
+	KEY_ID_BYTES                  = 8
+)
+
+var (
+	signingAlgorithm    = ALG_HS256
+	keyRotationInterval time.Duration
+	keyRefreshInterval  time.Duration
+	acceptLegacyTokens  bool
+	keys                keyring
+)
+
+// SigningKey is a stored signing key. the private key is PKCS8 DER sealed by EncryptSecret
+type SigningKey struct {
+	KeyId      string
+	Algorithm  string
+	PrivateKey string
+	CreatedAt  time.Time
+}
+
+// KeyStore keeps the signing keys in one place so every instance signs and verifies with the same set
+type KeyStore interface {
+	GetSigningKeys(context.Context) ([]SigningKey, error)
+	// AddSigningKey stores the key unless a key of the same algorithm was created after the given time
+	AddSigningKey(context.Context, SigningKey, time.Time) (bool, error)
+}
+
+type signingKey struct {
+	keyId      string
+	method     jwt.SigningMethod
+	privateKey interface{}
+	publicKey  interface{}
+	createdAt  time.Time
+}
+
+// keyring holds the key used for signing and every key a live token may still be signed with
+type keyring struct {
+	sync.RWMutex
+	active *signingKey
+	byId   map[string]*signingKey
+}
+
+// SigningAlgorithm is the algorithm new access tokens are signed with
+func SigningAlgorithm() string {
+	return signingAlgorithm
+}
+
+// InitKeys loads the signing keys from the store and creates the first key when there is none
+func InitKeys(ctx context.Context, store KeyStore) error {
+	if signingAlgorithm == ALG_HS256 {
+		return nil
+	}
+	return RotateKeys(ctx, store, time.Now())
+}
+
+// StartKeyRotation reloads the keys every refresh interval so keys created by other instances are picked up,
+// and rotates the signing key once it is older than the rotation interval. the returned func stops it
+func StartKeyRotation(store KeyStore) context.CancelFunc {
+	ctx, cancel := context.WithCancel(context.Background())
+	if signingAlgorithm == ALG_HS256 {
+		return cancel
+	}
+
+	go func() {
+		ticker := time.NewTicker(KeyRefreshInterval())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := RotateKeys(ctx, store, time.Now()); err != nil {
+					log.Printf("failed to rotate signing keys. Error: %s", err.Error())
+				}
+			}
+		}
+	}()
+	return cancel
+}
+
+// RotateKeys adds a new key when the newest key of the configured algorithm is due for rotation and reloads the keyring
+func RotateKeys(ctx context.Context, store KeyStore, now time.Time) error {
+	stored, err := store.GetSigningKeys(ctx)
+	if err != nil {
+		return err
+	}
+
+	rotateBefore := now.Add(-KeyRotationInterval())
+	due := true
+	for _, key := range stored {
+		if key.Algorithm == signingAlgorithm && key.CreatedAt.After(rotateBefore) {
+			due = false
+			break
+		}
+	}
+
+	if due {
+		key, err := generateSigningKey(signingAlgorithm, now)
+		if err != nil {
+			return err
+		}
+		added, err := store.AddSigningKey(ctx, key, rotateBefore)
+		if err != nil {
+			return err
+		}
+		if added {
+			log.Printf("added %s signing key %s", key.Algorithm, key.KeyId)
+		}
+
+		//another instance may have rotated at the same time, so read back what was stored
+		stored, err = store.GetSigningKeys(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	return keys.load(stored, now)
+}
+
+// load keeps the keys a live token can be signed with. a key is retired once its successor was created
+// longer than a token lifetime ago. a new key signs only after every instance had a refresh interval to load it
+func (ring *keyring) load(stored []SigningKey, now time.Time) error {
+	sort.Slice(stored, func(i, j int) bool {
+		return stored[i].CreatedAt.After(stored[j].CreatedAt)
+	})
+
+	var (
+		active    *signingKey
+		byId      = make(map[string]*signingKey)
+		successor time.Time
+	)
+	for _, entry := range stored {
+		if !successor.IsZero() && now.Sub(successor) > AccessTokenTTL()+KeyRefreshInterval() {
+			break
+		}
+
+		key, err := parseSigningKey(entry)
+		if err != nil {
+			log.Printf("skipping signing key %s. Error: %s", entry.KeyId, err.Error())
+			continue
+		}
+		byId[key.keyId] = key
+		if entry.Algorithm == signingAlgorithm && (active == nil || now.Sub(active.createdAt) < KeyRefreshInterval()) {
+			active = key
+		}
+		successor = entry.CreatedAt
+	}
+	if active == nil {
+		return fmt.Errorf("no %s signing key available", signingAlgorithm)
+	}
+
+	ring.Lock()
+	defer ring.Unlock()
+	ring.active = active
+	ring.byId = byId
+	return nil
+}
+
+func (ring *keyring) signingKey() *signingKey {
+	ring.RLock()
+	defer ring.RUnlock()
+	return ring.active
+}
+
+func (ring *keyring) verificationKey(keyId string) (*signingKey, bool) {
+	ring.RLock()
+	defer ring.RUnlock()
+	key, ok := ring.byId[keyId]
+	return key, ok
+}
+
+func (ring *keyring) verificationKeys() []*signingKey {
+	ring.RLock()
+	defer ring.RUnlock()
+	list := make([]*signingKey, 0, len(ring.byId))
+	for _, key := range ring.byId {
+		list = append(list, key)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].createdAt.After(list[j].createdAt)
+	})
+	return list
+}
+
+func acceptHS256() bool {
+	return signingAlgorithm == ALG_HS256 || acceptLegacyTokens
+}
+
+// KeyRotationInterval is how long a key signs new tokens before it is replaced
+func KeyRotationInterval() time.Duration {
+	if keyRotationInterval <= 0 {
+		return DEFAULT_KEY_ROTATION_INTERVAL
+	}
+	return keyRotationInterval
+}
+
+// KeyRefreshInterval is how often the keyring is reloaded from the store
+func KeyRefreshInterval() time.Duration {
+	if keyRefreshInterval <= 0 {
+		return DEFAULT_KEY_REFRESH_INTERVAL
+	}
+	return keyRefreshInterval
+}
+
+func generateSigningKey(algorithm string, now time.Time) (SigningKey, error) {
+	var privateKey interface{}
+	switch algorithm {
+	case ALG_RS256:
+		key, err := rsa.GenerateKey(rand.Reader, RSA_KEY_BITS)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		privateKey = key
+	case ALG_EDDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return SigningKey{}, err
+		}
+		privateKey = key
+	default:
+		return SigningKey{}, fmt.Errorf("unsupported signing algorithm %s", algorithm)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return SigningKey{}, err
+	}
+	sealed, err := EncryptSecret(string(der))
+	if err != nil {
+		return SigningKey{}, err
+	}
+
+	id := make([]byte, KEY_ID_BYTES)
+	if _, err := rand.Read(id); err != nil {
+		return SigningKey{}, err
+	}
+	return SigningKey{
+		KeyId:      hex.EncodeToString(id),
+		Algorithm:  algorithm,
+		PrivateKey: sealed,
+		CreatedAt:  now,
+	}, nil
+}
+
+func parseSigningKey(stored SigningKey) (*signingKey, error) {
+	der, err := DecryptSecret(stored.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := x509.ParsePKCS8PrivateKey([]byte(der))
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{
+		keyId:      stored.KeyId,
+		privateKey: privateKey,
+		createdAt:  stored.CreatedAt,
+	}
+	switch private := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if stored.Algorithm != ALG_RS256 {
+			return nil, errors.New("key type does not match the algorithm")
+		}
+		key.method = jwt.SigningMethodRS256
+		key.publicKey = &private.PublicKey
+	case ed25519.PrivateKey:
+		if stored.Algorithm != ALG_EDDSA {
+			return nil, errors.New("key type does not match the algorithm")
+		}
+		key.method = jwt.SigningMethodEdDSA
+		key.publicKey = private.Public()
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+	return key, nil
+}
+
+// JWK is the public part of a signing key as per RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyId     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS lists the public keys of every token that may still be valid, including a key about to be used
+func JWKS() JWKSet {
+	set := JWKSet{Keys: make([]JWK, 0)}
+	for _, key := range keys.verificationKeys() {
+		jwk := JWK{
+			KeyId:     key.keyId,
+			Use:       "sig",
+			Algorithm: key.method.Alg(),
+		}
+		switch public := key.publicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.KeyType = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.KeyType = "OKP"
+			jwk.Curve = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// memoryKeyStore keeps signing keys in memory the way the db does
+type memoryKeyStore struct {
+	keys []SigningKey
+}
+
+func (store *memoryKeyStore) GetSigningKeys(ctx context.Context) ([]SigningKey, error) {
+	return append([]SigningKey{}, store.keys...), nil
+}
+
+func (store *memoryKeyStore) AddSigningKey(ctx context.Context, key SigningKey, rotateBefore time.Time) (bool, error) {
+	for _, stored := range store.keys {
+		if stored.Algorithm == key.Algorithm && stored.CreatedAt.After(rotateBefore) {
+			return false, nil
+		}
+	}
+	store.keys = append(store.keys, key)
+	return true, nil
+}
+
+func useSigningAlgorithm(t *testing.T, algorithm string) {
+	signingAlgorithm = algorithm
+	t.Cleanup(func() {
+		signingAlgorithm = ALG_HS256
+		keys = keyring{}
+	})
+}
+
+func signedToken(t *testing.T, userId int64) string {
+	token, err := GenerateJWT(Token{UserId: userId, TokenId: "jti", Exp: time.Now().Add(time.Minute)})
+	assert.Equal(t, nil, err)
+	return token
+}
+
+func Test_SigningAlgorithms(t *testing.T) {
+	for _, algorithm := range []string{ALG_RS256, ALG_EDDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			useSigningAlgorithm(t, algorithm)
+			store := &memoryKeyStore{}
+			assert.Equal(t, nil, InitKeys(context.Background(), store))
+			assert.Equal(t, 1, len(store.keys))
+
+			claims := &Claims{}
+			token, err := parseJWT(signedToken(t, 7), claims)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, token.Valid)
+			assert.Equal(t, algorithm, token.Method.Alg())
+			assert.Equal(t, store.keys[0].KeyId, token.Header["kid"])
+			assert.Equal(t, int64(7), claims.Payload.UserId)
+
+			set := JWKS()
+			assert.Equal(t, 1, len(set.Keys))
+			assert.Equal(t, store.keys[0].KeyId, set.Keys[0].KeyId)
+			assert.Equal(t, algorithm, set.Keys[0].Algorithm)
+		})
+	}
+}
+
+func Test_RotateKeys(t *testing.T) {
+	useSigningAlgorithm(t, ALG_EDDSA)
+	store := &memoryKeyStore{}
+	start := time.Now().Add(-KeyRotationInterval() - time.Hour)
+	assert.Equal(t, nil, RotateKeys(context.Background(), store, start))
+	oldToken := signedToken(t, 1)
+
+	//a due key is replaced, but the successor signs only after every instance could load it
+	now := time.Now()
+	assert.Equal(t, nil, RotateKeys(context.Background(), store, now))
+	assert.Equal(t, 2, len(store.keys))
+	assert.Equal(t, store.keys[0].KeyId, keys.signingKey().keyId)
+	assert.Equal(t, 2, len(JWKS().Keys))
+
+	//a second rotation in the same window adds nothing
+	assert.Equal(t, nil, RotateKeys(context.Background(), store, now))
+	assert.Equal(t, 2, len(store.keys))
+
+	now = now.Add(KeyRefreshInterval())
+	assert.Equal(t, nil, RotateKeys(context.Background(), store, now))
+	assert.Equal(t, store.keys[1].KeyId, keys.signingKey().keyId)
+
+	//tokens of the previous key stay valid
+	_, err := parseJWT(oldToken, &Claims{})
+	assert.Equal(t, nil, err)
+
+	//the previous key is dropped once its tokens have expired
+	now = now.Add(AccessTokenTTL() + KeyRefreshInterval())
+	assert.Equal(t, nil, RotateKeys(context.Background(), store, now))
+	assert.Equal(t, 1, len(JWKS().Keys))
+	_, err = parseJWT(oldToken, &Claims{})
+	assert.NotEqual(t, nil, err)
+}
+
+func Test_LegacyHS256Tokens(t *testing.T) {
+	jwtKey = []byte("legacy")
+	defer func() { jwtKey = nil }()
+	legacyToken := signedToken(t, 1)
+
+	useSigningAlgorithm(t, ALG_RS256)
+	assert.Equal(t, nil, InitKeys(context.Background(), &memoryKeyStore{}))
+
+	//HS256 tokens are refused once an asymmetric algorithm is in use, unless switching over
+	_, err := parseJWT(legacyToken, &Claims{})
+	assert.NotEqual(t, nil, err)
+
+	acceptLegacyTokens = true
+	defer func() { acceptLegacyTokens = false }()
+	_, err = parseJWT(legacyToken, &Claims{})
+	assert.Equal(t, nil, err)
+}
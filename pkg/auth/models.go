@@ -15,10 +15,13 @@ type AuthResponse struct {
 }
 
 type Token struct {
-	UserName string    `json:"username"`
-	UserId   int64     `json:"userId"`
-	UserType string    `json:"userType"`
-	Exp      time.Time `json:"expiry"`
+	UserName     string    `json:"username"`
+	UserId       int64     `json:"userId"`
+	UserType     string    `json:"userType"`
+	Roles        []string  `json:"roles"`
+	TokenVersion int64     `json:"tokenVersion"`
+	TokenId      string    `json:"jti"`
+	Exp          time.Time `json:"expiry"`
 }
 
 type Claims struct {
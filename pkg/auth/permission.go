@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"aspire-assignment/pkg/config"
+	e "aspire-assignment/pkg/errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// permissions granted to roles in the role_permission table. :own permissions act on the data of the logged in user
+const (
+	LOAN_WRITE_OWN      = "loan:write:own"
+	LOAN_READ_OWN       = "loan:read:own"
+	LOAN_READ_ANY       = "loan:read:any"
+	LOAN_APPROVE        = "loan:approve"
+	PAYMENT_CREATE_OWN  = "payment:create:own"
+	PAYMENT_CREATE_ANY  = "payment:create:any"
+	PROFILE_READ_OWN    = "profile:read:own"
+	PROFILE_WRITE_OWN   = "profile:write:own"
+	DOCUMENT_READ_OWN   = "document:read:own"
+	DOCUMENT_WRITE_OWN  = "document:write:own"
+	DOCUMENT_READ_ANY   = "document:read:any"
+	DOCUMENT_VERIFY     = "document:verify"
+	ADMIN_INVITE        = "admin:invite"
+	ROLE_READ           = "role:read"
+	ROLE_ASSIGN         = "role:assign"
+	USER_UNLOCK         = "user:unlock"
+	SERVICE_ACCT_MANAGE = "service_account:manage"
+	AUDIT_READ          = "audit:read"
+	WEBHOOK_MANAGE      = "webhook:manage"
+	RECONCILE_MANAGE    = "reconciliation:manage"
+)
+
+// ServiceAccountScopes are the permissions an API key can be granted. :own permissions need a user and are never granted to keys
+var ServiceAccountScopes = []string{PAYMENT_CREATE_ANY, LOAN_READ_ANY, DOCUMENT_READ_ANY}
+
+// Privileged tells whether the permissions reach beyond the data of the user holding them, as those of the admin
+// roles do. customers hold :own permissions only
+func Privileged(permissions []string) bool {
+	for _, permission := range permissions {
+		if !strings.HasSuffix(permission, ":own") {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionChecker resolves the permissions granted to a role set
+type PermissionChecker interface {
+	HasPermission(*gin.Context, []string, string) (bool, error)
+}
+
+// Authorize allows the route only when a role from the token, or a scope of the API key, grants one of the permissions.
+// it must run after AuthMiddleware and keeps the granted permission for the handler
+func Authorize(checker PermissionChecker, permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			response AuthResponse
+			allowed  bool
+			granted  string
+			err      error
+		)
+		if IsServiceAccount(c) {
+			scopes := c.GetStringSlice(config.SCOPES)
+			for _, permission := range permissions {
+				if allowed = contains(scopes, permission); allowed {
+					granted = permission
+					break
+				}
+			}
+		} else {
+			roles, _ := c.Get(config.ROLES)
+			roleSet, _ := roles.([]string)
+			for _, permission := range permissions {
+				if allowed, err = checker.HasPermission(c, roleSet, permission); allowed || err != nil {
+					granted = permission
+					break
+				}
+			}
+		}
+		if err != nil {
+			log.Printf("failed to check permission. Error: %s", err.Error())
+			response.Status = false
+			response.Message = "failed to check permission"
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+			c.JSON(http.StatusInternalServerError, response)
+			c.Abort()
+			return
+		}
+		if !allowed {
+			log.Printf("permission %v denied for %s", permissions, Actor(c))
+			response.Status = false
+			response.Message = "access not allowed"
+			response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("access not allowed"))
+			c.JSON(http.StatusForbidden, response)
+			c.Abort()
+			return
+		}
+		c.Set(config.PERMISSION, granted)
+		c.Next()
+	}
+}
+
+// Granted tells whether Authorize allowed the request with the permission. Authorize grants the first of its
+// permissions the caller holds, so routes list the broader permission first
+func Granted(c *gin.Context, permission string) bool {
+	return c.GetString(config.PERMISSION) == permission
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
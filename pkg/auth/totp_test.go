@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func Test_ValidateTOTP(t *testing.T) {
+	//RFC 6238 SHA1 test secret. the 8 digit vector 94287082 at T=59 truncates to 287082
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+	at := time.Unix(59, 0)
+
+	step, ok := ValidateTOTP(secret, "287082", at)
+	assert.Equal(t, true, ok)
+	assert.Equal(t, int64(1), step)
+
+	//one step of clock drift is allowed, two are not
+	_, ok = ValidateTOTP(secret, "287082", at.Add(TOTP_PERIOD*time.Second))
+	assert.Equal(t, true, ok)
+	_, ok = ValidateTOTP(secret, "287082", at.Add(2*TOTP_PERIOD*time.Second))
+	assert.Equal(t, false, ok)
+
+	code, err := GenerateTOTPCode(secret, at)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "287082", code)
+}
+
+func Test_EncryptSecret(t *testing.T) {
+	jwtKey = []byte("test-key")
+	secret, err := GenerateTOTPSecret()
+	assert.Equal(t, nil, err)
+
+	encrypted, err := EncryptSecret(secret)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, strings.Contains(encrypted, secret))
+
+	decrypted, err := DecryptSecret(encrypted)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, secret, decrypted)
+}
+
+func Test_TOTPProvisioningURI(t *testing.T) {
+	uri := TOTPProvisioningURI("Aspire", "admin", "ABCDEF")
+	assert.Equal(t, true, strings.HasPrefix(uri, "otpauth://totp/Aspire:admin?"))
+	assert.Equal(t, true, strings.Contains(uri, "secret=ABCDEF"))
+}
@@ -0,0 +1,69 @@
+package autodebit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// account providers, selected by autodebit.provider in config
+const (
+	BALANCE = "balance"
+)
+
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// AccountProvider moves money out of and back into the account of a customer. reference names the debit, and a
+// provider must take the money of a reference once however often it is asked, so a debit attempted again after a
+// crash does not charge the customer twice
+type AccountProvider interface {
+	//Debit fails with ErrInsufficientFunds when the account does not cover amount
+	Debit(ctx context.Context, userId int64, amount float64, reference string) error
+	//Refund gives back the debit of reference, which could not be applied to the loan. a reference never debited
+	//or already refunded is left as it is
+	Refund(ctx context.Context, userId int64, amount float64, reference string) error
+}
+
+// BalanceStore is the part of the db layer the balance provider works with
+type BalanceStore interface {
+	DebitAccountBalance(context.Context, int64, float64, string) (bool, error)
+	RefundAccountDebit(context.Context, string) error
+}
+
+// NewAccountProvider returns the provider selected by autodebit.provider in config
+func NewAccountProvider(store BalanceStore) (AccountProvider, error) {
+	switch provider {
+	case BALANCE, "":
+		log.Println("Balance account provider initialized")
+		return NewBalanceProvider(store), nil
+	default:
+		return nil, fmt.Errorf("unsupported account provider %s", provider)
+	}
+}
+
+type balanceProvider struct {
+	store BalanceStore
+}
+
+// NewBalanceProvider debits the account balance customers keep in their profile, without any bank behind it
+func NewBalanceProvider(store BalanceStore) AccountProvider {
+	return &balanceProvider{
+		store: store,
+	}
+}
+
+func (obj *balanceProvider) Debit(ctx context.Context, userId int64, amount float64, reference string) error {
+	debited, err := obj.store.DebitAccountBalance(ctx, userId, amount, reference)
+	if err != nil {
+		return err
+	}
+	if !debited {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+func (obj *balanceProvider) Refund(ctx context.Context, userId int64, amount float64, reference string) error {
+	return obj.store.RefundAccountDebit(ctx, reference)
+}
@@ -0,0 +1,298 @@
+package autodebit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbautodebit "aspire-assignment/pkg/db/v1/autodebit"
+	"aspire-assignment/pkg/notification"
+	"aspire-assignment/pkg/poller"
+	auditservice "aspire-assignment/pkg/service/v1/audit"
+	"aspire-assignment/pkg/service/v1/loan"
+)
+
+// TRANSACTION_PREFIX starts the transaction id of the repayments made by a debit, followed by the debit id
+const TRANSACTION_PREFIX = "AUTODEBIT"
+
+// ACTOR names the scheduler in the audit log
+const ACTOR = "auto debit scheduler"
+
+// debit policy, overridden by autodebit.* in config
+var (
+	provider     = BALANCE
+	pollInterval = time.Minute
+	batchSize    = 100
+	//how long a claimed debit is left to one scheduler before another may attempt it again
+	lease = 5 * time.Minute
+	//a debit failing this many times is given up and the customer repays the installment themselves
+	maxAttempts   = int64(3)
+	retryInterval = 24 * time.Hour
+)
+
+func InitDebitPolicy() {
+	confi := config.GetConfig()
+	if value := confi.GetString("autodebit.provider"); value != "" {
+		provider = value
+	}
+	if value := confi.GetDuration("autodebit.poll_interval"); value > 0 {
+		pollInterval = value
+	}
+	if value := confi.GetInt("autodebit.batch_size"); value > 0 {
+		batchSize = value
+	}
+	if value := confi.GetDuration("autodebit.lease"); value > 0 {
+		lease = value
+	}
+	if value := confi.GetInt64("autodebit.max_attempts"); value > 0 {
+		maxAttempts = value
+	}
+	if value := confi.GetDuration("autodebit.retry_interval"); value > 0 {
+		retryInterval = value
+	}
+	log.Println("InitDebitPolicy successful")
+}
+
+// Scheduler debits the installments falling due under the mandates of the customers and repays them
+type Scheduler struct {
+	store         v1.V1DBLayer
+	loans         loan.Loans
+	account       AccountProvider
+	notifications notification.Notifications
+	audit         audit.Recorder
+	now           func() time.Time
+}
+
+func NewScheduler(store v1.V1DBLayer, account AccountProvider, notifications notification.Notifications) *Scheduler {
+	return &Scheduler{
+		store:         store,
+		loans:         loan.NewLoans(store),
+		account:       account,
+		notifications: notifications,
+		audit:         auditservice.NewAuditService(store),
+		now:           time.Now,
+	}
+}
+
+// Start runs the scheduler every poll interval in the background. the returned func stops the scheduler and waits
+// for the debits in progress
+func (obj *Scheduler) Start() func() {
+	return poller.Start(pollInterval, batchSize, "run auto debits", obj.Run)
+}
+
+// Run makes one pass: it schedules a debit for the installments fallen due and attempts the debits due, new or
+// retried. it returns how many debits it attempted, succeeded or not
+func (obj *Scheduler) Run(ctx context.Context) (int, error) {
+	now := obj.now()
+	due, err := obj.store.GetDueInstallments(ctx, now, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, debit := range due {
+		debit.CreatedAt = sql.NullTime{Time: now, Valid: true}
+		if _, err := obj.store.AddAutoDebit(ctx, debit); err != nil {
+			return 0, err
+		}
+	}
+
+	debits, err := obj.store.GetDueAutoDebits(ctx, now, batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, debit := range debits {
+		if err := obj.attempt(ctx, debit); err != nil {
+			return 0, err
+		}
+	}
+	return len(debits), nil
+}
+
+// attempt claims the debit, takes the installment from the account of the customer and repays it. a failed
+// attempt is retried every retry interval until the debit runs out of attempts. the account is debited under the
+// transaction id of the debit, so an attempt cut short by a crash or an expired lease is picked up again without
+// charging the customer twice. only store errors are returned
+func (obj *Scheduler) attempt(ctx context.Context, debit dbautodebit.AutoDebit) error {
+	debitId := debit.DebitId.Int64
+	claimed, err := obj.store.ClaimAutoDebit(ctx, debitId, debit.Attempts.Int64, obj.now().Add(lease))
+	if err != nil || !claimed {
+		return err
+	}
+	debit.Attempts.Int64++
+	userId, loanId := debit.UserId.Int64, debit.LoanId.Int64
+	txnId := fmt.Sprintf("%s%d", TRANSACTION_PREFIX, debitId)
+
+	//the installment may have been repaid another way since it fell due, and what is due may have changed with it
+	amount, due, paid, err := obj.installmentDue(ctx, debit, txnId)
+	if err != nil {
+		return obj.failed(ctx, debit, err)
+	}
+	if paid {
+		debit.TransactionId = sql.NullString{String: txnId, Valid: true}
+		log.Printf("auto debit %d already repaid installment %d of LoanId: %d", debitId, debit.InstallmentNum.Int64, loanId)
+		return obj.complete(ctx, debit, dbautodebit.SUCCEEDED, "")
+	}
+	if !due {
+		//an attempt cut short after the debit left the account debited, the money is given back first
+		if err := obj.account.Refund(ctx, userId, debit.Amount.Float64, txnId); err != nil {
+			return obj.refundFailed(ctx, debit, err)
+		}
+		obj.record(ctx, audit.AUTODEBIT_REFUND, audit.TARGET_AUTODEBIT, debitId, nil, obj.movement(debit, debit.Amount.Float64, txnId))
+		log.Printf("auto debit %d cancelled, installment %d of LoanId: %d is no longer due", debitId, debit.InstallmentNum.Int64, loanId)
+		return obj.complete(ctx, debit, dbautodebit.CANCELLED, "installment is no longer due")
+	}
+	debit.Amount.Float64 = amount
+
+	if err := obj.account.Debit(ctx, userId, amount, txnId); err != nil {
+		return obj.failed(ctx, debit, err)
+	}
+	obj.record(ctx, audit.AUTODEBIT_DEBIT, audit.TARGET_AUTODEBIT, debitId, nil, obj.movement(debit, amount, txnId))
+	repayment, err := obj.loans.Repay(ctx, userId, loanId, amount, txnId)
+	if err != nil {
+		//a concurrent attempt let in by an expired lease may have repaid the installment with the same debit
+		if _, _, paid, dueErr := obj.installmentDue(ctx, debit, txnId); dueErr == nil && paid {
+			debit.TransactionId = sql.NullString{String: txnId, Valid: true}
+			return obj.complete(ctx, debit, dbautodebit.SUCCEEDED, "")
+		}
+		if refundErr := obj.account.Refund(ctx, userId, amount, txnId); refundErr != nil {
+			return obj.refundFailed(ctx, debit, fmt.Errorf("%s. refund failed: %w", err.Error(), refundErr))
+		}
+		obj.record(ctx, audit.AUTODEBIT_REFUND, audit.TARGET_AUTODEBIT, debitId, nil, obj.movement(debit, amount, txnId))
+		return obj.failed(ctx, debit, err)
+	}
+	obj.record(ctx, audit.LOAN_REPAY, audit.TARGET_LOAN, loanId, map[string]interface{}{"outstandingAmount": repayment.OutstandingAmount + repayment.Amount}, map[string]interface{}{
+		"outstandingAmount": repayment.OutstandingAmount,
+		"amount":            repayment.Amount,
+		"transactionId":     repayment.TransactionId,
+		"installmentNumber": repayment.InstallmentNumber,
+		"loanClosed":        repayment.LoanClosed,
+		"debitId":           debitId,
+	})
+	debit.TransactionId = sql.NullString{String: txnId, Valid: true}
+	log.Printf("auto debit %d repaid installment %d of LoanId: %d with %.2f", debitId, debit.InstallmentNum.Int64, loanId, amount)
+	return obj.complete(ctx, debit, dbautodebit.SUCCEEDED, "")
+}
+
+// installmentDue returns the amount due on the installment of the debit, and false when the loan is no longer
+// approved or the installment is not the next one pending. paid is true when the installment was repaid with
+// txnId, by an attempt cut short before it completed the debit
+func (obj *Scheduler) installmentDue(ctx context.Context, debit dbautodebit.AutoDebit, txnId string) (float64, bool, bool, error) {
+	schedule, err := obj.loans.Schedule(ctx, debit.UserId.Int64, debit.LoanId.Int64)
+	if errors.Is(err, loan.ErrNoInstallments) {
+		return 0, false, false, nil
+	}
+	if err != nil {
+		return 0, false, false, err
+	}
+	for _, installment := range schedule.Installments {
+		if installment.TransactionId == txnId {
+			return 0, false, true, nil
+		}
+	}
+	if schedule.Status != loan.LOAN_APPROVED {
+		return 0, false, false, nil
+	}
+	for _, installment := range schedule.Installments {
+		if installment.Status == loan.TXN_PENDING {
+			return installment.AmountDue, installment.InstallmentNumber == debit.InstallmentNum.Int64, false, nil
+		}
+	}
+	return 0, false, false, nil
+}
+
+// refundFailed gives up a debit whose money could not be given back. it is not attempted again, which could debit
+// the account once more, and is left REFUND_FAILED for an admin to settle with the customer
+func (obj *Scheduler) refundFailed(ctx context.Context, debit dbautodebit.AutoDebit, cause error) error {
+	log.Printf("failed to refund auto debit %d of %.2f to UserId: %d. Error: %s", debit.DebitId.Int64, debit.Amount.Float64, debit.UserId.Int64, cause.Error())
+	return obj.complete(ctx, debit, dbautodebit.REFUND_FAILED, cause.Error())
+}
+
+// failed retries the debit or gives it up once it ran out of attempts. the customer is told when their account
+// could not cover the installment, so they can top it up before the next attempt
+func (obj *Scheduler) failed(ctx context.Context, debit dbautodebit.AutoDebit, cause error) error {
+	debitId, attempts := debit.DebitId.Int64, debit.Attempts.Int64
+	log.Printf("failed to auto debit %d for LoanId: %d, attempt %d. Error: %s", debitId, debit.LoanId.Int64, attempts, cause.Error())
+	if attempts >= maxAttempts {
+		log.Printf("auto debit %d failed after %d attempts", debitId, attempts)
+		if errors.Is(cause, ErrInsufficientFunds) {
+			obj.notify(ctx, debit, time.Time{})
+		}
+		return obj.complete(ctx, debit, dbautodebit.FAILED, cause.Error())
+	}
+	nextAttemptAt := obj.now().Add(retryInterval)
+	if errors.Is(cause, ErrInsufficientFunds) {
+		obj.notify(ctx, debit, nextAttemptAt)
+	}
+	return obj.store.RetryAutoDebit(ctx, debitId, nextAttemptAt, cause.Error())
+}
+
+// movement is the audited state of money taken from or given back to the account of the customer for the debit
+func (obj *Scheduler) movement(debit dbautodebit.AutoDebit, amount float64, txnId string) map[string]interface{} {
+	return map[string]interface{}{
+		"userId":            debit.UserId.Int64,
+		"loanId":            debit.LoanId.Int64,
+		"installmentNumber": debit.InstallmentNum.Int64,
+		"amount":            amount,
+		"transactionId":     txnId,
+	}
+}
+
+// record writes an action of the scheduler to the audit log, as the SYSTEM actor. a failed write is only logged, as
+// it is for the requests
+func (obj *Scheduler) record(ctx context.Context, action string, targetType string, targetId int64, before interface{}, after interface{}) {
+	entry := audit.Entry{
+		ActorType:  audit.ACTOR_SYSTEM,
+		Actor:      ACTOR,
+		Action:     action,
+		Outcome:    audit.SUCCESS,
+		TargetType: targetType,
+		TargetId:   fmt.Sprint(targetId),
+	}
+	if before != nil {
+		value, _ := json.Marshal(before)
+		entry.Before = string(value)
+	}
+	if after != nil {
+		value, _ := json.Marshal(after)
+		entry.After = string(value)
+	}
+	if err := obj.audit.RecordAudit(ctx, entry); err != nil {
+		log.Printf("failed to write audit entry for %s. Error: %s", action, err.Error())
+	}
+}
+
+func (obj *Scheduler) complete(ctx context.Context, debit dbautodebit.AutoDebit, status string, lastError string) error {
+	debit.Status = sql.NullString{String: status, Valid: true}
+	debit.LastError = sql.NullString{String: lastError, Valid: lastError != ""}
+	debit.UpdatedAt = sql.NullTime{Time: obj.now(), Valid: true}
+	return obj.store.CompleteAutoDebit(ctx, debit)
+}
+
+// notify tells the customer about a debit their account could not cover. a zero nextAttemptAt means it will not
+// be attempted again. a notification which cannot be queued is only logged
+func (obj *Scheduler) notify(ctx context.Context, debit dbautodebit.AutoDebit, nextAttemptAt time.Time) {
+	data := map[string]interface{}{
+		"loanId":            debit.LoanId.Int64,
+		"installmentNumber": debit.InstallmentNum.Int64,
+		"amount":            debit.Amount.Float64,
+		"dueDate":           debit.DueDate.Time,
+	}
+	if !nextAttemptAt.IsZero() {
+		data["nextAttemptAt"] = nextAttemptAt
+	}
+	err := obj.notifications.Notify(ctx, notification.Notice{
+		UserId:    debit.UserId.Int64,
+		EventType: notification.EVENT_AUTODEBIT_FAILED,
+		Key:       fmt.Sprintf("%s:%d:%d", notification.EVENT_AUTODEBIT_FAILED, debit.DebitId.Int64, debit.Attempts.Int64),
+		Data:      data,
+	})
+	if err != nil {
+		log.Printf("failed to notify UserId: %d of auto debit %d. Error: %s", debit.UserId.Int64, debit.DebitId.Int64, err.Error())
+	}
+}
@@ -0,0 +1,318 @@
+package autodebit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbaudit "aspire-assignment/pkg/db/v1/audit"
+	dbautodebit "aspire-assignment/pkg/db/v1/autodebit"
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/memory"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	"aspire-assignment/pkg/notification"
+	svcloan "aspire-assignment/pkg/service/v1/loan"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// recordingNotifications keeps the notices it was given
+type recordingNotifications struct {
+	notices []notification.Notice
+}
+
+func (obj *recordingNotifications) Notify(ctx context.Context, notice notification.Notice) error {
+	obj.notices = append(obj.notices, notice)
+	return nil
+}
+
+// mandatedLoan is an approved loan of 300 in 3 weekly installments of 100, the first due at approval, with a
+// mandate to debit them from a balance of 150
+func mandatedLoan(t *testing.T, ctx context.Context, store v1.V1DBLayer) (int64, int64) {
+	userId, _ := store.AddUser(ctx, usermanagement.UserDetails{
+		UserName:       sql.NullString{String: "john", Valid: true},
+		UserType:       sql.NullString{String: "CUSTOMER", Valid: true},
+		Email:          sql.NullString{String: "john@example.com", Valid: true},
+		AccountBalance: sql.NullFloat64{Float64: 150, Valid: true},
+	})
+	loanId, _ := store.CreateLoan(ctx, userId, 300, 3, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+	approval := loan.StatusChange{From: "PENDING", To: "APPROVED", Actor: "ADMIN", ActorId: 1}
+	assert.Equal(t, nil, store.UpdateAndInsertInstallments(ctx, loanId, approval, 100, 3, nil))
+	_, err := store.AddDebitMandate(ctx, dbautodebit.DebitMandate{
+		UserId:    sql.NullInt64{Int64: userId, Valid: true},
+		LoanId:    sql.NullInt64{Int64: loanId, Valid: true},
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	assert.Equal(t, nil, err)
+	return userId, loanId
+}
+
+// setBalance updates the account balance as the customer does from their profile
+func setBalance(t *testing.T, ctx context.Context, store v1.V1DBLayer, userId int64, amount float64) {
+	change := usermanagement.ProfileChange{
+		Field:    sql.NullString{String: "BANK_BALANCE", Valid: true},
+		NewValue: sql.NullString{String: strconv.FormatFloat(amount, 'f', 2, 64), Valid: true},
+	}
+	assert.Equal(t, nil, store.UpdateUserProfile(ctx, userId, []usermanagement.ProfileChange{change}))
+}
+
+func balance(t *testing.T, ctx context.Context, store v1.V1DBLayer, userId int64) float64 {
+	user, err := store.GetUserById(ctx, userId)
+	assert.Equal(t, nil, err)
+	return user.AccountBalance.Float64
+}
+
+func Test_Scheduler_Run(t *testing.T) {
+	ctx := context.Background()
+	maxAttempts, retryInterval, batchSize = 2, 24*time.Hour, 10
+
+	store := memory.NewV1DbLayer()
+	userId, loanId := mandatedLoan(t, ctx, store)
+	notifications := &recordingNotifications{}
+	scheduler := NewScheduler(store, NewBalanceProvider(store), notifications)
+	start := time.Now().Add(time.Minute)
+	at := func(offset time.Duration) {
+		scheduler.now = func() time.Time { return start.Add(offset) }
+	}
+	week := 7 * 24 * time.Hour
+
+	//the first installment is debited when it falls due
+	at(0)
+	attempted, err := scheduler.Run(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, float64(50), balance(t, ctx, store, userId))
+	debits, _ := store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, 1, len(debits))
+	assert.Equal(t, dbautodebit.SUCCEEDED, debits[0].Status.String)
+	assert.Equal(t, "AUTODEBIT1", debits[0].TransactionId.String)
+	installments, _ := store.GetUserLoanInstallments(ctx, userId, loanId)
+	assert.Equal(t, "PAID", installments[0].Status.String)
+	assert.Equal(t, "AUTODEBIT1", installments[0].TransactionId.String)
+
+	//the debit and the repayment are audited as the system
+	entries, _ := store.GetAuditEntries(ctx, dbaudit.AuditFilter{Limit: 10})
+	assert.Equal(t, 2, len(entries))
+	assert.Equal(t, audit.AUTODEBIT_DEBIT, entries[0].Action.String)
+	assert.Equal(t, audit.TARGET_AUTODEBIT, entries[0].TargetType.String)
+	assert.Equal(t, "1", entries[0].TargetId.String)
+	assert.Equal(t, audit.LOAN_REPAY, entries[1].Action.String)
+	assert.Equal(t, audit.ACTOR_SYSTEM, entries[1].ActorType.String)
+	assert.Equal(t, strconv.FormatInt(loanId, 10), entries[1].TargetId.String)
+	assert.Equal(t, true, strings.Contains(entries[1].AfterState.String, `"debitId":1`))
+
+	//nothing else is due before the next week
+	attempted, _ = scheduler.Run(ctx)
+	assert.Equal(t, 0, attempted)
+
+	//a balance not covering the installment is retried and the customer told
+	at(week)
+	attempted, err = scheduler.Run(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, float64(50), balance(t, ctx, store, userId))
+	debits, _ = store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.PENDING, debits[1].Status.String)
+	assert.Equal(t, int64(1), debits[1].Attempts.Int64)
+	assert.Equal(t, ErrInsufficientFunds.Error(), debits[1].LastError.String)
+	assert.Equal(t, 1, len(notifications.notices))
+	assert.Equal(t, notification.EVENT_AUTODEBIT_FAILED, notifications.notices[0].EventType)
+	assert.Equal(t, userId, notifications.notices[0].UserId)
+	assert.Equal(t, "autodebit.failed:2:1", notifications.notices[0].Key)
+	assert.Equal(t, start.Add(week+retryInterval), notifications.notices[0].Data["nextAttemptAt"])
+
+	//the retry waits for the retry interval and goes through once the balance is topped up
+	at(week + time.Hour)
+	attempted, _ = scheduler.Run(ctx)
+	assert.Equal(t, 0, attempted)
+	setBalance(t, ctx, store, userId, 150)
+	at(week + retryInterval)
+	attempted, _ = scheduler.Run(ctx)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, float64(50), balance(t, ctx, store, userId))
+	debits, _ = store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.SUCCEEDED, debits[1].Status.String)
+	assert.Equal(t, int64(2), debits[1].Attempts.Int64)
+	assert.Equal(t, false, debits[1].LastError.Valid)
+
+	//a debit running out of attempts fails and is left to the customer
+	at(2 * week)
+	scheduler.Run(ctx)
+	at(2*week + retryInterval)
+	attempted, _ = scheduler.Run(ctx)
+	assert.Equal(t, 1, attempted)
+	debits, _ = store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.FAILED, debits[2].Status.String)
+	assert.Equal(t, 3, len(notifications.notices))
+	_, retried := notifications.notices[2].Data["nextAttemptAt"]
+	assert.Equal(t, false, retried)
+	at(2*week + 2*retryInterval)
+	attempted, _ = scheduler.Run(ctx)
+	assert.Equal(t, 0, attempted)
+	assert.Equal(t, float64(50), balance(t, ctx, store, userId))
+}
+
+// countingProvider is the balance provider counting its refunds
+type countingProvider struct {
+	AccountProvider
+	refunds int
+}
+
+func (obj *countingProvider) Refund(ctx context.Context, userId int64, amount float64, reference string) error {
+	obj.refunds++
+	return obj.AccountProvider.Refund(ctx, userId, amount, reference)
+}
+
+func Test_Scheduler_InstallmentPaidOtherwise(t *testing.T) {
+	ctx := context.Background()
+	maxAttempts, retryInterval, batchSize = 2, 24*time.Hour, 10
+
+	store := memory.NewV1DbLayer()
+	userId, loanId := mandatedLoan(t, ctx, store)
+	provider := &countingProvider{AccountProvider: NewBalanceProvider(store)}
+	scheduler := NewScheduler(store, provider, &recordingNotifications{})
+	start := time.Now().Add(time.Minute)
+	scheduler.now = func() time.Time { return start }
+
+	//the installment falls due but the balance does not cover it
+	setBalance(t, ctx, store, userId, 50)
+	scheduler.Run(ctx)
+	debits, _ := store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.PENDING, debits[0].Status.String)
+
+	//the customer pays it another way before the next attempt, which is cancelled without debiting
+	setBalance(t, ctx, store, userId, 150)
+	_, err := scheduler.loans.Repay(ctx, userId, loanId, 100, "TXN1")
+	assert.Equal(t, nil, err)
+	scheduler.now = func() time.Time { return start.Add(retryInterval) }
+	attempted, err := scheduler.Run(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, attempted)
+	debits, _ = store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.CANCELLED, debits[0].Status.String)
+	assert.Equal(t, float64(150), balance(t, ctx, store, userId))
+	//the refund of a debit never taken leaves the balance as it is
+	assert.Equal(t, 1, provider.refunds)
+}
+
+// refusingLoans refuses every repayment, like a loan changed between the debit and the repayment
+type refusingLoans struct {
+	svcloan.Loans
+}
+
+func (obj refusingLoans) Repay(ctx context.Context, userId, loanId int64, amount float64, txnId string) (svcloan.Repayment, error) {
+	return svcloan.Repayment{}, errors.New("loan changed")
+}
+
+func Test_Scheduler_RepaymentRefused(t *testing.T) {
+	ctx := context.Background()
+	maxAttempts, retryInterval, batchSize = 2, 24*time.Hour, 10
+
+	store := memory.NewV1DbLayer()
+	userId, loanId := mandatedLoan(t, ctx, store)
+	provider := &countingProvider{AccountProvider: NewBalanceProvider(store)}
+	notifications := &recordingNotifications{}
+	scheduler := NewScheduler(store, provider, notifications)
+	scheduler.loans = refusingLoans{scheduler.loans}
+	start := time.Now().Add(time.Minute)
+	scheduler.now = func() time.Time { return start }
+
+	//a debit the loan does not take is given back and retried, without telling the customer about their balance
+	attempted, err := scheduler.Run(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, attempted)
+	assert.Equal(t, 1, provider.refunds)
+	assert.Equal(t, float64(150), balance(t, ctx, store, userId))
+	debits, _ := store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.PENDING, debits[0].Status.String)
+	assert.Equal(t, 0, len(notifications.notices))
+}
+
+func Test_Scheduler_AttemptCutShort(t *testing.T) {
+	ctx := context.Background()
+	maxAttempts, retryInterval, batchSize = 3, 24*time.Hour, 10
+	start := time.Now().Add(time.Minute)
+
+	//cutShort schedules the debit of the first installment, with a first attempt the balance does not cover, and
+	//runs cut on the next attempt before letting the scheduler attempt it
+	cutShort := func(cut func(store v1.V1DBLayer, scheduler *Scheduler, userId, loanId int64)) (v1.V1DBLayer, int64, int64) {
+		store := memory.NewV1DbLayer()
+		userId, loanId := mandatedLoan(t, ctx, store)
+		scheduler := NewScheduler(store, NewBalanceProvider(store), &recordingNotifications{})
+		scheduler.now = func() time.Time { return start }
+		setBalance(t, ctx, store, userId, 50)
+		scheduler.Run(ctx)
+		setBalance(t, ctx, store, userId, 150)
+		assert.Equal(t, nil, scheduler.account.Debit(ctx, userId, 100, "AUTODEBIT1"))
+		cut(store, scheduler, userId, loanId)
+		scheduler.now = func() time.Time { return start.Add(retryInterval) }
+		attempted, err := scheduler.Run(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, attempted)
+		return store, userId, loanId
+	}
+
+	//an attempt which debited the account and stopped before the repayment is repaid without debiting again
+	store, userId, loanId := cutShort(func(v1.V1DBLayer, *Scheduler, int64, int64) {})
+	assert.Equal(t, float64(50), balance(t, ctx, store, userId))
+	debits, _ := store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.SUCCEEDED, debits[0].Status.String)
+
+	//an attempt which repaid the installment and stopped before completing the debit succeeds
+	store, userId, loanId = cutShort(func(store v1.V1DBLayer, scheduler *Scheduler, userId, loanId int64) {
+		_, err := scheduler.loans.Repay(ctx, userId, loanId, 100, "AUTODEBIT1")
+		assert.Equal(t, nil, err)
+	})
+	assert.Equal(t, float64(50), balance(t, ctx, store, userId))
+	debits, _ = store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.SUCCEEDED, debits[0].Status.String)
+	assert.Equal(t, "AUTODEBIT1", debits[0].TransactionId.String)
+
+	//an attempt which debited the account of an installment paid another way since is given back
+	store, userId, loanId = cutShort(func(store v1.V1DBLayer, scheduler *Scheduler, userId, loanId int64) {
+		_, err := scheduler.loans.Repay(ctx, userId, loanId, 100, "TXN1")
+		assert.Equal(t, nil, err)
+	})
+	assert.Equal(t, float64(150), balance(t, ctx, store, userId))
+	debits, _ = store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.CANCELLED, debits[0].Status.String)
+}
+
+// failingRefunds is the balance provider failing every refund
+type failingRefunds struct {
+	AccountProvider
+}
+
+func (obj failingRefunds) Refund(ctx context.Context, userId int64, amount float64, reference string) error {
+	return errors.New("provider unavailable")
+}
+
+func Test_Scheduler_RefundFailed(t *testing.T) {
+	ctx := context.Background()
+	maxAttempts, retryInterval, batchSize = 2, 24*time.Hour, 10
+
+	store := memory.NewV1DbLayer()
+	userId, loanId := mandatedLoan(t, ctx, store)
+	scheduler := NewScheduler(store, failingRefunds{NewBalanceProvider(store)}, &recordingNotifications{})
+	scheduler.loans = refusingLoans{scheduler.loans}
+	start := time.Now().Add(time.Minute)
+	scheduler.now = func() time.Time { return start }
+
+	//a debit which could not be given back is left to an admin and never attempted again
+	attempted, err := scheduler.Run(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, attempted)
+	debits, _ := store.GetAutoDebits(ctx, loanId)
+	assert.Equal(t, dbautodebit.REFUND_FAILED, debits[0].Status.String)
+	assert.Equal(t, "loan changed. refund failed: provider unavailable", debits[0].LastError.String)
+	scheduler.now = func() time.Time { return start.Add(retryInterval) }
+	attempted, _ = scheduler.Run(ctx)
+	assert.Equal(t, 0, attempted)
+	assert.Equal(t, float64(50), balance(t, ctx, store, userId))
+}
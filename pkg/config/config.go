@@ -14,7 +14,18 @@ const (
 	USERID        = "userId"
 	USERTYPE      = "userType"
 	USERNAME      = "username"
+	TOKENID       = "tokenId"
+	TOKENEXPIRY   = "tokenExpiry"
+	ROLES         = "roles"
+	APIKEY        = "apiKey"
+	APIKEYID      = "apiKeyId"
+	SCOPES        = "scopes"
+	PERMISSION    = "permission"
+	SERVICEACCT   = "serviceAccount"
+	REQUESTID     = "requestId"
 	AUTHORIZATION = "Authorization"
+	APIKEYHEADER  = "X-API-Key"
+	REQUESTHEADER = "X-Request-ID"
 	ADMIN         = "ADMIN"
 	CUSTOMER      = "CUSTOMER"
 )
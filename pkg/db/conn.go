@@ -2,16 +2,39 @@ package db
 
 import (
 	"aspire-assignment/pkg/config"
+	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/schema"
 )
 
+const (
+	POSTGRES = "postgres"
+	SQLITE   = "sqlite"
+	MEMORY   = "memory"
+)
+
 type dbLogger struct{}
 
+// Connect opens the sql database selected by databases.driver in config
+func Connect() (*gorm.DB, error) {
+	driver := config.GetConfig().GetString("databases.driver")
+	switch driver {
+	case POSTGRES, "":
+		return PsqlConnect()
+	case SQLITE:
+		return SqliteConnect()
+	default:
+		return nil, fmt.Errorf("unsupported sql database driver %s", driver)
+	}
+}
+
 func PsqlConnect() (*gorm.DB, error) {
 
 	c := config.GetConfig()
@@ -35,3 +58,56 @@ func PsqlConnect() (*gorm.DB, error) {
 	log.Println("Postgres Database Connected")
 	return dbc, nil
 }
+
+// SqliteConnect opens the sqlite file in databases.sqlite.path, creating it when missing
+func SqliteConnect() (*gorm.DB, error) {
+	c := config.GetConfig()
+	return OpenSqlite(c.GetString("databases.sqlite.path"), c.GetDuration("databases.sqlite.busy_timeout"))
+}
+
+// OpenSqlite opens the sqlite file at path. writers wait up to busyTimeout for the database lock
+func OpenSqlite(path string, busyTimeout time.Duration) (*gorm.DB, error) {
+	// foreign keys are off in sqlite unless asked for. transactions take the write lock when they begin,
+	// so concurrent writers wait for busy_timeout instead of failing half way through
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(%d)&_txlock=immediate&_time_format=sqlite",
+		path, busyTimeout.Milliseconds())
+
+	dbc, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		log.Printf("Failed to connect to database. Error: %s, conn: %s", err.Error(), dsn)
+		return nil, err
+	}
+
+	if err := dbc.Callback().Raw().Before("gorm:raw").Register("aspire:sqlite_statement", sqliteStatement); err != nil {
+		return nil, err
+	}
+	if err := dbc.Callback().Row().Before("gorm:row").Register("aspire:sqlite_statement", sqliteStatement); err != nil {
+		return nil, err
+	}
+
+	log.Println("Sqlite Database Connected")
+	return dbc, nil
+}
+
+// sqliteStatement adapts the raw queries of the repositories to sqlite. the driver reads anything after the
+// trailing semicolon as another statement, so it is trimmed. sqlite keeps times as text, so they only compare
+// correctly with each other and with CURRENT_TIMESTAMP, which is UTC, when every time parameter is written in UTC
+func sqliteStatement(db *gorm.DB) {
+	query := strings.TrimSpace(db.Statement.SQL.String())
+	db.Statement.SQL.Reset()
+	db.Statement.SQL.WriteString(query)
+
+	for i, value := range db.Statement.Vars {
+		switch t := value.(type) {
+		case time.Time:
+			db.Statement.Vars[i] = t.UTC()
+		case sql.NullTime:
+			db.Statement.Vars[i] = sql.NullTime{Time: t.Time.UTC(), Valid: t.Valid}
+		}
+	}
+}
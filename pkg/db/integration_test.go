@@ -0,0 +1,1481 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/migrations"
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/audit"
+	"aspire-assignment/pkg/db/v1/autodebit"
+	"aspire-assignment/pkg/db/v1/document"
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/db/v1/outbox"
+	"aspire-assignment/pkg/db/v1/payment"
+	"aspire-assignment/pkg/db/v1/reconciliation"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	"aspire-assignment/pkg/db/v1/webhook"
+
+	"github.com/go-playground/assert/v2"
+	"gorm.io/gorm"
+)
+
+// testDatabases runs every repository test against each database the app can run on
+var testDatabases = []struct {
+	name string
+	open func(t *testing.T) v1.V1DBLayer
+}{
+	{name: POSTGRES, open: postgresLayer},
+	{name: SQLITE, open: sqliteLayer},
+	{name: MEMORY, open: func(t *testing.T) v1.V1DBLayer { return NewMemoryDBObject().GetV1DBLayer() }},
+}
+
+func forEachDatabase(t *testing.T, test func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer)) {
+	for _, database := range testDatabases {
+		t.Run(database.name, func(t *testing.T) {
+			test(t, context.Background(), database.open(t))
+		})
+	}
+}
+
+// postgresLayer creates a new database on the postgres server of local.yaml and migrates it. the test is skipped
+// when the server is not running
+func postgresLayer(t *testing.T) v1.V1DBLayer {
+	config.Load("local")
+	server, err := PsqlConnect()
+	if err != nil {
+		t.Skipf("postgres is not available: %s", err.Error())
+	}
+	t.Cleanup(func() { closeConn(server) })
+
+	name := fmt.Sprintf("aspire_test_%d", time.Now().UnixNano())
+	if err := server.Exec("CREATE DATABASE " + name).Error; err != nil {
+		t.Fatal(err)
+	}
+	config.GetConfig().Set("databases.postgres.db", name)
+	conn, err := PsqlConnect()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		closeConn(conn)
+		server.Exec("DROP DATABASE " + name)
+	})
+	return migrated(t, conn)
+}
+
+func sqliteLayer(t *testing.T) v1.V1DBLayer {
+	conn, err := OpenSqlite(filepath.Join(t.TempDir(), "aspire.db"), 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { closeConn(conn) })
+	return migrated(t, conn)
+}
+
+func migrated(t *testing.T, conn *gorm.DB) v1.V1DBLayer {
+	migrator, err := migrations.NewMigrator(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewDBObject(conn).GetV1DBLayer()
+}
+
+func closeConn(conn *gorm.DB) {
+	if sqlDb, _ := conn.DB(); sqlDb != nil {
+		sqlDb.Close()
+	}
+}
+
+func testUser(userName string, userType string) usermanagement.UserDetails {
+	return usermanagement.UserDetails{
+		UserName:       sql.NullString{String: userName, Valid: true},
+		UserPassword:   sql.NullString{String: "hash", Valid: true},
+		UserType:       sql.NullString{String: userType, Valid: true},
+		Email:          sql.NullString{String: userName + "@example.com", Valid: true},
+		Mobile:         sql.NullString{String: "9876543210", Valid: true},
+		MonthlySalary:  sql.NullFloat64{Float64: 5000, Valid: true},
+		AccountBalance: sql.NullFloat64{Float64: 100, Valid: true},
+	}
+}
+
+func Test_Repository_Users(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, err := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		assert.Equal(t, nil, err)
+		_, err = dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		assert.Equal(t, true, usermanagement.IsUniqueViolation(err))
+		_, err = dbObj.AddUser(ctx, testUser("jane", "OWNER"))
+		assert.Equal(t, true, err != nil)
+		adminId, _ := dbObj.AddFirstUserOfType(ctx, testUser("admin", "ADMIN"))
+		assert.Equal(t, true, adminId > 0)
+
+		customers, _ := dbObj.CountUsersByType(ctx, "CUSTOMER")
+		assert.Equal(t, int64(1), customers)
+		firstAdmin, err := dbObj.AddFirstUserOfType(ctx, testUser("other", "ADMIN"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(0), firstAdmin)
+
+		detail, err := dbObj.GetUserByUsername(ctx, "john")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, userId, detail.UserId.Int64)
+		assert.Equal(t, "hash", detail.UserPassword.String)
+		assert.Equal(t, float64(5000), detail.MonthlySalary.Float64)
+		assert.Equal(t, int64(0), detail.TokenVersion.Int64)
+		missing, err := dbObj.GetUserByUsername(ctx, "jane")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, missing.UserId.Valid)
+
+		detail, _ = dbObj.GetUserById(ctx, adminId)
+		assert.Equal(t, "admin@example.com", detail.Email.String)
+		assert.Equal(t, true, detail.CreatedAt.Valid)
+
+		//every user starts with the role of its type
+		roles, _ := dbObj.GetUserRoles(ctx, userId)
+		assert.Equal(t, []string{"CUSTOMER"}, roles)
+		permissions, _ := dbObj.GetRolePermissions(ctx, []string{"CUSTOMER", "SUPPORT"})
+		assert.Equal(t, 9, len(permissions))
+
+		allRoles, _ := dbObj.GetRoles(ctx)
+		names := make([]string, 0)
+		for _, role := range allRoles {
+			names = append(names, role.Name)
+		}
+		assert.Equal(t, []string{"ADMIN", "AUDITOR", "COLLECTIONS", "CUSTOMER", "SUPPORT"}, names)
+		assert.Equal(t, []string{"loan:read:any", "user:unlock"}, allRoles[4].Permissions)
+
+		count, _ := dbObj.SetUserRoles(ctx, adminId, []string{"AUDITOR", "OWNER"})
+		assert.Equal(t, int64(0), count)
+		count, _ = dbObj.SetUserRoles(ctx, adminId, []string{"AUDITOR", "SUPPORT"})
+		assert.Equal(t, int64(2), count)
+		roles, _ = dbObj.GetUserRoles(ctx, adminId)
+		assert.Equal(t, []string{"AUDITOR", "SUPPORT"}, roles)
+		version, _ := dbObj.GetTokenVersion(ctx, adminId)
+		assert.Equal(t, int64(1), version)
+		count, _ = dbObj.SetUserRoles(ctx, adminId, []string{"SUPPORT", "AUDITOR", "SUPPORT"})
+		assert.Equal(t, int64(2), count)
+		roles, _ = dbObj.GetUserRoles(ctx, adminId)
+		assert.Equal(t, []string{"AUDITOR", "SUPPORT"}, roles)
+	})
+}
+
+func Test_Repository_Profile(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		change := func(field, oldValue, newValue string) usermanagement.ProfileChange {
+			return usermanagement.ProfileChange{
+				Field:     sql.NullString{String: field, Valid: true},
+				OldValue:  sql.NullString{String: oldValue, Valid: true},
+				NewValue:  sql.NullString{String: newValue, Valid: true},
+				ChangedBy: sql.NullInt64{Int64: userId, Valid: true},
+			}
+		}
+
+		err := dbObj.UpdateUserProfile(ctx, userId, []usermanagement.ProfileChange{change("SALARY", "5000", "6000"), change("BANK_BALANCE", "100", "250.5")})
+		assert.Equal(t, nil, err)
+		detail, _ := dbObj.GetUserById(ctx, userId)
+		assert.Equal(t, float64(6000), detail.MonthlySalary.Float64)
+		assert.Equal(t, 250.5, detail.AccountBalance.Float64)
+
+		//a change which is not valid undoes the earlier changes of the same call
+		err = dbObj.UpdateUserProfile(ctx, userId, []usermanagement.ProfileChange{change("EMAIL", "john@example.com", "new@example.com"), change("SALARY", "6000", "lots")})
+		assert.Equal(t, true, err != nil)
+		detail, _ = dbObj.GetUserById(ctx, userId)
+		assert.Equal(t, "john@example.com", detail.Email.String)
+
+		history, _ := dbObj.GetProfileHistory(ctx, userId)
+		assert.Equal(t, 2, len(history))
+		assert.Equal(t, "BANK_BALANCE", history[0].Field.String)
+		assert.Equal(t, "6000", history[1].NewValue.String)
+
+		//a declared salary only counts once an income proof is verified
+		income, _ := dbObj.GetLatestVerifiedIncome(ctx, userId)
+		assert.Equal(t, float64(0), income)
+
+		verificationId, err := dbObj.AddContactVerification(ctx, usermanagement.ContactVerification{
+			UserId:    sql.NullInt64{Int64: userId, Valid: true},
+			Channel:   sql.NullString{String: "EMAIL", Valid: true},
+			NewValue:  sql.NullString{String: "new@example.com", Valid: true},
+			OtpHash:   sql.NullString{String: "otp", Valid: true},
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(10 * time.Minute), Valid: true},
+		})
+		assert.Equal(t, nil, err)
+		other, _ := dbObj.GetContactVerification(ctx, verificationId, userId+1)
+		assert.Equal(t, false, other.VerificationId.Valid)
+		counted, err := dbObj.IncrementContactVerificationAttempts(ctx, verificationId, 2)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, counted)
+		dbObj.IncrementContactVerificationAttempts(ctx, verificationId, 2)
+		counted, _ = dbObj.IncrementContactVerificationAttempts(ctx, verificationId, 2)
+		assert.Equal(t, false, counted)
+		verification, _ := dbObj.GetContactVerification(ctx, verificationId, userId)
+		assert.Equal(t, int64(2), verification.Attempts.Int64)
+		assert.Equal(t, "PENDING", verification.Status.String)
+		assert.Equal(t, true, verification.ExpiresAt.Time.After(time.Now()))
+
+		err = dbObj.CompleteContactVerification(ctx, verification)
+		assert.Equal(t, nil, err)
+		verification, _ = dbObj.GetContactVerification(ctx, verificationId, userId)
+		assert.Equal(t, "VERIFIED", verification.Status.String)
+		detail, _ = dbObj.GetUserById(ctx, userId)
+		assert.Equal(t, "new@example.com", detail.Email.String)
+		history, _ = dbObj.GetProfileHistory(ctx, userId)
+		assert.Equal(t, "john@example.com", history[0].OldValue.String)
+
+		err = dbObj.CompleteContactVerification(ctx, verification)
+		assert.Equal(t, true, err != nil)
+	})
+}
+
+func Test_Repository_Passwords(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		version, _ := dbObj.UpdatePassword(ctx, userId, "changed")
+		assert.Equal(t, int64(1), version)
+
+		//times written in another zone still compare correctly with the clock of the database
+		zone := time.FixedZone("IST", 5*60*60+30*60)
+		resetToken := func(hash string, expiresAt time.Time) usermanagement.PasswordResetToken {
+			return usermanagement.PasswordResetToken{
+				UserId:    sql.NullInt64{Int64: userId, Valid: true},
+				TokenHash: sql.NullString{String: hash, Valid: true},
+				ExpiresAt: sql.NullTime{Time: expiresAt.In(zone), Valid: true},
+			}
+		}
+		_, err := dbObj.AddPasswordResetToken(ctx, resetToken("expired", time.Now().Add(-time.Minute)))
+		assert.Equal(t, nil, err)
+		dbObj.AddPasswordResetToken(ctx, resetToken("first", time.Now().Add(time.Hour)))
+		dbObj.AddPasswordResetToken(ctx, resetToken("second", time.Now().Add(time.Hour)))
+		_, err = dbObj.AddPasswordResetToken(ctx, resetToken("second", time.Now().Add(time.Hour)))
+		assert.Equal(t, true, usermanagement.IsUniqueViolation(err))
+
+		resetId, err := dbObj.ResetPassword(ctx, "expired", "reset")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(0), resetId)
+		resetId, _ = dbObj.ResetPassword(ctx, "first", "reset")
+		assert.Equal(t, userId, resetId)
+		detail, _ := dbObj.GetUserById(ctx, userId)
+		assert.Equal(t, "reset", detail.UserPassword.String)
+		version, _ = dbObj.GetTokenVersion(ctx, userId)
+		assert.Equal(t, int64(2), version)
+
+		//the other outstanding token is void after the reset
+		resetId, _ = dbObj.ResetPassword(ctx, "second", "again")
+		assert.Equal(t, int64(0), resetId)
+	})
+}
+
+func Test_Repository_Sessions(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		token := func(hash string, family string) usermanagement.RefreshToken {
+			return usermanagement.RefreshToken{
+				UserId:    sql.NullInt64{Int64: userId, Valid: true},
+				TokenHash: sql.NullString{String: hash, Valid: true},
+				FamilyId:  sql.NullString{String: family, Valid: true},
+				ExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+			}
+		}
+		firstId, err := dbObj.AddRefreshToken(ctx, token("first", "family"))
+		assert.Equal(t, nil, err)
+		dbObj.AddRefreshToken(ctx, token("second", "family"))
+
+		//the successor clashes with an existing token, so retiring the old token is undone
+		rotated, err := dbObj.RotateRefreshToken(ctx, firstId, token("second", "family"))
+		assert.Equal(t, false, rotated)
+		assert.Equal(t, true, usermanagement.IsUniqueViolation(err))
+		first, _ := dbObj.GetRefreshToken(ctx, "first")
+		assert.Equal(t, "ACTIVE", first.Status.String)
+
+		rotated, _ = dbObj.RotateRefreshToken(ctx, firstId, token("third", "family"))
+		assert.Equal(t, true, rotated)
+		rotated, _ = dbObj.RotateRefreshToken(ctx, firstId, token("fourth", "family"))
+		assert.Equal(t, false, rotated)
+		first, _ = dbObj.GetRefreshToken(ctx, "first")
+		assert.Equal(t, "ROTATED", first.Status.String)
+		assert.Equal(t, true, first.RotatedAt.Valid)
+
+		dbObj.AddRefreshToken(ctx, token("other", "other-family"))
+		err = dbObj.RevokeRefreshTokenFamily(ctx, "family")
+		assert.Equal(t, nil, err)
+		third, _ := dbObj.GetRefreshToken(ctx, "third")
+		assert.Equal(t, "REVOKED", third.Status.String)
+		other, _ := dbObj.GetRefreshToken(ctx, "other")
+		assert.Equal(t, "ACTIVE", other.Status.String)
+
+		//a password change ends every session of the user
+		dbObj.UpdatePassword(ctx, userId, "changed")
+		other, _ = dbObj.GetRefreshToken(ctx, "other")
+		assert.Equal(t, "REVOKED", other.Status.String)
+		missing, _ := dbObj.GetRefreshToken(ctx, "missing")
+		assert.Equal(t, false, missing.TokenId.Valid)
+
+		err = dbObj.RevokeAccessToken(ctx, "jti", userId, time.Now().Add(time.Hour))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, nil, dbObj.RevokeAccessToken(ctx, "jti", userId, time.Now().Add(time.Hour)))
+		revoked, _ := dbObj.IsAccessTokenRevoked(ctx, "jti")
+		assert.Equal(t, true, revoked)
+		revoked, _ = dbObj.IsAccessTokenRevoked(ctx, "other")
+		assert.Equal(t, false, revoked)
+	})
+}
+
+func Test_Repository_AdminInvites(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		adminId, _ := dbObj.AddUser(ctx, testUser("admin", "ADMIN"))
+		invite := func(hash string, expiresAt time.Time) usermanagement.AdminInvite {
+			return usermanagement.AdminInvite{
+				CodeHash:  sql.NullString{String: hash, Valid: true},
+				Email:     sql.NullString{String: "jane@example.com", Valid: true},
+				CreatedBy: sql.NullInt64{Int64: adminId, Valid: true},
+				ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+			}
+		}
+		_, err := dbObj.AddAdminInvite(ctx, invite("expired", time.Now().Add(-time.Minute)))
+		assert.Equal(t, nil, err)
+		dbObj.AddAdminInvite(ctx, invite("code", time.Now().Add(time.Hour)))
+		_, err = dbObj.AddAdminInvite(ctx, invite("code", time.Now().Add(time.Hour)))
+		assert.Equal(t, true, usermanagement.IsUniqueViolation(err))
+
+		userId, _ := dbObj.RedeemAdminInvite(ctx, "expired", testUser("jane", "ADMIN"))
+		assert.Equal(t, int64(0), userId)
+		wrongEmail := testUser("jane", "ADMIN")
+		wrongEmail.Email = sql.NullString{String: "other@example.com", Valid: true}
+		userId, _ = dbObj.RedeemAdminInvite(ctx, "code", wrongEmail)
+		assert.Equal(t, int64(0), userId)
+
+		//a taken username leaves the invite usable
+		_, err = dbObj.RedeemAdminInvite(ctx, "code", func() usermanagement.UserDetails {
+			taken := testUser("admin", "ADMIN")
+			taken.Email = sql.NullString{String: "jane@example.com", Valid: true}
+			return taken
+		}())
+		assert.Equal(t, true, usermanagement.IsUniqueViolation(err))
+		userId, err = dbObj.RedeemAdminInvite(ctx, "code", testUser("jane", "ADMIN"))
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), userId)
+		roles, _ := dbObj.GetUserRoles(ctx, userId)
+		assert.Equal(t, []string{"ADMIN"}, roles)
+
+		userId, _ = dbObj.RedeemAdminInvite(ctx, "code", testUser("jack", "ADMIN"))
+		assert.Equal(t, int64(0), userId)
+	})
+}
+
+func Test_Repository_LoginThrottle(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		now := time.Now()
+		throttle, err := dbObj.AddLoginFailure(ctx, "user:john", now.Add(-time.Hour), now.Add(-2*time.Hour))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(1), throttle.Failures.Int64)
+		throttle, _ = dbObj.AddLoginFailure(ctx, "user:john", now, now.Add(-2*time.Hour))
+		assert.Equal(t, int64(2), throttle.Failures.Int64)
+		assert.Equal(t, now.Unix(), throttle.LastFailureAt.Time.Unix())
+		assert.Equal(t, false, throttle.LockedUntil.Valid)
+
+		//failures before the window are forgotten
+		throttle, _ = dbObj.AddLoginFailure(ctx, "user:john", now.Add(time.Hour), now.Add(time.Minute))
+		assert.Equal(t, int64(1), throttle.Failures.Int64)
+
+		err = dbObj.LockLogin(ctx, "user:john", now.Add(time.Hour))
+		assert.Equal(t, nil, err)
+		throttles, _ := dbObj.GetLoginThrottles(ctx, []string{"user:john", "ip:127.0.0.1"})
+		assert.Equal(t, 1, len(throttles))
+		assert.Equal(t, int64(0), throttles[0].Failures.Int64)
+		assert.Equal(t, now.Add(time.Hour).Unix(), throttles[0].LockedUntil.Time.Unix())
+
+		cleared, _ := dbObj.ClearLoginFailures(ctx, []string{"user:john", "ip:127.0.0.1"})
+		assert.Equal(t, int64(1), cleared)
+		throttles, _ = dbObj.GetLoginThrottles(ctx, []string{"user:john"})
+		assert.Equal(t, 0, len(throttles))
+	})
+}
+
+func Test_Repository_Totp(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, _ := dbObj.AddUser(ctx, testUser("admin", "ADMIN"))
+		saved, err := dbObj.SaveTotpSecret(ctx, userId, "first")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, saved)
+		saved, _ = dbObj.SaveTotpSecret(ctx, userId, "second")
+		assert.Equal(t, true, saved)
+		totp, _ := dbObj.GetTotp(ctx, userId)
+		assert.Equal(t, "second", totp.Secret.String)
+		assert.Equal(t, false, totp.Enabled.Bool)
+
+		err = dbObj.EnableTotp(ctx, userId, 100, []string{"code-1", "code-2"})
+		assert.Equal(t, nil, err)
+		totp, _ = dbObj.GetTotp(ctx, userId)
+		assert.Equal(t, true, totp.Enabled.Bool)
+		assert.Equal(t, int64(100), totp.LastUsedStep.Int64)
+		saved, _ = dbObj.SaveTotpSecret(ctx, userId, "third")
+		assert.Equal(t, false, saved)
+
+		used, _ := dbObj.UseTotpStep(ctx, userId, 100)
+		assert.Equal(t, false, used)
+		used, _ = dbObj.UseTotpStep(ctx, userId, 101)
+		assert.Equal(t, true, used)
+		used, _ = dbObj.UseRecoveryCode(ctx, userId, "code-1")
+		assert.Equal(t, true, used)
+		used, _ = dbObj.UseRecoveryCode(ctx, userId, "code-1")
+		assert.Equal(t, false, used)
+
+		challengeId, err := dbObj.AddLoginChallenge(ctx, usermanagement.LoginChallenge{
+			UserId:    sql.NullInt64{Int64: userId, Valid: true},
+			TokenHash: sql.NullString{String: "challenge", Valid: true},
+			ExpiresAt: sql.NullTime{Time: time.Now().Add(5 * time.Minute), Valid: true},
+		})
+		assert.Equal(t, nil, err)
+		dbObj.IncrementLoginChallengeAttempts(ctx, challengeId)
+		challenge, _ := dbObj.GetLoginChallenge(ctx, "challenge")
+		assert.Equal(t, challengeId, challenge.ChallengeId.Int64)
+		assert.Equal(t, int64(1), challenge.Attempts.Int64)
+		assert.Equal(t, false, challenge.UsedAt.Valid)
+
+		completed, _ := dbObj.CompleteLoginChallenge(ctx, challengeId)
+		assert.Equal(t, true, completed)
+		completed, _ = dbObj.CompleteLoginChallenge(ctx, challengeId)
+		assert.Equal(t, false, completed)
+	})
+}
+
+func Test_Repository_SigningKeys(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		now := time.Now()
+		key := func(kid string, createdAt time.Time) usermanagement.SigningKey {
+			return usermanagement.SigningKey{
+				KeyId:      sql.NullString{String: kid, Valid: true},
+				Algorithm:  sql.NullString{String: "RS256", Valid: true},
+				PrivateKey: sql.NullString{String: "pem", Valid: true},
+				CreatedAt:  sql.NullTime{Time: createdAt, Valid: true},
+			}
+		}
+		added, err := dbObj.AddSigningKey(ctx, key("old", now.Add(-48*time.Hour)), now.Add(-24*time.Hour))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, added)
+		added, _ = dbObj.AddSigningKey(ctx, key("new", now), now.Add(-24*time.Hour))
+		assert.Equal(t, true, added)
+
+		//another instance rotated within the interval, so the key is not added
+		added, _ = dbObj.AddSigningKey(ctx, key("late", now), now.Add(-24*time.Hour))
+		assert.Equal(t, false, added)
+
+		keys, _ := dbObj.GetSigningKeys(ctx)
+		assert.Equal(t, 2, len(keys))
+		assert.Equal(t, "new", keys[0].KeyId.String)
+		assert.Equal(t, now.Unix(), keys[0].CreatedAt.Time.Unix())
+	})
+}
+
+func Test_Repository_ServiceAccounts(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		adminId, _ := dbObj.AddUser(ctx, testUser("admin", "ADMIN"))
+		account := usermanagement.ServiceAccount{
+			Name:        sql.NullString{String: "collections", Valid: true},
+			Description: sql.NullString{String: "records repayments", Valid: true},
+			CreatedBy:   sql.NullInt64{Int64: adminId, Valid: true},
+		}
+		accountId, err := dbObj.AddServiceAccount(ctx, account)
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), accountId)
+		duplicateId, _ := dbObj.AddServiceAccount(ctx, account)
+		assert.Equal(t, int64(0), duplicateId)
+		accounts, _ := dbObj.GetServiceAccounts(ctx)
+		assert.Equal(t, 1, len(accounts))
+
+		apiKey := func(prefix string, scopes ...string) usermanagement.APIKey {
+			return usermanagement.APIKey{
+				ServiceAccountId: sql.NullInt64{Int64: accountId, Valid: true},
+				Prefix:           sql.NullString{String: prefix, Valid: true},
+				KeyHash:          sql.NullString{String: prefix + "-hash", Valid: true},
+				Scopes:           scopes,
+				ExpiresAt:        sql.NullTime{Time: time.Now().Add(24 * time.Hour), Valid: true},
+				CreatedBy:        sql.NullInt64{Int64: adminId, Valid: true},
+			}
+		}
+		keyId, _ := dbObj.AddAPIKey(ctx, apiKey("unknown", "payment:create:any", "loan:fly"))
+		assert.Equal(t, int64(0), keyId)
+		keyId, err = dbObj.AddAPIKey(ctx, apiKey("valid", "payment:create:any", "loan:read:any"))
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), keyId)
+
+		keys, _ := dbObj.GetAPIKeys(ctx)
+		assert.Equal(t, 1, len(keys))
+		assert.Equal(t, "collections", keys[0].ServiceAccount.String)
+		assert.Equal(t, false, keys[0].KeyHash.Valid)
+		assert.Equal(t, []string{"loan:read:any", "payment:create:any"}, keys[0].Scopes)
+
+		usedAt := time.Now()
+		err = dbObj.TouchAPIKey(ctx, keyId, usedAt, usedAt.Add(-time.Minute))
+		assert.Equal(t, nil, err)
+		dbObj.TouchAPIKey(ctx, keyId, usedAt.Add(time.Second), usedAt.Add(-time.Minute))
+		key, _ := dbObj.GetAPIKey(ctx, "valid-hash")
+		assert.Equal(t, keyId, key.KeyId.Int64)
+		assert.Equal(t, usedAt.Unix(), key.LastUsedAt.Time.Unix())
+
+		revoked, _ := dbObj.RevokeAPIKey(ctx, keyId)
+		assert.Equal(t, true, revoked)
+		revoked, _ = dbObj.RevokeAPIKey(ctx, keyId)
+		assert.Equal(t, false, revoked)
+		key, _ = dbObj.GetAPIKey(ctx, "valid-hash")
+		assert.Equal(t, true, key.RevokedAt.Valid)
+	})
+}
+
+func Test_Repository_AuditLog(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		head, err := dbObj.GetLastAuditEntry(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, head.Hash.Valid)
+
+		start := time.Date(2026, 1, 1, 10, 0, 0, 123456000, time.UTC)
+		entry := func(prevHash string, hash string, actorId int64, action string, minutes int) audit.AuditEntry {
+			return audit.AuditEntry{
+				ActorType:   sql.NullString{String: "ADMIN", Valid: true},
+				ActorId:     sql.NullInt64{Int64: actorId, Valid: true},
+				Actor:       sql.NullString{String: "admin", Valid: true},
+				Roles:       sql.NullString{String: "ADMIN", Valid: true},
+				Action:      sql.NullString{String: action, Valid: true},
+				Outcome:     sql.NullString{String: "SUCCESS", Valid: true},
+				TargetType:  sql.NullString{String: "loan", Valid: true},
+				TargetId:    sql.NullString{String: "3", Valid: true},
+				BeforeState: sql.NullString{String: `{"status":"PENDING"}`, Valid: true},
+				AfterState:  sql.NullString{String: `{"status":"APPROVED"}`, Valid: true},
+				RequestId:   sql.NullString{String: "req-1", Valid: true},
+				Ip:          sql.NullString{String: "10.0.0.1", Valid: true},
+				CreatedAt:   sql.NullTime{Time: start.Add(time.Duration(minutes) * time.Minute), Valid: true},
+				PrevHash:    sql.NullString{String: prevHash, Valid: true},
+				Hash:        sql.NullString{String: hash, Valid: true},
+			}
+		}
+		firstId, err := dbObj.AddAuditEntry(ctx, entry("genesis", "a", 1, "loan.decision", 0))
+		assert.Equal(t, nil, err)
+		dbObj.AddAuditEntry(ctx, entry("a", "b", 2, "loan.repay", 1))
+		dbObj.AddAuditEntry(ctx, entry("b", "c", 1, "loan.decision", 2))
+
+		//a second entry linking to the same head is refused, so the chain cannot fork
+		_, err = dbObj.AddAuditEntry(ctx, entry("b", "d", 1, "loan.decision", 3))
+		assert.Equal(t, true, usermanagement.IsUniqueViolation(err))
+
+		head, _ = dbObj.GetLastAuditEntry(ctx)
+		assert.Equal(t, "c", head.Hash.String)
+		assert.Equal(t, "b", head.PrevHash.String)
+
+		entries, _ := dbObj.GetAuditEntries(ctx, audit.AuditFilter{Limit: 10})
+		assert.Equal(t, 3, len(entries))
+		assert.Equal(t, firstId, entries[0].EntryId.Int64)
+		assert.Equal(t, `{"status":"APPROVED"}`, entries[0].AfterState.String)
+		//the hash covers the timestamp, which has to read back as written
+		assert.Equal(t, true, entries[0].CreatedAt.Time.Equal(start))
+
+		filtered, _ := dbObj.GetAuditEntries(ctx, audit.AuditFilter{ActorId: 1, Action: "loan.decision", TargetType: "loan", TargetId: "3", Limit: 10})
+		assert.Equal(t, 2, len(filtered))
+		filtered, _ = dbObj.GetAuditEntries(ctx, audit.AuditFilter{From: start.Add(time.Minute), To: start.Add(2 * time.Minute), Limit: 10})
+		assert.Equal(t, 1, len(filtered))
+		assert.Equal(t, "loan.repay", filtered[0].Action.String)
+		filtered, _ = dbObj.GetAuditEntries(ctx, audit.AuditFilter{AfterId: firstId, Limit: 1})
+		assert.Equal(t, 1, len(filtered))
+		assert.Equal(t, "b", filtered[0].Hash.String)
+	})
+}
+
+func Test_Repository_Documents(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		adminId, _ := dbObj.AddUser(ctx, testUser("admin", "ADMIN"))
+		upload := func(docType string, key string) document.DocumentDetails {
+			return document.DocumentDetails{
+				UserId:      sql.NullInt64{Int64: userId, Valid: true},
+				DocType:     sql.NullString{String: docType, Valid: true},
+				FileName:    sql.NullString{String: "proof.pdf", Valid: true},
+				ContentType: sql.NullString{String: "application/pdf", Valid: true},
+				StorageKey:  sql.NullString{String: key, Valid: true},
+				Size:        sql.NullInt64{Int64: 1024, Valid: true},
+			}
+		}
+		incomeId, err := dbObj.AddDocument(ctx, upload("INCOME_PROOF", "1/income.pdf"))
+		assert.Equal(t, nil, err)
+		idId, _ := dbObj.AddDocument(ctx, upload("ID_PROOF", "1/id.pdf"))
+		_, err = dbObj.AddDocument(ctx, upload("PAYSLIP", "1/payslip.pdf"))
+		assert.Equal(t, true, err != nil)
+
+		documents, _ := dbObj.GetUserDocuments(ctx, userId)
+		assert.Equal(t, 2, len(documents))
+		assert.Equal(t, "PENDING", documents[0].Status.String)
+		pending, _ := dbObj.GetPendingDocuments(ctx)
+		assert.Equal(t, 2, len(pending))
+		assert.Equal(t, "john", pending[0].UserName.String)
+
+		//verifying the income proof verifies the salary declared at signup, not one declared after the upload
+		raise := usermanagement.ProfileChange{
+			Field:    sql.NullString{String: "SALARY", Valid: true},
+			OldValue: sql.NullString{String: "5000", Valid: true},
+			NewValue: sql.NullString{String: "90000", Valid: true},
+		}
+		assert.Equal(t, nil, dbObj.UpdateUserProfile(ctx, userId, []usermanagement.ProfileChange{raise}))
+		updatedId, _ := dbObj.UpdateDocumentStatus(ctx, incomeId, "VERIFIED", adminId, "")
+		assert.Equal(t, incomeId, updatedId)
+		updatedId, _ = dbObj.UpdateDocumentStatus(ctx, incomeId, "REJECTED", adminId, "")
+		assert.Equal(t, int64(0), updatedId)
+		income, _ := dbObj.GetLatestVerifiedIncome(ctx, userId)
+		assert.Equal(t, float64(5000), income)
+		dbObj.UpdateDocumentStatus(ctx, idId, "REJECTED", adminId, "blurred")
+
+		detail, _ := dbObj.GetDocument(ctx, idId)
+		assert.Equal(t, "REJECTED", detail.Status.String)
+		assert.Equal(t, "blurred", detail.Remarks.String)
+		assert.Equal(t, adminId, detail.VerifiedBy.Int64)
+		_, err = dbObj.GetDocument(ctx, idId+10)
+		assert.Equal(t, true, errors.Is(err, sql.ErrNoRows))
+
+		docTypes, _ := dbObj.GetVerifiedDocumentTypes(ctx, userId)
+		assert.Equal(t, []string{"INCOME_PROOF"}, docTypes)
+	})
+}
+
+func Test_Repository_LoanLifecycle(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		otherId, _ := dbObj.AddUser(ctx, testUser("jane", "CUSTOMER"))
+
+		applied := func(userId int64) loan.StatusChange {
+			return loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId, Reason: "applied for loan"}
+		}
+		loanId, err := dbObj.CreateLoan(ctx, userId, 3000, 3, applied(userId), nil)
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), loanId)
+		changedId, _ := dbObj.ModifyLoan(ctx, otherId, loanId, 4500, 3, nil)
+		assert.Equal(t, int64(0), changedId)
+		changedId, _ = dbObj.ModifyLoan(ctx, userId, loanId, 4500, 3, nil)
+		assert.Equal(t, loanId, changedId)
+
+		cancelledId, _ := dbObj.CreateLoan(ctx, userId, 1000, 1, applied(userId), nil)
+		err = dbObj.UpdateLoanStatus(ctx, cancelledId, loan.StatusChange{From: "PENDING", To: "CANCELLED", Actor: "CUSTOMER", ActorId: userId}, nil)
+		assert.Equal(t, nil, err)
+		changedId, _ = dbObj.ModifyLoan(ctx, userId, cancelledId, 2000, 2, nil)
+		assert.Equal(t, int64(0), changedId)
+
+		rejectedId, _ := dbObj.CreateLoan(ctx, otherId, 1000, 1, applied(otherId), nil)
+		err = dbObj.UpdateLoanStatus(ctx, rejectedId, loan.StatusChange{From: "PENDING", To: "REJECTED", Actor: "ADMIN", Reason: "income too low"}, nil)
+		assert.Equal(t, nil, err)
+		//a status write only applies from the status it expects
+		err = dbObj.UpdateLoanStatus(ctx, rejectedId, loan.StatusChange{From: "PENDING", To: "APPROVED", Actor: "ADMIN"}, nil)
+		assert.Equal(t, loan.ErrStatusChanged, err)
+
+		unapproved, _ := dbObj.GetUnapprovedLoans(ctx)
+		assert.Equal(t, 1, len(unapproved))
+		assert.Equal(t, "john", unapproved[0].UserName.String)
+		assert.Equal(t, float64(4500), unapproved[0].Amount.Float64)
+
+		detail, err := dbObj.FetchLoanDetails(ctx, loanId)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "PENDING", detail.Status.String)
+		assert.Equal(t, int64(3), detail.Tenure.Int64)
+		assert.Equal(t, "PERSONAL", detail.Product.String)
+		_, err = dbObj.FetchLoanDetails(ctx, loanId+10)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		approval := loan.StatusChange{From: "PENDING", To: "APPROVED", Actor: "ADMIN", ActorId: otherId}
+		err = dbObj.UpdateAndInsertInstallments(ctx, loanId, approval, 1500, 3, nil)
+		assert.Equal(t, nil, err)
+		//approving twice neither succeeds nor adds installments
+		err = dbObj.UpdateAndInsertInstallments(ctx, loanId, approval, 1500, 3, nil)
+		assert.Equal(t, loan.ErrStatusChanged, err)
+
+		installments, _ := dbObj.GetUserLoanInstallments(ctx, userId, loanId)
+		assert.Equal(t, 3, len(installments))
+		assert.Equal(t, "APPROVED", installments[0].LoanStatus.String)
+		assert.Equal(t, int64(1), installments[0].InstallmentSeq.Int64)
+		assert.Equal(t, 7*24*time.Hour, installments[1].DueDate.Time.Sub(installments[0].DueDate.Time).Round(time.Hour))
+		otherInstallments, _ := dbObj.GetUserLoanInstallments(ctx, otherId, loanId)
+		assert.Equal(t, 0, len(otherInstallments))
+
+		//a failed payment is rolled back, so the next write is not blocked by an open transaction
+		invalid := installments[0]
+		invalid.Status = sql.NullString{String: "LOST", Valid: true}
+		err = dbObj.UpdateSingleInstallmentPayment(ctx, loanId, invalid, loan.StatusChange{}, nil)
+		assert.Equal(t, true, err != nil)
+
+		paid := installments[0]
+		paid.AmountPaid = sql.NullFloat64{Float64: 1500, Valid: true}
+		paid.Status = sql.NullString{String: "PAID", Valid: true}
+		paid.TransactionId = sql.NullString{String: "txn-1", Valid: true}
+		err = dbObj.UpdateSingleInstallmentPayment(ctx, loanId, paid, loan.StatusChange{}, nil)
+		assert.Equal(t, nil, err)
+		//a concurrent payment of the same installment read it PENDING too, it loses instead of overwriting the first
+		paid.TransactionId = sql.NullString{String: "txn-2", Valid: true}
+		err = dbObj.UpdateSingleInstallmentPayment(ctx, loanId, paid, loan.StatusChange{}, nil)
+		assert.Equal(t, loan.ErrInstallmentChanged, err)
+		//a transaction pays one installment only
+		replayed := installments[1]
+		replayed.AmountPaid = sql.NullFloat64{Float64: 1500, Valid: true}
+		replayed.Status = sql.NullString{String: "PAID", Valid: true}
+		replayed.TransactionId = sql.NullString{String: "txn-1", Valid: true}
+		err = dbObj.UpdateSingleInstallmentPayment(ctx, loanId, replayed, loan.StatusChange{}, nil)
+		assert.Equal(t, loan.ErrDuplicateTransaction, err)
+
+		//an invalid installment undoes the whole payment
+		closure := loan.StatusChange{From: "APPROVED", To: "PAID", Actor: "SYSTEM"}
+		rest := installments[1:]
+		rest[0].AmountPaid = sql.NullFloat64{Float64: 3000, Valid: true}
+		rest[0].Status = sql.NullString{String: "PAID", Valid: true}
+		rest[1].Status = sql.NullString{String: "LOST", Valid: true}
+		err = dbObj.UpdateInstallment(ctx, loanId, []loan.InstallmentDetails{rest[0], rest[1]}, closure, nil)
+		assert.Equal(t, true, err != nil)
+		detail, _ = dbObj.FetchLoanDetails(ctx, loanId)
+		assert.Equal(t, "APPROVED", detail.Status.String)
+
+		rest[1].Status = sql.NullString{String: "CANCELLED", Valid: true}
+		err = dbObj.UpdateInstallment(ctx, loanId, []loan.InstallmentDetails{rest[0], rest[1]}, closure, nil)
+		assert.Equal(t, nil, err)
+
+		detail, _ = dbObj.FetchLoanDetails(ctx, loanId)
+		assert.Equal(t, "PAID", detail.Status.String)
+		installments, _ = dbObj.GetUserLoanInstallments(ctx, userId, loanId)
+		statuses := make([]string, 0)
+		for _, installment := range installments {
+			statuses = append(statuses, installment.Status.String)
+		}
+		assert.Equal(t, []string{"PAID", "PAID", "CANCELLED"}, statuses)
+		assert.Equal(t, "txn-1", installments[0].TransactionId.String)
+
+		loans, _ := dbObj.GetUserLoans(ctx, userId)
+		assert.Equal(t, 2, len(loans))
+
+		//every status change is in the history once, failed writes leave nothing behind
+		history, err := dbObj.GetLoanStatusHistory(ctx, loanId)
+		assert.Equal(t, nil, err)
+		transitions := make([]string, 0)
+		for _, entry := range history {
+			transitions = append(transitions, entry.FromStatus.String+">"+entry.ToStatus.String)
+		}
+		assert.Equal(t, []string{">PENDING", "PENDING>APPROVED", "APPROVED>PAID"}, transitions)
+		assert.Equal(t, false, history[0].FromStatus.Valid)
+		assert.Equal(t, "CUSTOMER", history[0].Actor.String)
+		assert.Equal(t, userId, history[0].ActorId.Int64)
+		assert.Equal(t, "applied for loan", history[0].Reason.String)
+		assert.Equal(t, false, history[2].ActorId.Valid)
+		assert.Equal(t, true, history[0].CreatedAt.Valid)
+		rejected, _ := dbObj.GetLoanStatusHistory(ctx, rejectedId)
+		assert.Equal(t, 2, len(rejected))
+	})
+}
+
+func Test_Repository_Outbox(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		applied := loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}
+		event := func(eventType string, loanId int64) outbox.Event {
+			return outbox.Event{EventType: eventType, AggregateType: "loan", AggregateId: loanId, Payload: `{"userId":1}`}
+		}
+		eventTypes := func(events []outbox.OutboxEvent) []string {
+			types := make([]string, 0)
+			for _, event := range events {
+				types = append(types, event.EventType.String)
+			}
+			return types
+		}
+
+		//a new loan is the aggregate of its events
+		loanId, err := dbObj.CreateLoan(ctx, userId, 3000, 3, applied, []outbox.Event{event("loan.applied", 0)})
+		assert.Equal(t, nil, err)
+		otherId, _ := dbObj.CreateLoan(ctx, userId, 1000, 1, applied, []outbox.Event{event("loan.applied", 0)})
+		//events are kept only with the change they announce
+		changedId, _ := dbObj.ModifyLoan(ctx, userId+10, loanId, 4500, 3, []outbox.Event{event("loan.modified", loanId)})
+		assert.Equal(t, int64(0), changedId)
+		err = dbObj.UpdateLoanStatus(ctx, loanId, loan.StatusChange{From: "APPROVED", To: "PAID", Actor: "SYSTEM"}, []outbox.Event{event("loan.closed", loanId)})
+		assert.Equal(t, loan.ErrStatusChanged, err)
+		changedId, _ = dbObj.ModifyLoan(ctx, userId, loanId, 4500, 3, []outbox.Event{event("loan.modified", loanId)})
+		assert.Equal(t, loanId, changedId)
+
+		now := time.Now()
+		//only the oldest pending event of a loan is due
+		due, err := dbObj.GetDueOutboxEvents(ctx, now, 10)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []string{"loan.applied", "loan.applied"}, eventTypes(due))
+		assert.Equal(t, loanId, due[0].AggregateId.Int64)
+		assert.Equal(t, otherId, due[1].AggregateId.Int64)
+		assert.Equal(t, `{"userId":1}`, due[0].Payload.String)
+		assert.Equal(t, "PENDING", due[0].Status.String)
+		assert.Equal(t, int64(0), due[0].Attempts.Int64)
+		limited, _ := dbObj.GetDueOutboxEvents(ctx, now, 1)
+		assert.Equal(t, 1, len(limited))
+
+		//an event is claimed by one relay at a time
+		claimed, err := dbObj.ClaimOutboxEvent(ctx, due[0].EventId.Int64, 0, now.Add(time.Minute))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, claimed)
+		claimed, _ = dbObj.ClaimOutboxEvent(ctx, due[0].EventId.Int64, 0, now.Add(time.Minute))
+		assert.Equal(t, false, claimed)
+		due, _ = dbObj.GetDueOutboxEvents(ctx, now, 10)
+		assert.Equal(t, 1, len(due))
+		assert.Equal(t, otherId, due[0].AggregateId.Int64)
+
+		//a failed delivery waits for its retry and holds the later events of its loan
+		first, _ := dbObj.GetDueOutboxEvents(ctx, now.Add(time.Minute), 10)
+		assert.Equal(t, loanId, first[0].AggregateId.Int64)
+		assert.Equal(t, int64(1), first[0].Attempts.Int64)
+		err = dbObj.RetryOutboxEvent(ctx, first[0].EventId.Int64, now.Add(time.Hour), "sink down")
+		assert.Equal(t, nil, err)
+		due, _ = dbObj.GetDueOutboxEvents(ctx, now.Add(time.Minute), 10)
+		assert.Equal(t, 1, len(due))
+		assert.Equal(t, otherId, due[0].AggregateId.Int64)
+		later, _ := dbObj.GetDueOutboxEvents(ctx, now.Add(2*time.Hour), 10)
+		assert.Equal(t, "sink down", later[0].LastError.String)
+
+		//once it gives up on an event the next one of the loan is due
+		err = dbObj.FailOutboxEvent(ctx, first[0].EventId.Int64, "sink down")
+		assert.Equal(t, nil, err)
+		err = dbObj.MarkOutboxEventDelivered(ctx, due[0].EventId.Int64, now)
+		assert.Equal(t, nil, err)
+		due, _ = dbObj.GetDueOutboxEvents(ctx, now, 10)
+		assert.Equal(t, []string{"loan.modified"}, eventTypes(due))
+		assert.Equal(t, loanId, due[0].AggregateId.Int64)
+
+		//failed and delivered events stay as they are
+		claimed, _ = dbObj.ClaimOutboxEvent(ctx, first[0].EventId.Int64, 1, now.Add(time.Minute))
+		assert.Equal(t, false, claimed)
+		err = dbObj.RetryOutboxEvent(ctx, first[0].EventId.Int64, now, "again")
+		assert.Equal(t, nil, err)
+		due, _ = dbObj.GetDueOutboxEvents(ctx, now.Add(2*time.Hour), 10)
+		assert.Equal(t, 1, len(due))
+	})
+}
+
+func Test_Repository_Webhooks(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		adminId, _ := dbObj.AddUser(ctx, testUser("admin", "ADMIN"))
+		subscription := func(url string, active bool, eventTypes ...string) webhook.WebhookSubscription {
+			return webhook.WebhookSubscription{
+				Url:        sql.NullString{String: url, Valid: true},
+				EventTypes: eventTypes,
+				Secret:     sql.NullString{String: "sealed", Valid: true},
+				Active:     sql.NullBool{Bool: active, Valid: true},
+				CreatedBy:  sql.NullInt64{Int64: adminId, Valid: true},
+			}
+		}
+		delivery := func(subscriptionId int64, eventId int64) webhook.WebhookDelivery {
+			return webhook.WebhookDelivery{
+				SubscriptionId: sql.NullInt64{Int64: subscriptionId, Valid: true},
+				EventId:        sql.NullInt64{Int64: eventId, Valid: true},
+				EventType:      sql.NullString{String: "loan.approved", Valid: true},
+				Body:           sql.NullString{String: `{"eventId":1}`, Valid: true},
+			}
+		}
+
+		crmId, err := dbObj.AddWebhookSubscription(ctx, subscription("https://crm.example.com/hook", true, "loan.approved", "loan.closed"))
+		assert.Equal(t, nil, err)
+		pausedId, _ := dbObj.AddWebhookSubscription(ctx, subscription("https://books.example.com/hook", false, "loan.approved"))
+		subscriptions, err := dbObj.GetWebhookSubscriptions(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(subscriptions))
+		assert.Equal(t, []string{"loan.approved", "loan.closed"}, subscriptions[0].EventTypes)
+		assert.Equal(t, true, subscriptions[0].Active.Bool)
+		assert.Equal(t, false, subscriptions[1].Active.Bool)
+
+		stored, err := dbObj.GetWebhookSubscription(ctx, crmId)
+		assert.Equal(t, nil, err)
+		stored.EventTypes = []string{"loan.closed"}
+		stored.Description = sql.NullString{String: "crm", Valid: true}
+		updatedId, err := dbObj.UpdateWebhookSubscription(ctx, stored)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, crmId, updatedId)
+		stored, _ = dbObj.GetWebhookSubscription(ctx, crmId)
+		assert.Equal(t, []string{"loan.closed"}, stored.EventTypes)
+		assert.Equal(t, "crm", stored.Description.String)
+		_, err = dbObj.GetWebhookSubscription(ctx, pausedId+10)
+		assert.Equal(t, true, errors.Is(err, sql.ErrNoRows))
+		stored.SubscriptionId = sql.NullInt64{Int64: pausedId + 10, Valid: true}
+		updatedId, _ = dbObj.UpdateWebhookSubscription(ctx, stored)
+		assert.Equal(t, int64(0), updatedId)
+
+		//an event is queued once per subscription
+		err = dbObj.AddWebhookDeliveries(ctx, []webhook.WebhookDelivery{delivery(crmId, 1), delivery(pausedId, 1)})
+		assert.Equal(t, nil, err)
+		err = dbObj.AddWebhookDeliveries(ctx, []webhook.WebhookDelivery{delivery(crmId, 1), delivery(crmId, 2)})
+		assert.Equal(t, nil, err)
+		all, _ := dbObj.GetWebhookDeliveries(ctx, webhook.DeliveryFilter{Limit: 10})
+		assert.Equal(t, 3, len(all))
+
+		//deliveries of a paused subscription wait
+		now := time.Now()
+		due, err := dbObj.GetDueWebhookDeliveries(ctx, now, 10)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(due))
+		assert.Equal(t, crmId, due[0].SubscriptionId.Int64)
+		assert.Equal(t, int64(1), due[0].EventId.Int64)
+		assert.Equal(t, `{"eventId":1}`, due[0].Body.String)
+		assert.Equal(t, "PENDING", due[0].Status.String)
+		limited, _ := dbObj.GetDueWebhookDeliveries(ctx, now, 1)
+		assert.Equal(t, 1, len(limited))
+
+		//a delivery is claimed by one dispatcher at a time
+		first, second := due[0].DeliveryId.Int64, due[1].DeliveryId.Int64
+		claimed, err := dbObj.ClaimWebhookDelivery(ctx, first, 0, now.Add(time.Minute))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, claimed)
+		claimed, _ = dbObj.ClaimWebhookDelivery(ctx, first, 0, now.Add(time.Minute))
+		assert.Equal(t, false, claimed)
+		due, _ = dbObj.GetDueWebhookDeliveries(ctx, now, 10)
+		assert.Equal(t, 1, len(due))
+		assert.Equal(t, second, due[0].DeliveryId.Int64)
+
+		//a failed attempt waits for its retry and a delivery out of attempts is dead
+		err = dbObj.RetryWebhookDelivery(ctx, first, now.Add(time.Hour), 500, "answered 500")
+		assert.Equal(t, nil, err)
+		due, _ = dbObj.GetDueWebhookDeliveries(ctx, now.Add(2*time.Hour), 10)
+		assert.Equal(t, 2, len(due))
+		assert.Equal(t, int64(1), due[0].Attempts.Int64)
+		assert.Equal(t, int64(500), due[0].ResponseCode.Int64)
+		assert.Equal(t, "answered 500", due[0].LastError.String)
+		err = dbObj.DeadLetterWebhookDelivery(ctx, first, 0, "timeout")
+		assert.Equal(t, nil, err)
+		err = dbObj.MarkWebhookDeliveryDelivered(ctx, second, 204, now)
+		assert.Equal(t, nil, err)
+		due, _ = dbObj.GetDueWebhookDeliveries(ctx, now.Add(2*time.Hour), 10)
+		assert.Equal(t, 0, len(due))
+
+		//the delivery log is filtered and paged in id order
+		dead, err := dbObj.GetWebhookDeliveries(ctx, webhook.DeliveryFilter{Status: "DEAD", Limit: 10})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(dead))
+		assert.Equal(t, "timeout", dead[0].LastError.String)
+		delivered, _ := dbObj.GetWebhookDeliveries(ctx, webhook.DeliveryFilter{SubscriptionId: crmId, EventId: 2, Limit: 10})
+		assert.Equal(t, 1, len(delivered))
+		assert.Equal(t, "DELIVERED", delivered[0].Status.String)
+		assert.Equal(t, int64(204), delivered[0].ResponseCode.Int64)
+		assert.Equal(t, true, delivered[0].DeliveredAt.Valid)
+		assert.Equal(t, false, delivered[0].LastError.Valid)
+		page, _ := dbObj.GetWebhookDeliveries(ctx, webhook.DeliveryFilter{AfterId: all[0].DeliveryId.Int64, Limit: 1})
+		assert.Equal(t, 1, len(page))
+		assert.Equal(t, all[1].DeliveryId.Int64, page[0].DeliveryId.Int64)
+
+		//delivered and dead deliveries can be sent again, a pending one is left as it is
+		redeliveredId, err := dbObj.RedeliverWebhookDelivery(ctx, first, now)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, first, redeliveredId)
+		redeliveredId, _ = dbObj.RedeliverWebhookDelivery(ctx, first, now)
+		assert.Equal(t, int64(0), redeliveredId)
+		dbObj.RedeliverWebhookDelivery(ctx, second, now)
+		due, _ = dbObj.GetDueWebhookDeliveries(ctx, now, 10)
+		assert.Equal(t, 2, len(due))
+		assert.Equal(t, int64(0), due[0].Attempts.Int64)
+		assert.Equal(t, false, due[1].DeliveredAt.Valid)
+
+		//removing a subscription removes its deliveries
+		deletedId, err := dbObj.DeleteWebhookSubscription(ctx, crmId)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, crmId, deletedId)
+		deletedId, _ = dbObj.DeleteWebhookSubscription(ctx, crmId)
+		assert.Equal(t, int64(0), deletedId)
+		all, _ = dbObj.GetWebhookDeliveries(ctx, webhook.DeliveryFilter{Limit: 10})
+		assert.Equal(t, 1, len(all))
+		assert.Equal(t, pausedId, all[0].SubscriptionId.Int64)
+	})
+}
+
+func Test_Repository_Payments(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		loanId, _ := dbObj.CreateLoan(ctx, userId, 3000, 3, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+		received := func(providerEventId string) payment.PaymentEvent {
+			return payment.PaymentEvent{
+				Provider:        sql.NullString{String: "fakepay", Valid: true},
+				ProviderEventId: sql.NullString{String: providerEventId, Valid: true},
+				EventType:       sql.NullString{String: "payment.succeeded", Valid: true},
+				PaymentId:       sql.NullString{String: "pay_" + providerEventId, Valid: true},
+				Reference:       sql.NullString{String: "AL000000034", Valid: true},
+				Amount:          sql.NullFloat64{Float64: 1000, Valid: true},
+				Payload:         sql.NullString{String: `{"id":"` + providerEventId + `"}`, Valid: true},
+				ReceivedAt:      sql.NullTime{Time: time.Now(), Valid: true},
+				LeaseUntil:      sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+			}
+		}
+
+		//an event is recorded once per provider
+		firstId, err := dbObj.AddPaymentEvent(ctx, received("evt_1"))
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), firstId)
+		duplicateId, err := dbObj.AddPaymentEvent(ctx, received("evt_1"))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(0), duplicateId)
+		other := received("evt_1")
+		other.Provider = sql.NullString{String: "otherpay", Valid: true}
+		otherId, _ := dbObj.AddPaymentEvent(ctx, other)
+		assert.NotEqual(t, int64(0), otherId)
+
+		event, err := dbObj.GetPaymentEvent(ctx, "fakepay", "evt_1")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, firstId, event.EventId.Int64)
+		assert.Equal(t, "RECEIVED", event.Status.String)
+		assert.Equal(t, float64(1000), event.Amount.Float64)
+		assert.Equal(t, `{"id":"evt_1"}`, event.Payload.String)
+		assert.Equal(t, false, event.LoanId.Valid)
+		_, err = dbObj.GetPaymentEvent(ctx, "fakepay", "evt_9")
+		assert.Equal(t, true, errors.Is(err, sql.ErrNoRows))
+
+		//a received event is taken over only once its lease passed, by one request
+		now := time.Now()
+		claimed, err := dbObj.ClaimPaymentEvent(ctx, firstId, now, now.Add(time.Minute))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, claimed)
+		claimed, _ = dbObj.ClaimPaymentEvent(ctx, firstId, now.Add(2*time.Minute), now.Add(3*time.Minute))
+		assert.Equal(t, true, claimed)
+		claimed, _ = dbObj.ClaimPaymentEvent(ctx, firstId, now.Add(2*time.Minute), now.Add(3*time.Minute))
+		assert.Equal(t, false, claimed)
+
+		//an outcome is recorded once, and an event with an outcome is kept
+		event.Status = sql.NullString{String: "APPLIED", Valid: true}
+		event.LoanId = sql.NullInt64{Int64: loanId, Valid: true}
+		event.ProcessedAt = sql.NullTime{Time: time.Now(), Valid: true}
+		assert.Equal(t, nil, dbObj.CompletePaymentEvent(ctx, event))
+		event.Status = sql.NullString{String: "REJECTED", Valid: true}
+		assert.Equal(t, nil, dbObj.CompletePaymentEvent(ctx, event))
+		assert.Equal(t, nil, dbObj.DeletePaymentEvent(ctx, firstId))
+		event, _ = dbObj.GetPaymentEvent(ctx, "fakepay", "evt_1")
+		assert.Equal(t, "APPLIED", event.Status.String)
+		assert.Equal(t, loanId, event.LoanId.Int64)
+		assert.Equal(t, true, event.ProcessedAt.Valid)
+		claimed, _ = dbObj.ClaimPaymentEvent(ctx, firstId, now.Add(time.Hour), now.Add(2*time.Hour))
+		assert.Equal(t, false, claimed)
+
+		//an event which could not be applied is forgotten so it can be received again
+		assert.Equal(t, nil, dbObj.DeletePaymentEvent(ctx, otherId))
+		_, err = dbObj.GetPaymentEvent(ctx, "otherpay", "evt_1")
+		assert.Equal(t, true, errors.Is(err, sql.ErrNoRows))
+		otherId, _ = dbObj.AddPaymentEvent(ctx, other)
+		assert.NotEqual(t, int64(0), otherId)
+
+		rejectedId, _ := dbObj.AddPaymentEvent(ctx, received("evt_2"))
+		err = dbObj.CompletePaymentEvent(ctx, payment.PaymentEvent{
+			EventId:     sql.NullInt64{Int64: rejectedId, Valid: true},
+			Status:      sql.NullString{String: "REJECTED", Valid: true},
+			Error:       sql.NullString{String: "loan not found", Valid: true},
+			ProcessedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		})
+		assert.Equal(t, nil, err)
+
+		//events are filtered and paged in id order
+		all, err := dbObj.GetPaymentEvents(ctx, payment.PaymentEventFilter{Limit: 10})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 3, len(all))
+		rejected, _ := dbObj.GetPaymentEvents(ctx, payment.PaymentEventFilter{Status: "REJECTED", Limit: 10})
+		assert.Equal(t, 1, len(rejected))
+		assert.Equal(t, "loan not found", rejected[0].Error.String)
+		applied, _ := dbObj.GetPaymentEvents(ctx, payment.PaymentEventFilter{LoanId: loanId, Limit: 10})
+		assert.Equal(t, 1, len(applied))
+		assert.Equal(t, firstId, applied[0].EventId.Int64)
+		page, _ := dbObj.GetPaymentEvents(ctx, payment.PaymentEventFilter{AfterId: all[0].EventId.Int64, Limit: 1})
+		assert.Equal(t, 1, len(page))
+		assert.Equal(t, all[1].EventId.Int64, page[0].EventId.Int64)
+	})
+}
+
+func Test_Repository_BankStatements(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		adminId, _ := dbObj.AddUser(ctx, testUser("admin", "ADMIN"))
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		loanId, _ := dbObj.CreateLoan(ctx, userId, 3000, 3, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+		otherId, _ := dbObj.CreateLoan(ctx, userId, 2000, 2, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+		approval := loan.StatusChange{From: "PENDING", To: "APPROVED", Actor: "ADMIN", ActorId: adminId}
+		assert.Equal(t, nil, dbObj.UpdateAndInsertInstallments(ctx, loanId, approval, 1000, 3, nil))
+		assert.Equal(t, nil, dbObj.UpdateAndInsertInstallments(ctx, otherId, approval, 1000, 2, nil))
+
+		statement := func(checksum string) reconciliation.BankStatement {
+			return reconciliation.BankStatement{
+				FileName:   sql.NullString{String: "statement.csv", Valid: true},
+				Format:     sql.NullString{String: "CSV", Valid: true},
+				Checksum:   sql.NullString{String: checksum, Valid: true},
+				ImportedBy: sql.NullInt64{Int64: adminId, Valid: true},
+				ImportedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			}
+		}
+		line := func(lineNum int64, bankReference string, amount float64) reconciliation.StatementLine {
+			return reconciliation.StatementLine{
+				LineNum:       sql.NullInt64{Int64: lineNum, Valid: true},
+				BookedOn:      sql.NullTime{Time: time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), Valid: true},
+				Amount:        sql.NullFloat64{Float64: amount, Valid: true},
+				Reference:     sql.NullString{String: "AL000000018", Valid: true},
+				BankReference: sql.NullString{String: bankReference, Valid: bankReference != ""},
+			}
+		}
+
+		//a statement is imported once and its lines are received
+		statementId, lines, err := dbObj.AddBankStatement(ctx, statement("abc"), []reconciliation.StatementLine{line(1, "B1", 1000), line(2, "B2", 250), line(3, "", 99)})
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), statementId)
+		assert.Equal(t, 3, len(lines))
+		assert.Equal(t, statementId, lines[0].StatementId.Int64)
+		assert.Equal(t, "RECEIVED", lines[0].Status.String)
+		duplicateId, lines2, err := dbObj.AddBankStatement(ctx, statement("abc"), []reconciliation.StatementLine{line(1, "B9", 1000)})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(0), duplicateId)
+		assert.Equal(t, 0, len(lines2))
+		//a line already imported with another statement is skipped
+		secondId, lines2, err := dbObj.AddBankStatement(ctx, statement("def"), []reconciliation.StatementLine{line(1, "B1", 1000), line(2, "B3", 500)})
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), secondId)
+		assert.Equal(t, 1, len(lines2))
+		assert.Equal(t, "B3", lines2[0].BankReference.String)
+
+		fetched, err := dbObj.GetStatementLine(ctx, lines[0].LineId.Int64)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, float64(1000), fetched.Amount.Float64)
+		assert.Equal(t, "AL000000018", fetched.Reference.String)
+		assert.Equal(t, 2024, fetched.BookedOn.Time.Year())
+		_, err = dbObj.GetStatementLine(ctx, lines2[0].LineId.Int64+10)
+		assert.Equal(t, true, errors.Is(err, sql.ErrNoRows))
+
+		//both loans are due 1000 next
+		loanIds, err := dbObj.GetLoansByNextInstallment(ctx, 1000)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, []int64{loanId, otherId}, loanIds)
+		loanIds, _ = dbObj.GetLoansByNextInstallment(ctx, 250)
+		assert.Equal(t, 0, len(loanIds))
+
+		//an outcome is recorded once
+		applied := lines[0]
+		applied.Status = sql.NullString{String: "APPLIED", Valid: true}
+		applied.LoanId = sql.NullInt64{Int64: loanId, Valid: true}
+		applied.MatchRule = sql.NullString{String: "REFERENCE", Valid: true}
+		assert.Equal(t, nil, dbObj.CompleteStatementLine(ctx, applied))
+		exception := lines[1]
+		exception.Status = sql.NullString{String: "EXCEPTION", Valid: true}
+		exception.Reason = sql.NullString{String: "no loan matches", Valid: true}
+		assert.Equal(t, nil, dbObj.CompleteStatementLine(ctx, exception))
+		applied.Status = exception.Status
+		assert.Equal(t, nil, dbObj.CompleteStatementLine(ctx, applied))
+		fetched, _ = dbObj.GetStatementLine(ctx, applied.LineId.Int64)
+		assert.Equal(t, "APPLIED", fetched.Status.String)
+		assert.Equal(t, loanId, fetched.LoanId.Int64)
+
+		//an exception is resolved once and reopened when it could not be applied
+		resolution := reconciliation.StatementLine{
+			LineId:     exception.LineId,
+			Status:     sql.NullString{String: "RESOLVED", Valid: true},
+			LoanId:     sql.NullInt64{Int64: otherId, Valid: true},
+			MatchRule:  sql.NullString{String: "MANUAL", Valid: true},
+			ResolvedBy: sql.NullInt64{Int64: adminId, Valid: true},
+			ResolvedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			Note:       sql.NullString{String: "paid by the spouse", Valid: true},
+		}
+		resolvedId, err := dbObj.ResolveStatementLine(ctx, resolution)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, exception.LineId.Int64, resolvedId)
+		resolvedId, _ = dbObj.ResolveStatementLine(ctx, resolution)
+		assert.Equal(t, int64(0), resolvedId)
+		fetched, _ = dbObj.GetStatementLine(ctx, exception.LineId.Int64)
+		assert.Equal(t, "RESOLVED", fetched.Status.String)
+		assert.Equal(t, adminId, fetched.ResolvedBy.Int64)
+		assert.Equal(t, "paid by the spouse", fetched.Note.String)
+		assert.Equal(t, nil, dbObj.ReopenStatementLine(ctx, exception.LineId.Int64, "loan is paid"))
+		fetched, _ = dbObj.GetStatementLine(ctx, exception.LineId.Int64)
+		assert.Equal(t, "EXCEPTION", fetched.Status.String)
+		assert.Equal(t, "loan is paid", fetched.Reason.String)
+		assert.Equal(t, false, fetched.ResolvedBy.Valid)
+
+		//lines are filtered and paged in id order
+		exceptions, err := dbObj.GetStatementLines(ctx, reconciliation.StatementLineFilter{Status: "EXCEPTION", Limit: 10})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(exceptions))
+		firstLines, _ := dbObj.GetStatementLines(ctx, reconciliation.StatementLineFilter{StatementId: statementId, Limit: 10})
+		assert.Equal(t, 3, len(firstLines))
+		page, _ := dbObj.GetStatementLines(ctx, reconciliation.StatementLineFilter{AfterId: firstLines[0].LineId.Int64, Limit: 1})
+		assert.Equal(t, 1, len(page))
+		assert.Equal(t, firstLines[1].LineId.Int64, page[0].LineId.Int64)
+
+		//statements are listed latest first with their lines counted by status
+		statements, err := dbObj.GetBankStatements(ctx)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(statements))
+		assert.Equal(t, secondId, statements[0].StatementId.Int64)
+		assert.Equal(t, map[string]int64{"RECEIVED": 1}, statements[0].Lines)
+		assert.Equal(t, map[string]int64{"APPLIED": 1, "EXCEPTION": 1, "RECEIVED": 1}, statements[1].Lines)
+		assert.Equal(t, adminId, statements[1].ImportedBy.Int64)
+	})
+}
+
+func Test_Repository_AutoDebit(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		adminId, _ := dbObj.AddUser(ctx, testUser("admin", "ADMIN"))
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		loanId, _ := dbObj.CreateLoan(ctx, userId, 300, 3, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+		pendingId, _ := dbObj.CreateLoan(ctx, userId, 200, 2, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+		approval := loan.StatusChange{From: "PENDING", To: "APPROVED", Actor: "ADMIN", ActorId: adminId}
+		assert.Equal(t, nil, dbObj.UpdateAndInsertInstallments(ctx, loanId, approval, 100, 3, nil))
+
+		mandate := func(loanId int64) autodebit.DebitMandate {
+			return autodebit.DebitMandate{
+				UserId:    sql.NullInt64{Int64: userId, Valid: true},
+				LoanId:    sql.NullInt64{Int64: loanId, Valid: true},
+				CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			}
+		}
+		mandateId, err := dbObj.AddDebitMandate(ctx, mandate(loanId))
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), mandateId)
+		//a loan has one mandate
+		againId, err := dbObj.AddDebitMandate(ctx, mandate(loanId))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, mandateId, againId)
+		otherMandateId, _ := dbObj.AddDebitMandate(ctx, mandate(pendingId))
+		mandates, err := dbObj.GetDebitMandates(ctx, userId)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(mandates))
+		assert.Equal(t, "ACTIVE", mandates[0].Status.String)
+
+		//the first installment is due at approval, the loan still pending has none
+		now := time.Now().Add(time.Minute)
+		due, err := dbObj.GetDueInstallments(ctx, now, 10)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(due))
+		assert.Equal(t, mandateId, due[0].MandateId.Int64)
+		assert.Equal(t, int64(1), due[0].InstallmentNum.Int64)
+		assert.Equal(t, float64(100), due[0].Amount.Float64)
+
+		due[0].CreatedAt = sql.NullTime{Time: now, Valid: true}
+		debitId, err := dbObj.AddAutoDebit(ctx, due[0])
+		assert.Equal(t, nil, err)
+		assert.NotEqual(t, int64(0), debitId)
+		duplicateId, err := dbObj.AddAutoDebit(ctx, due[0])
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(0), duplicateId)
+		due, _ = dbObj.GetDueInstallments(ctx, now, 10)
+		assert.Equal(t, 0, len(due))
+
+		//a debit is claimed once per attempt
+		debits, err := dbObj.GetDueAutoDebits(ctx, now, 10)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(debits))
+		assert.Equal(t, "PENDING", debits[0].Status.String)
+		claimed, err := dbObj.ClaimAutoDebit(ctx, debitId, 0, now.Add(time.Minute))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, claimed)
+		claimed, _ = dbObj.ClaimAutoDebit(ctx, debitId, 0, now.Add(time.Minute))
+		assert.Equal(t, false, claimed)
+		debits, _ = dbObj.GetDueAutoDebits(ctx, now, 10)
+		assert.Equal(t, 0, len(debits))
+		assert.Equal(t, nil, dbObj.RetryAutoDebit(ctx, debitId, now, "insufficient funds"))
+		debits, _ = dbObj.GetDueAutoDebits(ctx, now, 10)
+		assert.Equal(t, 1, len(debits))
+		assert.Equal(t, int64(1), debits[0].Attempts.Int64)
+		assert.Equal(t, "insufficient funds", debits[0].LastError.String)
+
+		//the balance is debited only when it covers the amount, and once per reference until it is refunded
+		debited, err := dbObj.DebitAccountBalance(ctx, userId, 150, "AUTODEBIT1")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, false, debited)
+		debited, err = dbObj.DebitAccountBalance(ctx, userId, 60, "AUTODEBIT1")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, debited)
+		debited, err = dbObj.DebitAccountBalance(ctx, userId, 60, "AUTODEBIT1")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, debited)
+		user, _ := dbObj.GetUserById(ctx, userId)
+		assert.Equal(t, float64(40), user.AccountBalance.Float64)
+		assert.Equal(t, nil, dbObj.RefundAccountDebit(ctx, "AUTODEBIT1"))
+		assert.Equal(t, nil, dbObj.RefundAccountDebit(ctx, "AUTODEBIT1"))
+		assert.Equal(t, nil, dbObj.RefundAccountDebit(ctx, "AUTODEBIT2"))
+		user, _ = dbObj.GetUserById(ctx, userId)
+		assert.Equal(t, float64(100), user.AccountBalance.Float64)
+		debited, err = dbObj.DebitAccountBalance(ctx, userId, 60, "AUTODEBIT1")
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, debited)
+		user, _ = dbObj.GetUserById(ctx, userId)
+		assert.Equal(t, float64(40), user.AccountBalance.Float64)
+
+		debits[0].Status = sql.NullString{String: "SUCCEEDED", Valid: true}
+		debits[0].TransactionId = sql.NullString{String: "AUTODEBIT1", Valid: true}
+		debits[0].LastError = sql.NullString{}
+		debits[0].UpdatedAt = sql.NullTime{Time: now, Valid: true}
+		assert.Equal(t, nil, dbObj.CompleteAutoDebit(ctx, debits[0]))
+		debits, err = dbObj.GetAutoDebits(ctx, loanId)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(debits))
+		assert.Equal(t, "SUCCEEDED", debits[0].Status.String)
+		assert.Equal(t, "AUTODEBIT1", debits[0].TransactionId.String)
+		assert.Equal(t, false, debits[0].LastError.Valid)
+
+		//cancelling the mandate cancels its pending debits
+		assert.Equal(t, nil, dbObj.UpdateSingleInstallmentPayment(ctx, loanId, loan.InstallmentDetails{
+			InstallmentSeq: sql.NullInt64{Int64: 1, Valid: true},
+			AmountDue:      sql.NullFloat64{Float64: 100, Valid: true},
+			AmountPaid:     sql.NullFloat64{Float64: 100, Valid: true},
+			Status:         sql.NullString{String: "PAID", Valid: true},
+			TransactionId:  sql.NullString{String: "AUTODEBIT1", Valid: true},
+		}, loan.StatusChange{}, nil))
+		due, _ = dbObj.GetDueInstallments(ctx, now.Add(8*24*time.Hour), 10)
+		assert.Equal(t, 1, len(due))
+		assert.Equal(t, int64(2), due[0].InstallmentNum.Int64)
+		due[0].CreatedAt = sql.NullTime{Time: now, Valid: true}
+		secondId, _ := dbObj.AddAutoDebit(ctx, due[0])
+		cancelledId, err := dbObj.CancelDebitMandate(ctx, loanId, now)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, mandateId, cancelledId)
+		cancelledId, err = dbObj.CancelDebitMandate(ctx, loanId, now)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(0), cancelledId)
+		debits, _ = dbObj.GetAutoDebits(ctx, loanId)
+		assert.Equal(t, 2, len(debits))
+		assert.Equal(t, secondId, debits[1].DebitId.Int64)
+		assert.Equal(t, "CANCELLED", debits[1].Status.String)
+		//a debit cancelled while its attempt was running may still end with its refund failed
+		debits[1].Status = sql.NullString{String: "REFUND_FAILED", Valid: true}
+		debits[1].LastError = sql.NullString{String: "refund failed", Valid: true}
+		debits[1].UpdatedAt = sql.NullTime{Time: now, Valid: true}
+		assert.Equal(t, nil, dbObj.CompleteAutoDebit(ctx, debits[1]))
+		debits, _ = dbObj.GetAutoDebits(ctx, loanId)
+		assert.Equal(t, "REFUND_FAILED", debits[1].Status.String)
+		fetched, err := dbObj.GetDebitMandate(ctx, loanId)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, "CANCELLED", fetched.Status.String)
+		assert.Equal(t, true, fetched.CancelledAt.Valid)
+		_, err = dbObj.GetDebitMandate(ctx, loanId+10)
+		assert.Equal(t, true, errors.Is(err, sql.ErrNoRows))
+
+		//a cancelled mandate is activated again
+		againId, _ = dbObj.AddDebitMandate(ctx, mandate(loanId))
+		assert.Equal(t, mandateId, againId)
+		fetched, _ = dbObj.GetDebitMandate(ctx, loanId)
+		assert.Equal(t, "ACTIVE", fetched.Status.String)
+		assert.Equal(t, false, fetched.CancelledAt.Valid)
+		assert.NotEqual(t, int64(0), otherMandateId)
+	})
+}
+
+func Test_Repository_Notifications(t *testing.T) {
+	forEachDatabase(t, func(t *testing.T, ctx context.Context, dbObj v1.V1DBLayer) {
+		adminId, _ := dbObj.AddUser(ctx, testUser("admin", "ADMIN"))
+		userId, _ := dbObj.AddUser(ctx, testUser("john", "CUSTOMER"))
+		otherId, _ := dbObj.AddUser(ctx, testUser("jane", "CUSTOMER"))
+
+		preference := func(channel string, enabled bool) notification.NotificationPreference {
+			return notification.NotificationPreference{
+				UserId:    sql.NullInt64{Int64: userId, Valid: true},
+				Channel:   sql.NullString{String: channel, Valid: true},
+				Enabled:   sql.NullBool{Bool: enabled, Valid: true},
+				UpdatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+			}
+		}
+		preferences, err := dbObj.GetNotificationPreferences(ctx, userId)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 0, len(preferences))
+		assert.Equal(t, nil, dbObj.SetNotificationPreferences(ctx, []notification.NotificationPreference{preference("EMAIL", true), preference("SMS", true)}))
+		assert.Equal(t, nil, dbObj.SetNotificationPreferences(ctx, []notification.NotificationPreference{preference("SMS", false)}))
+		preferences, _ = dbObj.GetNotificationPreferences(ctx, userId)
+		assert.Equal(t, 2, len(preferences))
+		assert.Equal(t, "EMAIL", preferences[0].Channel.String)
+		assert.Equal(t, true, preferences[0].Enabled.Bool)
+		assert.Equal(t, "SMS", preferences[1].Channel.String)
+		assert.Equal(t, false, preferences[1].Enabled.Bool)
+		assert.NotEqual(t, nil, dbObj.SetNotificationPreferences(ctx, []notification.NotificationPreference{preference("PIGEON", true)}))
+
+		now := time.Now()
+		notice := func(userId int64, channel string, status string, dedupKey string) notification.Notification {
+			row := notification.Notification{
+				UserId:    sql.NullInt64{Int64: userId, Valid: true},
+				EventType: sql.NullString{String: "loan.approved", Valid: true},
+				Channel:   sql.NullString{String: channel, Valid: true},
+				Subject:   sql.NullString{String: "Your loan is approved", Valid: true},
+				Body:      sql.NullString{String: "Hello", Valid: true},
+				DedupKey:  sql.NullString{String: dedupKey, Valid: true},
+				Status:    sql.NullString{String: status, Valid: true},
+				CreatedAt: sql.NullTime{Time: now, Valid: true},
+			}
+			if channel == "EMAIL" {
+				row.Recipient = sql.NullString{String: "john@example.com", Valid: true}
+			}
+			if status == "SENT" {
+				row.SentAt = sql.NullTime{Time: now, Valid: true}
+			}
+			return row
+		}
+		//a dedup key is notified once per channel
+		assert.Equal(t, nil, dbObj.AddNotifications(ctx, []notification.Notification{
+			notice(userId, "EMAIL", "PENDING", "event:1"),
+			notice(userId, "IN_APP", "SENT", "event:1"),
+			notice(otherId, "IN_APP", "SENT", "event:2"),
+		}))
+		assert.Equal(t, nil, dbObj.AddNotifications(ctx, []notification.Notification{notice(userId, "EMAIL", "PENDING", "event:1")}))
+
+		due, err := dbObj.GetDueNotifications(ctx, now.Add(time.Second), 10)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 1, len(due))
+		emailId := due[0].NotificationId.Int64
+		assert.Equal(t, "john@example.com", due[0].Recipient.String)
+		assert.Equal(t, "PENDING", due[0].Status.String)
+
+		//a notification is claimed once per attempt
+		claimed, err := dbObj.ClaimNotification(ctx, emailId, 0, now.Add(time.Minute))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, true, claimed)
+		claimed, _ = dbObj.ClaimNotification(ctx, emailId, 0, now.Add(time.Minute))
+		assert.Equal(t, false, claimed)
+		due, _ = dbObj.GetDueNotifications(ctx, now.Add(time.Second), 10)
+		assert.Equal(t, 0, len(due))
+		assert.Equal(t, nil, dbObj.RetryNotification(ctx, emailId, now, "connection refused"))
+		due, _ = dbObj.GetDueNotifications(ctx, now.Add(time.Second), 10)
+		assert.Equal(t, 1, len(due))
+		assert.Equal(t, int64(1), due[0].Attempts.Int64)
+		assert.Equal(t, "connection refused", due[0].LastError.String)
+		assert.Equal(t, nil, dbObj.MarkNotificationSent(ctx, emailId, now))
+
+		assert.Equal(t, nil, dbObj.AddNotifications(ctx, []notification.Notification{notice(userId, "SMS", "PENDING", "event:1")}))
+		due, _ = dbObj.GetDueNotifications(ctx, now.Add(time.Second), 10)
+		assert.Equal(t, 1, len(due))
+		assert.Equal(t, nil, dbObj.FailNotification(ctx, due[0].NotificationId.Int64, "no gateway"))
+
+		//the notifications of a user come latest first
+		notifications, err := dbObj.GetNotifications(ctx, notification.NotificationFilter{UserId: userId, Limit: 10})
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 3, len(notifications))
+		assert.Equal(t, "SMS", notifications[0].Channel.String)
+		assert.Equal(t, "FAILED", notifications[0].Status.String)
+		assert.Equal(t, "no gateway", notifications[0].LastError.String)
+		assert.Equal(t, "IN_APP", notifications[1].Channel.String)
+		assert.Equal(t, "EMAIL", notifications[2].Channel.String)
+		assert.Equal(t, "SENT", notifications[2].Status.String)
+		assert.Equal(t, false, notifications[2].LastError.Valid)
+		assert.Equal(t, true, notifications[2].SentAt.Valid)
+		notifications, _ = dbObj.GetNotifications(ctx, notification.NotificationFilter{UserId: userId, BeforeId: notifications[1].NotificationId.Int64, Limit: 10})
+		assert.Equal(t, 1, len(notifications))
+		assert.Equal(t, emailId, notifications[0].NotificationId.Int64)
+
+		//only in-app notifications are read
+		inbox, _ := dbObj.GetNotifications(ctx, notification.NotificationFilter{UserId: userId, Channel: "IN_APP", Unread: true, Limit: 10})
+		assert.Equal(t, 1, len(inbox))
+		marked, err := dbObj.MarkNotificationsRead(ctx, userId, []int64{emailId, inbox[0].NotificationId.Int64}, now)
+		assert.Equal(t, nil, err)
+		assert.Equal(t, int64(1), marked)
+		marked, _ = dbObj.MarkNotificationsRead(ctx, userId, nil, now)
+		assert.Equal(t, int64(0), marked)
+		inbox, _ = dbObj.GetNotifications(ctx, notification.NotificationFilter{UserId: userId, Channel: "IN_APP", Unread: true, Limit: 10})
+		assert.Equal(t, 0, len(inbox))
+		marked, _ = dbObj.MarkNotificationsRead(ctx, otherId, nil, now)
+		assert.Equal(t, int64(1), marked)
+
+		//installments of approved loans are reminded about
+		loanId, _ := dbObj.CreateLoan(ctx, userId, 300, 3, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+		dbObj.CreateLoan(ctx, otherId, 200, 2, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: otherId}, nil)
+		approval := loan.StatusChange{From: "PENDING", To: "APPROVED", Actor: "ADMIN", ActorId: adminId}
+		assert.Equal(t, nil, dbObj.UpdateAndInsertInstallments(ctx, loanId, approval, 100, 3, nil))
+		reminders, err := dbObj.GetInstallmentsDueBetween(ctx, now.Add(24*time.Hour), now.Add(15*24*time.Hour))
+		assert.Equal(t, nil, err)
+		assert.Equal(t, 2, len(reminders))
+		assert.Equal(t, userId, reminders[0].UserId.Int64)
+		assert.Equal(t, loanId, reminders[0].LoanId.Int64)
+		assert.Equal(t, int64(2), reminders[0].InstallmentNum.Int64)
+		assert.Equal(t, float64(100), reminders[0].AmountDue.Float64)
+		assert.Equal(t, int64(3), reminders[1].InstallmentNum.Int64)
+	})
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/memory"
 
 	"gorm.io/gorm"
 )
@@ -17,6 +18,13 @@ func NewDBObject(psqlDB *gorm.DB) DBLayer {
 	return temp
 }
 
+// NewMemoryDBObject keeps all data in process memory. it is meant for local runs and tests, nothing survives a restart
+func NewMemoryDBObject() DBLayer {
+	return &dbService{
+		memory.NewV1DbLayer(),
+	}
+}
+
 type DBLayer interface {
 	GetV1DBLayer() v1.V1DBLayer //v1 db layer
 }
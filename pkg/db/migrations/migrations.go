@@ -0,0 +1,351 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var migrationFS embed.FS
+
+// every database has its own migrations directory named after the gorm dialect, with the same versions in each
+const (
+	POSTGRES_DIR = "postgres"
+	SQLITE_DIR   = "sqlite"
+	// ADVISORY_LOCK_ID keeps instances starting together from migrating the same database at once
+	ADVISORY_LOCK_ID = 41572023
+	// SCHEMA_TABLE is created by the initial migration. a database holding it without any recorded version was
+	// built before the migrations, from create.sql, and is adopted with Baseline
+	SCHEMA_TABLE = "loan"
+)
+
+// migration files are named <version>_<name>.<up|down>.sql, for example 0002_add_audit_log.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a known migration and when it was applied. AppliedAt is zero for a pending migration
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt time.Time
+}
+
+func (status Status) Applied() bool {
+	return !status.AppliedAt.IsZero()
+}
+
+// Migrator applies the embedded migrations and records them in the schema_version table
+type Migrator struct {
+	dbObj      *gorm.DB
+	dialect    string
+	migrations []Migration
+}
+
+// NewMigrator picks the migrations of the database the connection was opened for
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	dialect := db.Dialector.Name()
+	if dialect != POSTGRES_DIR && dialect != SQLITE_DIR {
+		return nil, fmt.Errorf("no migrations for database %s", dialect)
+	}
+	migrations, err := Load(migrationFS, dialect)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		dbObj:      db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// Load reads the migrations of a directory ordered by version. every version needs both an up and a down file
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", entry.Name())
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		if version <= 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files with different names: %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns how many were applied. a schema built before the
+// migrations is refused instead of being created again over the existing tables
+func (obj *Migrator) Up(ctx context.Context) (int, error) {
+	count := 0
+	err := obj.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		if len(applied) == 0 {
+			exists, err := obj.tableExists(ctx, conn, SCHEMA_TABLE)
+			if err != nil {
+				return err
+			}
+			if exists {
+				return fmt.Errorf("database has a schema not built by the migrations. run migrate baseline -version <version> with the last migration it matches")
+			}
+		}
+		for _, migration := range obj.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := apply(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down reverts the last applied migrations, newest first, and returns how many were reverted
+func (obj *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	count := 0
+	err := obj.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for i := len(obj.migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := obj.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := apply(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Baseline records every migration up to version as applied without running it, so a database whose schema was
+// built before the migrations is adopted and only the later migrations are applied to it. it returns how many
+// versions were recorded and is refused on a database which already has recorded versions
+func (obj *Migrator) Baseline(ctx context.Context, version int64) (int, error) {
+	known := false
+	for _, migration := range obj.migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return 0, fmt.Errorf("unknown migration version %d", version)
+	}
+
+	count := 0
+	err := obj.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		if len(applied) != 0 {
+			return fmt.Errorf("database already has %d applied migrations", len(applied))
+		}
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for _, migration := range obj.migrations {
+			if migration.Version > version {
+				break
+			}
+			if _, err := tx.ExecContext(ctx, "insert into schema_version(version,name) values ($1,$2)", migration.Version, migration.Name); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("failed to record migration %d_%s. Error: %w", migration.Version, migration.Name, err)
+			}
+			count++
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Printf("baselined the schema at migration %d", version)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Status lists every known migration with the time it was applied
+func (obj *Migrator) Status(ctx context.Context) ([]Status, error) {
+	list := make([]Status, 0, len(obj.migrations))
+	err := obj.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for _, migration := range obj.migrations {
+			list = append(list, Status{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: applied[migration.Version],
+			})
+			delete(applied, migration.Version)
+		}
+		//versions applied by a newer build are reported so a downgrade does not go unnoticed
+		for version, appliedAt := range applied {
+			list = append(list, Status{Version: version, Name: "unknown", AppliedAt: appliedAt})
+		}
+		return nil
+	})
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	return list, err
+}
+
+// locked runs fn on a single connection holding the migration advisory lock, with the applied versions read under the lock.
+// the lock belongs to the session, so the connection is taken out of the pool until fn returns.
+// sqlite has no advisory locks, but it runs one write transaction at a time and a migration applied twice
+// fails on the schema_version primary key and is rolled back
+func (obj *Migrator) locked(ctx context.Context, fn func(*sql.Conn, map[int64]time.Time) error) error {
+	sqlDb, err := obj.dbObj.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDb.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if obj.dialect == POSTGRES_DIR {
+		if _, err := conn.ExecContext(ctx, "select pg_advisory_lock($1)", ADVISORY_LOCK_ID); err != nil {
+			return fmt.Errorf("failed to take migration lock. Error: %w", err)
+		}
+		defer func() {
+			//the lock must be released even when the request was cancelled
+			if _, err := conn.ExecContext(context.Background(), "select pg_advisory_unlock($1)", ADVISORY_LOCK_ID); err != nil {
+				log.Printf("failed to release migration lock. Error: %s", err.Error())
+			}
+		}()
+	}
+
+	createQuery := `
+		create table if not exists schema_version(
+			version bigint primary key,
+			name text not null,
+			applied_at timestamp not null default CURRENT_TIMESTAMP
+		);
+	`
+	if _, err := conn.ExecContext(ctx, createQuery); err != nil {
+		return fmt.Errorf("failed to create schema_version. Error: %w", err)
+	}
+
+	rows, err := conn.QueryContext(ctx, "select version, applied_at from schema_version")
+	if err != nil {
+		return fmt.Errorf("failed to read schema_version. Error: %w", err)
+	}
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = appliedAt
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+func (obj *Migrator) tableExists(ctx context.Context, conn *sql.Conn, table string) (bool, error) {
+	query := "select count(1) from information_schema.tables where table_schema = current_schema() and table_name = $1"
+	if obj.dialect == SQLITE_DIR {
+		query = "select count(1) from sqlite_master where type = 'table' and name = $1"
+	}
+	var count int64
+	if err := conn.QueryRowContext(ctx, query, table).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to read the tables. Error: %w", err)
+	}
+	return count != 0, nil
+}
+
+// apply runs one direction of a migration and its schema_version change in one transaction
+func apply(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	var (
+		script    = migration.Up
+		record    = "insert into schema_version(version,name) values ($1,$2)"
+		args      = []interface{}{migration.Version, migration.Name}
+		direction = "up"
+	)
+	if !up {
+		script = migration.Down
+		record = "delete from schema_version where version = $1"
+		args = []interface{}{migration.Version}
+		direction = "down"
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %d_%s %s failed. Error: %w", migration.Version, migration.Name, direction, err)
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %d_%s. Error: %w", migration.Version, migration.Name, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	log.Printf("migration %d_%s %s applied", migration.Version, migration.Name, direction)
+	return nil
+}
@@ -0,0 +1,199 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-playground/assert/v2"
+	"gorm.io/gorm"
+)
+
+func Test_Load(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    fstest.MapFS
+		versions []int64
+		wantErr  bool
+	}{
+		{
+			name: "OrderedByVersion",
+			files: fstest.MapFS{
+				"sql/0010_add_audit.up.sql":        {Data: []byte("create table audit();")},
+				"sql/0010_add_audit.down.sql":      {Data: []byte("drop table audit;")},
+				"sql/0002_add_outbox.up.sql":       {Data: []byte("create table outbox();")},
+				"sql/0002_add_outbox.down.sql":     {Data: []byte("drop table outbox;")},
+				"sql/0001_initial_schema.up.sql":   {Data: []byte("create table loan();")},
+				"sql/0001_initial_schema.down.sql": {Data: []byte("drop table loan;")},
+			},
+			versions: []int64{1, 2, 10},
+		},
+		{
+			name: "MissingDown",
+			files: fstest.MapFS{
+				"sql/0001_initial_schema.up.sql": {Data: []byte("create table loan();")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "MismatchedNames",
+			files: fstest.MapFS{
+				"sql/0001_initial_schema.up.sql": {Data: []byte("create table loan();")},
+				"sql/0001_initial.down.sql":      {Data: []byte("drop table loan;")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "InvalidFileName",
+			files: fstest.MapFS{
+				"sql/create.sql": {Data: []byte("create table loan();")},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := Load(tt.files, "sql")
+			assert.Equal(t, tt.wantErr, err != nil)
+
+			versions := make([]int64, 0)
+			for _, migration := range migrations {
+				versions = append(versions, migration.Version)
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.versions, versions)
+			}
+		})
+	}
+}
+
+func Test_EmbeddedMigrations(t *testing.T) {
+	postgres, err := Load(migrationFS, POSTGRES_DIR)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), postgres[0].Version)
+	for i, migration := range postgres {
+		assert.Equal(t, int64(i+1), migration.Version)
+	}
+
+	//every schema change is made for each database
+	sqlite, err := Load(migrationFS, SQLITE_DIR)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(postgres), len(sqlite))
+	for i := range sqlite {
+		assert.Equal(t, postgres[i].Version, sqlite[i].Version)
+		assert.Equal(t, postgres[i].Name, sqlite[i].Name)
+	}
+}
+
+func Test_Migrator_Sqlite(t *testing.T) {
+	ctx := context.Background()
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "aspire.db")+"?_pragma=foreign_keys(1)"), &gorm.Config{})
+	assert.Equal(t, nil, err)
+
+	migrator, err := NewMigrator(conn)
+	assert.Equal(t, nil, err)
+
+	applied, err := migrator.Up(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(migrator.migrations), applied)
+
+	//applying again is a no-op
+	applied, err = migrator.Up(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, applied)
+
+	var roles int64
+	conn.Raw("select count(1) from role").Scan(&roles)
+	assert.Equal(t, int64(5), roles)
+
+	//written audit entries cannot be changed or removed
+	err = conn.Exec("insert into audit_log(actor_type, action, outcome, created_at, prev_hash, hash) values ('SYSTEM', 'test', 'SUCCESS', CURRENT_TIMESTAMP, 'a', 'b')").Error
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, conn.Exec("update audit_log set action = 'changed'").Error)
+	assert.NotEqual(t, nil, conn.Exec("delete from audit_log").Error)
+
+	list, err := migrator.Status(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, list[0].Applied())
+
+	reverted, err := migrator.Down(ctx, len(migrator.migrations))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(migrator.migrations), reverted)
+
+	var tables int64
+	conn.Raw("select count(1) from sqlite_master where type = 'table' and name = 'loan'").Scan(&tables)
+	assert.Equal(t, int64(0), tables)
+}
+
+func Test_Migrator_Baseline(t *testing.T) {
+	ctx := context.Background()
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "aspire.db")+"?_pragma=foreign_keys(1)"), &gorm.Config{})
+	assert.Equal(t, nil, err)
+
+	migrator, err := NewMigrator(conn)
+	assert.Equal(t, nil, err)
+
+	//a schema created before the migrations, from create.sql
+	assert.Equal(t, nil, conn.Exec(migrator.migrations[0].Up).Error)
+
+	//the initial migration is not run again over it
+	applied, err := migrator.Up(ctx)
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, 0, applied)
+
+	_, err = migrator.Baseline(ctx, 999)
+	assert.NotEqual(t, nil, err)
+	recorded, err := migrator.Baseline(ctx, 1)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, recorded)
+
+	//the later migrations are applied to the adopted schema
+	applied, err = migrator.Up(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, len(migrator.migrations)-1, applied)
+
+	//a migrated database is not baselined again
+	_, err = migrator.Baseline(ctx, 1)
+	assert.NotEqual(t, nil, err)
+}
+
+func Test_Migrator_DuplicateTransactionIds(t *testing.T) {
+	ctx := context.Background()
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "aspire.db")), &gorm.Config{})
+	assert.Equal(t, nil, err)
+
+	migrator, err := NewMigrator(conn)
+	assert.Equal(t, nil, err)
+	sqlDb, _ := conn.DB()
+	sqlConn, err := sqlDb.Conn(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, conn.Exec("create table if not exists schema_version(version bigint primary key, name text not null, applied_at timestamp not null default CURRENT_TIMESTAMP)").Error)
+	for _, migration := range migrator.migrations {
+		if migration.Version >= 10 {
+			break
+		}
+		assert.Equal(t, nil, apply(ctx, sqlConn, migration, true))
+	}
+	sqlConn.Close()
+
+	//transaction ids were not unique before, so one may have paid several installments
+	for i, txnId := range []string{"T1", "T1", "T2", "T1", ""} {
+		err = conn.Exec("insert into installment(loan_id, amount_due, status, installment_num, due_date, transaction_id) values (1, 100, 'PAID', ?, CURRENT_TIMESTAMP, ?)", i+1, txnId).Error
+		assert.Equal(t, nil, err)
+	}
+
+	_, err = migrator.Up(ctx)
+	assert.Equal(t, nil, err)
+	var txnIds []sql.NullString
+	conn.Raw("select transaction_id from installment order by id").Scan(&txnIds)
+	assert.Equal(t, []sql.NullString{
+		{String: "T1", Valid: true},
+		{String: "T1#2", Valid: true},
+		{String: "T2", Valid: true},
+		{String: "T1#4", Valid: true},
+		{},
+	}, txnIds)
+}
@@ -0,0 +1,34 @@
+-- drop the initial schema. tables are dropped before the tables they reference
+DROP TABLE IF EXISTS api_key_scope;
+DROP TABLE IF EXISTS api_key;
+DROP TABLE IF EXISTS service_account;
+DROP TABLE IF EXISTS signing_key;
+DROP TABLE IF EXISTS login_challenge;
+DROP TABLE IF EXISTS totp_recovery_code;
+DROP TABLE IF EXISTS user_totp;
+DROP TABLE IF EXISTS login_throttle;
+DROP TABLE IF EXISTS user_role;
+DROP TABLE IF EXISTS role_permission;
+DROP TABLE IF EXISTS permission;
+DROP TABLE IF EXISTS role;
+DROP TABLE IF EXISTS admin_invite;
+DROP TABLE IF EXISTS revoked_token;
+DROP TABLE IF EXISTS refresh_token;
+DROP TABLE IF EXISTS password_reset;
+DROP TABLE IF EXISTS contact_verification;
+DROP TABLE IF EXISTS user_profile_history;
+DROP TABLE IF EXISTS user_income;
+DROP TABLE IF EXISTS user_document;
+DROP TABLE IF EXISTS installment;
+DROP TABLE IF EXISTS loan;
+DROP TABLE IF EXISTS user_detail;
+
+DROP FUNCTION IF EXISTS trigger_set_timestamp();
+
+DROP TYPE IF EXISTS RefreshTokenStatus;
+DROP TYPE IF EXISTS VerificationStatus;
+DROP TYPE IF EXISTS DocumentStatus;
+DROP TYPE IF EXISTS DocumentTypes;
+DROP TYPE IF EXISTS LoanTransactionStatus;
+DROP TYPE IF EXISTS LoanStatus;
+DROP TYPE IF EXISTS UserTypes;
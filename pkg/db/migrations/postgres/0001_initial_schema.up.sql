@@ -0,0 +1,388 @@
+-- initial schema: types, tables and the seeded roles and permissions
+
+--create types
+CREATE TYPE UserTypes AS ENUM('CUSTOMER','ADMIN');
+CREATE TYPE LoanStatus AS ENUM('PENDING','APPROVED','REJECTED','CANCELLED','PAID');
+CREATE TYPE LoanTransactionStatus AS ENUM('PENDING','PAID','CANCELLED');
+CREATE TYPE DocumentTypes AS ENUM('ID_PROOF','INCOME_PROOF');
+CREATE TYPE DocumentStatus AS ENUM('PENDING','VERIFIED','REJECTED');
+CREATE TYPE VerificationStatus AS ENUM('PENDING','VERIFIED','EXPIRED');
+CREATE TYPE RefreshTokenStatus AS ENUM('ACTIVE','ROTATED','REVOKED');
+
+-- create a function for timestamp
+CREATE OR REPLACE FUNCTION trigger_set_timestamp()
+RETURNS TRIGGER AS $$
+BEGIN
+  NEW.updated_at = NOW();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+--create tables
+CREATE TABLE user_detail(
+   id serial,
+   user_name text not null unique,
+   password text not null,
+   user_type UserTypes not null,
+   email text not null, 
+   mobile text not null,
+   monthly_salary float DEFAULT 0.0,
+   acc_bal float DEFAULT 0.0,
+   token_version int not null DEFAULT 0,
+   created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+   PRIMARY KEY(id)
+);
+
+CREATE TABLE loan(
+    id serial,
+    user_id int not null,
+    amount float not null,
+    tenure int not null,
+    status LoanStatus not null,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE installment(
+    id serial,
+    loan_id int not null,
+    amount_due float not null,
+    amount_paid float default 0,
+    status LoanTransactionStatus not null,
+    installment_num int not null,
+    due_date timestamp not null,
+    transaction_id text,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_loanid
+   		FOREIGN KEY(loan_id) 
+		REFERENCES loan(id)
+);
+
+CREATE TABLE user_document(
+    id serial,
+    user_id int not null,
+    doc_type DocumentTypes not null,
+    file_name text not null,
+    content_type text not null,
+    storage_key text not null unique,
+    size bigint not null,
+    status DocumentStatus not null,
+    remarks text,
+    verified_by int,
+    verified_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id),
+    CONSTRAINT fk_verifiedby
+   		FOREIGN KEY(verified_by) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE user_income(
+    id serial,
+    user_id int not null,
+    monthly_salary float not null,
+    verified boolean not null DEFAULT false,
+    verified_by int,
+    verified_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE user_profile_history(
+    id serial,
+    user_id int not null,
+    field text not null,
+    old_value text,
+    new_value text,
+    changed_by int not null,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE contact_verification(
+    id serial,
+    user_id int not null,
+    channel text not null,
+    new_value text not null,
+    otp_hash text not null,
+    attempts int not null DEFAULT 0,
+    status VerificationStatus not null,
+    expires_at timestamp not null,
+    verified_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE password_reset(
+    id serial,
+    user_id int not null,
+    token_hash text not null unique,
+    expires_at timestamp not null,
+    used_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE refresh_token(
+    id serial,
+    user_id int not null,
+    token_hash text not null unique,
+    family_id text not null,
+    status RefreshTokenStatus not null DEFAULT 'ACTIVE',
+    expires_at timestamp not null,
+    rotated_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE INDEX idx_refresh_token_family ON refresh_token(family_id);
+
+CREATE TABLE revoked_token(
+    jti text not null,
+    user_id int not null,
+    expires_at timestamp not null,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(jti),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE admin_invite(
+    id serial,
+    code_hash text not null unique,
+    email text not null,
+    created_by int not null,
+    expires_at timestamp not null,
+    used_by int,
+    used_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_created_by
+   		FOREIGN KEY(created_by) 
+		REFERENCES user_detail(id),
+    CONSTRAINT fk_used_by
+   		FOREIGN KEY(used_by) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE role(
+    id serial,
+    name text not null unique,
+    description text,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE TABLE permission(
+    id serial,
+    name text not null unique,
+    description text,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id)
+);
+
+CREATE TABLE role_permission(
+    role_id int not null,
+    permission_id int not null,
+    PRIMARY KEY(role_id, permission_id),
+    CONSTRAINT fk_roleid
+   		FOREIGN KEY(role_id) 
+		REFERENCES role(id),
+    CONSTRAINT fk_permissionid
+   		FOREIGN KEY(permission_id) 
+		REFERENCES permission(id)
+);
+
+CREATE TABLE user_role(
+    user_id int not null,
+    role_id int not null,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(user_id, role_id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id),
+    CONSTRAINT fk_roleid
+   		FOREIGN KEY(role_id) 
+		REFERENCES role(id)
+);
+
+CREATE TABLE login_throttle(
+    subject text not null,
+    failures int not null DEFAULT 0,
+    last_failure_at timestamp,
+    locked_until timestamp,
+    PRIMARY KEY(subject)
+);
+
+CREATE TABLE user_totp(
+    user_id int not null,
+    secret text not null,
+    enabled boolean not null DEFAULT false,
+    last_used_step bigint not null DEFAULT 0,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    enabled_at timestamp,
+    PRIMARY KEY(user_id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE totp_recovery_code(
+    id serial,
+    user_id int not null,
+    code_hash text not null,
+    used_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE login_challenge(
+    id serial,
+    user_id int not null,
+    token_hash text not null unique,
+    expires_at timestamp not null,
+    attempts int not null DEFAULT 0,
+    used_at timestamp,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE signing_key(
+    kid text not null,
+    algorithm text not null,
+    private_key text not null,
+    created_at timestamp not null DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(kid)
+);
+
+CREATE TABLE service_account(
+    id serial,
+    name text not null unique,
+    description text,
+    created_by int not null,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_createdby
+   		FOREIGN KEY(created_by) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE api_key(
+    id serial,
+    service_account_id int not null,
+    prefix text not null unique,
+    key_hash text not null unique,
+    expires_at timestamp not null,
+    last_used_at timestamp,
+    revoked_at timestamp,
+    created_by int not null,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_serviceaccountid
+   		FOREIGN KEY(service_account_id) 
+		REFERENCES service_account(id),
+    CONSTRAINT fk_createdby
+   		FOREIGN KEY(created_by) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE api_key_scope(
+    api_key_id int not null,
+    permission_id int not null,
+    PRIMARY KEY(api_key_id, permission_id),
+    CONSTRAINT fk_apikeyid
+   		FOREIGN KEY(api_key_id) 
+		REFERENCES api_key(id),
+    CONSTRAINT fk_permissionid
+   		FOREIGN KEY(permission_id) 
+		REFERENCES permission(id)
+);
+
+-- seed roles and permissions
+INSERT INTO role(name, description) VALUES
+    ('CUSTOMER', 'applies for and repays own loans'),
+    ('ADMIN', 'approves loans, verifies documents and manages admins'),
+    ('AUDITOR', 'read only access to all loans and documents'),
+    ('SUPPORT', 'read only access to all loans and unlocks accounts'),
+    ('COLLECTIONS', 'read only access to all loans for follow up on repayments');
+
+INSERT INTO permission(name, description) VALUES
+    ('loan:write:own', 'apply, modify and cancel own loans'),
+    ('loan:read:own', 'view own loans and installments'),
+    ('loan:read:any', 'view loans of all users'),
+    ('loan:approve', 'approve or reject loan applications'),
+    ('payment:create:own', 'repay own loans'),
+    ('payment:create:any', 'record repayments for any customer'),
+    ('profile:read:own', 'view own profile'),
+    ('profile:write:own', 'update own profile'),
+    ('document:read:own', 'view own documents'),
+    ('document:write:own', 'upload own documents'),
+    ('document:read:any', 'view and download documents of all users'),
+    ('document:verify', 'verify or reject documents'),
+    ('admin:invite', 'invite new admins'),
+    ('role:read', 'view roles and permissions'),
+    ('role:assign', 'assign roles to users'),
+    ('user:unlock', 'clear failed logins and lockouts'),
+    ('service_account:manage', 'manage service accounts and their api keys');
+
+INSERT INTO role_permission(role_id, permission_id)
+SELECT r.id, p.id FROM role r, permission p
+WHERE (r.name = 'CUSTOMER' AND p.name IN ('loan:write:own', 'loan:read:own', 'payment:create:own', 'profile:read:own', 'profile:write:own', 'document:read:own', 'document:write:own'))
+    OR (r.name = 'ADMIN' AND p.name IN ('loan:read:any', 'loan:approve', 'document:read:any', 'document:verify', 'admin:invite', 'role:read', 'role:assign', 'user:unlock', 'service_account:manage'))
+    OR (r.name = 'AUDITOR' AND p.name IN ('loan:read:any', 'document:read:any', 'role:read'))
+    OR (r.name = 'SUPPORT' AND p.name IN ('loan:read:any', 'user:unlock'))
+    OR (r.name = 'COLLECTIONS' AND p.name IN ('loan:read:any'));
+
+-- create a trigger for timestamp
+CREATE TRIGGER set_timestamp
+AFTER UPDATE ON user_detail
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+AFTER UPDATE ON loan
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+AFTER UPDATE ON installment
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+AFTER UPDATE ON user_document
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
\ No newline at end of file
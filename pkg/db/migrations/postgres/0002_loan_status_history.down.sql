@@ -0,0 +1,27 @@
+-- drop the loan status history and put back the AFTER UPDATE triggers
+DROP TRIGGER set_timestamp ON user_detail;
+DROP TRIGGER set_timestamp ON loan;
+DROP TRIGGER set_timestamp ON installment;
+DROP TRIGGER set_timestamp ON user_document;
+
+CREATE TRIGGER set_timestamp
+AFTER UPDATE ON user_detail
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+AFTER UPDATE ON loan
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+AFTER UPDATE ON installment
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+AFTER UPDATE ON user_document
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+DROP TABLE IF EXISTS loan_status_history;
@@ -0,0 +1,53 @@
+-- loan status history: every status change of a loan with the actor who made it and why. the updated_at triggers
+-- become BEFORE UPDATE, as the row returned by an AFTER UPDATE trigger is ignored and updated_at never changed
+
+CREATE TABLE loan_status_history(
+    id serial,
+    loan_id int not null,
+    from_status LoanStatus,
+    to_status LoanStatus not null,
+    actor text not null,
+    actor_id int,
+    reason text,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_loanid
+   		FOREIGN KEY(loan_id) 
+		REFERENCES loan(id)
+);
+
+CREATE INDEX idx_loan_status_history_loan ON loan_status_history(loan_id);
+
+-- existing loans get their application and, when it moved on, their current status. when the change happened
+-- is not known any better than the last update of the loan
+INSERT INTO loan_status_history(loan_id, from_status, to_status, actor, actor_id, reason, created_at)
+SELECT id, NULL, 'PENDING', 'CUSTOMER', user_id, 'applied for loan', created_at FROM loan;
+
+INSERT INTO loan_status_history(loan_id, from_status, to_status, actor, reason, created_at)
+SELECT id, CASE WHEN status = 'PAID' THEN 'APPROVED'::LoanStatus ELSE 'PENDING'::LoanStatus END, status, 'SYSTEM', 'recorded before the status history', updated_at
+FROM loan WHERE status <> 'PENDING';
+
+DROP TRIGGER set_timestamp ON user_detail;
+DROP TRIGGER set_timestamp ON loan;
+DROP TRIGGER set_timestamp ON installment;
+DROP TRIGGER set_timestamp ON user_document;
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON user_detail
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON loan
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON installment
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+CREATE TRIGGER set_timestamp
+BEFORE UPDATE ON user_document
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
@@ -0,0 +1,6 @@
+-- drop the audit log and the permission to read it
+DELETE FROM role_permission WHERE permission_id IN (SELECT id FROM permission WHERE name = 'audit:read');
+DELETE FROM permission WHERE name = 'audit:read';
+
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS trigger_audit_log_append_only();
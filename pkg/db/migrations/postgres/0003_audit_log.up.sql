@@ -0,0 +1,51 @@
+-- audit log: append-only record of admin and money actions. every entry carries the hash of the entry before it,
+-- so a changed, removed or inserted entry breaks the chain. prev_hash is unique so the chain cannot fork when two
+-- entries are written at once, and the triggers refuse any change to a written entry
+
+CREATE TABLE audit_log(
+    id serial,
+    actor_type text not null,
+    actor_id int,
+    actor text,
+    roles text,
+    action text not null,
+    outcome text not null,
+    target_type text,
+    target_id text,
+    before_state text,
+    after_state text,
+    request_id text,
+    ip text,
+    created_at timestamp not null,
+    prev_hash text not null UNIQUE,
+    hash text not null UNIQUE,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id);
+CREATE INDEX idx_audit_log_target ON audit_log(target_type, target_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+CREATE OR REPLACE FUNCTION trigger_audit_log_append_only()
+RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+BEFORE UPDATE OR DELETE ON audit_log
+FOR EACH ROW
+EXECUTE PROCEDURE trigger_audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+BEFORE TRUNCATE ON audit_log
+FOR EACH STATEMENT
+EXECUTE PROCEDURE trigger_audit_log_append_only();
+
+INSERT INTO permission(name, description) VALUES
+    ('audit:read', 'view and filter the audit log');
+
+INSERT INTO role_permission(role_id, permission_id)
+SELECT r.id, p.id FROM role r, permission p
+WHERE r.name IN ('ADMIN', 'AUDITOR') AND p.name = 'audit:read';
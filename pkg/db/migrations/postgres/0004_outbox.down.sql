@@ -0,0 +1,3 @@
+-- drop the outbox and every event not delivered yet
+DROP TABLE IF EXISTS outbox_event;
+DROP TYPE IF EXISTS OutboxStatus;
@@ -0,0 +1,22 @@
+-- outbox: domain events written in the transaction of the change they announce. the relay delivers PENDING events
+-- whose next_attempt_at has passed to the sinks and marks them DELIVERED, or FAILED once they run out of attempts
+
+CREATE TYPE OutboxStatus AS ENUM('PENDING','DELIVERED','FAILED');
+
+CREATE TABLE outbox_event(
+    id bigserial,
+    event_type text not null,
+    aggregate_type text not null,
+    aggregate_id bigint not null,
+    payload text not null,
+    status OutboxStatus not null DEFAULT 'PENDING',
+    attempts int not null DEFAULT 0,
+    next_attempt_at timestamp not null,
+    last_error text,
+    created_at timestamp not null,
+    delivered_at timestamp,
+    PRIMARY KEY(id)
+);
+
+CREATE INDEX idx_outbox_event_due ON outbox_event(status, next_attempt_at);
+CREATE INDEX idx_outbox_event_aggregate ON outbox_event(aggregate_type, aggregate_id);
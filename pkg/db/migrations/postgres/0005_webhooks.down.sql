@@ -0,0 +1,7 @@
+-- drop the webhooks and the permission to manage them
+DELETE FROM role_permission WHERE permission_id IN (SELECT id FROM permission WHERE name = 'webhook:manage');
+DELETE FROM permission WHERE name = 'webhook:manage';
+
+DROP TABLE IF EXISTS webhook_delivery;
+DROP TABLE IF EXISTS webhook_subscription;
+DROP TYPE IF EXISTS WebhookDeliveryStatus;
@@ -0,0 +1,50 @@
+-- webhooks: partner subscriptions to outbox events and one delivery per subscription and event. a delivery is
+-- retried while PENDING and moves to DEAD once it runs out of attempts. removing a subscription removes its log
+
+CREATE TYPE WebhookDeliveryStatus AS ENUM('PENDING','DELIVERED','DEAD');
+
+CREATE TABLE webhook_subscription(
+    id serial,
+    url text not null,
+    event_types text not null,
+    secret text not null,
+    description text,
+    active boolean not null DEFAULT true,
+    created_by int not null,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY(id),
+    CONSTRAINT fk_createdby
+   		FOREIGN KEY(created_by) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE webhook_delivery(
+    id bigserial,
+    subscription_id int not null,
+    event_id bigint not null,
+    event_type text not null,
+    body text not null,
+    status WebhookDeliveryStatus not null DEFAULT 'PENDING',
+    attempts int not null DEFAULT 0,
+    next_attempt_at timestamp not null,
+    response_code int,
+    last_error text,
+    created_at timestamp not null,
+    delivered_at timestamp,
+    PRIMARY KEY(id),
+    UNIQUE(subscription_id, event_id),
+    CONSTRAINT fk_subscriptionid
+   		FOREIGN KEY(subscription_id) 
+		REFERENCES webhook_subscription(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_delivery_due ON webhook_delivery(status, next_attempt_at);
+
+INSERT INTO permission(name, description) VALUES
+    ('webhook:manage', 'manage webhook subscriptions and their deliveries');
+
+INSERT INTO role_permission(role_id, permission_id)
+SELECT r.id, p.id FROM role r, permission p
+WHERE r.name = 'ADMIN' AND p.name = 'webhook:manage';
@@ -0,0 +1,3 @@
+-- drop the payment events
+DROP TABLE IF EXISTS payment_event;
+DROP TYPE IF EXISTS PaymentEventStatus;
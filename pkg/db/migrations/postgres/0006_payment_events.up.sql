@@ -0,0 +1,28 @@
+-- payment events: notifications received from the payment provider. an event is kept once per provider and event
+-- id so a notification sent again is not applied twice. it is RECEIVED while it is being applied, then APPLIED,
+-- REJECTED when it could not be matched to a loan or repaid, or IGNORED when it is not a payment
+
+CREATE TYPE PaymentEventStatus AS ENUM('RECEIVED','APPLIED','REJECTED','IGNORED');
+
+CREATE TABLE payment_event(
+    id bigserial,
+    provider text not null,
+    provider_event_id text not null,
+    event_type text not null,
+    payment_id text,
+    reference text,
+    amount float,
+    loan_id int,
+    status PaymentEventStatus not null DEFAULT 'RECEIVED',
+    error text,
+    payload text not null,
+    received_at timestamp not null,
+    processed_at timestamp,
+    PRIMARY KEY(id),
+    UNIQUE(provider, provider_event_id),
+    CONSTRAINT fk_loanid
+   		FOREIGN KEY(loan_id) 
+		REFERENCES loan(id)
+);
+
+CREATE INDEX idx_payment_event_status ON payment_event(status, id);
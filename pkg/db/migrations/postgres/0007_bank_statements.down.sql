@@ -0,0 +1,7 @@
+-- drop the bank statements and the permission to reconcile them
+DELETE FROM role_permission WHERE permission_id IN (SELECT id FROM permission WHERE name = 'reconciliation:manage');
+DELETE FROM permission WHERE name = 'reconciliation:manage';
+
+DROP TABLE IF EXISTS statement_line;
+DROP TABLE IF EXISTS bank_statement;
+DROP TYPE IF EXISTS StatementLineStatus;
@@ -0,0 +1,57 @@
+-- bank statements: imported statements and their credit lines. a line matched to a loan is APPLIED as a repayment,
+-- any other line is an EXCEPTION until an admin applies it to a loan (RESOLVED) or sets it aside (DISMISSED).
+-- a line is RECEIVED while it is being matched. a statement and a bank reference are imported once
+
+CREATE TYPE StatementLineStatus AS ENUM('RECEIVED','APPLIED','EXCEPTION','RESOLVED','DISMISSED');
+
+CREATE TABLE bank_statement(
+    id serial,
+    file_name text not null,
+    format text not null,
+    checksum text not null,
+    imported_by int,
+    imported_at timestamp not null,
+    PRIMARY KEY(id),
+    UNIQUE(checksum),
+    CONSTRAINT fk_importedby
+   		FOREIGN KEY(imported_by) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE statement_line(
+    id bigserial,
+    statement_id int not null,
+    line_num int not null,
+    booked_on timestamp,
+    amount float not null,
+    reference text,
+    counterparty text,
+    bank_reference text,
+    status StatementLineStatus not null DEFAULT 'RECEIVED',
+    loan_id int,
+    match_rule text,
+    reason text,
+    resolved_by int,
+    resolved_at timestamp,
+    note text,
+    PRIMARY KEY(id),
+    UNIQUE(bank_reference),
+    CONSTRAINT fk_statementid
+   		FOREIGN KEY(statement_id) 
+		REFERENCES bank_statement(id),
+    CONSTRAINT fk_loanid
+   		FOREIGN KEY(loan_id) 
+		REFERENCES loan(id),
+    CONSTRAINT fk_resolvedby
+   		FOREIGN KEY(resolved_by) 
+		REFERENCES user_detail(id)
+);
+
+CREATE INDEX idx_statement_line_status ON statement_line(status, id);
+
+INSERT INTO permission(name, description) VALUES
+    ('reconciliation:manage', 'import bank statements and resolve the lines not matched to a loan');
+
+INSERT INTO role_permission(role_id, permission_id)
+SELECT r.id, p.id FROM role r, permission p
+WHERE r.name = 'ADMIN' AND p.name = 'reconciliation:manage';
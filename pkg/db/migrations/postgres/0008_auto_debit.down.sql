@@ -0,0 +1,5 @@
+-- drop the auto debit mandates and their debits
+DROP TABLE IF EXISTS auto_debit;
+DROP TABLE IF EXISTS debit_mandate;
+DROP TYPE IF EXISTS AutoDebitStatus;
+DROP TYPE IF EXISTS MandateStatus;
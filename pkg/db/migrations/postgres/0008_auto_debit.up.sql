@@ -0,0 +1,54 @@
+-- auto debit: customer mandates to debit the installments of a loan from their account on the due date, and one
+-- debit per installment falling due under an ACTIVE mandate. a debit is retried while PENDING and is FAILED once it
+-- runs out of attempts, SUCCEEDED when the installment was repaid from the account, or CANCELLED when the
+-- installment was paid otherwise or the mandate was cancelled first
+
+CREATE TYPE MandateStatus AS ENUM('ACTIVE','CANCELLED');
+CREATE TYPE AutoDebitStatus AS ENUM('PENDING','SUCCEEDED','FAILED','CANCELLED');
+
+CREATE TABLE debit_mandate(
+    id serial,
+    user_id int not null,
+    loan_id int not null,
+    status MandateStatus not null DEFAULT 'ACTIVE',
+    created_at timestamp not null,
+    cancelled_at timestamp,
+    PRIMARY KEY(id),
+    UNIQUE(loan_id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id),
+    CONSTRAINT fk_loanid
+   		FOREIGN KEY(loan_id) 
+		REFERENCES loan(id)
+);
+
+CREATE TABLE auto_debit(
+    id bigserial,
+    mandate_id int not null,
+    user_id int not null,
+    loan_id int not null,
+    installment_num int not null,
+    amount float not null,
+    due_date timestamp not null,
+    status AutoDebitStatus not null DEFAULT 'PENDING',
+    attempts int not null DEFAULT 0,
+    next_attempt_at timestamp not null,
+    transaction_id text,
+    last_error text,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    PRIMARY KEY(id),
+    UNIQUE(loan_id, installment_num),
+    CONSTRAINT fk_mandateid
+   		FOREIGN KEY(mandate_id) 
+		REFERENCES debit_mandate(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id),
+    CONSTRAINT fk_loanid
+   		FOREIGN KEY(loan_id) 
+		REFERENCES loan(id)
+);
+
+CREATE INDEX idx_auto_debit_due ON auto_debit(status, next_attempt_at);
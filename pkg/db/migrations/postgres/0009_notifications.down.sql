@@ -0,0 +1,5 @@
+-- drop the notifications and the notification preferences
+DROP TABLE IF EXISTS notification;
+DROP TABLE IF EXISTS notification_preference;
+DROP TYPE IF EXISTS NotificationStatus;
+DROP TYPE IF EXISTS NotificationChannel;
@@ -0,0 +1,10 @@
+-- drop the account debits, and REFUND_FAILED auto debits are FAILED again
+DROP TABLE IF EXISTS account_debit;
+DROP TYPE IF EXISTS AccountDebitStatus;
+ALTER TABLE auto_debit ALTER COLUMN status DROP DEFAULT;
+ALTER TABLE auto_debit ALTER COLUMN status TYPE text;
+UPDATE auto_debit SET status = 'FAILED' WHERE status = 'REFUND_FAILED';
+DROP TYPE AutoDebitStatus;
+CREATE TYPE AutoDebitStatus AS ENUM('PENDING','SUCCEEDED','FAILED','CANCELLED');
+ALTER TABLE auto_debit ALTER COLUMN status TYPE AutoDebitStatus USING status::AutoDebitStatus;
+ALTER TABLE auto_debit ALTER COLUMN status SET DEFAULT 'PENDING';
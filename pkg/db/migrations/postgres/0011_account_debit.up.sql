@@ -0,0 +1,20 @@
+-- account debits: the debits taken from the account balance, one per reference, so a debit attempted again after a
+-- crash or an expired lease takes the money once. a refunded debit is REFUNDED and may be debited again. an auto
+-- debit whose repayment failed and whose refund failed too is REFUND_FAILED and is not attempted again, an admin
+-- gives the money back by hand
+
+ALTER TYPE AutoDebitStatus ADD VALUE 'REFUND_FAILED';
+CREATE TYPE AccountDebitStatus AS ENUM('DEBITED','REFUNDED');
+
+CREATE TABLE account_debit(
+    reference text not null,
+    user_id int not null,
+    amount float not null,
+    status AccountDebitStatus not null DEFAULT 'DEBITED',
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    PRIMARY KEY(reference),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
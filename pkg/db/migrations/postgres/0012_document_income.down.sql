@@ -0,0 +1,2 @@
+-- drop the income an income proof was uploaded for
+ALTER TABLE user_document DROP COLUMN income_id;
@@ -0,0 +1,6 @@
+-- an income proof is uploaded for the monthly salary declared at the time, kept in income_id, and verifying it
+-- verifies that income only. income proofs uploaded before take the income declared when they were uploaded
+ALTER TABLE user_document ADD COLUMN income_id int REFERENCES user_income(id);
+UPDATE user_document SET income_id = (
+    select max(i.id) from user_income i where i.user_id = user_document.user_id and i.created_at <= user_document.created_at
+) WHERE doc_type = 'INCOME_PROOF';
@@ -0,0 +1,2 @@
+-- drop the product of loans
+ALTER TABLE loan DROP COLUMN product;
@@ -0,0 +1,2 @@
+-- drop the loan status history
+DROP TABLE IF EXISTS loan_status_history;
@@ -0,0 +1,27 @@
+-- loan status history: every status change of a loan with the actor who made it and why. the sqlite updated_at
+-- triggers already update the row, so only the table is added
+
+CREATE TABLE loan_status_history(
+    id integer primary key autoincrement,
+    loan_id int not null,
+    from_status text CHECK(from_status IN ('PENDING', 'APPROVED', 'REJECTED', 'CANCELLED', 'PAID')),
+    to_status text not null CHECK(to_status IN ('PENDING', 'APPROVED', 'REJECTED', 'CANCELLED', 'PAID')),
+    actor text not null,
+    actor_id int,
+    reason text,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_loanid
+   		FOREIGN KEY(loan_id) 
+		REFERENCES loan(id)
+);
+
+CREATE INDEX idx_loan_status_history_loan ON loan_status_history(loan_id);
+
+-- existing loans get their application and, when it moved on, their current status. when the change happened
+-- is not known any better than the last update of the loan
+INSERT INTO loan_status_history(loan_id, from_status, to_status, actor, actor_id, reason, created_at)
+SELECT id, NULL, 'PENDING', 'CUSTOMER', user_id, 'applied for loan', created_at FROM loan;
+
+INSERT INTO loan_status_history(loan_id, from_status, to_status, actor, reason, created_at)
+SELECT id, CASE WHEN status = 'PAID' THEN 'APPROVED' ELSE 'PENDING' END, status, 'SYSTEM', 'recorded before the status history', updated_at
+FROM loan WHERE status <> 'PENDING';
@@ -0,0 +1,45 @@
+-- audit log: append-only record of admin and money actions. every entry carries the hash of the entry before it,
+-- so a changed, removed or inserted entry breaks the chain. prev_hash is unique so the chain cannot fork when two
+-- entries are written at once. sqlite triggers abort the statement instead of raising from a function
+
+CREATE TABLE audit_log(
+    id integer primary key autoincrement,
+    actor_type text not null,
+    actor_id int,
+    actor text,
+    roles text,
+    action text not null,
+    outcome text not null,
+    target_type text,
+    target_id text,
+    before_state text,
+    after_state text,
+    request_id text,
+    ip text,
+    created_at timestamp not null,
+    prev_hash text not null UNIQUE,
+    hash text not null UNIQUE
+);
+
+CREATE INDEX idx_audit_log_actor ON audit_log(actor_id);
+CREATE INDEX idx_audit_log_target ON audit_log(target_type, target_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+CREATE TRIGGER audit_log_no_update
+BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER audit_log_no_delete
+BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+INSERT INTO permission(name, description) VALUES
+    ('audit:read', 'view and filter the audit log');
+
+INSERT INTO role_permission(role_id, permission_id)
+SELECT r.id, p.id FROM role r, permission p
+WHERE r.name IN ('ADMIN', 'AUDITOR') AND p.name = 'audit:read';
@@ -0,0 +1,2 @@
+-- drop the outbox and every event not delivered yet
+DROP TABLE IF EXISTS outbox_event;
@@ -0,0 +1,19 @@
+-- outbox: domain events written in the transaction of the change they announce. the relay delivers PENDING events
+-- whose next_attempt_at has passed to the sinks and marks them DELIVERED, or FAILED once they run out of attempts
+
+CREATE TABLE outbox_event(
+    id integer primary key autoincrement,
+    event_type text not null,
+    aggregate_type text not null,
+    aggregate_id int not null,
+    payload text not null,
+    status text not null DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'DELIVERED', 'FAILED')),
+    attempts int not null DEFAULT 0,
+    next_attempt_at timestamp not null,
+    last_error text,
+    created_at timestamp not null,
+    delivered_at timestamp
+);
+
+CREATE INDEX idx_outbox_event_due ON outbox_event(status, next_attempt_at);
+CREATE INDEX idx_outbox_event_aggregate ON outbox_event(aggregate_type, aggregate_id);
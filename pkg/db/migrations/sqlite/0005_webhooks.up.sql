@@ -0,0 +1,46 @@
+-- webhooks: partner subscriptions to outbox events and one delivery per subscription and event. a delivery is
+-- retried while PENDING and moves to DEAD once it runs out of attempts. removing a subscription removes its log
+
+CREATE TABLE webhook_subscription(
+    id integer primary key autoincrement,
+    url text not null,
+    event_types text not null,
+    secret text not null,
+    description text,
+    active boolean not null DEFAULT true,
+    created_by int not null,
+    created_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    updated_at timestamp DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_createdby
+   		FOREIGN KEY(created_by) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE webhook_delivery(
+    id integer primary key autoincrement,
+    subscription_id int not null,
+    event_id int not null,
+    event_type text not null,
+    body text not null,
+    status text not null DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'DELIVERED', 'DEAD')),
+    attempts int not null DEFAULT 0,
+    next_attempt_at timestamp not null,
+    response_code int,
+    last_error text,
+    created_at timestamp not null,
+    delivered_at timestamp,
+    UNIQUE(subscription_id, event_id),
+    CONSTRAINT fk_subscriptionid
+   		FOREIGN KEY(subscription_id) 
+		REFERENCES webhook_subscription(id)
+		ON DELETE CASCADE
+);
+
+CREATE INDEX idx_webhook_delivery_due ON webhook_delivery(status, next_attempt_at);
+
+INSERT INTO permission(name, description) VALUES
+    ('webhook:manage', 'manage webhook subscriptions and their deliveries');
+
+INSERT INTO role_permission(role_id, permission_id)
+SELECT r.id, p.id FROM role r, permission p
+WHERE r.name = 'ADMIN' AND p.name = 'webhook:manage';
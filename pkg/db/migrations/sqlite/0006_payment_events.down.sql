@@ -0,0 +1,2 @@
+-- drop the payment events
+DROP TABLE IF EXISTS payment_event;
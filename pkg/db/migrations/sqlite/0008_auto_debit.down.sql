@@ -0,0 +1,3 @@
+-- drop the auto debit mandates and their debits
+DROP TABLE IF EXISTS auto_debit;
+DROP TABLE IF EXISTS debit_mandate;
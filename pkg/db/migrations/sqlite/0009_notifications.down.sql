@@ -0,0 +1,3 @@
+-- drop the notifications and the notification preferences
+DROP TABLE IF EXISTS notification;
+DROP TABLE IF EXISTS notification_preference;
@@ -0,0 +1,40 @@
+-- notifications: the channels each customer wants to be told on, and every notification rendered for them. EMAIL and
+-- SMS notifications are queued PENDING and retried until SENT, or FAILED once they run out of attempts. IN_APP
+-- notifications are SENT as soon as they are stored, they are read in the app. dedup_key holds one notification
+-- per occurrence of an event on a channel
+
+CREATE TABLE notification_preference(
+    user_id int not null,
+    channel text not null CHECK(channel IN ('EMAIL', 'SMS', 'IN_APP')),
+    enabled boolean not null,
+    updated_at timestamp not null,
+    PRIMARY KEY(user_id, channel),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE TABLE notification(
+    id integer primary key autoincrement,
+    user_id int not null,
+    event_type text not null,
+    channel text not null CHECK(channel IN ('EMAIL', 'SMS', 'IN_APP')),
+    recipient text not null,
+    subject text not null,
+    body text not null,
+    dedup_key text not null,
+    status text not null DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'SENT', 'FAILED')),
+    attempts int not null DEFAULT 0,
+    next_attempt_at timestamp not null,
+    last_error text,
+    created_at timestamp not null,
+    sent_at timestamp,
+    read_at timestamp,
+    UNIQUE(channel, dedup_key),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+CREATE INDEX idx_notification_due ON notification(status, next_attempt_at);
+CREATE INDEX idx_notification_user ON notification(user_id, channel, id);
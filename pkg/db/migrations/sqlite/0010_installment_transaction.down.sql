@@ -0,0 +1,2 @@
+-- drop the unique transaction id of installments
+DROP INDEX IF EXISTS installment_transaction_id_key;
@@ -0,0 +1,10 @@
+-- a transaction id pays at most one installment. installments written without a payment held an empty transaction
+-- id, they hold none so they do not collide on the index. a transaction id reused before it was unique is kept by
+-- the first installment it paid, the later ones are renamed to <transaction id>#<installment id>
+UPDATE installment SET transaction_id = NULL WHERE transaction_id = '';
+UPDATE installment SET transaction_id = transaction_id || '#' || id
+WHERE EXISTS (
+    SELECT 1 FROM installment earlier
+    WHERE earlier.transaction_id = installment.transaction_id AND earlier.id < installment.id
+);
+CREATE UNIQUE INDEX installment_transaction_id_key ON installment(transaction_id);
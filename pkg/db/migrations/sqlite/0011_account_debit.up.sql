@@ -0,0 +1,50 @@
+-- account debits: the debits taken from the account balance, one per reference, so a debit attempted again after a
+-- crash or an expired lease takes the money once. a refunded debit is REFUNDED and may be debited again. an auto
+-- debit whose repayment failed and whose refund failed too is REFUND_FAILED and is not attempted again, an admin
+-- gives the money back by hand
+
+CREATE TABLE account_debit(
+    reference text primary key,
+    user_id int not null,
+    amount float not null,
+    status text not null DEFAULT 'DEBITED' CHECK(status IN ('DEBITED', 'REFUNDED')),
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id)
+);
+
+-- sqlite cannot change a CHECK constraint, the table is copied into one allowing REFUND_FAILED
+CREATE TABLE auto_debit_new(
+    id integer primary key autoincrement,
+    mandate_id int not null,
+    user_id int not null,
+    loan_id int not null,
+    installment_num int not null,
+    amount float not null,
+    due_date timestamp not null,
+    status text not null DEFAULT 'PENDING' CHECK(status IN ('PENDING', 'SUCCEEDED', 'FAILED', 'CANCELLED', 'REFUND_FAILED')),
+    attempts int not null DEFAULT 0,
+    next_attempt_at timestamp not null,
+    transaction_id text,
+    last_error text,
+    created_at timestamp not null,
+    updated_at timestamp not null,
+    UNIQUE(loan_id, installment_num),
+    CONSTRAINT fk_mandateid
+   		FOREIGN KEY(mandate_id) 
+		REFERENCES debit_mandate(id),
+    CONSTRAINT fk_userid
+   		FOREIGN KEY(user_id) 
+		REFERENCES user_detail(id),
+    CONSTRAINT fk_loanid
+   		FOREIGN KEY(loan_id) 
+		REFERENCES loan(id)
+);
+
+INSERT INTO auto_debit_new(id, mandate_id, user_id, loan_id, installment_num, amount, due_date, status, attempts, next_attempt_at, transaction_id, last_error, created_at, updated_at)
+    SELECT id, mandate_id, user_id, loan_id, installment_num, amount, due_date, status, attempts, next_attempt_at, transaction_id, last_error, created_at, updated_at FROM auto_debit;
+DROP TABLE auto_debit;
+ALTER TABLE auto_debit_new RENAME TO auto_debit;
+CREATE INDEX idx_auto_debit_due ON auto_debit(status, next_attempt_at);
@@ -0,0 +1,2 @@
+-- drop the lease of received payment events
+ALTER TABLE payment_event DROP COLUMN lease_until;
@@ -0,0 +1,3 @@
+-- a received payment event is applied under a lease. an event left RECEIVED by a crash or a failed write is taken
+-- over by the next notification of the provider once lease_until passed. events received before have no lease
+ALTER TABLE payment_event ADD COLUMN lease_until timestamp;
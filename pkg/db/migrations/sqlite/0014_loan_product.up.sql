@@ -0,0 +1,2 @@
+-- the product of a loan keys its approval policy. every loan so far is a personal loan
+ALTER TABLE loan ADD COLUMN product varchar(32) NOT NULL DEFAULT 'PERSONAL';
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"strings"
+)
+
+func (obj *auditDb) AddAuditEntry(ctx context.Context, entry AuditEntry) (int64, error) {
+	query := `
+		insert into
+			audit_log(actor_type, actor_id, actor, roles, action, outcome, target_type, target_id, before_state, after_state, request_id, ip, created_at, prev_hash, hash)
+		values
+			(?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)
+		returning id;
+	`
+
+	var entryId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, entry.ActorType, entry.ActorId, entry.Actor, entry.Roles, entry.Action, entry.Outcome, entry.TargetType, entry.TargetId, entry.BeforeState, entry.AfterState, entry.RequestId, entry.Ip, entry.CreatedAt, entry.PrevHash, entry.Hash).Scan(&entryId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add audit entry. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+
+	return entryId.Int64, nil
+}
+
+// GetLastAuditEntry returns the head of the chain, or an empty entry while the log is empty
+func (obj *auditDb) GetLastAuditEntry(ctx context.Context) (AuditEntry, error) {
+	query := `
+		select
+			` + auditColumns + `
+		from
+			audit_log
+		order by id desc
+		limit 1;
+	`
+
+	var entry AuditEntry
+	row := obj.dbObj.WithContext(ctx).Raw(query).Row()
+	if row.Err() != nil {
+		log.Printf("failed to fetch last audit entry. Error: %s", row.Err().Error())
+		return entry, row.Err()
+	}
+
+	err := scanEntry(row, &entry)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AuditEntry{}, nil
+	}
+	if err != nil {
+		log.Printf("failed to scan audit entry. Error:%s", err.Error())
+		return entry, err
+	}
+	return entry, nil
+}
+
+func (obj *auditDb) GetAuditEntries(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	conditions := []string{"id > ?"}
+	args := []interface{}{filter.AfterId}
+	if filter.ActorId != 0 {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, filter.ActorId)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetId != "" {
+		conditions = append(conditions, "target_id = ?")
+		args = append(args, filter.TargetId)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To)
+	}
+	args = append(args, filter.Limit)
+
+	query := `
+		select
+			` + auditColumns + `
+		from
+			audit_log
+		where
+			` + strings.Join(conditions, " and ") + `
+		order by id
+		limit ?;
+	`
+
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, args...).Rows()
+	if err != nil {
+		log.Printf("failed to fetch audit entries. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]AuditEntry, 0)
+	for rows.Next() {
+		var entry AuditEntry
+		if err := scanEntry(rows, &entry); err != nil {
+			log.Printf("failed to scan audit entry. Error:%s", err.Error())
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+const auditColumns = `id, actor_type, actor_id, actor, roles, action, outcome, target_type, target_id, before_state, after_state, request_id, ip, created_at, prev_hash, hash`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEntry(row scanner, entry *AuditEntry) error {
+	return row.Scan(&entry.EntryId, &entry.ActorType, &entry.ActorId, &entry.Actor, &entry.Roles, &entry.Action, &entry.Outcome, &entry.TargetType, &entry.TargetId, &entry.BeforeState, &entry.AfterState, &entry.RequestId, &entry.Ip, &entry.CreatedAt, &entry.PrevHash, &entry.Hash)
+}
@@ -0,0 +1,23 @@
+package audit
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type auditDb struct {
+	dbObj *gorm.DB
+}
+
+type DbAuditInterface interface {
+	AddAuditEntry(context.Context, AuditEntry) (int64, error)
+	GetLastAuditEntry(context.Context) (AuditEntry, error)
+	GetAuditEntries(context.Context, AuditFilter) ([]AuditEntry, error)
+}
+
+func NewAuditDbObject(db *gorm.DB) DbAuditInterface {
+	return &auditDb{
+		dbObj: db,
+	}
+}
@@ -0,0 +1,39 @@
+package audit
+
+import (
+	"database/sql"
+	"time"
+)
+
+// AuditEntry is a row of the append-only audit log. Hash covers every other column but the id and PrevHash is the
+// Hash of the entry written before it
+type AuditEntry struct {
+	EntryId     sql.NullInt64
+	ActorType   sql.NullString
+	ActorId     sql.NullInt64
+	Actor       sql.NullString
+	Roles       sql.NullString
+	Action      sql.NullString
+	Outcome     sql.NullString
+	TargetType  sql.NullString
+	TargetId    sql.NullString
+	BeforeState sql.NullString
+	AfterState  sql.NullString
+	RequestId   sql.NullString
+	Ip          sql.NullString
+	CreatedAt   sql.NullTime
+	PrevHash    sql.NullString
+	Hash        sql.NullString
+}
+
+// AuditFilter selects audit entries in id order. zero fields do not filter and AfterId pages through the log
+type AuditFilter struct {
+	ActorId    int64
+	Action     string
+	TargetType string
+	TargetId   string
+	From       time.Time
+	To         time.Time
+	AfterId    int64
+	Limit      int
+}
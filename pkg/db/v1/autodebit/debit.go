@@ -0,0 +1,308 @@
+package autodebit
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// GetDueInstallments lists the debits to schedule at now: the next pending installment of every approved loan with
+// an active mandate, once it is due and has no debit yet. a later installment waits for the one before it, so a
+// failed debit holds the loan back until the customer pays the missed installment
+func (obj *autoDebitDb) GetDueInstallments(ctx context.Context, now time.Time, limit int) ([]AutoDebit, error) {
+	query := `
+		select
+			m.id,
+			m.user_id,
+			m.loan_id,
+			i.installment_num,
+			i.amount_due,
+			i.due_date
+		from
+			debit_mandate m
+		inner join
+			loan l
+		on
+			l.id = m.loan_id
+		inner join
+			installment i
+		on
+			i.loan_id = m.loan_id
+		where
+			m.status = 'ACTIVE'
+			and l.status = 'APPROVED'
+			and i.status = 'PENDING'
+			and i.due_date <= ?
+			and i.installment_num = (select min(p.installment_num) from installment p where p.loan_id = m.loan_id and p.status = 'PENDING')
+			and not exists (select 1 from auto_debit d where d.loan_id = m.loan_id and d.installment_num = i.installment_num)
+		order by i.due_date, m.id
+		limit ?;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, now.UTC(), limit).Rows()
+	if err != nil {
+		log.Printf("failed to fetch due installments. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	debits := make([]AutoDebit, 0)
+	for rows.Next() {
+		var debit AutoDebit
+		err := rows.Scan(&debit.MandateId, &debit.UserId, &debit.LoanId, &debit.InstallmentNum, &debit.Amount, &debit.DueDate)
+		if err != nil {
+			log.Printf("failed to scan due installment. Error:%s", err.Error())
+			return nil, err
+		}
+		debits = append(debits, debit)
+	}
+	return debits, nil
+}
+
+// AddAutoDebit schedules the debit of an installment for now and returns 0 when the installment already has one
+func (obj *autoDebitDb) AddAutoDebit(ctx context.Context, debit AutoDebit) (int64, error) {
+	query := `
+		insert into
+			auto_debit(mandate_id, user_id, loan_id, installment_num, amount, due_date, next_attempt_at, created_at, updated_at)
+		values
+			(?,?,?,?,?,?,?,?,?)
+		on conflict (loan_id, installment_num) do nothing
+		returning id;
+	`
+	now := debit.CreatedAt.Time.UTC()
+	var debitId int64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, debit.MandateId.Int64, debit.UserId.Int64, debit.LoanId.Int64, debit.InstallmentNum.Int64, debit.Amount.Float64, debit.DueDate.Time.UTC(), now, now, now).Scan(&debitId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add auto debit. Error :%s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return debitId, nil
+}
+
+// GetDueAutoDebits lists the pending debits due at now, in id order
+func (obj *autoDebitDb) GetDueAutoDebits(ctx context.Context, now time.Time, limit int) ([]AutoDebit, error) {
+	query := `
+		select
+			` + debitColumns + `
+		from
+			auto_debit
+		where
+			status = 'PENDING'
+			and next_attempt_at <= ?
+		order by id
+		limit ?;
+	`
+	return obj.debits(ctx, query, now.UTC(), limit)
+}
+
+// ClaimAutoDebit starts an attempt of a pending debit seen with the given attempts and keeps other schedulers away
+// from it until leaseUntil. it returns false when another scheduler claimed it first
+func (obj *autoDebitDb) ClaimAutoDebit(ctx context.Context, debitId int64, attempts int64, leaseUntil time.Time) (bool, error) {
+	query := `
+		update
+			auto_debit
+		set
+			attempts = attempts + 1,
+			next_attempt_at = ?
+		where
+			id = ?
+			and status = 'PENDING'
+			and attempts = ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, leaseUntil.UTC(), debitId, attempts)
+	if updateTx.Error != nil {
+		log.Printf("failed to claim auto debit. Error :%s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+// CompleteAutoDebit records the outcome of a debit: SUCCEEDED with the Amount debited and the TransactionId of the
+// repayment, FAILED or CANCELLED with the LastError. a debit cancelled with its mandate while the attempt was
+// running is completed all the same, the account was debited by then
+func (obj *autoDebitDb) CompleteAutoDebit(ctx context.Context, debit AutoDebit) error {
+	query := `
+		update
+			auto_debit
+		set
+			status = ?,
+			amount = ?,
+			transaction_id = ?,
+			last_error = ?,
+			updated_at = ?
+		where
+			id = ?
+			and status in ('PENDING', 'CANCELLED');
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, debit.Status.String, debit.Amount.Float64, debit.TransactionId, debit.LastError, debit.UpdatedAt.Time.UTC(), debit.DebitId.Int64)
+	if updateTx.Error != nil {
+		log.Printf("failed to complete auto debit. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// RetryAutoDebit keeps a failed debit for another attempt at nextAttemptAt
+func (obj *autoDebitDb) RetryAutoDebit(ctx context.Context, debitId int64, nextAttemptAt time.Time, lastError string) error {
+	query := `
+		update
+			auto_debit
+		set
+			next_attempt_at = ?,
+			last_error = ?,
+			updated_at = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, nextAttemptAt.UTC(), lastError, time.Now().UTC(), debitId)
+	if updateTx.Error != nil {
+		log.Printf("failed to reschedule auto debit. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// GetAutoDebits lists the debits of a loan in installment order
+func (obj *autoDebitDb) GetAutoDebits(ctx context.Context, loanId int64) ([]AutoDebit, error) {
+	query := `
+		select
+			` + debitColumns + `
+		from
+			auto_debit
+		where
+			loan_id = ?
+		order by installment_num;
+	`
+	return obj.debits(ctx, query, loanId)
+}
+
+// GetAutoDebitsByStatus lists the debits of every loan in a status, in id order from the one after afterId
+func (obj *autoDebitDb) GetAutoDebitsByStatus(ctx context.Context, status string, afterId int64, limit int) ([]AutoDebit, error) {
+	query := `
+		select
+			` + debitColumns + `
+		from
+			auto_debit
+		where
+			status = ?
+			and id > ?
+		order by id
+		limit ?;
+	`
+	return obj.debits(ctx, query, status, afterId, limit)
+}
+
+// DebitAccountBalance takes amount from the account balance of the user under reference and returns false, leaving
+// the balance as it is, when the balance does not cover it. the debit is recorded with the balance update, so a
+// reference already debited returns true without taking the money again
+func (obj *autoDebitDb) DebitAccountBalance(ctx context.Context, userId int64, amount float64, reference string) (bool, error) {
+	recordQuery := `
+		insert into
+			account_debit(reference, user_id, amount, status, created_at, updated_at)
+		values
+			(?,?,?,'DEBITED',?,?)
+		on conflict (reference) do update set
+			amount = excluded.amount,
+			status = 'DEBITED',
+			updated_at = excluded.updated_at
+		where
+			account_debit.status = 'REFUNDED';
+	`
+	debitQuery := `
+		update
+			user_detail
+		set
+			acc_bal = acc_bal - ?
+		where
+			id = ?
+			and acc_bal >= ?;
+	`
+	now := time.Now().UTC()
+	tx := obj.dbObj.Begin()
+	recordTx := tx.WithContext(ctx).Exec(recordQuery, reference, userId, amount, now, now)
+	if recordTx.Error != nil {
+		log.Printf("failed to record account debit. Error :%s", recordTx.Error.Error())
+		tx.Rollback()
+		return false, recordTx.Error
+	}
+	if recordTx.RowsAffected == 0 {
+		log.Printf("account debit %s already taken", reference)
+		tx.Rollback()
+		return true, nil
+	}
+	updateTx := tx.WithContext(ctx).Exec(debitQuery, amount, userId, amount)
+	if updateTx.Error != nil {
+		log.Printf("failed to debit account balance. Error :%s", updateTx.Error.Error())
+		tx.Rollback()
+		return false, updateTx.Error
+	}
+	if updateTx.RowsAffected != 1 {
+		tx.Rollback()
+		return false, nil
+	}
+	return true, tx.Commit().Error
+}
+
+// RefundAccountDebit gives back the amount debited under reference to the account balance of its user. a reference
+// never debited or already refunded is left as it is
+func (obj *autoDebitDb) RefundAccountDebit(ctx context.Context, reference string) error {
+	refundQuery := `
+		update
+			account_debit
+		set
+			status = 'REFUNDED',
+			updated_at = ?
+		where
+			reference = ?
+			and status = 'DEBITED';
+	`
+	creditQuery := `
+		update
+			user_detail
+		set
+			acc_bal = acc_bal + (select d.amount from account_debit d where d.reference = ?)
+		where
+			id = (select d.user_id from account_debit d where d.reference = ?);
+	`
+	tx := obj.dbObj.Begin()
+	refundTx := tx.WithContext(ctx).Exec(refundQuery, time.Now().UTC(), reference)
+	if refundTx.Error != nil {
+		log.Printf("failed to record account refund. Error :%s", refundTx.Error.Error())
+		tx.Rollback()
+		return refundTx.Error
+	}
+	if refundTx.RowsAffected == 0 {
+		tx.Rollback()
+		return nil
+	}
+	creditTx := tx.WithContext(ctx).Exec(creditQuery, reference, reference)
+	if creditTx.Error != nil {
+		log.Printf("failed to credit account balance. Error :%s", creditTx.Error.Error())
+		tx.Rollback()
+		return creditTx.Error
+	}
+	return tx.Commit().Error
+}
+
+const debitColumns = `id, mandate_id, user_id, loan_id, installment_num, amount, due_date, status, attempts, next_attempt_at, transaction_id, last_error, created_at, updated_at`
+
+func (obj *autoDebitDb) debits(ctx context.Context, query string, values ...interface{}) ([]AutoDebit, error) {
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, values...).Rows()
+	if err != nil {
+		log.Printf("failed to fetch auto debits. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	debits := make([]AutoDebit, 0)
+	for rows.Next() {
+		var debit AutoDebit
+		err := rows.Scan(&debit.DebitId, &debit.MandateId, &debit.UserId, &debit.LoanId, &debit.InstallmentNum, &debit.Amount, &debit.DueDate, &debit.Status, &debit.Attempts, &debit.NextAttemptAt, &debit.TransactionId, &debit.LastError, &debit.CreatedAt, &debit.UpdatedAt)
+		if err != nil {
+			log.Printf("failed to scan auto debit. Error:%s", err.Error())
+			return nil, err
+		}
+		debits = append(debits, debit)
+	}
+	return debits, nil
+}
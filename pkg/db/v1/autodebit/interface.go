@@ -0,0 +1,37 @@
+package autodebit
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type autoDebitDb struct {
+	dbObj *gorm.DB
+}
+
+type DbAutoDebitInterface interface {
+	AddDebitMandate(context.Context, DebitMandate) (int64, error)
+	CancelDebitMandate(context.Context, int64, time.Time) (int64, error)
+	GetDebitMandate(context.Context, int64) (DebitMandate, error)
+	GetDebitMandates(context.Context, int64) ([]DebitMandate, error)
+
+	GetDueInstallments(context.Context, time.Time, int) ([]AutoDebit, error)
+	AddAutoDebit(context.Context, AutoDebit) (int64, error)
+	GetDueAutoDebits(context.Context, time.Time, int) ([]AutoDebit, error)
+	ClaimAutoDebit(context.Context, int64, int64, time.Time) (bool, error)
+	CompleteAutoDebit(context.Context, AutoDebit) error
+	RetryAutoDebit(context.Context, int64, time.Time, string) error
+	GetAutoDebits(context.Context, int64) ([]AutoDebit, error)
+	GetAutoDebitsByStatus(context.Context, string, int64, int) ([]AutoDebit, error)
+
+	DebitAccountBalance(context.Context, int64, float64, string) (bool, error)
+	RefundAccountDebit(context.Context, string) error
+}
+
+func NewAutoDebitDbObject(db *gorm.DB) DbAutoDebitInterface {
+	return &autoDebitDb{
+		dbObj: db,
+	}
+}
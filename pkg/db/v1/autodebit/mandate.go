@@ -0,0 +1,141 @@
+package autodebit
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// AddDebitMandate activates the mandate of the loan and returns its id. a loan has one mandate, so a cancelled one
+// is activated again rather than added twice
+func (obj *autoDebitDb) AddDebitMandate(ctx context.Context, mandate DebitMandate) (int64, error) {
+	query := `
+		insert into
+			debit_mandate(user_id, loan_id, status, created_at)
+		values
+			(?,?,'ACTIVE',?)
+		on conflict (loan_id) do update set
+			status = 'ACTIVE',
+			cancelled_at = null
+		returning id;
+	`
+	var mandateId int64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, mandate.UserId.Int64, mandate.LoanId.Int64, mandate.CreatedAt.Time.UTC()).Scan(&mandateId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add debit mandate. Error :%s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return mandateId, nil
+}
+
+// CancelDebitMandate cancels the active mandate of the loan together with its pending debits and returns 0 when
+// the loan has no active mandate. an attempt the scheduler already started still completes
+func (obj *autoDebitDb) CancelDebitMandate(ctx context.Context, loanId int64, cancelledAt time.Time) (int64, error) {
+	cancelQuery := `
+		update
+			debit_mandate
+		set
+			status = 'CANCELLED',
+			cancelled_at = ?
+		where
+			loan_id = ?
+			and status = 'ACTIVE'
+		returning id;
+	`
+	debitQuery := `
+		update
+			auto_debit
+		set
+			status = 'CANCELLED',
+			last_error = 'mandate cancelled',
+			updated_at = ?
+		where
+			mandate_id = ?
+			and status = 'PENDING';
+	`
+	tx := obj.dbObj.Begin()
+	var mandateId int64
+	cancelTx := tx.WithContext(ctx).Raw(cancelQuery, cancelledAt.UTC(), loanId).Scan(&mandateId)
+	if cancelTx.Error != nil {
+		log.Printf("failed to cancel debit mandate. Error :%s", cancelTx.Error.Error())
+		tx.Rollback()
+		return 0, cancelTx.Error
+	}
+	if mandateId == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+	debitTx := tx.WithContext(ctx).Exec(debitQuery, cancelledAt.UTC(), mandateId)
+	if debitTx.Error != nil {
+		log.Printf("failed to cancel auto debits. Error :%s", debitTx.Error.Error())
+		tx.Rollback()
+		return 0, debitTx.Error
+	}
+	return mandateId, tx.Commit().Error
+}
+
+// GetDebitMandate fails with sql.ErrNoRows when the loan never had a mandate
+func (obj *autoDebitDb) GetDebitMandate(ctx context.Context, loanId int64) (DebitMandate, error) {
+	query := `
+		select
+			` + mandateColumns + `
+		from
+			debit_mandate
+		where
+			loan_id = ?;
+	`
+	var mandate DebitMandate
+	row := obj.dbObj.WithContext(ctx).Raw(query, loanId).Row()
+	if row.Err() != nil {
+		log.Printf("failed to fetch debit mandate. Error: %s", row.Err().Error())
+		return mandate, row.Err()
+	}
+	if err := scanMandate(row, &mandate); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("failed to scan debit mandate. Error:%s", err.Error())
+		}
+		return mandate, err
+	}
+	return mandate, nil
+}
+
+// GetDebitMandates lists the mandates of a user, active or not, in loan order
+func (obj *autoDebitDb) GetDebitMandates(ctx context.Context, userId int64) ([]DebitMandate, error) {
+	query := `
+		select
+			` + mandateColumns + `
+		from
+			debit_mandate
+		where
+			user_id = ?
+		order by loan_id;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId).Rows()
+	if err != nil {
+		log.Printf("failed to fetch debit mandates. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	mandates := make([]DebitMandate, 0)
+	for rows.Next() {
+		var mandate DebitMandate
+		if err := scanMandate(rows, &mandate); err != nil {
+			log.Printf("failed to scan debit mandate. Error:%s", err.Error())
+			return nil, err
+		}
+		mandates = append(mandates, mandate)
+	}
+	return mandates, nil
+}
+
+const mandateColumns = `id, user_id, loan_id, status, created_at, cancelled_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMandate(row scanner, mandate *DebitMandate) error {
+	return row.Scan(&mandate.MandateId, &mandate.UserId, &mandate.LoanId, &mandate.Status, &mandate.CreatedAt, &mandate.CancelledAt)
+}
@@ -0,0 +1,65 @@
+package autodebit
+
+import "database/sql"
+
+// debit mandate status
+const (
+	MANDATE_ACTIVE    = "ACTIVE"
+	MANDATE_CANCELLED = "CANCELLED"
+)
+
+// auto debit status
+const (
+	PENDING   = "PENDING"
+	SUCCEEDED = "SUCCEEDED"
+	FAILED    = "FAILED"
+	CANCELLED = "CANCELLED"
+	//the repayment failed and so did the refund of the debit. it is not attempted again
+	REFUND_FAILED = "REFUND_FAILED"
+)
+
+// account debit status
+const (
+	DEBITED  = "DEBITED"
+	REFUNDED = "REFUNDED"
+)
+
+// DebitMandate lets the installments of LoanId be debited from the account of UserId on their due date
+type DebitMandate struct {
+	MandateId   sql.NullInt64
+	UserId      sql.NullInt64
+	LoanId      sql.NullInt64
+	Status      sql.NullString
+	CreatedAt   sql.NullTime
+	CancelledAt sql.NullTime
+}
+
+// AutoDebit is the debit of one installment under a mandate. Amount is what was due when the debit was scheduled
+// and TransactionId is the repayment recorded against the installment once the debit succeeded
+type AutoDebit struct {
+	DebitId        sql.NullInt64
+	MandateId      sql.NullInt64
+	UserId         sql.NullInt64
+	LoanId         sql.NullInt64
+	InstallmentNum sql.NullInt64
+	Amount         sql.NullFloat64
+	DueDate        sql.NullTime
+	Status         sql.NullString
+	Attempts       sql.NullInt64
+	NextAttemptAt  sql.NullTime
+	TransactionId  sql.NullString
+	LastError      sql.NullString
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+}
+
+// AccountDebit is money taken from the account balance of UserId under Reference, the transaction id of the debit
+// asking for it. a reference debits the account once until it is refunded
+type AccountDebit struct {
+	Reference sql.NullString
+	UserId    sql.NullInt64
+	Amount    sql.NullFloat64
+	Status    sql.NullString
+	CreatedAt sql.NullTime
+	UpdatedAt sql.NullTime
+}
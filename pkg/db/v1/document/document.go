@@ -0,0 +1,229 @@
+package document
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+func (obj *documentDb) AddDocument(ctx context.Context, document DocumentDetails) (int64, error) {
+	//an income proof is for the income the customer declared last, verifying it verifies that income
+	query := `
+		insert into
+			user_document(user_id,doc_type,file_name,content_type,storage_key,size,status,income_id)
+		values
+			(?,?,?,?,?,?,'PENDING',case when ? = 'INCOME_PROOF' then (select max(id) from user_income where user_id = ?) end)
+		returning id;
+	`
+
+	var documentId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, document.UserId.Int64, document.DocType.String, document.FileName.String, document.ContentType.String, document.StorageKey.String, document.Size.Int64, document.DocType.String, document.UserId.Int64).Scan(&documentId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add document. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+
+	return documentId.Int64, nil
+}
+
+func (obj *documentDb) GetUserDocuments(ctx context.Context, userId int64) ([]DocumentDetails, error) {
+	query := `
+		select
+			id,
+			user_id,
+			doc_type,
+			file_name,
+			content_type,
+			storage_key,
+			size,
+			status,
+			remarks,
+			verified_by,
+			verified_at,
+			created_at
+		from
+			user_document
+		where
+			user_id = ?
+		order by id;
+	`
+
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId).Rows()
+	if err != nil {
+		log.Printf("failed to fetch documents for the user. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := make([]DocumentDetails, 0)
+	for rows.Next() {
+		var document DocumentDetails
+		err := rows.Scan(&document.DocumentId, &document.UserId, &document.DocType, &document.FileName, &document.ContentType, &document.StorageKey, &document.Size, &document.Status, &document.Remarks, &document.VerifiedBy, &document.VerifiedAt, &document.CreatedAt)
+		if err != nil {
+			log.Printf("failed to scan document. Error:%s", err.Error())
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+func (obj *documentDb) GetDocument(ctx context.Context, documentId int64) (DocumentDetails, error) {
+	query := `
+		select
+			id,
+			user_id,
+			doc_type,
+			file_name,
+			content_type,
+			storage_key,
+			size,
+			status,
+			remarks,
+			verified_by,
+			verified_at,
+			created_at
+		from
+			user_document
+		where
+			id = ?;
+	`
+
+	var document DocumentDetails
+	row := obj.dbObj.WithContext(ctx).Raw(query, documentId).Row()
+	if row.Err() != nil {
+		log.Printf("failed to fetch document. Error: %s", row.Err().Error())
+		return document, row.Err()
+	}
+
+	err := row.Scan(&document.DocumentId, &document.UserId, &document.DocType, &document.FileName, &document.ContentType, &document.StorageKey, &document.Size, &document.Status, &document.Remarks, &document.VerifiedBy, &document.VerifiedAt, &document.CreatedAt)
+	if err != nil {
+		log.Printf("failed to scan document. Error:%s", err.Error())
+		return document, err
+	}
+
+	return document, nil
+}
+
+func (obj *documentDb) GetPendingDocuments(ctx context.Context) ([]DocumentDetails, error) {
+	query := `
+		select
+			d.id,
+			d.user_id,
+			u.user_name,
+			d.doc_type,
+			d.file_name,
+			d.content_type,
+			d.size,
+			d.status,
+			d.created_at
+		from
+			user_document d
+		inner join
+			user_detail u
+		on
+			d.user_id = u.id
+		where
+			d.status = 'PENDING'
+		order by d.id;
+	`
+
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		log.Printf("failed to fetch pending documents. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	documents := make([]DocumentDetails, 0)
+	for rows.Next() {
+		var document DocumentDetails
+		err := rows.Scan(&document.DocumentId, &document.UserId, &document.UserName, &document.DocType, &document.FileName, &document.ContentType, &document.Size, &document.Status, &document.CreatedAt)
+		if err != nil {
+			log.Printf("failed to scan document. Error:%s", err.Error())
+			return nil, err
+		}
+		documents = append(documents, document)
+	}
+	return documents, nil
+}
+
+// UpdateDocumentStatus records the admin verdict on a PENDING document and returns 0 if the document was not pending.
+// verifying an income proof also verifies the income declared when it was uploaded
+func (obj *documentDb) UpdateDocumentStatus(ctx context.Context, documentId int64, status string, verifierId int64, remarks string) (int64, error) {
+	query := `
+		update
+			user_document
+		set
+			status = ?,
+			remarks = ?,
+			verified_by = ?,
+			verified_at = CURRENT_TIMESTAMP
+		where
+			id = ?
+			and status = 'PENDING'
+		returning id, user_id, doc_type, income_id;
+	`
+
+	var document DocumentDetails
+	tx := obj.dbObj.Begin()
+	row := tx.WithContext(ctx).Raw(query, status, remarks, verifierId, documentId).Row()
+	if err := row.Scan(&document.DocumentId, &document.UserId, &document.DocType, &document.IncomeId); err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return 0, nil
+		}
+		log.Printf("failed to update document status. Error: %s", err.Error())
+		return 0, err
+	}
+
+	if status == "VERIFIED" && document.IncomeId.Valid {
+		incomeQuery := `
+			update
+				user_income
+			set
+				verified = true,
+				verified_by = ?,
+				verified_at = CURRENT_TIMESTAMP
+			where
+				id = ?;
+		`
+		incomeTx := tx.WithContext(ctx).Exec(incomeQuery, verifierId, document.IncomeId.Int64)
+		if incomeTx.Error != nil {
+			log.Printf("failed to verify user income. Error: %s", incomeTx.Error.Error())
+			tx.Rollback()
+			return 0, incomeTx.Error
+		}
+	}
+	return document.DocumentId.Int64, tx.Commit().Error
+}
+
+func (obj *documentDb) GetVerifiedDocumentTypes(ctx context.Context, userId int64) ([]string, error) {
+	query := `
+		select distinct
+			doc_type
+		from
+			user_document
+		where
+			user_id = ?
+			and status = 'VERIFIED';
+	`
+
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId).Rows()
+	if err != nil {
+		log.Printf("failed to fetch verified documents. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	docTypes := make([]string, 0)
+	for rows.Next() {
+		var docType sql.NullString
+		if err := rows.Scan(&docType); err != nil {
+			log.Printf("failed to scan document type. Error:%s", err.Error())
+			return nil, err
+		}
+		docTypes = append(docTypes, docType.String)
+	}
+	return docTypes, nil
+}
@@ -0,0 +1,26 @@
+package document
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type documentDb struct {
+	dbObj *gorm.DB
+}
+
+type DbDocumentInterface interface {
+	AddDocument(context.Context, DocumentDetails) (int64, error)
+	GetUserDocuments(context.Context, int64) ([]DocumentDetails, error)
+	GetDocument(context.Context, int64) (DocumentDetails, error)
+	GetPendingDocuments(context.Context) ([]DocumentDetails, error)
+	UpdateDocumentStatus(context.Context, int64, string, int64, string) (int64, error)
+	GetVerifiedDocumentTypes(context.Context, int64) ([]string, error)
+}
+
+func NewDocumentDbObject(db *gorm.DB) DbDocumentInterface {
+	return &documentDb{
+		dbObj: db,
+	}
+}
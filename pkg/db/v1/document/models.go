@@ -0,0 +1,23 @@
+package document
+
+import "database/sql"
+
+// DocumentDetails is a KYC document of a user. IncomeId is the income declared when an INCOME_PROOF was uploaded,
+// the one verifying the proof verifies
+type DocumentDetails struct {
+	DocumentId  sql.NullInt64
+	UserId      sql.NullInt64
+	UserName    sql.NullString
+	DocType     sql.NullString
+	FileName    sql.NullString
+	ContentType sql.NullString
+	StorageKey  sql.NullString
+	Size        sql.NullInt64
+	IncomeId    sql.NullInt64
+	Status      sql.NullString
+	Remarks     sql.NullString
+	VerifiedBy  sql.NullInt64
+	VerifiedAt  sql.NullTime
+	CreatedAt   sql.NullTime
+	UpdatedAt   sql.NullTime
+}
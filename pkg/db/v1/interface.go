@@ -1,8 +1,16 @@
 package v1
 
 import (
+	"aspire-assignment/pkg/db/v1/audit"
+	"aspire-assignment/pkg/db/v1/autodebit"
+	"aspire-assignment/pkg/db/v1/document"
 	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/db/v1/outbox"
+	"aspire-assignment/pkg/db/v1/payment"
+	"aspire-assignment/pkg/db/v1/reconciliation"
 	"aspire-assignment/pkg/db/v1/usermanagement"
+	"aspire-assignment/pkg/db/v1/webhook"
 
 	"gorm.io/gorm"
 )
@@ -10,17 +18,41 @@ import (
 type dbV1LayerObj struct {
 	loan.DbLoanInterface
 	usermanagement.DbUserManagementInterface
+	document.DbDocumentInterface
+	audit.DbAuditInterface
+	outbox.DbOutboxInterface
+	webhook.DbWebhookInterface
+	payment.DbPaymentInterface
+	reconciliation.DbReconciliationInterface
+	autodebit.DbAutoDebitInterface
+	notification.DbNotificationInterface
 }
 
 //go:generate mockgen -destination=mock/mock.go -package=mock aspire-assignment/pkg/db/v1  V1DBLayer
 type V1DBLayer interface {
 	loan.DbLoanInterface
 	usermanagement.DbUserManagementInterface
+	document.DbDocumentInterface
+	audit.DbAuditInterface
+	outbox.DbOutboxInterface
+	webhook.DbWebhookInterface
+	payment.DbPaymentInterface
+	reconciliation.DbReconciliationInterface
+	autodebit.DbAutoDebitInterface
+	notification.DbNotificationInterface
 }
 
 func NewV1DbLayer(db *gorm.DB) V1DBLayer {
 	return dbV1LayerObj{
 		loan.NewLoanDbObject(db),
 		usermanagement.NewLoanDbObject(db),
+		document.NewDocumentDbObject(db),
+		audit.NewAuditDbObject(db),
+		outbox.NewOutboxDbObject(db),
+		webhook.NewWebhookDbObject(db),
+		payment.NewPaymentDbObject(db),
+		reconciliation.NewReconciliationDbObject(db),
+		autodebit.NewAutoDebitDbObject(db),
+		notification.NewNotificationDbObject(db),
 	}
 }
@@ -1,16 +1,15 @@
 package loan
 
 import (
-	"database/sql"
-	"fmt"
+	"context"
 	"log"
 	"strings"
 	"time"
 
-	"github.com/gin-gonic/gin"
+	"aspire-assignment/pkg/db/v1/outbox"
 )
 
-func (obj *loanDb) GetUnapprovedLoans(c *gin.Context) ([]UnApprovedLoan, error) {
+func (obj *loanDb) GetUnapprovedLoans(ctx context.Context) ([]UnApprovedLoan, error) {
 	query := `
 		select 
 			l.id as loan_id,
@@ -30,11 +29,12 @@ func (obj *loanDb) GetUnapprovedLoans(c *gin.Context) ([]UnApprovedLoan, error)
 			and l.status = 'PENDING';
 	`
 
-	rows, err := obj.dbObj.WithContext(c).Raw(query).Rows()
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query).Rows()
 	if err != nil {
 		log.Printf("failed to fetch pending loans. Error: %s", err.Error())
 		return nil, err
 	}
+	defer rows.Close()
 
 	loans := make([]UnApprovedLoan, 0)
 	for rows.Next() {
@@ -49,57 +49,12 @@ func (obj *loanDb) GetUnapprovedLoans(c *gin.Context) ([]UnApprovedLoan, error)
 	return loans, nil
 }
 
-func (obj *loanDb) UpdateUnapprovedLoan(c *gin.Context, loanId int64, approved bool) error {
-	updateQuery := `
-		update 
-			loan
-		set
-			status = ?
-		where
-			id = ?
-		returning id;
-	`
-	loanStatus := "REJECTED"
-	if approved {
-		loanStatus = "APPROVED"
-	}
-
-	var updatedLoanId sql.NullInt64
-	row := obj.dbObj.WithContext(c).Raw(updateQuery, loanStatus, loanId).Row()
-	if row.Err() != nil {
-		log.Printf("failed to update loan status. Error :%s", row.Err().Error())
-		return row.Err()
-	}
-	if row.Scan(&updatedLoanId) != nil {
-		log.Printf("failed to update loan status. Error :%s", row.Err().Error())
-		return row.Err()
-	}
-
-	return nil
-}
-
-func (obj *loanDb) UpdateAndInsertInstallments(c *gin.Context, loanId int64, installmentAmount float64, installment int64) error {
-	updateQuery := `
-		update 
-			loan
-		set
-			status = 'APPROVED'
-		where
-			id = ?
-		returning id;
-	`
-	var updatedLoanId sql.NullInt64
+// UpdateAndInsertInstallments applies the approval of the loan and creates its installments in one transaction
+func (obj *loanDb) UpdateAndInsertInstallments(ctx context.Context, loanId int64, change StatusChange, installmentAmount float64, installment int64, events []outbox.Event) error {
 	tx := obj.dbObj.Begin()
-	updateTx := tx.WithContext(c).Raw(updateQuery, loanId).Scan(&updatedLoanId)
-	if updateTx.Error != nil {
-		log.Printf("failed to update loan status. Error :%s", updateTx.Error.Error())
-		tx.Rollback()
-		return updateTx.Error
-	}
-
-	if updatedLoanId.Int64 != loanId {
+	if err := updateStatus(ctx, tx, loanId, change); err != nil {
 		tx.Rollback()
-		return fmt.Errorf("unable to update loan status")
+		return err
 	}
 
 	insertQuery := `
@@ -116,11 +71,15 @@ func (obj *loanDb) UpdateAndInsertInstallments(c *gin.Context, loanId int64, ins
 		t1 = t1.Add(24 * 7 * time.Hour)
 	}
 	insertQuery += strings.Join(queryFields, ",")
-	insertTx := tx.WithContext(c).Exec(insertQuery, queryValues...)
+	insertTx := tx.WithContext(ctx).Exec(insertQuery, queryValues...)
 	if insertTx.Error != nil {
 		log.Printf("failed to insert installments. Error :%s", insertTx.Error.Error())
 		tx.Rollback()
 		return insertTx.Error
 	}
+	if err := outbox.AddEvents(ctx, tx, events); err != nil {
+		tx.Rollback()
+		return err
+	}
 	return tx.Commit().Error
 }
@@ -1,12 +1,27 @@
 package loan
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"log"
 
-	"github.com/gin-gonic/gin"
+	"aspire-assignment/pkg/db/v1/outbox"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInstallmentChanged is returned by an installment write when the installment is no longer PENDING, it was
+	// paid or cancelled by a concurrent repayment
+	ErrInstallmentChanged = errors.New("installment changed concurrently")
+	// ErrDuplicateTransaction is returned by an installment write carrying a transaction id already applied to an
+	// installment
+	ErrDuplicateTransaction = errors.New("transaction already applied to an installment")
 )
 
-func (obj *loanDb) GetUserLoanInstallments(c *gin.Context, userId int64, loanId int64) ([]InstallmentDetails, error) {
+func (obj *loanDb) GetUserLoanInstallments(ctx context.Context, userId int64, loanId int64) ([]InstallmentDetails, error) {
 	query := `
 		select 
 			l.id as loan_id,
@@ -32,11 +47,12 @@ func (obj *loanDb) GetUserLoanInstallments(c *gin.Context, userId int64, loanId
 		order by i.installment_num;
 		`
 
-	rows, err := obj.dbObj.WithContext(c).Raw(query, userId, loanId).Rows()
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId, loanId).Rows()
 	if err != nil {
 		log.Printf("failed to fetch loans for the user. Error: %s", err.Error())
 		return nil, err
 	}
+	defer rows.Close()
 	installments := make([]InstallmentDetails, 0)
 	for rows.Next() {
 		var installment InstallmentDetails
@@ -50,51 +66,52 @@ func (obj *loanDb) GetUserLoanInstallments(c *gin.Context, userId int64, loanId
 	return installments, nil
 }
 
-func (obj *loanDb) UpdateInstallment(c *gin.Context, loanId int64, installments []InstallmentDetails, loanClosed bool) error {
-	updateQuery := `
-		update 
-			installment
-		set
-			amount_paid = ?,
-			amount_due = ?,
-			status = ?,
-			transaction_id = ?
-		where
-			installment_num = ?
-			and loan_id = ?;
-	`
+func (obj *loanDb) UpdateInstallment(ctx context.Context, loanId int64, installments []InstallmentDetails, change StatusChange, events []outbox.Event) error {
 	tx := obj.dbObj.Begin()
 	for _, installment := range installments {
-		updateTx := tx.WithContext(c).Exec(updateQuery, installment.AmountPaid.Float64, installment.AmountDue.Float64, installment.Status.String, installment.TransactionId.String, installment.InstallmentSeq.Int64, loanId)
-		if updateTx.Error != nil {
-			log.Println("failed to update installment")
+		if err := updateInstallment(ctx, tx, loanId, installment); err != nil {
 			tx.Rollback()
-			return updateTx.Error
+			return err
 		}
 	}
 
-	updateLoanQuery := `
-			update
-				loan
-			set
-				status = 'PAID'
-			where 
-				id = ?;
-	`
-	if loanClosed {
-		updateTx := tx.WithContext(c).Exec(updateLoanQuery, loanId)
-		if updateTx.Error != nil {
-			log.Println("failed to update loan closure")
-			tx.Rollback()
-			return updateTx.Error
-		}
+	if err := updateStatus(ctx, tx, loanId, change); err != nil {
+		log.Println("failed to update loan closure")
+		tx.Rollback()
+		return err
+	}
+	if err := outbox.AddEvents(ctx, tx, events); err != nil {
+		tx.Rollback()
+		return err
 	}
 
 	return tx.Commit().Error
 }
 
-func (obj *loanDb) UpdateSingleInstallmentPayment(c *gin.Context, loanId int64, installment InstallmentDetails, loanClosed bool) error {
-	updateQuery := `
+func (obj *loanDb) UpdateSingleInstallmentPayment(ctx context.Context, loanId int64, installment InstallmentDetails, change StatusChange, events []outbox.Event) error {
+	tx := obj.dbObj.Begin()
+	if err := updateInstallment(ctx, tx, loanId, installment); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := updateStatus(ctx, tx, loanId, change); err != nil {
+		log.Println("failed to update loan closure")
+		tx.Rollback()
+		return err
+	}
+	if err := outbox.AddEvents(ctx, tx, events); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// updateInstallment writes a payment, or the amount due recalculated after it, to an installment that is still
+// PENDING. a repayment reads the installments before writing them, so a concurrent payment of the same installment
+// makes it lose with ErrInstallmentChanged instead of overwriting the amount paid and the transaction id
+func updateInstallment(ctx context.Context, tx *gorm.DB, loanId int64, installment InstallmentDetails) error {
+	query := `
 		update 
 			installment
 		set
@@ -104,30 +121,21 @@ func (obj *loanDb) UpdateSingleInstallmentPayment(c *gin.Context, loanId int64,
 			transaction_id = ?
 		where
 			installment_num = ?
-			and loan_id = ?;
+			and loan_id = ?
+			and status = 'PENDING';
 	`
-	tx := obj.dbObj.Begin()
-	updateTx := tx.WithContext(c).Exec(updateQuery, installment.AmountPaid.Float64, installment.AmountDue.Float64, installment.Status.String, installment.TransactionId.String, installment.InstallmentSeq.Int64, loanId)
+	transactionId := sql.NullString{String: installment.TransactionId.String, Valid: installment.TransactionId.String != ""}
+	updateTx := tx.WithContext(ctx).Exec(query, installment.AmountPaid.Float64, installment.AmountDue.Float64, installment.Status.String, transactionId, installment.InstallmentSeq.Int64, loanId)
 	if updateTx.Error != nil {
-		log.Println("failed to update installment")
+		log.Printf("failed to update installment. Error :%s", updateTx.Error.Error())
+		if usermanagement.IsUniqueViolation(updateTx.Error) {
+			return ErrDuplicateTransaction
+		}
 		return updateTx.Error
 	}
-
-	updateLoanQuery := `
-			update
-				loan
-			set
-				status = 'PAID'
-			where 
-				id = ?;
-	`
-	if loanClosed {
-		updateTx := tx.WithContext(c).Exec(updateLoanQuery, loanId)
-		if updateTx.Error != nil {
-			log.Println("failed to update loan closure")
-			tx.Rollback()
-			return updateTx.Error
-		}
+	if updateTx.RowsAffected != 1 {
+		log.Printf("installment %d of loan %d is no longer PENDING. payment not applied", installment.InstallmentSeq.Int64, loanId)
+		return ErrInstallmentChanged
 	}
-	return tx.Commit().Error
+	return nil
 }
@@ -1,7 +1,10 @@
 package loan
 
 import (
-	"github.com/gin-gonic/gin"
+	"context"
+
+	"aspire-assignment/pkg/db/v1/outbox"
+
 	"gorm.io/gorm"
 )
 
@@ -10,19 +13,19 @@ type loanDb struct {
 }
 
 type DbLoanInterface interface {
-	CreateLoan(*gin.Context, int64, float64, int64) (int64, error)
-	ModifyLoan(*gin.Context, int64, int64, float64, int64) (int64, error)
-	CancelLoan(*gin.Context, int64, int64) (int64, error)
-	GetUserLoans(*gin.Context, int64) ([]LoanDetails, error)
-	GetUserLoanInstallments(*gin.Context, int64, int64) ([]InstallmentDetails, error)
-	FetchLoanDetails(*gin.Context, int64) (LoanDetails, error)
-
-	GetUnapprovedLoans(*gin.Context) ([]UnApprovedLoan, error)
-	UpdateUnapprovedLoan(*gin.Context, int64, bool) error
-
-	UpdateAndInsertInstallments(*gin.Context, int64, float64, int64) error
-	UpdateInstallment(*gin.Context, int64, []InstallmentDetails, bool) error
-	UpdateSingleInstallmentPayment(*gin.Context, int64, InstallmentDetails, bool) error
+	CreateLoan(context.Context, int64, float64, int64, StatusChange, []outbox.Event) (int64, error)
+	ModifyLoan(context.Context, int64, int64, float64, int64, []outbox.Event) (int64, error)
+	GetUserLoans(context.Context, int64) ([]LoanDetails, error)
+	GetUserLoanInstallments(context.Context, int64, int64) ([]InstallmentDetails, error)
+	FetchLoanDetails(context.Context, int64) (LoanDetails, error)
+	UpdateLoanStatus(context.Context, int64, StatusChange, []outbox.Event) error
+	GetLoanStatusHistory(context.Context, int64) ([]StatusHistory, error)
+
+	GetUnapprovedLoans(context.Context) ([]UnApprovedLoan, error)
+
+	UpdateAndInsertInstallments(context.Context, int64, StatusChange, float64, int64, []outbox.Event) error
+	UpdateInstallment(context.Context, int64, []InstallmentDetails, StatusChange, []outbox.Event) error
+	UpdateSingleInstallmentPayment(context.Context, int64, InstallmentDetails, StatusChange, []outbox.Event) error
 }
 
 func NewLoanDbObject(db *gorm.DB) DbLoanInterface {
@@ -1,41 +1,49 @@
 package loan
 
 import (
+	"context"
 	"database/sql"
 	"log"
 
-	"github.com/gin-gonic/gin"
+	"aspire-assignment/pkg/db/v1/outbox"
 )
 
-func (obj *loanDb) CreateLoan(c *gin.Context, userId int64, amount float64, installments int64) (int64, error) {
+// CreateLoan adds the loan in the status the change moves it to and records the change in its history. the
+// events are written with the loan as their aggregate
+func (obj *loanDb) CreateLoan(ctx context.Context, userId int64, amount float64, installments int64, change StatusChange, events []outbox.Event) (int64, error) {
 	query := `
 			insert into
 				loan(user_id, amount, tenure, status)
 			values 
-				(?,?,?,'PENDING')
+				(?,?,?,?)
 			returning 
 				id;
 			`
-	rows, err := obj.dbObj.WithContext(c).Raw(query, userId, amount, installments).Rows()
-	if err != nil {
-		log.Printf("failed to create a new loan. Error: %s", err.Error())
+	var loanId sql.NullInt64
+	tx := obj.dbObj.Begin()
+	insertTx := tx.WithContext(ctx).Raw(query, userId, amount, installments, change.To).Scan(&loanId)
+	if insertTx.Error != nil {
+		log.Printf("failed to create a new loan. Error: %s", insertTx.Error.Error())
+		tx.Rollback()
+		return 0, insertTx.Error
+	}
+	if err := recordStatus(ctx, tx, loanId.Int64, change); err != nil {
+		tx.Rollback()
 		return 0, err
 	}
-
-	var (
-		loanId sql.NullInt64
-	)
-	for rows.Next() {
-		err := rows.Scan(&loanId)
-		if err != nil {
-			log.Printf("failed to failed inserted loan id. Error: %s", err.Error())
-			return 0, err
-		}
+	for i := range events {
+		events[i].AggregateId = loanId.Int64
+	}
+	if err := outbox.AddEvents(ctx, tx, events); err != nil {
+		tx.Rollback()
+		return 0, err
 	}
-	return loanId.Int64, nil
+	return loanId.Int64, tx.Commit().Error
 }
 
-func (obj *loanDb) ModifyLoan(c *gin.Context, userId int64, loanId int64, amount float64, installments int64) (int64, error) {
+// ModifyLoan changes a pending loan of the user and returns 0 when there is none. the events are written only
+// when the loan changed
+func (obj *loanDb) ModifyLoan(ctx context.Context, userId int64, loanId int64, amount float64, installments int64, events []outbox.Event) (int64, error) {
 	query := `
 			update 
 				loan
@@ -50,37 +58,25 @@ func (obj *loanDb) ModifyLoan(c *gin.Context, userId int64, loanId int64, amount
 				id;
 			`
 	var id sql.NullInt64
-	updateTx := obj.dbObj.WithContext(c).Raw(query, amount, installments, loanId, userId).Scan(&id)
+	tx := obj.dbObj.Begin()
+	updateTx := tx.WithContext(ctx).Raw(query, amount, installments, loanId, userId).Scan(&id)
 	if updateTx.Error != nil {
 		log.Printf("failed to modify loan. Error: %s", updateTx.Error.Error())
+		tx.Rollback()
 		return 0, updateTx.Error
 	}
-	return id.Int64, nil
-}
-
-func (obj *loanDb) CancelLoan(c *gin.Context, userId int64, loanId int64) (int64, error) {
-	query := `
-			update 
-				loan
-			set
-				status = 'CANCELLED'
-			where
-				id = ?
-				and user_id = ?
-				and status = 'PENDING'
-			returning
-				id;
-			`
-	var id sql.NullInt64
-	updateTx := obj.dbObj.WithContext(c).Raw(query, loanId, userId).Scan(&id)
-	if updateTx.Error != nil {
-		log.Printf("failed to modify loan. Error: %s", updateTx.Error.Error())
-		return 0, updateTx.Error
+	if id.Int64 == 0 {
+		tx.Rollback()
+		return 0, nil
 	}
-	return id.Int64, nil
+	if err := outbox.AddEvents(ctx, tx, events); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return id.Int64, tx.Commit().Error
 }
 
-func (obj *loanDb) GetUserLoans(c *gin.Context, userId int64) ([]LoanDetails, error) {
+func (obj *loanDb) GetUserLoans(ctx context.Context, userId int64) ([]LoanDetails, error) {
 	query := `
 		select 
 			id, amount, tenure, status, created_at
@@ -90,11 +86,12 @@ func (obj *loanDb) GetUserLoans(c *gin.Context, userId int64) ([]LoanDetails, er
 			user_id = ?;
 		`
 
-	rows, err := obj.dbObj.WithContext(c).Raw(query, userId).Rows()
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId).Rows()
 	if err != nil {
 		log.Printf("failed to fetch loans for the user. Error: %s", err.Error())
 		return nil, err
 	}
+	defer rows.Close()
 	loans := make([]LoanDetails, 0)
 	for rows.Next() {
 		var loan LoanDetails
@@ -108,10 +105,10 @@ func (obj *loanDb) GetUserLoans(c *gin.Context, userId int64) ([]LoanDetails, er
 	return loans, nil
 }
 
-func (obj *loanDb) FetchLoanDetails(c *gin.Context, loanId int64) (LoanDetails, error) {
+func (obj *loanDb) FetchLoanDetails(ctx context.Context, loanId int64) (LoanDetails, error) {
 	query := `
 		select 
-			id, amount, tenure, status, created_at
+			id, user_id, amount, tenure, status, product, created_at
 		from
 			loan
 		where
@@ -119,13 +116,13 @@ func (obj *loanDb) FetchLoanDetails(c *gin.Context, loanId int64) (LoanDetails,
 	`
 	var loan LoanDetails
 
-	row := obj.dbObj.WithContext(c).Raw(query, loanId).Row()
+	row := obj.dbObj.WithContext(ctx).Raw(query, loanId).Row()
 	if row.Err() != nil {
 		log.Printf("failed to fetch loan. Error: %s", row.Err().Error())
 		return loan, row.Err()
 	}
 
-	err := row.Scan(&loan.LoanId, &loan.Amount, &loan.Tenure, &loan.Status, &loan.CreatedAt)
+	err := row.Scan(&loan.LoanId, &loan.UserId, &loan.Amount, &loan.Tenure, &loan.Status, &loan.Product, &loan.CreatedAt)
 	if err != nil {
 		log.Printf("failed to scan loan. Error:%s", err.Error())
 		return loan, err
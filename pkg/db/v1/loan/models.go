@@ -8,6 +8,7 @@ type LoanDetails struct {
 	Amount    sql.NullFloat64
 	Tenure    sql.NullInt64
 	Status    sql.NullString
+	Product   sql.NullString
 	CreatedAt sql.NullTime
 	UpdatedAt sql.NullTime
 }
@@ -35,3 +36,15 @@ type InstallmentDetails struct {
 	CreatedAt      sql.NullTime
 	UpdatedAt      sql.NullTime
 }
+
+// StatusHistory is a status change of a loan. FromStatus is null for the application of the loan
+type StatusHistory struct {
+	HistoryId  sql.NullInt64
+	LoanId     sql.NullInt64
+	FromStatus sql.NullString
+	ToStatus   sql.NullString
+	Actor      sql.NullString
+	ActorId    sql.NullInt64
+	Reason     sql.NullString
+	CreatedAt  sql.NullTime
+}
@@ -0,0 +1,114 @@
+package loan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+
+	"aspire-assignment/pkg/db/v1/outbox"
+
+	"gorm.io/gorm"
+)
+
+// ErrStatusChanged is returned by a status write when the loan is no longer in the status the change starts from
+var ErrStatusChanged = errors.New("loan status changed concurrently")
+
+// StatusChange moves a loan from one status to another and is kept in the status history of the loan with the
+// actor and reason. From is empty for a new loan and the zero value leaves the status as it is
+type StatusChange struct {
+	From    string
+	To      string
+	Actor   string
+	ActorId int64
+	Reason  string
+}
+
+func (obj *loanDb) UpdateLoanStatus(ctx context.Context, loanId int64, change StatusChange, events []outbox.Event) error {
+	tx := obj.dbObj.Begin()
+	if err := updateStatus(ctx, tx, loanId, change); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := outbox.AddEvents(ctx, tx, events); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+func (obj *loanDb) GetLoanStatusHistory(ctx context.Context, loanId int64) ([]StatusHistory, error) {
+	query := `
+		select
+			id, loan_id, from_status, to_status, actor, actor_id, reason, created_at
+		from
+			loan_status_history
+		where
+			loan_id = ?
+		order by id;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, loanId).Rows()
+	if err != nil {
+		log.Printf("failed to fetch loan status history. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make([]StatusHistory, 0)
+	for rows.Next() {
+		var entry StatusHistory
+		err := rows.Scan(&entry.HistoryId, &entry.LoanId, &entry.FromStatus, &entry.ToStatus, &entry.Actor, &entry.ActorId, &entry.Reason, &entry.CreatedAt)
+		if err != nil {
+			log.Printf("failed to scan loan status history. Error:%s", err.Error())
+			return nil, err
+		}
+		history = append(history, entry)
+	}
+	return history, nil
+}
+
+// updateStatus is the only write of loan.status after the loan is created. it applies only while the loan is still
+// in change.From, so two concurrent transitions of the same loan cannot both succeed, and records the change
+func updateStatus(ctx context.Context, tx *gorm.DB, loanId int64, change StatusChange) error {
+	if change == (StatusChange{}) {
+		return nil
+	}
+	query := `
+		update
+			loan
+		set
+			status = ?
+		where
+			id = ?
+			and status = ?;
+	`
+	updateTx := tx.WithContext(ctx).Exec(query, change.To, loanId, change.From)
+	if updateTx.Error != nil {
+		log.Printf("failed to update loan status. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	if updateTx.RowsAffected == 0 {
+		log.Printf("loan %d is no longer %s. status not changed to %s", loanId, change.From, change.To)
+		return ErrStatusChanged
+	}
+	return recordStatus(ctx, tx, loanId, change)
+}
+
+// recordStatus adds the change to the status history of the loan in the transaction writing the status
+func recordStatus(ctx context.Context, tx *gorm.DB, loanId int64, change StatusChange) error {
+	query := `
+		insert into
+			loan_status_history(loan_id, from_status, to_status, actor, actor_id, reason)
+		values
+			(?,?,?,?,?,?);
+	`
+	from := sql.NullString{String: change.From, Valid: change.From != ""}
+	actorId := sql.NullInt64{Int64: change.ActorId, Valid: change.ActorId != 0}
+	reason := sql.NullString{String: change.Reason, Valid: change.Reason != ""}
+	insertTx := tx.WithContext(ctx).Exec(query, loanId, from, change.To, change.Actor, actorId, reason)
+	if insertTx.Error != nil {
+		log.Printf("failed to record loan status history. Error :%s", insertTx.Error.Error())
+		return insertTx.Error
+	}
+	return nil
+}
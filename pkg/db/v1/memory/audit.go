@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"context"
+
+	"aspire-assignment/pkg/db/v1/audit"
+)
+
+// AddAuditEntry keeps prev_hash and hash unique like the table does, so concurrent writers cannot fork the chain
+func (obj *memoryDb) AddAuditEntry(ctx context.Context, entry audit.AuditEntry) (int64, error) {
+	var entryId int64
+	err := obj.write(ctx, func(data *tables) error {
+		for _, row := range data.auditLog {
+			if row.PrevHash.String == entry.PrevHash.String {
+				return uniqueViolation("audit_log_prev_hash_key")
+			}
+			if row.Hash.String == entry.Hash.String {
+				return uniqueViolation("audit_log_hash_key")
+			}
+		}
+		entryId = int64(len(data.auditLog) + 1)
+		entry.EntryId = nullInt(entryId)
+		data.auditLog = append(data.auditLog, entry)
+		return nil
+	})
+	return entryId, err
+}
+
+func (obj *memoryDb) GetLastAuditEntry(ctx context.Context) (audit.AuditEntry, error) {
+	var entry audit.AuditEntry
+	err := obj.read(ctx, func(data *tables) error {
+		if len(data.auditLog) != 0 {
+			entry = data.auditLog[len(data.auditLog)-1]
+		}
+		return nil
+	})
+	return entry, err
+}
+
+func (obj *memoryDb) GetAuditEntries(ctx context.Context, filter audit.AuditFilter) ([]audit.AuditEntry, error) {
+	entries := make([]audit.AuditEntry, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.auditLog {
+			if len(entries) == filter.Limit {
+				break
+			}
+			if row.EntryId.Int64 <= filter.AfterId ||
+				(filter.ActorId != 0 && row.ActorId.Int64 != filter.ActorId) ||
+				(filter.Action != "" && row.Action.String != filter.Action) ||
+				(filter.TargetType != "" && row.TargetType.String != filter.TargetType) ||
+				(filter.TargetId != "" && row.TargetId.String != filter.TargetId) ||
+				(!filter.From.IsZero() && row.CreatedAt.Time.Before(filter.From)) ||
+				(!filter.To.IsZero() && !row.CreatedAt.Time.Before(filter.To)) {
+				continue
+			}
+			entries = append(entries, row)
+		}
+		return nil
+	})
+	return entries, err
+}
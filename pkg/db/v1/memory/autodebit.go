@@ -0,0 +1,350 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/autodebit"
+)
+
+func (obj *memoryDb) AddDebitMandate(ctx context.Context, mandate autodebit.DebitMandate) (int64, error) {
+	var mandateId int64
+	err := obj.write(ctx, func(data *tables) error {
+		if row := data.mandate(mandate.LoanId.Int64); row != nil {
+			row.Status = nullString(autodebit.MANDATE_ACTIVE)
+			row.CancelledAt = sql.NullTime{}
+			mandateId = row.MandateId.Int64
+			return nil
+		}
+		if data.user(mandate.UserId.Int64) == nil {
+			return foreignKeyViolation("debit_mandate", "fk_userid")
+		}
+		if data.loan(mandate.LoanId.Int64) == nil {
+			return foreignKeyViolation("debit_mandate", "fk_loanid")
+		}
+		data.mandateSeq++
+		mandateId = data.mandateSeq
+		data.mandates = append(data.mandates, autodebit.DebitMandate{
+			MandateId: nullInt(mandateId),
+			UserId:    nullInt(mandate.UserId.Int64),
+			LoanId:    nullInt(mandate.LoanId.Int64),
+			Status:    nullString(autodebit.MANDATE_ACTIVE),
+			CreatedAt: nullTime(mandate.CreatedAt.Time),
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return mandateId, nil
+}
+
+func (obj *memoryDb) CancelDebitMandate(ctx context.Context, loanId int64, cancelledAt time.Time) (int64, error) {
+	var mandateId int64
+	err := obj.write(ctx, func(data *tables) error {
+		row := data.mandate(loanId)
+		if row == nil || row.Status.String != autodebit.MANDATE_ACTIVE {
+			return nil
+		}
+		row.Status = nullString(autodebit.MANDATE_CANCELLED)
+		row.CancelledAt = nullTime(cancelledAt)
+		mandateId = row.MandateId.Int64
+		for i := range data.autoDebits {
+			debit := &data.autoDebits[i]
+			if debit.MandateId.Int64 == mandateId && debit.Status.String == autodebit.PENDING {
+				debit.Status = nullString(autodebit.CANCELLED)
+				debit.LastError = nullString("mandate cancelled")
+				debit.UpdatedAt = nullTime(cancelledAt)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return mandateId, nil
+}
+
+func (obj *memoryDb) GetDebitMandate(ctx context.Context, loanId int64) (autodebit.DebitMandate, error) {
+	var mandate autodebit.DebitMandate
+	err := obj.read(ctx, func(data *tables) error {
+		row := data.mandate(loanId)
+		if row == nil {
+			return sql.ErrNoRows
+		}
+		mandate = *row
+		return nil
+	})
+	return mandate, err
+}
+
+func (obj *memoryDb) GetDebitMandates(ctx context.Context, userId int64) ([]autodebit.DebitMandate, error) {
+	mandates := make([]autodebit.DebitMandate, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.mandates {
+			if row.UserId.Int64 == userId {
+				mandates = append(mandates, row)
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(mandates, func(i, j int) bool {
+		return mandates[i].LoanId.Int64 < mandates[j].LoanId.Int64
+	})
+	return mandates, err
+}
+
+func (obj *memoryDb) GetDueInstallments(ctx context.Context, now time.Time, limit int) ([]autodebit.AutoDebit, error) {
+	debits := make([]autodebit.AutoDebit, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, mandate := range data.mandates {
+			if mandate.Status.String != autodebit.MANDATE_ACTIVE {
+				continue
+			}
+			if row := data.loan(mandate.LoanId.Int64); row == nil || row.Status.String != "APPROVED" {
+				continue
+			}
+			//only the next pending installment of the loan is debited
+			var next *autodebit.AutoDebit
+			for _, installment := range data.installments {
+				if installment.LoanId.Int64 != mandate.LoanId.Int64 || installment.Status.String != "PENDING" {
+					continue
+				}
+				if next != nil && next.InstallmentNum.Int64 < installment.InstallmentSeq.Int64 {
+					continue
+				}
+				next = &autodebit.AutoDebit{
+					MandateId:      mandate.MandateId,
+					UserId:         mandate.UserId,
+					LoanId:         mandate.LoanId,
+					InstallmentNum: installment.InstallmentSeq,
+					Amount:         installment.AmountDue,
+					DueDate:        installment.DueDate,
+				}
+			}
+			if next == nil || next.DueDate.Time.After(now) || data.hasAutoDebit(next.LoanId.Int64, next.InstallmentNum.Int64) {
+				continue
+			}
+			debits = append(debits, *next)
+		}
+		return nil
+	})
+	sort.SliceStable(debits, func(i, j int) bool {
+		if debits[i].DueDate.Time.Equal(debits[j].DueDate.Time) {
+			return debits[i].MandateId.Int64 < debits[j].MandateId.Int64
+		}
+		return debits[i].DueDate.Time.Before(debits[j].DueDate.Time)
+	})
+	if len(debits) > limit {
+		debits = debits[:limit]
+	}
+	return debits, err
+}
+
+func (obj *memoryDb) AddAutoDebit(ctx context.Context, debit autodebit.AutoDebit) (int64, error) {
+	var debitId int64
+	err := obj.write(ctx, func(data *tables) error {
+		if data.hasAutoDebit(debit.LoanId.Int64, debit.InstallmentNum.Int64) {
+			return nil
+		}
+		if row := data.mandate(debit.LoanId.Int64); row == nil || row.MandateId.Int64 != debit.MandateId.Int64 {
+			return foreignKeyViolation("auto_debit", "fk_mandateid")
+		}
+		data.autoDebitSeq++
+		debitId = data.autoDebitSeq
+		data.autoDebits = append(data.autoDebits, autodebit.AutoDebit{
+			DebitId:        nullInt(debitId),
+			MandateId:      nullInt(debit.MandateId.Int64),
+			UserId:         nullInt(debit.UserId.Int64),
+			LoanId:         nullInt(debit.LoanId.Int64),
+			InstallmentNum: nullInt(debit.InstallmentNum.Int64),
+			Amount:         nullFloat(debit.Amount.Float64),
+			DueDate:        nullTime(debit.DueDate.Time),
+			Status:         nullString(autodebit.PENDING),
+			Attempts:       nullInt(0),
+			NextAttemptAt:  nullTime(debit.CreatedAt.Time),
+			CreatedAt:      nullTime(debit.CreatedAt.Time),
+			UpdatedAt:      nullTime(debit.CreatedAt.Time),
+		})
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return debitId, nil
+}
+
+func (obj *memoryDb) GetDueAutoDebits(ctx context.Context, now time.Time, limit int) ([]autodebit.AutoDebit, error) {
+	debits := make([]autodebit.AutoDebit, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.autoDebits {
+			if len(debits) == limit {
+				break
+			}
+			if row.Status.String == autodebit.PENDING && !row.NextAttemptAt.Time.After(now) {
+				debits = append(debits, row)
+			}
+		}
+		return nil
+	})
+	return debits, err
+}
+
+func (obj *memoryDb) ClaimAutoDebit(ctx context.Context, debitId int64, attempts int64, leaseUntil time.Time) (bool, error) {
+	claimed := false
+	err := obj.write(ctx, func(data *tables) error {
+		debit := data.autoDebit(debitId)
+		if debit == nil || debit.Status.String != autodebit.PENDING || debit.Attempts.Int64 != attempts {
+			return nil
+		}
+		debit.Attempts = nullInt(attempts + 1)
+		debit.NextAttemptAt = nullTime(leaseUntil)
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
+
+func (obj *memoryDb) CompleteAutoDebit(ctx context.Context, debit autodebit.AutoDebit) error {
+	return obj.write(ctx, func(data *tables) error {
+		if err := checkEnum("autodebitstatus", debit.Status.String); err != nil {
+			return err
+		}
+		row := data.autoDebit(debit.DebitId.Int64)
+		if row == nil || (row.Status.String != autodebit.PENDING && row.Status.String != autodebit.CANCELLED) {
+			return nil
+		}
+		row.Status = nullString(debit.Status.String)
+		row.Amount = nullFloat(debit.Amount.Float64)
+		row.TransactionId = debit.TransactionId
+		row.LastError = debit.LastError
+		row.UpdatedAt = nullTime(debit.UpdatedAt.Time)
+		return nil
+	})
+}
+
+func (obj *memoryDb) RetryAutoDebit(ctx context.Context, debitId int64, nextAttemptAt time.Time, lastError string) error {
+	return obj.write(ctx, func(data *tables) error {
+		if debit := data.autoDebit(debitId); debit != nil && debit.Status.String == autodebit.PENDING {
+			debit.NextAttemptAt = nullTime(nextAttemptAt)
+			debit.LastError = nullString(lastError)
+			debit.UpdatedAt = nullTime(time.Now())
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) GetAutoDebits(ctx context.Context, loanId int64) ([]autodebit.AutoDebit, error) {
+	debits := make([]autodebit.AutoDebit, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.autoDebits {
+			if row.LoanId.Int64 == loanId {
+				debits = append(debits, row)
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(debits, func(i, j int) bool {
+		return debits[i].InstallmentNum.Int64 < debits[j].InstallmentNum.Int64
+	})
+	return debits, err
+}
+
+func (obj *memoryDb) GetAutoDebitsByStatus(ctx context.Context, status string, afterId int64, limit int) ([]autodebit.AutoDebit, error) {
+	debits := make([]autodebit.AutoDebit, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.autoDebits {
+			if row.Status.String == status && row.DebitId.Int64 > afterId && len(debits) < limit {
+				debits = append(debits, row)
+			}
+		}
+		return nil
+	})
+	return debits, err
+}
+
+// DebitAccountBalance records the debit with the balance update, as the postgres transaction does, so a reference
+// already debited is not taken again
+func (obj *memoryDb) DebitAccountBalance(ctx context.Context, userId int64, amount float64, reference string) (bool, error) {
+	debited := false
+	err := obj.write(ctx, func(data *tables) error {
+		row := data.accountDebit(reference)
+		if row != nil && row.Status.String == autodebit.DEBITED {
+			debited = true
+			return nil
+		}
+		user := data.user(userId)
+		if user == nil || user.AccountBalance.Float64 < amount {
+			return nil
+		}
+		user.AccountBalance = nullFloat(user.AccountBalance.Float64 - amount)
+		now := time.Now()
+		if row == nil {
+			data.accountDebits = append(data.accountDebits, autodebit.AccountDebit{
+				Reference: nullString(reference),
+				UserId:    nullInt(userId),
+				CreatedAt: nullTime(now),
+			})
+			row = &data.accountDebits[len(data.accountDebits)-1]
+		}
+		row.Amount = nullFloat(amount)
+		row.Status = nullString(autodebit.DEBITED)
+		row.UpdatedAt = nullTime(now)
+		debited = true
+		return nil
+	})
+	return debited, err
+}
+
+func (obj *memoryDb) RefundAccountDebit(ctx context.Context, reference string) error {
+	return obj.write(ctx, func(data *tables) error {
+		row := data.accountDebit(reference)
+		if row == nil || row.Status.String != autodebit.DEBITED {
+			return nil
+		}
+		if user := data.user(row.UserId.Int64); user != nil {
+			user.AccountBalance = nullFloat(user.AccountBalance.Float64 + row.Amount.Float64)
+		}
+		row.Status = nullString(autodebit.REFUNDED)
+		row.UpdatedAt = nullTime(time.Now())
+		return nil
+	})
+}
+
+func (data *tables) accountDebit(reference string) *autodebit.AccountDebit {
+	for i := range data.accountDebits {
+		if data.accountDebits[i].Reference.String == reference {
+			return &data.accountDebits[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) mandate(loanId int64) *autodebit.DebitMandate {
+	for i := range data.mandates {
+		if data.mandates[i].LoanId.Int64 == loanId {
+			return &data.mandates[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) autoDebit(debitId int64) *autodebit.AutoDebit {
+	for i := range data.autoDebits {
+		if data.autoDebits[i].DebitId.Int64 == debitId {
+			return &data.autoDebits[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) hasAutoDebit(loanId int64, installmentNum int64) bool {
+	for _, row := range data.autoDebits {
+		if row.LoanId.Int64 == loanId && row.InstallmentNum.Int64 == installmentNum {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/document"
+)
+
+func (obj *memoryDb) AddDocument(ctx context.Context, doc document.DocumentDetails) (int64, error) {
+	var documentId int64
+	err := obj.write(ctx, func(data *tables) error {
+		if err := checkEnum("documenttypes", doc.DocType.String); err != nil {
+			return err
+		}
+		for _, row := range data.documents {
+			if row.StorageKey.String == doc.StorageKey.String {
+				return uniqueViolation("user_document_storage_key_key")
+			}
+		}
+		var incomeId sql.NullInt64
+		if doc.DocType.String == "INCOME_PROOF" {
+			for _, income := range data.incomes {
+				if income.UserId == doc.UserId.Int64 {
+					incomeId = nullInt(income.IncomeId)
+				}
+			}
+		}
+		now := time.Now()
+		documentId = int64(len(data.documents) + 1)
+		data.documents = append(data.documents, document.DocumentDetails{
+			DocumentId:  nullInt(documentId),
+			UserId:      nullInt(doc.UserId.Int64),
+			DocType:     nullString(doc.DocType.String),
+			FileName:    nullString(doc.FileName.String),
+			ContentType: nullString(doc.ContentType.String),
+			StorageKey:  nullString(doc.StorageKey.String),
+			Size:        nullInt(doc.Size.Int64),
+			IncomeId:    incomeId,
+			Status:      nullString("PENDING"),
+			CreatedAt:   nullTime(now),
+			UpdatedAt:   nullTime(now),
+		})
+		return nil
+	})
+	return documentId, err
+}
+
+func (obj *memoryDb) GetUserDocuments(ctx context.Context, userId int64) ([]document.DocumentDetails, error) {
+	documents := make([]document.DocumentDetails, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.documents {
+			if row.UserId.Int64 == userId {
+				documents = append(documents, row)
+			}
+		}
+		return nil
+	})
+	return documents, err
+}
+
+// GetDocument fails with sql.ErrNoRows for an unknown document, as the postgres row scan does
+func (obj *memoryDb) GetDocument(ctx context.Context, documentId int64) (document.DocumentDetails, error) {
+	var doc document.DocumentDetails
+	err := obj.read(ctx, func(data *tables) error {
+		row := data.document(documentId)
+		if row == nil {
+			return sql.ErrNoRows
+		}
+		doc = *row
+		return nil
+	})
+	return doc, err
+}
+
+func (obj *memoryDb) GetPendingDocuments(ctx context.Context) ([]document.DocumentDetails, error) {
+	documents := make([]document.DocumentDetails, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.documents {
+			user := data.user(row.UserId.Int64)
+			if row.Status.String != "PENDING" || user == nil {
+				continue
+			}
+			row.UserName = user.UserName
+			documents = append(documents, row)
+		}
+		return nil
+	})
+	return documents, err
+}
+
+// UpdateDocumentStatus returns 0 if the document was not pending. verifying an income proof also verifies the latest income
+func (obj *memoryDb) UpdateDocumentStatus(ctx context.Context, documentId int64, status string, verifierId int64, remarks string) (int64, error) {
+	var updatedId int64
+	err := obj.write(ctx, func(data *tables) error {
+		if err := checkEnum("documentstatus", status); err != nil {
+			return err
+		}
+		row := data.document(documentId)
+		if row == nil || row.Status.String != "PENDING" {
+			return nil
+		}
+		now := time.Now()
+		row.Status = nullString(status)
+		row.Remarks = nullString(remarks)
+		row.VerifiedBy = nullInt(verifierId)
+		row.VerifiedAt = nullTime(now)
+		row.UpdatedAt = nullTime(now)
+
+		if status == "VERIFIED" && row.IncomeId.Valid {
+			for i := range data.incomes {
+				if data.incomes[i].IncomeId == row.IncomeId.Int64 {
+					data.incomes[i].Verified = true
+					data.incomes[i].VerifiedBy = nullInt(verifierId)
+					data.incomes[i].VerifiedAt = nullTime(now)
+				}
+			}
+		}
+		updatedId = documentId
+		return nil
+	})
+	return updatedId, err
+}
+
+func (obj *memoryDb) GetVerifiedDocumentTypes(ctx context.Context, userId int64) ([]string, error) {
+	docTypes := make([]string, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.documents {
+			if row.UserId.Int64 == userId && row.Status.String == "VERIFIED" && !containsString(docTypes, row.DocType.String) {
+				docTypes = append(docTypes, row.DocType.String)
+			}
+		}
+		return nil
+	})
+	return docTypes, err
+}
+
+func (data *tables) document(documentId int64) *document.DocumentDetails {
+	for i := range data.documents {
+		if data.documents[i].DocumentId.Int64 == documentId {
+			return &data.documents[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/outbox"
+)
+
+func (obj *memoryDb) CreateLoan(ctx context.Context, userId int64, amount float64, installments int64, change loan.StatusChange, events []outbox.Event) (int64, error) {
+	var loanId int64
+	err := obj.write(ctx, func(data *tables) error {
+		if err := checkEnum("loanstatus", change.To); err != nil {
+			return err
+		}
+		now := time.Now()
+		loanId = int64(len(data.loans) + 1)
+		//loans get the default of the product column
+		data.loans = append(data.loans, loan.LoanDetails{
+			LoanId:    nullInt(loanId),
+			UserId:    nullInt(userId),
+			Amount:    nullFloat(amount),
+			Tenure:    nullInt(installments),
+			Status:    nullString(change.To),
+			Product:   nullString("PERSONAL"),
+			CreatedAt: nullTime(now),
+			UpdatedAt: nullTime(now),
+		})
+		data.recordStatus(loanId, change)
+		for _, event := range events {
+			event.AggregateId = loanId
+			data.addEvent(event)
+		}
+		return nil
+	})
+	return loanId, err
+}
+
+func (obj *memoryDb) ModifyLoan(ctx context.Context, userId int64, loanId int64, amount float64, installments int64, events []outbox.Event) (int64, error) {
+	var id int64
+	err := obj.write(ctx, func(data *tables) error {
+		row := data.userLoan(userId, loanId)
+		if row == nil || row.Status.String != "PENDING" {
+			return nil
+		}
+		row.Amount = nullFloat(amount)
+		row.Tenure = nullInt(installments)
+		row.UpdatedAt = nullTime(time.Now())
+		id = loanId
+		data.addEvents(events)
+		return nil
+	})
+	return id, err
+}
+
+func (obj *memoryDb) GetUserLoans(ctx context.Context, userId int64) ([]loan.LoanDetails, error) {
+	loans := make([]loan.LoanDetails, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.loans {
+			if row.UserId.Int64 == userId {
+				loans = append(loans, row)
+			}
+		}
+		return nil
+	})
+	return loans, err
+}
+
+func (obj *memoryDb) GetUserLoanInstallments(ctx context.Context, userId int64, loanId int64) ([]loan.InstallmentDetails, error) {
+	installments := make([]loan.InstallmentDetails, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		row := data.userLoan(userId, loanId)
+		if row == nil {
+			return nil
+		}
+		for _, installment := range data.installments {
+			if installment.LoanId.Int64 == loanId {
+				installment.LoanAmount = row.Amount
+				installment.LoanStatus = row.Status
+				installments = append(installments, installment)
+			}
+		}
+		return nil
+	})
+	sort.Slice(installments, func(i, j int) bool {
+		return installments[i].InstallmentSeq.Int64 < installments[j].InstallmentSeq.Int64
+	})
+	return installments, err
+}
+
+// FetchLoanDetails fails with sql.ErrNoRows for an unknown loan, as the postgres row scan does
+func (obj *memoryDb) FetchLoanDetails(ctx context.Context, loanId int64) (loan.LoanDetails, error) {
+	var detail loan.LoanDetails
+	err := obj.read(ctx, func(data *tables) error {
+		row := data.loan(loanId)
+		if row == nil {
+			return sql.ErrNoRows
+		}
+		detail = *row
+		return nil
+	})
+	return detail, err
+}
+
+func (obj *memoryDb) GetLoanStatusHistory(ctx context.Context, loanId int64) ([]loan.StatusHistory, error) {
+	history := make([]loan.StatusHistory, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, entry := range data.loanHistory {
+			if entry.LoanId.Int64 == loanId {
+				history = append(history, entry)
+			}
+		}
+		return nil
+	})
+	return history, err
+}
+
+func (obj *memoryDb) GetUnapprovedLoans(ctx context.Context) ([]loan.UnApprovedLoan, error) {
+	loans := make([]loan.UnApprovedLoan, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.loans {
+			user := data.user(row.UserId.Int64)
+			if row.Status.String != "PENDING" || user == nil || user.UserType.String != "CUSTOMER" {
+				continue
+			}
+			loans = append(loans, loan.UnApprovedLoan{
+				LoanId:       row.LoanId,
+				UserName:     user.UserName,
+				Amount:       row.Amount,
+				Installments: row.Tenure,
+				Status:       row.Status,
+				CreatedAt:    row.CreatedAt,
+			})
+		}
+		return nil
+	})
+	return loans, err
+}
+
+func (obj *memoryDb) UpdateLoanStatus(ctx context.Context, loanId int64, change loan.StatusChange, events []outbox.Event) error {
+	return obj.write(ctx, func(data *tables) error {
+		if err := data.updateStatus(loanId, change); err != nil {
+			return err
+		}
+		data.addEvents(events)
+		return nil
+	})
+}
+
+func (obj *memoryDb) UpdateAndInsertInstallments(ctx context.Context, loanId int64, change loan.StatusChange, installmentAmount float64, installment int64, events []outbox.Event) error {
+	return obj.write(ctx, func(data *tables) error {
+		if err := data.updateStatus(loanId, change); err != nil {
+			return err
+		}
+		now := time.Now()
+
+		dueDate := now
+		for i := 1; i <= int(installment); i++ {
+			data.installments = append(data.installments, loan.InstallmentDetails{
+				InstallmentId:  nullInt(int64(len(data.installments) + 1)),
+				LoanId:         nullInt(loanId),
+				AmountDue:      nullFloat(installmentAmount),
+				AmountPaid:     nullFloat(0),
+				Status:         nullString("PENDING"),
+				InstallmentSeq: nullInt(int64(i)),
+				DueDate:        nullTime(dueDate),
+				CreatedAt:      nullTime(now),
+				UpdatedAt:      nullTime(now),
+			})
+			dueDate = dueDate.Add(24 * 7 * time.Hour)
+		}
+		data.addEvents(events)
+		return nil
+	})
+}
+
+func (obj *memoryDb) UpdateInstallment(ctx context.Context, loanId int64, installments []loan.InstallmentDetails, change loan.StatusChange, events []outbox.Event) error {
+	return obj.write(ctx, func(data *tables) error {
+		for _, installment := range installments {
+			if err := data.payInstallment(loanId, installment); err != nil {
+				return err
+			}
+		}
+		if err := data.updateStatus(loanId, change); err != nil {
+			return err
+		}
+		data.addEvents(events)
+		return nil
+	})
+}
+
+func (obj *memoryDb) UpdateSingleInstallmentPayment(ctx context.Context, loanId int64, installment loan.InstallmentDetails, change loan.StatusChange, events []outbox.Event) error {
+	return obj.write(ctx, func(data *tables) error {
+		if err := data.payInstallment(loanId, installment); err != nil {
+			return err
+		}
+		if err := data.updateStatus(loanId, change); err != nil {
+			return err
+		}
+		data.addEvents(events)
+		return nil
+	})
+}
+
+func (data *tables) loan(loanId int64) *loan.LoanDetails {
+	for i := range data.loans {
+		if data.loans[i].LoanId.Int64 == loanId {
+			return &data.loans[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) userLoan(userId int64, loanId int64) *loan.LoanDetails {
+	row := data.loan(loanId)
+	if row == nil || row.UserId.Int64 != userId {
+		return nil
+	}
+	return row
+}
+
+func (data *tables) payInstallment(loanId int64, installment loan.InstallmentDetails) error {
+	if err := checkEnum("loantransactionstatus", installment.Status.String); err != nil {
+		return err
+	}
+	transactionId := installment.TransactionId.String
+	var row *loan.InstallmentDetails
+	for i := range data.installments {
+		if data.installments[i].LoanId.Int64 == loanId && data.installments[i].InstallmentSeq.Int64 == installment.InstallmentSeq.Int64 {
+			row = &data.installments[i]
+		}
+	}
+	//only a PENDING installment is written, as the postgres update does
+	if row == nil || row.Status.String != "PENDING" {
+		return loan.ErrInstallmentChanged
+	}
+	for _, other := range data.installments {
+		if transactionId != "" && other.TransactionId.String == transactionId {
+			return loan.ErrDuplicateTransaction
+		}
+	}
+	row.AmountPaid = nullFloat(installment.AmountPaid.Float64)
+	row.AmountDue = nullFloat(installment.AmountDue.Float64)
+	row.Status = nullString(installment.Status.String)
+	row.TransactionId = sql.NullString{String: transactionId, Valid: transactionId != ""}
+	row.UpdatedAt = nullTime(time.Now())
+	return nil
+}
+
+// updateStatus applies the change only while the loan is still in change.From, as the postgres update does
+func (data *tables) updateStatus(loanId int64, change loan.StatusChange) error {
+	if change == (loan.StatusChange{}) {
+		return nil
+	}
+	if err := checkEnum("loanstatus", change.To); err != nil {
+		return err
+	}
+	row := data.loan(loanId)
+	if row == nil || row.Status.String != change.From {
+		return loan.ErrStatusChanged
+	}
+	row.Status = nullString(change.To)
+	row.UpdatedAt = nullTime(time.Now())
+	data.recordStatus(loanId, change)
+	return nil
+}
+
+func (data *tables) recordStatus(loanId int64, change loan.StatusChange) {
+	data.loanHistory = append(data.loanHistory, loan.StatusHistory{
+		HistoryId:  nullInt(int64(len(data.loanHistory) + 1)),
+		LoanId:     nullInt(loanId),
+		FromStatus: sql.NullString{String: change.From, Valid: change.From != ""},
+		ToStatus:   nullString(change.To),
+		Actor:      nullString(change.Actor),
+		ActorId:    sql.NullInt64{Int64: change.ActorId, Valid: change.ActorId != 0},
+		Reason:     sql.NullString{String: change.Reason, Valid: change.Reason != ""},
+		CreatedAt:  nullTime(time.Now()),
+	})
+}
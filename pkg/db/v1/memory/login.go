@@ -0,0 +1,320 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/usermanagement"
+)
+
+func (obj *memoryDb) GetUserRoles(ctx context.Context, userId int64) ([]string, error) {
+	roles := make([]string, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		roles = append(roles, sortedCopy(data.userRoles[userId])...)
+		return nil
+	})
+	return roles, err
+}
+
+// GetRolePermissions returns the union of the permissions granted to the roles
+func (obj *memoryDb) GetRolePermissions(ctx context.Context, roles []string) ([]string, error) {
+	permissions := make([]string, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, name := range roles {
+			role := data.role(name)
+			if role == nil {
+				continue
+			}
+			for _, permission := range role.Permissions {
+				if !containsString(permissions, permission) {
+					permissions = append(permissions, permission)
+				}
+			}
+		}
+		return nil
+	})
+	return permissions, err
+}
+
+func (obj *memoryDb) GetRoles(ctx context.Context) ([]usermanagement.Role, error) {
+	roles := make([]usermanagement.Role, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, role := range data.roles {
+			role.Permissions = sortedCopy(role.Permissions)
+			roles = append(roles, role)
+		}
+		return nil
+	})
+	return roles, err
+}
+
+// SetUserRoles replaces the roles of the user and invalidates the issued tokens. returns 0 when any of the roles does not exist.
+// a role named twice is assigned once
+func (obj *memoryDb) SetUserRoles(ctx context.Context, userId int64, roles []string) (int64, error) {
+	var count int64
+	err := obj.write(ctx, func(data *tables) error {
+		assigned := make([]string, 0, len(roles))
+		for _, name := range roles {
+			if data.role(name) == nil {
+				return nil
+			}
+			if !containsString(assigned, name) {
+				assigned = append(assigned, name)
+			}
+		}
+		data.userRoles[userId] = assigned
+		if user := data.user(userId); user != nil {
+			user.TokenVersion = nullInt(user.TokenVersion.Int64 + 1)
+		}
+		data.revokeUserRefreshTokens(userId)
+		count = int64(len(assigned))
+		return nil
+	})
+	return count, err
+}
+
+func (obj *memoryDb) GetLoginThrottles(ctx context.Context, subjects []string) ([]usermanagement.LoginThrottle, error) {
+	throttles := make([]usermanagement.LoginThrottle, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, subject := range subjects {
+			if throttle, ok := data.throttles[subject]; ok {
+				throttles = append(throttles, throttle)
+			}
+		}
+		return nil
+	})
+	return throttles, err
+}
+
+// AddLoginFailure counts a failed login against the subject. failures older than windowStart are forgotten
+func (obj *memoryDb) AddLoginFailure(ctx context.Context, subject string, failedAt time.Time, windowStart time.Time) (usermanagement.LoginThrottle, error) {
+	var throttle usermanagement.LoginThrottle
+	err := obj.write(ctx, func(data *tables) error {
+		current, ok := data.throttles[subject]
+		switch {
+		case !ok:
+			current = usermanagement.LoginThrottle{Subject: nullString(subject), Failures: nullInt(1)}
+		case current.LastFailureAt.Valid && current.LastFailureAt.Time.Before(windowStart):
+			current.Failures = nullInt(1)
+		default:
+			current.Failures = nullInt(current.Failures.Int64 + 1)
+		}
+		current.LastFailureAt = nullTime(failedAt)
+		data.throttles[subject] = current
+		throttle = current
+		return nil
+	})
+	return throttle, err
+}
+
+func (obj *memoryDb) LockLogin(ctx context.Context, subject string, lockedUntil time.Time) error {
+	return obj.write(ctx, func(data *tables) error {
+		if throttle, ok := data.throttles[subject]; ok {
+			throttle.Failures = nullInt(0)
+			throttle.LockedUntil = nullTime(lockedUntil)
+			data.throttles[subject] = throttle
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) ClearLoginFailures(ctx context.Context, subjects []string) (int64, error) {
+	var count int64
+	err := obj.write(ctx, func(data *tables) error {
+		for _, subject := range subjects {
+			if _, ok := data.throttles[subject]; ok {
+				delete(data.throttles, subject)
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// SaveTotpSecret stores a pending secret for enrollment. returns false when totp is already enabled for the user
+func (obj *memoryDb) SaveTotpSecret(ctx context.Context, userId int64, secret string) (bool, error) {
+	var saved bool
+	err := obj.write(ctx, func(data *tables) error {
+		totp, ok := data.totps[userId]
+		if ok && totp.Enabled.Bool {
+			return nil
+		}
+		if !ok {
+			totp = usermanagement.UserTotp{UserId: nullInt(userId), Enabled: nullBool(false), LastUsedStep: nullInt(0)}
+		}
+		totp.Secret = nullString(secret)
+		totp.CreatedAt = nullTime(time.Now())
+		data.totps[userId] = totp
+		saved = true
+		return nil
+	})
+	return saved, err
+}
+
+func (obj *memoryDb) GetTotp(ctx context.Context, userId int64) (usermanagement.UserTotp, error) {
+	var totp usermanagement.UserTotp
+	err := obj.read(ctx, func(data *tables) error {
+		totp = data.totps[userId]
+		return nil
+	})
+	return totp, err
+}
+
+// EnableTotp activates the pending secret at the verified step and replaces the recovery codes of the user
+func (obj *memoryDb) EnableTotp(ctx context.Context, userId int64, step int64, recoveryCodeHashes []string) error {
+	return obj.write(ctx, func(data *tables) error {
+		if totp, ok := data.totps[userId]; ok {
+			totp.Enabled = nullBool(true)
+			totp.EnabledAt = nullTime(time.Now())
+			totp.LastUsedStep = nullInt(step)
+			data.totps[userId] = totp
+		}
+
+		codes := make([]recoveryCode, 0, len(data.recoveryCodes)+len(recoveryCodeHashes))
+		for _, code := range data.recoveryCodes {
+			if code.UserId != userId {
+				codes = append(codes, code)
+			}
+		}
+		for _, codeHash := range recoveryCodeHashes {
+			codes = append(codes, recoveryCode{UserId: userId, CodeHash: codeHash})
+		}
+		data.recoveryCodes = codes
+		return nil
+	})
+}
+
+// UseTotpStep records the time step of an accepted code. returns false when the step or a later one was already used
+func (obj *memoryDb) UseTotpStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	var used bool
+	err := obj.write(ctx, func(data *tables) error {
+		totp, ok := data.totps[userId]
+		if !ok || totp.LastUsedStep.Int64 >= step {
+			return nil
+		}
+		totp.LastUsedStep = nullInt(step)
+		data.totps[userId] = totp
+		used = true
+		return nil
+	})
+	return used, err
+}
+
+func (obj *memoryDb) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	var used bool
+	err := obj.write(ctx, func(data *tables) error {
+		for i := range data.recoveryCodes {
+			code := &data.recoveryCodes[i]
+			if code.UserId == userId && code.CodeHash == codeHash && !code.UsedAt.Valid {
+				code.UsedAt = nullTime(time.Now())
+				used = true
+				return nil
+			}
+		}
+		return nil
+	})
+	return used, err
+}
+
+func (obj *memoryDb) AddLoginChallenge(ctx context.Context, challenge usermanagement.LoginChallenge) (int64, error) {
+	var challengeId int64
+	err := obj.write(ctx, func(data *tables) error {
+		for _, row := range data.challenges {
+			if row.TokenHash.String == challenge.TokenHash.String {
+				return uniqueViolation("login_challenge_token_hash_key")
+			}
+		}
+		challengeId = int64(len(data.challenges) + 1)
+		data.challenges = append(data.challenges, usermanagement.LoginChallenge{
+			ChallengeId: nullInt(challengeId),
+			UserId:      nullInt(challenge.UserId.Int64),
+			TokenHash:   nullString(challenge.TokenHash.String),
+			ExpiresAt:   nullTime(challenge.ExpiresAt.Time),
+			Attempts:    nullInt(0),
+			CreatedAt:   nullTime(time.Now()),
+		})
+		return nil
+	})
+	return challengeId, err
+}
+
+func (obj *memoryDb) GetLoginChallenge(ctx context.Context, tokenHash string) (usermanagement.LoginChallenge, error) {
+	var challenge usermanagement.LoginChallenge
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.challenges {
+			if row.TokenHash.String == tokenHash {
+				challenge = row
+			}
+		}
+		return nil
+	})
+	return challenge, err
+}
+
+func (obj *memoryDb) IncrementLoginChallengeAttempts(ctx context.Context, challengeId int64) error {
+	return obj.write(ctx, func(data *tables) error {
+		if row := data.challenge(challengeId); row != nil {
+			row.Attempts = nullInt(row.Attempts.Int64 + 1)
+		}
+		return nil
+	})
+}
+
+// CompleteLoginChallenge marks the challenge used. returns false when a concurrent request completed it first
+func (obj *memoryDb) CompleteLoginChallenge(ctx context.Context, challengeId int64) (bool, error) {
+	var completed bool
+	err := obj.write(ctx, func(data *tables) error {
+		if row := data.challenge(challengeId); row != nil && !row.UsedAt.Valid {
+			row.UsedAt = nullTime(time.Now())
+			completed = true
+		}
+		return nil
+	})
+	return completed, err
+}
+
+func (obj *memoryDb) GetSigningKeys(ctx context.Context) ([]usermanagement.SigningKey, error) {
+	keys := make([]usermanagement.SigningKey, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		//keys are only ever appended, so the newest is last
+		for i := len(data.signingKeys) - 1; i >= 0; i-- {
+			keys = append(keys, data.signingKeys[i])
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// AddSigningKey stores the key unless a key of the same algorithm was created after rotateBefore
+func (obj *memoryDb) AddSigningKey(ctx context.Context, key usermanagement.SigningKey, rotateBefore time.Time) (bool, error) {
+	var added bool
+	err := obj.write(ctx, func(data *tables) error {
+		for _, row := range data.signingKeys {
+			if row.KeyId.String == key.KeyId.String {
+				return uniqueViolation("signing_key_pkey")
+			}
+			if row.Algorithm.String == key.Algorithm.String && row.CreatedAt.Time.After(rotateBefore) {
+				return nil
+			}
+		}
+		data.signingKeys = append(data.signingKeys, usermanagement.SigningKey{
+			KeyId:      nullString(key.KeyId.String),
+			Algorithm:  nullString(key.Algorithm.String),
+			PrivateKey: nullString(key.PrivateKey.String),
+			CreatedAt:  nullTime(key.CreatedAt.Time),
+		})
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+func (data *tables) challenge(challengeId int64) *usermanagement.LoginChallenge {
+	for i := range data.challenges {
+		if data.challenges[i].ChallengeId.Int64 == challengeId {
+			return &data.challenges[i]
+		}
+	}
+	return nil
+}
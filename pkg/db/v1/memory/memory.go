@@ -0,0 +1,327 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/audit"
+	"aspire-assignment/pkg/db/v1/autodebit"
+	"aspire-assignment/pkg/db/v1/document"
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/db/v1/outbox"
+	"aspire-assignment/pkg/db/v1/payment"
+	"aspire-assignment/pkg/db/v1/reconciliation"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	"aspire-assignment/pkg/db/v1/webhook"
+)
+
+// memoryDb keeps every table in process memory. it behaves like the postgres repositories so the app and
+// its tests can run without a database. nothing survives a restart
+type memoryDb struct {
+	sync.RWMutex
+	data *tables
+}
+
+type income struct {
+	IncomeId      int64
+	UserId        int64
+	MonthlySalary float64
+	Verified      bool
+	VerifiedBy    sql.NullInt64
+	VerifiedAt    sql.NullTime
+	CreatedAt     time.Time
+}
+
+type revokedToken struct {
+	UserId    int64
+	ExpiresAt time.Time
+}
+
+type recoveryCode struct {
+	UserId   int64
+	CodeHash string
+	UsedAt   sql.NullTime
+}
+
+// tables hold rows by value and row slices are replaced rather than changed in place, so a shallow copy of
+// every table is a snapshot a transaction can work on
+type tables struct {
+	users           []usermanagement.UserDetails
+	incomes         []income
+	profileHistory  []usermanagement.ProfileChange
+	verifications   []usermanagement.ContactVerification
+	passwordResets  []usermanagement.PasswordResetToken
+	refreshTokens   []usermanagement.RefreshToken
+	revokedTokens   map[string]revokedToken
+	invites         []usermanagement.AdminInvite
+	roles           []usermanagement.Role
+	permissions     []string
+	userRoles       map[int64][]string
+	throttles       map[string]usermanagement.LoginThrottle
+	totps           map[int64]usermanagement.UserTotp
+	recoveryCodes   []recoveryCode
+	challenges      []usermanagement.LoginChallenge
+	signingKeys     []usermanagement.SigningKey
+	serviceAccounts []usermanagement.ServiceAccount
+	apiKeys         []usermanagement.APIKey
+	loans           []loan.LoanDetails
+	loanHistory     []loan.StatusHistory
+	installments    []loan.InstallmentDetails
+	documents       []document.DocumentDetails
+	auditLog        []audit.AuditEntry
+	outbox          []outbox.OutboxEvent
+	webhooks        []webhook.WebhookSubscription
+	deliveries      []webhook.WebhookDelivery
+	paymentEvents   []payment.PaymentEvent
+	statements      []reconciliation.BankStatement
+	statementLines  []reconciliation.StatementLine
+	mandates        []autodebit.DebitMandate
+	autoDebits      []autodebit.AutoDebit
+	accountDebits   []autodebit.AccountDebit
+	preferences     []notification.NotificationPreference
+	notifications   []notification.Notification
+	//subscriptions, deliveries and payment events can be deleted, and statements, lines, mandates, debits and
+	//notifications skip ids on conflicts, so their ids come from sequences as in postgres
+	webhookSeq       int64
+	deliverySeq      int64
+	paymentEventSeq  int64
+	statementSeq     int64
+	statementLineSeq int64
+	mandateSeq       int64
+	autoDebitSeq     int64
+	notificationSeq  int64
+}
+
+// NewV1DbLayer returns an empty store holding only the seeded roles and permissions
+func NewV1DbLayer() v1.V1DBLayer {
+	return &memoryDb{
+		data: newTables(),
+	}
+}
+
+func newTables() *tables {
+	return &tables{
+		revokedTokens: make(map[string]revokedToken),
+		roles:         append([]usermanagement.Role{}, seedRoles...),
+		permissions:   append([]string{}, seedPermissions...),
+		userRoles:     make(map[int64][]string),
+		throttles:     make(map[string]usermanagement.LoginThrottle),
+		totps:         make(map[int64]usermanagement.UserTotp),
+	}
+}
+
+func (data *tables) clone() *tables {
+	snapshot := &tables{
+		users:            append([]usermanagement.UserDetails{}, data.users...),
+		incomes:          append([]income{}, data.incomes...),
+		profileHistory:   append([]usermanagement.ProfileChange{}, data.profileHistory...),
+		verifications:    append([]usermanagement.ContactVerification{}, data.verifications...),
+		passwordResets:   append([]usermanagement.PasswordResetToken{}, data.passwordResets...),
+		refreshTokens:    append([]usermanagement.RefreshToken{}, data.refreshTokens...),
+		revokedTokens:    make(map[string]revokedToken, len(data.revokedTokens)),
+		invites:          append([]usermanagement.AdminInvite{}, data.invites...),
+		roles:            append([]usermanagement.Role{}, data.roles...),
+		permissions:      append([]string{}, data.permissions...),
+		userRoles:        make(map[int64][]string, len(data.userRoles)),
+		throttles:        make(map[string]usermanagement.LoginThrottle, len(data.throttles)),
+		totps:            make(map[int64]usermanagement.UserTotp, len(data.totps)),
+		recoveryCodes:    append([]recoveryCode{}, data.recoveryCodes...),
+		challenges:       append([]usermanagement.LoginChallenge{}, data.challenges...),
+		signingKeys:      append([]usermanagement.SigningKey{}, data.signingKeys...),
+		serviceAccounts:  append([]usermanagement.ServiceAccount{}, data.serviceAccounts...),
+		apiKeys:          append([]usermanagement.APIKey{}, data.apiKeys...),
+		loans:            append([]loan.LoanDetails{}, data.loans...),
+		loanHistory:      append([]loan.StatusHistory{}, data.loanHistory...),
+		installments:     append([]loan.InstallmentDetails{}, data.installments...),
+		documents:        append([]document.DocumentDetails{}, data.documents...),
+		auditLog:         append([]audit.AuditEntry{}, data.auditLog...),
+		outbox:           append([]outbox.OutboxEvent{}, data.outbox...),
+		webhooks:         append([]webhook.WebhookSubscription{}, data.webhooks...),
+		deliveries:       append([]webhook.WebhookDelivery{}, data.deliveries...),
+		paymentEvents:    append([]payment.PaymentEvent{}, data.paymentEvents...),
+		statements:       append([]reconciliation.BankStatement{}, data.statements...),
+		statementLines:   append([]reconciliation.StatementLine{}, data.statementLines...),
+		mandates:         append([]autodebit.DebitMandate{}, data.mandates...),
+		autoDebits:       append([]autodebit.AutoDebit{}, data.autoDebits...),
+		accountDebits:    append([]autodebit.AccountDebit{}, data.accountDebits...),
+		preferences:      append([]notification.NotificationPreference{}, data.preferences...),
+		notifications:    append([]notification.Notification{}, data.notifications...),
+		webhookSeq:       data.webhookSeq,
+		deliverySeq:      data.deliverySeq,
+		paymentEventSeq:  data.paymentEventSeq,
+		statementSeq:     data.statementSeq,
+		statementLineSeq: data.statementLineSeq,
+		mandateSeq:       data.mandateSeq,
+		autoDebitSeq:     data.autoDebitSeq,
+		notificationSeq:  data.notificationSeq,
+	}
+	for jti, token := range data.revokedTokens {
+		snapshot.revokedTokens[jti] = token
+	}
+	for userId, roles := range data.userRoles {
+		snapshot.userRoles[userId] = roles
+	}
+	for subject, throttle := range data.throttles {
+		snapshot.throttles[subject] = throttle
+	}
+	for userId, totp := range data.totps {
+		snapshot.totps[userId] = totp
+	}
+	return snapshot
+}
+
+// read runs fn against the committed tables. fn must not change them
+func (obj *memoryDb) read(ctx context.Context, fn func(*tables) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	obj.RLock()
+	defer obj.RUnlock()
+	return fn(obj.data)
+}
+
+// write runs fn as a transaction: fn changes a snapshot which replaces the tables only when fn succeeds,
+// and writers are serialized
+func (obj *memoryDb) write(ctx context.Context, fn func(*tables) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	obj.Lock()
+	defer obj.Unlock()
+	snapshot := obj.data.clone()
+	if err := fn(snapshot); err != nil {
+		return err
+	}
+	obj.data = snapshot
+	return nil
+}
+
+// enums lists the values of the postgres enum types, which reject any other value
+var enums = map[string][]string{
+	"usertypes":             {"CUSTOMER", "ADMIN"},
+	"documenttypes":         {"ID_PROOF", "INCOME_PROOF"},
+	"documentstatus":        {"PENDING", "VERIFIED", "REJECTED"},
+	"loanstatus":            {"PENDING", "APPROVED", "REJECTED", "CANCELLED", "PAID"},
+	"loantransactionstatus": {"PENDING", "PAID", "CANCELLED"},
+	"webhookdeliverystatus": {"PENDING", "DELIVERED", "DEAD"},
+	"paymenteventstatus":    {"RECEIVED", "APPLIED", "REJECTED", "IGNORED"},
+	"statementlinestatus":   {"RECEIVED", "APPLIED", "EXCEPTION", "RESOLVED", "DISMISSED"},
+	"mandatestatus":         {"ACTIVE", "CANCELLED"},
+	"autodebitstatus":       {"PENDING", "SUCCEEDED", "FAILED", "CANCELLED", "REFUND_FAILED"},
+	"accountdebitstatus":    {"DEBITED", "REFUNDED"},
+	"notificationchannel":   {"EMAIL", "SMS", "IN_APP"},
+	"notificationstatus":    {"PENDING", "SENT", "FAILED"},
+}
+
+// checkEnum fails like postgres does for a value outside the enum type
+func checkEnum(enum string, value string) error {
+	if containsString(enums[enum], value) {
+		return nil
+	}
+	return fmt.Errorf("ERROR: invalid input value for enum %s: \"%s\" (SQLSTATE 22P02)", enum, value)
+}
+
+// uniqueViolation reads like the postgres error so callers detecting duplicates by SQLSTATE keep working
+func uniqueViolation(constraint string) error {
+	return fmt.Errorf("ERROR: duplicate key value violates unique constraint \"%s\" (SQLSTATE 23505)", constraint)
+}
+
+// foreignKeyViolation reads like the postgres error for a row referencing a missing one
+func foreignKeyViolation(table string, constraint string) error {
+	return fmt.Errorf("ERROR: insert or update on table \"%s\" violates foreign key constraint \"%s\" (SQLSTATE 23503)", table, constraint)
+}
+
+func nullInt(value int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: value, Valid: true}
+}
+
+func nullString(value string) sql.NullString {
+	return sql.NullString{String: value, Valid: true}
+}
+
+func nullFloat(value float64) sql.NullFloat64 {
+	return sql.NullFloat64{Float64: value, Valid: true}
+}
+
+func nullBool(value bool) sql.NullBool {
+	return sql.NullBool{Bool: value, Valid: true}
+}
+
+func nullTime(value time.Time) sql.NullTime {
+	return sql.NullTime{Time: value, Valid: true}
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(list []int64, value int64) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// seedPermissions and seedRoles match the seeds of the migrations
+var seedPermissions = []string{
+	"loan:write:own",
+	"loan:read:own",
+	"loan:read:any",
+	"loan:approve",
+	"payment:create:own",
+	"payment:create:any",
+	"profile:read:own",
+	"profile:write:own",
+	"document:read:own",
+	"document:write:own",
+	"document:read:any",
+	"document:verify",
+	"admin:invite",
+	"role:read",
+	"role:assign",
+	"user:unlock",
+	"service_account:manage",
+	"audit:read",
+	"webhook:manage",
+	"reconciliation:manage",
+}
+
+var seedRoles = []usermanagement.Role{
+	{
+		Name:        "ADMIN",
+		Description: "approves loans, verifies documents and manages admins",
+		Permissions: []string{"admin:invite", "audit:read", "document:read:any", "document:verify", "loan:approve", "loan:read:any", "reconciliation:manage", "role:assign", "role:read", "service_account:manage", "user:unlock", "webhook:manage"},
+	},
+	{
+		Name:        "AUDITOR",
+		Description: "read only access to all loans and documents",
+		Permissions: []string{"audit:read", "document:read:any", "loan:read:any", "role:read"},
+	},
+	{
+		Name:        "COLLECTIONS",
+		Description: "read only access to all loans for follow up on repayments",
+		Permissions: []string{"loan:read:any"},
+	},
+	{
+		Name:        "CUSTOMER",
+		Description: "applies for and repays own loans",
+		Permissions: []string{"document:read:own", "document:write:own", "loan:read:own", "loan:write:own", "payment:create:own", "profile:read:own", "profile:write:own"},
+	},
+	{
+		Name:        "SUPPORT",
+		Description: "read only access to all loans and unlocks accounts",
+		Permissions: []string{"loan:read:any", "user:unlock"},
+	},
+}
@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/document"
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func customer(userName string) usermanagement.UserDetails {
+	return usermanagement.UserDetails{
+		UserName:      sql.NullString{String: userName, Valid: true},
+		UserPassword:  sql.NullString{String: "hash", Valid: true},
+		UserType:      sql.NullString{String: "CUSTOMER", Valid: true},
+		Email:         sql.NullString{String: userName + "@example.com", Valid: true},
+		MonthlySalary: sql.NullFloat64{Float64: 5000, Valid: true},
+	}
+}
+
+func Test_memoryDb_AddUser(t *testing.T) {
+	ctx := context.Background()
+	dbObj := NewV1DbLayer()
+
+	userId, err := dbObj.AddUser(ctx, customer("john"))
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), userId)
+
+	_, err = dbObj.AddUser(ctx, customer("john"))
+	assert.Equal(t, true, err != nil && strings.Contains(err.Error(), "SQLSTATE 23505"))
+
+	roles, _ := dbObj.GetUserRoles(ctx, userId)
+	assert.Equal(t, []string{"CUSTOMER"}, roles)
+	permissions, _ := dbObj.GetRolePermissions(ctx, roles)
+	assert.Equal(t, 7, len(permissions))
+
+	detail, _ := dbObj.GetUserByUsername(ctx, "john")
+	assert.Equal(t, userId, detail.UserId.Int64)
+	assert.Equal(t, int64(0), detail.TokenVersion.Int64)
+
+	missing, _ := dbObj.GetUserByUsername(ctx, "jane")
+	assert.Equal(t, false, missing.UserId.Valid)
+}
+
+func Test_memoryDb_Transaction(t *testing.T) {
+	ctx := context.Background()
+	dbObj := NewV1DbLayer()
+	userId, _ := dbObj.AddUser(ctx, customer("john"))
+	expiresAt := time.Now().Add(time.Hour)
+
+	token := func(hash string) usermanagement.RefreshToken {
+		return usermanagement.RefreshToken{
+			UserId:    sql.NullInt64{Int64: userId, Valid: true},
+			TokenHash: sql.NullString{String: hash, Valid: true},
+			FamilyId:  sql.NullString{String: "family", Valid: true},
+			ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+		}
+	}
+	firstId, _ := dbObj.AddRefreshToken(ctx, token("first"))
+	dbObj.AddRefreshToken(ctx, token("second"))
+
+	//the successor clashes with an existing token, so retiring the old token must be undone
+	rotated, err := dbObj.RotateRefreshToken(ctx, firstId, token("second"))
+	assert.Equal(t, false, rotated)
+	assert.Equal(t, true, err != nil)
+	first, _ := dbObj.GetRefreshToken(ctx, "first")
+	assert.Equal(t, "ACTIVE", first.Status.String)
+
+	rotated, err = dbObj.RotateRefreshToken(ctx, firstId, token("third"))
+	assert.Equal(t, true, rotated)
+	assert.Equal(t, nil, err)
+	first, _ = dbObj.GetRefreshToken(ctx, "first")
+	assert.Equal(t, "ROTATED", first.Status.String)
+
+	//a failing profile change leaves the earlier changes of the same call unapplied
+	err = dbObj.UpdateUserProfile(ctx, userId, []usermanagement.ProfileChange{
+		{Field: sql.NullString{String: "EMAIL", Valid: true}, NewValue: sql.NullString{String: "new@example.com", Valid: true}},
+		{Field: sql.NullString{String: "SALARY", Valid: true}, NewValue: sql.NullString{String: "lots", Valid: true}},
+	})
+	assert.Equal(t, true, err != nil)
+	detail, _ := dbObj.GetUserById(ctx, userId)
+	assert.Equal(t, "john@example.com", detail.Email.String)
+	history, _ := dbObj.GetProfileHistory(ctx, userId)
+	assert.Equal(t, 0, len(history))
+
+	//a cancelled request does not reach the store
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = dbObj.AddUser(cancelled, customer("jane"))
+	assert.Equal(t, true, errors.Is(err, context.Canceled))
+}
+
+func Test_memoryDb_LoanLifecycle(t *testing.T) {
+	ctx := context.Background()
+	dbObj := NewV1DbLayer()
+	userId, _ := dbObj.AddUser(ctx, customer("john"))
+
+	//verifying the income proof verifies the declared salary
+	documentId, _ := dbObj.AddDocument(ctx, document.DocumentDetails{
+		UserId:     sql.NullInt64{Int64: userId, Valid: true},
+		DocType:    sql.NullString{String: "INCOME_PROOF", Valid: true},
+		StorageKey: sql.NullString{String: "1/income.pdf", Valid: true},
+	})
+	pending, _ := dbObj.GetPendingDocuments(ctx)
+	assert.Equal(t, "john", pending[0].UserName.String)
+	updatedId, _ := dbObj.UpdateDocumentStatus(ctx, documentId, "VERIFIED", 99, "")
+	assert.Equal(t, documentId, updatedId)
+	updatedId, _ = dbObj.UpdateDocumentStatus(ctx, documentId, "REJECTED", 99, "")
+	assert.Equal(t, int64(0), updatedId)
+	income, _ := dbObj.GetLatestVerifiedIncome(ctx, userId)
+	assert.Equal(t, float64(5000), income)
+
+	loanId, _ := dbObj.CreateLoan(ctx, userId, 3000, 3, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+	unapproved, _ := dbObj.GetUnapprovedLoans(ctx)
+	assert.Equal(t, 1, len(unapproved))
+	_, err := dbObj.FetchLoanDetails(ctx, loanId+1)
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	err = dbObj.UpdateAndInsertInstallments(ctx, loanId, loan.StatusChange{From: "PENDING", To: "APPROVED"}, 1000, 3, nil)
+	assert.Equal(t, nil, err)
+	changedId, _ := dbObj.ModifyLoan(ctx, userId, loanId, 5000, 5, nil)
+	assert.Equal(t, int64(0), changedId)
+
+	installments, _ := dbObj.GetUserLoanInstallments(ctx, userId, loanId)
+	assert.Equal(t, 3, len(installments))
+	assert.Equal(t, "APPROVED", installments[0].LoanStatus.String)
+	otherUser, _ := dbObj.GetUserLoanInstallments(ctx, userId+1, loanId)
+	assert.Equal(t, 0, len(otherUser))
+
+	paid := installments[0]
+	paid.AmountPaid = sql.NullFloat64{Float64: 1000, Valid: true}
+	paid.Status = sql.NullString{String: "PAID", Valid: true}
+	dbObj.UpdateSingleInstallmentPayment(ctx, loanId, paid, loan.StatusChange{}, nil)
+
+	rest := installments[1:]
+	rest[0].AmountPaid = sql.NullFloat64{Float64: 2000, Valid: true}
+	rest[0].Status = sql.NullString{String: "PAID", Valid: true}
+	rest[1].Status = sql.NullString{String: "CANCELLED", Valid: true}
+	err = dbObj.UpdateInstallment(ctx, loanId, []loan.InstallmentDetails{rest[0], rest[1]}, loan.StatusChange{From: "PENDING", To: "PAID"}, nil)
+	assert.Equal(t, loan.ErrStatusChanged, err)
+	err = dbObj.UpdateInstallment(ctx, loanId, []loan.InstallmentDetails{rest[0], rest[1]}, loan.StatusChange{From: "APPROVED", To: "PAID"}, nil)
+	assert.Equal(t, nil, err)
+
+	detail, _ := dbObj.FetchLoanDetails(ctx, loanId)
+	assert.Equal(t, "PAID", detail.Status.String)
+	installments, _ = dbObj.GetUserLoanInstallments(ctx, userId, loanId)
+	statuses := make([]string, 0)
+	for _, installment := range installments {
+		statuses = append(statuses, installment.Status.String)
+	}
+	assert.Equal(t, []string{"PAID", "PAID", "CANCELLED"}, statuses)
+}
+
+func Test_memoryDb_AddLoginFailure(t *testing.T) {
+	ctx := context.Background()
+	dbObj := NewV1DbLayer()
+	now := time.Now()
+
+	throttle, _ := dbObj.AddLoginFailure(ctx, "user:john", now.Add(-time.Hour), now.Add(-2*time.Hour))
+	assert.Equal(t, int64(1), throttle.Failures.Int64)
+	throttle, _ = dbObj.AddLoginFailure(ctx, "user:john", now, now.Add(-2*time.Hour))
+	assert.Equal(t, int64(2), throttle.Failures.Int64)
+
+	//failures before the window are forgotten
+	throttle, _ = dbObj.AddLoginFailure(ctx, "user:john", now.Add(time.Hour), now.Add(time.Minute))
+	assert.Equal(t, int64(1), throttle.Failures.Int64)
+
+	dbObj.LockLogin(ctx, "user:john", now.Add(time.Hour))
+	throttles, _ := dbObj.GetLoginThrottles(ctx, []string{"user:john", "ip:127.0.0.1"})
+	assert.Equal(t, 1, len(throttles))
+	assert.Equal(t, int64(0), throttles[0].Failures.Int64)
+	assert.Equal(t, true, throttles[0].LockedUntil.Valid)
+
+	cleared, _ := dbObj.ClearLoginFailures(ctx, []string{"user:john", "ip:127.0.0.1"})
+	assert.Equal(t, int64(1), cleared)
+}
@@ -0,0 +1,251 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/notification"
+)
+
+func (obj *memoryDb) GetNotificationPreferences(ctx context.Context, userId int64) ([]notification.NotificationPreference, error) {
+	preferences := make([]notification.NotificationPreference, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.preferences {
+			if row.UserId.Int64 == userId {
+				preferences = append(preferences, row)
+			}
+		}
+		return nil
+	})
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].Channel.String < preferences[j].Channel.String
+	})
+	return preferences, err
+}
+
+func (obj *memoryDb) SetNotificationPreferences(ctx context.Context, preferences []notification.NotificationPreference) error {
+	return obj.write(ctx, func(data *tables) error {
+		for _, preference := range preferences {
+			if err := checkEnum("notificationchannel", preference.Channel.String); err != nil {
+				return err
+			}
+			if data.user(preference.UserId.Int64) == nil {
+				return foreignKeyViolation("notification_preference", "fk_userid")
+			}
+			if row := data.preference(preference.UserId.Int64, preference.Channel.String); row != nil {
+				row.Enabled = nullBool(preference.Enabled.Bool)
+				row.UpdatedAt = nullTime(preference.UpdatedAt.Time)
+				continue
+			}
+			data.preferences = append(data.preferences, notification.NotificationPreference{
+				UserId:    nullInt(preference.UserId.Int64),
+				Channel:   nullString(preference.Channel.String),
+				Enabled:   nullBool(preference.Enabled.Bool),
+				UpdatedAt: nullTime(preference.UpdatedAt.Time),
+			})
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) AddNotifications(ctx context.Context, notifications []notification.Notification) error {
+	return obj.write(ctx, func(data *tables) error {
+		for _, row := range notifications {
+			if err := checkEnum("notificationchannel", row.Channel.String); err != nil {
+				return err
+			}
+			if err := checkEnum("notificationstatus", row.Status.String); err != nil {
+				return err
+			}
+			if data.hasNotification(row.Channel.String, row.DedupKey.String) {
+				continue
+			}
+			if data.user(row.UserId.Int64) == nil {
+				return foreignKeyViolation("notification", "fk_userid")
+			}
+			data.notificationSeq++
+			data.notifications = append(data.notifications, notification.Notification{
+				NotificationId: nullInt(data.notificationSeq),
+				UserId:         nullInt(row.UserId.Int64),
+				EventType:      nullString(row.EventType.String),
+				Channel:        nullString(row.Channel.String),
+				Recipient:      nullString(row.Recipient.String),
+				Subject:        nullString(row.Subject.String),
+				Body:           nullString(row.Body.String),
+				DedupKey:       nullString(row.DedupKey.String),
+				Status:         nullString(row.Status.String),
+				Attempts:       nullInt(0),
+				NextAttemptAt:  nullTime(row.CreatedAt.Time),
+				CreatedAt:      nullTime(row.CreatedAt.Time),
+				SentAt:         row.SentAt,
+			})
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) GetDueNotifications(ctx context.Context, now time.Time, limit int) ([]notification.Notification, error) {
+	notifications := make([]notification.Notification, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.notifications {
+			if len(notifications) == limit {
+				break
+			}
+			if row.Status.String == notification.PENDING && !row.NextAttemptAt.Time.After(now) {
+				notifications = append(notifications, row)
+			}
+		}
+		return nil
+	})
+	return notifications, err
+}
+
+func (obj *memoryDb) ClaimNotification(ctx context.Context, notificationId int64, attempts int64, leaseUntil time.Time) (bool, error) {
+	claimed := false
+	err := obj.write(ctx, func(data *tables) error {
+		row := data.notification(notificationId)
+		if row == nil || row.Status.String != notification.PENDING || row.Attempts.Int64 != attempts {
+			return nil
+		}
+		row.Attempts = nullInt(attempts + 1)
+		row.NextAttemptAt = nullTime(leaseUntil)
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
+
+func (obj *memoryDb) MarkNotificationSent(ctx context.Context, notificationId int64, sentAt time.Time) error {
+	return obj.write(ctx, func(data *tables) error {
+		if row := data.notification(notificationId); row != nil && row.Status.String == notification.PENDING {
+			row.Status = nullString(notification.SENT)
+			row.LastError.Valid = false
+			row.SentAt = nullTime(sentAt)
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) RetryNotification(ctx context.Context, notificationId int64, nextAttemptAt time.Time, lastError string) error {
+	return obj.write(ctx, func(data *tables) error {
+		if row := data.notification(notificationId); row != nil && row.Status.String == notification.PENDING {
+			row.NextAttemptAt = nullTime(nextAttemptAt)
+			row.LastError = nullString(lastError)
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) FailNotification(ctx context.Context, notificationId int64, lastError string) error {
+	return obj.write(ctx, func(data *tables) error {
+		if row := data.notification(notificationId); row != nil && row.Status.String == notification.PENDING {
+			row.Status = nullString(notification.FAILED)
+			row.LastError = nullString(lastError)
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) GetNotifications(ctx context.Context, filter notification.NotificationFilter) ([]notification.Notification, error) {
+	notifications := make([]notification.Notification, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for i := len(data.notifications) - 1; i >= 0 && len(notifications) < filter.Limit; i-- {
+			row := data.notifications[i]
+			if row.UserId.Int64 != filter.UserId {
+				continue
+			}
+			if filter.Channel != "" && row.Channel.String != filter.Channel {
+				continue
+			}
+			if filter.Unread && row.ReadAt.Valid {
+				continue
+			}
+			if filter.BeforeId != 0 && row.NotificationId.Int64 >= filter.BeforeId {
+				continue
+			}
+			notifications = append(notifications, row)
+		}
+		return nil
+	})
+	return notifications, err
+}
+
+func (obj *memoryDb) MarkNotificationsRead(ctx context.Context, userId int64, notificationIds []int64, readAt time.Time) (int64, error) {
+	var marked int64
+	err := obj.write(ctx, func(data *tables) error {
+		for i := range data.notifications {
+			row := &data.notifications[i]
+			if row.UserId.Int64 != userId || row.Channel.String != notification.IN_APP || row.ReadAt.Valid {
+				continue
+			}
+			if len(notificationIds) > 0 && !containsInt(notificationIds, row.NotificationId.Int64) {
+				continue
+			}
+			row.ReadAt = nullTime(readAt)
+			marked++
+		}
+		return nil
+	})
+	return marked, err
+}
+
+func (obj *memoryDb) GetInstallmentsDueBetween(ctx context.Context, from time.Time, to time.Time) ([]notification.InstallmentReminder, error) {
+	reminders := make([]notification.InstallmentReminder, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, installment := range data.installments {
+			if installment.Status.String != "PENDING" || !installment.DueDate.Time.After(from) || installment.DueDate.Time.After(to) {
+				continue
+			}
+			row := data.loan(installment.LoanId.Int64)
+			if row == nil || row.Status.String != "APPROVED" {
+				continue
+			}
+			reminders = append(reminders, notification.InstallmentReminder{
+				UserId:         row.UserId,
+				LoanId:         installment.LoanId,
+				InstallmentNum: installment.InstallmentSeq,
+				AmountDue:      installment.AmountDue,
+				DueDate:        installment.DueDate,
+			})
+		}
+		return nil
+	})
+	sort.SliceStable(reminders, func(i, j int) bool {
+		if !reminders[i].DueDate.Time.Equal(reminders[j].DueDate.Time) {
+			return reminders[i].DueDate.Time.Before(reminders[j].DueDate.Time)
+		}
+		if reminders[i].LoanId.Int64 != reminders[j].LoanId.Int64 {
+			return reminders[i].LoanId.Int64 < reminders[j].LoanId.Int64
+		}
+		return reminders[i].InstallmentNum.Int64 < reminders[j].InstallmentNum.Int64
+	})
+	return reminders, err
+}
+
+func (data *tables) preference(userId int64, channel string) *notification.NotificationPreference {
+	for i := range data.preferences {
+		if data.preferences[i].UserId.Int64 == userId && data.preferences[i].Channel.String == channel {
+			return &data.preferences[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) notification(notificationId int64) *notification.Notification {
+	for i := range data.notifications {
+		if data.notifications[i].NotificationId.Int64 == notificationId {
+			return &data.notifications[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) hasNotification(channel string, dedupKey string) bool {
+	for _, row := range data.notifications {
+		if row.Channel.String == channel && row.DedupKey.String == dedupKey {
+			return true
+		}
+	}
+	return false
+}
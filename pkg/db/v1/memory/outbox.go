@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/outbox"
+)
+
+func (obj *memoryDb) GetDueOutboxEvents(ctx context.Context, now time.Time, limit int) ([]outbox.OutboxEvent, error) {
+	events := make([]outbox.OutboxEvent, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		//an event waits while an older event of its aggregate is pending, as in the postgres query
+		type aggregate struct {
+			kind string
+			id   int64
+		}
+		held := make(map[aggregate]bool)
+		for _, event := range data.outbox {
+			if event.Status.String != outbox.PENDING {
+				continue
+			}
+			key := aggregate{kind: event.AggregateType.String, id: event.AggregateId.Int64}
+			if held[key] {
+				continue
+			}
+			held[key] = true
+			if event.NextAttemptAt.Time.After(now) || len(events) == limit {
+				continue
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (obj *memoryDb) ClaimOutboxEvent(ctx context.Context, eventId int64, attempts int64, leaseUntil time.Time) (bool, error) {
+	claimed := false
+	err := obj.write(ctx, func(data *tables) error {
+		event := data.outboxEvent(eventId)
+		if event == nil || event.Status.String != outbox.PENDING || event.Attempts.Int64 != attempts {
+			return nil
+		}
+		event.Attempts = nullInt(attempts + 1)
+		event.NextAttemptAt = nullTime(leaseUntil)
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
+
+func (obj *memoryDb) MarkOutboxEventDelivered(ctx context.Context, eventId int64, deliveredAt time.Time) error {
+	return obj.write(ctx, func(data *tables) error {
+		if event := data.outboxEvent(eventId); event != nil {
+			event.Status = nullString(outbox.DELIVERED)
+			event.DeliveredAt = nullTime(deliveredAt)
+			event.LastError = sql.NullString{}
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) RetryOutboxEvent(ctx context.Context, eventId int64, nextAttemptAt time.Time, lastError string) error {
+	return obj.write(ctx, func(data *tables) error {
+		if event := data.outboxEvent(eventId); event != nil && event.Status.String == outbox.PENDING {
+			event.NextAttemptAt = nullTime(nextAttemptAt)
+			event.LastError = nullString(lastError)
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) FailOutboxEvent(ctx context.Context, eventId int64, lastError string) error {
+	return obj.write(ctx, func(data *tables) error {
+		if event := data.outboxEvent(eventId); event != nil && event.Status.String == outbox.PENDING {
+			event.Status = nullString(outbox.FAILED)
+			event.LastError = nullString(lastError)
+		}
+		return nil
+	})
+}
+
+func (data *tables) outboxEvent(eventId int64) *outbox.OutboxEvent {
+	for i := range data.outbox {
+		if data.outbox[i].EventId.Int64 == eventId {
+			return &data.outbox[i]
+		}
+	}
+	return nil
+}
+
+// addEvents writes the events in the transaction of the change they announce, as outbox.AddEvents does
+func (data *tables) addEvents(events []outbox.Event) {
+	for _, event := range events {
+		data.addEvent(event)
+	}
+}
+
+func (data *tables) addEvent(event outbox.Event) {
+	now := time.Now().UTC()
+	data.outbox = append(data.outbox, outbox.OutboxEvent{
+		EventId:       nullInt(int64(len(data.outbox) + 1)),
+		EventType:     nullString(event.EventType),
+		AggregateType: nullString(event.AggregateType),
+		AggregateId:   nullInt(event.AggregateId),
+		Payload:       nullString(event.Payload),
+		Status:        nullString(outbox.PENDING),
+		Attempts:      nullInt(0),
+		NextAttemptAt: nullTime(now),
+		CreatedAt:     nullTime(now),
+	})
+}
@@ -0,0 +1,131 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/payment"
+)
+
+func (obj *memoryDb) AddPaymentEvent(ctx context.Context, event payment.PaymentEvent) (int64, error) {
+	var eventId int64
+	err := obj.write(ctx, func(data *tables) error {
+		for _, row := range data.paymentEvents {
+			if row.Provider.String == event.Provider.String && row.ProviderEventId.String == event.ProviderEventId.String {
+				return nil
+			}
+		}
+		data.paymentEventSeq++
+		eventId = data.paymentEventSeq
+		data.paymentEvents = append(data.paymentEvents, payment.PaymentEvent{
+			EventId:         nullInt(eventId),
+			Provider:        nullString(event.Provider.String),
+			ProviderEventId: nullString(event.ProviderEventId.String),
+			EventType:       nullString(event.EventType.String),
+			PaymentId:       event.PaymentId,
+			Reference:       event.Reference,
+			Amount:          event.Amount,
+			Status:          nullString(payment.RECEIVED),
+			Payload:         nullString(event.Payload.String),
+			ReceivedAt:      nullTime(event.ReceivedAt.Time),
+			LeaseUntil:      nullTime(event.LeaseUntil.Time),
+		})
+		return nil
+	})
+	return eventId, err
+}
+
+func (obj *memoryDb) GetPaymentEvent(ctx context.Context, provider string, providerEventId string) (payment.PaymentEvent, error) {
+	var event payment.PaymentEvent
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.paymentEvents {
+			if row.Provider.String == provider && row.ProviderEventId.String == providerEventId {
+				event = row
+				return nil
+			}
+		}
+		return sql.ErrNoRows
+	})
+	return event, err
+}
+
+func (obj *memoryDb) CompletePaymentEvent(ctx context.Context, event payment.PaymentEvent) error {
+	if err := checkEnum("paymenteventstatus", event.Status.String); err != nil {
+		return err
+	}
+	return obj.write(ctx, func(data *tables) error {
+		row := data.paymentEvent(event.EventId.Int64)
+		if row == nil || row.Status.String != payment.RECEIVED {
+			return nil
+		}
+		if event.LoanId.Valid && data.loan(event.LoanId.Int64) == nil {
+			return foreignKeyViolation("payment_event", "fk_loanid")
+		}
+		row.Status = nullString(event.Status.String)
+		row.LoanId = event.LoanId
+		row.Error = event.Error
+		row.ProcessedAt = nullTime(event.ProcessedAt.Time)
+		return nil
+	})
+}
+
+func (obj *memoryDb) ClaimPaymentEvent(ctx context.Context, eventId int64, now time.Time, leaseUntil time.Time) (bool, error) {
+	claimed := false
+	err := obj.write(ctx, func(data *tables) error {
+		row := data.paymentEvent(eventId)
+		if row == nil || row.Status.String != payment.RECEIVED || (row.LeaseUntil.Valid && row.LeaseUntil.Time.After(now)) {
+			return nil
+		}
+		row.LeaseUntil = nullTime(leaseUntil)
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
+
+func (obj *memoryDb) DeletePaymentEvent(ctx context.Context, eventId int64) error {
+	return obj.write(ctx, func(data *tables) error {
+		events := make([]payment.PaymentEvent, 0, len(data.paymentEvents))
+		for _, row := range data.paymentEvents {
+			if row.EventId.Int64 != eventId || row.Status.String != payment.RECEIVED {
+				events = append(events, row)
+			}
+		}
+		data.paymentEvents = events
+		return nil
+	})
+}
+
+func (obj *memoryDb) GetPaymentEvents(ctx context.Context, filter payment.PaymentEventFilter) ([]payment.PaymentEvent, error) {
+	if filter.Status != "" {
+		if err := checkEnum("paymenteventstatus", filter.Status); err != nil {
+			return nil, err
+		}
+	}
+	events := make([]payment.PaymentEvent, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, event := range data.paymentEvents {
+			if len(events) == filter.Limit {
+				break
+			}
+			if event.EventId.Int64 <= filter.AfterId ||
+				(filter.Status != "" && event.Status.String != filter.Status) ||
+				(filter.LoanId != 0 && event.LoanId.Int64 != filter.LoanId) {
+				continue
+			}
+			events = append(events, event)
+		}
+		return nil
+	})
+	return events, err
+}
+
+func (data *tables) paymentEvent(eventId int64) *payment.PaymentEvent {
+	for i := range data.paymentEvents {
+		if data.paymentEvents[i].EventId.Int64 == eventId {
+			return &data.paymentEvents[i]
+		}
+	}
+	return nil
+}
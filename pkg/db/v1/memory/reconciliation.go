@@ -0,0 +1,230 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+
+	"aspire-assignment/pkg/db/v1/reconciliation"
+)
+
+func (obj *memoryDb) AddBankStatement(ctx context.Context, statement reconciliation.BankStatement, lines []reconciliation.StatementLine) (int64, []reconciliation.StatementLine, error) {
+	var (
+		statementId int64
+		added       []reconciliation.StatementLine
+	)
+	err := obj.write(ctx, func(data *tables) error {
+		for _, row := range data.statements {
+			if row.Checksum.String == statement.Checksum.String {
+				return nil
+			}
+		}
+		if statement.ImportedBy.Valid && data.user(statement.ImportedBy.Int64) == nil {
+			return foreignKeyViolation("bank_statement", "fk_importedby")
+		}
+		data.statementSeq++
+		statementId = data.statementSeq
+		data.statements = append(data.statements, reconciliation.BankStatement{
+			StatementId: nullInt(statementId),
+			FileName:    nullString(statement.FileName.String),
+			Format:      nullString(statement.Format.String),
+			Checksum:    nullString(statement.Checksum.String),
+			ImportedBy:  statement.ImportedBy,
+			ImportedAt:  nullTime(statement.ImportedAt.Time),
+		})
+		added = make([]reconciliation.StatementLine, 0, len(lines))
+		for _, line := range lines {
+			if line.BankReference.Valid && data.hasBankReference(line.BankReference.String) {
+				continue
+			}
+			data.statementLineSeq++
+			row := reconciliation.StatementLine{
+				LineId:        nullInt(data.statementLineSeq),
+				StatementId:   nullInt(statementId),
+				LineNum:       nullInt(line.LineNum.Int64),
+				BookedOn:      line.BookedOn,
+				Amount:        nullFloat(line.Amount.Float64),
+				Reference:     line.Reference,
+				Counterparty:  line.Counterparty,
+				BankReference: line.BankReference,
+				Status:        nullString(reconciliation.RECEIVED),
+			}
+			data.statementLines = append(data.statementLines, row)
+			added = append(added, row)
+		}
+		return nil
+	})
+	if err != nil || statementId == 0 {
+		return 0, nil, err
+	}
+	return statementId, added, nil
+}
+
+func (obj *memoryDb) GetBankStatements(ctx context.Context) ([]reconciliation.BankStatement, error) {
+	statements := make([]reconciliation.BankStatement, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.statements {
+			row.Lines = make(map[string]int64)
+			for _, line := range data.statementLines {
+				if line.StatementId.Int64 == row.StatementId.Int64 {
+					row.Lines[line.Status.String]++
+				}
+			}
+			statements = append(statements, row)
+		}
+		return nil
+	})
+	sort.SliceStable(statements, func(i, j int) bool {
+		return statements[i].StatementId.Int64 > statements[j].StatementId.Int64
+	})
+	return statements, err
+}
+
+func (obj *memoryDb) CompleteStatementLine(ctx context.Context, line reconciliation.StatementLine) error {
+	if err := checkEnum("statementlinestatus", line.Status.String); err != nil {
+		return err
+	}
+	return obj.write(ctx, func(data *tables) error {
+		row := data.statementLine(line.LineId.Int64)
+		if row == nil || row.Status.String != reconciliation.RECEIVED {
+			return nil
+		}
+		if line.LoanId.Valid && data.loan(line.LoanId.Int64) == nil {
+			return foreignKeyViolation("statement_line", "fk_loanid")
+		}
+		row.Status = nullString(line.Status.String)
+		row.LoanId = line.LoanId
+		row.MatchRule = line.MatchRule
+		row.Reason = line.Reason
+		return nil
+	})
+}
+
+func (obj *memoryDb) GetStatementLine(ctx context.Context, lineId int64) (reconciliation.StatementLine, error) {
+	var line reconciliation.StatementLine
+	err := obj.read(ctx, func(data *tables) error {
+		row := data.statementLine(lineId)
+		if row == nil {
+			return sql.ErrNoRows
+		}
+		line = *row
+		return nil
+	})
+	return line, err
+}
+
+func (obj *memoryDb) GetStatementLines(ctx context.Context, filter reconciliation.StatementLineFilter) ([]reconciliation.StatementLine, error) {
+	if filter.Status != "" {
+		if err := checkEnum("statementlinestatus", filter.Status); err != nil {
+			return nil, err
+		}
+	}
+	lines := make([]reconciliation.StatementLine, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, line := range data.statementLines {
+			if len(lines) == filter.Limit {
+				break
+			}
+			if line.LineId.Int64 <= filter.AfterId ||
+				(filter.StatementId != 0 && line.StatementId.Int64 != filter.StatementId) ||
+				(filter.Status != "" && line.Status.String != filter.Status) {
+				continue
+			}
+			lines = append(lines, line)
+		}
+		return nil
+	})
+	return lines, err
+}
+
+func (obj *memoryDb) ResolveStatementLine(ctx context.Context, line reconciliation.StatementLine) (int64, error) {
+	if err := checkEnum("statementlinestatus", line.Status.String); err != nil {
+		return 0, err
+	}
+	var lineId int64
+	err := obj.write(ctx, func(data *tables) error {
+		row := data.statementLine(line.LineId.Int64)
+		if row == nil || row.Status.String != reconciliation.EXCEPTION {
+			return nil
+		}
+		if line.LoanId.Valid && data.loan(line.LoanId.Int64) == nil {
+			return foreignKeyViolation("statement_line", "fk_loanid")
+		}
+		if line.ResolvedBy.Valid && data.user(line.ResolvedBy.Int64) == nil {
+			return foreignKeyViolation("statement_line", "fk_resolvedby")
+		}
+		row.Status = nullString(line.Status.String)
+		row.LoanId = line.LoanId
+		row.MatchRule = line.MatchRule
+		row.ResolvedBy = line.ResolvedBy
+		row.ResolvedAt = nullTime(line.ResolvedAt.Time)
+		row.Note = line.Note
+		lineId = line.LineId.Int64
+		return nil
+	})
+	return lineId, err
+}
+
+func (obj *memoryDb) ReopenStatementLine(ctx context.Context, lineId int64, reason string) error {
+	return obj.write(ctx, func(data *tables) error {
+		row := data.statementLine(lineId)
+		if row == nil || row.Status.String != reconciliation.RESOLVED {
+			return nil
+		}
+		row.Status = nullString(reconciliation.EXCEPTION)
+		row.LoanId = sql.NullInt64{}
+		row.MatchRule = sql.NullString{}
+		row.Reason = nullString(reason)
+		row.ResolvedBy = sql.NullInt64{}
+		row.ResolvedAt = sql.NullTime{}
+		row.Note = sql.NullString{}
+		return nil
+	})
+}
+
+func (obj *memoryDb) GetLoansByNextInstallment(ctx context.Context, amount float64) ([]int64, error) {
+	loanIds := make([]int64, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.loans {
+			if row.Status.String != "APPROVED" {
+				continue
+			}
+			var next *int64
+			var due float64
+			for _, installment := range data.installments {
+				if installment.LoanId.Int64 != row.LoanId.Int64 || installment.Status.String != "PENDING" {
+					continue
+				}
+				if next == nil || installment.InstallmentSeq.Int64 < *next {
+					seq := installment.InstallmentSeq.Int64
+					next = &seq
+					due = installment.AmountDue.Float64
+				}
+			}
+			if next != nil && math.Abs(due-amount) < reconciliation.AMOUNT_TOLERANCE {
+				loanIds = append(loanIds, row.LoanId.Int64)
+			}
+		}
+		return nil
+	})
+	return loanIds, err
+}
+
+func (data *tables) statementLine(lineId int64) *reconciliation.StatementLine {
+	for i := range data.statementLines {
+		if data.statementLines[i].LineId.Int64 == lineId {
+			return &data.statementLines[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) hasBankReference(bankReference string) bool {
+	for _, line := range data.statementLines {
+		if line.BankReference.Valid && line.BankReference.String == bankReference {
+			return true
+		}
+	}
+	return false
+}
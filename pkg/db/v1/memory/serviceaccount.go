@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/usermanagement"
+)
+
+// AddServiceAccount returns 0 when a service account with the name already exists
+func (obj *memoryDb) AddServiceAccount(ctx context.Context, account usermanagement.ServiceAccount) (int64, error) {
+	var accountId int64
+	err := obj.write(ctx, func(data *tables) error {
+		for _, row := range data.serviceAccounts {
+			if row.Name.String == account.Name.String {
+				return nil
+			}
+		}
+		accountId = int64(len(data.serviceAccounts) + 1)
+		data.serviceAccounts = append(data.serviceAccounts, usermanagement.ServiceAccount{
+			ServiceAccountId: nullInt(accountId),
+			Name:             nullString(account.Name.String),
+			Description:      nullString(account.Description.String),
+			CreatedBy:        nullInt(account.CreatedBy.Int64),
+			CreatedAt:        nullTime(time.Now()),
+		})
+		return nil
+	})
+	return accountId, err
+}
+
+func (obj *memoryDb) GetServiceAccounts(ctx context.Context) ([]usermanagement.ServiceAccount, error) {
+	accounts := make([]usermanagement.ServiceAccount, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		accounts = append(accounts, data.serviceAccounts...)
+		return nil
+	})
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Name.String < accounts[j].Name.String
+	})
+	return accounts, err
+}
+
+// AddAPIKey stores the key with its scopes. returns 0 when the service account or any of the scopes does not exist
+func (obj *memoryDb) AddAPIKey(ctx context.Context, key usermanagement.APIKey) (int64, error) {
+	var keyId int64
+	err := obj.write(ctx, func(data *tables) error {
+		if data.serviceAccount(key.ServiceAccountId.Int64) == nil {
+			return nil
+		}
+		//like the insert select of the scopes, a repeated scope counts once and fails the check
+		scopes := make([]string, 0, len(key.Scopes))
+		for _, scope := range key.Scopes {
+			if containsString(data.permissions, scope) && !containsString(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+		if len(scopes) != len(key.Scopes) {
+			return nil
+		}
+		for _, row := range data.apiKeys {
+			if row.Prefix.String == key.Prefix.String {
+				return uniqueViolation("api_key_prefix_key")
+			}
+			if row.KeyHash.String == key.KeyHash.String {
+				return uniqueViolation("api_key_key_hash_key")
+			}
+		}
+
+		keyId = int64(len(data.apiKeys) + 1)
+		data.apiKeys = append(data.apiKeys, usermanagement.APIKey{
+			KeyId:            nullInt(keyId),
+			ServiceAccountId: nullInt(key.ServiceAccountId.Int64),
+			Prefix:           nullString(key.Prefix.String),
+			KeyHash:          nullString(key.KeyHash.String),
+			Scopes:           sortedCopy(scopes),
+			ExpiresAt:        nullTime(key.ExpiresAt.Time),
+			CreatedBy:        nullInt(key.CreatedBy.Int64),
+			CreatedAt:        nullTime(time.Now()),
+		})
+		return nil
+	})
+	return keyId, err
+}
+
+// GetAPIKeys lists the keys of every service account, without the key hashes
+func (obj *memoryDb) GetAPIKeys(ctx context.Context) ([]usermanagement.APIKey, error) {
+	keys := make([]usermanagement.APIKey, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.apiKeys {
+			row.ServiceAccount = data.serviceAccount(row.ServiceAccountId.Int64).Name
+			row.KeyHash = sql.NullString{}
+			row.Scopes = append([]string{}, row.Scopes...)
+			keys = append(keys, row)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (obj *memoryDb) GetAPIKey(ctx context.Context, keyHash string) (usermanagement.APIKey, error) {
+	key := usermanagement.APIKey{Scopes: make([]string, 0)}
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.apiKeys {
+			if row.KeyHash.String != keyHash {
+				continue
+			}
+			key = row
+			key.ServiceAccount = data.serviceAccount(row.ServiceAccountId.Int64).Name
+			key.Scopes = append([]string{}, row.Scopes...)
+			key.CreatedBy, key.CreatedAt = sql.NullInt64{}, sql.NullTime{}
+		}
+		return nil
+	})
+	return key, err
+}
+
+// RevokeAPIKey returns false when the key does not exist or was already revoked
+func (obj *memoryDb) RevokeAPIKey(ctx context.Context, keyId int64) (bool, error) {
+	var revoked bool
+	err := obj.write(ctx, func(data *tables) error {
+		if row := data.apiKey(keyId); row != nil && !row.RevokedAt.Valid {
+			row.RevokedAt = nullTime(time.Now())
+			revoked = true
+		}
+		return nil
+	})
+	return revoked, err
+}
+
+// TouchAPIKey records the use of a key. uses after touchBefore are not written again
+func (obj *memoryDb) TouchAPIKey(ctx context.Context, keyId int64, usedAt time.Time, touchBefore time.Time) error {
+	return obj.write(ctx, func(data *tables) error {
+		if row := data.apiKey(keyId); row != nil && (!row.LastUsedAt.Valid || row.LastUsedAt.Time.Before(touchBefore)) {
+			row.LastUsedAt = nullTime(usedAt)
+		}
+		return nil
+	})
+}
+
+func (data *tables) serviceAccount(accountId int64) *usermanagement.ServiceAccount {
+	for i := range data.serviceAccounts {
+		if data.serviceAccounts[i].ServiceAccountId.Int64 == accountId {
+			return &data.serviceAccounts[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) apiKey(keyId int64) *usermanagement.APIKey {
+	for i := range data.apiKeys {
+		if data.apiKeys[i].KeyId.Int64 == keyId {
+			return &data.apiKeys[i]
+		}
+	}
+	return nil
+}
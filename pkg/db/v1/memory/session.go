@@ -0,0 +1,248 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/usermanagement"
+)
+
+func (obj *memoryDb) GetTokenVersion(ctx context.Context, userId int64) (int64, error) {
+	var version int64
+	err := obj.read(ctx, func(data *tables) error {
+		if user := data.user(userId); user != nil {
+			version = user.TokenVersion.Int64
+		}
+		return nil
+	})
+	return version, err
+}
+
+func (obj *memoryDb) UpdatePassword(ctx context.Context, userId int64, passwordHash string) (int64, error) {
+	var version int64
+	err := obj.write(ctx, func(data *tables) error {
+		version = data.setPassword(userId, passwordHash)
+		data.revokeUserRefreshTokens(userId)
+		return nil
+	})
+	return version, err
+}
+
+func (obj *memoryDb) AddPasswordResetToken(ctx context.Context, token usermanagement.PasswordResetToken) (int64, error) {
+	var tokenId int64
+	err := obj.write(ctx, func(data *tables) error {
+		for _, row := range data.passwordResets {
+			if row.TokenHash.String == token.TokenHash.String {
+				return uniqueViolation("password_reset_token_hash_key")
+			}
+		}
+		tokenId = int64(len(data.passwordResets) + 1)
+		data.passwordResets = append(data.passwordResets, usermanagement.PasswordResetToken{
+			TokenId:   nullInt(tokenId),
+			UserId:    nullInt(token.UserId.Int64),
+			TokenHash: nullString(token.TokenHash.String),
+			ExpiresAt: nullTime(token.ExpiresAt.Time),
+			CreatedAt: nullTime(time.Now()),
+		})
+		return nil
+	})
+	return tokenId, err
+}
+
+// ResetPassword consumes an unused and unexpired reset token and sets the new password. returns 0 when the token is not usable
+func (obj *memoryDb) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int64, error) {
+	var userId int64
+	err := obj.write(ctx, func(data *tables) error {
+		now := time.Now()
+		for _, row := range data.passwordResets {
+			if row.TokenHash.String == tokenHash && !row.UsedAt.Valid && row.ExpiresAt.Time.After(now) {
+				userId = row.UserId.Int64
+			}
+		}
+		if userId == 0 {
+			return nil
+		}
+
+		//any other outstanding token for the user is void once the password is reset
+		for i := range data.passwordResets {
+			row := &data.passwordResets[i]
+			if row.UserId.Int64 == userId && !row.UsedAt.Valid {
+				row.UsedAt = nullTime(now)
+			}
+		}
+		data.setPassword(userId, passwordHash)
+		data.revokeUserRefreshTokens(userId)
+		return nil
+	})
+	return userId, err
+}
+
+func (obj *memoryDb) AddRefreshToken(ctx context.Context, token usermanagement.RefreshToken) (int64, error) {
+	var tokenId int64
+	err := obj.write(ctx, func(data *tables) (err error) {
+		tokenId, err = data.insertRefreshToken(token)
+		return err
+	})
+	return tokenId, err
+}
+
+func (obj *memoryDb) GetRefreshToken(ctx context.Context, tokenHash string) (usermanagement.RefreshToken, error) {
+	var token usermanagement.RefreshToken
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.refreshTokens {
+			if row.TokenHash.String == tokenHash {
+				token = row
+			}
+		}
+		return nil
+	})
+	return token, err
+}
+
+// RotateRefreshToken returns false when the old token was already rotated or revoked
+func (obj *memoryDb) RotateRefreshToken(ctx context.Context, oldTokenId int64, newToken usermanagement.RefreshToken) (bool, error) {
+	var rotated bool
+	err := obj.write(ctx, func(data *tables) error {
+		for i := range data.refreshTokens {
+			row := &data.refreshTokens[i]
+			if row.TokenId.Int64 != oldTokenId || row.Status.String != "ACTIVE" {
+				continue
+			}
+			row.Status = nullString("ROTATED")
+			row.RotatedAt = nullTime(time.Now())
+			if _, err := data.insertRefreshToken(newToken); err != nil {
+				return err
+			}
+			rotated = true
+			return nil
+		}
+		return nil
+	})
+	return rotated, err
+}
+
+func (obj *memoryDb) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	return obj.write(ctx, func(data *tables) error {
+		for i := range data.refreshTokens {
+			row := &data.refreshTokens[i]
+			if row.FamilyId.String == familyId && row.Status.String == "ACTIVE" {
+				row.Status = nullString("REVOKED")
+			}
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) RevokeAccessToken(ctx context.Context, tokenId string, userId int64, expiresAt time.Time) error {
+	return obj.write(ctx, func(data *tables) error {
+		if _, ok := data.revokedTokens[tokenId]; !ok {
+			data.revokedTokens[tokenId] = revokedToken{UserId: userId, ExpiresAt: expiresAt}
+		}
+
+		//entries past expiry can never match a valid token
+		now := time.Now()
+		for jti, token := range data.revokedTokens {
+			if token.ExpiresAt.Before(now) {
+				delete(data.revokedTokens, jti)
+			}
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) IsAccessTokenRevoked(ctx context.Context, tokenId string) (bool, error) {
+	var revoked bool
+	err := obj.read(ctx, func(data *tables) error {
+		_, revoked = data.revokedTokens[tokenId]
+		return nil
+	})
+	return revoked, err
+}
+
+func (obj *memoryDb) AddAdminInvite(ctx context.Context, invite usermanagement.AdminInvite) (int64, error) {
+	var inviteId int64
+	err := obj.write(ctx, func(data *tables) error {
+		for _, row := range data.invites {
+			if row.CodeHash.String == invite.CodeHash.String {
+				return uniqueViolation("admin_invite_code_hash_key")
+			}
+		}
+		inviteId = int64(len(data.invites) + 1)
+		data.invites = append(data.invites, usermanagement.AdminInvite{
+			InviteId:  nullInt(inviteId),
+			CodeHash:  nullString(invite.CodeHash.String),
+			Email:     nullString(invite.Email.String),
+			CreatedBy: nullInt(invite.CreatedBy.Int64),
+			ExpiresAt: nullTime(invite.ExpiresAt.Time),
+			CreatedAt: nullTime(time.Now()),
+		})
+		return nil
+	})
+	return inviteId, err
+}
+
+// RedeemAdminInvite consumes an unused and unexpired invite issued for the email of the user and creates the account.
+// returns 0 when the invite is not usable
+func (obj *memoryDb) RedeemAdminInvite(ctx context.Context, codeHash string, userDetail usermanagement.UserDetails) (int64, error) {
+	var userId int64
+	err := obj.write(ctx, func(data *tables) error {
+		now := time.Now()
+		for i := range data.invites {
+			invite := &data.invites[i]
+			if invite.CodeHash.String != codeHash || invite.Email.String != userDetail.Email.String || invite.UsedAt.Valid || !invite.ExpiresAt.Time.After(now) {
+				continue
+			}
+			id, err := data.insertUser(userDetail)
+			if err != nil {
+				return err
+			}
+			invite.UsedAt = nullTime(now)
+			invite.UsedBy = nullInt(id)
+			userId = id
+			return nil
+		}
+		return nil
+	})
+	return userId, err
+}
+
+// setPassword stores the password hash and returns the bumped token version
+func (data *tables) setPassword(userId int64, passwordHash string) int64 {
+	user := data.user(userId)
+	if user == nil {
+		return 0
+	}
+	user.UserPassword = nullString(passwordHash)
+	user.TokenVersion = nullInt(user.TokenVersion.Int64 + 1)
+	user.UpdatedAt = nullTime(time.Now())
+	return user.TokenVersion.Int64
+}
+
+// revokeUserRefreshTokens ends every refresh token family of the user
+func (data *tables) revokeUserRefreshTokens(userId int64) {
+	for i := range data.refreshTokens {
+		row := &data.refreshTokens[i]
+		if row.UserId.Int64 == userId && row.Status.String == "ACTIVE" {
+			row.Status = nullString("REVOKED")
+		}
+	}
+}
+
+func (data *tables) insertRefreshToken(token usermanagement.RefreshToken) (int64, error) {
+	for _, row := range data.refreshTokens {
+		if row.TokenHash.String == token.TokenHash.String {
+			return 0, uniqueViolation("refresh_token_token_hash_key")
+		}
+	}
+	tokenId := int64(len(data.refreshTokens) + 1)
+	data.refreshTokens = append(data.refreshTokens, usermanagement.RefreshToken{
+		TokenId:   nullInt(tokenId),
+		UserId:    nullInt(token.UserId.Int64),
+		TokenHash: nullString(token.TokenHash.String),
+		FamilyId:  nullString(token.FamilyId.String),
+		Status:    nullString("ACTIVE"),
+		ExpiresAt: nullTime(token.ExpiresAt.Time),
+		CreatedAt: nullTime(time.Now()),
+	})
+	return tokenId, nil
+}
@@ -0,0 +1,315 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/usermanagement"
+)
+
+func (obj *memoryDb) AddUser(ctx context.Context, userDetail usermanagement.UserDetails) (int64, error) {
+	var userId int64
+	err := obj.write(ctx, func(data *tables) (err error) {
+		userId, err = data.insertUser(userDetail)
+		return err
+	})
+	return userId, err
+}
+
+// insertUser adds the user along with the salary declared at signup as the first unverified income and the default role
+func (data *tables) insertUser(userDetail usermanagement.UserDetails) (int64, error) {
+	if err := checkEnum("usertypes", userDetail.UserType.String); err != nil {
+		return 0, err
+	}
+	for _, row := range data.users {
+		if row.UserName.String == userDetail.UserName.String {
+			return 0, uniqueViolation("user_detail_user_name_key")
+		}
+	}
+	now := time.Now()
+	userId := int64(len(data.users) + 1)
+	data.users = append(data.users, usermanagement.UserDetails{
+		UserId:         nullInt(userId),
+		UserName:       nullString(userDetail.UserName.String),
+		UserPassword:   nullString(userDetail.UserPassword.String),
+		UserType:       nullString(userDetail.UserType.String),
+		Email:          nullString(userDetail.Email.String),
+		Mobile:         nullString(userDetail.Mobile.String),
+		MonthlySalary:  nullFloat(userDetail.MonthlySalary.Float64),
+		AccountBalance: nullFloat(userDetail.AccountBalance.Float64),
+		TokenVersion:   nullInt(0),
+		CreatedAt:      nullTime(now),
+		UpdatedAt:      nullTime(now),
+	})
+	data.addIncome(userId, userDetail.MonthlySalary.Float64)
+
+	//every user starts with the role of the same name as the user type
+	if data.role(userDetail.UserType.String) != nil {
+		data.userRoles[userId] = []string{userDetail.UserType.String}
+	}
+	return userId, nil
+}
+
+func (obj *memoryDb) CountUsersByType(ctx context.Context, userType string) (int64, error) {
+	var count int64
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.users {
+			if row.UserType.String == userType {
+				count++
+			}
+		}
+		return nil
+	})
+	return count, err
+}
+
+// AddFirstUserOfType adds the user only while no user of its type exists and returns 0 otherwise
+func (obj *memoryDb) AddFirstUserOfType(ctx context.Context, userDetail usermanagement.UserDetails) (int64, error) {
+	var userId int64
+	err := obj.write(ctx, func(data *tables) (err error) {
+		for _, row := range data.users {
+			if row.UserType.String == userDetail.UserType.String {
+				return nil
+			}
+		}
+		userId, err = data.insertUser(userDetail)
+		return err
+	})
+	return userId, err
+}
+
+func (obj *memoryDb) GetUserByUsername(ctx context.Context, userName string) (usermanagement.UserDetails, error) {
+	var userDetail usermanagement.UserDetails
+	err := obj.read(ctx, func(data *tables) error {
+		for _, row := range data.users {
+			if row.UserName.String == userName {
+				userDetail = row
+			}
+		}
+		return nil
+	})
+	return userDetail, err
+}
+
+func (obj *memoryDb) GetUserById(ctx context.Context, userId int64) (usermanagement.UserDetails, error) {
+	var userDetail usermanagement.UserDetails
+	err := obj.read(ctx, func(data *tables) error {
+		if row := data.user(userId); row != nil {
+			userDetail = *row
+		}
+		return nil
+	})
+	return userDetail, err
+}
+
+func (obj *memoryDb) UpdateUserProfile(ctx context.Context, userId int64, changes []usermanagement.ProfileChange) error {
+	return obj.write(ctx, func(data *tables) error {
+		for _, change := range changes {
+			if err := data.applyProfileChange(userId, change); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// applyProfileChange mirrors the postgres column update, so a salary or balance which is not a number fails the transaction
+func (data *tables) applyProfileChange(userId int64, change usermanagement.ProfileChange) error {
+	user := data.user(userId)
+	field, value := change.Field.String, change.NewValue.String
+	switch field {
+	case "EMAIL", "MOBILE":
+		if user == nil {
+			break
+		}
+		if field == "EMAIL" {
+			user.Email = nullString(value)
+		} else {
+			user.Mobile = nullString(value)
+		}
+	case "SALARY", "BANK_BALANCE":
+		amount, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid input syntax for type double precision: \"%s\"", value)
+		}
+		if user == nil {
+			break
+		}
+		if field == "SALARY" {
+			user.MonthlySalary = nullFloat(amount)
+		} else {
+			user.AccountBalance = nullFloat(amount)
+		}
+	default:
+		return fmt.Errorf("profile field %s cannot be updated", field)
+	}
+
+	data.profileHistory = append(data.profileHistory, usermanagement.ProfileChange{
+		ChangeId:  nullInt(int64(len(data.profileHistory) + 1)),
+		UserId:    nullInt(userId),
+		Field:     nullString(field),
+		OldValue:  nullString(change.OldValue.String),
+		NewValue:  nullString(value),
+		ChangedBy: nullInt(change.ChangedBy.Int64),
+		CreatedAt: nullTime(time.Now()),
+	})
+
+	//a changed salary is a new self declared income which stays unverified until an income proof is verified
+	if field == "SALARY" {
+		amount, _ := strconv.ParseFloat(value, 64)
+		data.addIncome(userId, amount)
+	}
+	return nil
+}
+
+func (obj *memoryDb) GetProfileHistory(ctx context.Context, userId int64) ([]usermanagement.ProfileChange, error) {
+	changes := make([]usermanagement.ProfileChange, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for i := len(data.profileHistory) - 1; i >= 0; i-- {
+			if data.profileHistory[i].UserId.Int64 == userId {
+				changes = append(changes, data.profileHistory[i])
+			}
+		}
+		return nil
+	})
+	return changes, err
+}
+
+func (obj *memoryDb) GetLatestVerifiedIncome(ctx context.Context, userId int64) (float64, error) {
+	var salary float64
+	err := obj.read(ctx, func(data *tables) error {
+		for i := len(data.incomes) - 1; i >= 0; i-- {
+			if data.incomes[i].UserId == userId && data.incomes[i].Verified {
+				salary = data.incomes[i].MonthlySalary
+				break
+			}
+		}
+		return nil
+	})
+	return salary, err
+}
+
+func (obj *memoryDb) AddContactVerification(ctx context.Context, verification usermanagement.ContactVerification) (int64, error) {
+	var verificationId int64
+	err := obj.write(ctx, func(data *tables) error {
+		verificationId = int64(len(data.verifications) + 1)
+		data.verifications = append(data.verifications, usermanagement.ContactVerification{
+			VerificationId: nullInt(verificationId),
+			UserId:         nullInt(verification.UserId.Int64),
+			Channel:        nullString(verification.Channel.String),
+			NewValue:       nullString(verification.NewValue.String),
+			OtpHash:        nullString(verification.OtpHash.String),
+			Attempts:       nullInt(0),
+			Status:         nullString("PENDING"),
+			ExpiresAt:      nullTime(verification.ExpiresAt.Time),
+			CreatedAt:      nullTime(time.Now()),
+		})
+		return nil
+	})
+	return verificationId, err
+}
+
+func (obj *memoryDb) GetContactVerification(ctx context.Context, verificationId int64, userId int64) (usermanagement.ContactVerification, error) {
+	var verification usermanagement.ContactVerification
+	err := obj.read(ctx, func(data *tables) error {
+		if row := data.verification(verificationId); row != nil && row.UserId.Int64 == userId {
+			verification = *row
+		}
+		return nil
+	})
+	return verification, err
+}
+
+// IncrementContactVerificationAttempts counts an attempt at the otp of a pending verification and returns false when
+// maxAttempts were made already
+func (obj *memoryDb) IncrementContactVerificationAttempts(ctx context.Context, verificationId int64, maxAttempts int64) (bool, error) {
+	var counted bool
+	err := obj.write(ctx, func(data *tables) error {
+		row := data.verification(verificationId)
+		if row == nil || row.Status.String != "PENDING" || row.Attempts.Int64 >= maxAttempts {
+			return nil
+		}
+		row.Attempts = nullInt(row.Attempts.Int64 + 1)
+		counted = true
+		return nil
+	})
+	return counted, err
+}
+
+// CompleteContactVerification marks the verification done and applies the verified contact to the profile in one transaction
+func (obj *memoryDb) CompleteContactVerification(ctx context.Context, verification usermanagement.ContactVerification) error {
+	return obj.write(ctx, func(data *tables) error {
+		row := data.verification(verification.VerificationId.Int64)
+		if row == nil || row.Status.String != "PENDING" {
+			return fmt.Errorf("contact verification is not pending")
+		}
+		row.Status = nullString("VERIFIED")
+		row.VerifiedAt = nullTime(time.Now())
+
+		//supersede any other pending change of the same contact
+		for i := range data.verifications {
+			other := &data.verifications[i]
+			if other.UserId.Int64 == verification.UserId.Int64 && other.Channel.String == verification.Channel.String && other.Status.String == "PENDING" {
+				other.Status = nullString("EXPIRED")
+			}
+		}
+
+		change := usermanagement.ProfileChange{
+			Field:     verification.Channel,
+			NewValue:  verification.NewValue,
+			ChangedBy: verification.UserId,
+		}
+		if user := data.user(verification.UserId.Int64); user != nil {
+			change.OldValue = user.Email
+			if verification.Channel.String == "MOBILE" {
+				change.OldValue = user.Mobile
+			}
+		}
+		return data.applyProfileChange(verification.UserId.Int64, change)
+	})
+}
+
+func (data *tables) user(userId int64) *usermanagement.UserDetails {
+	for i := range data.users {
+		if data.users[i].UserId.Int64 == userId {
+			return &data.users[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) addIncome(userId int64, salary float64) {
+	data.incomes = append(data.incomes, income{
+		IncomeId:      int64(len(data.incomes) + 1),
+		UserId:        userId,
+		MonthlySalary: salary,
+		CreatedAt:     time.Now(),
+	})
+}
+
+func (data *tables) verification(verificationId int64) *usermanagement.ContactVerification {
+	for i := range data.verifications {
+		if data.verifications[i].VerificationId.Int64 == verificationId {
+			return &data.verifications[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) role(name string) *usermanagement.Role {
+	for i := range data.roles {
+		if data.roles[i].Name == name {
+			return &data.roles[i]
+		}
+	}
+	return nil
+}
+
+func sortedCopy(list []string) []string {
+	sorted := append([]string{}, list...)
+	sort.Strings(sorted)
+	return sorted
+}
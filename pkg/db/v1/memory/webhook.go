@@ -0,0 +1,264 @@
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/webhook"
+)
+
+func (obj *memoryDb) AddWebhookSubscription(ctx context.Context, subscription webhook.WebhookSubscription) (int64, error) {
+	var subscriptionId int64
+	err := obj.write(ctx, func(data *tables) error {
+		now := time.Now()
+		data.webhookSeq++
+		subscriptionId = data.webhookSeq
+		data.webhooks = append(data.webhooks, webhook.WebhookSubscription{
+			SubscriptionId: nullInt(subscriptionId),
+			Url:            nullString(subscription.Url.String),
+			EventTypes:     append([]string{}, subscription.EventTypes...),
+			Secret:         nullString(subscription.Secret.String),
+			Description:    subscription.Description,
+			Active:         nullBool(subscription.Active.Bool),
+			CreatedBy:      nullInt(subscription.CreatedBy.Int64),
+			CreatedAt:      nullTime(now),
+			UpdatedAt:      nullTime(now),
+		})
+		return nil
+	})
+	return subscriptionId, err
+}
+
+func (obj *memoryDb) GetWebhookSubscriptions(ctx context.Context) ([]webhook.WebhookSubscription, error) {
+	subscriptions := make([]webhook.WebhookSubscription, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		subscriptions = append(subscriptions, data.webhooks...)
+		return nil
+	})
+	return subscriptions, err
+}
+
+func (obj *memoryDb) GetWebhookSubscription(ctx context.Context, subscriptionId int64) (webhook.WebhookSubscription, error) {
+	var subscription webhook.WebhookSubscription
+	err := obj.read(ctx, func(data *tables) error {
+		row := data.webhook(subscriptionId)
+		if row == nil {
+			return sql.ErrNoRows
+		}
+		subscription = *row
+		return nil
+	})
+	return subscription, err
+}
+
+func (obj *memoryDb) UpdateWebhookSubscription(ctx context.Context, subscription webhook.WebhookSubscription) (int64, error) {
+	var subscriptionId int64
+	err := obj.write(ctx, func(data *tables) error {
+		row := data.webhook(subscription.SubscriptionId.Int64)
+		if row == nil {
+			return nil
+		}
+		row.Url = nullString(subscription.Url.String)
+		row.EventTypes = append([]string{}, subscription.EventTypes...)
+		row.Secret = nullString(subscription.Secret.String)
+		row.Description = subscription.Description
+		row.Active = nullBool(subscription.Active.Bool)
+		row.UpdatedAt = nullTime(time.Now())
+		subscriptionId = row.SubscriptionId.Int64
+		return nil
+	})
+	return subscriptionId, err
+}
+
+// DeleteWebhookSubscription removes the deliveries of the subscription too, like the cascading foreign key
+func (obj *memoryDb) DeleteWebhookSubscription(ctx context.Context, subscriptionId int64) (int64, error) {
+	var deletedId int64
+	err := obj.write(ctx, func(data *tables) error {
+		if data.webhook(subscriptionId) == nil {
+			return nil
+		}
+		webhooks := make([]webhook.WebhookSubscription, 0, len(data.webhooks))
+		for _, row := range data.webhooks {
+			if row.SubscriptionId.Int64 != subscriptionId {
+				webhooks = append(webhooks, row)
+			}
+		}
+		deliveries := make([]webhook.WebhookDelivery, 0, len(data.deliveries))
+		for _, row := range data.deliveries {
+			if row.SubscriptionId.Int64 != subscriptionId {
+				deliveries = append(deliveries, row)
+			}
+		}
+		data.webhooks = webhooks
+		data.deliveries = deliveries
+		deletedId = subscriptionId
+		return nil
+	})
+	return deletedId, err
+}
+
+func (obj *memoryDb) AddWebhookDeliveries(ctx context.Context, deliveries []webhook.WebhookDelivery) error {
+	return obj.write(ctx, func(data *tables) error {
+		now := time.Now().UTC()
+		for _, delivery := range deliveries {
+			if data.webhook(delivery.SubscriptionId.Int64) == nil {
+				return foreignKeyViolation("webhook_delivery", "fk_subscriptionid")
+			}
+			if data.hasDelivery(delivery.SubscriptionId.Int64, delivery.EventId.Int64) {
+				continue
+			}
+			data.deliverySeq++
+			data.deliveries = append(data.deliveries, webhook.WebhookDelivery{
+				DeliveryId:     nullInt(data.deliverySeq),
+				SubscriptionId: nullInt(delivery.SubscriptionId.Int64),
+				EventId:        nullInt(delivery.EventId.Int64),
+				EventType:      nullString(delivery.EventType.String),
+				Body:           nullString(delivery.Body.String),
+				Status:         nullString(webhook.PENDING),
+				Attempts:       nullInt(0),
+				NextAttemptAt:  nullTime(now),
+				CreatedAt:      nullTime(now),
+			})
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]webhook.WebhookDelivery, error) {
+	deliveries := make([]webhook.WebhookDelivery, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, delivery := range data.deliveries {
+			if len(deliveries) == limit {
+				break
+			}
+			if delivery.Status.String != webhook.PENDING || delivery.NextAttemptAt.Time.After(now) {
+				continue
+			}
+			if subscription := data.webhook(delivery.SubscriptionId.Int64); subscription == nil || !subscription.Active.Bool {
+				continue
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+func (obj *memoryDb) ClaimWebhookDelivery(ctx context.Context, deliveryId int64, attempts int64, leaseUntil time.Time) (bool, error) {
+	claimed := false
+	err := obj.write(ctx, func(data *tables) error {
+		delivery := data.delivery(deliveryId)
+		if delivery == nil || delivery.Status.String != webhook.PENDING || delivery.Attempts.Int64 != attempts {
+			return nil
+		}
+		delivery.Attempts = nullInt(attempts + 1)
+		delivery.NextAttemptAt = nullTime(leaseUntil)
+		claimed = true
+		return nil
+	})
+	return claimed, err
+}
+
+func (obj *memoryDb) MarkWebhookDeliveryDelivered(ctx context.Context, deliveryId int64, responseCode int64, deliveredAt time.Time) error {
+	return obj.write(ctx, func(data *tables) error {
+		if delivery := data.delivery(deliveryId); delivery != nil && delivery.Status.String == webhook.PENDING {
+			delivery.Status = nullString(webhook.DELIVERED)
+			delivery.ResponseCode = nullInt(responseCode)
+			delivery.LastError = sql.NullString{}
+			delivery.DeliveredAt = nullTime(deliveredAt)
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) RetryWebhookDelivery(ctx context.Context, deliveryId int64, nextAttemptAt time.Time, responseCode int64, lastError string) error {
+	return obj.write(ctx, func(data *tables) error {
+		if delivery := data.delivery(deliveryId); delivery != nil && delivery.Status.String == webhook.PENDING {
+			delivery.NextAttemptAt = nullTime(nextAttemptAt)
+			delivery.ResponseCode = nullInt(responseCode)
+			delivery.LastError = nullString(lastError)
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) DeadLetterWebhookDelivery(ctx context.Context, deliveryId int64, responseCode int64, lastError string) error {
+	return obj.write(ctx, func(data *tables) error {
+		if delivery := data.delivery(deliveryId); delivery != nil && delivery.Status.String == webhook.PENDING {
+			delivery.Status = nullString(webhook.DEAD)
+			delivery.ResponseCode = nullInt(responseCode)
+			delivery.LastError = nullString(lastError)
+		}
+		return nil
+	})
+}
+
+func (obj *memoryDb) GetWebhookDeliveries(ctx context.Context, filter webhook.DeliveryFilter) ([]webhook.WebhookDelivery, error) {
+	if filter.Status != "" {
+		if err := checkEnum("webhookdeliverystatus", filter.Status); err != nil {
+			return nil, err
+		}
+	}
+	deliveries := make([]webhook.WebhookDelivery, 0)
+	err := obj.read(ctx, func(data *tables) error {
+		for _, delivery := range data.deliveries {
+			if len(deliveries) == filter.Limit {
+				break
+			}
+			if delivery.DeliveryId.Int64 <= filter.AfterId ||
+				(filter.SubscriptionId != 0 && delivery.SubscriptionId.Int64 != filter.SubscriptionId) ||
+				(filter.EventId != 0 && delivery.EventId.Int64 != filter.EventId) ||
+				(filter.Status != "" && delivery.Status.String != filter.Status) {
+				continue
+			}
+			deliveries = append(deliveries, delivery)
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+func (obj *memoryDb) RedeliverWebhookDelivery(ctx context.Context, deliveryId int64, now time.Time) (int64, error) {
+	var redeliveredId int64
+	err := obj.write(ctx, func(data *tables) error {
+		delivery := data.delivery(deliveryId)
+		if delivery == nil || delivery.Status.String == webhook.PENDING {
+			return nil
+		}
+		delivery.Status = nullString(webhook.PENDING)
+		delivery.Attempts = nullInt(0)
+		delivery.NextAttemptAt = nullTime(now)
+		delivery.DeliveredAt = sql.NullTime{}
+		redeliveredId = deliveryId
+		return nil
+	})
+	return redeliveredId, err
+}
+
+func (data *tables) webhook(subscriptionId int64) *webhook.WebhookSubscription {
+	for i := range data.webhooks {
+		if data.webhooks[i].SubscriptionId.Int64 == subscriptionId {
+			return &data.webhooks[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) delivery(deliveryId int64) *webhook.WebhookDelivery {
+	for i := range data.deliveries {
+		if data.deliveries[i].DeliveryId.Int64 == deliveryId {
+			return &data.deliveries[i]
+		}
+	}
+	return nil
+}
+
+func (data *tables) hasDelivery(subscriptionId int64, eventId int64) bool {
+	for _, delivery := range data.deliveries {
+		if delivery.SubscriptionId.Int64 == subscriptionId && delivery.EventId.Int64 == eventId {
+			return true
+		}
+	}
+	return false
+}
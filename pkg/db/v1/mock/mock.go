@@ -5,11 +5,20 @@
 package mock
 
 import (
+	audit "aspire-assignment/pkg/db/v1/audit"
+	autodebit "aspire-assignment/pkg/db/v1/autodebit"
+	document "aspire-assignment/pkg/db/v1/document"
 	loan "aspire-assignment/pkg/db/v1/loan"
+	notification "aspire-assignment/pkg/db/v1/notification"
+	outbox "aspire-assignment/pkg/db/v1/outbox"
+	payment "aspire-assignment/pkg/db/v1/payment"
+	reconciliation "aspire-assignment/pkg/db/v1/reconciliation"
 	usermanagement "aspire-assignment/pkg/db/v1/usermanagement"
+	webhook "aspire-assignment/pkg/db/v1/webhook"
+	context "context"
 	reflect "reflect"
+	time "time"
 
-	gin "github.com/gin-gonic/gin"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -36,193 +45,1877 @@ func (m *MockV1DBLayer) EXPECT() *MockV1DBLayerMockRecorder {
 	return m.recorder
 }
 
+// AddAPIKey mocks base method.
+func (m *MockV1DBLayer) AddAPIKey(arg0 context.Context, arg1 usermanagement.APIKey) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAPIKey indicates an expected call of AddAPIKey.
+func (mr *MockV1DBLayerMockRecorder) AddAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAPIKey", reflect.TypeOf((*MockV1DBLayer)(nil).AddAPIKey), arg0, arg1)
+}
+
+// AddAdminInvite mocks base method.
+func (m *MockV1DBLayer) AddAdminInvite(arg0 context.Context, arg1 usermanagement.AdminInvite) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAdminInvite", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAdminInvite indicates an expected call of AddAdminInvite.
+func (mr *MockV1DBLayerMockRecorder) AddAdminInvite(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAdminInvite", reflect.TypeOf((*MockV1DBLayer)(nil).AddAdminInvite), arg0, arg1)
+}
+
+// AddAuditEntry mocks base method.
+func (m *MockV1DBLayer) AddAuditEntry(arg0 context.Context, arg1 audit.AuditEntry) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAuditEntry", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAuditEntry indicates an expected call of AddAuditEntry.
+func (mr *MockV1DBLayerMockRecorder) AddAuditEntry(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAuditEntry", reflect.TypeOf((*MockV1DBLayer)(nil).AddAuditEntry), arg0, arg1)
+}
+
+// AddAutoDebit mocks base method.
+func (m *MockV1DBLayer) AddAutoDebit(arg0 context.Context, arg1 autodebit.AutoDebit) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddAutoDebit", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddAutoDebit indicates an expected call of AddAutoDebit.
+func (mr *MockV1DBLayerMockRecorder) AddAutoDebit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddAutoDebit", reflect.TypeOf((*MockV1DBLayer)(nil).AddAutoDebit), arg0, arg1)
+}
+
+// AddBankStatement mocks base method.
+func (m *MockV1DBLayer) AddBankStatement(arg0 context.Context, arg1 reconciliation.BankStatement, arg2 []reconciliation.StatementLine) (int64, []reconciliation.StatementLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddBankStatement", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].([]reconciliation.StatementLine)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// AddBankStatement indicates an expected call of AddBankStatement.
+func (mr *MockV1DBLayerMockRecorder) AddBankStatement(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddBankStatement", reflect.TypeOf((*MockV1DBLayer)(nil).AddBankStatement), arg0, arg1, arg2)
+}
+
+// AddContactVerification mocks base method.
+func (m *MockV1DBLayer) AddContactVerification(arg0 context.Context, arg1 usermanagement.ContactVerification) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddContactVerification", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddContactVerification indicates an expected call of AddContactVerification.
+func (mr *MockV1DBLayerMockRecorder) AddContactVerification(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddContactVerification", reflect.TypeOf((*MockV1DBLayer)(nil).AddContactVerification), arg0, arg1)
+}
+
+// AddDebitMandate mocks base method.
+func (m *MockV1DBLayer) AddDebitMandate(arg0 context.Context, arg1 autodebit.DebitMandate) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDebitMandate", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddDebitMandate indicates an expected call of AddDebitMandate.
+func (mr *MockV1DBLayerMockRecorder) AddDebitMandate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDebitMandate", reflect.TypeOf((*MockV1DBLayer)(nil).AddDebitMandate), arg0, arg1)
+}
+
+// AddDocument mocks base method.
+func (m *MockV1DBLayer) AddDocument(arg0 context.Context, arg1 document.DocumentDetails) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddDocument", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddDocument indicates an expected call of AddDocument.
+func (mr *MockV1DBLayerMockRecorder) AddDocument(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddDocument", reflect.TypeOf((*MockV1DBLayer)(nil).AddDocument), arg0, arg1)
+}
+
+// AddFirstUserOfType mocks base method.
+func (m *MockV1DBLayer) AddFirstUserOfType(arg0 context.Context, arg1 usermanagement.UserDetails) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddFirstUserOfType", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddFirstUserOfType indicates an expected call of AddFirstUserOfType.
+func (mr *MockV1DBLayerMockRecorder) AddFirstUserOfType(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddFirstUserOfType", reflect.TypeOf((*MockV1DBLayer)(nil).AddFirstUserOfType), arg0, arg1)
+}
+
+// AddLoginChallenge mocks base method.
+func (m *MockV1DBLayer) AddLoginChallenge(arg0 context.Context, arg1 usermanagement.LoginChallenge) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginChallenge", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginChallenge indicates an expected call of AddLoginChallenge.
+func (mr *MockV1DBLayerMockRecorder) AddLoginChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginChallenge", reflect.TypeOf((*MockV1DBLayer)(nil).AddLoginChallenge), arg0, arg1)
+}
+
+// AddLoginFailure mocks base method.
+func (m *MockV1DBLayer) AddLoginFailure(arg0 context.Context, arg1 string, arg2, arg3 time.Time) (usermanagement.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddLoginFailure", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(usermanagement.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddLoginFailure indicates an expected call of AddLoginFailure.
+func (mr *MockV1DBLayerMockRecorder) AddLoginFailure(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddLoginFailure", reflect.TypeOf((*MockV1DBLayer)(nil).AddLoginFailure), arg0, arg1, arg2, arg3)
+}
+
+// AddNotifications mocks base method.
+func (m *MockV1DBLayer) AddNotifications(arg0 context.Context, arg1 []notification.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddNotifications", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddNotifications indicates an expected call of AddNotifications.
+func (mr *MockV1DBLayerMockRecorder) AddNotifications(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddNotifications", reflect.TypeOf((*MockV1DBLayer)(nil).AddNotifications), arg0, arg1)
+}
+
+// AddPasswordResetToken mocks base method.
+func (m *MockV1DBLayer) AddPasswordResetToken(arg0 context.Context, arg1 usermanagement.PasswordResetToken) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPasswordResetToken", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddPasswordResetToken indicates an expected call of AddPasswordResetToken.
+func (mr *MockV1DBLayerMockRecorder) AddPasswordResetToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPasswordResetToken", reflect.TypeOf((*MockV1DBLayer)(nil).AddPasswordResetToken), arg0, arg1)
+}
+
+// AddPaymentEvent mocks base method.
+func (m *MockV1DBLayer) AddPaymentEvent(arg0 context.Context, arg1 payment.PaymentEvent) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddPaymentEvent", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddPaymentEvent indicates an expected call of AddPaymentEvent.
+func (mr *MockV1DBLayerMockRecorder) AddPaymentEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddPaymentEvent", reflect.TypeOf((*MockV1DBLayer)(nil).AddPaymentEvent), arg0, arg1)
+}
+
+// AddRefreshToken mocks base method.
+func (m *MockV1DBLayer) AddRefreshToken(arg0 context.Context, arg1 usermanagement.RefreshToken) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddRefreshToken indicates an expected call of AddRefreshToken.
+func (mr *MockV1DBLayerMockRecorder) AddRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddRefreshToken", reflect.TypeOf((*MockV1DBLayer)(nil).AddRefreshToken), arg0, arg1)
+}
+
+// AddServiceAccount mocks base method.
+func (m *MockV1DBLayer) AddServiceAccount(arg0 context.Context, arg1 usermanagement.ServiceAccount) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddServiceAccount", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddServiceAccount indicates an expected call of AddServiceAccount.
+func (mr *MockV1DBLayerMockRecorder) AddServiceAccount(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddServiceAccount", reflect.TypeOf((*MockV1DBLayer)(nil).AddServiceAccount), arg0, arg1)
+}
+
+// AddSigningKey mocks base method.
+func (m *MockV1DBLayer) AddSigningKey(arg0 context.Context, arg1 usermanagement.SigningKey, arg2 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddSigningKey", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddSigningKey indicates an expected call of AddSigningKey.
+func (mr *MockV1DBLayerMockRecorder) AddSigningKey(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddSigningKey", reflect.TypeOf((*MockV1DBLayer)(nil).AddSigningKey), arg0, arg1, arg2)
+}
+
 // AddUser mocks base method.
-func (m *MockV1DBLayer) AddUser(arg0 *gin.Context, arg1 usermanagement.UserDetails) (int64, error) {
+func (m *MockV1DBLayer) AddUser(arg0 context.Context, arg1 usermanagement.UserDetails) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddUser", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddUser indicates an expected call of AddUser.
+func (mr *MockV1DBLayerMockRecorder) AddUser(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockV1DBLayer)(nil).AddUser), arg0, arg1)
+}
+
+// AddWebhookDeliveries mocks base method.
+func (m *MockV1DBLayer) AddWebhookDeliveries(arg0 context.Context, arg1 []webhook.WebhookDelivery) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddWebhookDeliveries indicates an expected call of AddWebhookDeliveries.
+func (mr *MockV1DBLayerMockRecorder) AddWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhookDeliveries", reflect.TypeOf((*MockV1DBLayer)(nil).AddWebhookDeliveries), arg0, arg1)
+}
+
+// AddWebhookSubscription mocks base method.
+func (m *MockV1DBLayer) AddWebhookSubscription(arg0 context.Context, arg1 webhook.WebhookSubscription) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddWebhookSubscription", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AddWebhookSubscription indicates an expected call of AddWebhookSubscription.
+func (mr *MockV1DBLayerMockRecorder) AddWebhookSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddWebhookSubscription", reflect.TypeOf((*MockV1DBLayer)(nil).AddWebhookSubscription), arg0, arg1)
+}
+
+// CancelDebitMandate mocks base method.
+func (m *MockV1DBLayer) CancelDebitMandate(arg0 context.Context, arg1 int64, arg2 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDebitMandate", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDebitMandate indicates an expected call of CancelDebitMandate.
+func (mr *MockV1DBLayerMockRecorder) CancelDebitMandate(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDebitMandate", reflect.TypeOf((*MockV1DBLayer)(nil).CancelDebitMandate), arg0, arg1, arg2)
+}
+
+// ClaimAutoDebit mocks base method.
+func (m *MockV1DBLayer) ClaimAutoDebit(arg0 context.Context, arg1, arg2 int64, arg3 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimAutoDebit", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimAutoDebit indicates an expected call of ClaimAutoDebit.
+func (mr *MockV1DBLayerMockRecorder) ClaimAutoDebit(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimAutoDebit", reflect.TypeOf((*MockV1DBLayer)(nil).ClaimAutoDebit), arg0, arg1, arg2, arg3)
+}
+
+// ClaimNotification mocks base method.
+func (m *MockV1DBLayer) ClaimNotification(arg0 context.Context, arg1, arg2 int64, arg3 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimNotification", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimNotification indicates an expected call of ClaimNotification.
+func (mr *MockV1DBLayerMockRecorder) ClaimNotification(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimNotification", reflect.TypeOf((*MockV1DBLayer)(nil).ClaimNotification), arg0, arg1, arg2, arg3)
+}
+
+// ClaimOutboxEvent mocks base method.
+func (m *MockV1DBLayer) ClaimOutboxEvent(arg0 context.Context, arg1, arg2 int64, arg3 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutboxEvent", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutboxEvent indicates an expected call of ClaimOutboxEvent.
+func (mr *MockV1DBLayerMockRecorder) ClaimOutboxEvent(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutboxEvent", reflect.TypeOf((*MockV1DBLayer)(nil).ClaimOutboxEvent), arg0, arg1, arg2, arg3)
+}
+
+// ClaimPaymentEvent mocks base method.
+func (m *MockV1DBLayer) ClaimPaymentEvent(arg0 context.Context, arg1 int64, arg2, arg3 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimPaymentEvent", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimPaymentEvent indicates an expected call of ClaimPaymentEvent.
+func (mr *MockV1DBLayerMockRecorder) ClaimPaymentEvent(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimPaymentEvent", reflect.TypeOf((*MockV1DBLayer)(nil).ClaimPaymentEvent), arg0, arg1, arg2, arg3)
+}
+
+// ClaimWebhookDelivery mocks base method.
+func (m *MockV1DBLayer) ClaimWebhookDelivery(arg0 context.Context, arg1, arg2 int64, arg3 time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimWebhookDelivery", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimWebhookDelivery indicates an expected call of ClaimWebhookDelivery.
+func (mr *MockV1DBLayerMockRecorder) ClaimWebhookDelivery(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimWebhookDelivery", reflect.TypeOf((*MockV1DBLayer)(nil).ClaimWebhookDelivery), arg0, arg1, arg2, arg3)
+}
+
+// ClearLoginFailures mocks base method.
+func (m *MockV1DBLayer) ClearLoginFailures(arg0 context.Context, arg1 []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClearLoginFailures", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClearLoginFailures indicates an expected call of ClearLoginFailures.
+func (mr *MockV1DBLayerMockRecorder) ClearLoginFailures(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClearLoginFailures", reflect.TypeOf((*MockV1DBLayer)(nil).ClearLoginFailures), arg0, arg1)
+}
+
+// CompleteAutoDebit mocks base method.
+func (m *MockV1DBLayer) CompleteAutoDebit(arg0 context.Context, arg1 autodebit.AutoDebit) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteAutoDebit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteAutoDebit indicates an expected call of CompleteAutoDebit.
+func (mr *MockV1DBLayerMockRecorder) CompleteAutoDebit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteAutoDebit", reflect.TypeOf((*MockV1DBLayer)(nil).CompleteAutoDebit), arg0, arg1)
+}
+
+// CompleteContactVerification mocks base method.
+func (m *MockV1DBLayer) CompleteContactVerification(arg0 context.Context, arg1 usermanagement.ContactVerification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteContactVerification", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteContactVerification indicates an expected call of CompleteContactVerification.
+func (mr *MockV1DBLayerMockRecorder) CompleteContactVerification(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteContactVerification", reflect.TypeOf((*MockV1DBLayer)(nil).CompleteContactVerification), arg0, arg1)
+}
+
+// CompleteLoginChallenge mocks base method.
+func (m *MockV1DBLayer) CompleteLoginChallenge(arg0 context.Context, arg1 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteLoginChallenge", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteLoginChallenge indicates an expected call of CompleteLoginChallenge.
+func (mr *MockV1DBLayerMockRecorder) CompleteLoginChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteLoginChallenge", reflect.TypeOf((*MockV1DBLayer)(nil).CompleteLoginChallenge), arg0, arg1)
+}
+
+// CompletePaymentEvent mocks base method.
+func (m *MockV1DBLayer) CompletePaymentEvent(arg0 context.Context, arg1 payment.PaymentEvent) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompletePaymentEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompletePaymentEvent indicates an expected call of CompletePaymentEvent.
+func (mr *MockV1DBLayerMockRecorder) CompletePaymentEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompletePaymentEvent", reflect.TypeOf((*MockV1DBLayer)(nil).CompletePaymentEvent), arg0, arg1)
+}
+
+// CompleteStatementLine mocks base method.
+func (m *MockV1DBLayer) CompleteStatementLine(arg0 context.Context, arg1 reconciliation.StatementLine) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteStatementLine", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteStatementLine indicates an expected call of CompleteStatementLine.
+func (mr *MockV1DBLayerMockRecorder) CompleteStatementLine(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteStatementLine", reflect.TypeOf((*MockV1DBLayer)(nil).CompleteStatementLine), arg0, arg1)
+}
+
+// CountUsersByType mocks base method.
+func (m *MockV1DBLayer) CountUsersByType(arg0 context.Context, arg1 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUsersByType", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUsersByType indicates an expected call of CountUsersByType.
+func (mr *MockV1DBLayerMockRecorder) CountUsersByType(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUsersByType", reflect.TypeOf((*MockV1DBLayer)(nil).CountUsersByType), arg0, arg1)
+}
+
+// CreateLoan mocks base method.
+func (m *MockV1DBLayer) CreateLoan(arg0 context.Context, arg1 int64, arg2 float64, arg3 int64, arg4 loan.StatusChange, arg5 []outbox.Event) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateLoan", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateLoan indicates an expected call of CreateLoan.
+func (mr *MockV1DBLayerMockRecorder) CreateLoan(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoan", reflect.TypeOf((*MockV1DBLayer)(nil).CreateLoan), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// DeadLetterWebhookDelivery mocks base method.
+func (m *MockV1DBLayer) DeadLetterWebhookDelivery(arg0 context.Context, arg1, arg2 int64, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadLetterWebhookDelivery", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeadLetterWebhookDelivery indicates an expected call of DeadLetterWebhookDelivery.
+func (mr *MockV1DBLayerMockRecorder) DeadLetterWebhookDelivery(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadLetterWebhookDelivery", reflect.TypeOf((*MockV1DBLayer)(nil).DeadLetterWebhookDelivery), arg0, arg1, arg2, arg3)
+}
+
+// DebitAccountBalance mocks base method.
+func (m *MockV1DBLayer) DebitAccountBalance(arg0 context.Context, arg1 int64, arg2 float64, arg3 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DebitAccountBalance", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DebitAccountBalance indicates an expected call of DebitAccountBalance.
+func (mr *MockV1DBLayerMockRecorder) DebitAccountBalance(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DebitAccountBalance", reflect.TypeOf((*MockV1DBLayer)(nil).DebitAccountBalance), arg0, arg1, arg2, arg3)
+}
+
+// DeletePaymentEvent mocks base method.
+func (m *MockV1DBLayer) DeletePaymentEvent(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePaymentEvent", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeletePaymentEvent indicates an expected call of DeletePaymentEvent.
+func (mr *MockV1DBLayerMockRecorder) DeletePaymentEvent(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePaymentEvent", reflect.TypeOf((*MockV1DBLayer)(nil).DeletePaymentEvent), arg0, arg1)
+}
+
+// DeleteWebhookSubscription mocks base method.
+func (m *MockV1DBLayer) DeleteWebhookSubscription(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhookSubscription", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteWebhookSubscription indicates an expected call of DeleteWebhookSubscription.
+func (mr *MockV1DBLayerMockRecorder) DeleteWebhookSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhookSubscription", reflect.TypeOf((*MockV1DBLayer)(nil).DeleteWebhookSubscription), arg0, arg1)
+}
+
+// EnableTotp mocks base method.
+func (m *MockV1DBLayer) EnableTotp(arg0 context.Context, arg1, arg2 int64, arg3 []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableTotp", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableTotp indicates an expected call of EnableTotp.
+func (mr *MockV1DBLayerMockRecorder) EnableTotp(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableTotp", reflect.TypeOf((*MockV1DBLayer)(nil).EnableTotp), arg0, arg1, arg2, arg3)
+}
+
+// FailNotification mocks base method.
+func (m *MockV1DBLayer) FailNotification(arg0 context.Context, arg1 int64, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailNotification", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailNotification indicates an expected call of FailNotification.
+func (mr *MockV1DBLayerMockRecorder) FailNotification(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailNotification", reflect.TypeOf((*MockV1DBLayer)(nil).FailNotification), arg0, arg1, arg2)
+}
+
+// FailOutboxEvent mocks base method.
+func (m *MockV1DBLayer) FailOutboxEvent(arg0 context.Context, arg1 int64, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailOutboxEvent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailOutboxEvent indicates an expected call of FailOutboxEvent.
+func (mr *MockV1DBLayerMockRecorder) FailOutboxEvent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailOutboxEvent", reflect.TypeOf((*MockV1DBLayer)(nil).FailOutboxEvent), arg0, arg1, arg2)
+}
+
+// FetchLoanDetails mocks base method.
+func (m *MockV1DBLayer) FetchLoanDetails(arg0 context.Context, arg1 int64) (loan.LoanDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchLoanDetails", arg0, arg1)
+	ret0, _ := ret[0].(loan.LoanDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchLoanDetails indicates an expected call of FetchLoanDetails.
+func (mr *MockV1DBLayerMockRecorder) FetchLoanDetails(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLoanDetails", reflect.TypeOf((*MockV1DBLayer)(nil).FetchLoanDetails), arg0, arg1)
+}
+
+// GetAPIKey mocks base method.
+func (m *MockV1DBLayer) GetAPIKey(arg0 context.Context, arg1 string) (usermanagement.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(usermanagement.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKey indicates an expected call of GetAPIKey.
+func (mr *MockV1DBLayerMockRecorder) GetAPIKey(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKey", reflect.TypeOf((*MockV1DBLayer)(nil).GetAPIKey), arg0, arg1)
+}
+
+// GetAPIKeys mocks base method.
+func (m *MockV1DBLayer) GetAPIKeys(arg0 context.Context) ([]usermanagement.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAPIKeys", arg0)
+	ret0, _ := ret[0].([]usermanagement.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAPIKeys indicates an expected call of GetAPIKeys.
+func (mr *MockV1DBLayerMockRecorder) GetAPIKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAPIKeys", reflect.TypeOf((*MockV1DBLayer)(nil).GetAPIKeys), arg0)
+}
+
+// GetAuditEntries mocks base method.
+func (m *MockV1DBLayer) GetAuditEntries(arg0 context.Context, arg1 audit.AuditFilter) ([]audit.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAuditEntries", arg0, arg1)
+	ret0, _ := ret[0].([]audit.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAuditEntries indicates an expected call of GetAuditEntries.
+func (mr *MockV1DBLayerMockRecorder) GetAuditEntries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAuditEntries", reflect.TypeOf((*MockV1DBLayer)(nil).GetAuditEntries), arg0, arg1)
+}
+
+// GetAutoDebits mocks base method.
+func (m *MockV1DBLayer) GetAutoDebits(arg0 context.Context, arg1 int64) ([]autodebit.AutoDebit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAutoDebits", arg0, arg1)
+	ret0, _ := ret[0].([]autodebit.AutoDebit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAutoDebits indicates an expected call of GetAutoDebits.
+func (mr *MockV1DBLayerMockRecorder) GetAutoDebits(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAutoDebits", reflect.TypeOf((*MockV1DBLayer)(nil).GetAutoDebits), arg0, arg1)
+}
+
+// GetAutoDebitsByStatus mocks base method.
+func (m *MockV1DBLayer) GetAutoDebitsByStatus(arg0 context.Context, arg1 string, arg2 int64, arg3 int) ([]autodebit.AutoDebit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAutoDebitsByStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]autodebit.AutoDebit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAutoDebitsByStatus indicates an expected call of GetAutoDebitsByStatus.
+func (mr *MockV1DBLayerMockRecorder) GetAutoDebitsByStatus(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAutoDebitsByStatus", reflect.TypeOf((*MockV1DBLayer)(nil).GetAutoDebitsByStatus), arg0, arg1, arg2, arg3)
+}
+
+// GetBankStatements mocks base method.
+func (m *MockV1DBLayer) GetBankStatements(arg0 context.Context) ([]reconciliation.BankStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBankStatements", arg0)
+	ret0, _ := ret[0].([]reconciliation.BankStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBankStatements indicates an expected call of GetBankStatements.
+func (mr *MockV1DBLayerMockRecorder) GetBankStatements(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBankStatements", reflect.TypeOf((*MockV1DBLayer)(nil).GetBankStatements), arg0)
+}
+
+// GetContactVerification mocks base method.
+func (m *MockV1DBLayer) GetContactVerification(arg0 context.Context, arg1, arg2 int64) (usermanagement.ContactVerification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetContactVerification", arg0, arg1, arg2)
+	ret0, _ := ret[0].(usermanagement.ContactVerification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetContactVerification indicates an expected call of GetContactVerification.
+func (mr *MockV1DBLayerMockRecorder) GetContactVerification(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetContactVerification", reflect.TypeOf((*MockV1DBLayer)(nil).GetContactVerification), arg0, arg1, arg2)
+}
+
+// GetDebitMandate mocks base method.
+func (m *MockV1DBLayer) GetDebitMandate(arg0 context.Context, arg1 int64) (autodebit.DebitMandate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDebitMandate", arg0, arg1)
+	ret0, _ := ret[0].(autodebit.DebitMandate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDebitMandate indicates an expected call of GetDebitMandate.
+func (mr *MockV1DBLayerMockRecorder) GetDebitMandate(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDebitMandate", reflect.TypeOf((*MockV1DBLayer)(nil).GetDebitMandate), arg0, arg1)
+}
+
+// GetDebitMandates mocks base method.
+func (m *MockV1DBLayer) GetDebitMandates(arg0 context.Context, arg1 int64) ([]autodebit.DebitMandate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDebitMandates", arg0, arg1)
+	ret0, _ := ret[0].([]autodebit.DebitMandate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDebitMandates indicates an expected call of GetDebitMandates.
+func (mr *MockV1DBLayerMockRecorder) GetDebitMandates(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDebitMandates", reflect.TypeOf((*MockV1DBLayer)(nil).GetDebitMandates), arg0, arg1)
+}
+
+// GetDocument mocks base method.
+func (m *MockV1DBLayer) GetDocument(arg0 context.Context, arg1 int64) (document.DocumentDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDocument", arg0, arg1)
+	ret0, _ := ret[0].(document.DocumentDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDocument indicates an expected call of GetDocument.
+func (mr *MockV1DBLayerMockRecorder) GetDocument(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDocument", reflect.TypeOf((*MockV1DBLayer)(nil).GetDocument), arg0, arg1)
+}
+
+// GetDueAutoDebits mocks base method.
+func (m *MockV1DBLayer) GetDueAutoDebits(arg0 context.Context, arg1 time.Time, arg2 int) ([]autodebit.AutoDebit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueAutoDebits", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]autodebit.AutoDebit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueAutoDebits indicates an expected call of GetDueAutoDebits.
+func (mr *MockV1DBLayerMockRecorder) GetDueAutoDebits(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueAutoDebits", reflect.TypeOf((*MockV1DBLayer)(nil).GetDueAutoDebits), arg0, arg1, arg2)
+}
+
+// GetDueInstallments mocks base method.
+func (m *MockV1DBLayer) GetDueInstallments(arg0 context.Context, arg1 time.Time, arg2 int) ([]autodebit.AutoDebit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueInstallments", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]autodebit.AutoDebit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueInstallments indicates an expected call of GetDueInstallments.
+func (mr *MockV1DBLayerMockRecorder) GetDueInstallments(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueInstallments", reflect.TypeOf((*MockV1DBLayer)(nil).GetDueInstallments), arg0, arg1, arg2)
+}
+
+// GetDueNotifications mocks base method.
+func (m *MockV1DBLayer) GetDueNotifications(arg0 context.Context, arg1 time.Time, arg2 int) ([]notification.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueNotifications", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]notification.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueNotifications indicates an expected call of GetDueNotifications.
+func (mr *MockV1DBLayerMockRecorder) GetDueNotifications(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueNotifications", reflect.TypeOf((*MockV1DBLayer)(nil).GetDueNotifications), arg0, arg1, arg2)
+}
+
+// GetDueOutboxEvents mocks base method.
+func (m *MockV1DBLayer) GetDueOutboxEvents(arg0 context.Context, arg1 time.Time, arg2 int) ([]outbox.OutboxEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueOutboxEvents", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]outbox.OutboxEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueOutboxEvents indicates an expected call of GetDueOutboxEvents.
+func (mr *MockV1DBLayerMockRecorder) GetDueOutboxEvents(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueOutboxEvents", reflect.TypeOf((*MockV1DBLayer)(nil).GetDueOutboxEvents), arg0, arg1, arg2)
+}
+
+// GetDueWebhookDeliveries mocks base method.
+func (m *MockV1DBLayer) GetDueWebhookDeliveries(arg0 context.Context, arg1 time.Time, arg2 int) ([]webhook.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDueWebhookDeliveries", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]webhook.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDueWebhookDeliveries indicates an expected call of GetDueWebhookDeliveries.
+func (mr *MockV1DBLayerMockRecorder) GetDueWebhookDeliveries(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDueWebhookDeliveries", reflect.TypeOf((*MockV1DBLayer)(nil).GetDueWebhookDeliveries), arg0, arg1, arg2)
+}
+
+// GetInstallmentsDueBetween mocks base method.
+func (m *MockV1DBLayer) GetInstallmentsDueBetween(arg0 context.Context, arg1, arg2 time.Time) ([]notification.InstallmentReminder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInstallmentsDueBetween", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]notification.InstallmentReminder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInstallmentsDueBetween indicates an expected call of GetInstallmentsDueBetween.
+func (mr *MockV1DBLayerMockRecorder) GetInstallmentsDueBetween(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstallmentsDueBetween", reflect.TypeOf((*MockV1DBLayer)(nil).GetInstallmentsDueBetween), arg0, arg1, arg2)
+}
+
+// GetLastAuditEntry mocks base method.
+func (m *MockV1DBLayer) GetLastAuditEntry(arg0 context.Context) (audit.AuditEntry, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLastAuditEntry", arg0)
+	ret0, _ := ret[0].(audit.AuditEntry)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLastAuditEntry indicates an expected call of GetLastAuditEntry.
+func (mr *MockV1DBLayerMockRecorder) GetLastAuditEntry(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLastAuditEntry", reflect.TypeOf((*MockV1DBLayer)(nil).GetLastAuditEntry), arg0)
+}
+
+// GetLatestVerifiedIncome mocks base method.
+func (m *MockV1DBLayer) GetLatestVerifiedIncome(arg0 context.Context, arg1 int64) (float64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLatestVerifiedIncome", arg0, arg1)
+	ret0, _ := ret[0].(float64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLatestVerifiedIncome indicates an expected call of GetLatestVerifiedIncome.
+func (mr *MockV1DBLayerMockRecorder) GetLatestVerifiedIncome(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLatestVerifiedIncome", reflect.TypeOf((*MockV1DBLayer)(nil).GetLatestVerifiedIncome), arg0, arg1)
+}
+
+// GetLoanStatusHistory mocks base method.
+func (m *MockV1DBLayer) GetLoanStatusHistory(arg0 context.Context, arg1 int64) ([]loan.StatusHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoanStatusHistory", arg0, arg1)
+	ret0, _ := ret[0].([]loan.StatusHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoanStatusHistory indicates an expected call of GetLoanStatusHistory.
+func (mr *MockV1DBLayerMockRecorder) GetLoanStatusHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoanStatusHistory", reflect.TypeOf((*MockV1DBLayer)(nil).GetLoanStatusHistory), arg0, arg1)
+}
+
+// GetLoansByNextInstallment mocks base method.
+func (m *MockV1DBLayer) GetLoansByNextInstallment(arg0 context.Context, arg1 float64) ([]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoansByNextInstallment", arg0, arg1)
+	ret0, _ := ret[0].([]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoansByNextInstallment indicates an expected call of GetLoansByNextInstallment.
+func (mr *MockV1DBLayerMockRecorder) GetLoansByNextInstallment(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoansByNextInstallment", reflect.TypeOf((*MockV1DBLayer)(nil).GetLoansByNextInstallment), arg0, arg1)
+}
+
+// GetLoginChallenge mocks base method.
+func (m *MockV1DBLayer) GetLoginChallenge(arg0 context.Context, arg1 string) (usermanagement.LoginChallenge, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginChallenge", arg0, arg1)
+	ret0, _ := ret[0].(usermanagement.LoginChallenge)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginChallenge indicates an expected call of GetLoginChallenge.
+func (mr *MockV1DBLayerMockRecorder) GetLoginChallenge(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginChallenge", reflect.TypeOf((*MockV1DBLayer)(nil).GetLoginChallenge), arg0, arg1)
+}
+
+// GetLoginThrottles mocks base method.
+func (m *MockV1DBLayer) GetLoginThrottles(arg0 context.Context, arg1 []string) ([]usermanagement.LoginThrottle, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLoginThrottles", arg0, arg1)
+	ret0, _ := ret[0].([]usermanagement.LoginThrottle)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLoginThrottles indicates an expected call of GetLoginThrottles.
+func (mr *MockV1DBLayerMockRecorder) GetLoginThrottles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLoginThrottles", reflect.TypeOf((*MockV1DBLayer)(nil).GetLoginThrottles), arg0, arg1)
+}
+
+// GetNotificationPreferences mocks base method.
+func (m *MockV1DBLayer) GetNotificationPreferences(arg0 context.Context, arg1 int64) ([]notification.NotificationPreference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotificationPreferences", arg0, arg1)
+	ret0, _ := ret[0].([]notification.NotificationPreference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotificationPreferences indicates an expected call of GetNotificationPreferences.
+func (mr *MockV1DBLayerMockRecorder) GetNotificationPreferences(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotificationPreferences", reflect.TypeOf((*MockV1DBLayer)(nil).GetNotificationPreferences), arg0, arg1)
+}
+
+// GetNotifications mocks base method.
+func (m *MockV1DBLayer) GetNotifications(arg0 context.Context, arg1 notification.NotificationFilter) ([]notification.Notification, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNotifications", arg0, arg1)
+	ret0, _ := ret[0].([]notification.Notification)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNotifications indicates an expected call of GetNotifications.
+func (mr *MockV1DBLayerMockRecorder) GetNotifications(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNotifications", reflect.TypeOf((*MockV1DBLayer)(nil).GetNotifications), arg0, arg1)
+}
+
+// GetPaymentEvent mocks base method.
+func (m *MockV1DBLayer) GetPaymentEvent(arg0 context.Context, arg1, arg2 string) (payment.PaymentEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentEvent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(payment.PaymentEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentEvent indicates an expected call of GetPaymentEvent.
+func (mr *MockV1DBLayerMockRecorder) GetPaymentEvent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentEvent", reflect.TypeOf((*MockV1DBLayer)(nil).GetPaymentEvent), arg0, arg1, arg2)
+}
+
+// GetPaymentEvents mocks base method.
+func (m *MockV1DBLayer) GetPaymentEvents(arg0 context.Context, arg1 payment.PaymentEventFilter) ([]payment.PaymentEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPaymentEvents", arg0, arg1)
+	ret0, _ := ret[0].([]payment.PaymentEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPaymentEvents indicates an expected call of GetPaymentEvents.
+func (mr *MockV1DBLayerMockRecorder) GetPaymentEvents(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPaymentEvents", reflect.TypeOf((*MockV1DBLayer)(nil).GetPaymentEvents), arg0, arg1)
+}
+
+// GetPendingDocuments mocks base method.
+func (m *MockV1DBLayer) GetPendingDocuments(arg0 context.Context) ([]document.DocumentDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingDocuments", arg0)
+	ret0, _ := ret[0].([]document.DocumentDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingDocuments indicates an expected call of GetPendingDocuments.
+func (mr *MockV1DBLayerMockRecorder) GetPendingDocuments(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingDocuments", reflect.TypeOf((*MockV1DBLayer)(nil).GetPendingDocuments), arg0)
+}
+
+// GetProfileHistory mocks base method.
+func (m *MockV1DBLayer) GetProfileHistory(arg0 context.Context, arg1 int64) ([]usermanagement.ProfileChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProfileHistory", arg0, arg1)
+	ret0, _ := ret[0].([]usermanagement.ProfileChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProfileHistory indicates an expected call of GetProfileHistory.
+func (mr *MockV1DBLayerMockRecorder) GetProfileHistory(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProfileHistory", reflect.TypeOf((*MockV1DBLayer)(nil).GetProfileHistory), arg0, arg1)
+}
+
+// GetRefreshToken mocks base method.
+func (m *MockV1DBLayer) GetRefreshToken(arg0 context.Context, arg1 string) (usermanagement.RefreshToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshToken", arg0, arg1)
+	ret0, _ := ret[0].(usermanagement.RefreshToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshToken indicates an expected call of GetRefreshToken.
+func (mr *MockV1DBLayerMockRecorder) GetRefreshToken(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshToken", reflect.TypeOf((*MockV1DBLayer)(nil).GetRefreshToken), arg0, arg1)
+}
+
+// GetRolePermissions mocks base method.
+func (m *MockV1DBLayer) GetRolePermissions(arg0 context.Context, arg1 []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRolePermissions", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRolePermissions indicates an expected call of GetRolePermissions.
+func (mr *MockV1DBLayerMockRecorder) GetRolePermissions(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRolePermissions", reflect.TypeOf((*MockV1DBLayer)(nil).GetRolePermissions), arg0, arg1)
+}
+
+// GetRoles mocks base method.
+func (m *MockV1DBLayer) GetRoles(arg0 context.Context) ([]usermanagement.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", arg0)
+	ret0, _ := ret[0].([]usermanagement.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockV1DBLayerMockRecorder) GetRoles(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockV1DBLayer)(nil).GetRoles), arg0)
+}
+
+// GetServiceAccounts mocks base method.
+func (m *MockV1DBLayer) GetServiceAccounts(arg0 context.Context) ([]usermanagement.ServiceAccount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetServiceAccounts", arg0)
+	ret0, _ := ret[0].([]usermanagement.ServiceAccount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetServiceAccounts indicates an expected call of GetServiceAccounts.
+func (mr *MockV1DBLayerMockRecorder) GetServiceAccounts(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetServiceAccounts", reflect.TypeOf((*MockV1DBLayer)(nil).GetServiceAccounts), arg0)
+}
+
+// GetSigningKeys mocks base method.
+func (m *MockV1DBLayer) GetSigningKeys(arg0 context.Context) ([]usermanagement.SigningKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSigningKeys", arg0)
+	ret0, _ := ret[0].([]usermanagement.SigningKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSigningKeys indicates an expected call of GetSigningKeys.
+func (mr *MockV1DBLayerMockRecorder) GetSigningKeys(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSigningKeys", reflect.TypeOf((*MockV1DBLayer)(nil).GetSigningKeys), arg0)
+}
+
+// GetStatementLine mocks base method.
+func (m *MockV1DBLayer) GetStatementLine(arg0 context.Context, arg1 int64) (reconciliation.StatementLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatementLine", arg0, arg1)
+	ret0, _ := ret[0].(reconciliation.StatementLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatementLine indicates an expected call of GetStatementLine.
+func (mr *MockV1DBLayerMockRecorder) GetStatementLine(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatementLine", reflect.TypeOf((*MockV1DBLayer)(nil).GetStatementLine), arg0, arg1)
+}
+
+// GetStatementLines mocks base method.
+func (m *MockV1DBLayer) GetStatementLines(arg0 context.Context, arg1 reconciliation.StatementLineFilter) ([]reconciliation.StatementLine, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetStatementLines", arg0, arg1)
+	ret0, _ := ret[0].([]reconciliation.StatementLine)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetStatementLines indicates an expected call of GetStatementLines.
+func (mr *MockV1DBLayerMockRecorder) GetStatementLines(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatementLines", reflect.TypeOf((*MockV1DBLayer)(nil).GetStatementLines), arg0, arg1)
+}
+
+// GetTokenVersion mocks base method.
+func (m *MockV1DBLayer) GetTokenVersion(arg0 context.Context, arg1 int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenVersion", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenVersion indicates an expected call of GetTokenVersion.
+func (mr *MockV1DBLayerMockRecorder) GetTokenVersion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenVersion", reflect.TypeOf((*MockV1DBLayer)(nil).GetTokenVersion), arg0, arg1)
+}
+
+// GetTotp mocks base method.
+func (m *MockV1DBLayer) GetTotp(arg0 context.Context, arg1 int64) (usermanagement.UserTotp, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTotp", arg0, arg1)
+	ret0, _ := ret[0].(usermanagement.UserTotp)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTotp indicates an expected call of GetTotp.
+func (mr *MockV1DBLayerMockRecorder) GetTotp(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTotp", reflect.TypeOf((*MockV1DBLayer)(nil).GetTotp), arg0, arg1)
+}
+
+// GetUnapprovedLoans mocks base method.
+func (m *MockV1DBLayer) GetUnapprovedLoans(arg0 context.Context) ([]loan.UnApprovedLoan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUnapprovedLoans", arg0)
+	ret0, _ := ret[0].([]loan.UnApprovedLoan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUnapprovedLoans indicates an expected call of GetUnapprovedLoans.
+func (mr *MockV1DBLayerMockRecorder) GetUnapprovedLoans(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnapprovedLoans", reflect.TypeOf((*MockV1DBLayer)(nil).GetUnapprovedLoans), arg0)
+}
+
+// GetUserById mocks base method.
+func (m *MockV1DBLayer) GetUserById(arg0 context.Context, arg1 int64) (usermanagement.UserDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserById", arg0, arg1)
+	ret0, _ := ret[0].(usermanagement.UserDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserById indicates an expected call of GetUserById.
+func (mr *MockV1DBLayerMockRecorder) GetUserById(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserById), arg0, arg1)
+}
+
+// GetUserByUsername mocks base method.
+func (m *MockV1DBLayer) GetUserByUsername(arg0 context.Context, arg1 string) (usermanagement.UserDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
+	ret0, _ := ret[0].(usermanagement.UserDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByUsername indicates an expected call of GetUserByUsername.
+func (mr *MockV1DBLayerMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserByUsername), arg0, arg1)
+}
+
+// GetUserDocuments mocks base method.
+func (m *MockV1DBLayer) GetUserDocuments(arg0 context.Context, arg1 int64) ([]document.DocumentDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserDocuments", arg0, arg1)
+	ret0, _ := ret[0].([]document.DocumentDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserDocuments indicates an expected call of GetUserDocuments.
+func (mr *MockV1DBLayerMockRecorder) GetUserDocuments(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserDocuments", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserDocuments), arg0, arg1)
+}
+
+// GetUserLoanInstallments mocks base method.
+func (m *MockV1DBLayer) GetUserLoanInstallments(arg0 context.Context, arg1, arg2 int64) ([]loan.InstallmentDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLoanInstallments", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]loan.InstallmentDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLoanInstallments indicates an expected call of GetUserLoanInstallments.
+func (mr *MockV1DBLayerMockRecorder) GetUserLoanInstallments(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLoanInstallments", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserLoanInstallments), arg0, arg1, arg2)
+}
+
+// GetUserLoans mocks base method.
+func (m *MockV1DBLayer) GetUserLoans(arg0 context.Context, arg1 int64) ([]loan.LoanDetails, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLoans", arg0, arg1)
+	ret0, _ := ret[0].([]loan.LoanDetails)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLoans indicates an expected call of GetUserLoans.
+func (mr *MockV1DBLayerMockRecorder) GetUserLoans(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLoans", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserLoans), arg0, arg1)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockV1DBLayer) GetUserRoles(arg0 context.Context, arg1 int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockV1DBLayerMockRecorder) GetUserRoles(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserRoles), arg0, arg1)
+}
+
+// GetVerifiedDocumentTypes mocks base method.
+func (m *MockV1DBLayer) GetVerifiedDocumentTypes(arg0 context.Context, arg1 int64) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVerifiedDocumentTypes", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVerifiedDocumentTypes indicates an expected call of GetVerifiedDocumentTypes.
+func (mr *MockV1DBLayerMockRecorder) GetVerifiedDocumentTypes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVerifiedDocumentTypes", reflect.TypeOf((*MockV1DBLayer)(nil).GetVerifiedDocumentTypes), arg0, arg1)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockV1DBLayer) GetWebhookDeliveries(arg0 context.Context, arg1 webhook.DeliveryFilter) ([]webhook.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", arg0, arg1)
+	ret0, _ := ret[0].([]webhook.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockV1DBLayerMockRecorder) GetWebhookDeliveries(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockV1DBLayer)(nil).GetWebhookDeliveries), arg0, arg1)
+}
+
+// GetWebhookSubscription mocks base method.
+func (m *MockV1DBLayer) GetWebhookSubscription(arg0 context.Context, arg1 int64) (webhook.WebhookSubscription, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUser", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetWebhookSubscription", arg0, arg1)
+	ret0, _ := ret[0].(webhook.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscription indicates an expected call of GetWebhookSubscription.
+func (mr *MockV1DBLayerMockRecorder) GetWebhookSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscription", reflect.TypeOf((*MockV1DBLayer)(nil).GetWebhookSubscription), arg0, arg1)
+}
+
+// GetWebhookSubscriptions mocks base method.
+func (m *MockV1DBLayer) GetWebhookSubscriptions(arg0 context.Context) ([]webhook.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookSubscriptions", arg0)
+	ret0, _ := ret[0].([]webhook.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhookSubscriptions indicates an expected call of GetWebhookSubscriptions.
+func (mr *MockV1DBLayerMockRecorder) GetWebhookSubscriptions(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookSubscriptions", reflect.TypeOf((*MockV1DBLayer)(nil).GetWebhookSubscriptions), arg0)
+}
+
+// IncrementContactVerificationAttempts mocks base method.
+func (m *MockV1DBLayer) IncrementContactVerificationAttempts(arg0 context.Context, arg1, arg2 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementContactVerificationAttempts", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementContactVerificationAttempts indicates an expected call of IncrementContactVerificationAttempts.
+func (mr *MockV1DBLayerMockRecorder) IncrementContactVerificationAttempts(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementContactVerificationAttempts", reflect.TypeOf((*MockV1DBLayer)(nil).IncrementContactVerificationAttempts), arg0, arg1, arg2)
+}
+
+// IncrementLoginChallengeAttempts mocks base method.
+func (m *MockV1DBLayer) IncrementLoginChallengeAttempts(arg0 context.Context, arg1 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementLoginChallengeAttempts", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrementLoginChallengeAttempts indicates an expected call of IncrementLoginChallengeAttempts.
+func (mr *MockV1DBLayerMockRecorder) IncrementLoginChallengeAttempts(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementLoginChallengeAttempts", reflect.TypeOf((*MockV1DBLayer)(nil).IncrementLoginChallengeAttempts), arg0, arg1)
+}
+
+// IsAccessTokenRevoked mocks base method.
+func (m *MockV1DBLayer) IsAccessTokenRevoked(arg0 context.Context, arg1 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAccessTokenRevoked", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsAccessTokenRevoked indicates an expected call of IsAccessTokenRevoked.
+func (mr *MockV1DBLayerMockRecorder) IsAccessTokenRevoked(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAccessTokenRevoked", reflect.TypeOf((*MockV1DBLayer)(nil).IsAccessTokenRevoked), arg0, arg1)
+}
+
+// LockLogin mocks base method.
+func (m *MockV1DBLayer) LockLogin(arg0 context.Context, arg1 string, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LockLogin", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// LockLogin indicates an expected call of LockLogin.
+func (mr *MockV1DBLayerMockRecorder) LockLogin(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LockLogin", reflect.TypeOf((*MockV1DBLayer)(nil).LockLogin), arg0, arg1, arg2)
+}
+
+// MarkNotificationSent mocks base method.
+func (m *MockV1DBLayer) MarkNotificationSent(arg0 context.Context, arg1 int64, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationSent", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkNotificationSent indicates an expected call of MarkNotificationSent.
+func (mr *MockV1DBLayerMockRecorder) MarkNotificationSent(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationSent", reflect.TypeOf((*MockV1DBLayer)(nil).MarkNotificationSent), arg0, arg1, arg2)
+}
+
+// MarkNotificationsRead mocks base method.
+func (m *MockV1DBLayer) MarkNotificationsRead(arg0 context.Context, arg1 int64, arg2 []int64, arg3 time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkNotificationsRead", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AddUser indicates an expected call of AddUser.
-func (mr *MockV1DBLayerMockRecorder) AddUser(arg0, arg1 interface{}) *gomock.Call {
+// MarkNotificationsRead indicates an expected call of MarkNotificationsRead.
+func (mr *MockV1DBLayerMockRecorder) MarkNotificationsRead(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockV1DBLayer)(nil).AddUser), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkNotificationsRead", reflect.TypeOf((*MockV1DBLayer)(nil).MarkNotificationsRead), arg0, arg1, arg2, arg3)
+}
+
+// MarkOutboxEventDelivered mocks base method.
+func (m *MockV1DBLayer) MarkOutboxEventDelivered(arg0 context.Context, arg1 int64, arg2 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxEventDelivered", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxEventDelivered indicates an expected call of MarkOutboxEventDelivered.
+func (mr *MockV1DBLayerMockRecorder) MarkOutboxEventDelivered(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxEventDelivered", reflect.TypeOf((*MockV1DBLayer)(nil).MarkOutboxEventDelivered), arg0, arg1, arg2)
+}
+
+// MarkWebhookDeliveryDelivered mocks base method.
+func (m *MockV1DBLayer) MarkWebhookDeliveryDelivered(arg0 context.Context, arg1, arg2 int64, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkWebhookDeliveryDelivered", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkWebhookDeliveryDelivered indicates an expected call of MarkWebhookDeliveryDelivered.
+func (mr *MockV1DBLayerMockRecorder) MarkWebhookDeliveryDelivered(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkWebhookDeliveryDelivered", reflect.TypeOf((*MockV1DBLayer)(nil).MarkWebhookDeliveryDelivered), arg0, arg1, arg2, arg3)
 }
 
-// CancelLoan mocks base method.
-func (m *MockV1DBLayer) CancelLoan(arg0 *gin.Context, arg1, arg2 int64) (int64, error) {
+// ModifyLoan mocks base method.
+func (m *MockV1DBLayer) ModifyLoan(arg0 context.Context, arg1, arg2 int64, arg3 float64, arg4 int64, arg5 []outbox.Event) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CancelLoan", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "ModifyLoan", arg0, arg1, arg2, arg3, arg4, arg5)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CancelLoan indicates an expected call of CancelLoan.
-func (mr *MockV1DBLayerMockRecorder) CancelLoan(arg0, arg1, arg2 interface{}) *gomock.Call {
+// ModifyLoan indicates an expected call of ModifyLoan.
+func (mr *MockV1DBLayerMockRecorder) ModifyLoan(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelLoan", reflect.TypeOf((*MockV1DBLayer)(nil).CancelLoan), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModifyLoan", reflect.TypeOf((*MockV1DBLayer)(nil).ModifyLoan), arg0, arg1, arg2, arg3, arg4, arg5)
 }
 
-// CreateLoan mocks base method.
-func (m *MockV1DBLayer) CreateLoan(arg0 *gin.Context, arg1 int64, arg2 float64, arg3 int64) (int64, error) {
+// RedeemAdminInvite mocks base method.
+func (m *MockV1DBLayer) RedeemAdminInvite(arg0 context.Context, arg1 string, arg2 usermanagement.UserDetails) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateLoan", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "RedeemAdminInvite", arg0, arg1, arg2)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// CreateLoan indicates an expected call of CreateLoan.
-func (mr *MockV1DBLayerMockRecorder) CreateLoan(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+// RedeemAdminInvite indicates an expected call of RedeemAdminInvite.
+func (mr *MockV1DBLayerMockRecorder) RedeemAdminInvite(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateLoan", reflect.TypeOf((*MockV1DBLayer)(nil).CreateLoan), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeemAdminInvite", reflect.TypeOf((*MockV1DBLayer)(nil).RedeemAdminInvite), arg0, arg1, arg2)
 }
 
-// FetchLoanDetails mocks base method.
-func (m *MockV1DBLayer) FetchLoanDetails(arg0 *gin.Context, arg1 int64) (loan.LoanDetails, error) {
+// RedeliverWebhookDelivery mocks base method.
+func (m *MockV1DBLayer) RedeliverWebhookDelivery(arg0 context.Context, arg1 int64, arg2 time.Time) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FetchLoanDetails", arg0, arg1)
-	ret0, _ := ret[0].(loan.LoanDetails)
+	ret := m.ctrl.Call(m, "RedeliverWebhookDelivery", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// FetchLoanDetails indicates an expected call of FetchLoanDetails.
-func (mr *MockV1DBLayerMockRecorder) FetchLoanDetails(arg0, arg1 interface{}) *gomock.Call {
+// RedeliverWebhookDelivery indicates an expected call of RedeliverWebhookDelivery.
+func (mr *MockV1DBLayerMockRecorder) RedeliverWebhookDelivery(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLoanDetails", reflect.TypeOf((*MockV1DBLayer)(nil).FetchLoanDetails), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RedeliverWebhookDelivery", reflect.TypeOf((*MockV1DBLayer)(nil).RedeliverWebhookDelivery), arg0, arg1, arg2)
 }
 
-// GetUnapprovedLoans mocks base method.
-func (m *MockV1DBLayer) GetUnapprovedLoans(arg0 *gin.Context) ([]loan.UnApprovedLoan, error) {
+// RefundAccountDebit mocks base method.
+func (m *MockV1DBLayer) RefundAccountDebit(arg0 context.Context, arg1 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUnapprovedLoans", arg0)
-	ret0, _ := ret[0].([]loan.UnApprovedLoan)
+	ret := m.ctrl.Call(m, "RefundAccountDebit", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RefundAccountDebit indicates an expected call of RefundAccountDebit.
+func (mr *MockV1DBLayerMockRecorder) RefundAccountDebit(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefundAccountDebit", reflect.TypeOf((*MockV1DBLayer)(nil).RefundAccountDebit), arg0, arg1)
+}
+
+// ReopenStatementLine mocks base method.
+func (m *MockV1DBLayer) ReopenStatementLine(arg0 context.Context, arg1 int64, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReopenStatementLine", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReopenStatementLine indicates an expected call of ReopenStatementLine.
+func (mr *MockV1DBLayerMockRecorder) ReopenStatementLine(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReopenStatementLine", reflect.TypeOf((*MockV1DBLayer)(nil).ReopenStatementLine), arg0, arg1, arg2)
+}
+
+// ResetPassword mocks base method.
+func (m *MockV1DBLayer) ResetPassword(arg0 context.Context, arg1, arg2 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUnapprovedLoans indicates an expected call of GetUnapprovedLoans.
-func (mr *MockV1DBLayerMockRecorder) GetUnapprovedLoans(arg0 interface{}) *gomock.Call {
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockV1DBLayerMockRecorder) ResetPassword(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUnapprovedLoans", reflect.TypeOf((*MockV1DBLayer)(nil).GetUnapprovedLoans), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockV1DBLayer)(nil).ResetPassword), arg0, arg1, arg2)
 }
 
-// GetUserByUsername mocks base method.
-func (m *MockV1DBLayer) GetUserByUsername(arg0 *gin.Context, arg1 string) (usermanagement.UserDetails, error) {
+// ResolveStatementLine mocks base method.
+func (m *MockV1DBLayer) ResolveStatementLine(arg0 context.Context, arg1 reconciliation.StatementLine) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByUsername", arg0, arg1)
-	ret0, _ := ret[0].(usermanagement.UserDetails)
+	ret := m.ctrl.Call(m, "ResolveStatementLine", arg0, arg1)
+	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserByUsername indicates an expected call of GetUserByUsername.
-func (mr *MockV1DBLayerMockRecorder) GetUserByUsername(arg0, arg1 interface{}) *gomock.Call {
+// ResolveStatementLine indicates an expected call of ResolveStatementLine.
+func (mr *MockV1DBLayerMockRecorder) ResolveStatementLine(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByUsername", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserByUsername), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveStatementLine", reflect.TypeOf((*MockV1DBLayer)(nil).ResolveStatementLine), arg0, arg1)
 }
 
-// GetUserLoanInstallments mocks base method.
-func (m *MockV1DBLayer) GetUserLoanInstallments(arg0 *gin.Context, arg1, arg2 int64) ([]loan.InstallmentDetails, error) {
+// RetryAutoDebit mocks base method.
+func (m *MockV1DBLayer) RetryAutoDebit(arg0 context.Context, arg1 int64, arg2 time.Time, arg3 string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserLoanInstallments", arg0, arg1, arg2)
-	ret0, _ := ret[0].([]loan.InstallmentDetails)
+	ret := m.ctrl.Call(m, "RetryAutoDebit", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryAutoDebit indicates an expected call of RetryAutoDebit.
+func (mr *MockV1DBLayerMockRecorder) RetryAutoDebit(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryAutoDebit", reflect.TypeOf((*MockV1DBLayer)(nil).RetryAutoDebit), arg0, arg1, arg2, arg3)
+}
+
+// RetryNotification mocks base method.
+func (m *MockV1DBLayer) RetryNotification(arg0 context.Context, arg1 int64, arg2 time.Time, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryNotification", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryNotification indicates an expected call of RetryNotification.
+func (mr *MockV1DBLayerMockRecorder) RetryNotification(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryNotification", reflect.TypeOf((*MockV1DBLayer)(nil).RetryNotification), arg0, arg1, arg2, arg3)
+}
+
+// RetryOutboxEvent mocks base method.
+func (m *MockV1DBLayer) RetryOutboxEvent(arg0 context.Context, arg1 int64, arg2 time.Time, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryOutboxEvent", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryOutboxEvent indicates an expected call of RetryOutboxEvent.
+func (mr *MockV1DBLayerMockRecorder) RetryOutboxEvent(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryOutboxEvent", reflect.TypeOf((*MockV1DBLayer)(nil).RetryOutboxEvent), arg0, arg1, arg2, arg3)
+}
+
+// RetryWebhookDelivery mocks base method.
+func (m *MockV1DBLayer) RetryWebhookDelivery(arg0 context.Context, arg1 int64, arg2 time.Time, arg3 int64, arg4 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryWebhookDelivery", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryWebhookDelivery indicates an expected call of RetryWebhookDelivery.
+func (mr *MockV1DBLayerMockRecorder) RetryWebhookDelivery(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryWebhookDelivery", reflect.TypeOf((*MockV1DBLayer)(nil).RetryWebhookDelivery), arg0, arg1, arg2, arg3, arg4)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockV1DBLayer) RevokeAPIKey(arg0 context.Context, arg1 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", arg0, arg1)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserLoanInstallments indicates an expected call of GetUserLoanInstallments.
-func (mr *MockV1DBLayerMockRecorder) GetUserLoanInstallments(arg0, arg1, arg2 interface{}) *gomock.Call {
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockV1DBLayerMockRecorder) RevokeAPIKey(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLoanInstallments", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserLoanInstallments), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockV1DBLayer)(nil).RevokeAPIKey), arg0, arg1)
 }
 
-// GetUserLoans mocks base method.
-func (m *MockV1DBLayer) GetUserLoans(arg0 *gin.Context, arg1 int64) ([]loan.LoanDetails, error) {
+// RevokeAccessToken mocks base method.
+func (m *MockV1DBLayer) RevokeAccessToken(arg0 context.Context, arg1 string, arg2 int64, arg3 time.Time) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserLoans", arg0, arg1)
-	ret0, _ := ret[0].([]loan.LoanDetails)
+	ret := m.ctrl.Call(m, "RevokeAccessToken", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAccessToken indicates an expected call of RevokeAccessToken.
+func (mr *MockV1DBLayerMockRecorder) RevokeAccessToken(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAccessToken", reflect.TypeOf((*MockV1DBLayer)(nil).RevokeAccessToken), arg0, arg1, arg2, arg3)
+}
+
+// RevokeRefreshTokenFamily mocks base method.
+func (m *MockV1DBLayer) RevokeRefreshTokenFamily(arg0 context.Context, arg1 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeRefreshTokenFamily", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeRefreshTokenFamily indicates an expected call of RevokeRefreshTokenFamily.
+func (mr *MockV1DBLayerMockRecorder) RevokeRefreshTokenFamily(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeRefreshTokenFamily", reflect.TypeOf((*MockV1DBLayer)(nil).RevokeRefreshTokenFamily), arg0, arg1)
+}
+
+// RotateRefreshToken mocks base method.
+func (m *MockV1DBLayer) RotateRefreshToken(arg0 context.Context, arg1 int64, arg2 usermanagement.RefreshToken) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RotateRefreshToken", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserLoans indicates an expected call of GetUserLoans.
-func (mr *MockV1DBLayerMockRecorder) GetUserLoans(arg0, arg1 interface{}) *gomock.Call {
+// RotateRefreshToken indicates an expected call of RotateRefreshToken.
+func (mr *MockV1DBLayerMockRecorder) RotateRefreshToken(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLoans", reflect.TypeOf((*MockV1DBLayer)(nil).GetUserLoans), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RotateRefreshToken", reflect.TypeOf((*MockV1DBLayer)(nil).RotateRefreshToken), arg0, arg1, arg2)
 }
 
-// ModifyLoan mocks base method.
-func (m *MockV1DBLayer) ModifyLoan(arg0 *gin.Context, arg1, arg2 int64, arg3 float64, arg4 int64) (int64, error) {
+// SaveTotpSecret mocks base method.
+func (m *MockV1DBLayer) SaveTotpSecret(arg0 context.Context, arg1 int64, arg2 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveTotpSecret", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveTotpSecret indicates an expected call of SaveTotpSecret.
+func (mr *MockV1DBLayerMockRecorder) SaveTotpSecret(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveTotpSecret", reflect.TypeOf((*MockV1DBLayer)(nil).SaveTotpSecret), arg0, arg1, arg2)
+}
+
+// SetNotificationPreferences mocks base method.
+func (m *MockV1DBLayer) SetNotificationPreferences(arg0 context.Context, arg1 []notification.NotificationPreference) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotificationPreferences", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetNotificationPreferences indicates an expected call of SetNotificationPreferences.
+func (mr *MockV1DBLayerMockRecorder) SetNotificationPreferences(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotificationPreferences", reflect.TypeOf((*MockV1DBLayer)(nil).SetNotificationPreferences), arg0, arg1)
+}
+
+// SetUserRoles mocks base method.
+func (m *MockV1DBLayer) SetUserRoles(arg0 context.Context, arg1 int64, arg2 []string) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ModifyLoan", arg0, arg1, arg2, arg3, arg4)
+	ret := m.ctrl.Call(m, "SetUserRoles", arg0, arg1, arg2)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// ModifyLoan indicates an expected call of ModifyLoan.
-func (mr *MockV1DBLayerMockRecorder) ModifyLoan(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+// SetUserRoles indicates an expected call of SetUserRoles.
+func (mr *MockV1DBLayerMockRecorder) SetUserRoles(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetUserRoles", reflect.TypeOf((*MockV1DBLayer)(nil).SetUserRoles), arg0, arg1, arg2)
+}
+
+// TouchAPIKey mocks base method.
+func (m *MockV1DBLayer) TouchAPIKey(arg0 context.Context, arg1 int64, arg2, arg3 time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TouchAPIKey", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TouchAPIKey indicates an expected call of TouchAPIKey.
+func (mr *MockV1DBLayerMockRecorder) TouchAPIKey(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ModifyLoan", reflect.TypeOf((*MockV1DBLayer)(nil).ModifyLoan), arg0, arg1, arg2, arg3, arg4)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TouchAPIKey", reflect.TypeOf((*MockV1DBLayer)(nil).TouchAPIKey), arg0, arg1, arg2, arg3)
 }
 
 // UpdateAndInsertInstallments mocks base method.
-func (m *MockV1DBLayer) UpdateAndInsertInstallments(arg0 *gin.Context, arg1 int64, arg2 float64, arg3 int64) error {
+func (m *MockV1DBLayer) UpdateAndInsertInstallments(arg0 context.Context, arg1 int64, arg2 loan.StatusChange, arg3 float64, arg4 int64, arg5 []outbox.Event) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateAndInsertInstallments", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "UpdateAndInsertInstallments", arg0, arg1, arg2, arg3, arg4, arg5)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateAndInsertInstallments indicates an expected call of UpdateAndInsertInstallments.
-func (mr *MockV1DBLayerMockRecorder) UpdateAndInsertInstallments(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockV1DBLayerMockRecorder) UpdateAndInsertInstallments(arg0, arg1, arg2, arg3, arg4, arg5 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAndInsertInstallments", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateAndInsertInstallments), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// UpdateDocumentStatus mocks base method.
+func (m *MockV1DBLayer) UpdateDocumentStatus(arg0 context.Context, arg1 int64, arg2 string, arg3 int64, arg4 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateDocumentStatus", arg0, arg1, arg2, arg3, arg4)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateDocumentStatus indicates an expected call of UpdateDocumentStatus.
+func (mr *MockV1DBLayerMockRecorder) UpdateDocumentStatus(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAndInsertInstallments", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateAndInsertInstallments), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateDocumentStatus", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateDocumentStatus), arg0, arg1, arg2, arg3, arg4)
 }
 
 // UpdateInstallment mocks base method.
-func (m *MockV1DBLayer) UpdateInstallment(arg0 *gin.Context, arg1 int64, arg2 []loan.InstallmentDetails, arg3 bool) error {
+func (m *MockV1DBLayer) UpdateInstallment(arg0 context.Context, arg1 int64, arg2 []loan.InstallmentDetails, arg3 loan.StatusChange, arg4 []outbox.Event) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateInstallment", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "UpdateInstallment", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateInstallment indicates an expected call of UpdateInstallment.
-func (mr *MockV1DBLayerMockRecorder) UpdateInstallment(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockV1DBLayerMockRecorder) UpdateInstallment(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInstallment", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateInstallment), arg0, arg1, arg2, arg3, arg4)
+}
+
+// UpdateLoanStatus mocks base method.
+func (m *MockV1DBLayer) UpdateLoanStatus(arg0 context.Context, arg1 int64, arg2 loan.StatusChange, arg3 []outbox.Event) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLoanStatus", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLoanStatus indicates an expected call of UpdateLoanStatus.
+func (mr *MockV1DBLayerMockRecorder) UpdateLoanStatus(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLoanStatus", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateLoanStatus), arg0, arg1, arg2, arg3)
+}
+
+// UpdatePassword mocks base method.
+func (m *MockV1DBLayer) UpdatePassword(arg0 context.Context, arg1 int64, arg2 string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdatePassword indicates an expected call of UpdatePassword.
+func (mr *MockV1DBLayerMockRecorder) UpdatePassword(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInstallment", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateInstallment), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockV1DBLayer)(nil).UpdatePassword), arg0, arg1, arg2)
 }
 
 // UpdateSingleInstallmentPayment mocks base method.
-func (m *MockV1DBLayer) UpdateSingleInstallmentPayment(arg0 *gin.Context, arg1 int64, arg2 loan.InstallmentDetails, arg3 bool) error {
+func (m *MockV1DBLayer) UpdateSingleInstallmentPayment(arg0 context.Context, arg1 int64, arg2 loan.InstallmentDetails, arg3 loan.StatusChange, arg4 []outbox.Event) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateSingleInstallmentPayment", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "UpdateSingleInstallmentPayment", arg0, arg1, arg2, arg3, arg4)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateSingleInstallmentPayment indicates an expected call of UpdateSingleInstallmentPayment.
-func (mr *MockV1DBLayerMockRecorder) UpdateSingleInstallmentPayment(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+func (mr *MockV1DBLayerMockRecorder) UpdateSingleInstallmentPayment(arg0, arg1, arg2, arg3, arg4 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSingleInstallmentPayment", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateSingleInstallmentPayment), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSingleInstallmentPayment", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateSingleInstallmentPayment), arg0, arg1, arg2, arg3, arg4)
 }
 
-// UpdateUnapprovedLoan mocks base method.
-func (m *MockV1DBLayer) UpdateUnapprovedLoan(arg0 *gin.Context, arg1 int64, arg2 bool) error {
+// UpdateUserProfile mocks base method.
+func (m *MockV1DBLayer) UpdateUserProfile(arg0 context.Context, arg1 int64, arg2 []usermanagement.ProfileChange) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUnapprovedLoan", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "UpdateUserProfile", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateUnapprovedLoan indicates an expected call of UpdateUnapprovedLoan.
-func (mr *MockV1DBLayerMockRecorder) UpdateUnapprovedLoan(arg0, arg1, arg2 interface{}) *gomock.Call {
+// UpdateUserProfile indicates an expected call of UpdateUserProfile.
+func (mr *MockV1DBLayerMockRecorder) UpdateUserProfile(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserProfile", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateUserProfile), arg0, arg1, arg2)
+}
+
+// UpdateWebhookSubscription mocks base method.
+func (m *MockV1DBLayer) UpdateWebhookSubscription(arg0 context.Context, arg1 webhook.WebhookSubscription) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWebhookSubscription", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateWebhookSubscription indicates an expected call of UpdateWebhookSubscription.
+func (mr *MockV1DBLayerMockRecorder) UpdateWebhookSubscription(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWebhookSubscription", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateWebhookSubscription), arg0, arg1)
+}
+
+// UseRecoveryCode mocks base method.
+func (m *MockV1DBLayer) UseRecoveryCode(arg0 context.Context, arg1 int64, arg2 string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseRecoveryCode", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseRecoveryCode indicates an expected call of UseRecoveryCode.
+func (mr *MockV1DBLayerMockRecorder) UseRecoveryCode(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseRecoveryCode", reflect.TypeOf((*MockV1DBLayer)(nil).UseRecoveryCode), arg0, arg1, arg2)
+}
+
+// UseTotpStep mocks base method.
+func (m *MockV1DBLayer) UseTotpStep(arg0 context.Context, arg1, arg2 int64) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UseTotpStep", arg0, arg1, arg2)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseTotpStep indicates an expected call of UseTotpStep.
+func (mr *MockV1DBLayerMockRecorder) UseTotpStep(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUnapprovedLoan", reflect.TypeOf((*MockV1DBLayer)(nil).UpdateUnapprovedLoan), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseTotpStep", reflect.TypeOf((*MockV1DBLayer)(nil).UseTotpStep), arg0, arg1, arg2)
 }
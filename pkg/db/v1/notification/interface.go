@@ -0,0 +1,34 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type notificationDb struct {
+	dbObj *gorm.DB
+}
+
+type DbNotificationInterface interface {
+	GetNotificationPreferences(context.Context, int64) ([]NotificationPreference, error)
+	SetNotificationPreferences(context.Context, []NotificationPreference) error
+
+	AddNotifications(context.Context, []Notification) error
+	GetDueNotifications(context.Context, time.Time, int) ([]Notification, error)
+	ClaimNotification(context.Context, int64, int64, time.Time) (bool, error)
+	MarkNotificationSent(context.Context, int64, time.Time) error
+	RetryNotification(context.Context, int64, time.Time, string) error
+	FailNotification(context.Context, int64, string) error
+	GetNotifications(context.Context, NotificationFilter) ([]Notification, error)
+	MarkNotificationsRead(context.Context, int64, []int64, time.Time) (int64, error)
+
+	GetInstallmentsDueBetween(context.Context, time.Time, time.Time) ([]InstallmentReminder, error)
+}
+
+func NewNotificationDbObject(db *gorm.DB) DbNotificationInterface {
+	return &notificationDb{
+		dbObj: db,
+	}
+}
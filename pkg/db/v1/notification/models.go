@@ -0,0 +1,64 @@
+package notification
+
+import "database/sql"
+
+// notification channels
+const (
+	EMAIL  = "EMAIL"
+	SMS    = "SMS"
+	IN_APP = "IN_APP"
+)
+
+// notification status
+const (
+	PENDING = "PENDING"
+	SENT    = "SENT"
+	FAILED  = "FAILED"
+)
+
+// NotificationPreference turns a channel on or off for a user. channels without a preference use the defaults
+type NotificationPreference struct {
+	UserId    sql.NullInt64
+	Channel   sql.NullString
+	Enabled   sql.NullBool
+	UpdatedAt sql.NullTime
+}
+
+// Notification is an event rendered for a user on one channel. Recipient is the email or mobile it is sent to,
+// empty for IN_APP, and DedupKey names the occurrence of the event so it is notified once per channel
+type Notification struct {
+	NotificationId sql.NullInt64
+	UserId         sql.NullInt64
+	EventType      sql.NullString
+	Channel        sql.NullString
+	Recipient      sql.NullString
+	Subject        sql.NullString
+	Body           sql.NullString
+	DedupKey       sql.NullString
+	Status         sql.NullString
+	Attempts       sql.NullInt64
+	NextAttemptAt  sql.NullTime
+	LastError      sql.NullString
+	CreatedAt      sql.NullTime
+	SentAt         sql.NullTime
+	ReadAt         sql.NullTime
+}
+
+// NotificationFilter selects the notifications of a user, latest first. zero fields do not filter and BeforeId
+// pages back through them
+type NotificationFilter struct {
+	UserId   int64
+	Channel  string
+	Unread   bool
+	BeforeId int64
+	Limit    int
+}
+
+// InstallmentReminder is a pending installment of an approved loan, to remind its borrower about
+type InstallmentReminder struct {
+	UserId         sql.NullInt64
+	LoanId         sql.NullInt64
+	InstallmentNum sql.NullInt64
+	AmountDue      sql.NullFloat64
+	DueDate        sql.NullTime
+}
@@ -0,0 +1,252 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// AddNotifications stores the notifications in one transaction. a notification of a dedup key the channel already
+// has is skipped, so an event notified again is not sent twice
+func (obj *notificationDb) AddNotifications(ctx context.Context, notifications []Notification) error {
+	query := `
+		insert into
+			notification(user_id, event_type, channel, recipient, subject, body, dedup_key, status, next_attempt_at, created_at, sent_at)
+		values
+			(?,?,?,?,?,?,?,?,?,?,?)
+		on conflict (channel, dedup_key) do nothing;
+	`
+	tx := obj.dbObj.Begin()
+	for _, notification := range notifications {
+		createdAt := notification.CreatedAt.Time.UTC()
+		sentAt := notification.SentAt
+		if sentAt.Valid {
+			sentAt.Time = sentAt.Time.UTC()
+		}
+		insertTx := tx.WithContext(ctx).Exec(query, notification.UserId.Int64, notification.EventType.String, notification.Channel.String, notification.Recipient.String, notification.Subject.String, notification.Body.String, notification.DedupKey.String, notification.Status.String, createdAt, createdAt, sentAt)
+		if insertTx.Error != nil {
+			log.Printf("failed to add notification. Error :%s", insertTx.Error.Error())
+			tx.Rollback()
+			return insertTx.Error
+		}
+	}
+	return tx.Commit().Error
+}
+
+// GetDueNotifications lists the pending notifications due at now, in id order
+func (obj *notificationDb) GetDueNotifications(ctx context.Context, now time.Time, limit int) ([]Notification, error) {
+	query := `
+		select
+			` + notificationColumns + `
+		from
+			notification
+		where
+			status = 'PENDING'
+			and next_attempt_at <= ?
+		order by id
+		limit ?;
+	`
+	return obj.notifications(ctx, query, now.UTC(), limit)
+}
+
+// ClaimNotification starts an attempt of a pending notification seen with the given attempts and keeps other
+// dispatchers away from it until leaseUntil. it returns false when another dispatcher claimed it first
+func (obj *notificationDb) ClaimNotification(ctx context.Context, notificationId int64, attempts int64, leaseUntil time.Time) (bool, error) {
+	query := `
+		update
+			notification
+		set
+			attempts = attempts + 1,
+			next_attempt_at = ?
+		where
+			id = ?
+			and status = 'PENDING'
+			and attempts = ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, leaseUntil.UTC(), notificationId, attempts)
+	if updateTx.Error != nil {
+		log.Printf("failed to claim notification. Error :%s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+func (obj *notificationDb) MarkNotificationSent(ctx context.Context, notificationId int64, sentAt time.Time) error {
+	query := `
+		update
+			notification
+		set
+			status = 'SENT',
+			last_error = null,
+			sent_at = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, sentAt.UTC(), notificationId)
+	if updateTx.Error != nil {
+		log.Printf("failed to mark notification sent. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// RetryNotification keeps a notification which could not be sent for another attempt at nextAttemptAt
+func (obj *notificationDb) RetryNotification(ctx context.Context, notificationId int64, nextAttemptAt time.Time, lastError string) error {
+	query := `
+		update
+			notification
+		set
+			next_attempt_at = ?,
+			last_error = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, nextAttemptAt.UTC(), lastError, notificationId)
+	if updateTx.Error != nil {
+		log.Printf("failed to reschedule notification. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// FailNotification gives up on a notification which ran out of attempts
+func (obj *notificationDb) FailNotification(ctx context.Context, notificationId int64, lastError string) error {
+	query := `
+		update
+			notification
+		set
+			status = 'FAILED',
+			last_error = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, lastError, notificationId)
+	if updateTx.Error != nil {
+		log.Printf("failed to fail notification. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+func (obj *notificationDb) GetNotifications(ctx context.Context, filter NotificationFilter) ([]Notification, error) {
+	conditions := []string{"user_id = ?"}
+	values := []interface{}{filter.UserId}
+	if filter.Channel != "" {
+		conditions = append(conditions, "channel = ?")
+		values = append(values, filter.Channel)
+	}
+	if filter.Unread {
+		conditions = append(conditions, "read_at is null")
+	}
+	if filter.BeforeId != 0 {
+		conditions = append(conditions, "id < ?")
+		values = append(values, filter.BeforeId)
+	}
+	query := `
+		select
+			` + notificationColumns + `
+		from
+			notification
+		where
+			` + strings.Join(conditions, " and ") + `
+		order by id desc
+		limit ?;
+	`
+	values = append(values, filter.Limit)
+	return obj.notifications(ctx, query, values...)
+}
+
+// MarkNotificationsRead marks the unread in-app notifications of the user with the given ids read, or all of them
+// when there are no ids, and returns how many were marked
+func (obj *notificationDb) MarkNotificationsRead(ctx context.Context, userId int64, notificationIds []int64, readAt time.Time) (int64, error) {
+	conditions := []string{"user_id = ?", "channel = 'IN_APP'", "read_at is null"}
+	values := []interface{}{readAt.UTC(), userId}
+	if len(notificationIds) > 0 {
+		conditions = append(conditions, "id in ?")
+		values = append(values, notificationIds)
+	}
+	query := `
+		update
+			notification
+		set
+			read_at = ?
+		where
+			` + strings.Join(conditions, " and ") + `;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, values...)
+	if updateTx.Error != nil {
+		log.Printf("failed to mark notifications read. Error :%s", updateTx.Error.Error())
+		return 0, updateTx.Error
+	}
+	return updateTx.RowsAffected, nil
+}
+
+// GetInstallmentsDueBetween lists the pending installments of approved loans falling due after from and up to to,
+// in due date order
+func (obj *notificationDb) GetInstallmentsDueBetween(ctx context.Context, from time.Time, to time.Time) ([]InstallmentReminder, error) {
+	query := `
+		select
+			l.user_id,
+			i.loan_id,
+			i.installment_num,
+			i.amount_due,
+			i.due_date
+		from
+			installment i
+		inner join
+			loan l
+		on
+			l.id = i.loan_id
+		where
+			l.status = 'APPROVED'
+			and i.status = 'PENDING'
+			and i.due_date > ?
+			and i.due_date <= ?
+		order by i.due_date, i.loan_id, i.installment_num;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, from.UTC(), to.UTC()).Rows()
+	if err != nil {
+		log.Printf("failed to fetch installments due. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := make([]InstallmentReminder, 0)
+	for rows.Next() {
+		var reminder InstallmentReminder
+		err := rows.Scan(&reminder.UserId, &reminder.LoanId, &reminder.InstallmentNum, &reminder.AmountDue, &reminder.DueDate)
+		if err != nil {
+			log.Printf("failed to scan installment due. Error:%s", err.Error())
+			return nil, err
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, nil
+}
+
+const notificationColumns = `id, user_id, event_type, channel, recipient, subject, body, dedup_key, status, attempts, next_attempt_at, last_error, created_at, sent_at, read_at`
+
+func (obj *notificationDb) notifications(ctx context.Context, query string, values ...interface{}) ([]Notification, error) {
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, values...).Rows()
+	if err != nil {
+		log.Printf("failed to fetch notifications. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := make([]Notification, 0)
+	for rows.Next() {
+		var notification Notification
+		err := rows.Scan(&notification.NotificationId, &notification.UserId, &notification.EventType, &notification.Channel, &notification.Recipient, &notification.Subject, &notification.Body, &notification.DedupKey, &notification.Status, &notification.Attempts, &notification.NextAttemptAt, &notification.LastError, &notification.CreatedAt, &notification.SentAt, &notification.ReadAt)
+		if err != nil {
+			log.Printf("failed to scan notification. Error:%s", err.Error())
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"context"
+	"log"
+)
+
+func (obj *notificationDb) GetNotificationPreferences(ctx context.Context, userId int64) ([]NotificationPreference, error) {
+	query := `
+		select
+			user_id,
+			channel,
+			enabled,
+			updated_at
+		from
+			notification_preference
+		where
+			user_id = ?
+		order by channel;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId).Rows()
+	if err != nil {
+		log.Printf("failed to fetch notification preferences. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	preferences := make([]NotificationPreference, 0)
+	for rows.Next() {
+		var preference NotificationPreference
+		err := rows.Scan(&preference.UserId, &preference.Channel, &preference.Enabled, &preference.UpdatedAt)
+		if err != nil {
+			log.Printf("failed to scan notification preference. Error:%s", err.Error())
+			return nil, err
+		}
+		preferences = append(preferences, preference)
+	}
+	return preferences, nil
+}
+
+// SetNotificationPreferences saves the preferences in one transaction, replacing those of the same user and channel
+func (obj *notificationDb) SetNotificationPreferences(ctx context.Context, preferences []NotificationPreference) error {
+	query := `
+		insert into
+			notification_preference(user_id, channel, enabled, updated_at)
+		values
+			(?,?,?,?)
+		on conflict (user_id, channel) do update set
+			enabled = excluded.enabled,
+			updated_at = excluded.updated_at;
+	`
+	tx := obj.dbObj.Begin()
+	for _, preference := range preferences {
+		insertTx := tx.WithContext(ctx).Exec(query, preference.UserId.Int64, preference.Channel.String, preference.Enabled.Bool, preference.UpdatedAt.Time.UTC())
+		if insertTx.Error != nil {
+			log.Printf("failed to set notification preference. Error :%s", insertTx.Error.Error())
+			tx.Rollback()
+			return insertTx.Error
+		}
+	}
+	return tx.Commit().Error
+}
@@ -0,0 +1,26 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type outboxDb struct {
+	dbObj *gorm.DB
+}
+
+type DbOutboxInterface interface {
+	GetDueOutboxEvents(context.Context, time.Time, int) ([]OutboxEvent, error)
+	ClaimOutboxEvent(context.Context, int64, int64, time.Time) (bool, error)
+	MarkOutboxEventDelivered(context.Context, int64, time.Time) error
+	RetryOutboxEvent(context.Context, int64, time.Time, string) error
+	FailOutboxEvent(context.Context, int64, string) error
+}
+
+func NewOutboxDbObject(db *gorm.DB) DbOutboxInterface {
+	return &outboxDb{
+		dbObj: db,
+	}
+}
@@ -0,0 +1,34 @@
+package outbox
+
+import "database/sql"
+
+// outbox event status
+const (
+	PENDING   = "PENDING"
+	DELIVERED = "DELIVERED"
+	FAILED    = "FAILED"
+)
+
+// Event is a domain event written to the outbox with the change it announces. Payload is the JSON body of the
+// event and AggregateId the id of the entity it is about
+type Event struct {
+	EventType     string
+	AggregateType string
+	AggregateId   int64
+	Payload       string
+}
+
+// OutboxEvent is a row of the outbox. Attempts counts the deliveries started so far
+type OutboxEvent struct {
+	EventId       sql.NullInt64
+	EventType     sql.NullString
+	AggregateType sql.NullString
+	AggregateId   sql.NullInt64
+	Payload       sql.NullString
+	Status        sql.NullString
+	Attempts      sql.NullInt64
+	NextAttemptAt sql.NullTime
+	LastError     sql.NullString
+	CreatedAt     sql.NullTime
+	DeliveredAt   sql.NullTime
+}
@@ -0,0 +1,152 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AddEvents writes the events in tx, the transaction of the change they announce, so an event is kept exactly
+// when the change is. the events are due right away
+func AddEvents(ctx context.Context, tx *gorm.DB, events []Event) error {
+	query := `
+		insert into
+			outbox_event(event_type, aggregate_type, aggregate_id, payload, next_attempt_at, created_at)
+		values
+			(?,?,?,?,?,?);
+	`
+	now := time.Now().UTC()
+	for _, event := range events {
+		insertTx := tx.WithContext(ctx).Exec(query, event.EventType, event.AggregateType, event.AggregateId, event.Payload, now, now)
+		if insertTx.Error != nil {
+			log.Printf("failed to add outbox event. Error :%s", insertTx.Error.Error())
+			return insertTx.Error
+		}
+	}
+	return nil
+}
+
+// GetDueOutboxEvents lists the pending events due at now in id order. an event waits while an older event of
+// the same aggregate is pending, so each aggregate's events are delivered in the order they happened
+func (obj *outboxDb) GetDueOutboxEvents(ctx context.Context, now time.Time, limit int) ([]OutboxEvent, error) {
+	query := `
+		select
+			e.id, e.event_type, e.aggregate_type, e.aggregate_id, e.payload, e.status, e.attempts, e.next_attempt_at, e.last_error, e.created_at, e.delivered_at
+		from
+			outbox_event e
+		where
+			e.status = 'PENDING'
+			and e.next_attempt_at <= ?
+			and not exists (
+				select 1 from outbox_event p
+				where p.aggregate_type = e.aggregate_type
+					and p.aggregate_id = e.aggregate_id
+					and p.status = 'PENDING'
+					and p.id < e.id
+			)
+		order by e.id
+		limit ?;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, now.UTC(), limit).Rows()
+	if err != nil {
+		log.Printf("failed to fetch due outbox events. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]OutboxEvent, 0)
+	for rows.Next() {
+		var event OutboxEvent
+		err := rows.Scan(&event.EventId, &event.EventType, &event.AggregateType, &event.AggregateId, &event.Payload, &event.Status, &event.Attempts, &event.NextAttemptAt, &event.LastError, &event.CreatedAt, &event.DeliveredAt)
+		if err != nil {
+			log.Printf("failed to scan outbox event. Error:%s", err.Error())
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ClaimOutboxEvent starts a delivery of a pending event seen with the given attempts and keeps other relays away
+// from it until leaseUntil. it returns false when another relay claimed the event first. a relay stopping
+// mid-delivery leaves the event to be delivered again once the lease ends
+func (obj *outboxDb) ClaimOutboxEvent(ctx context.Context, eventId int64, attempts int64, leaseUntil time.Time) (bool, error) {
+	query := `
+		update
+			outbox_event
+		set
+			attempts = attempts + 1,
+			next_attempt_at = ?
+		where
+			id = ?
+			and status = 'PENDING'
+			and attempts = ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, leaseUntil.UTC(), eventId, attempts)
+	if updateTx.Error != nil {
+		log.Printf("failed to claim outbox event. Error :%s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+func (obj *outboxDb) MarkOutboxEventDelivered(ctx context.Context, eventId int64, deliveredAt time.Time) error {
+	query := `
+		update
+			outbox_event
+		set
+			status = 'DELIVERED',
+			delivered_at = ?,
+			last_error = null
+		where
+			id = ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, deliveredAt.UTC(), eventId)
+	if updateTx.Error != nil {
+		log.Printf("failed to mark outbox event delivered. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// RetryOutboxEvent keeps a pending event whose delivery failed for another attempt at nextAttemptAt
+func (obj *outboxDb) RetryOutboxEvent(ctx context.Context, eventId int64, nextAttemptAt time.Time, lastError string) error {
+	query := `
+		update
+			outbox_event
+		set
+			next_attempt_at = ?,
+			last_error = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, nextAttemptAt.UTC(), lastError, eventId)
+	if updateTx.Error != nil {
+		log.Printf("failed to reschedule outbox event. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// FailOutboxEvent gives up on an event which ran out of attempts. later events of its aggregate are no longer held
+func (obj *outboxDb) FailOutboxEvent(ctx context.Context, eventId int64, lastError string) error {
+	query := `
+		update
+			outbox_event
+		set
+			status = 'FAILED',
+			last_error = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, lastError, eventId)
+	if updateTx.Error != nil {
+		log.Printf("failed to mark outbox event failed. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+	"time"
+)
+
+// AddPaymentEvent records a received event and returns 0 when the provider sent it before
+func (obj *paymentDb) AddPaymentEvent(ctx context.Context, event PaymentEvent) (int64, error) {
+	query := `
+		insert into
+			payment_event(provider, provider_event_id, event_type, payment_id, reference, amount, payload, received_at, lease_until)
+		values
+			(?,?,?,?,?,?,?,?,?)
+		on conflict (provider, provider_event_id) do nothing
+		returning id;
+	`
+
+	var eventId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, event.Provider.String, event.ProviderEventId.String, event.EventType.String, event.PaymentId, event.Reference, event.Amount, event.Payload.String, event.ReceivedAt.Time.UTC(), event.LeaseUntil.Time.UTC()).Scan(&eventId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add payment event. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return eventId.Int64, nil
+}
+
+// GetPaymentEvent fails with sql.ErrNoRows when the provider never sent the event
+func (obj *paymentDb) GetPaymentEvent(ctx context.Context, provider string, providerEventId string) (PaymentEvent, error) {
+	query := `
+		select
+			` + eventColumns + `
+		from
+			payment_event
+		where
+			provider = ?
+			and provider_event_id = ?;
+	`
+	var event PaymentEvent
+	row := obj.dbObj.WithContext(ctx).Raw(query, provider, providerEventId).Row()
+	if row.Err() != nil {
+		log.Printf("failed to fetch payment event. Error: %s", row.Err().Error())
+		return event, row.Err()
+	}
+	if err := scanEvent(row, &event); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("failed to scan payment event. Error:%s", err.Error())
+		}
+		return event, err
+	}
+	return event, nil
+}
+
+// CompletePaymentEvent records the outcome of a received event with the loan it was matched to
+func (obj *paymentDb) CompletePaymentEvent(ctx context.Context, event PaymentEvent) error {
+	query := `
+		update
+			payment_event
+		set
+			status = ?,
+			loan_id = ?,
+			error = ?,
+			processed_at = ?
+		where
+			id = ?
+			and status = 'RECEIVED';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, event.Status.String, event.LoanId, event.Error, event.ProcessedAt.Time.UTC(), event.EventId.Int64)
+	if updateTx.Error != nil {
+		log.Printf("failed to complete payment event. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// ClaimPaymentEvent takes over a RECEIVED event whose lease passed, until leaseUntil. it returns false when the
+// event has an outcome or is still leased to the notification applying it
+func (obj *paymentDb) ClaimPaymentEvent(ctx context.Context, eventId int64, now time.Time, leaseUntil time.Time) (bool, error) {
+	query := `
+		update
+			payment_event
+		set
+			lease_until = ?
+		where
+			id = ?
+			and status = 'RECEIVED'
+			and (lease_until is null or lease_until <= ?);
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, leaseUntil.UTC(), eventId, now.UTC())
+	if updateTx.Error != nil {
+		log.Printf("failed to claim payment event. Error :%s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+// DeletePaymentEvent forgets a received event which could not be applied, so the provider sending it again
+// gets it applied. events with an outcome are kept
+func (obj *paymentDb) DeletePaymentEvent(ctx context.Context, eventId int64) error {
+	query := `
+		delete from
+			payment_event
+		where
+			id = ?
+			and status = 'RECEIVED';
+	`
+	deleteTx := obj.dbObj.WithContext(ctx).Exec(query, eventId)
+	if deleteTx.Error != nil {
+		log.Printf("failed to delete payment event. Error :%s", deleteTx.Error.Error())
+		return deleteTx.Error
+	}
+	return nil
+}
+
+func (obj *paymentDb) GetPaymentEvents(ctx context.Context, filter PaymentEventFilter) ([]PaymentEvent, error) {
+	conditions := []string{"id > ?"}
+	values := []interface{}{filter.AfterId}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		values = append(values, filter.Status)
+	}
+	if filter.LoanId != 0 {
+		conditions = append(conditions, "loan_id = ?")
+		values = append(values, filter.LoanId)
+	}
+	query := `
+		select
+			` + eventColumns + `
+		from
+			payment_event
+		where
+			` + strings.Join(conditions, " and ") + `
+		order by id
+		limit ?;
+	`
+	values = append(values, filter.Limit)
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, values...).Rows()
+	if err != nil {
+		log.Printf("failed to fetch payment events. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]PaymentEvent, 0)
+	for rows.Next() {
+		var event PaymentEvent
+		if err := scanEvent(rows, &event); err != nil {
+			log.Printf("failed to scan payment event. Error:%s", err.Error())
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+const eventColumns = `id, provider, provider_event_id, event_type, payment_id, reference, amount, loan_id, status, error, payload, received_at, processed_at, lease_until`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEvent(row scanner, event *PaymentEvent) error {
+	return row.Scan(&event.EventId, &event.Provider, &event.ProviderEventId, &event.EventType, &event.PaymentId, &event.Reference, &event.Amount, &event.LoanId, &event.Status, &event.Error, &event.Payload, &event.ReceivedAt, &event.ProcessedAt, &event.LeaseUntil)
+}
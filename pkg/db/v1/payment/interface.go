@@ -0,0 +1,27 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type paymentDb struct {
+	dbObj *gorm.DB
+}
+
+type DbPaymentInterface interface {
+	AddPaymentEvent(context.Context, PaymentEvent) (int64, error)
+	GetPaymentEvent(context.Context, string, string) (PaymentEvent, error)
+	CompletePaymentEvent(context.Context, PaymentEvent) error
+	ClaimPaymentEvent(context.Context, int64, time.Time, time.Time) (bool, error)
+	DeletePaymentEvent(context.Context, int64) error
+	GetPaymentEvents(context.Context, PaymentEventFilter) ([]PaymentEvent, error)
+}
+
+func NewPaymentDbObject(db *gorm.DB) DbPaymentInterface {
+	return &paymentDb{
+		dbObj: db,
+	}
+}
@@ -0,0 +1,39 @@
+package payment
+
+import "database/sql"
+
+// payment event status
+const (
+	RECEIVED = "RECEIVED"
+	APPLIED  = "APPLIED"
+	REJECTED = "REJECTED"
+	IGNORED  = "IGNORED"
+)
+
+// PaymentEvent is a notification of the payment provider, kept as it was received in Payload. LoanId is the
+// loan Reference was matched to and Error says why the payment was rejected. a RECEIVED event is being applied
+// until LeaseUntil
+type PaymentEvent struct {
+	EventId         sql.NullInt64
+	Provider        sql.NullString
+	ProviderEventId sql.NullString
+	EventType       sql.NullString
+	PaymentId       sql.NullString
+	Reference       sql.NullString
+	Amount          sql.NullFloat64
+	LoanId          sql.NullInt64
+	Status          sql.NullString
+	Error           sql.NullString
+	Payload         sql.NullString
+	ReceivedAt      sql.NullTime
+	ProcessedAt     sql.NullTime
+	LeaseUntil      sql.NullTime
+}
+
+// PaymentEventFilter selects events in id order. zero fields do not filter and AfterId pages through them
+type PaymentEventFilter struct {
+	Status  string
+	LoanId  int64
+	AfterId int64
+	Limit   int
+}
@@ -0,0 +1,28 @@
+package reconciliation
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type reconciliationDb struct {
+	dbObj *gorm.DB
+}
+
+type DbReconciliationInterface interface {
+	AddBankStatement(context.Context, BankStatement, []StatementLine) (int64, []StatementLine, error)
+	GetBankStatements(context.Context) ([]BankStatement, error)
+	CompleteStatementLine(context.Context, StatementLine) error
+	GetStatementLine(context.Context, int64) (StatementLine, error)
+	GetStatementLines(context.Context, StatementLineFilter) ([]StatementLine, error)
+	ResolveStatementLine(context.Context, StatementLine) (int64, error)
+	ReopenStatementLine(context.Context, int64, string) error
+	GetLoansByNextInstallment(context.Context, float64) ([]int64, error)
+}
+
+func NewReconciliationDbObject(db *gorm.DB) DbReconciliationInterface {
+	return &reconciliationDb{
+		dbObj: db,
+	}
+}
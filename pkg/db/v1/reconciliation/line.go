@@ -0,0 +1,199 @@
+package reconciliation
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+)
+
+// CompleteStatementLine records the outcome of matching a received line: APPLIED to LoanId by MatchRule,
+// or an EXCEPTION for Reason
+func (obj *reconciliationDb) CompleteStatementLine(ctx context.Context, line StatementLine) error {
+	query := `
+		update
+			statement_line
+		set
+			status = ?,
+			loan_id = ?,
+			match_rule = ?,
+			reason = ?
+		where
+			id = ?
+			and status = 'RECEIVED';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, line.Status.String, line.LoanId, line.MatchRule, line.Reason, line.LineId.Int64)
+	if updateTx.Error != nil {
+		log.Printf("failed to complete statement line. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// GetStatementLine fails with sql.ErrNoRows when there is no such line
+func (obj *reconciliationDb) GetStatementLine(ctx context.Context, lineId int64) (StatementLine, error) {
+	query := `
+		select
+			` + lineColumns + `
+		from
+			statement_line
+		where
+			id = ?;
+	`
+	var line StatementLine
+	row := obj.dbObj.WithContext(ctx).Raw(query, lineId).Row()
+	if row.Err() != nil {
+		log.Printf("failed to fetch statement line. Error: %s", row.Err().Error())
+		return line, row.Err()
+	}
+	if err := scanLine(row, &line); err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("failed to scan statement line. Error:%s", err.Error())
+		}
+		return line, err
+	}
+	return line, nil
+}
+
+func (obj *reconciliationDb) GetStatementLines(ctx context.Context, filter StatementLineFilter) ([]StatementLine, error) {
+	conditions := []string{"id > ?"}
+	values := []interface{}{filter.AfterId}
+	if filter.StatementId != 0 {
+		conditions = append(conditions, "statement_id = ?")
+		values = append(values, filter.StatementId)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		values = append(values, filter.Status)
+	}
+	query := `
+		select
+			` + lineColumns + `
+		from
+			statement_line
+		where
+			` + strings.Join(conditions, " and ") + `
+		order by id
+		limit ?;
+	`
+	values = append(values, filter.Limit)
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, values...).Rows()
+	if err != nil {
+		log.Printf("failed to fetch statement lines. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	lines := make([]StatementLine, 0)
+	for rows.Next() {
+		var line StatementLine
+		if err := scanLine(rows, &line); err != nil {
+			log.Printf("failed to scan statement line. Error:%s", err.Error())
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// ResolveStatementLine closes an exception as RESOLVED to LoanId or DISMISSED. it returns 0 when the line
+// is not an exception, which another admin may have resolved first
+func (obj *reconciliationDb) ResolveStatementLine(ctx context.Context, line StatementLine) (int64, error) {
+	query := `
+		update
+			statement_line
+		set
+			status = ?,
+			loan_id = ?,
+			match_rule = ?,
+			resolved_by = ?,
+			resolved_at = ?,
+			note = ?
+		where
+			id = ?
+			and status = 'EXCEPTION';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, line.Status.String, line.LoanId, line.MatchRule, line.ResolvedBy, line.ResolvedAt.Time.UTC(), line.Note, line.LineId.Int64)
+	if updateTx.Error != nil {
+		log.Printf("failed to resolve statement line. Error :%s", updateTx.Error.Error())
+		return 0, updateTx.Error
+	}
+	if updateTx.RowsAffected == 0 {
+		return 0, nil
+	}
+	return line.LineId.Int64, nil
+}
+
+// ReopenStatementLine puts a resolved line back in the exceptions when its repayment could not be applied
+func (obj *reconciliationDb) ReopenStatementLine(ctx context.Context, lineId int64, reason string) error {
+	query := `
+		update
+			statement_line
+		set
+			status = 'EXCEPTION',
+			loan_id = null,
+			match_rule = null,
+			reason = ?,
+			resolved_by = null,
+			resolved_at = null,
+			note = null
+		where
+			id = ?
+			and status = 'RESOLVED';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, reason, lineId)
+	if updateTx.Error != nil {
+		log.Printf("failed to reopen statement line. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// GetLoansByNextInstallment lists the approved loans whose next pending installment is due for amount
+func (obj *reconciliationDb) GetLoansByNextInstallment(ctx context.Context, amount float64) ([]int64, error) {
+	query := `
+		select
+			l.id
+		from
+			loan l
+		inner join
+			installment i
+		on
+			i.loan_id = l.id
+		where
+			l.status = 'APPROVED'
+			and i.status = 'PENDING'
+			and i.installment_num = (
+				select min(p.installment_num) from installment p where p.loan_id = l.id and p.status = 'PENDING'
+			)
+			and abs(i.amount_due - ?) < ?
+		order by l.id;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, amount, AMOUNT_TOLERANCE).Rows()
+	if err != nil {
+		log.Printf("failed to fetch loans by installment. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	loanIds := make([]int64, 0)
+	for rows.Next() {
+		var loanId int64
+		if err := rows.Scan(&loanId); err != nil {
+			log.Printf("failed to scan loan id. Error:%s", err.Error())
+			return nil, err
+		}
+		loanIds = append(loanIds, loanId)
+	}
+	return loanIds, nil
+}
+
+const lineColumns = `id, statement_id, line_num, booked_on, amount, reference, counterparty, bank_reference, status, loan_id, match_rule, reason, resolved_by, resolved_at, note`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLine(row scanner, line *StatementLine) error {
+	return row.Scan(&line.LineId, &line.StatementId, &line.LineNum, &line.BookedOn, &line.Amount, &line.Reference, &line.Counterparty, &line.BankReference, &line.Status, &line.LoanId, &line.MatchRule, &line.Reason, &line.ResolvedBy, &line.ResolvedAt, &line.Note)
+}
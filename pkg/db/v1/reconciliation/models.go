@@ -0,0 +1,63 @@
+package reconciliation
+
+import "database/sql"
+
+// statement line status
+const (
+	RECEIVED  = "RECEIVED"
+	APPLIED   = "APPLIED"
+	EXCEPTION = "EXCEPTION"
+	RESOLVED  = "RESOLVED"
+	DISMISSED = "DISMISSED"
+)
+
+// rules matching a line to a loan
+const (
+	MATCH_REFERENCE = "REFERENCE"
+	MATCH_AMOUNT    = "AMOUNT"
+	MATCH_MANUAL    = "MANUAL"
+)
+
+// AMOUNT_TOLERANCE is how far apart two amounts can be and still be the same amount
+const AMOUNT_TOLERANCE = 0.005
+
+// BankStatement is an imported statement file, known by the checksum of its content. Lines counts the lines
+// of the statement by status
+type BankStatement struct {
+	StatementId sql.NullInt64
+	FileName    sql.NullString
+	Format      sql.NullString
+	Checksum    sql.NullString
+	ImportedBy  sql.NullInt64
+	ImportedAt  sql.NullTime
+	Lines       map[string]int64
+}
+
+// StatementLine is a credit of a bank statement. LoanId is the loan it was applied to, MatchRule says how the
+// loan was found and Reason why the line is an exception. ResolvedBy, ResolvedAt and Note record the admin
+// resolving an exception
+type StatementLine struct {
+	LineId        sql.NullInt64
+	StatementId   sql.NullInt64
+	LineNum       sql.NullInt64
+	BookedOn      sql.NullTime
+	Amount        sql.NullFloat64
+	Reference     sql.NullString
+	Counterparty  sql.NullString
+	BankReference sql.NullString
+	Status        sql.NullString
+	LoanId        sql.NullInt64
+	MatchRule     sql.NullString
+	Reason        sql.NullString
+	ResolvedBy    sql.NullInt64
+	ResolvedAt    sql.NullTime
+	Note          sql.NullString
+}
+
+// StatementLineFilter selects lines in id order. zero fields do not filter and AfterId pages through them
+type StatementLineFilter struct {
+	StatementId int64
+	Status      string
+	AfterId     int64
+	Limit       int
+}
@@ -0,0 +1,121 @@
+package reconciliation
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// AddBankStatement records a statement with its lines in one transaction and returns the lines recorded with
+// their ids. it returns 0 when a statement with the same checksum was imported before, and skips a line whose
+// bank reference was imported before with another statement
+func (obj *reconciliationDb) AddBankStatement(ctx context.Context, statement BankStatement, lines []StatementLine) (int64, []StatementLine, error) {
+	statementQuery := `
+		insert into
+			bank_statement(file_name, format, checksum, imported_by, imported_at)
+		values
+			(?,?,?,?,?)
+		on conflict (checksum) do nothing
+		returning id;
+	`
+	lineQuery := `
+		insert into
+			statement_line(statement_id, line_num, booked_on, amount, reference, counterparty, bank_reference)
+		values
+			(?,?,?,?,?,?,?)
+		on conflict (bank_reference) do nothing
+		returning id;
+	`
+
+	tx := obj.dbObj.Begin()
+	var statementId sql.NullInt64
+	insertTx := tx.WithContext(ctx).Raw(statementQuery, statement.FileName.String, statement.Format.String, statement.Checksum.String, statement.ImportedBy, statement.ImportedAt.Time.UTC()).Scan(&statementId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add bank statement. Error: %s", insertTx.Error.Error())
+		tx.Rollback()
+		return 0, nil, insertTx.Error
+	}
+	if statementId.Int64 == 0 {
+		tx.Rollback()
+		return 0, nil, nil
+	}
+
+	added := make([]StatementLine, 0, len(lines))
+	for _, line := range lines {
+		var bookedOn sql.NullTime
+		if line.BookedOn.Valid {
+			bookedOn = sql.NullTime{Time: line.BookedOn.Time.UTC(), Valid: true}
+		}
+		var lineId sql.NullInt64
+		insertTx := tx.WithContext(ctx).Raw(lineQuery, statementId.Int64, line.LineNum.Int64, bookedOn, line.Amount.Float64, line.Reference, line.Counterparty, line.BankReference).Scan(&lineId)
+		if insertTx.Error != nil {
+			log.Printf("failed to add statement line. Error: %s", insertTx.Error.Error())
+			tx.Rollback()
+			return 0, nil, insertTx.Error
+		}
+		if lineId.Int64 == 0 {
+			continue
+		}
+		line.LineId = lineId
+		line.StatementId = statementId
+		line.Status = sql.NullString{String: RECEIVED, Valid: true}
+		added = append(added, line)
+	}
+	if err := tx.Commit().Error; err != nil {
+		log.Printf("failed to commit bank statement. Error: %s", err.Error())
+		return 0, nil, err
+	}
+	return statementId.Int64, added, nil
+}
+
+// GetBankStatements lists the imported statements, latest first, with their lines counted by status
+func (obj *reconciliationDb) GetBankStatements(ctx context.Context) ([]BankStatement, error) {
+	query := `
+		select
+			s.id,
+			s.file_name,
+			s.format,
+			s.checksum,
+			s.imported_by,
+			s.imported_at,
+			l.status,
+			count(l.id)
+		from
+			bank_statement s
+		left join
+			statement_line l
+		on
+			l.statement_id = s.id
+		group by s.id, s.file_name, s.format, s.checksum, s.imported_by, s.imported_at, l.status
+		order by s.id desc;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		log.Printf("failed to fetch bank statements. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	statements := make([]BankStatement, 0)
+	for rows.Next() {
+		var (
+			statement BankStatement
+			status    sql.NullString
+			count     int64
+		)
+		if err := rows.Scan(&statement.StatementId, &statement.FileName, &statement.Format, &statement.Checksum, &statement.ImportedBy, &statement.ImportedAt, &status, &count); err != nil {
+			log.Printf("failed to scan bank statement. Error:%s", err.Error())
+			return nil, err
+		}
+		last := len(statements) - 1
+		if last < 0 || statements[last].StatementId.Int64 != statement.StatementId.Int64 {
+			statement.Lines = make(map[string]int64)
+			statements = append(statements, statement)
+			last++
+		}
+		if status.Valid {
+			statements[last].Lines[status.String] = count
+		}
+	}
+	return statements, nil
+}
@@ -1,7 +1,10 @@
 package usermanagement
 
 import (
-	"github.com/gin-gonic/gin"
+	"context"
+	"strings"
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -10,8 +13,66 @@ type userMgtDb struct {
 }
 
 type DbUserManagementInterface interface {
-	AddUser(*gin.Context, UserDetails) (int64, error)
-	GetUserByUsername(*gin.Context, string) (UserDetails, error)
+	AddUser(context.Context, UserDetails) (int64, error)
+	GetUserByUsername(context.Context, string) (UserDetails, error)
+	GetUserById(context.Context, int64) (UserDetails, error)
+	CountUsersByType(context.Context, string) (int64, error)
+	AddFirstUserOfType(context.Context, UserDetails) (int64, error)
+
+	UpdateUserProfile(context.Context, int64, []ProfileChange) error
+	GetProfileHistory(context.Context, int64) ([]ProfileChange, error)
+	GetLatestVerifiedIncome(context.Context, int64) (float64, error)
+
+	AddContactVerification(context.Context, ContactVerification) (int64, error)
+	GetContactVerification(context.Context, int64, int64) (ContactVerification, error)
+	IncrementContactVerificationAttempts(context.Context, int64, int64) (bool, error)
+	CompleteContactVerification(context.Context, ContactVerification) error
+
+	GetTokenVersion(context.Context, int64) (int64, error)
+	UpdatePassword(context.Context, int64, string) (int64, error)
+	AddPasswordResetToken(context.Context, PasswordResetToken) (int64, error)
+	ResetPassword(context.Context, string, string) (int64, error)
+
+	AddRefreshToken(context.Context, RefreshToken) (int64, error)
+	GetRefreshToken(context.Context, string) (RefreshToken, error)
+	RotateRefreshToken(context.Context, int64, RefreshToken) (bool, error)
+	RevokeRefreshTokenFamily(context.Context, string) error
+	RevokeAccessToken(context.Context, string, int64, time.Time) error
+	IsAccessTokenRevoked(context.Context, string) (bool, error)
+
+	AddAdminInvite(context.Context, AdminInvite) (int64, error)
+	RedeemAdminInvite(context.Context, string, UserDetails) (int64, error)
+
+	GetUserRoles(context.Context, int64) ([]string, error)
+	GetRolePermissions(context.Context, []string) ([]string, error)
+	GetRoles(context.Context) ([]Role, error)
+	SetUserRoles(context.Context, int64, []string) (int64, error)
+
+	GetLoginThrottles(context.Context, []string) ([]LoginThrottle, error)
+	AddLoginFailure(context.Context, string, time.Time, time.Time) (LoginThrottle, error)
+	LockLogin(context.Context, string, time.Time) error
+	ClearLoginFailures(context.Context, []string) (int64, error)
+
+	SaveTotpSecret(context.Context, int64, string) (bool, error)
+	GetTotp(context.Context, int64) (UserTotp, error)
+	EnableTotp(context.Context, int64, int64, []string) error
+	UseTotpStep(context.Context, int64, int64) (bool, error)
+	UseRecoveryCode(context.Context, int64, string) (bool, error)
+	AddLoginChallenge(context.Context, LoginChallenge) (int64, error)
+	GetLoginChallenge(context.Context, string) (LoginChallenge, error)
+	IncrementLoginChallengeAttempts(context.Context, int64) error
+	CompleteLoginChallenge(context.Context, int64) (bool, error)
+
+	GetSigningKeys(context.Context) ([]SigningKey, error)
+	AddSigningKey(context.Context, SigningKey, time.Time) (bool, error)
+
+	AddServiceAccount(context.Context, ServiceAccount) (int64, error)
+	GetServiceAccounts(context.Context) ([]ServiceAccount, error)
+	AddAPIKey(context.Context, APIKey) (int64, error)
+	GetAPIKeys(context.Context) ([]APIKey, error)
+	GetAPIKey(context.Context, string) (APIKey, error)
+	RevokeAPIKey(context.Context, int64) (bool, error)
+	TouchAPIKey(context.Context, int64, time.Time, time.Time) error
 }
 
 func NewLoanDbObject(db *gorm.DB) DbUserManagementInterface {
@@ -19,3 +80,8 @@ func NewLoanDbObject(db *gorm.DB) DbUserManagementInterface {
 		dbObj: db,
 	}
 }
+
+// IsUniqueViolation reports whether the insert failed on a unique constraint. postgres reports the SQLSTATE, sqlite only the message
+func IsUniqueViolation(err error) bool {
+	return err != nil && (strings.Contains(err.Error(), "SQLSTATE 23505") || strings.Contains(err.Error(), "UNIQUE constraint failed"))
+}
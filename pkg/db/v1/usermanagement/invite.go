@@ -0,0 +1,77 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+func (obj *userMgtDb) AddAdminInvite(ctx context.Context, invite AdminInvite) (int64, error) {
+	query := `
+		insert into
+			admin_invite(code_hash,email,created_by,expires_at)
+		values
+			(?,?,?,?)
+		returning id;
+	`
+
+	var inviteId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, invite.CodeHash.String, invite.Email.String, invite.CreatedBy.Int64, invite.ExpiresAt.Time).Scan(&inviteId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add admin invite. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return inviteId.Int64, nil
+}
+
+// RedeemAdminInvite consumes an unused and unexpired invite issued for the email of the user and creates the ADMIN account.
+// returns 0 when the invite is not usable
+func (obj *userMgtDb) RedeemAdminInvite(ctx context.Context, codeHash string, userDetail UserDetails) (int64, error) {
+	consumeQuery := `
+		update
+			admin_invite
+		set
+			used_at = CURRENT_TIMESTAMP
+		where
+			code_hash = ?
+			and email = ?
+			and used_at is null
+			and expires_at > CURRENT_TIMESTAMP
+		returning id;
+	`
+
+	var inviteId sql.NullInt64
+	tx := obj.dbObj.Begin()
+	consumeTx := tx.WithContext(ctx).Raw(consumeQuery, codeHash, userDetail.Email.String).Scan(&inviteId)
+	if consumeTx.Error != nil {
+		log.Printf("failed to consume admin invite. Error: %s", consumeTx.Error.Error())
+		tx.Rollback()
+		return 0, consumeTx.Error
+	}
+	if inviteId.Int64 == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+
+	userId, err := insertUser(ctx, tx, userDetail)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	usedByQuery := `
+		update
+			admin_invite
+		set
+			used_by = ?
+		where
+			id = ?;
+	`
+	usedByTx := tx.WithContext(ctx).Exec(usedByQuery, userId, inviteId.Int64)
+	if usedByTx.Error != nil {
+		log.Printf("failed to update admin invite. Error: %s", usedByTx.Error.Error())
+		tx.Rollback()
+		return 0, usedByTx.Error
+	}
+	return userId, tx.Commit().Error
+}
@@ -0,0 +1,70 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+func (obj *userMgtDb) GetSigningKeys(ctx context.Context) ([]SigningKey, error) {
+	query := `
+		select
+			kid,
+			algorithm,
+			private_key,
+			created_at
+		from
+			signing_key
+		order by
+			created_at desc;
+	`
+
+	keys := make([]SigningKey, 0)
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		log.Printf("failed to fetch signing keys. Error: %s", err.Error())
+		return keys, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key SigningKey
+		err := rows.Scan(&key.KeyId, &key.Algorithm, &key.PrivateKey, &key.CreatedAt)
+		if err != nil {
+			log.Printf("failed to scan signing key. Error:%s", err.Error())
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// AddSigningKey stores the key unless a key of the same algorithm was created after rotateBefore,
+// so instances rotating at the same time add a single key
+func (obj *userMgtDb) AddSigningKey(ctx context.Context, key SigningKey, rotateBefore time.Time) (bool, error) {
+	query := `
+		insert into
+			signing_key(kid,algorithm,private_key,created_at)
+		select
+			?,?,?,?
+		where
+			not exists (
+				select
+					1
+				from
+					signing_key
+				where
+					algorithm = ?
+					and created_at > ?
+			)
+		returning kid;
+	`
+
+	var keyId sql.NullString
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, key.KeyId.String, key.Algorithm.String, key.PrivateKey.String, key.CreatedAt.Time, key.Algorithm.String, rotateBefore).Scan(&keyId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add signing key. Error: %s", insertTx.Error.Error())
+		return false, insertTx.Error
+	}
+	return keyId.Valid, nil
+}
@@ -0,0 +1,104 @@
+package usermanagement
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+func (obj *userMgtDb) GetLoginThrottles(ctx context.Context, subjects []string) ([]LoginThrottle, error) {
+	query := `
+		select
+			subject,
+			failures,
+			last_failure_at,
+			locked_until
+		from
+			login_throttle
+		where
+			subject in ?;
+	`
+
+	throttles := make([]LoginThrottle, 0)
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, subjects).Rows()
+	if err != nil {
+		log.Printf("failed to fetch login throttles. Error: %s", err.Error())
+		return throttles, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var throttle LoginThrottle
+		err := rows.Scan(&throttle.Subject, &throttle.Failures, &throttle.LastFailureAt, &throttle.LockedUntil)
+		if err != nil {
+			log.Printf("failed to scan login throttle. Error:%s", err.Error())
+			return throttles, err
+		}
+		throttles = append(throttles, throttle)
+	}
+	return throttles, nil
+}
+
+// AddLoginFailure counts a failed login against the subject. failures older than windowStart are forgotten
+func (obj *userMgtDb) AddLoginFailure(ctx context.Context, subject string, failedAt time.Time, windowStart time.Time) (LoginThrottle, error) {
+	query := `
+		insert into
+			login_throttle(subject,failures,last_failure_at)
+		values
+			(?,1,?)
+		on conflict (subject) do update set
+			failures = case when login_throttle.last_failure_at < ? then 1 else login_throttle.failures + 1 end,
+			last_failure_at = excluded.last_failure_at
+		returning subject, failures, last_failure_at, locked_until;
+	`
+
+	var throttle LoginThrottle
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, subject, failedAt, windowStart).Rows()
+	if err != nil {
+		log.Printf("failed to add login failure. Error: %s", err.Error())
+		return throttle, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&throttle.Subject, &throttle.Failures, &throttle.LastFailureAt, &throttle.LockedUntil)
+		if err != nil {
+			log.Printf("failed to scan login throttle. Error:%s", err.Error())
+			return throttle, err
+		}
+	}
+	return throttle, nil
+}
+
+// LockLogin blocks the subject until the given time. the failure count starts over once the lock ends
+func (obj *userMgtDb) LockLogin(ctx context.Context, subject string, lockedUntil time.Time) error {
+	query := `
+		update
+			login_throttle
+		set
+			failures = 0,
+			locked_until = ?
+		where
+			subject = ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, lockedUntil, subject)
+	if updateTx.Error != nil {
+		log.Printf("failed to lock login. Error: %s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// ClearLoginFailures removes failure counts and locks of the subjects. returns the number of subjects cleared
+func (obj *userMgtDb) ClearLoginFailures(ctx context.Context, subjects []string) (int64, error) {
+	query := `
+		delete from
+			login_throttle
+		where
+			subject in ?;
+	`
+	deleteTx := obj.dbObj.WithContext(ctx).Exec(query, subjects)
+	if deleteTx.Error != nil {
+		log.Printf("failed to clear login failures. Error: %s", deleteTx.Error.Error())
+		return 0, deleteTx.Error
+	}
+	return deleteTx.RowsAffected, nil
+}
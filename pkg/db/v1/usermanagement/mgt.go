@@ -1,13 +1,58 @@
 package usermanagement
 
 import (
+	"context"
 	"database/sql"
 	"log"
 
-	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-func (obj *userMgtDb) AddUser(c *gin.Context, userDetail UserDetails) (int64, error) {
+func (obj *userMgtDb) AddUser(ctx context.Context, userDetail UserDetails) (int64, error) {
+	tx := obj.dbObj.Begin()
+	userId, err := insertUser(ctx, tx, userDetail)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return userId, tx.Commit().Error
+}
+
+// FIRST_USER_LOCK_ID is the postgres advisory lock taken by AddFirstUserOfType
+const FIRST_USER_LOCK_ID = 41572024
+
+// AddFirstUserOfType adds the user only while no user of its type exists and returns 0 otherwise. the count and the
+// insert run in one transaction. postgres takes an advisory lock for it, as there is no row to lock before the first
+// user exists, and sqlite lets one transaction write at a time, so two of them at once add a single user
+func (obj *userMgtDb) AddFirstUserOfType(ctx context.Context, userDetail UserDetails) (int64, error) {
+	tx := obj.dbObj.Begin()
+	if tx.Dialector.Name() == "postgres" {
+		lockTx := tx.WithContext(ctx).Exec("select pg_advisory_xact_lock(?)", FIRST_USER_LOCK_ID)
+		if lockTx.Error != nil {
+			log.Printf("failed to take first user lock. Error: %s", lockTx.Error.Error())
+			tx.Rollback()
+			return 0, lockTx.Error
+		}
+	}
+	count, err := countUsersByType(ctx, tx, userDetail.UserType.String)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	if count > 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+	userId, err := insertUser(ctx, tx, userDetail)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return userId, tx.Commit().Error
+}
+
+// insertUser adds the user along with the salary declared at signup as the first unverified income and the default role
+func insertUser(ctx context.Context, tx *gorm.DB, userDetail UserDetails) (int64, error) {
 	query := `
 		insert into
 			user_detail(user_name,password,user_type,email,mobile,monthly_salary,acc_bal)
@@ -17,16 +62,67 @@ func (obj *userMgtDb) AddUser(c *gin.Context, userDetail UserDetails) (int64, er
 	`
 
 	var userId sql.NullInt64
-	insertTx := obj.dbObj.WithContext(c).Raw(query, userDetail.UserName.String, userDetail.UserPassword.String, userDetail.UserType.String, userDetail.Email.String, userDetail.Mobile.String, userDetail.MonthlySalary.Float64, userDetail.AccountBalance.Float64).Scan(&userId)
+	insertTx := tx.WithContext(ctx).Raw(query, userDetail.UserName.String, userDetail.UserPassword.String, userDetail.UserType.String, userDetail.Email.String, userDetail.Mobile.String, userDetail.MonthlySalary.Float64, userDetail.AccountBalance.Float64).Scan(&userId)
 	if insertTx.Error != nil {
 		log.Println("error in adding user")
 		return 0, insertTx.Error
 	}
 
+	incomeQuery := `
+		insert into
+			user_income(user_id,monthly_salary)
+		values
+			(?,?);
+	`
+	incomeTx := tx.WithContext(ctx).Exec(incomeQuery, userId.Int64, userDetail.MonthlySalary.Float64)
+	if incomeTx.Error != nil {
+		log.Println("error in adding user income")
+		return 0, incomeTx.Error
+	}
+
+	//every user starts with the role of the same name as the user type
+	roleQuery := `
+		insert into
+			user_role(user_id,role_id)
+		select
+			?, id
+		from
+			role
+		where
+			name = ?;
+	`
+	roleTx := tx.WithContext(ctx).Exec(roleQuery, userId.Int64, userDetail.UserType.String)
+	if roleTx.Error != nil {
+		log.Println("error in adding user role")
+		return 0, roleTx.Error
+	}
 	return userId.Int64, nil
 }
 
-func (obj *userMgtDb) GetUserByUsername(c *gin.Context, userName string) (UserDetails, error) {
+func (obj *userMgtDb) CountUsersByType(ctx context.Context, userType string) (int64, error) {
+	return countUsersByType(ctx, obj.dbObj, userType)
+}
+
+func countUsersByType(ctx context.Context, tx *gorm.DB, userType string) (int64, error) {
+	query := `
+		select
+			count(1)
+		from
+			user_detail
+		where
+			user_type = ?;
+	`
+
+	var count sql.NullInt64
+	fetchTx := tx.WithContext(ctx).Raw(query, userType).Scan(&count)
+	if fetchTx.Error != nil {
+		log.Printf("failed to count users. Error: %s", fetchTx.Error.Error())
+		return 0, fetchTx.Error
+	}
+	return count.Int64, nil
+}
+
+func (obj *userMgtDb) GetUserByUsername(ctx context.Context, userName string) (UserDetails, error) {
 	query := `
 		select 
 			id, 
@@ -37,6 +133,7 @@ func (obj *userMgtDb) GetUserByUsername(c *gin.Context, userName string) (UserDe
 			mobile, 
 			monthly_salary, 
 			acc_bal, 
+			token_version,
 			created_at
 		from
 			user_detail
@@ -45,13 +142,51 @@ func (obj *userMgtDb) GetUserByUsername(c *gin.Context, userName string) (UserDe
 	`
 
 	var userDetail UserDetails
-	rows, err := obj.dbObj.WithContext(c).Raw(query, userName).Rows()
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userName).Rows()
+	if err != nil {
+		log.Println("failed to fetch user detail")
+		return userDetail, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&userDetail.UserId, &userDetail.UserName, &userDetail.UserPassword, &userDetail.UserType, &userDetail.Email, &userDetail.Mobile, &userDetail.MonthlySalary, &userDetail.AccountBalance, &userDetail.TokenVersion, &userDetail.CreatedAt)
+		if err != nil {
+			log.Println("failed to scan user detail")
+			return userDetail, err
+		}
+	}
+	return userDetail, nil
+}
+
+func (obj *userMgtDb) GetUserById(ctx context.Context, userId int64) (UserDetails, error) {
+	query := `
+		select 
+			id, 
+			user_name, 
+			password, 
+			user_type, 
+			email, 
+			mobile, 
+			monthly_salary, 
+			acc_bal, 
+			token_version,
+			created_at,
+			updated_at
+		from
+			user_detail
+		where
+			id=?;
+	`
+
+	var userDetail UserDetails
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId).Rows()
 	if err != nil {
 		log.Println("failed to fetch user detail")
 		return userDetail, err
 	}
+	defer rows.Close()
 	for rows.Next() {
-		err := rows.Scan(&userDetail.UserId, &userDetail.UserName, &userDetail.UserPassword, &userDetail.UserType, &userDetail.Email, &userDetail.Mobile, &userDetail.MonthlySalary, &userDetail.AccountBalance, &userDetail.CreatedAt)
+		err := rows.Scan(&userDetail.UserId, &userDetail.UserName, &userDetail.UserPassword, &userDetail.UserType, &userDetail.Email, &userDetail.Mobile, &userDetail.MonthlySalary, &userDetail.AccountBalance, &userDetail.TokenVersion, &userDetail.CreatedAt, &userDetail.UpdatedAt)
 		if err != nil {
 			log.Println("failed to scan user detail")
 			return userDetail, err
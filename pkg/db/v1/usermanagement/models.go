@@ -11,6 +11,122 @@ type UserDetails struct {
 	Mobile         sql.NullString
 	MonthlySalary  sql.NullFloat64
 	AccountBalance sql.NullFloat64
+	TokenVersion   sql.NullInt64
 	CreatedAt      sql.NullTime
 	UpdatedAt      sql.NullTime
 }
+
+type ProfileChange struct {
+	ChangeId  sql.NullInt64
+	UserId    sql.NullInt64
+	Field     sql.NullString
+	OldValue  sql.NullString
+	NewValue  sql.NullString
+	ChangedBy sql.NullInt64
+	CreatedAt sql.NullTime
+}
+
+type ContactVerification struct {
+	VerificationId sql.NullInt64
+	UserId         sql.NullInt64
+	Channel        sql.NullString
+	NewValue       sql.NullString
+	OtpHash        sql.NullString
+	Attempts       sql.NullInt64
+	Status         sql.NullString
+	ExpiresAt      sql.NullTime
+	CreatedAt      sql.NullTime
+	VerifiedAt     sql.NullTime
+}
+
+type PasswordResetToken struct {
+	TokenId   sql.NullInt64
+	UserId    sql.NullInt64
+	TokenHash sql.NullString
+	ExpiresAt sql.NullTime
+	UsedAt    sql.NullTime
+	CreatedAt sql.NullTime
+}
+
+type RefreshToken struct {
+	TokenId   sql.NullInt64
+	UserId    sql.NullInt64
+	TokenHash sql.NullString
+	FamilyId  sql.NullString
+	Status    sql.NullString
+	ExpiresAt sql.NullTime
+	RotatedAt sql.NullTime
+	CreatedAt sql.NullTime
+}
+
+type AdminInvite struct {
+	InviteId  sql.NullInt64
+	CodeHash  sql.NullString
+	Email     sql.NullString
+	CreatedBy sql.NullInt64
+	ExpiresAt sql.NullTime
+	UsedBy    sql.NullInt64
+	UsedAt    sql.NullTime
+	CreatedAt sql.NullTime
+}
+
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+type LoginThrottle struct {
+	Subject       sql.NullString
+	Failures      sql.NullInt64
+	LastFailureAt sql.NullTime
+	LockedUntil   sql.NullTime
+}
+
+type UserTotp struct {
+	UserId       sql.NullInt64
+	Secret       sql.NullString
+	Enabled      sql.NullBool
+	LastUsedStep sql.NullInt64
+	CreatedAt    sql.NullTime
+	EnabledAt    sql.NullTime
+}
+
+type LoginChallenge struct {
+	ChallengeId sql.NullInt64
+	UserId      sql.NullInt64
+	TokenHash   sql.NullString
+	ExpiresAt   sql.NullTime
+	Attempts    sql.NullInt64
+	UsedAt      sql.NullTime
+	CreatedAt   sql.NullTime
+}
+
+type SigningKey struct {
+	KeyId      sql.NullString
+	Algorithm  sql.NullString
+	PrivateKey sql.NullString
+	CreatedAt  sql.NullTime
+}
+
+type ServiceAccount struct {
+	ServiceAccountId sql.NullInt64
+	Name             sql.NullString
+	Description      sql.NullString
+	CreatedBy        sql.NullInt64
+	CreatedAt        sql.NullTime
+}
+
+type APIKey struct {
+	KeyId            sql.NullInt64
+	ServiceAccountId sql.NullInt64
+	ServiceAccount   sql.NullString
+	Prefix           sql.NullString
+	KeyHash          sql.NullString
+	Scopes           []string
+	ExpiresAt        sql.NullTime
+	LastUsedAt       sql.NullTime
+	RevokedAt        sql.NullTime
+	CreatedBy        sql.NullInt64
+	CreatedAt        sql.NullTime
+}
@@ -0,0 +1,162 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// GetTokenVersion returns the version every JWT of the user must carry. it is bumped whenever the password changes
+func (obj *userMgtDb) GetTokenVersion(ctx context.Context, userId int64) (int64, error) {
+	query := `
+		select
+			token_version
+		from
+			user_detail
+		where
+			id = ?;
+	`
+
+	var version sql.NullInt64
+	fetchTx := obj.dbObj.WithContext(ctx).Raw(query, userId).Scan(&version)
+	if fetchTx.Error != nil {
+		log.Printf("failed to fetch token version. Error: %s", fetchTx.Error.Error())
+		return 0, fetchTx.Error
+	}
+	return version.Int64, nil
+}
+
+// UpdatePassword stores the new password hash and returns the new token version, invalidating all issued JWTs and refresh tokens
+func (obj *userMgtDb) UpdatePassword(ctx context.Context, userId int64, passwordHash string) (int64, error) {
+	query := `
+		update
+			user_detail
+		set
+			password = ?,
+			token_version = token_version + 1
+		where
+			id = ?
+		returning token_version;
+	`
+
+	var version sql.NullInt64
+	tx := obj.dbObj.Begin()
+	updateTx := tx.WithContext(ctx).Raw(query, passwordHash, userId).Scan(&version)
+	if updateTx.Error != nil {
+		log.Printf("failed to update password. Error: %s", updateTx.Error.Error())
+		tx.Rollback()
+		return 0, updateTx.Error
+	}
+
+	if err := revokeUserRefreshTokens(ctx, tx, userId); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return version.Int64, tx.Commit().Error
+}
+
+// revokeUserRefreshTokens ends every refresh token family of the user
+func revokeUserRefreshTokens(ctx context.Context, tx *gorm.DB, userId int64) error {
+	query := `
+		update
+			refresh_token
+		set
+			status = 'REVOKED'
+		where
+			user_id = ?
+			and status = 'ACTIVE';
+	`
+	revokeTx := tx.WithContext(ctx).Exec(query, userId)
+	if revokeTx.Error != nil {
+		log.Printf("failed to revoke refresh tokens. Error: %s", revokeTx.Error.Error())
+		return revokeTx.Error
+	}
+	return nil
+}
+
+func (obj *userMgtDb) AddPasswordResetToken(ctx context.Context, token PasswordResetToken) (int64, error) {
+	query := `
+		insert into
+			password_reset(user_id,token_hash,expires_at)
+		values
+			(?,?,?)
+		returning id;
+	`
+
+	var tokenId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, token.UserId.Int64, token.TokenHash.String, token.ExpiresAt.Time).Scan(&tokenId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add password reset token. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return tokenId.Int64, nil
+}
+
+// ResetPassword consumes an unused and unexpired reset token and sets the new password. returns 0 when the token is not usable
+func (obj *userMgtDb) ResetPassword(ctx context.Context, tokenHash string, passwordHash string) (int64, error) {
+	consumeQuery := `
+		update
+			password_reset
+		set
+			used_at = CURRENT_TIMESTAMP
+		where
+			token_hash = ?
+			and used_at is null
+			and expires_at > CURRENT_TIMESTAMP
+		returning user_id;
+	`
+
+	var userId sql.NullInt64
+	tx := obj.dbObj.Begin()
+	consumeTx := tx.WithContext(ctx).Raw(consumeQuery, tokenHash).Scan(&userId)
+	if consumeTx.Error != nil {
+		log.Printf("failed to consume password reset token. Error: %s", consumeTx.Error.Error())
+		tx.Rollback()
+		return 0, consumeTx.Error
+	}
+	if userId.Int64 == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+
+	//any other outstanding token for the user is void once the password is reset
+	revokeQuery := `
+		update
+			password_reset
+		set
+			used_at = CURRENT_TIMESTAMP
+		where
+			user_id = ?
+			and used_at is null;
+	`
+	revokeTx := tx.WithContext(ctx).Exec(revokeQuery, userId.Int64)
+	if revokeTx.Error != nil {
+		log.Printf("failed to revoke password reset tokens. Error: %s", revokeTx.Error.Error())
+		tx.Rollback()
+		return 0, revokeTx.Error
+	}
+
+	updateQuery := `
+		update
+			user_detail
+		set
+			password = ?,
+			token_version = token_version + 1
+		where
+			id = ?;
+	`
+	updateTx := tx.WithContext(ctx).Exec(updateQuery, passwordHash, userId.Int64)
+	if updateTx.Error != nil {
+		log.Printf("failed to reset password. Error: %s", updateTx.Error.Error())
+		tx.Rollback()
+		return 0, updateTx.Error
+	}
+
+	if err := revokeUserRefreshTokens(ctx, tx, userId.Int64); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return userId.Int64, tx.Commit().Error
+}
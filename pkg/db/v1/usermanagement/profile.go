@@ -0,0 +1,282 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// profileColumns maps the editable profile fields to their user_detail columns
+var profileColumns = map[string]string{
+	"EMAIL":        "email",
+	"MOBILE":       "mobile",
+	"SALARY":       "monthly_salary",
+	"BANK_BALANCE": "acc_bal",
+}
+
+// UpdateUserProfile applies the changes to user_detail and records each of them in the profile history
+func (obj *userMgtDb) UpdateUserProfile(ctx context.Context, userId int64, changes []ProfileChange) error {
+	tx := obj.dbObj.Begin()
+	for _, change := range changes {
+		if err := applyProfileChange(ctx, tx, userId, change); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit().Error
+}
+
+func applyProfileChange(ctx context.Context, tx *gorm.DB, userId int64, change ProfileChange) error {
+	column, ok := profileColumns[change.Field.String]
+	if !ok {
+		return fmt.Errorf("profile field %s cannot be updated", change.Field.String)
+	}
+
+	updateQuery := fmt.Sprintf(`
+		update
+			user_detail
+		set
+			%s = ?
+		where
+			id = ?;
+	`, column)
+	updateTx := tx.WithContext(ctx).Exec(updateQuery, change.NewValue.String, userId)
+	if updateTx.Error != nil {
+		log.Printf("failed to update user profile. Error: %s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+
+	historyQuery := `
+		insert into
+			user_profile_history(user_id,field,old_value,new_value,changed_by)
+		values
+			(?,?,?,?,?);
+	`
+	historyTx := tx.WithContext(ctx).Exec(historyQuery, userId, change.Field.String, change.OldValue.String, change.NewValue.String, change.ChangedBy.Int64)
+	if historyTx.Error != nil {
+		log.Printf("failed to add profile history. Error: %s", historyTx.Error.Error())
+		return historyTx.Error
+	}
+
+	//a changed salary is a new self declared income which stays unverified until an income proof is verified
+	if change.Field.String == "SALARY" {
+		incomeQuery := `
+			insert into
+				user_income(user_id,monthly_salary)
+			values
+				(?,?);
+		`
+		incomeTx := tx.WithContext(ctx).Exec(incomeQuery, userId, change.NewValue.String)
+		if incomeTx.Error != nil {
+			log.Printf("failed to add user income. Error: %s", incomeTx.Error.Error())
+			return incomeTx.Error
+		}
+	}
+	return nil
+}
+
+func (obj *userMgtDb) GetProfileHistory(ctx context.Context, userId int64) ([]ProfileChange, error) {
+	query := `
+		select
+			id,
+			user_id,
+			field,
+			old_value,
+			new_value,
+			changed_by,
+			created_at
+		from
+			user_profile_history
+		where
+			user_id = ?
+		order by id desc;
+	`
+
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId).Rows()
+	if err != nil {
+		log.Printf("failed to fetch profile history. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := make([]ProfileChange, 0)
+	for rows.Next() {
+		var change ProfileChange
+		err := rows.Scan(&change.ChangeId, &change.UserId, &change.Field, &change.OldValue, &change.NewValue, &change.ChangedBy, &change.CreatedAt)
+		if err != nil {
+			log.Printf("failed to scan profile history. Error:%s", err.Error())
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// GetLatestVerifiedIncome returns the most recently declared monthly salary which has been verified. 0 when none is verified
+func (obj *userMgtDb) GetLatestVerifiedIncome(ctx context.Context, userId int64) (float64, error) {
+	query := `
+		select
+			monthly_salary
+		from
+			user_income
+		where
+			user_id = ?
+			and verified = true
+		order by id desc
+		limit 1;
+	`
+
+	var income sql.NullFloat64
+	fetchTx := obj.dbObj.WithContext(ctx).Raw(query, userId).Scan(&income)
+	if fetchTx.Error != nil {
+		log.Printf("failed to fetch verified income. Error: %s", fetchTx.Error.Error())
+		return 0, fetchTx.Error
+	}
+	return income.Float64, nil
+}
+
+func (obj *userMgtDb) AddContactVerification(ctx context.Context, verification ContactVerification) (int64, error) {
+	query := `
+		insert into
+			contact_verification(user_id,channel,new_value,otp_hash,status,expires_at)
+		values
+			(?,?,?,?,'PENDING',?)
+		returning id;
+	`
+
+	var verificationId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, verification.UserId.Int64, verification.Channel.String, verification.NewValue.String, verification.OtpHash.String, verification.ExpiresAt.Time).Scan(&verificationId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add contact verification. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return verificationId.Int64, nil
+}
+
+func (obj *userMgtDb) GetContactVerification(ctx context.Context, verificationId int64, userId int64) (ContactVerification, error) {
+	query := `
+		select
+			id,
+			user_id,
+			channel,
+			new_value,
+			otp_hash,
+			attempts,
+			status,
+			expires_at,
+			created_at,
+			verified_at
+		from
+			contact_verification
+		where
+			id = ?
+			and user_id = ?;
+	`
+
+	var verification ContactVerification
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, verificationId, userId).Rows()
+	if err != nil {
+		log.Printf("failed to fetch contact verification. Error: %s", err.Error())
+		return verification, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&verification.VerificationId, &verification.UserId, &verification.Channel, &verification.NewValue, &verification.OtpHash, &verification.Attempts, &verification.Status, &verification.ExpiresAt, &verification.CreatedAt, &verification.VerifiedAt)
+		if err != nil {
+			log.Printf("failed to scan contact verification. Error:%s", err.Error())
+			return verification, err
+		}
+	}
+	return verification, nil
+}
+
+// IncrementContactVerificationAttempts counts an attempt at the otp of a pending verification and returns false when
+// maxAttempts were made already. the check and the count are one update, so attempts made at once cannot exceed it
+func (obj *userMgtDb) IncrementContactVerificationAttempts(ctx context.Context, verificationId int64, maxAttempts int64) (bool, error) {
+	query := `
+		update
+			contact_verification
+		set
+			attempts = attempts + 1
+		where
+			id = ?
+			and status = 'PENDING'
+			and attempts < ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, verificationId, maxAttempts)
+	if updateTx.Error != nil {
+		log.Printf("failed to update verification attempts. Error: %s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+// CompleteContactVerification marks the verification done and applies the verified contact to the profile in one transaction
+func (obj *userMgtDb) CompleteContactVerification(ctx context.Context, verification ContactVerification) error {
+	query := `
+		update
+			contact_verification
+		set
+			status = 'VERIFIED',
+			verified_at = CURRENT_TIMESTAMP
+		where
+			id = ?
+			and status = 'PENDING'
+		returning id;
+	`
+
+	var verifiedId sql.NullInt64
+	tx := obj.dbObj.Begin()
+	updateTx := tx.WithContext(ctx).Raw(query, verification.VerificationId.Int64).Scan(&verifiedId)
+	if updateTx.Error != nil {
+		log.Printf("failed to complete contact verification. Error: %s", updateTx.Error.Error())
+		tx.Rollback()
+		return updateTx.Error
+	}
+	if verifiedId.Int64 != verification.VerificationId.Int64 {
+		tx.Rollback()
+		return fmt.Errorf("contact verification is not pending")
+	}
+
+	//supersede any other pending change of the same contact
+	expireQuery := `
+		update
+			contact_verification
+		set
+			status = 'EXPIRED'
+		where
+			user_id = ?
+			and channel = ?
+			and status = 'PENDING';
+	`
+	expireTx := tx.WithContext(ctx).Exec(expireQuery, verification.UserId.Int64, verification.Channel.String)
+	if expireTx.Error != nil {
+		log.Printf("failed to expire pending verifications. Error: %s", expireTx.Error.Error())
+		tx.Rollback()
+		return expireTx.Error
+	}
+
+	var oldValue sql.NullString
+	column := profileColumns[verification.Channel.String]
+	selectTx := tx.WithContext(ctx).Raw(fmt.Sprintf("select %s from user_detail where id = ?;", column), verification.UserId.Int64).Scan(&oldValue)
+	if selectTx.Error != nil {
+		log.Printf("failed to fetch current contact. Error: %s", selectTx.Error.Error())
+		tx.Rollback()
+		return selectTx.Error
+	}
+
+	change := ProfileChange{
+		Field:     verification.Channel,
+		OldValue:  oldValue,
+		NewValue:  verification.NewValue,
+		ChangedBy: verification.UserId,
+	}
+	if err := applyProfileChange(ctx, tx, verification.UserId.Int64, change); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
@@ -0,0 +1,168 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+func (obj *userMgtDb) GetUserRoles(ctx context.Context, userId int64) ([]string, error) {
+	query := `
+		select
+			r.name
+		from
+			user_role ur
+			join role r on r.id = ur.role_id
+		where
+			ur.user_id = ?
+		order by
+			r.name;
+	`
+
+	roles := make([]string, 0)
+	fetchTx := obj.dbObj.WithContext(ctx).Raw(query, userId).Scan(&roles)
+	if fetchTx.Error != nil {
+		log.Printf("failed to fetch user roles. Error: %s", fetchTx.Error.Error())
+		return nil, fetchTx.Error
+	}
+	return roles, nil
+}
+
+// GetRolePermissions returns the union of the permissions granted to the roles
+func (obj *userMgtDb) GetRolePermissions(ctx context.Context, roles []string) ([]string, error) {
+	permissions := make([]string, 0)
+	if len(roles) == 0 {
+		return permissions, nil
+	}
+
+	query := `
+		select distinct
+			p.name
+		from
+			role r
+			join role_permission rp on rp.role_id = r.id
+			join permission p on p.id = rp.permission_id
+		where
+			r.name in ?;
+	`
+
+	fetchTx := obj.dbObj.WithContext(ctx).Raw(query, roles).Scan(&permissions)
+	if fetchTx.Error != nil {
+		log.Printf("failed to fetch role permissions. Error: %s", fetchTx.Error.Error())
+		return nil, fetchTx.Error
+	}
+	return permissions, nil
+}
+
+func (obj *userMgtDb) GetRoles(ctx context.Context) ([]Role, error) {
+	query := `
+		select
+			r.name,
+			r.description,
+			p.name
+		from
+			role r
+			left join role_permission rp on rp.role_id = r.id
+			left join permission p on p.id = rp.permission_id
+		order by
+			r.name, p.name;
+	`
+
+	roles := make([]Role, 0)
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		log.Printf("failed to fetch roles. Error: %s", err.Error())
+		return roles, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			name        string
+			description sql.NullString
+			permission  sql.NullString
+		)
+		err := rows.Scan(&name, &description, &permission)
+		if err != nil {
+			log.Printf("failed to scan role. Error:%s", err.Error())
+			return roles, err
+		}
+		if len(roles) == 0 || roles[len(roles)-1].Name != name {
+			roles = append(roles, Role{Name: name, Description: description.String, Permissions: make([]string, 0)})
+		}
+		if permission.Valid {
+			roles[len(roles)-1].Permissions = append(roles[len(roles)-1].Permissions, permission.String)
+		}
+	}
+	return roles, nil
+}
+
+// SetUserRoles replaces the roles of the user and invalidates the issued tokens so the new role set applies on the next login.
+// returns 0 when any of the roles does not exist. a role named twice is assigned once
+func (obj *userMgtDb) SetUserRoles(ctx context.Context, userId int64, roles []string) (int64, error) {
+	//the insert is counted against the distinct names, as a repeated name matches its role only once
+	distinct := make([]string, 0, len(roles))
+	named := make(map[string]bool)
+	for _, role := range roles {
+		if !named[role] {
+			named[role] = true
+			distinct = append(distinct, role)
+		}
+	}
+
+	deleteQuery := `
+		delete from
+			user_role
+		where
+			user_id = ?;
+	`
+
+	tx := obj.dbObj.Begin()
+	deleteTx := tx.WithContext(ctx).Exec(deleteQuery, userId)
+	if deleteTx.Error != nil {
+		log.Printf("failed to remove user roles. Error: %s", deleteTx.Error.Error())
+		tx.Rollback()
+		return 0, deleteTx.Error
+	}
+
+	insertQuery := `
+		insert into
+			user_role(user_id,role_id)
+		select
+			?, id
+		from
+			role
+		where
+			name in ?;
+	`
+	insertTx := tx.WithContext(ctx).Exec(insertQuery, userId, distinct)
+	if insertTx.Error != nil {
+		log.Printf("failed to add user roles. Error: %s", insertTx.Error.Error())
+		tx.Rollback()
+		return 0, insertTx.Error
+	}
+	if insertTx.RowsAffected != int64(len(distinct)) {
+		tx.Rollback()
+		return 0, nil
+	}
+
+	versionQuery := `
+		update
+			user_detail
+		set
+			token_version = token_version + 1
+		where
+			id = ?;
+	`
+	versionTx := tx.WithContext(ctx).Exec(versionQuery, userId)
+	if versionTx.Error != nil {
+		log.Printf("failed to update token version. Error: %s", versionTx.Error.Error())
+		tx.Rollback()
+		return 0, versionTx.Error
+	}
+
+	if err := revokeUserRefreshTokens(ctx, tx, userId); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+	return insertTx.RowsAffected, tx.Commit().Error
+}
@@ -0,0 +1,242 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+// AddServiceAccount returns 0 when a service account with the name already exists
+func (obj *userMgtDb) AddServiceAccount(ctx context.Context, account ServiceAccount) (int64, error) {
+	query := `
+		insert into
+			service_account(name,description,created_by)
+		values
+			(?,?,?)
+		on conflict (name) do nothing
+		returning id;
+	`
+
+	var accountId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, account.Name.String, account.Description.String, account.CreatedBy.Int64).Scan(&accountId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add service account. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return accountId.Int64, nil
+}
+
+func (obj *userMgtDb) GetServiceAccounts(ctx context.Context) ([]ServiceAccount, error) {
+	query := `
+		select
+			id,
+			name,
+			description,
+			created_by,
+			created_at
+		from
+			service_account
+		order by
+			name;
+	`
+
+	accounts := make([]ServiceAccount, 0)
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		log.Printf("failed to fetch service accounts. Error: %s", err.Error())
+		return accounts, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var account ServiceAccount
+		err := rows.Scan(&account.ServiceAccountId, &account.Name, &account.Description, &account.CreatedBy, &account.CreatedAt)
+		if err != nil {
+			log.Printf("failed to scan service account. Error:%s", err.Error())
+			return accounts, err
+		}
+		accounts = append(accounts, account)
+	}
+	return accounts, nil
+}
+
+// AddAPIKey stores the key with its scopes. returns 0 when the service account or any of the scopes does not exist
+func (obj *userMgtDb) AddAPIKey(ctx context.Context, key APIKey) (int64, error) {
+	insertQuery := `
+		insert into
+			api_key(service_account_id,prefix,key_hash,expires_at,created_by)
+		select
+			id,?,?,?,?
+		from
+			service_account
+		where
+			id = ?
+		returning id;
+	`
+
+	var keyId sql.NullInt64
+	tx := obj.dbObj.Begin()
+	insertTx := tx.WithContext(ctx).Raw(insertQuery, key.Prefix.String, key.KeyHash.String, key.ExpiresAt.Time, key.CreatedBy.Int64, key.ServiceAccountId.Int64).Scan(&keyId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add api key. Error: %s", insertTx.Error.Error())
+		tx.Rollback()
+		return 0, insertTx.Error
+	}
+	if keyId.Int64 == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+
+	scopeQuery := `
+		insert into
+			api_key_scope(api_key_id,permission_id)
+		select
+			?, id
+		from
+			permission
+		where
+			name in ?;
+	`
+	scopeTx := tx.WithContext(ctx).Exec(scopeQuery, keyId.Int64, key.Scopes)
+	if scopeTx.Error != nil {
+		log.Printf("failed to add api key scopes. Error: %s", scopeTx.Error.Error())
+		tx.Rollback()
+		return 0, scopeTx.Error
+	}
+	if scopeTx.RowsAffected != int64(len(key.Scopes)) {
+		tx.Rollback()
+		return 0, nil
+	}
+	return keyId.Int64, tx.Commit().Error
+}
+
+// GetAPIKeys lists the keys of every service account, without the key hashes
+func (obj *userMgtDb) GetAPIKeys(ctx context.Context) ([]APIKey, error) {
+	query := `
+		select
+			k.id,
+			k.service_account_id,
+			sa.name,
+			k.prefix,
+			k.expires_at,
+			k.last_used_at,
+			k.revoked_at,
+			k.created_by,
+			k.created_at,
+			p.name
+		from
+			api_key k
+			join service_account sa on sa.id = k.service_account_id
+			left join api_key_scope ks on ks.api_key_id = k.id
+			left join permission p on p.id = ks.permission_id
+		order by
+			k.id, p.name;
+	`
+
+	keys := make([]APIKey, 0)
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		log.Printf("failed to fetch api keys. Error: %s", err.Error())
+		return keys, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			key   APIKey
+			scope sql.NullString
+		)
+		err := rows.Scan(&key.KeyId, &key.ServiceAccountId, &key.ServiceAccount, &key.Prefix, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &key.CreatedBy, &key.CreatedAt, &scope)
+		if err != nil {
+			log.Printf("failed to scan api key. Error:%s", err.Error())
+			return keys, err
+		}
+		if len(keys) == 0 || keys[len(keys)-1].KeyId.Int64 != key.KeyId.Int64 {
+			key.Scopes = make([]string, 0)
+			keys = append(keys, key)
+		}
+		if scope.Valid {
+			keys[len(keys)-1].Scopes = append(keys[len(keys)-1].Scopes, scope.String)
+		}
+	}
+	return keys, nil
+}
+
+func (obj *userMgtDb) GetAPIKey(ctx context.Context, keyHash string) (APIKey, error) {
+	query := `
+		select
+			k.id,
+			k.service_account_id,
+			sa.name,
+			k.prefix,
+			k.key_hash,
+			k.expires_at,
+			k.last_used_at,
+			k.revoked_at,
+			p.name
+		from
+			api_key k
+			join service_account sa on sa.id = k.service_account_id
+			left join api_key_scope ks on ks.api_key_id = k.id
+			left join permission p on p.id = ks.permission_id
+		where
+			k.key_hash = ?;
+	`
+
+	key := APIKey{Scopes: make([]string, 0)}
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, keyHash).Rows()
+	if err != nil {
+		log.Printf("failed to fetch api key. Error: %s", err.Error())
+		return key, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var scope sql.NullString
+		err := rows.Scan(&key.KeyId, &key.ServiceAccountId, &key.ServiceAccount, &key.Prefix, &key.KeyHash, &key.ExpiresAt, &key.LastUsedAt, &key.RevokedAt, &scope)
+		if err != nil {
+			log.Printf("failed to scan api key. Error:%s", err.Error())
+			return key, err
+		}
+		if scope.Valid {
+			key.Scopes = append(key.Scopes, scope.String)
+		}
+	}
+	return key, nil
+}
+
+// RevokeAPIKey returns false when the key does not exist or was already revoked
+func (obj *userMgtDb) RevokeAPIKey(ctx context.Context, keyId int64) (bool, error) {
+	query := `
+		update
+			api_key
+		set
+			revoked_at = CURRENT_TIMESTAMP
+		where
+			id = ?
+			and revoked_at is null;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, keyId)
+	if updateTx.Error != nil {
+		log.Printf("failed to revoke api key. Error: %s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+// TouchAPIKey records the use of a key. uses after touchBefore are not written again to keep busy keys cheap
+func (obj *userMgtDb) TouchAPIKey(ctx context.Context, keyId int64, usedAt time.Time, touchBefore time.Time) error {
+	query := `
+		update
+			api_key
+		set
+			last_used_at = ?
+		where
+			id = ?
+			and (last_used_at is null or last_used_at < ?);
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, usedAt, keyId, touchBefore)
+	if updateTx.Error != nil {
+		log.Printf("failed to update api key usage. Error: %s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
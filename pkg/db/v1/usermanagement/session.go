@@ -0,0 +1,170 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+)
+
+func (obj *userMgtDb) AddRefreshToken(ctx context.Context, token RefreshToken) (int64, error) {
+	query := `
+		insert into
+			refresh_token(user_id,token_hash,family_id,status,expires_at)
+		values
+			(?,?,?,'ACTIVE',?)
+		returning id;
+	`
+
+	var tokenId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, token.UserId.Int64, token.TokenHash.String, token.FamilyId.String, token.ExpiresAt.Time).Scan(&tokenId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add refresh token. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return tokenId.Int64, nil
+}
+
+func (obj *userMgtDb) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	query := `
+		select
+			id,
+			user_id,
+			token_hash,
+			family_id,
+			status,
+			expires_at,
+			rotated_at,
+			created_at
+		from
+			refresh_token
+		where
+			token_hash = ?;
+	`
+
+	var token RefreshToken
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, tokenHash).Rows()
+	if err != nil {
+		log.Printf("failed to fetch refresh token. Error: %s", err.Error())
+		return token, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&token.TokenId, &token.UserId, &token.TokenHash, &token.FamilyId, &token.Status, &token.ExpiresAt, &token.RotatedAt, &token.CreatedAt)
+		if err != nil {
+			log.Printf("failed to scan refresh token. Error:%s", err.Error())
+			return token, err
+		}
+	}
+	return token, nil
+}
+
+// RotateRefreshToken retires an ACTIVE refresh token and stores its successor in the same family.
+// returns false when the old token was already rotated or revoked by a concurrent request
+func (obj *userMgtDb) RotateRefreshToken(ctx context.Context, oldTokenId int64, newToken RefreshToken) (bool, error) {
+	rotateQuery := `
+		update
+			refresh_token
+		set
+			status = 'ROTATED',
+			rotated_at = CURRENT_TIMESTAMP
+		where
+			id = ?
+			and status = 'ACTIVE'
+		returning id;
+	`
+
+	var rotatedId sql.NullInt64
+	tx := obj.dbObj.Begin()
+	rotateTx := tx.WithContext(ctx).Raw(rotateQuery, oldTokenId).Scan(&rotatedId)
+	if rotateTx.Error != nil {
+		log.Printf("failed to rotate refresh token. Error: %s", rotateTx.Error.Error())
+		tx.Rollback()
+		return false, rotateTx.Error
+	}
+	if rotatedId.Int64 != oldTokenId {
+		tx.Rollback()
+		return false, nil
+	}
+
+	insertQuery := `
+		insert into
+			refresh_token(user_id,token_hash,family_id,status,expires_at)
+		values
+			(?,?,?,'ACTIVE',?);
+	`
+	insertTx := tx.WithContext(ctx).Exec(insertQuery, newToken.UserId.Int64, newToken.TokenHash.String, newToken.FamilyId.String, newToken.ExpiresAt.Time)
+	if insertTx.Error != nil {
+		log.Printf("failed to add refresh token. Error: %s", insertTx.Error.Error())
+		tx.Rollback()
+		return false, insertTx.Error
+	}
+	return true, tx.Commit().Error
+}
+
+// RevokeRefreshTokenFamily revokes every token descending from the same login
+func (obj *userMgtDb) RevokeRefreshTokenFamily(ctx context.Context, familyId string) error {
+	query := `
+		update
+			refresh_token
+		set
+			status = 'REVOKED'
+		where
+			family_id = ?
+			and status = 'ACTIVE';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, familyId)
+	if updateTx.Error != nil {
+		log.Printf("failed to revoke refresh tokens. Error: %s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// RevokeAccessToken adds the jti to the revocation list until the token would have expired anyway
+func (obj *userMgtDb) RevokeAccessToken(ctx context.Context, tokenId string, userId int64, expiresAt time.Time) error {
+	query := `
+		insert into
+			revoked_token(jti,user_id,expires_at)
+		values
+			(?,?,?)
+		on conflict (jti) do nothing;
+	`
+	insertTx := obj.dbObj.WithContext(ctx).Exec(query, tokenId, userId, expiresAt)
+	if insertTx.Error != nil {
+		log.Printf("failed to revoke access token. Error: %s", insertTx.Error.Error())
+		return insertTx.Error
+	}
+
+	//entries past expiry can never match a valid token
+	cleanupQuery := `
+		delete from
+			revoked_token
+		where
+			expires_at < CURRENT_TIMESTAMP;
+	`
+	cleanupTx := obj.dbObj.WithContext(ctx).Exec(cleanupQuery)
+	if cleanupTx.Error != nil {
+		log.Printf("failed to clean up revoked tokens. Error: %s", cleanupTx.Error.Error())
+	}
+	return nil
+}
+
+func (obj *userMgtDb) IsAccessTokenRevoked(ctx context.Context, tokenId string) (bool, error) {
+	query := `
+		select
+			count(1)
+		from
+			revoked_token
+		where
+			jti = ?;
+	`
+
+	var count sql.NullInt64
+	fetchTx := obj.dbObj.WithContext(ctx).Raw(query, tokenId).Scan(&count)
+	if fetchTx.Error != nil {
+		log.Printf("failed to check revoked token. Error: %s", fetchTx.Error.Error())
+		return false, fetchTx.Error
+	}
+	return count.Int64 > 0, nil
+}
@@ -0,0 +1,237 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"log"
+)
+
+// SaveTotpSecret stores a pending secret for enrollment. returns false when totp is already enabled for the user
+func (obj *userMgtDb) SaveTotpSecret(ctx context.Context, userId int64, secret string) (bool, error) {
+	query := `
+		insert into
+			user_totp(user_id,secret)
+		values
+			(?,?)
+		on conflict (user_id) do update set
+			secret = excluded.secret,
+			created_at = CURRENT_TIMESTAMP
+		where
+			user_totp.enabled = false;
+	`
+	saveTx := obj.dbObj.WithContext(ctx).Exec(query, userId, secret)
+	if saveTx.Error != nil {
+		log.Printf("failed to save totp secret. Error: %s", saveTx.Error.Error())
+		return false, saveTx.Error
+	}
+	return saveTx.RowsAffected == 1, nil
+}
+
+func (obj *userMgtDb) GetTotp(ctx context.Context, userId int64) (UserTotp, error) {
+	query := `
+		select
+			user_id,
+			secret,
+			enabled,
+			last_used_step,
+			created_at,
+			enabled_at
+		from
+			user_totp
+		where
+			user_id = ?;
+	`
+
+	var totp UserTotp
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, userId).Rows()
+	if err != nil {
+		log.Printf("failed to fetch totp. Error: %s", err.Error())
+		return totp, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&totp.UserId, &totp.Secret, &totp.Enabled, &totp.LastUsedStep, &totp.CreatedAt, &totp.EnabledAt)
+		if err != nil {
+			log.Printf("failed to scan totp. Error:%s", err.Error())
+			return totp, err
+		}
+	}
+	return totp, nil
+}
+
+// EnableTotp activates the pending secret at the verified step and replaces the recovery codes of the user
+func (obj *userMgtDb) EnableTotp(ctx context.Context, userId int64, step int64, recoveryCodeHashes []string) error {
+	enableQuery := `
+		update
+			user_totp
+		set
+			enabled = true,
+			enabled_at = CURRENT_TIMESTAMP,
+			last_used_step = ?
+		where
+			user_id = ?;
+	`
+
+	tx := obj.dbObj.Begin()
+	enableTx := tx.WithContext(ctx).Exec(enableQuery, step, userId)
+	if enableTx.Error != nil {
+		log.Printf("failed to enable totp. Error: %s", enableTx.Error.Error())
+		tx.Rollback()
+		return enableTx.Error
+	}
+
+	deleteQuery := `
+		delete from
+			totp_recovery_code
+		where
+			user_id = ?;
+	`
+	deleteTx := tx.WithContext(ctx).Exec(deleteQuery, userId)
+	if deleteTx.Error != nil {
+		log.Printf("failed to remove recovery codes. Error: %s", deleteTx.Error.Error())
+		tx.Rollback()
+		return deleteTx.Error
+	}
+
+	insertQuery := `
+		insert into
+			totp_recovery_code(user_id,code_hash)
+		values
+			(?,?);
+	`
+	for _, codeHash := range recoveryCodeHashes {
+		insertTx := tx.WithContext(ctx).Exec(insertQuery, userId, codeHash)
+		if insertTx.Error != nil {
+			log.Printf("failed to add recovery code. Error: %s", insertTx.Error.Error())
+			tx.Rollback()
+			return insertTx.Error
+		}
+	}
+	return tx.Commit().Error
+}
+
+// UseTotpStep records the time step of an accepted code. returns false when the step or a later one was already used
+func (obj *userMgtDb) UseTotpStep(ctx context.Context, userId int64, step int64) (bool, error) {
+	query := `
+		update
+			user_totp
+		set
+			last_used_step = ?
+		where
+			user_id = ?
+			and last_used_step < ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, step, userId, step)
+	if updateTx.Error != nil {
+		log.Printf("failed to update totp step. Error: %s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+// UseRecoveryCode consumes an unused recovery code of the user. returns false when the code is not usable
+func (obj *userMgtDb) UseRecoveryCode(ctx context.Context, userId int64, codeHash string) (bool, error) {
+	query := `
+		update
+			totp_recovery_code
+		set
+			used_at = CURRENT_TIMESTAMP
+		where
+			user_id = ?
+			and code_hash = ?
+			and used_at is null;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, userId, codeHash)
+	if updateTx.Error != nil {
+		log.Printf("failed to use recovery code. Error: %s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+func (obj *userMgtDb) AddLoginChallenge(ctx context.Context, challenge LoginChallenge) (int64, error) {
+	query := `
+		insert into
+			login_challenge(user_id,token_hash,expires_at)
+		values
+			(?,?,?)
+		returning id;
+	`
+
+	var challengeId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, challenge.UserId.Int64, challenge.TokenHash.String, challenge.ExpiresAt.Time).Scan(&challengeId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add login challenge. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return challengeId.Int64, nil
+}
+
+func (obj *userMgtDb) GetLoginChallenge(ctx context.Context, tokenHash string) (LoginChallenge, error) {
+	query := `
+		select
+			id,
+			user_id,
+			token_hash,
+			expires_at,
+			attempts,
+			used_at,
+			created_at
+		from
+			login_challenge
+		where
+			token_hash = ?;
+	`
+
+	var challenge LoginChallenge
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, tokenHash).Rows()
+	if err != nil {
+		log.Printf("failed to fetch login challenge. Error: %s", err.Error())
+		return challenge, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.Scan(&challenge.ChallengeId, &challenge.UserId, &challenge.TokenHash, &challenge.ExpiresAt, &challenge.Attempts, &challenge.UsedAt, &challenge.CreatedAt)
+		if err != nil {
+			log.Printf("failed to scan login challenge. Error:%s", err.Error())
+			return challenge, err
+		}
+	}
+	return challenge, nil
+}
+
+func (obj *userMgtDb) IncrementLoginChallengeAttempts(ctx context.Context, challengeId int64) error {
+	query := `
+		update
+			login_challenge
+		set
+			attempts = attempts + 1
+		where
+			id = ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, challengeId)
+	if updateTx.Error != nil {
+		log.Printf("failed to update login challenge. Error: %s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// CompleteLoginChallenge marks the challenge used. returns false when a concurrent request completed it first
+func (obj *userMgtDb) CompleteLoginChallenge(ctx context.Context, challengeId int64) (bool, error) {
+	query := `
+		update
+			login_challenge
+		set
+			used_at = CURRENT_TIMESTAMP
+		where
+			id = ?
+			and used_at is null;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, challengeId)
+	if updateTx.Error != nil {
+		log.Printf("failed to complete login challenge. Error: %s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
@@ -0,0 +1,215 @@
+package webhook
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+)
+
+// AddWebhookDeliveries queues the deliveries in one transaction. a delivery of an event the subscription
+// already has is skipped, so an event published again is not sent twice
+func (obj *webhookDb) AddWebhookDeliveries(ctx context.Context, deliveries []WebhookDelivery) error {
+	query := `
+		insert into
+			webhook_delivery(subscription_id, event_id, event_type, body, next_attempt_at, created_at)
+		values
+			(?,?,?,?,?,?)
+		on conflict (subscription_id, event_id) do nothing;
+	`
+	now := time.Now().UTC()
+	tx := obj.dbObj.Begin()
+	for _, delivery := range deliveries {
+		insertTx := tx.WithContext(ctx).Exec(query, delivery.SubscriptionId.Int64, delivery.EventId.Int64, delivery.EventType.String, delivery.Body.String, now, now)
+		if insertTx.Error != nil {
+			log.Printf("failed to add webhook delivery. Error :%s", insertTx.Error.Error())
+			tx.Rollback()
+			return insertTx.Error
+		}
+	}
+	return tx.Commit().Error
+}
+
+// GetDueWebhookDeliveries lists the pending deliveries due at now of active subscriptions, in id order
+func (obj *webhookDb) GetDueWebhookDeliveries(ctx context.Context, now time.Time, limit int) ([]WebhookDelivery, error) {
+	query := `
+		select
+			` + deliveryColumns + `
+		from
+			webhook_delivery d
+		inner join
+			webhook_subscription s
+		on
+			s.id = d.subscription_id
+		where
+			d.status = 'PENDING'
+			and d.next_attempt_at <= ?
+			and s.active
+		order by d.id
+		limit ?;
+	`
+	return obj.deliveries(ctx, query, now.UTC(), limit)
+}
+
+// ClaimWebhookDelivery starts an attempt of a pending delivery seen with the given attempts and keeps other
+// dispatchers away from it until leaseUntil. it returns false when another dispatcher claimed it first
+func (obj *webhookDb) ClaimWebhookDelivery(ctx context.Context, deliveryId int64, attempts int64, leaseUntil time.Time) (bool, error) {
+	query := `
+		update
+			webhook_delivery
+		set
+			attempts = attempts + 1,
+			next_attempt_at = ?
+		where
+			id = ?
+			and status = 'PENDING'
+			and attempts = ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, leaseUntil.UTC(), deliveryId, attempts)
+	if updateTx.Error != nil {
+		log.Printf("failed to claim webhook delivery. Error :%s", updateTx.Error.Error())
+		return false, updateTx.Error
+	}
+	return updateTx.RowsAffected == 1, nil
+}
+
+func (obj *webhookDb) MarkWebhookDeliveryDelivered(ctx context.Context, deliveryId int64, responseCode int64, deliveredAt time.Time) error {
+	query := `
+		update
+			webhook_delivery
+		set
+			status = 'DELIVERED',
+			response_code = ?,
+			last_error = null,
+			delivered_at = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, responseCode, deliveredAt.UTC(), deliveryId)
+	if updateTx.Error != nil {
+		log.Printf("failed to mark webhook delivery delivered. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// RetryWebhookDelivery keeps a failed delivery for another attempt at nextAttemptAt
+func (obj *webhookDb) RetryWebhookDelivery(ctx context.Context, deliveryId int64, nextAttemptAt time.Time, responseCode int64, lastError string) error {
+	query := `
+		update
+			webhook_delivery
+		set
+			next_attempt_at = ?,
+			response_code = ?,
+			last_error = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, nextAttemptAt.UTC(), responseCode, lastError, deliveryId)
+	if updateTx.Error != nil {
+		log.Printf("failed to reschedule webhook delivery. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+// DeadLetterWebhookDelivery gives up on a delivery which ran out of attempts. it stays in the log to be redelivered
+func (obj *webhookDb) DeadLetterWebhookDelivery(ctx context.Context, deliveryId int64, responseCode int64, lastError string) error {
+	query := `
+		update
+			webhook_delivery
+		set
+			status = 'DEAD',
+			response_code = ?,
+			last_error = ?
+		where
+			id = ?
+			and status = 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, responseCode, lastError, deliveryId)
+	if updateTx.Error != nil {
+		log.Printf("failed to dead letter webhook delivery. Error :%s", updateTx.Error.Error())
+		return updateTx.Error
+	}
+	return nil
+}
+
+func (obj *webhookDb) GetWebhookDeliveries(ctx context.Context, filter DeliveryFilter) ([]WebhookDelivery, error) {
+	conditions := []string{"d.id > ?"}
+	values := []interface{}{filter.AfterId}
+	if filter.SubscriptionId != 0 {
+		conditions = append(conditions, "d.subscription_id = ?")
+		values = append(values, filter.SubscriptionId)
+	}
+	if filter.EventId != 0 {
+		conditions = append(conditions, "d.event_id = ?")
+		values = append(values, filter.EventId)
+	}
+	if filter.Status != "" {
+		conditions = append(conditions, "d.status = ?")
+		values = append(values, filter.Status)
+	}
+	query := `
+		select
+			` + deliveryColumns + `
+		from
+			webhook_delivery d
+		where
+			` + strings.Join(conditions, " and ") + `
+		order by d.id
+		limit ?;
+	`
+	values = append(values, filter.Limit)
+	return obj.deliveries(ctx, query, values...)
+}
+
+// RedeliverWebhookDelivery queues a delivered or dead delivery again with all its attempts and returns 0 when
+// there is no such delivery or it is still pending
+func (obj *webhookDb) RedeliverWebhookDelivery(ctx context.Context, deliveryId int64, now time.Time) (int64, error) {
+	query := `
+		update
+			webhook_delivery
+		set
+			status = 'PENDING',
+			attempts = 0,
+			next_attempt_at = ?,
+			delivered_at = null
+		where
+			id = ?
+			and status <> 'PENDING';
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, now.UTC(), deliveryId)
+	if updateTx.Error != nil {
+		log.Printf("failed to redeliver webhook delivery. Error :%s", updateTx.Error.Error())
+		return 0, updateTx.Error
+	}
+	if updateTx.RowsAffected == 0 {
+		return 0, nil
+	}
+	return deliveryId, nil
+}
+
+const deliveryColumns = `d.id, d.subscription_id, d.event_id, d.event_type, d.body, d.status, d.attempts, d.next_attempt_at, d.response_code, d.last_error, d.created_at, d.delivered_at`
+
+func (obj *webhookDb) deliveries(ctx context.Context, query string, values ...interface{}) ([]WebhookDelivery, error) {
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query, values...).Rows()
+	if err != nil {
+		log.Printf("failed to fetch webhook deliveries. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := make([]WebhookDelivery, 0)
+	for rows.Next() {
+		var delivery WebhookDelivery
+		err := rows.Scan(&delivery.DeliveryId, &delivery.SubscriptionId, &delivery.EventId, &delivery.EventType, &delivery.Body, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.ResponseCode, &delivery.LastError, &delivery.CreatedAt, &delivery.DeliveredAt)
+		if err != nil {
+			log.Printf("failed to scan webhook delivery. Error:%s", err.Error())
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type webhookDb struct {
+	dbObj *gorm.DB
+}
+
+type DbWebhookInterface interface {
+	AddWebhookSubscription(context.Context, WebhookSubscription) (int64, error)
+	GetWebhookSubscriptions(context.Context) ([]WebhookSubscription, error)
+	GetWebhookSubscription(context.Context, int64) (WebhookSubscription, error)
+	UpdateWebhookSubscription(context.Context, WebhookSubscription) (int64, error)
+	DeleteWebhookSubscription(context.Context, int64) (int64, error)
+
+	AddWebhookDeliveries(context.Context, []WebhookDelivery) error
+	GetDueWebhookDeliveries(context.Context, time.Time, int) ([]WebhookDelivery, error)
+	ClaimWebhookDelivery(context.Context, int64, int64, time.Time) (bool, error)
+	MarkWebhookDeliveryDelivered(context.Context, int64, int64, time.Time) error
+	RetryWebhookDelivery(context.Context, int64, time.Time, int64, string) error
+	DeadLetterWebhookDelivery(context.Context, int64, int64, string) error
+	GetWebhookDeliveries(context.Context, DeliveryFilter) ([]WebhookDelivery, error)
+	RedeliverWebhookDelivery(context.Context, int64, time.Time) (int64, error)
+}
+
+func NewWebhookDbObject(db *gorm.DB) DbWebhookInterface {
+	return &webhookDb{
+		dbObj: db,
+	}
+}
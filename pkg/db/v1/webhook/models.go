@@ -0,0 +1,49 @@
+package webhook
+
+import "database/sql"
+
+// webhook delivery status
+const (
+	PENDING   = "PENDING"
+	DELIVERED = "DELIVERED"
+	DEAD      = "DEAD"
+)
+
+// WebhookSubscription sends the events of EventTypes to Url. Secret is kept encrypted and signs every delivery
+type WebhookSubscription struct {
+	SubscriptionId sql.NullInt64
+	Url            sql.NullString
+	EventTypes     []string
+	Secret         sql.NullString
+	Description    sql.NullString
+	Active         sql.NullBool
+	CreatedBy      sql.NullInt64
+	CreatedAt      sql.NullTime
+	UpdatedAt      sql.NullTime
+}
+
+// WebhookDelivery is an event on its way to a subscription. Body is sent as it is on every attempt and
+// ResponseCode is the status of the last answer, 0 when the subscriber could not be reached
+type WebhookDelivery struct {
+	DeliveryId     sql.NullInt64
+	SubscriptionId sql.NullInt64
+	EventId        sql.NullInt64
+	EventType      sql.NullString
+	Body           sql.NullString
+	Status         sql.NullString
+	Attempts       sql.NullInt64
+	NextAttemptAt  sql.NullTime
+	ResponseCode   sql.NullInt64
+	LastError      sql.NullString
+	CreatedAt      sql.NullTime
+	DeliveredAt    sql.NullTime
+}
+
+// DeliveryFilter selects deliveries in id order. zero fields do not filter and AfterId pages through the log
+type DeliveryFilter struct {
+	SubscriptionId int64
+	EventId        int64
+	Status         string
+	AfterId        int64
+	Limit          int
+}
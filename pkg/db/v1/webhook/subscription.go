@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"strings"
+)
+
+func (obj *webhookDb) AddWebhookSubscription(ctx context.Context, subscription WebhookSubscription) (int64, error) {
+	query := `
+		insert into
+			webhook_subscription(url, event_types, secret, description, active, created_by)
+		values
+			(?,?,?,?,?,?)
+		returning id;
+	`
+
+	var subscriptionId sql.NullInt64
+	insertTx := obj.dbObj.WithContext(ctx).Raw(query, subscription.Url.String, strings.Join(subscription.EventTypes, ","), subscription.Secret.String, subscription.Description, subscription.Active.Bool, subscription.CreatedBy.Int64).Scan(&subscriptionId)
+	if insertTx.Error != nil {
+		log.Printf("failed to add webhook subscription. Error: %s", insertTx.Error.Error())
+		return 0, insertTx.Error
+	}
+	return subscriptionId.Int64, nil
+}
+
+func (obj *webhookDb) GetWebhookSubscriptions(ctx context.Context) ([]WebhookSubscription, error) {
+	query := `
+		select
+			` + subscriptionColumns + `
+		from
+			webhook_subscription
+		order by
+			id;
+	`
+	rows, err := obj.dbObj.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		log.Printf("failed to fetch webhook subscriptions. Error: %s", err.Error())
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscriptions := make([]WebhookSubscription, 0)
+	for rows.Next() {
+		var subscription WebhookSubscription
+		if err := scanSubscription(rows, &subscription); err != nil {
+			log.Printf("failed to scan webhook subscription. Error:%s", err.Error())
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, nil
+}
+
+// GetWebhookSubscription fails with sql.ErrNoRows for an unknown subscription
+func (obj *webhookDb) GetWebhookSubscription(ctx context.Context, subscriptionId int64) (WebhookSubscription, error) {
+	query := `
+		select
+			` + subscriptionColumns + `
+		from
+			webhook_subscription
+		where
+			id = ?;
+	`
+	var subscription WebhookSubscription
+	row := obj.dbObj.WithContext(ctx).Raw(query, subscriptionId).Row()
+	if row.Err() != nil {
+		log.Printf("failed to fetch webhook subscription. Error: %s", row.Err().Error())
+		return subscription, row.Err()
+	}
+	if err := scanSubscription(row, &subscription); err != nil {
+		log.Printf("failed to scan webhook subscription. Error:%s", err.Error())
+		return subscription, err
+	}
+	return subscription, nil
+}
+
+// UpdateWebhookSubscription replaces the url, event types, secret, description and active flag of the
+// subscription and returns 0 when there is none
+func (obj *webhookDb) UpdateWebhookSubscription(ctx context.Context, subscription WebhookSubscription) (int64, error) {
+	query := `
+		update
+			webhook_subscription
+		set
+			url = ?,
+			event_types = ?,
+			secret = ?,
+			description = ?,
+			active = ?,
+			updated_at = CURRENT_TIMESTAMP
+		where
+			id = ?;
+	`
+	updateTx := obj.dbObj.WithContext(ctx).Exec(query, subscription.Url.String, strings.Join(subscription.EventTypes, ","), subscription.Secret.String, subscription.Description, subscription.Active.Bool, subscription.SubscriptionId.Int64)
+	if updateTx.Error != nil {
+		log.Printf("failed to update webhook subscription. Error :%s", updateTx.Error.Error())
+		return 0, updateTx.Error
+	}
+	if updateTx.RowsAffected == 0 {
+		return 0, nil
+	}
+	return subscription.SubscriptionId.Int64, nil
+}
+
+// DeleteWebhookSubscription removes the subscription with its deliveries and returns 0 when there is none
+func (obj *webhookDb) DeleteWebhookSubscription(ctx context.Context, subscriptionId int64) (int64, error) {
+	query := `
+		delete from
+			webhook_subscription
+		where
+			id = ?;
+	`
+	deleteTx := obj.dbObj.WithContext(ctx).Exec(query, subscriptionId)
+	if deleteTx.Error != nil {
+		log.Printf("failed to delete webhook subscription. Error :%s", deleteTx.Error.Error())
+		return 0, deleteTx.Error
+	}
+	if deleteTx.RowsAffected == 0 {
+		return 0, nil
+	}
+	return subscriptionId, nil
+}
+
+const subscriptionColumns = `id, url, event_types, secret, description, active, created_by, created_at, updated_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row scanner, subscription *WebhookSubscription) error {
+	var eventTypes sql.NullString
+	err := row.Scan(&subscription.SubscriptionId, &subscription.Url, &eventTypes, &subscription.Secret, &subscription.Description, &subscription.Active, &subscription.CreatedBy, &subscription.CreatedAt, &subscription.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	subscription.EventTypes = strings.Split(eventTypes.String, ",")
+	return nil
+}
@@ -24,6 +24,8 @@ const (
 	DbError         string = "DBError"
 	UnAuthorized    string = "UnAuthorized"
 	ConversionError string = "ConversionError"
+	TooManyRequests string = "TooManyRequests"
+	Conflict        string = "Conflict"
 )
 
 func ErrorInit() {
@@ -38,6 +40,8 @@ func ErrorInit() {
 	ErrorInfo[ConversionError] = &Error{ErrName: ConversionError, Description: "Conversion Failed", Code: 1006}
 	ErrorInfo[DefaultError] = &Error{ErrName: DefaultError, Description: "Something went wrong", Code: 1007}
 	ErrorInfo[UnAuthorized] = &Error{ErrName: UnAuthorized, Description: "UnAuthorized", Code: 1008}
+	ErrorInfo[TooManyRequests] = &Error{ErrName: TooManyRequests, Description: "Too many requests", Code: 1009}
+	ErrorInfo[Conflict] = &Error{ErrName: Conflict, Description: "Request conflicts with the current state", Code: 1010}
 
 	log.Println("ErrorInit successful")
 }
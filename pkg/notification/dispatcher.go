@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"context"
+	"log"
+	"time"
+
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/notifier"
+	"aspire-assignment/pkg/poller"
+)
+
+// Dispatcher sends the queued notifications with the sender of their channel
+type Dispatcher struct {
+	store    Store
+	notifier notifier.Notifier
+	now      func() time.Time
+}
+
+func NewDispatcher(store Store, notifierObj notifier.Notifier) *Dispatcher {
+	return &Dispatcher{
+		store:    store,
+		notifier: notifierObj,
+		now:      time.Now,
+	}
+}
+
+// Start sends due notifications every poll interval in the background. the returned func stops the dispatcher and
+// waits for the notifications in progress
+func (obj *Dispatcher) Start() func() {
+	return poller.Start(pollInterval, batchSize, "dispatch notifications", obj.Dispatch)
+}
+
+// Dispatch makes one pass over the due notifications and returns how many it handled, sent or not
+func (obj *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	notifications, err := obj.store.GetDueNotifications(ctx, obj.now(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, notification := range notifications {
+		if err := obj.dispatch(ctx, notification); err != nil {
+			return 0, err
+		}
+	}
+	return len(notifications), nil
+}
+
+// dispatch claims the notification and sends it. a failed attempt is retried with exponential backoff until the
+// notification runs out of attempts and is given up. only store errors are returned
+func (obj *Dispatcher) dispatch(ctx context.Context, notification dbnotification.Notification) error {
+	notificationId := notification.NotificationId.Int64
+	claimed, err := obj.store.ClaimNotification(ctx, notificationId, notification.Attempts.Int64, obj.now().Add(lease))
+	if err != nil || !claimed {
+		return err
+	}
+	attempts := notification.Attempts.Int64 + 1
+
+	err = obj.notifier.Send(ctx, notifier.Message{
+		Channel: notification.Channel.String,
+		To:      notification.Recipient.String,
+		Subject: notification.Subject.String,
+		Body:    notification.Body.String,
+	})
+	if err == nil {
+		return obj.store.MarkNotificationSent(ctx, notificationId, obj.now())
+	}
+	log.Printf("failed to send notification %d on %s to UserId: %d, attempt %d. Error: %s", notificationId, notification.Channel.String, notification.UserId.Int64, attempts, err.Error())
+	if attempts >= maxAttempts {
+		log.Printf("notification %d failed after %d attempts", notificationId, attempts)
+		return obj.store.FailNotification(ctx, notificationId, err.Error())
+	}
+	return obj.store.RetryNotification(ctx, notificationId, obj.now().Add(poller.Backoff(baseDelay, maxDelay, attempts)), err.Error())
+}
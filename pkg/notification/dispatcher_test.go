@@ -0,0 +1,79 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/memory"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/notifier"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// sender keeps the messages it was given and fails them while err is set
+type sender struct {
+	err      error
+	messages []notifier.Message
+}
+
+func (obj *sender) Send(ctx context.Context, msg notifier.Message) error {
+	obj.messages = append(obj.messages, msg)
+	return obj.err
+}
+
+func Test_Dispatcher_Dispatch(t *testing.T) {
+	ctx := context.Background()
+	defaultChannels = []string{dbnotification.EMAIL, dbnotification.IN_APP}
+	maxAttempts, baseDelay, maxDelay, batchSize, lease = 2, time.Minute, time.Hour, 10, time.Minute
+
+	store := memory.NewV1DbLayer()
+	userId := customer(t, ctx, store)
+	notificationsObj := NewNotifications(store, builtin(t))
+	assert.Equal(t, nil, notificationsObj.Notify(ctx, approved(userId)))
+
+	smtp := &sender{err: errors.New("connection refused")}
+	dispatcher := NewDispatcher(store, smtp)
+	start := time.Now().Add(time.Second)
+	dispatcher.now = func() time.Time { return start }
+
+	//only the email is sent, a failed attempt is retried after the backoff
+	sent, err := dispatcher.Dispatch(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, 1, len(smtp.messages))
+	assert.Equal(t, notifier.EMAIL, smtp.messages[0].Channel)
+	assert.Equal(t, "john@example.com", smtp.messages[0].To)
+	assert.Equal(t, "Your loan 7 is approved", smtp.messages[0].Subject)
+	email := inbox(t, ctx, store, userId)[1]
+	assert.Equal(t, dbnotification.PENDING, email.Status.String)
+	assert.Equal(t, "connection refused", email.LastError.String)
+	sent, _ = dispatcher.Dispatch(ctx)
+	assert.Equal(t, 0, sent)
+
+	smtp.err = nil
+	dispatcher.now = func() time.Time { return start.Add(baseDelay) }
+	sent, _ = dispatcher.Dispatch(ctx)
+	assert.Equal(t, 1, sent)
+	email = inbox(t, ctx, store, userId)[1]
+	assert.Equal(t, dbnotification.SENT, email.Status.String)
+	assert.Equal(t, int64(2), email.Attempts.Int64)
+	assert.Equal(t, false, email.LastError.Valid)
+
+	//a notification running out of attempts is given up
+	smtp.err = errors.New("mailbox unavailable")
+	assert.Equal(t, nil, notificationsObj.Notify(ctx, Notice{UserId: userId, EventType: "loan.rejected", Key: "event:2", Data: map[string]interface{}{"loanId": int64(8)}}))
+	for i := 0; i < 2; i++ {
+		dispatcher.now = func() time.Time { return start.Add(time.Duration(i+1) * time.Hour) }
+		sent, _ = dispatcher.Dispatch(ctx)
+		assert.Equal(t, 1, sent)
+	}
+	rejected := inbox(t, ctx, store, userId)[1]
+	assert.Equal(t, dbnotification.FAILED, rejected.Status.String)
+	assert.Equal(t, "mailbox unavailable", rejected.LastError.String)
+	dispatcher.now = func() time.Time { return start.Add(24 * time.Hour) }
+	sent, _ = dispatcher.Dispatch(ctx)
+	assert.Equal(t, 0, sent)
+}
@@ -0,0 +1,220 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"aspire-assignment/pkg/config"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+)
+
+// events customers are notified of besides the loan events of the outbox
+const (
+	EVENT_INSTALLMENT_DUE     = "installment.due"
+	EVENT_INSTALLMENT_OVERDUE = "installment.overdue"
+	EVENT_AUTODEBIT_FAILED    = "autodebit.failed"
+)
+
+// Channels lists the channels a customer can be notified on
+var Channels = []string{dbnotification.EMAIL, dbnotification.SMS, dbnotification.IN_APP}
+
+// notification policy, overridden by notifications.* in config
+var (
+	//the channels of a customer who has not set a preference for them
+	defaultChannels = []string{dbnotification.EMAIL, dbnotification.IN_APP}
+	//a directory of <event>.tmpl files replacing the built in templates
+	templatesPath = ""
+	pollInterval  = 10 * time.Second
+	batchSize     = 100
+	//how long a claimed notification is left to one dispatcher before another may send it again
+	lease = time.Minute
+	//a notification failing this many times is given up
+	maxAttempts = int64(5)
+	baseDelay   = time.Minute
+	maxDelay    = 6 * time.Hour
+	//installments are reminded about once they are due within reminderBefore, and again once they are
+	//overdueAfter past their due date. an installment which went overdue while the reminder was not running is
+	//still reminded about for overdueWindow
+	reminderInterval = time.Hour
+	reminderBefore   = 3 * 24 * time.Hour
+	overdueAfter     = 24 * time.Hour
+	overdueWindow    = 7 * 24 * time.Hour
+)
+
+func InitNotificationPolicy() {
+	confi := config.GetConfig()
+	if value := confi.GetStringSlice("notifications.default_channels"); len(value) > 0 {
+		defaultChannels = value
+	}
+	if value := confi.GetString("notifications.templates_path"); value != "" {
+		templatesPath = value
+	}
+	if value := confi.GetDuration("notifications.poll_interval"); value > 0 {
+		pollInterval = value
+	}
+	if value := confi.GetInt("notifications.batch_size"); value > 0 {
+		batchSize = value
+	}
+	if value := confi.GetDuration("notifications.lease"); value > 0 {
+		lease = value
+	}
+	if value := confi.GetInt64("notifications.max_attempts"); value > 0 {
+		maxAttempts = value
+	}
+	if value := confi.GetDuration("notifications.base_delay"); value > 0 {
+		baseDelay = value
+	}
+	if value := confi.GetDuration("notifications.max_delay"); value > 0 {
+		maxDelay = value
+	}
+	if value := confi.GetDuration("notifications.reminder_interval"); value > 0 {
+		reminderInterval = value
+	}
+	if value := confi.GetDuration("notifications.reminder_before"); value > 0 {
+		reminderBefore = value
+	}
+	if value := confi.GetDuration("notifications.overdue_after"); value > 0 {
+		overdueAfter = value
+	}
+	if value := confi.GetDuration("notifications.overdue_window"); value > 0 {
+		overdueWindow = value
+	}
+	log.Println("InitNotificationPolicy successful")
+}
+
+// Store is the part of the db layer the notifications work with
+type Store interface {
+	GetUserById(context.Context, int64) (usermanagement.UserDetails, error)
+	GetNotificationPreferences(context.Context, int64) ([]dbnotification.NotificationPreference, error)
+	AddNotifications(context.Context, []dbnotification.Notification) error
+	GetDueNotifications(context.Context, time.Time, int) ([]dbnotification.Notification, error)
+	ClaimNotification(context.Context, int64, int64, time.Time) (bool, error)
+	MarkNotificationSent(context.Context, int64, time.Time) error
+	RetryNotification(context.Context, int64, time.Time, string) error
+	FailNotification(context.Context, int64, string) error
+	GetInstallmentsDueBetween(context.Context, time.Time, time.Time) ([]dbnotification.InstallmentReminder, error)
+}
+
+// Notice is an event to tell a customer about. Key names the occurrence of the event, a notice of a key already
+// notified is dropped. Data is what the templates of the event are rendered with
+type Notice struct {
+	UserId    int64
+	EventType string
+	Key       string
+	Data      map[string]interface{}
+}
+
+// Notifications tells customers about the events concerning them on the channels they chose
+type Notifications interface {
+	Notify(ctx context.Context, notice Notice) error
+}
+
+type notifications struct {
+	store     Store
+	templates *Templates
+	now       func() time.Time
+}
+
+func NewNotifications(store Store, templates *Templates) Notifications {
+	return &notifications{
+		store:     store,
+		templates: templates,
+		now:       time.Now,
+	}
+}
+
+// Notify renders the notice for every channel the customer is notified on and queues it. EMAIL and SMS are sent by
+// the dispatcher, IN_APP is in the inbox of the customer right away. an event without templates and a channel
+// the customer has no email or mobile for are skipped. only store errors are returned
+func (obj *notifications) Notify(ctx context.Context, notice Notice) error {
+	if !obj.templates.Has(notice.EventType) {
+		return nil
+	}
+	user, err := obj.store.GetUserById(ctx, notice.UserId)
+	if err != nil {
+		return err
+	}
+	if !user.UserId.Valid {
+		log.Printf("no UserId: %d to notify of %s", notice.UserId, notice.EventType)
+		return nil
+	}
+	preferences, err := obj.store.GetNotificationPreferences(ctx, notice.UserId)
+	if err != nil {
+		return err
+	}
+
+	data := map[string]interface{}{"userName": user.UserName.String}
+	for key, value := range notice.Data {
+		data[key] = value
+	}
+	now := obj.now()
+	rows := make([]dbnotification.Notification, 0)
+	for _, channel := range EnabledChannels(preferences) {
+		recipient := ""
+		switch channel {
+		case dbnotification.EMAIL:
+			recipient = user.Email.String
+		case dbnotification.SMS:
+			recipient = user.Mobile.String
+		}
+		if recipient == "" && channel != dbnotification.IN_APP {
+			continue
+		}
+		//a template the data does not fit is logged rather than retried, the data will not change
+		subject, body, err := obj.templates.Render(notice.EventType, channel, data)
+		if err != nil {
+			log.Printf("failed to render %s on %s for UserId: %d. Error: %s", notice.EventType, channel, notice.UserId, err.Error())
+			continue
+		}
+		row := dbnotification.Notification{
+			UserId:    sql.NullInt64{Int64: notice.UserId, Valid: true},
+			EventType: sql.NullString{String: notice.EventType, Valid: true},
+			Channel:   sql.NullString{String: channel, Valid: true},
+			Recipient: sql.NullString{String: recipient, Valid: true},
+			Subject:   sql.NullString{String: subject, Valid: true},
+			Body:      sql.NullString{String: body, Valid: true},
+			DedupKey:  sql.NullString{String: notice.Key, Valid: true},
+			Status:    sql.NullString{String: dbnotification.PENDING, Valid: true},
+			CreatedAt: sql.NullTime{Time: now, Valid: true},
+		}
+		if channel == dbnotification.IN_APP {
+			row.Status.String = dbnotification.SENT
+			row.SentAt = sql.NullTime{Time: now, Valid: true}
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	return obj.store.AddNotifications(ctx, rows)
+}
+
+// EnabledChannels returns the channels a customer is notified on: their preferences, and the default channels
+// for those they have not set
+func EnabledChannels(preferences []dbnotification.NotificationPreference) []string {
+	channels := make([]string, 0)
+	for _, channel := range Channels {
+		enabled := contains(defaultChannels, channel)
+		for _, preference := range preferences {
+			if preference.Channel.String == channel {
+				enabled = preference.Enabled.Bool
+			}
+		}
+		if enabled {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/memory"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// customer is a customer with an email and no mobile
+func customer(t *testing.T, ctx context.Context, store v1.V1DBLayer) int64 {
+	userId, err := store.AddUser(ctx, usermanagement.UserDetails{
+		UserName: sql.NullString{String: "john", Valid: true},
+		UserType: sql.NullString{String: "CUSTOMER", Valid: true},
+		Email:    sql.NullString{String: "john@example.com", Valid: true},
+	})
+	assert.Equal(t, nil, err)
+	return userId
+}
+
+func builtin(t *testing.T) *Templates {
+	templates, err := LoadTemplates("")
+	assert.Equal(t, nil, err)
+	return templates
+}
+
+func inbox(t *testing.T, ctx context.Context, store v1.V1DBLayer, userId int64) []dbnotification.Notification {
+	notifications, err := store.GetNotifications(ctx, dbnotification.NotificationFilter{UserId: userId, Limit: 100})
+	assert.Equal(t, nil, err)
+	return notifications
+}
+
+func approved(userId int64) Notice {
+	return Notice{
+		UserId:    userId,
+		EventType: "loan.approved",
+		Key:       "event:1",
+		Data:      map[string]interface{}{"loanId": int64(7), "amount": float64(300), "tenure": float64(3), "installmentAmount": float64(100)},
+	}
+}
+
+func Test_Notifications_Notify(t *testing.T) {
+	ctx := context.Background()
+	defaultChannels = []string{dbnotification.EMAIL, dbnotification.IN_APP}
+	store := memory.NewV1DbLayer()
+	userId := customer(t, ctx, store)
+	notificationsObj := NewNotifications(store, builtin(t))
+
+	//the default channels are notified, an event notified again is dropped
+	assert.Equal(t, nil, notificationsObj.Notify(ctx, approved(userId)))
+	assert.Equal(t, nil, notificationsObj.Notify(ctx, approved(userId)))
+	notifications := inbox(t, ctx, store, userId)
+	assert.Equal(t, 2, len(notifications))
+	inApp, email := notifications[0], notifications[1]
+	assert.Equal(t, dbnotification.IN_APP, inApp.Channel.String)
+	assert.Equal(t, dbnotification.SENT, inApp.Status.String)
+	assert.Equal(t, dbnotification.EMAIL, email.Channel.String)
+	assert.Equal(t, dbnotification.PENDING, email.Status.String)
+	assert.Equal(t, "john@example.com", email.Recipient.String)
+	assert.Equal(t, "Your loan 7 is approved", email.Subject.String)
+	assert.Equal(t, true, strings.HasPrefix(email.Body.String, "Hello john,"))
+	assert.Equal(t, true, strings.Contains(email.Body.String, "Your loan 7 of 300.00 is approved. You will repay it in 3 weekly installments of 100.00."))
+	assert.Equal(t, false, strings.Contains(email.Body.String, "Note from our team"))
+
+	//preferences turn channels on and off, and SMS are skipped without a mobile
+	now := time.Now()
+	assert.Equal(t, nil, store.SetNotificationPreferences(ctx, []dbnotification.NotificationPreference{
+		{UserId: sql.NullInt64{Int64: userId, Valid: true}, Channel: sql.NullString{String: dbnotification.EMAIL, Valid: true}, Enabled: sql.NullBool{Bool: false, Valid: true}, UpdatedAt: sql.NullTime{Time: now, Valid: true}},
+		{UserId: sql.NullInt64{Int64: userId, Valid: true}, Channel: sql.NullString{String: dbnotification.SMS, Valid: true}, Enabled: sql.NullBool{Bool: true, Valid: true}, UpdatedAt: sql.NullTime{Time: now, Valid: true}},
+	}))
+	rejected := Notice{UserId: userId, EventType: "loan.rejected", Key: "event:2", Data: map[string]interface{}{"loanId": int64(8), "reason": "income too low"}}
+	assert.Equal(t, nil, notificationsObj.Notify(ctx, rejected))
+	notifications = inbox(t, ctx, store, userId)
+	assert.Equal(t, 3, len(notifications))
+	assert.Equal(t, dbnotification.IN_APP, notifications[0].Channel.String)
+	assert.Equal(t, true, strings.Contains(notifications[0].Body.String, "Reason: income too low"))
+
+	//events without templates, data the templates do not fit and unknown users are not notified
+	assert.Equal(t, nil, notificationsObj.Notify(ctx, Notice{UserId: userId, EventType: "loan.applied", Key: "event:3"}))
+	assert.Equal(t, nil, notificationsObj.Notify(ctx, Notice{UserId: userId, EventType: "loan.closed", Key: "event:4"}))
+	assert.Equal(t, nil, notificationsObj.Notify(ctx, approved(userId+10)))
+	assert.Equal(t, 3, len(inbox(t, ctx, store, userId)))
+}
+
+func Test_EnabledChannels(t *testing.T) {
+	defaultChannels = []string{dbnotification.EMAIL, dbnotification.IN_APP}
+	preference := func(channel string, enabled bool) dbnotification.NotificationPreference {
+		return dbnotification.NotificationPreference{Channel: sql.NullString{String: channel, Valid: true}, Enabled: sql.NullBool{Bool: enabled, Valid: true}}
+	}
+	assert.Equal(t, []string{"EMAIL", "IN_APP"}, EnabledChannels(nil))
+	assert.Equal(t, []string{"SMS", "IN_APP"}, EnabledChannels([]dbnotification.NotificationPreference{preference("EMAIL", false), preference("SMS", true)}))
+	assert.Equal(t, []string{}, EnabledChannels([]dbnotification.NotificationPreference{preference("EMAIL", false), preference("IN_APP", false)}))
+}
+
+func Test_LoadTemplates(t *testing.T) {
+	data := map[string]interface{}{"userName": "john", "loanId": int64(7), "installmentNumber": int64(2), "amount": float64(100), "dueDate": time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)}
+
+	//every built in event renders on every channel
+	templates := builtin(t)
+	for _, eventType := range []string{EVENT_INSTALLMENT_DUE, EVENT_INSTALLMENT_OVERDUE, EVENT_AUTODEBIT_FAILED} {
+		for _, channel := range Channels {
+			_, body, err := templates.Render(eventType, channel, data)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, true, strings.Contains(body, "19 Oct 2026"))
+		}
+	}
+	_, text, _ := templates.Render(EVENT_INSTALLMENT_DUE, dbnotification.SMS, data)
+	assert.Equal(t, "Aspire: installment 2 of loan 7, 100.00, is due on 19 Oct 2026.", text)
+
+	//the files of the templates path replace the built in templates, an SMS falls back to the subject
+	dir := t.TempDir()
+	content := `{{define "subject"}}Pay {{money .amount}} by {{date .dueDate}}{{end}}{{define "body"}}Dear {{.userName}}{{end}}`
+	assert.Equal(t, nil, os.WriteFile(filepath.Join(dir, "installment.due.tmpl"), []byte(content), 0o600))
+	templates, err := LoadTemplates(dir)
+	assert.Equal(t, nil, err)
+	subject, body, err := templates.Render(EVENT_INSTALLMENT_DUE, dbnotification.EMAIL, data)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "Pay 100.00 by 19 Oct 2026", subject)
+	assert.Equal(t, "Dear john", body)
+	_, text, _ = templates.Render(EVENT_INSTALLMENT_DUE, dbnotification.SMS, data)
+	assert.Equal(t, "Pay 100.00 by 19 Oct 2026", text)
+	assert.Equal(t, true, templates.Has(EVENT_INSTALLMENT_OVERDUE))
+
+	//missing data fails the rendering and a template without a body is refused
+	_, _, err = templates.Render(EVENT_INSTALLMENT_OVERDUE, dbnotification.EMAIL, map[string]interface{}{"userName": "john"})
+	assert.NotEqual(t, nil, err)
+	assert.Equal(t, nil, os.WriteFile(filepath.Join(dir, "loan.closed.tmpl"), []byte(`{{define "subject"}}closed{{end}}`), 0o600))
+	_, err = LoadTemplates(dir)
+	assert.NotEqual(t, nil, err)
+}
@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/poller"
+)
+
+// Reminder tells customers about their installments falling due soon and those gone overdue
+type Reminder struct {
+	store         Store
+	notifications Notifications
+	now           func() time.Time
+}
+
+func NewReminder(store Store, notifications Notifications) *Reminder {
+	return &Reminder{
+		store:         store,
+		notifications: notifications,
+		now:           time.Now,
+	}
+}
+
+// Start runs the reminder every reminder interval in the background. the returned func stops the reminder and
+// waits for the reminders in progress
+func (obj *Reminder) Start() func() {
+	return poller.Start(reminderInterval, 0, "remind of installments", obj.Run)
+}
+
+// Run notifies the installments due within reminderBefore and those overdue for overdueAfter, and returns how many
+// installments it went through. an installment is notified once as due and once as overdue, later runs find it
+// notified already
+func (obj *Reminder) Run(ctx context.Context) (int, error) {
+	now := obj.now()
+	due, err := obj.store.GetInstallmentsDueBetween(ctx, now, now.Add(reminderBefore))
+	if err != nil {
+		return 0, err
+	}
+	for _, installment := range due {
+		if err := obj.remind(ctx, EVENT_INSTALLMENT_DUE, installment); err != nil {
+			return 0, err
+		}
+	}
+
+	overdueSince := now.Add(-overdueAfter)
+	overdue, err := obj.store.GetInstallmentsDueBetween(ctx, overdueSince.Add(-overdueWindow), overdueSince)
+	if err != nil {
+		return 0, err
+	}
+	for _, installment := range overdue {
+		if err := obj.remind(ctx, EVENT_INSTALLMENT_OVERDUE, installment); err != nil {
+			return 0, err
+		}
+	}
+	return len(due) + len(overdue), nil
+}
+
+func (obj *Reminder) remind(ctx context.Context, eventType string, installment dbnotification.InstallmentReminder) error {
+	return obj.notifications.Notify(ctx, Notice{
+		UserId:    installment.UserId.Int64,
+		EventType: eventType,
+		Key:       fmt.Sprintf("%s:%d:%d", eventType, installment.LoanId.Int64, installment.InstallmentNum.Int64),
+		Data: map[string]interface{}{
+			"loanId":            installment.LoanId.Int64,
+			"installmentNumber": installment.InstallmentNum.Int64,
+			"amount":            installment.AmountDue.Float64,
+			"dueDate":           installment.DueDate.Time,
+		},
+	})
+}
@@ -0,0 +1,64 @@
+package notification
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/memory"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func Test_Reminder_Run(t *testing.T) {
+	ctx := context.Background()
+	defaultChannels = []string{dbnotification.IN_APP}
+	reminderBefore, overdueAfter, overdueWindow = 3*24*time.Hour, 24*time.Hour, 7*24*time.Hour
+
+	//a loan of 300 in 3 weekly installments of 100, the first due at approval
+	store := memory.NewV1DbLayer()
+	userId := customer(t, ctx, store)
+	loanId, _ := store.CreateLoan(ctx, userId, 300, 3, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}, nil)
+	approval := loan.StatusChange{From: "PENDING", To: "APPROVED", Actor: "ADMIN", ActorId: 1}
+	assert.Equal(t, nil, store.UpdateAndInsertInstallments(ctx, loanId, approval, 100, 3, nil))
+	reminder := NewReminder(store, NewNotifications(store, builtin(t)))
+	start := time.Now()
+	at := func(offset time.Duration) {
+		reminder.now = func() time.Time { return start.Add(offset) }
+	}
+	day := 24 * time.Hour
+
+	//nothing is due within three days of the approval
+	at(time.Hour)
+	reminded, err := reminder.Run(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 0, reminded)
+
+	//the first installment goes overdue a day after it fell due, once
+	at(day + time.Hour)
+	reminded, _ = reminder.Run(ctx)
+	assert.Equal(t, 1, reminded)
+	reminder.Run(ctx)
+	notifications := inbox(t, ctx, store, userId)
+	assert.Equal(t, 1, len(notifications))
+	assert.Equal(t, EVENT_INSTALLMENT_OVERDUE, notifications[0].EventType.String)
+	assert.Equal(t, "Installment 1 of loan 1 is overdue", notifications[0].Subject.String)
+
+	//the second installment is reminded about three days before it falls due
+	at(4*day + time.Hour)
+	reminded, _ = reminder.Run(ctx)
+	assert.Equal(t, 2, reminded)
+	notifications = inbox(t, ctx, store, userId)
+	assert.Equal(t, 2, len(notifications))
+	assert.Equal(t, EVENT_INSTALLMENT_DUE, notifications[0].EventType.String)
+	assert.Equal(t, true, strings.Contains(notifications[0].Body.String, "installment 2 of loan 1, 100.00, is due on"))
+
+	//an installment overdue for longer than the window is left alone
+	at(9*day + time.Hour)
+	reminded, _ = reminder.Run(ctx)
+	assert.Equal(t, 1, reminded)
+	assert.Equal(t, 3, len(inbox(t, ctx, store, userId)))
+}
@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"aspire-assignment/pkg/outbox"
+)
+
+type sink struct {
+	notifications Notifications
+}
+
+// NewSink returns the outbox sink notifying customers of the loan events concerning them. the notifications are
+// queued and sent by the dispatcher, so a slow mail server never holds the outbox back
+func NewSink(notifications Notifications) outbox.Sink {
+	return &sink{
+		notifications: notifications,
+	}
+}
+
+func (obj *sink) Publish(ctx context.Context, msg outbox.Message) error {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		log.Printf("failed to read payload of event %d to notify. Error: %s", msg.EventId, err.Error())
+		return nil
+	}
+	//every loan event has the user it concerns
+	userId, _ := payload["userId"].(float64)
+	if userId == 0 {
+		return nil
+	}
+	payload["loanId"] = msg.AggregateId
+	payload["occurredAt"] = msg.OccurredAt
+	//an event published again finds its notifications queued already and they are kept as they are
+	return obj.notifications.Notify(ctx, Notice{
+		UserId:    int64(userId),
+		EventType: msg.EventType,
+		Key:       fmt.Sprintf("event:%d", msg.EventId),
+		Data:      payload,
+	})
+}
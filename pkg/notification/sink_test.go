@@ -0,0 +1,43 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/memory"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	"aspire-assignment/pkg/outbox"
+	"aspire-assignment/pkg/service/v1/loan"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func Test_Sink_Publish(t *testing.T) {
+	ctx := context.Background()
+	defaultChannels = []string{dbnotification.EMAIL, dbnotification.IN_APP}
+	store := memory.NewV1DbLayer()
+	userId := customer(t, ctx, store)
+	sinkObj := NewSink(NewNotifications(store, builtin(t)))
+
+	message := func(eventId int64, eventType string, payload interface{}) outbox.Message {
+		body, _ := json.Marshal(payload)
+		return outbox.Message{EventId: eventId, EventType: eventType, AggregateType: loan.AGGREGATE_LOAN, AggregateId: 7, OccurredAt: time.Now(), Payload: body}
+	}
+	paid := message(1, loan.EVENT_INSTALLMENT_PAID, loan.InstallmentPaid{UserId: userId, InstallmentNumber: 1, Amount: 100, TransactionId: "TXN1", OutstandingAmount: 200})
+
+	//the event is notified once however often it is published
+	assert.Equal(t, nil, sinkObj.Publish(ctx, paid))
+	assert.Equal(t, nil, sinkObj.Publish(ctx, paid))
+	notifications := inbox(t, ctx, store, userId)
+	assert.Equal(t, 2, len(notifications))
+	assert.Equal(t, "Payment received for loan 7", notifications[0].Subject.String)
+	assert.Equal(t, "event:1", notifications[0].DedupKey.String)
+	assert.Equal(t, "Hello john,\n\nWe received your payment of 100.00 for installment 1 of loan 7, transaction TXN1.\n200.00 of the loan is outstanding.", notifications[1].Body.String)
+
+	//events customers are not notified of and payloads without a user are skipped
+	assert.Equal(t, nil, sinkObj.Publish(ctx, message(2, loan.EVENT_LOAN_APPLIED, loan.LoanApplied{UserId: userId, Amount: 300, Tenure: 3})))
+	assert.Equal(t, nil, sinkObj.Publish(ctx, message(3, loan.EVENT_LOAN_CLOSED, map[string]string{"transactionId": "TXN3"})))
+	assert.Equal(t, 2, len(inbox(t, ctx, store, userId)))
+}
@@ -0,0 +1,144 @@
+package notification
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+)
+
+// names of the templates an event file defines. "subject" and "body" are required, "sms" is the text of an SMS and
+// falls back to the subject
+const (
+	TEMPLATE_SUBJECT = "subject"
+	TEMPLATE_BODY    = "body"
+	TEMPLATE_SMS     = "sms"
+)
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// Templates holds the templates of every event customers are notified of, keyed by event type
+type Templates struct {
+	events map[string]*template.Template
+}
+
+// LoadTemplates parses the built in templates, replaced by the <event>.tmpl files of dir when it is not empty
+func LoadTemplates(dir string) (*Templates, error) {
+	templates := &Templates{events: make(map[string]*template.Template)}
+	if err := templates.load(builtinTemplates, "templates"); err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		if err := templates.load(os.DirFS(dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	return templates, nil
+}
+
+// NewTemplates loads the templates of notifications.templates_path
+func NewTemplates() (*Templates, error) {
+	return LoadTemplates(templatesPath)
+}
+
+func (obj *Templates) load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.tmpl"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		eventType := strings.TrimSuffix(path.Base(file), ".tmpl")
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		tmpl, err := template.New(eventType).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("invalid template of %s: %s", eventType, err.Error())
+		}
+		for _, name := range []string{TEMPLATE_SUBJECT, TEMPLATE_BODY} {
+			if tmpl.Lookup(name) == nil {
+				return fmt.Errorf("template of %s does not define %s", eventType, name)
+			}
+		}
+		obj.events[eventType] = tmpl
+	}
+	return nil
+}
+
+// Has returns whether customers are notified of the event
+func (obj *Templates) Has(eventType string) bool {
+	_, ok := obj.events[eventType]
+	return ok
+}
+
+// Render returns the subject and body of the event on channel. an SMS has its text as body
+func (obj *Templates) Render(eventType string, channel string, data map[string]interface{}) (string, string, error) {
+	tmpl, ok := obj.events[eventType]
+	if !ok {
+		return "", "", fmt.Errorf("no template for %s", eventType)
+	}
+	subject, err := execute(tmpl, TEMPLATE_SUBJECT, data)
+	if err != nil {
+		return "", "", err
+	}
+	if channel == dbnotification.SMS {
+		if tmpl.Lookup(TEMPLATE_SMS) == nil {
+			return subject, subject, nil
+		}
+		text, err := execute(tmpl, TEMPLATE_SMS, data)
+		return subject, text, err
+	}
+	body, err := execute(tmpl, TEMPLATE_BODY, data)
+	return subject, body, err
+}
+
+func execute(tmpl *template.Template, name string, data map[string]interface{}) (string, error) {
+	var builder strings.Builder
+	if err := tmpl.ExecuteTemplate(&builder, name, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// funcs are the functions the templates can use besides the text/template builtins
+var funcs = template.FuncMap{
+	"money": money,
+	"date":  date,
+}
+
+// money formats an amount with two decimals. amounts come as float64 from event payloads
+func money(value interface{}) string {
+	switch amount := value.(type) {
+	case float64:
+		return fmt.Sprintf("%.2f", amount)
+	case int64:
+		return fmt.Sprintf("%d.00", amount)
+	case int:
+		return fmt.Sprintf("%d.00", amount)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// date formats a time, or a RFC 3339 timestamp as event payloads have them, as a day
+func date(value interface{}) string {
+	switch day := value.(type) {
+	case time.Time:
+		return day.Format("02 Jan 2006")
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, day); err == nil {
+			return parsed.Format("02 Jan 2006")
+		}
+		return day
+	default:
+		return fmt.Sprint(value)
+	}
+}
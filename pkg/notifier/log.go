@@ -0,0 +1,18 @@
+package notifier
+
+import (
+	"context"
+	"log"
+)
+
+type logNotifier struct{}
+
+// NewLogNotifier writes messages to the application log instead of delivering them. meant for local use
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (obj *logNotifier) Send(ctx context.Context, msg Message) error {
+	log.Printf("notification channel: %s, to: %s, subject: %s, body: %s", msg.Channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
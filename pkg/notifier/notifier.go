@@ -0,0 +1,87 @@
+package notifier
+
+import (
+	"aspire-assignment/pkg/config"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// notifier drivers
+const (
+	LOG  = "log"
+	SMTP = "smtp"
+)
+
+// delivery channels. in-app messages are kept for the customer to read in the app and are never sent
+const (
+	EMAIL  = "EMAIL"
+	SMS    = "SMS"
+	IN_APP = "IN_APP"
+)
+
+type Message struct {
+	Channel string
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers one-off messages like OTPs to a customer's email or mobile
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewNotifier returns the notifier selected by notifier.driver in config. the smtp driver sends the emails through
+// the server of notifier.smtp, and there is no SMS gateway yet, so SMS are always logged
+func NewNotifier() (Notifier, error) {
+	confi := config.GetConfig()
+	driver := confi.GetString("notifier.driver")
+	switch driver {
+	case LOG, "":
+		log.Println("Log notifier initialized")
+		return NewLogNotifier(), nil
+	case SMTP:
+		if confi.GetString("notifier.smtp.host") == "" || confi.GetString("notifier.smtp.from") == "" {
+			return nil, fmt.Errorf("notifier.smtp.host and notifier.smtp.from are required for the smtp notifier")
+		}
+		timeout := confi.GetDuration("notifier.smtp.timeout")
+		if timeout <= 0 {
+			timeout = 10 * time.Second
+		}
+		email := NewSMTPNotifier(SMTPConfig{
+			Host:     confi.GetString("notifier.smtp.host"),
+			Port:     confi.GetInt("notifier.smtp.port"),
+			Username: confi.GetString("notifier.smtp.username"),
+			Password: confi.GetString("notifier.smtp.password"),
+			From:     confi.GetString("notifier.smtp.from"),
+			Timeout:  timeout,
+		})
+		log.Println("SMTP notifier initialized")
+		return NewChannelNotifier(map[string]Notifier{EMAIL: email}, NewLogNotifier()), nil
+	default:
+		return nil, fmt.Errorf("unsupported notifier driver %s", driver)
+	}
+}
+
+type channelNotifier struct {
+	notifiers map[string]Notifier
+	fallback  Notifier
+}
+
+// NewChannelNotifier sends every message with the notifier of its channel, or with fallback for the channels
+// without one
+func NewChannelNotifier(notifiers map[string]Notifier, fallback Notifier) Notifier {
+	return &channelNotifier{
+		notifiers: notifiers,
+		fallback:  fallback,
+	}
+}
+
+func (obj *channelNotifier) Send(ctx context.Context, msg Message) error {
+	if notifier, ok := obj.notifiers[msg.Channel]; ok {
+		return notifier.Send(ctx, msg)
+	}
+	return obj.fallback.Send(ctx, msg)
+}
@@ -0,0 +1,115 @@
+package notifier
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host string
+	Port int
+	//no authentication without a username, as with a local mail sink
+	Username string
+	Password string
+	//the sender, an address or "Name <address>"
+	From    string
+	Timeout time.Duration
+}
+
+type smtpNotifier struct {
+	config SMTPConfig
+}
+
+// NewSMTPNotifier sends emails through an SMTP server, upgrading the connection with STARTTLS when the server
+// offers it. messages of other channels are refused
+func NewSMTPNotifier(config SMTPConfig) Notifier {
+	return &smtpNotifier{
+		config: config,
+	}
+}
+
+func (obj *smtpNotifier) Send(ctx context.Context, msg Message) error {
+	if msg.Channel != EMAIL {
+		return fmt.Errorf("smtp notifier cannot send %s messages", msg.Channel)
+	}
+	from, err := mail.ParseAddress(obj.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %s: %s", obj.config.From, err.Error())
+	}
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %s: %s", msg.To, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, obj.config.Timeout)
+	defer cancel()
+	addr := net.JoinHostPort(obj.config.Host, strconv.Itoa(obj.config.Port))
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	//the deadline covers the whole conversation, the client has no context of its own
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, obj.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: obj.config.Host}); err != nil {
+			return err
+		}
+	}
+	if obj.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", obj.config.Username, obj.config.Password, obj.config.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(compose(from, to, msg, time.Now())); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// compose returns the plain text email of msg. line breaks are taken out of the subject, so it cannot add headers
+func compose(from *mail.Address, to *mail.Address, msg Message, date time.Time) []byte {
+	subject := strings.Join(strings.Fields(msg.Subject), " ")
+	var builder strings.Builder
+	builder.WriteString("From: " + from.String() + "\r\n")
+	builder.WriteString("To: " + to.String() + "\r\n")
+	builder.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	builder.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	builder.WriteString("\r\n")
+	return []byte(builder.String())
+}
@@ -0,0 +1,153 @@
+package notifier
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// sink is a local SMTP server accepting every mail and keeping the envelope and data it was sent
+type sink struct {
+	sync.Mutex
+	listener net.Listener
+	from     []string
+	to       []string
+	data     []string
+}
+
+func newSink(t *testing.T) *sink {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen. Error:%s", err.Error())
+	}
+	obj := &sink{listener: listener}
+	go obj.serve()
+	t.Cleanup(func() { listener.Close() })
+	return obj
+}
+
+func (obj *sink) port() int {
+	return obj.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (obj *sink) serve() {
+	for {
+		conn, err := obj.listener.Accept()
+		if err != nil {
+			return
+		}
+		go obj.session(textproto.NewConn(conn))
+	}
+}
+
+func (obj *sink) session(conn *textproto.Conn) {
+	defer conn.Close()
+	conn.PrintfLine("220 sink ready")
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO", "HELO":
+			conn.PrintfLine("250 sink")
+		case "MAIL":
+			obj.Lock()
+			obj.from = append(obj.from, line)
+			obj.Unlock()
+			conn.PrintfLine("250 ok")
+		case "RCPT":
+			obj.Lock()
+			obj.to = append(obj.to, line)
+			obj.Unlock()
+			conn.PrintfLine("250 ok")
+		case "DATA":
+			conn.PrintfLine("354 go ahead")
+			data, err := conn.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			obj.Lock()
+			obj.data = append(obj.data, string(data))
+			obj.Unlock()
+			conn.PrintfLine("250 queued")
+		case "QUIT":
+			conn.PrintfLine("221 bye")
+			return
+		default:
+			conn.PrintfLine("250 ok")
+		}
+	}
+}
+
+func TestSMTPNotifier_Send(t *testing.T) {
+	server := newSink(t)
+	notifierObj := NewSMTPNotifier(SMTPConfig{
+		Host:    "127.0.0.1",
+		Port:    server.port(),
+		From:    "Aspire <no-reply@aspire.local>",
+		Timeout: 5 * time.Second,
+	})
+
+	err := notifierObj.Send(context.Background(), Message{
+		Channel: EMAIL,
+		To:      "john@example.com",
+		Subject: "Your loan is approved\r\nBcc: eve@example.com",
+		Body:    "Hello John,\nyour loan of 1000.00 is approved.\n.\nThanks",
+	})
+	assert.Equal(t, err, nil)
+
+	server.Lock()
+	defer server.Unlock()
+	assert.Equal(t, server.from, []string{"MAIL FROM:<no-reply@aspire.local>"})
+	assert.Equal(t, len(server.to), 1)
+	assert.Equal(t, strings.HasPrefix(server.to[0], "RCPT TO:<john@example.com>"), true)
+	assert.Equal(t, len(server.data), 1)
+	headers, body, _ := strings.Cut(server.data[0], "\n\n")
+	assert.Equal(t, strings.Contains(headers, "From: \"Aspire\" <no-reply@aspire.local>\n"), true)
+	assert.Equal(t, strings.Contains(headers, "To: <john@example.com>\n"), true)
+	assert.Equal(t, strings.Contains(headers, "Subject: Your loan is approved Bcc: eve@example.com\n"), true)
+	assert.Equal(t, strings.Contains(headers, "\nBcc:"), false)
+	assert.Equal(t, body, "Hello John,\nyour loan of 1000.00 is approved.\n.\nThanks\n")
+}
+
+func TestSMTPNotifier_Refused(t *testing.T) {
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	notifierObj := NewSMTPNotifier(SMTPConfig{Host: "127.0.0.1", Port: port, From: "no-reply@aspire.local", Timeout: time.Second})
+
+	err := notifierObj.Send(context.Background(), Message{Channel: EMAIL, To: "john@example.com", Subject: "s", Body: "b"})
+	assert.NotEqual(t, err, nil)
+
+	err = notifierObj.Send(context.Background(), Message{Channel: SMS, To: "+6500000000", Body: "b"})
+	assert.NotEqual(t, err, nil)
+}
+
+func TestChannelNotifier_Send(t *testing.T) {
+	email := &recorder{}
+	fallback := &recorder{}
+	notifierObj := NewChannelNotifier(map[string]Notifier{EMAIL: email}, fallback)
+
+	notifierObj.Send(context.Background(), Message{Channel: EMAIL, To: "john@example.com"})
+	notifierObj.Send(context.Background(), Message{Channel: SMS, To: "+6500000000"})
+
+	assert.Equal(t, email.sent, []Message{{Channel: EMAIL, To: "john@example.com"}})
+	assert.Equal(t, fallback.sent, []Message{{Channel: SMS, To: "+6500000000"}})
+}
+
+type recorder struct {
+	sent []Message
+}
+
+func (obj *recorder) Send(ctx context.Context, msg Message) error {
+	obj.sent = append(obj.sent, msg)
+	return nil
+}
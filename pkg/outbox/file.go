@@ -0,0 +1,38 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+type fileSink struct {
+	sync.Mutex
+	out io.Writer
+}
+
+// NewFileSink appends each message as a line of JSON to the file at path, or writes it to stdout when path is
+// empty. meant for local use
+func NewFileSink(path string) (Sink, error) {
+	if path == "" {
+		return &fileSink{out: os.Stdout}, nil
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{out: file}, nil
+}
+
+func (obj *fileSink) Publish(ctx context.Context, msg Message) error {
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	obj.Lock()
+	defer obj.Unlock()
+	_, err = obj.out.Write(append(line, '\n'))
+	return err
+}
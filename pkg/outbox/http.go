@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// headers sent with every message of the http sink
+const (
+	EVENT_ID_HEADER   = "X-Event-ID"
+	EVENT_TYPE_HEADER = "X-Event-Type"
+)
+
+const DEFAULT_HTTP_TIMEOUT = 10 * time.Second
+
+type httpSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHttpSink posts each message as JSON to url. any answer but a 2xx is a failed delivery
+func NewHttpSink(url string, timeout time.Duration) Sink {
+	if timeout <= 0 {
+		timeout = DEFAULT_HTTP_TIMEOUT
+	}
+	return &httpSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (obj *httpSink) Publish(ctx context.Context, msg Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, obj.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EVENT_ID_HEADER, strconv.FormatInt(msg.EventId, 10))
+	req.Header.Set(EVENT_TYPE_HEADER, msg.EventType)
+
+	resp, err := obj.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	//drain the body so the connection is reused
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s answered %d", obj.url, resp.StatusCode)
+	}
+	return nil
+}
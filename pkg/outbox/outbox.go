@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"aspire-assignment/pkg/config"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// sink drivers
+const (
+	FILE = "file"
+	HTTP = "http"
+)
+
+// Message is an outbox event as sinks deliver it. EventId is the same on every delivery of the event, so consumers
+// drop the duplicates at-least-once delivery brings
+type Message struct {
+	EventId       int64           `json:"eventId"`
+	EventType     string          `json:"eventType"`
+	AggregateType string          `json:"aggregateType"`
+	AggregateId   int64           `json:"aggregateId"`
+	OccurredAt    time.Time       `json:"occurredAt"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Sink publishes outbox messages to their consumers. an error has the message delivered again later
+type Sink interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+// NewSinks returns the sinks listed in outbox.sinks in config
+func NewSinks() ([]Sink, error) {
+	confi := config.GetConfig()
+	sinks := make([]Sink, 0)
+	for _, driver := range confi.GetStringSlice("outbox.sinks") {
+		switch driver {
+		case FILE:
+			sink, err := NewFileSink(confi.GetString("outbox.file.path"))
+			if err != nil {
+				return nil, err
+			}
+			sinks = append(sinks, sink)
+		case HTTP:
+			url := confi.GetString("outbox.http.url")
+			if url == "" {
+				return nil, fmt.Errorf("outbox.http.url is required for the http sink")
+			}
+			sinks = append(sinks, NewHttpSink(url, confi.GetDuration("outbox.http.timeout")))
+		default:
+			return nil, fmt.Errorf("unsupported outbox sink %s", driver)
+		}
+		log.Printf("outbox %s sink initialized", driver)
+	}
+	return sinks, nil
+}
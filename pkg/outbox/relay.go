@@ -0,0 +1,122 @@
+package outbox
+
+import (
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/poller"
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	dboutbox "aspire-assignment/pkg/db/v1/outbox"
+)
+
+// relay policy, overridden by outbox.* in config
+var (
+	pollInterval = time.Second
+	batchSize    = 100
+	//how long a claimed event is left to one relay before another may deliver it again
+	lease       = 30 * time.Second
+	maxAttempts = int64(10)
+	baseDelay   = time.Second
+	maxDelay    = 5 * time.Minute
+)
+
+// Store is the part of the db layer the relay works with
+type Store interface {
+	GetDueOutboxEvents(context.Context, time.Time, int) ([]dboutbox.OutboxEvent, error)
+	ClaimOutboxEvent(context.Context, int64, int64, time.Time) (bool, error)
+	MarkOutboxEventDelivered(context.Context, int64, time.Time) error
+	RetryOutboxEvent(context.Context, int64, time.Time, string) error
+	FailOutboxEvent(context.Context, int64, string) error
+}
+
+func InitRelayPolicy() {
+	confi := config.GetConfig()
+	if value := confi.GetDuration("outbox.poll_interval"); value > 0 {
+		pollInterval = value
+	}
+	if value := confi.GetInt("outbox.batch_size"); value > 0 {
+		batchSize = value
+	}
+	if value := confi.GetDuration("outbox.lease"); value > 0 {
+		lease = value
+	}
+	if value := confi.GetInt64("outbox.max_attempts"); value > 0 {
+		maxAttempts = value
+	}
+	if value := confi.GetDuration("outbox.base_delay"); value > 0 {
+		baseDelay = value
+	}
+	if value := confi.GetDuration("outbox.max_delay"); value > 0 {
+		maxDelay = value
+	}
+	log.Println("InitRelayPolicy successful")
+}
+
+// Relay delivers the events of the outbox to every sink, at least once. an event is marked delivered only after
+// all sinks took it, so a failure has the event published again to each of them
+type Relay struct {
+	store Store
+	sinks []Sink
+	now   func() time.Time
+}
+
+func NewRelay(store Store, sinks []Sink) *Relay {
+	return &Relay{
+		store: store,
+		sinks: sinks,
+		now:   time.Now,
+	}
+}
+
+// Start delivers due events every poll interval in the background. the returned func stops the relay and waits
+// for the delivery in progress
+func (obj *Relay) Start() func() {
+	return poller.Start(pollInterval, batchSize, "relay outbox events", obj.Deliver)
+}
+
+// Deliver makes one pass over the due events and returns how many it handled, delivered or not
+func (obj *Relay) Deliver(ctx context.Context) (int, error) {
+	events, err := obj.store.GetDueOutboxEvents(ctx, obj.now(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		if err := obj.deliver(ctx, event); err != nil {
+			return 0, err
+		}
+	}
+	return len(events), nil
+}
+
+// deliver claims the event and publishes it. a failed publish is retried with exponential backoff until the
+// event runs out of attempts. only store errors are returned
+func (obj *Relay) deliver(ctx context.Context, event dboutbox.OutboxEvent) error {
+	eventId := event.EventId.Int64
+	claimed, err := obj.store.ClaimOutboxEvent(ctx, eventId, event.Attempts.Int64, obj.now().Add(lease))
+	if err != nil || !claimed {
+		return err
+	}
+	attempts := event.Attempts.Int64 + 1
+
+	msg := Message{
+		EventId:       eventId,
+		EventType:     event.EventType.String,
+		AggregateType: event.AggregateType.String,
+		AggregateId:   event.AggregateId.Int64,
+		OccurredAt:    event.CreatedAt.Time.UTC(),
+		Payload:       json.RawMessage(event.Payload.String),
+	}
+	for _, sink := range obj.sinks {
+		if err := sink.Publish(ctx, msg); err != nil {
+			log.Printf("failed to publish outbox event %d, attempt %d. Error: %s", eventId, attempts, err.Error())
+			if attempts >= maxAttempts {
+				log.Printf("outbox event %d failed after %d attempts", eventId, attempts)
+				return obj.store.FailOutboxEvent(ctx, eventId, err.Error())
+			}
+			return obj.store.RetryOutboxEvent(ctx, eventId, obj.now().Add(poller.Backoff(baseDelay, maxDelay, attempts)), err.Error())
+		}
+	}
+	return obj.store.MarkOutboxEventDelivered(ctx, eventId, obj.now())
+}
@@ -0,0 +1,130 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/memory"
+	dboutbox "aspire-assignment/pkg/db/v1/outbox"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// recordingSink keeps the messages it was given and fails while failing is set
+type recordingSink struct {
+	messages []Message
+	failing  bool
+}
+
+func (obj *recordingSink) Publish(ctx context.Context, msg Message) error {
+	if obj.failing {
+		return fmt.Errorf("sink down")
+	}
+	obj.messages = append(obj.messages, msg)
+	return nil
+}
+
+func (obj *recordingSink) eventTypes() []string {
+	types := make([]string, 0)
+	for _, msg := range obj.messages {
+		types = append(types, msg.EventType)
+	}
+	return types
+}
+
+func Test_Relay_Deliver(t *testing.T) {
+	ctx := context.Background()
+	baseDelay, maxDelay, maxAttempts, batchSize = time.Second, time.Minute, 3, 10
+
+	store := memory.NewV1DbLayer()
+	userId, _ := store.AddUser(ctx, usermanagement.UserDetails{})
+	event := func(eventType string, loanId int64) dboutbox.Event {
+		return dboutbox.Event{EventType: eventType, AggregateType: "loan", Payload: `{"userId":1}`, AggregateId: loanId}
+	}
+	applied := loan.StatusChange{To: "PENDING", Actor: "CUSTOMER", ActorId: userId}
+	loanId, _ := store.CreateLoan(ctx, userId, 3000, 3, applied, []dboutbox.Event{event("loan.applied", 0)})
+	store.ModifyLoan(ctx, userId, loanId, 4500, 3, []dboutbox.Event{event("loan.modified", loanId)})
+
+	now := time.Now()
+	sink := &recordingSink{failing: true}
+	other := &recordingSink{}
+	relay := NewRelay(store, []Sink{other, sink})
+	relay.now = func() time.Time { return now }
+
+	//a failed delivery is retried after the backoff and holds the later events of the loan
+	handled, err := relay.Deliver(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, handled)
+	handled, _ = relay.Deliver(ctx)
+	assert.Equal(t, 0, handled)
+
+	now = now.Add(time.Second)
+	relay.Deliver(ctx)
+	assert.Equal(t, 0, len(sink.messages))
+
+	//every sink is given the event again until all of them took it
+	sink.failing = false
+	now = now.Add(2 * time.Second)
+	relay.Deliver(ctx)
+	relay.Deliver(ctx)
+	assert.Equal(t, []string{"loan.applied", "loan.modified"}, sink.eventTypes())
+	assert.Equal(t, []string{"loan.applied", "loan.applied", "loan.applied", "loan.modified"}, other.eventTypes())
+
+	msg := sink.messages[0]
+	assert.Equal(t, "loan", msg.AggregateType)
+	assert.Equal(t, loanId, msg.AggregateId)
+	assert.Equal(t, `{"userId":1}`, string(msg.Payload))
+	assert.NotEqual(t, int64(0), msg.EventId)
+	assert.NotEqual(t, sink.messages[1].EventId, msg.EventId)
+
+	handled, _ = relay.Deliver(ctx)
+	assert.Equal(t, 0, handled)
+
+	//an event is given up after the last attempt and the next one of the loan goes out
+	store.UpdateLoanStatus(ctx, loanId, loan.StatusChange{From: "PENDING", To: "CANCELLED", Actor: "CUSTOMER", ActorId: userId}, []dboutbox.Event{event("loan.cancelled", loanId)})
+	store.CreateLoan(ctx, userId, 1000, 1, applied, []dboutbox.Event{event("loan.applied", 0)})
+	sink.failing = true
+	for i := 0; i < 3; i++ {
+		relay.Deliver(ctx)
+		now = now.Add(time.Hour)
+	}
+	due, _ := store.GetDueOutboxEvents(ctx, now, 10)
+	assert.Equal(t, 0, len(due))
+
+	//a lease which ended without a result has the event delivered again
+	store.UpdateLoanStatus(ctx, loanId, loan.StatusChange{}, []dboutbox.Event{event("loan.closed", loanId)})
+	due, _ = store.GetDueOutboxEvents(ctx, now, 10)
+	store.ClaimOutboxEvent(ctx, due[0].EventId.Int64, 0, now.Add(lease))
+	sink.failing = false
+	relay.Deliver(ctx)
+	assert.Equal(t, []string{"loan.applied", "loan.modified"}, sink.eventTypes())
+	now = now.Add(lease)
+	relay.Deliver(ctx)
+	assert.Equal(t, []string{"loan.applied", "loan.modified", "loan.closed"}, sink.eventTypes())
+}
+
+func Test_Relay_Start(t *testing.T) {
+	ctx := context.Background()
+	pollInterval, batchSize = 10*time.Millisecond, 1
+
+	store := memory.NewV1DbLayer()
+	for i := 0; i < 3; i++ {
+		store.CreateLoan(ctx, 1, 1000, 1, loan.StatusChange{To: "PENDING", Actor: "CUSTOMER"}, []dboutbox.Event{{EventType: "loan.applied", AggregateType: "loan", Payload: "{}"}})
+	}
+	sink := &recordingSink{}
+	stop := NewRelay(store, []Sink{sink}).Start()
+	//full batches are followed by the next one without waiting for the poll interval
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if due, _ := store.GetDueOutboxEvents(ctx, time.Now().Add(time.Hour), 10); len(due) == 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+	assert.Equal(t, 3, len(sink.messages))
+}
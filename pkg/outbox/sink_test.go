@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func testMessage(eventId int64) Message {
+	return Message{
+		EventId:       eventId,
+		EventType:     "loan.approved",
+		AggregateType: "loan",
+		AggregateId:   3,
+		OccurredAt:    time.Date(2024, 8, 8, 10, 0, 0, 0, time.UTC),
+		Payload:       json.RawMessage(`{"userId":1}`),
+	}
+}
+
+func Test_FileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	sink, err := NewFileSink(path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, sink.Publish(context.Background(), testMessage(1)))
+	assert.Equal(t, nil, sink.Publish(context.Background(), testMessage(2)))
+
+	file, _ := os.Open(path)
+	defer file.Close()
+	lines := make([]Message, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var msg Message
+		assert.Equal(t, nil, json.Unmarshal(scanner.Bytes(), &msg))
+		lines = append(lines, msg)
+	}
+	assert.Equal(t, []Message{testMessage(1), testMessage(2)}, lines)
+}
+
+func Test_HttpSink(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "Accepted", status: http.StatusAccepted},
+		{name: "Rejected", status: http.StatusBadRequest, wantErr: true},
+		{name: "Unavailable", status: http.StatusServiceUnavailable, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received *http.Request
+			var body []byte
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				received = r
+				body, _ = io.ReadAll(r.Body)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewHttpSink(server.URL, time.Second).Publish(context.Background(), testMessage(7))
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, http.MethodPost, received.Method)
+			assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+			assert.Equal(t, "7", received.Header.Get(EVENT_ID_HEADER))
+			assert.Equal(t, "loan.approved", received.Header.Get(EVENT_TYPE_HEADER))
+			var msg Message
+			json.Unmarshal(body, &msg)
+			assert.Equal(t, testMessage(7), msg)
+		})
+	}
+
+	//an unreachable consumer is a failed delivery
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	assert.NotEqual(t, nil, NewHttpSink(server.URL, time.Second).Publish(context.Background(), testMessage(7)))
+}
@@ -0,0 +1,73 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// FakeProvider plays the payment provider: it sends notifications signed the way the provider signs them, so the
+// webhook can be tried locally and tested without an account with the provider
+type FakeProvider struct {
+	secret string
+	client *http.Client
+	now    func() time.Time
+	seq    atomic.Int64
+}
+
+func NewFakeProvider(secret string) *FakeProvider {
+	return &FakeProvider{
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+		now:    time.Now,
+	}
+}
+
+// Payment returns a new notification of a succeeded payment quoting the reference of the loan
+func (fake *FakeProvider) Payment(loanId int64, amount float64) Event {
+	seq := fake.seq.Add(1)
+	now := fake.now()
+	return Event{
+		Id:      fmt.Sprintf("evt_%d_%d", now.UnixNano(), seq),
+		Type:    EVENT_PAYMENT_SUCCEEDED,
+		Created: now.Unix(),
+		Data: Payment{
+			PaymentId: fmt.Sprintf("pay_%d_%d", now.UnixNano(), seq),
+			Amount:    amount,
+			Reference: Reference(loanId),
+		},
+	}
+}
+
+// Request returns the signed notification of the event to url
+func (fake *FakeProvider) Request(ctx context.Context, url string, event Event) (*http.Request, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SIGNATURE_HEADER, Sign(fake.secret, fake.now().Unix(), body))
+	return request, nil
+}
+
+// Send posts the notification of the event to url and returns the status code of the answer
+func (fake *FakeProvider) Send(ctx context.Context, url string, event Event) (int, error) {
+	request, err := fake.Request(ctx, url, event)
+	if err != nil {
+		return 0, err
+	}
+	response, err := fake.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	return response.StatusCode, nil
+}
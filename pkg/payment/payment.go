@@ -0,0 +1,155 @@
+package payment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"aspire-assignment/pkg/config"
+)
+
+// SIGNATURE_HEADER carries the signature of a notification as "t=<unix seconds>,v1=<hex hmac>"
+const SIGNATURE_HEADER = "X-Payment-Signature"
+
+// notification types of the provider. only succeeded payments repay loans
+const (
+	EVENT_PAYMENT_SUCCEEDED = "payment.succeeded"
+	EVENT_PAYMENT_FAILED    = "payment.failed"
+)
+
+// gateway settings, overridden by payments.* in config
+var (
+	provider = "fakepay"
+	//notifications are refused while there is no secret shared with the provider
+	webhookSecret string
+	//how old a signature may be, so a captured notification cannot be replayed later
+	tolerance            = 5 * time.Minute
+	virtualAccountPrefix = "9911"
+	//how long a received notification is left to the request applying it before a retry may take it over
+	lease = time.Minute
+)
+
+var (
+	ErrWebhookDisabled  = errors.New("payment webhook secret is not configured")
+	ErrInvalidSignature = errors.New("invalid payment signature")
+	ErrStaleSignature   = errors.New("payment signature is too old")
+)
+
+func InitGateway() {
+	confi := config.GetConfig()
+	if value := confi.GetString("payments.provider"); value != "" {
+		provider = value
+	}
+	webhookSecret = confi.GetString("payments.webhook.secret")
+	if value := confi.GetDuration("payments.webhook.tolerance"); value > 0 {
+		tolerance = value
+	}
+	if value := confi.GetString("payments.virtual_account_prefix"); value != "" {
+		virtualAccountPrefix = value
+	}
+	if value := confi.GetDuration("payments.lease"); value > 0 {
+		lease = value
+	}
+	if webhookSecret == "" {
+		log.Println("payments.webhook.secret is not set, payment notifications will be refused")
+	}
+	log.Println("InitGateway successful")
+}
+
+// Provider names the payment provider the notifications come from
+func Provider() string {
+	return provider
+}
+
+// Lease is how long a received notification is left to the request applying it
+func Lease() time.Duration {
+	return lease
+}
+
+// Event is a notification of the provider. Id is unique per notification and is sent again with every retry of it
+type Event struct {
+	Id      string  `json:"id"`
+	Type    string  `json:"type"`
+	Created int64   `json:"created"`
+	Data    Payment `json:"data"`
+}
+
+// Payment is a payment received by the provider. it names the loan either by the virtual account it was paid
+// into or by the reference the payer quoted
+type Payment struct {
+	PaymentId      string  `json:"paymentId"`
+	Amount         float64 `json:"amount"`
+	Reference      string  `json:"reference,omitempty"`
+	VirtualAccount string  `json:"virtualAccount,omitempty"`
+}
+
+// Sign returns the signature header of a notification: the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// secret shared with the provider, with the timestamp in unix seconds
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Verify checks the signature header of a notification body against the configured secret. a header may hold
+// several v1 signatures while the provider rolls its secret, one matching is enough
+func Verify(header string, body []byte, now time.Time) error {
+	if webhookSecret == "" {
+		return ErrWebhookDisabled
+	}
+	var (
+		timestamp  int64
+		signatures []string
+	)
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp, _ = strconv.ParseInt(value, 10, 64)
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+	if timestamp == 0 || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+	_, expected, _ := strings.Cut(Sign(webhookSecret, timestamp, body), "v1=")
+	matched := false
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			matched = true
+		}
+	}
+	if !matched {
+		return ErrInvalidSignature
+	}
+	age := now.Sub(time.Unix(timestamp, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrStaleSignature
+	}
+	return nil
+}
+
+// ParseEvent verifies a notification and decodes it
+func ParseEvent(header string, body []byte, now time.Time) (Event, error) {
+	var event Event
+	if err := Verify(header, body, now); err != nil {
+		return event, err
+	}
+	if err := json.Unmarshal(body, &event); err != nil {
+		return event, err
+	}
+	if event.Id == "" || event.Type == "" {
+		return event, errors.New("payment event without id or type")
+	}
+	return event, nil
+}
@@ -0,0 +1,248 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func Test_Sign(t *testing.T) {
+	signature := Sign("paysec-test-secret", 1723111200, []byte(`{"id":"evt_1"}`))
+	assert.Equal(t, "t=1723111200,v1=b5eebb1ee09197c886bfc908545d0766e5802b131a4c5117a28306b7e813327b", signature)
+	assert.NotEqual(t, signature, Sign("paysec-test-secret", 1723111201, []byte(`{"id":"evt_1"}`)))
+	assert.NotEqual(t, signature, Sign("other-test-secret", 1723111200, []byte(`{"id":"evt_1"}`)))
+}
+
+func Test_ParseEvent(t *testing.T) {
+	webhookSecret = "paysec-test-secret"
+	defer func() {
+		webhookSecret = ""
+	}()
+
+	now := time.Unix(1723111200, 0)
+	body := []byte(`{"id":"evt_1","type":"payment.succeeded","created":1723111200,"data":{"paymentId":"pay_1","amount":1000,"reference":"AL000000034"}}`)
+	signed := Sign("paysec-test-secret", now.Unix(), body)
+	otherSignature := Sign("other-test-secret", now.Unix(), body)
+
+	tests := []struct {
+		name     string
+		disabled bool
+		header   string
+		body     []byte
+		now      time.Time
+		wantErr  error
+	}{
+		{
+			name:   "Valid",
+			header: signed,
+			body:   body,
+			now:    now,
+		},
+		{
+			name:   "WithinTolerance",
+			header: signed,
+			body:   body,
+			now:    now.Add(4 * time.Minute),
+		},
+		{
+			name:    "Stale",
+			header:  signed,
+			body:    body,
+			now:     now.Add(6 * time.Minute),
+			wantErr: ErrStaleSignature,
+		},
+		{
+			name:    "FromTheFuture",
+			header:  signed,
+			body:    body,
+			now:     now.Add(-6 * time.Minute),
+			wantErr: ErrStaleSignature,
+		},
+		{
+			name:    "TamperedBody",
+			header:  signed,
+			body:    []byte(`{"id":"evt_1","type":"payment.succeeded","created":1723111200,"data":{"paymentId":"pay_1","amount":9000,"reference":"AL000000034"}}`),
+			now:     now,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:    "OtherSecret",
+			header:  otherSignature,
+			body:    body,
+			now:     now,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			//while the provider rolls its secret it signs with both
+			name:   "RolledSecret",
+			header: Sign("other-test-secret", now.Unix(), body) + ",v1=" + signed[len("t=1723111200,v1="):],
+			body:   body,
+			now:    now,
+		},
+		{
+			name:    "MissingHeader",
+			body:    body,
+			now:     now,
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:     "NoSecret",
+			disabled: true,
+			header:   signed,
+			body:     body,
+			now:      now,
+			wantErr:  ErrWebhookDisabled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhookSecret = "paysec-test-secret"
+			if tt.disabled {
+				webhookSecret = ""
+			}
+
+			event, err := ParseEvent(tt.header, tt.body, tt.now)
+			assert.Equal(t, true, errors.Is(err, tt.wantErr))
+			if tt.wantErr == nil {
+				assert.Equal(t, Event{Id: "evt_1", Type: EVENT_PAYMENT_SUCCEEDED, Created: 1723111200, Data: Payment{PaymentId: "pay_1", Amount: 1000, Reference: "AL000000034"}}, event)
+			}
+		})
+	}
+}
+
+func Test_Payment_LoanId(t *testing.T) {
+	tests := []struct {
+		name    string
+		payment Payment
+		loanId  int64
+		found   bool
+	}{
+		{
+			name:    "Reference",
+			payment: Payment{Reference: Reference(3)},
+			loanId:  3,
+			found:   true,
+		},
+		{
+			name:    "ReferenceTypedByHand",
+			payment: Payment{Reference: " al000000034 "},
+			loanId:  3,
+			found:   true,
+		},
+		{
+			name:    "MistypedReference",
+			payment: Payment{Reference: "AL000000043"},
+		},
+		{
+			name:    "NotAReference",
+			payment: Payment{Reference: "invoice 42"},
+		},
+		{
+			name:    "VirtualAccount",
+			payment: Payment{VirtualAccount: VirtualAccount(1234567), Reference: Reference(3)},
+			loanId:  1234567,
+			found:   true,
+		},
+		{
+			name:    "VirtualAccountOfAnotherBank",
+			payment: Payment{VirtualAccount: "1234000000034", Reference: Reference(3)},
+		},
+		{
+			name:    "LoanZero",
+			payment: Payment{Reference: Reference(0)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanId, found := tt.payment.LoanId()
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.loanId, loanId)
+		})
+	}
+}
+
+func Test_LoanIdIn(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		loanId int64
+		found  bool
+	}{
+		{
+			name:   "ReferenceInText",
+			text:   "REPAYMENT LOAN/AL000000034 JOHN",
+			loanId: 3,
+			found:  true,
+		},
+		{
+			name:   "VirtualAccountInText",
+			text:   "TRF TO 9911000000034",
+			loanId: 3,
+			found:  true,
+		},
+		{
+			name:   "SameLoanTwice",
+			text:   "al000000034 ref AL000000034",
+			loanId: 3,
+			found:  true,
+		},
+		{
+			name: "TwoLoans",
+			text: "AL000000034 AL000000018",
+		},
+		{
+			name: "MistypedReference",
+			text: "payment AL000000043",
+		},
+		{
+			name: "NoReference",
+			text: "rent march",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanId, found := LoanIdIn(tt.text)
+			assert.Equal(t, tt.found, found)
+			assert.Equal(t, tt.loanId, loanId)
+		})
+	}
+}
+
+func Test_FakeProvider_Send(t *testing.T) {
+	webhookSecret = "paysec-test-secret"
+	defer func() {
+		webhookSecret = ""
+	}()
+
+	var received Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		event, err := ParseEvent(r.Header.Get(SIGNATURE_HEADER), body, time.Now())
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		received = event
+	}))
+	defer server.Close()
+
+	fake := NewFakeProvider("paysec-test-secret")
+	event := fake.Payment(3, 1000)
+	code, err := fake.Send(context.Background(), server.URL, event)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, event, received)
+	assert.Equal(t, "AL000000034", received.Data.Reference)
+	//every notification is a new event
+	assert.NotEqual(t, event.Id, fake.Payment(3, 1000).Id)
+
+	code, err = NewFakeProvider("other-test-secret").Send(context.Background(), server.URL, event)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, http.StatusUnauthorized, code)
+}
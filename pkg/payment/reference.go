@@ -0,0 +1,86 @@
+package payment
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const REFERENCE_PREFIX = "AL"
+
+// Reference is what a customer quotes when paying a loan by transfer: the loan id behind a prefix and followed by a
+// check digit, so a mistyped reference does not repay another loan
+func Reference(loanId int64) string {
+	return REFERENCE_PREFIX + withCheckDigit(fmt.Sprintf("%08d", loanId))
+}
+
+// VirtualAccount is the account number the provider collects the payments of a loan into
+func VirtualAccount(loanId int64) string {
+	return virtualAccountPrefix + withCheckDigit(fmt.Sprintf("%08d", loanId))
+}
+
+// LoanId returns the loan the payment is for, from its virtual account or else from its reference, and false
+// when neither names a loan
+func (payment Payment) LoanId() (int64, bool) {
+	if payment.VirtualAccount != "" {
+		return loanId(strings.TrimSpace(payment.VirtualAccount), virtualAccountPrefix)
+	}
+	return loanId(strings.ToUpper(strings.TrimSpace(payment.Reference)), REFERENCE_PREFIX)
+}
+
+// LoanIdIn finds the loan named in free text such as the remittance information of a bank transfer, by a
+// reference or a virtual account in it. it returns false when the text names no loan, or names more than one
+func LoanIdIn(text string) (int64, bool) {
+	var found int64
+	words := strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		id, ok := loanId(word, REFERENCE_PREFIX)
+		if !ok {
+			id, ok = loanId(word, virtualAccountPrefix)
+		}
+		if !ok {
+			continue
+		}
+		if found != 0 && found != id {
+			return 0, false
+		}
+		found = id
+	}
+	return found, found != 0
+}
+
+func loanId(value string, prefix string) (int64, bool) {
+	digits, found := strings.CutPrefix(value, prefix)
+	if !found || len(digits) < 2 || withCheckDigit(digits[:len(digits)-1]) != digits {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(digits[:len(digits)-1], 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// withCheckDigit appends the Luhn check digit of digits
+func withCheckDigit(digits string) string {
+	sum := 0
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := int(digits[i] - '0')
+		if digit < 0 || digit > 9 {
+			return ""
+		}
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return digits + strconv.Itoa((10-sum%10)%10)
+}
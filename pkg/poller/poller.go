@@ -0,0 +1,58 @@
+package poller
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Pass handles one batch of due work and returns how many items it handled, successful or not
+type Pass func(context.Context) (int, error)
+
+// Start runs pass every interval in the background. a pass handling a full batch is followed by the next one
+// without waiting for the interval, as more work is due, and a batch size of 0 runs one pass per interval. a failed
+// pass is logged as "failed to <work>". the returned func stops the poller and waits for the pass in progress
+func Start(interval time.Duration, batchSize int, work string, pass Pass) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for {
+					handled, err := pass(ctx)
+					if err != nil {
+						log.Printf("failed to %s. Error: %s", work, err.Error())
+					}
+					if err != nil || batchSize <= 0 || handled < batchSize || ctx.Err() != nil {
+						break
+					}
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// Backoff is the wait before the next attempt of work which failed the given number of attempts. it starts at base
+// and doubles with every attempt up to max
+func Backoff(base time.Duration, max time.Duration, attempts int64) time.Duration {
+	delay := base
+	for i := int64(1); i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
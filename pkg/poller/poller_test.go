@@ -0,0 +1,80 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func Test_Backoff(t *testing.T) {
+	assert.Equal(t, time.Second, Backoff(time.Second, time.Minute, 1))
+	assert.Equal(t, 2*time.Second, Backoff(time.Second, time.Minute, 2))
+	assert.Equal(t, 8*time.Second, Backoff(time.Second, time.Minute, 4))
+	assert.Equal(t, time.Minute, Backoff(time.Second, time.Minute, 7))
+	assert.Equal(t, time.Minute, Backoff(time.Second, time.Minute, 100))
+}
+
+func Test_Start(t *testing.T) {
+	interval := 20 * time.Millisecond
+	tests := []struct {
+		name      string
+		batchSize int
+		handled   int
+		err       error
+		//whether the second pass follows the first straight away or waits for the next interval
+		waits bool
+	}{
+		{
+			name:      "FullBatch",
+			batchSize: 2,
+			handled:   2,
+		},
+		{
+			name:      "PartialBatch",
+			batchSize: 2,
+			handled:   1,
+			waits:     true,
+		},
+		{
+			name:      "NoBatchSize",
+			batchSize: 0,
+			handled:   5,
+			waits:     true,
+		},
+		{
+			name:      "Failed",
+			batchSize: 2,
+			handled:   2,
+			err:       errors.New("store down"),
+			waits:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				passes []time.Time
+				done   = make(chan struct{})
+			)
+			stop := Start(interval, tt.batchSize, "test", func(ctx context.Context) (int, error) {
+				passes = append(passes, time.Now())
+				if len(passes) == 2 {
+					close(done)
+				}
+				if len(passes) >= 2 {
+					return 0, nil
+				}
+				return tt.handled, tt.err
+			})
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("second pass did not run")
+			}
+			stop()
+			assert.Equal(t, tt.waits, passes[1].Sub(passes[0]) >= interval/2)
+		})
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"aspire-assignment/pkg/db"
+	"aspire-assignment/pkg/notifier"
 	v1 "aspire-assignment/pkg/service/v1"
+	"aspire-assignment/pkg/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,9 +18,9 @@ type ServiceGroupLayer interface {
 	Health(*gin.Context)
 }
 
-func NewServiceGroupObject(db db.DBLayer) ServiceGroupLayer {
+func NewServiceGroupObject(db db.DBLayer, store storage.BlobStore, notifier notifier.Notifier) ServiceGroupLayer {
 	return &service{
-		v1.NewServiceObject(db.GetV1DBLayer()),
+		v1.NewServiceObject(db.GetV1DBLayer(), store, notifier),
 	}
 }
 
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"aspire-assignment/pkg/audit"
+	dbaudit "aspire-assignment/pkg/db/v1/audit"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Verification is the result of a chain check: how many entries were checked and the hash of the last one.
+// keeping Head outside the database shows whether entries were later cut from the end of the log
+type Verification struct {
+	Entries int64
+	Head    string
+}
+
+// ChainError is the first entry whose hash or link to the entry before it does not match
+type ChainError struct {
+	EntryId int64
+	Reason  string
+}
+
+func (err *ChainError) Error() string {
+	return fmt.Sprintf("audit chain broken at entry %d: %s", err.EntryId, err.Reason)
+}
+
+// chainedFields is what the hash of an entry covers, in a fixed order. the id is left out as the chain
+// itself orders the entries
+type chainedFields struct {
+	PrevHash    string `json:"prevHash"`
+	ActorType   string `json:"actorType"`
+	ActorId     int64  `json:"actorId"`
+	Actor       string `json:"actor"`
+	Roles       string `json:"roles"`
+	Action      string `json:"action"`
+	Outcome     string `json:"outcome"`
+	TargetType  string `json:"targetType"`
+	TargetId    string `json:"targetId"`
+	BeforeState string `json:"beforeState"`
+	AfterState  string `json:"afterState"`
+	RequestId   string `json:"requestId"`
+	Ip          string `json:"ip"`
+	CreatedAt   int64  `json:"createdAt"`
+}
+
+// RecordAudit appends the entry to the chain. two writers linking to the same head collide on the unique
+// prev_hash and the loser links to the new head instead
+func (obj *auditService) RecordAudit(ctx context.Context, entry audit.Entry) error {
+	var err error
+	for attempt := 0; attempt < MAX_APPEND_ATTEMPTS; attempt++ {
+		var head dbaudit.AuditEntry
+		head, err = obj.dbObj.GetLastAuditEntry(ctx)
+		if err != nil {
+			return err
+		}
+		prevHash := GENESIS_HASH
+		if head.Hash.Valid {
+			prevHash = head.Hash.String
+		}
+
+		row := newRow(entry, prevHash, time.Now())
+		if _, err = obj.dbObj.AddAuditEntry(ctx, row); !usermanagement.IsUniqueViolation(err) {
+			return err
+		}
+		log.Printf("audit chain head moved while writing %s. retrying", entry.Action)
+	}
+	return err
+}
+
+// VerifyAuditLog walks the chain from the first entry and recomputes every hash
+func (obj *auditService) VerifyAuditLog(ctx context.Context) (Verification, error) {
+	verification := Verification{Head: GENESIS_HASH}
+	var afterId int64
+	for {
+		entries, err := obj.dbObj.GetAuditEntries(ctx, dbaudit.AuditFilter{AfterId: afterId, Limit: VERIFY_PAGE_SIZE})
+		if err != nil {
+			return verification, err
+		}
+		for _, entry := range entries {
+			if entry.PrevHash.String != verification.Head {
+				return verification, &ChainError{EntryId: entry.EntryId.Int64, Reason: "does not link to the entry before it"}
+			}
+			if entry.Hash.String != hashEntry(entry) {
+				return verification, &ChainError{EntryId: entry.EntryId.Int64, Reason: "hash does not match its contents"}
+			}
+			verification.Head = entry.Hash.String
+			verification.Entries++
+			afterId = entry.EntryId.Int64
+		}
+		if len(entries) < VERIFY_PAGE_SIZE {
+			return verification, nil
+		}
+	}
+}
+
+func newRow(entry audit.Entry, prevHash string, now time.Time) dbaudit.AuditEntry {
+	row := dbaudit.AuditEntry{
+		ActorType:   sql.NullString{String: entry.ActorType, Valid: true},
+		ActorId:     sql.NullInt64{Int64: entry.ActorId, Valid: entry.ActorId != 0},
+		Actor:       sql.NullString{String: entry.Actor, Valid: true},
+		Roles:       sql.NullString{String: strings.Join(entry.Roles, ","), Valid: true},
+		Action:      sql.NullString{String: entry.Action, Valid: true},
+		Outcome:     sql.NullString{String: entry.Outcome, Valid: true},
+		TargetType:  sql.NullString{String: entry.TargetType, Valid: true},
+		TargetId:    sql.NullString{String: entry.TargetId, Valid: true},
+		BeforeState: sql.NullString{String: entry.Before, Valid: true},
+		AfterState:  sql.NullString{String: entry.After, Valid: true},
+		RequestId:   sql.NullString{String: entry.RequestId, Valid: true},
+		Ip:          sql.NullString{String: entry.Ip, Valid: true},
+		//the db keeps microseconds, so the hash is taken over what reads back
+		CreatedAt: sql.NullTime{Time: now.UTC().Truncate(time.Microsecond), Valid: true},
+		PrevHash:  sql.NullString{String: prevHash, Valid: true},
+	}
+	row.Hash = sql.NullString{String: hashEntry(row), Valid: true}
+	return row
+}
+
+// hashEntry is the sha256 of the entry and the hash of the entry before it
+func hashEntry(entry dbaudit.AuditEntry) string {
+	fields, _ := json.Marshal(chainedFields{
+		PrevHash:    entry.PrevHash.String,
+		ActorType:   entry.ActorType.String,
+		ActorId:     entry.ActorId.Int64,
+		Actor:       entry.Actor.String,
+		Roles:       entry.Roles.String,
+		Action:      entry.Action.String,
+		Outcome:     entry.Outcome.String,
+		TargetType:  entry.TargetType.String,
+		TargetId:    entry.TargetId.String,
+		BeforeState: entry.BeforeState.String,
+		AfterState:  entry.AfterState.String,
+		RequestId:   entry.RequestId.String,
+		Ip:          entry.Ip.String,
+		CreatedAt:   entry.CreatedAt.Time.UnixMicro(),
+	})
+	sum := sha256.Sum256(fields)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,169 @@
+package audit
+
+import (
+	"aspire-assignment/pkg/audit"
+	dbaudit "aspire-assignment/pkg/db/v1/audit"
+	"aspire-assignment/pkg/db/v1/memory"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func entry(action string, targetId int64) audit.Entry {
+	return audit.Entry{
+		ActorType:  "ADMIN",
+		ActorId:    1,
+		Actor:      "admin",
+		Roles:      []string{"ADMIN"},
+		Action:     action,
+		Outcome:    audit.SUCCESS,
+		TargetType: audit.TARGET_LOAN,
+		TargetId:   fmt.Sprint(targetId),
+		Before:     `{"status":"PENDING"}`,
+		After:      `{"status":"APPROVED"}`,
+		RequestId:  "req-1",
+		Ip:         "10.0.0.1",
+	}
+}
+
+func Test_auditService_RecordAudit(t *testing.T) {
+	ctx := context.Background()
+	dbObj := memory.NewV1DbLayer()
+	servObj := NewAuditService(dbObj)
+
+	for i := int64(1); i <= 3; i++ {
+		assert.Equal(t, nil, servObj.RecordAudit(ctx, entry(audit.LOAN_DECISION, i)))
+	}
+
+	entries, err := dbObj.GetAuditEntries(ctx, dbaudit.AuditFilter{Limit: 10})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(entries))
+	assert.Equal(t, GENESIS_HASH, entries[0].PrevHash.String)
+	assert.Equal(t, entries[0].Hash.String, entries[1].PrevHash.String)
+	assert.Equal(t, entries[1].Hash.String, entries[2].PrevHash.String)
+	assert.Equal(t, "ADMIN", entries[0].Roles.String)
+	assert.Equal(t, "3", entries[2].TargetId.String)
+
+	verification, err := servObj.VerifyAuditLog(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, Verification{Entries: 3, Head: entries[2].Hash.String}, verification)
+}
+
+func Test_auditService_RecordAuditRace(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	servObj := NewAuditService(repo)
+
+	//another writer takes the head between the read and the insert, so the entry links to the new head
+	first := dbaudit.AuditEntry{Hash: sql.NullString{String: "first", Valid: true}}
+	second := dbaudit.AuditEntry{Hash: sql.NullString{String: "second", Valid: true}}
+	gomock.InOrder(
+		repo.EXPECT().GetLastAuditEntry(ctx).Return(first, nil),
+		repo.EXPECT().AddAuditEntry(ctx, gomock.Any()).Return(int64(0), errors.New(`duplicate key value violates unique constraint "audit_log_prev_hash_key" (SQLSTATE 23505)`)),
+		repo.EXPECT().GetLastAuditEntry(ctx).Return(second, nil),
+		repo.EXPECT().AddAuditEntry(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, row dbaudit.AuditEntry) (int64, error) {
+			assert.Equal(t, "second", row.PrevHash.String)
+			assert.Equal(t, hashEntry(row), row.Hash.String)
+			return int64(3), nil
+		}),
+	)
+	assert.Equal(t, nil, servObj.RecordAudit(ctx, entry(audit.LOAN_REPAY, 1)))
+
+	//other errors are not retried
+	repo.EXPECT().GetLastAuditEntry(ctx).Return(second, nil)
+	repo.EXPECT().AddAuditEntry(ctx, gomock.Any()).Return(int64(0), errors.New("db down"))
+	assert.NotEqual(t, nil, servObj.RecordAudit(ctx, entry(audit.LOAN_REPAY, 1)))
+}
+
+func Test_auditService_VerifyAuditLog(t *testing.T) {
+	ctx := context.Background()
+
+	//a chain of four entries, tampered with by each test
+	var chain []dbaudit.AuditEntry
+	prevHash := GENESIS_HASH
+	for i := int64(1); i <= 4; i++ {
+		row := newRow(entry(audit.LOAN_DECISION, i), prevHash, newTime(i))
+		row.EntryId = sql.NullInt64{Int64: i, Valid: true}
+		chain = append(chain, row)
+		prevHash = row.Hash.String
+	}
+
+	tests := []struct {
+		name    string
+		tamper  func([]dbaudit.AuditEntry) []dbaudit.AuditEntry
+		entries int64
+		brokeAt int64
+	}{
+		{
+			name:    "Intact",
+			tamper:  func(entries []dbaudit.AuditEntry) []dbaudit.AuditEntry { return entries },
+			entries: 4,
+		},
+		{
+			name: "ChangedEntry",
+			tamper: func(entries []dbaudit.AuditEntry) []dbaudit.AuditEntry {
+				entries[2].AfterState.String = `{"status":"REJECTED"}`
+				return entries
+			},
+			entries: 2,
+			brokeAt: 3,
+		},
+		{
+			name: "RemovedEntry",
+			tamper: func(entries []dbaudit.AuditEntry) []dbaudit.AuditEntry {
+				return append(entries[:1], entries[2:]...)
+			},
+			entries: 1,
+			brokeAt: 3,
+		},
+		{
+			name: "RemovedFirstEntry",
+			tamper: func(entries []dbaudit.AuditEntry) []dbaudit.AuditEntry {
+				return entries[1:]
+			},
+			brokeAt: 2,
+		},
+		{
+			name: "RehashedEntry",
+			tamper: func(entries []dbaudit.AuditEntry) []dbaudit.AuditEntry {
+				//rewriting an entry with a valid hash of its own still breaks the link of the next one
+				entries[1].Actor.String = "someone else"
+				entries[1].Hash.String = hashEntry(entries[1])
+				return entries
+			},
+			entries: 2,
+			brokeAt: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := dbmock.NewMockV1DBLayer(ctrl)
+			entries := tt.tamper(append([]dbaudit.AuditEntry{}, chain...))
+			repo.EXPECT().GetAuditEntries(ctx, dbaudit.AuditFilter{Limit: VERIFY_PAGE_SIZE}).Return(entries, nil).Times(1)
+
+			verification, err := NewAuditService(repo).VerifyAuditLog(ctx)
+			assert.Equal(t, tt.entries, verification.Entries)
+			if tt.brokeAt == 0 {
+				assert.Equal(t, nil, err)
+				assert.Equal(t, chain[3].Hash.String, verification.Head)
+				return
+			}
+			var chainErr *ChainError
+			assert.Equal(t, true, errors.As(err, &chainErr))
+			assert.Equal(t, tt.brokeAt, chainErr.EntryId)
+		})
+	}
+}
+
+func newTime(minute int64) time.Time {
+	return time.Date(2026, 1, 1, 10, int(minute), 0, 0, time.UTC)
+}
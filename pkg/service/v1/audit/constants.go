@@ -0,0 +1,12 @@
+package audit
+
+const (
+	//prev_hash of the first entry of the log
+	GENESIS_HASH = "0000000000000000000000000000000000000000000000000000000000000000"
+	//writers racing for the head of the chain retry with the new head
+	MAX_APPEND_ATTEMPTS = 5
+	//entries read per page while verifying the chain
+	VERIFY_PAGE_SIZE = 500
+	//entries per page of the audit log when no limit is asked for
+	DEFAULT_PAGE_SIZE = 100
+)
@@ -0,0 +1,25 @@
+package audit
+
+import (
+	"aspire-assignment/pkg/audit"
+	v1 "aspire-assignment/pkg/db/v1"
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+type auditService struct {
+	dbObj v1.V1DBLayer
+}
+
+type AuditInterface interface {
+	GetAuditLog(*gin.Context)
+	RecordAudit(context.Context, audit.Entry) error
+	VerifyAuditLog(context.Context) (Verification, error)
+}
+
+func NewAuditService(db v1.V1DBLayer) AuditInterface {
+	return &auditService{
+		dbObj: db,
+	}
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	dbaudit "aspire-assignment/pkg/db/v1/audit"
+	e "aspire-assignment/pkg/errors"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLog lists audit entries matching the filters, oldest first, one page at a time
+func (obj *auditService) GetAuditLog(c *gin.Context) {
+	var (
+		request  GetAuditLogRequest
+		response GetAuditLogResponse
+	)
+	if err := c.BindQuery(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to fetch audit log"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	filter := dbaudit.AuditFilter{
+		ActorId:    request.ActorId,
+		Action:     request.Action,
+		TargetType: request.TargetType,
+		TargetId:   request.TargetId,
+		From:       request.From,
+		AfterId:    request.AfterId,
+		Limit:      request.Limit,
+	}
+	if !request.To.IsZero() {
+		filter.To = request.To.Add(24 * time.Hour)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DEFAULT_PAGE_SIZE
+	}
+
+	entries, err := obj.dbObj.GetAuditEntries(c, filter)
+	if err != nil {
+		log.Printf("failed to fetch audit entries. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch audit log"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = make([]AuditEntry, 0)
+	for _, entry := range entries {
+		var roles []string
+		if entry.Roles.String != "" {
+			roles = strings.Split(entry.Roles.String, ",")
+		}
+		response.Data = append(response.Data, AuditEntry{
+			EntryId:    entry.EntryId.Int64,
+			ActorType:  entry.ActorType.String,
+			ActorId:    entry.ActorId.Int64,
+			Actor:      entry.Actor.String,
+			Roles:      roles,
+			Action:     entry.Action.String,
+			Outcome:    entry.Outcome.String,
+			TargetType: entry.TargetType.String,
+			TargetId:   entry.TargetId.String,
+			Before:     json.RawMessage(entry.BeforeState.String),
+			After:      json.RawMessage(entry.AfterState.String),
+			RequestId:  entry.RequestId.String,
+			Ip:         entry.Ip.String,
+			CreatedAt:  entry.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+			PrevHash:   entry.PrevHash.String,
+			Hash:       entry.Hash.String,
+		})
+	}
+	response.Message = "successfully fetched audit log"
+	c.JSON(http.StatusOK, response)
+}
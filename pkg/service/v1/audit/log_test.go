@@ -0,0 +1,118 @@
+package audit
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+	dbaudit "aspire-assignment/pkg/db/v1/audit"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_auditService_GetAuditLog(t *testing.T) {
+	var dbObj v1.V1DBLayer
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		query      string
+		setup      func(*gin.Context)
+		httpStatus int
+		entries    int
+	}{
+		{
+			name:  "DefaultPage",
+			query: "",
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetAuditEntries(c, dbaudit.AuditFilter{Limit: DEFAULT_PAGE_SIZE}).Return([]dbaudit.AuditEntry{}, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:  "Filtered",
+			query: "actorId=7&action=loan.decision&targetType=loan&targetId=3&from=2026-01-01&to=2026-01-31&afterId=10&limit=2",
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				//to is a whole day
+				repo.EXPECT().GetAuditEntries(c, dbaudit.AuditFilter{
+					ActorId:    7,
+					Action:     "loan.decision",
+					TargetType: "loan",
+					TargetId:   "3",
+					From:       time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+					To:         time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+					AfterId:    10,
+					Limit:      2,
+				}).Return([]dbaudit.AuditEntry{
+					{
+						EntryId:     sql.NullInt64{Int64: 11, Valid: true},
+						ActorType:   sql.NullString{String: "ADMIN", Valid: true},
+						ActorId:     sql.NullInt64{Int64: 7, Valid: true},
+						Roles:       sql.NullString{String: "ADMIN,AUDITOR", Valid: true},
+						Action:      sql.NullString{String: "loan.decision", Valid: true},
+						BeforeState: sql.NullString{String: `{"status":"PENDING"}`, Valid: true},
+						CreatedAt:   sql.NullTime{Time: time.Now(), Valid: true},
+					},
+				}, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			entries:    1,
+		},
+		{
+			name:  "LimitTooLarge",
+			query: "limit=501",
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				dbObj = dbmock.NewMockV1DBLayer(ctrl)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "InvalidDate",
+			query: "from=01-01-2026",
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				dbObj = dbmock.NewMockV1DBLayer(ctrl)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil)
+
+			tt.setup(c)
+			NewAuditService(dbObj).GetAuditLog(c)
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response GetAuditLogResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			assert.Equal(t, tt.entries, len(response.Data))
+			if tt.entries != 0 {
+				assert.Equal(t, []string{"ADMIN", "AUDITOR"}, response.Data[0].Roles)
+				assert.Equal(t, `{"status":"PENDING"}`, string(response.Data[0].Before))
+			}
+		})
+	}
+}
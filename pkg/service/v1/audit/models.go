@@ -0,0 +1,46 @@
+package audit
+
+import (
+	e "aspire-assignment/pkg/errors"
+	"encoding/json"
+	"time"
+)
+
+// GetAuditLogRequest filters the audit log. from and to are dates and both are included. afterId continues
+// from the last entry of the previous page
+type GetAuditLogRequest struct {
+	ActorId    int64     `form:"actorId"`
+	Action     string    `form:"action"`
+	TargetType string    `form:"targetType"`
+	TargetId   string    `form:"targetId"`
+	From       time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To         time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	AfterId    int64     `form:"afterId" binding:"min=0"`
+	Limit      int       `form:"limit" binding:"min=0,max=500"`
+}
+
+type GetAuditLogResponse struct {
+	Data    []AuditEntry `json:"data,omitempty"`
+	Status  bool         `json:"success"`
+	Errors  []e.Error    `json:"errors,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+type AuditEntry struct {
+	EntryId    int64           `json:"entryId"`
+	ActorType  string          `json:"actorType"`
+	ActorId    int64           `json:"actorId,omitempty"`
+	Actor      string          `json:"actor,omitempty"`
+	Roles      []string        `json:"roles,omitempty"`
+	Action     string          `json:"action"`
+	Outcome    string          `json:"outcome"`
+	TargetType string          `json:"targetType,omitempty"`
+	TargetId   string          `json:"targetId,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	RequestId  string          `json:"requestId,omitempty"`
+	Ip         string          `json:"ip,omitempty"`
+	CreatedAt  string          `json:"createdAt"`
+	PrevHash   string          `json:"prevHash"`
+	Hash       string          `json:"hash"`
+}
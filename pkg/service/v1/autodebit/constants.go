@@ -0,0 +1,6 @@
+package autodebit
+
+const (
+	//debits per page of the debits listed by status when no limit is asked for
+	DEFAULT_PAGE_SIZE = 100
+)
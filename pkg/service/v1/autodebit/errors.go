@@ -0,0 +1,25 @@
+package autodebit
+
+import (
+	"errors"
+	"net/http"
+
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/service/v1/loan"
+)
+
+var (
+	ErrLoanNotEligible  = errors.New("auto debit is only for loans pending approval or being repaid")
+	ErrMandateNotActive = errors.New("auto debit is not enabled for the loan")
+)
+
+// errorResponse maps an error of a mandate request to the HTTP status and error reported to the client
+func errorResponse(err error) (int, e.Error) {
+	switch {
+	case errors.Is(err, loan.ErrLoanNotFound), errors.Is(err, ErrMandateNotActive):
+		return http.StatusNotFound, e.ErrorInfo[e.NoDataFound].GetErrorDetails(err.Error())
+	case errors.Is(err, ErrLoanNotEligible):
+		return http.StatusBadRequest, e.ErrorInfo[e.BadRequest].GetErrorDetails(err.Error())
+	}
+	return http.StatusInternalServerError, *e.ErrorInfo[e.GetDBError]
+}
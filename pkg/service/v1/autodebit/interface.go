@@ -0,0 +1,26 @@
+package autodebit
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+// autoDebitService lets customers opt their loans in and out of auto debit. the debits themselves are made by the
+// scheduler of the autodebit package
+type autoDebitService struct {
+	dbObj v1.V1DBLayer
+}
+
+type AutoDebitInterface interface {
+	EnableAutoDebit(*gin.Context)
+	DisableAutoDebit(*gin.Context)
+	GetAutoDebits(*gin.Context)
+	GetAutoDebitsByStatus(*gin.Context)
+}
+
+func NewAutoDebitService(db v1.V1DBLayer) AutoDebitInterface {
+	return &autoDebitService{
+		dbObj: db,
+	}
+}
@@ -0,0 +1,230 @@
+package autodebit
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/autodebit"
+	dbloan "aspire-assignment/pkg/db/v1/loan"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/service/v1/loan"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnableAutoDebit opts a loan of the customer in to auto debit. the installments of the loan are then debited
+// from their account balance on their due date, so the loan must still be pending approval or being repaid
+func (obj *autoDebitService) EnableAutoDebit(c *gin.Context) {
+	var (
+		request  AutoDebitRequest
+		response MandateResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to enable auto debit"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_LOAN, request.LoanId)
+
+	detail, err := obj.ownLoan(c, request.UserId, request.LoanId)
+	if err == nil && detail.Status.String != loan.LOAN_PENDING && detail.Status.String != loan.LOAN_APPROVED {
+		err = ErrLoanNotEligible
+	}
+	if err != nil {
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
+		response.Message = "failed to enable auto debit"
+		c.JSON(status, response)
+		return
+	}
+
+	_, err = obj.dbObj.AddDebitMandate(c, autodebit.DebitMandate{
+		UserId:    sql.NullInt64{Int64: request.UserId, Valid: true},
+		LoanId:    sql.NullInt64{Int64: request.LoanId, Valid: true},
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	})
+	if err != nil {
+		log.Printf("failed to add debit mandate. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to enable auto debit"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	mandate, err := obj.dbObj.GetDebitMandate(c, request.LoanId)
+	if err != nil {
+		log.Printf("failed to fetch debit mandate. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to enable auto debit"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	audit.Change(c, nil, map[string]interface{}{"autoDebit": true})
+
+	log.Printf("auto debit enabled for LoanId: %d by UserId: %d", request.LoanId, request.UserId)
+	response.Status = true
+	data := mandateDetail(mandate, nil)
+	response.Data = &data
+	response.Message = "successfully enabled auto debit"
+	c.JSON(http.StatusOK, response)
+}
+
+// DisableAutoDebit opts a loan out of auto debit. its pending debits are cancelled, so an installment waiting for
+// another attempt is left to the customer
+func (obj *autoDebitService) DisableAutoDebit(c *gin.Context) {
+	var (
+		request  AutoDebitRequest
+		response MandateResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to disable auto debit"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_LOAN, request.LoanId)
+
+	_, err := obj.ownLoan(c, request.UserId, request.LoanId)
+	if err == nil {
+		var mandateId int64
+		mandateId, err = obj.dbObj.CancelDebitMandate(c, request.LoanId, time.Now())
+		if err == nil && mandateId == 0 {
+			err = ErrMandateNotActive
+		}
+	}
+	if err != nil {
+		log.Printf("failed to disable auto debit for LoanId: %d. Error: %s", request.LoanId, err.Error())
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
+		response.Message = "failed to disable auto debit"
+		c.JSON(status, response)
+		return
+	}
+	audit.Change(c, map[string]interface{}{"autoDebit": true}, map[string]interface{}{"autoDebit": false})
+
+	log.Printf("auto debit disabled for LoanId: %d by UserId: %d", request.LoanId, request.UserId)
+	response.Status = true
+	response.Message = "successfully disabled auto debit"
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAutoDebits lists the mandates of the customer with their debits
+func (obj *autoDebitService) GetAutoDebits(c *gin.Context) {
+	var response MandatesResponse
+	userId := c.GetInt64(config.USERID)
+
+	mandates, err := obj.dbObj.GetDebitMandates(c, userId)
+	if err != nil {
+		log.Printf("failed to fetch debit mandates. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch auto debits"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Data = make([]Mandate, 0)
+	for _, mandate := range mandates {
+		debits, err := obj.dbObj.GetAutoDebits(c, mandate.LoanId.Int64)
+		if err != nil {
+			log.Printf("failed to fetch auto debits. Error: %s", err.Error())
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+			response.Message = "failed to fetch auto debits"
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+		response.Data = append(response.Data, mandateDetail(mandate, debits))
+	}
+	response.Status = true
+	response.Message = "successfully fetched auto debits"
+	c.JSON(http.StatusOK, response)
+}
+
+// GetAutoDebitsByStatus lists the debits of every loan in a status, like the REFUND_FAILED debits an admin has to
+// give back to the customers by hand
+func (obj *autoDebitService) GetAutoDebitsByStatus(c *gin.Context) {
+	var (
+		request  GetAutoDebitsRequest
+		response LoanDebitsResponse
+	)
+	if err := c.BindQuery(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to fetch auto debits"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if request.Limit == 0 {
+		request.Limit = DEFAULT_PAGE_SIZE
+	}
+
+	debits, err := obj.dbObj.GetAutoDebitsByStatus(c, request.Status, request.AfterId, request.Limit)
+	if err != nil {
+		log.Printf("failed to fetch auto debits. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch auto debits"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Data = make([]LoanDebit, 0)
+	for _, debit := range debits {
+		response.Data = append(response.Data, LoanDebit{
+			DebitId: debit.DebitId.Int64,
+			LoanId:  debit.LoanId.Int64,
+			UserId:  debit.UserId.Int64,
+			Debit:   debitDetail(debit),
+		})
+	}
+	response.Status = true
+	response.Message = "successfully fetched auto debits"
+	c.JSON(http.StatusOK, response)
+}
+
+// ownLoan returns the loan when it belongs to the user, and loan.ErrLoanNotFound for the loans of other users
+func (obj *autoDebitService) ownLoan(c *gin.Context, userId int64, loanId int64) (dbloan.LoanDetails, error) {
+	detail, err := obj.dbObj.FetchLoanDetails(c, loanId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && detail.UserId.Int64 != userId) {
+		return detail, loan.ErrLoanNotFound
+	}
+	return detail, err
+}
+
+func mandateDetail(mandate autodebit.DebitMandate, debits []autodebit.AutoDebit) Mandate {
+	detail := Mandate{
+		LoanId:    mandate.LoanId.Int64,
+		Status:    mandate.Status.String,
+		CreatedAt: mandate.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+	}
+	if mandate.CancelledAt.Valid {
+		detail.CancelledAt = mandate.CancelledAt.Time.Format("2006-01-02 15:04:05")
+	}
+	for _, debit := range debits {
+		detail.Debits = append(detail.Debits, debitDetail(debit))
+	}
+	return detail
+}
+
+func debitDetail(debit autodebit.AutoDebit) Debit {
+	detail := Debit{
+		InstallmentNumber: debit.InstallmentNum.Int64,
+		Amount:            debit.Amount.Float64,
+		DueDate:           debit.DueDate.Time.Format("2006-01-02"),
+		Status:            debit.Status.String,
+		Attempts:          debit.Attempts.Int64,
+		TransactionId:     debit.TransactionId.String,
+		LastError:         debit.LastError.String,
+	}
+	if debit.Status.String == autodebit.PENDING {
+		detail.NextAttemptAt = debit.NextAttemptAt.Time.Format("2006-01-02 15:04:05")
+	}
+	return detail
+}
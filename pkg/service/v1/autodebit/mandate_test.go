@@ -0,0 +1,366 @@
+package autodebit
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/autodebit"
+	"aspire-assignment/pkg/db/v1/loan"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	e "aspire-assignment/pkg/errors"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func loanDetails(userId int64, status string) loan.LoanDetails {
+	return loan.LoanDetails{
+		LoanId: sql.NullInt64{Int64: 7, Valid: true},
+		UserId: sql.NullInt64{Int64: userId, Valid: true},
+		Status: sql.NullString{String: status, Valid: true},
+	}
+}
+
+func Test_autoDebitService_EnableAutoDebit(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		input      interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+	}{
+		{
+			name:  "MissingLoan",
+			input: map[string]interface{}{},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "LoanNotFound",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loan.LoanDetails{}, sql.ErrNoRows).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:  "LoanOfAnotherUser",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loanDetails(2, "APPROVED"), nil).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:  "LoanPaid",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loanDetails(userId, "PAID"), nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DBError",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loanDetails(userId, "APPROVED"), nil).Times(1)
+				repo.EXPECT().AddDebitMandate(c, gomock.Any()).Return(int64(0), fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "Success",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loanDetails(userId, "PENDING"), nil).Times(1)
+				repo.EXPECT().AddDebitMandate(c, gomock.Any()).DoAndReturn(func(c *gin.Context, mandate autodebit.DebitMandate) (int64, error) {
+					assert.Equal(t, userId, mandate.UserId.Int64)
+					assert.Equal(t, int64(7), mandate.LoanId.Int64)
+					return 3, nil
+				}).Times(1)
+				repo.EXPECT().GetDebitMandate(c, int64(7)).Return(autodebit.DebitMandate{
+					MandateId: sql.NullInt64{Int64: 3, Valid: true},
+					LoanId:    sql.NullInt64{Int64: 7, Valid: true},
+					Status:    sql.NullString{String: autodebit.MANDATE_ACTIVE, Valid: true},
+					CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+				}, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fmt.Println("Starting Enable Auto Debit TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodPost, tt.input, nil)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx)
+			NewAutoDebitService(dbObj).EnableAutoDebit(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response MandateResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			if tt.httpStatus == http.StatusOK {
+				assert.Equal(t, int64(7), response.Data.LoanId)
+				assert.Equal(t, autodebit.MANDATE_ACTIVE, response.Data.Status)
+			}
+		})
+	}
+}
+
+func Test_autoDebitService_DisableAutoDebit(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		input      interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+	}{
+		{
+			name:  "LoanOfAnotherUser",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loanDetails(2, "APPROVED"), nil).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:  "NotEnabled",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loanDetails(userId, "APPROVED"), nil).Times(1)
+				repo.EXPECT().CancelDebitMandate(c, int64(7), gomock.Any()).Return(int64(0), nil).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:  "DBError",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loanDetails(userId, "APPROVED"), nil).Times(1)
+				repo.EXPECT().CancelDebitMandate(c, int64(7), gomock.Any()).Return(int64(0), fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "Success",
+			input: AutoDebitRequest{LoanId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(7)).Return(loanDetails(userId, "APPROVED"), nil).Times(1)
+				repo.EXPECT().CancelDebitMandate(c, int64(7), gomock.Any()).Return(int64(3), nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fmt.Println("Starting Disable Auto Debit TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodDelete, tt.input, nil)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx)
+			NewAutoDebitService(dbObj).DisableAutoDebit(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response MandateResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+		})
+	}
+}
+
+func Test_autoDebitService_GetAutoDebits(t *testing.T) {
+	e.ErrorInit()
+	repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+	w, ctx := getContext(http.MethodGet, nil, nil)
+	ctx.Set(config.USERID, int64(1))
+	repo.EXPECT().GetDebitMandates(ctx, int64(1)).Return([]autodebit.DebitMandate{{
+		LoanId: sql.NullInt64{Int64: 7, Valid: true},
+		Status: sql.NullString{String: autodebit.MANDATE_ACTIVE, Valid: true},
+	}}, nil).Times(1)
+	repo.EXPECT().GetAutoDebits(ctx, int64(7)).Return([]autodebit.AutoDebit{{
+		InstallmentNum: sql.NullInt64{Int64: 1, Valid: true},
+		Amount:         sql.NullFloat64{Float64: 100, Valid: true},
+		Status:         sql.NullString{String: autodebit.SUCCEEDED, Valid: true},
+		Attempts:       sql.NullInt64{Int64: 1, Valid: true},
+		TransactionId:  sql.NullString{String: "AUTODEBIT1", Valid: true},
+	}, {
+		InstallmentNum: sql.NullInt64{Int64: 2, Valid: true},
+		Amount:         sql.NullFloat64{Float64: 100, Valid: true},
+		Status:         sql.NullString{String: autodebit.PENDING, Valid: true},
+		Attempts:       sql.NullInt64{Int64: 1, Valid: true},
+		NextAttemptAt:  sql.NullTime{Time: time.Now(), Valid: true},
+		LastError:      sql.NullString{String: "insufficient funds", Valid: true},
+	}}, nil).Times(1)
+
+	NewAutoDebitService(repo).GetAutoDebits(ctx)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response MandatesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Error("unable to unmarshal response")
+	}
+	assert.Equal(t, 1, len(response.Data))
+	assert.Equal(t, 2, len(response.Data[0].Debits))
+	assert.Equal(t, "AUTODEBIT1", response.Data[0].Debits[0].TransactionId)
+	assert.Equal(t, "", response.Data[0].Debits[0].NextAttemptAt)
+	assert.NotEqual(t, "", response.Data[0].Debits[1].NextAttemptAt)
+	assert.Equal(t, "insufficient funds", response.Data[0].Debits[1].LastError)
+}
+
+func Test_autoDebitService_GetAutoDebitsByStatus(t *testing.T) {
+	e.ErrorInit()
+	tests := []struct {
+		name         string
+		queries      map[string]string
+		setup        func(repo *dbmock.MockV1DBLayer, ctx *gin.Context)
+		expectedCode int
+		expectedIds  []int64
+	}{
+		{
+			name:         "StatusRequired",
+			queries:      map[string]string{},
+			setup:        func(repo *dbmock.MockV1DBLayer, ctx *gin.Context) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "UnknownStatus",
+			queries:      map[string]string{"status": "LOST"},
+			setup:        func(repo *dbmock.MockV1DBLayer, ctx *gin.Context) {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:    "ErrorFetchingDebits",
+			queries: map[string]string{"status": autodebit.REFUND_FAILED},
+			setup: func(repo *dbmock.MockV1DBLayer, ctx *gin.Context) {
+				repo.EXPECT().GetAutoDebitsByStatus(ctx, autodebit.REFUND_FAILED, int64(0), DEFAULT_PAGE_SIZE).Return(nil, fmt.Errorf("db error")).Times(1)
+			},
+			expectedCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "RefundFailed",
+			queries: map[string]string{"status": autodebit.REFUND_FAILED, "afterId": "3", "limit": "10"},
+			setup: func(repo *dbmock.MockV1DBLayer, ctx *gin.Context) {
+				repo.EXPECT().GetAutoDebitsByStatus(ctx, autodebit.REFUND_FAILED, int64(3), 10).Return([]autodebit.AutoDebit{{
+					DebitId:        sql.NullInt64{Int64: 4, Valid: true},
+					LoanId:         sql.NullInt64{Int64: 7, Valid: true},
+					UserId:         sql.NullInt64{Int64: 1, Valid: true},
+					InstallmentNum: sql.NullInt64{Int64: 2, Valid: true},
+					Amount:         sql.NullFloat64{Float64: 100, Valid: true},
+					Status:         sql.NullString{String: autodebit.REFUND_FAILED, Valid: true},
+					LastError:      sql.NullString{String: "loan changed. refund failed: provider unavailable", Valid: true},
+				}}, nil).Times(1)
+			},
+			expectedCode: http.StatusOK,
+			expectedIds:  []int64{4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fmt.Println("Starting Get Auto Debits By Status TestCase: ", tt.name)
+			repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			w, ctx := getContext(http.MethodGet, nil, tt.queries)
+			tt.setup(repo, ctx)
+
+			NewAutoDebitService(repo).GetAutoDebitsByStatus(ctx)
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			var response LoanDebitsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			ids := make([]int64, 0)
+			for _, debit := range response.Data {
+				ids = append(ids, debit.DebitId)
+				assert.Equal(t, int64(7), debit.LoanId)
+				assert.Equal(t, autodebit.REFUND_FAILED, debit.Status)
+			}
+			if tt.expectedIds != nil {
+				assert.Equal(t, tt.expectedIds, ids)
+			}
+		})
+	}
+}
+
+func getContext(method string, data interface{}, queries map[string]string) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+	byteData, err := json.Marshal(data)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	temp.Request, err = http.NewRequest(method, "/", bytes.NewBuffer(byteData))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//add headers
+	temp.Request.Header = http.Header{}
+	temp.Request.Header.Set("Content-Type", "application/json")
+
+	//add query params
+	if queries != nil {
+		q := temp.Request.URL.Query()
+		for k, v := range queries {
+			q.Add(k, v)
+		}
+		temp.Request.URL.RawQuery = q.Encode()
+	}
+
+	return recorder, temp
+}
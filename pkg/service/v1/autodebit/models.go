@@ -0,0 +1,68 @@
+package autodebit
+
+import (
+	e "aspire-assignment/pkg/errors"
+)
+
+type AutoDebitRequest struct {
+	UserId int64 `json:"-"`
+	LoanId int64 `json:"loanId" binding:"required"`
+}
+
+// GetAutoDebitsRequest filters the debits of every loan by status. afterId continues from the last debit of the
+// previous page
+type GetAutoDebitsRequest struct {
+	Status  string `form:"status" binding:"required,oneof=PENDING SUCCEEDED FAILED CANCELLED REFUND_FAILED"`
+	AfterId int64  `form:"afterId" binding:"min=0"`
+	Limit   int    `form:"limit" binding:"min=0,max=500"`
+}
+
+type MandateResponse struct {
+	Data    *Mandate  `json:"data,omitempty"`
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type MandatesResponse struct {
+	Data    []Mandate `json:"data,omitempty"`
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type LoanDebitsResponse struct {
+	Data    []LoanDebit `json:"data,omitempty"`
+	Status  bool        `json:"success"`
+	Errors  []e.Error   `json:"errors,omitempty"`
+	Message string      `json:"message,omitempty"`
+}
+
+// Mandate is the auto debit of a loan with the debits made under it, one per installment fallen due
+type Mandate struct {
+	LoanId      int64   `json:"loanId"`
+	Status      string  `json:"status"`
+	CreatedAt   string  `json:"createdAt"`
+	CancelledAt string  `json:"cancelledAt,omitempty"`
+	Debits      []Debit `json:"debits,omitempty"`
+}
+
+// Debit is the debit of one installment. NextAttemptAt is set while it is pending
+type Debit struct {
+	InstallmentNumber int64   `json:"installmentNumber"`
+	Amount            float64 `json:"amount"`
+	DueDate           string  `json:"dueDate"`
+	Status            string  `json:"status"`
+	Attempts          int64   `json:"attempts"`
+	NextAttemptAt     string  `json:"nextAttemptAt,omitempty"`
+	TransactionId     string  `json:"transactionId,omitempty"`
+	LastError         string  `json:"lastError,omitempty"`
+}
+
+// LoanDebit is a debit as admins see it, with the loan and the customer it was taken from
+type LoanDebit struct {
+	DebitId int64 `json:"debitId"`
+	LoanId  int64 `json:"loanId"`
+	UserId  int64 `json:"userId"`
+	Debit
+}
@@ -0,0 +1,127 @@
+package document
+
+import (
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	e "aspire-assignment/pkg/errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (obj *documentService) GetPendingDocuments(c *gin.Context) {
+	var (
+		response GetDocumentsResponse
+	)
+
+	documents, err := obj.dbObj.GetPendingDocuments(c)
+	if err != nil {
+		log.Printf("failed to fetch documents. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch documents"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if len(documents) == 0 {
+		response.Message = "no documents pending verification"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response.Data = make([]DocumentDetails, 0)
+	for _, document := range documents {
+		response.Data = append(response.Data, toDocumentDetails(document))
+	}
+	response.Status = true
+	response.Message = "successfully fetched pending documents"
+	c.JSON(http.StatusOK, response)
+}
+
+func (obj *documentService) DownloadDocument(c *gin.Context) {
+	var (
+		request  DownloadDocumentRequest
+		response DownloadDocumentResponse
+	)
+	if err := c.BindQuery(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to fetch document"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	document, err := obj.dbObj.GetDocument(c, request.DocumentId)
+	if err != nil {
+		log.Printf("failed to fetch document detail. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.NoDataFound])
+		response.Message = "failed to fetch document"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	reader, err := obj.store.Get(c, document.StorageKey.String)
+	if err != nil {
+		log.Printf("failed to read document %s. Error:%s", document.StorageKey.String, err.Error())
+		response.Errors = append(response.Errors, e.ErrorInfo[e.DefaultError].GetErrorDetails("failed to read document"))
+		response.Message = "failed to fetch document"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	defer reader.Close()
+
+	headers := map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", document.FileName.String),
+	}
+	c.DataFromReader(http.StatusOK, document.Size.Int64, document.ContentType.String, reader, headers)
+}
+
+func (obj *documentService) VerifyDocument(c *gin.Context) {
+	var (
+		request  VerifyDocumentRequest
+		response VerifyDocumentResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to update document status"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_DOCUMENT, request.DocumentId)
+
+	status := DOC_VERIFIED
+	if request.Verdict == DOC_REJECT {
+		status = DOC_REJECTED
+	}
+
+	documentId, err := obj.dbObj.UpdateDocumentStatus(c, request.DocumentId, status, request.UserId, request.Remarks)
+	if err != nil {
+		log.Printf("failed to update document status. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to update document status"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	//document does not exist or was already reviewed
+	if documentId == 0 {
+		log.Printf("failed to update document status. DocumentId: %d is not pending verification", request.DocumentId)
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("only documents in PENDING status can be reviewed"))
+		response.Message = "failed to update document status"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	audit.Change(c, map[string]interface{}{"status": DOC_PENDING}, map[string]interface{}{"status": status, "remarks": request.Remarks})
+	response.Status = true
+	response.Data = &DocumentDetails{
+		DocumentId: documentId,
+		Status:     status,
+		Remarks:    request.Remarks,
+	}
+	response.Message = "successfully updated document status"
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,190 @@
+package document
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	e "aspire-assignment/pkg/errors"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_documentService_VerifyDocument(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 2
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          VerifyDocumentRequest
+		setup          func(*gin.Context, VerifyDocumentRequest)
+		expectedOutput VerifyDocumentResponse
+		actualOutput   VerifyDocumentResponse
+	}{
+		{
+			name: "InvalidVerdict",
+			input: VerifyDocumentRequest{
+				DocumentId: 4,
+				Verdict:    "MAYBE",
+			},
+			setup: func(c *gin.Context, data VerifyDocumentRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+			},
+			expectedOutput: VerifyDocumentResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to update document status",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "FailToUpdateDocument",
+			input: VerifyDocumentRequest{
+				DocumentId: 4,
+				Verdict:    DOC_VERIFY,
+			},
+			setup: func(c *gin.Context, data VerifyDocumentRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().UpdateDocumentStatus(c, data.DocumentId, DOC_VERIFIED, userId, "").Return(int64(0), fmt.Errorf("db error")).Times(1)
+			},
+			expectedOutput: VerifyDocumentResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.AddDBError].ErrName,
+					Description: e.ErrorInfo[e.AddDBError].Description,
+					Code:        e.ErrorInfo[e.AddDBError].Code,
+				}},
+				Message: "failed to update document status",
+			},
+			httpStatus: http.StatusInternalServerError,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "DocumentNotPending",
+			input: VerifyDocumentRequest{
+				DocumentId: 4,
+				Verdict:    DOC_VERIFY,
+			},
+			setup: func(c *gin.Context, data VerifyDocumentRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().UpdateDocumentStatus(c, data.DocumentId, DOC_VERIFIED, userId, "").Return(int64(0), nil).Times(1)
+			},
+			expectedOutput: VerifyDocumentResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to update document status",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "RejectDocumentSuccess",
+			input: VerifyDocumentRequest{
+				DocumentId: 4,
+				Verdict:    DOC_REJECT,
+				Remarks:    "blurred scan",
+			},
+			setup: func(c *gin.Context, data VerifyDocumentRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().UpdateDocumentStatus(c, data.DocumentId, DOC_REJECTED, userId, data.Remarks).Return(data.DocumentId, nil).Times(1)
+			},
+			expectedOutput: VerifyDocumentResponse{
+				Status: true,
+				Data: &DocumentDetails{
+					DocumentId: 4,
+					Status:     DOC_REJECTED,
+				},
+				Message: "successfully updated document status",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "VerifyDocumentSuccess",
+			input: VerifyDocumentRequest{
+				DocumentId: 4,
+				Verdict:    DOC_VERIFY,
+			},
+			setup: func(c *gin.Context, data VerifyDocumentRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().UpdateDocumentStatus(c, data.DocumentId, DOC_VERIFIED, userId, "").Return(data.DocumentId, nil).Times(1)
+			},
+			expectedOutput: VerifyDocumentResponse{
+				Status: true,
+				Data: &DocumentDetails{
+					DocumentId: 4,
+					Status:     DOC_VERIFIED,
+				},
+				Message: "successfully updated document status",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Verify Document TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input, nil)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewDocumentService(dbObj, nil)
+
+			//calling the function
+			servObj.VerifyDocument(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+			if tt.expectedOutput.Data != nil {
+				assert.Equal(t, tt.expectedOutput.Data.Status, tt.actualOutput.Data.Status)
+			}
+
+			fmt.Println("Ending Verify Document TestCase: ", tt.name)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package document
+
+// document types
+const (
+	DOC_ID_PROOF     = "ID_PROOF"
+	DOC_INCOME_PROOF = "INCOME_PROOF"
+)
+
+// document status
+const (
+	DOC_PENDING  = "PENDING"
+	DOC_VERIFIED = "VERIFIED"
+	DOC_VERIFY   = "VERIFY"
+	DOC_REJECTED = "REJECTED"
+	DOC_REJECT   = "REJECT"
+)
+
+// upload limits
+const (
+	MAX_UPLOAD_SIZE = 5 << 20 //5 MB
+)
+
+// allowedContentTypes lists the file formats accepted as proofs
+var allowedContentTypes = map[string]string{
+	"application/pdf": ".pdf",
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+}
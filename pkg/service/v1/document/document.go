@@ -0,0 +1,163 @@
+package document
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/document"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (obj *documentService) UploadDocument(c *gin.Context) {
+	var (
+		request  UploadDocumentRequest
+		response UploadDocumentResponse
+	)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MAX_UPLOAD_SIZE+(1<<20))
+	if err := c.Bind(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to upload document"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+
+	if request.File.Size > MAX_UPLOAD_SIZE {
+		log.Printf("document too large. Size: %d", request.File.Size)
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("document larger than 5 MB"))
+		response.Message = "failed to upload document"
+		c.JSON(http.StatusRequestEntityTooLarge, response)
+		return
+	}
+
+	file, err := request.File.Open()
+	if err != nil {
+		log.Printf("unable to open uploaded file. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to upload document"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	defer file.Close()
+
+	//sniff the content instead of trusting the client supplied header
+	head := make([]byte, 512)
+	n, _ := file.Read(head)
+	contentType := http.DetectContentType(head[:n])
+	ext, ok := allowedContentTypes[contentType]
+	if !ok {
+		log.Printf("unsupported document format %s", contentType)
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("only pdf, jpeg and png documents are accepted"))
+		response.Message = "failed to upload document"
+		c.JSON(http.StatusUnsupportedMediaType, response)
+		return
+	}
+	if _, err := file.Seek(0, 0); err != nil {
+		log.Printf("unable to rewind uploaded file. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to upload document"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	//store the blob before the db entry so a row never points at a missing file
+	storageKey := fmt.Sprintf("kyc/%d/%s_%d%s", request.UserId, request.DocType, time.Now().UnixNano(), ext)
+	size, err := obj.store.Put(c, storageKey, file)
+	if err != nil {
+		log.Printf("failed to store document. Error:%s", err.Error())
+		response.Errors = append(response.Errors, e.ErrorInfo[e.DefaultError].GetErrorDetails("failed to store document"))
+		response.Message = "failed to upload document"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	documentDetail := document.DocumentDetails{
+		UserId:      sql.NullInt64{Int64: request.UserId, Valid: true},
+		DocType:     sql.NullString{String: request.DocType, Valid: true},
+		FileName:    sql.NullString{String: filepath.Base(request.File.Filename), Valid: true},
+		ContentType: sql.NullString{String: contentType, Valid: true},
+		StorageKey:  sql.NullString{String: storageKey, Valid: true},
+		Size:        sql.NullInt64{Int64: size, Valid: true},
+	}
+	documentId, err := obj.dbObj.AddDocument(c, documentDetail)
+	if err != nil {
+		log.Printf("failed to add document. Error:%s", err.Error())
+		if delErr := obj.store.Delete(c, storageKey); delErr != nil {
+			log.Printf("failed to remove orphan document %s. Error:%s", storageKey, delErr.Error())
+		}
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to upload document"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = &DocumentDetails{
+		DocumentId:  documentId,
+		DocType:     request.DocType,
+		FileName:    documentDetail.FileName.String,
+		ContentType: contentType,
+		Size:        size,
+		Status:      DOC_PENDING,
+	}
+	response.Message = "successfully uploaded document"
+	c.JSON(http.StatusOK, response)
+}
+
+func (obj *documentService) GetDocuments(c *gin.Context) {
+	var (
+		request  GetDocumentsRequest
+		response GetDocumentsResponse
+	)
+	request.UserId = c.GetInt64(config.USERID)
+
+	documents, err := obj.dbObj.GetUserDocuments(c, request.UserId)
+	if err != nil {
+		log.Printf("failed to fetch documents. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch documents"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if len(documents) == 0 {
+		response.Message = "no documents available for user"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response.Data = make([]DocumentDetails, 0)
+	for _, document := range documents {
+		response.Data = append(response.Data, toDocumentDetails(document))
+	}
+	response.Status = true
+	response.Message = "successfully fetched user documents"
+	c.JSON(http.StatusOK, response)
+}
+
+func toDocumentDetails(document document.DocumentDetails) DocumentDetails {
+	detail := DocumentDetails{
+		DocumentId:  document.DocumentId.Int64,
+		UserId:      document.UserId.Int64,
+		UserName:    document.UserName.String,
+		DocType:     document.DocType.String,
+		FileName:    document.FileName.String,
+		ContentType: document.ContentType.String,
+		Size:        document.Size.Int64,
+		Status:      document.Status.String,
+		Remarks:     document.Remarks.String,
+		CreatedAt:   document.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+	}
+	if document.VerifiedAt.Valid {
+		detail.VerifiedAt = document.VerifiedAt.Time.Format("2006-01-02 15:04:05")
+	}
+	return detail
+}
@@ -0,0 +1,228 @@
+package document
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/storage"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+var pdfContent = []byte("%PDF-1.4\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")
+
+func Test_documentService_UploadDocument(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	store, err := storage.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name           string
+		httpStatus     int
+		docType        string
+		content        []byte
+		setup          func(*gin.Context)
+		expectedOutput UploadDocumentResponse
+		actualOutput   UploadDocumentResponse
+	}{
+		{
+			name:    "MissingInputType",
+			content: pdfContent,
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+			},
+			expectedOutput: UploadDocumentResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to upload document",
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "UnsupportedFormat",
+			docType: DOC_ID_PROOF,
+			content: []byte("plain text is not a proof"),
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+			},
+			expectedOutput: UploadDocumentResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to upload document",
+			},
+			httpStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:    "FailToAddDocument",
+			docType: DOC_ID_PROOF,
+			content: pdfContent,
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().AddDocument(c, gomock.Any()).Return(int64(0), fmt.Errorf("db error")).Times(1)
+			},
+			expectedOutput: UploadDocumentResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.AddDBError].ErrName,
+					Description: e.ErrorInfo[e.AddDBError].Description,
+					Code:        e.ErrorInfo[e.AddDBError].Code,
+				}},
+				Message: "failed to upload document",
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:    "SuccessUploadDocument",
+			docType: DOC_INCOME_PROOF,
+			content: pdfContent,
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().AddDocument(c, gomock.Any()).Return(int64(4), nil).Times(1)
+			},
+			expectedOutput: UploadDocumentResponse{
+				Status: true,
+				Data: &DocumentDetails{
+					DocumentId:  4,
+					DocType:     DOC_INCOME_PROOF,
+					ContentType: "application/pdf",
+					Status:      DOC_PENDING,
+				},
+				Message: "successfully uploaded document",
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Upload Document TestCase: ", tt.name)
+			w, ctx := getMultipartContext(map[string]string{"type": tt.docType}, "payslip.pdf", tt.content)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx)
+			servObj := NewDocumentService(dbObj, store)
+
+			//calling the function
+			servObj.UploadDocument(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+			if tt.expectedOutput.Data != nil {
+				assert.Equal(t, tt.expectedOutput.Data.DocumentId, tt.actualOutput.Data.DocumentId)
+				assert.Equal(t, tt.expectedOutput.Data.ContentType, tt.actualOutput.Data.ContentType)
+				assert.Equal(t, tt.expectedOutput.Data.Status, tt.actualOutput.Data.Status)
+			}
+
+			fmt.Println("Ending Upload Document TestCase: ", tt.name)
+		})
+	}
+}
+
+func getMultipartContext(fields map[string]string, fileName string, content []byte) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for k, v := range fields {
+		if v == "" {
+			continue
+		}
+		if err := writer.WriteField(k, v); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	temp.Request, err = http.NewRequest(http.MethodPost, "/", body)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	temp.Request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return recorder, temp
+}
+
+func getContext(method string, data interface{}, queries map[string]string) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+	byteData, err := json.Marshal(data)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	temp.Request, err = http.NewRequest(method, "/", bytes.NewBuffer(byteData))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//add headers
+	temp.Request.Header = http.Header{}
+	temp.Request.Header.Set("Content-Type", "application/json")
+
+	//add query params
+	if queries != nil {
+		q := temp.Request.URL.Query()
+		for k, v := range queries {
+			q.Add(k, v)
+		}
+		temp.Request.URL.RawQuery = q.Encode()
+	}
+
+	return recorder, temp
+}
@@ -0,0 +1,28 @@
+package document
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type documentService struct {
+	dbObj v1.V1DBLayer
+	store storage.BlobStore
+}
+
+type DocumentInterface interface {
+	UploadDocument(*gin.Context)
+	GetDocuments(*gin.Context)
+	GetPendingDocuments(*gin.Context)
+	DownloadDocument(*gin.Context)
+	VerifyDocument(*gin.Context)
+}
+
+func NewDocumentService(db v1.V1DBLayer, store storage.BlobStore) DocumentInterface {
+	return &documentService{
+		dbObj: db,
+		store: store,
+	}
+}
@@ -0,0 +1,68 @@
+package document
+
+import (
+	e "aspire-assignment/pkg/errors"
+	"mime/multipart"
+)
+
+type UploadDocumentRequest struct {
+	UserId  int64                 `form:"-"`
+	DocType string                `form:"type" binding:"required,oneof=ID_PROOF INCOME_PROOF"`
+	File    *multipart.FileHeader `form:"file" binding:"required"`
+}
+
+type UploadDocumentResponse struct {
+	Data    *DocumentDetails `json:"data,omitempty"`
+	Status  bool             `json:"success"`
+	Errors  []e.Error        `json:"errors,omitempty"`
+	Message string           `json:"message,omitempty"`
+}
+
+type DocumentDetails struct {
+	DocumentId  int64  `json:"documentId"`
+	UserId      int64  `json:"userId,omitempty"`
+	UserName    string `json:"username,omitempty"`
+	DocType     string `json:"type"`
+	FileName    string `json:"fileName,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Status      string `json:"status"`
+	Remarks     string `json:"remarks,omitempty"`
+	VerifiedAt  string `json:"verifiedAt,omitempty"`
+	CreatedAt   string `json:"createdAt,omitempty"`
+}
+
+type GetDocumentsRequest struct {
+	UserId int64 `form:"-"`
+}
+
+type GetDocumentsResponse struct {
+	Data    []DocumentDetails `json:"data,omitempty"`
+	Status  bool              `json:"success"`
+	Errors  []e.Error         `json:"errors,omitempty"`
+	Message string            `json:"message,omitempty"`
+}
+
+type DownloadDocumentRequest struct {
+	DocumentId int64 `form:"documentId" binding:"required"`
+}
+
+type DownloadDocumentResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type VerifyDocumentRequest struct {
+	UserId     int64  `json:"-"`
+	DocumentId int64  `json:"documentId" binding:"required"`
+	Verdict    string `json:"verdict" binding:"required,oneof=VERIFY REJECT"`
+	Remarks    string `json:"remarks"`
+}
+
+type VerifyDocumentResponse struct {
+	Data    *DocumentDetails `json:"data,omitempty"`
+	Status  bool             `json:"success"`
+	Errors  []e.Error        `json:"errors,omitempty"`
+	Message string           `json:"message,omitempty"`
+}
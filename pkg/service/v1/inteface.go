@@ -2,23 +2,53 @@ package v1
 
 import (
 	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/notifier"
+	"aspire-assignment/pkg/service/v1/audit"
+	"aspire-assignment/pkg/service/v1/autodebit"
+	"aspire-assignment/pkg/service/v1/document"
 	"aspire-assignment/pkg/service/v1/loan"
+	"aspire-assignment/pkg/service/v1/notification"
+	"aspire-assignment/pkg/service/v1/payment"
+	"aspire-assignment/pkg/service/v1/reconciliation"
 	"aspire-assignment/pkg/service/v1/usermanagement"
+	"aspire-assignment/pkg/service/v1/webhook"
+	"aspire-assignment/pkg/storage"
 )
 
 type serviceObj struct {
 	loan.LoanInterface
 	usermanagement.UserManagementInterface
+	document.DocumentInterface
+	audit.AuditInterface
+	webhook.WebhookInterface
+	payment.PaymentInterface
+	reconciliation.ReconciliationInterface
+	autodebit.AutoDebitInterface
+	notification.NotificationInterface
 }
 
 type ServiceLayer interface {
 	loan.LoanInterface
 	usermanagement.UserManagementInterface
+	document.DocumentInterface
+	audit.AuditInterface
+	webhook.WebhookInterface
+	payment.PaymentInterface
+	reconciliation.ReconciliationInterface
+	autodebit.AutoDebitInterface
+	notification.NotificationInterface
 }
 
-func NewServiceObject(db v1.V1DBLayer) ServiceLayer {
+func NewServiceObject(db v1.V1DBLayer, store storage.BlobStore, notifier notifier.Notifier) ServiceLayer {
 	return &serviceObj{
 		loan.NewLoanService(db),
-		usermanagement.NewUserManagementService(db),
+		usermanagement.NewUserManagementService(db, notifier),
+		document.NewDocumentService(db, store),
+		audit.NewAuditService(db),
+		webhook.NewWebhookService(db),
+		payment.NewPaymentService(db),
+		reconciliation.NewReconciliationService(db),
+		autodebit.NewAutoDebitService(db),
+		notification.NewNotificationService(db),
 	}
 }
@@ -1,6 +1,7 @@
 package loan
 
 import (
+	"aspire-assignment/pkg/audit"
 	"aspire-assignment/pkg/config"
 	e "aspire-assignment/pkg/errors"
 	"log"
@@ -24,12 +25,12 @@ func (obj *loanService) GetPendingLoans(c *gin.Context) {
 	request.UserId = c.GetInt64(config.USERID)
 
 	//check if the user is an admin and fetch only pending loans
-	loans, err := obj.dbObj.GetUnapprovedLoans(c)
+	loans, err := obj.loans.PendingLoans(c)
 	if err != nil {
-		log.Printf("failed to fetch loans. Error:%s", err.Error())
-		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
 		response.Message = "failed to fetch loans"
-		c.JSON(http.StatusInternalServerError, response)
+		c.JSON(status, response)
 		return
 	}
 
@@ -44,12 +45,12 @@ func (obj *loanService) GetPendingLoans(c *gin.Context) {
 	response.Data = make([]LoanDetails, 0)
 	for _, loan := range loans {
 		response.Data = append(response.Data, LoanDetails{
-			LoanId:    loan.LoanId.Int64,
-			UserName:  loan.UserName.String,
-			Amount:    loan.Amount.Float64,
-			Tenure:    loan.Installments.Int64,
-			Status:    loan.Status.String,
-			CreatedAt: loan.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+			LoanId:    loan.LoanId,
+			UserName:  loan.UserName,
+			Amount:    loan.Amount,
+			Tenure:    loan.Tenure,
+			Status:    loan.Status,
+			CreatedAt: loan.CreatedAt.Format("2006-01-02 15:04:05"),
 		})
 	}
 	response.Message = "successfully fetched unapproved loans"
@@ -70,53 +71,26 @@ func (obj *loanService) ApproveRejectLoanApplication(c *gin.Context) {
 		return
 	}
 	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_LOAN, request.LoanId)
 
-	//check loan details to create transactions
-	loanDetail, err := obj.dbObj.FetchLoanDetails(c, request.LoanId)
-	if err != nil {
-		log.Printf("failed to fetch loan detail. Error:%s", err.Error())
-		response.Errors = append(response.Errors, *e.ErrorInfo[e.NoDataFound])
-		response.Message = "failed to fetch loan detail"
-		c.JSON(http.StatusNotFound, response)
-		return
-	}
-
-	if loanDetail.Status.String != LOAN_PENDING {
-		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("loan not in PENDING state"))
-		response.Message = "failed to update loan status"
-		c.JSON(http.StatusBadRequest, response)
-		return
-	}
-
+	var err error
+	status := LOAN_APPROVED
 	if request.Approval == LOAN_REJECT {
-		log.Printf("loan is being rejected by admin. LoanId: %d, Status: %s", request.LoanId, request.Approval)
-		//update the rejection in db
-		err := obj.dbObj.UpdateUnapprovedLoan(c, request.LoanId, false)
-		if err != nil {
-			log.Printf("failed to update loan status. Error:%s", err.Error())
-			response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
-			response.Message = "failed to update loan status"
-			c.JSON(http.StatusInternalServerError, response)
-			return
-		}
-		response.Status = true
-		response.Message = "successfully updated loan status"
-		c.JSON(http.StatusOK, response)
-		return
+		status = LOAN_REJECTED
+		err = obj.loans.Reject(c, request.UserId, request.LoanId, request.Reason)
+	} else {
+		err = obj.loans.Approve(c, request.UserId, request.LoanId, request.Reason)
 	}
-
-	//finding installment per week but any other logic for installment can be applied here
-	equalInstallmentAmount := loanDetail.Amount.Float64 / float64(loanDetail.Tenure.Int64)
-	//update and insert transactions
-	err = obj.dbObj.UpdateAndInsertInstallments(c, request.LoanId, equalInstallmentAmount, loanDetail.Tenure.Int64)
 	if err != nil {
-		log.Printf("failed to prepare loan installments. Error:%s", err.Error())
-		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
-		response.Message = "failed to prepare loan installments"
-		c.JSON(http.StatusInternalServerError, response)
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
+		response.Message = "failed to update loan status"
+		c.JSON(status, response)
 		return
 	}
 
+	log.Printf("LoanId: %d %s by UserId: %d", request.LoanId, request.Approval, request.UserId)
+	audit.Change(c, map[string]interface{}{"status": LOAN_PENDING}, map[string]interface{}{"status": status, "reason": request.Reason})
 	response.Status = true
 	response.Message = "successfully updated loan status"
 	c.JSON(http.StatusOK, response)
@@ -5,6 +5,7 @@ import (
 	v1 "aspire-assignment/pkg/db/v1"
 	"aspire-assignment/pkg/db/v1/loan"
 	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/outbox"
 	e "aspire-assignment/pkg/errors"
 	"database/sql"
 	"encoding/json"
@@ -151,6 +152,12 @@ func Test_loanService_ApproveRejectLoanApplication(t *testing.T) {
 	//init error to be used in function
 	e.ErrorInit()
 
+	//the personal product approves without documents
+	requiredDocuments = map[string][]string{LOAN_PRODUCT_PERSONAL: {}}
+	defer func() {
+		requiredDocuments = nil
+	}()
+
 	tests := []struct {
 		name           string
 		httpMethod     string
@@ -240,20 +247,20 @@ func Test_loanService_ApproveRejectLoanApplication(t *testing.T) {
 				dbObj = repo
 				loanDetail := loan.LoanDetails{
 					LoanId: sql.NullInt64{Int64: data.LoanId, Valid: true},
-					Status: sql.NullString{String: LOAN_APPROVE, Valid: true},
+					Status: sql.NullString{String: LOAN_APPROVED, Valid: true},
 				}
 				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
 			},
 			expectedOutput: ApproveRejectLoanApplicationResponse{
 				Status: false,
 				Errors: []e.Error{{
-					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
-					Description: e.ErrorInfo[e.BadRequest].Description,
-					Code:        e.ErrorInfo[e.BadRequest].Code,
+					ErrName:     e.ErrorInfo[e.Conflict].ErrName,
+					Description: e.ErrorInfo[e.Conflict].Description + " | loan 3 in APPROVED status cannot be moved to APPROVED by admin",
+					Code:        e.ErrorInfo[e.Conflict].Code,
 				}},
 				Message: "failed to update loan status",
 			},
-			httpStatus: http.StatusBadRequest,
+			httpStatus: http.StatusConflict,
 			httpMethod: http.MethodPost,
 		},
 		{
@@ -271,7 +278,7 @@ func Test_loanService_ApproveRejectLoanApplication(t *testing.T) {
 					Status: sql.NullString{String: LOAN_PENDING, Valid: true},
 				}
 				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
-				repo.EXPECT().UpdateUnapprovedLoan(c, data.LoanId, false).Return(fmt.Errorf("db error")).Times(1)
+				repo.EXPECT().UpdateLoanStatus(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_REJECTED, Actor: ACTOR_ADMIN, ActorId: userId}, gomock.Any()).Return(fmt.Errorf("db error")).Times(1)
 			},
 			expectedOutput: ApproveRejectLoanApplicationResponse{
 				Status: false,
@@ -300,7 +307,7 @@ func Test_loanService_ApproveRejectLoanApplication(t *testing.T) {
 					Status: sql.NullString{String: LOAN_PENDING, Valid: true},
 				}
 				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
-				repo.EXPECT().UpdateUnapprovedLoan(c, data.LoanId, false).Return(nil).Times(1)
+				repo.EXPECT().UpdateLoanStatus(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_REJECTED, Actor: ACTOR_ADMIN, ActorId: userId}, []outbox.Event{loanEvent(EVENT_LOAN_REJECTED, data.LoanId, LoanRejected{AdminId: userId})}).Return(nil).Times(1)
 			},
 			expectedOutput: ApproveRejectLoanApplicationResponse{
 				Status:  true,
@@ -320,14 +327,15 @@ func Test_loanService_ApproveRejectLoanApplication(t *testing.T) {
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
 				loanDetail := loan.LoanDetails{
-					LoanId: sql.NullInt64{Int64: data.LoanId, Valid: true},
-					Status: sql.NullString{String: LOAN_PENDING, Valid: true},
-					Amount: sql.NullFloat64{Float64: 30000, Valid: true},
-					Tenure: sql.NullInt64{Int64: 10, Valid: true},
+					LoanId:  sql.NullInt64{Int64: data.LoanId, Valid: true},
+					Status:  sql.NullString{String: LOAN_PENDING, Valid: true},
+					Product: sql.NullString{String: LOAN_PRODUCT_PERSONAL, Valid: true},
+					Amount:  sql.NullFloat64{Float64: 30000, Valid: true},
+					Tenure:  sql.NullInt64{Int64: 10, Valid: true},
 				}
 				installment := loanDetail.Amount.Float64 / float64(loanDetail.Tenure.Int64)
 				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
-				repo.EXPECT().UpdateAndInsertInstallments(c, data.LoanId, installment, loanDetail.Tenure.Int64).Return(fmt.Errorf("db error")).Times(1)
+				repo.EXPECT().UpdateAndInsertInstallments(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_APPROVED, Actor: ACTOR_ADMIN, ActorId: userId}, installment, loanDetail.Tenure.Int64, gomock.Any()).Return(fmt.Errorf("db error")).Times(1)
 			},
 			expectedOutput: ApproveRejectLoanApplicationResponse{
 				Status: false,
@@ -352,14 +360,16 @@ func Test_loanService_ApproveRejectLoanApplication(t *testing.T) {
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
 				loanDetail := loan.LoanDetails{
-					LoanId: sql.NullInt64{Int64: data.LoanId, Valid: true},
-					Status: sql.NullString{String: LOAN_PENDING, Valid: true},
-					Amount: sql.NullFloat64{Float64: 30000, Valid: true},
-					Tenure: sql.NullInt64{Int64: 10, Valid: true},
+					LoanId:  sql.NullInt64{Int64: data.LoanId, Valid: true},
+					Status:  sql.NullString{String: LOAN_PENDING, Valid: true},
+					Product: sql.NullString{String: LOAN_PRODUCT_PERSONAL, Valid: true},
+					Amount:  sql.NullFloat64{Float64: 30000, Valid: true},
+					Tenure:  sql.NullInt64{Int64: 10, Valid: true},
 				}
 				installment := loanDetail.Amount.Float64 / float64(loanDetail.Tenure.Int64)
 				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
-				repo.EXPECT().UpdateAndInsertInstallments(c, data.LoanId, installment, loanDetail.Tenure.Int64).Return(nil).Times(1)
+				approved := loanEvent(EVENT_LOAN_APPROVED, data.LoanId, LoanApproved{AdminId: userId, Amount: 30000, Tenure: 10, InstallmentAmount: installment})
+				repo.EXPECT().UpdateAndInsertInstallments(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_APPROVED, Actor: ACTOR_ADMIN, ActorId: userId}, installment, loanDetail.Tenure.Int64, []outbox.Event{approved}).Return(nil).Times(1)
 			},
 			expectedOutput: ApproveRejectLoanApplicationResponse{
 				Status:  true,
@@ -402,3 +412,221 @@ func Test_loanService_ApproveRejectLoanApplication(t *testing.T) {
 		})
 	}
 }
+
+func Test_loanService_ApproveLoanPolicyCheck(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	//require kyc documents for the product
+	requiredDocuments = map[string][]string{LOAN_PRODUCT_PERSONAL: {"ID_PROOF", "INCOME_PROOF"}}
+	defer func() {
+		requiredDocuments = nil
+		maxInstallmentIncomeRatio = 0
+	}()
+
+	loanDetail := loan.LoanDetails{
+		LoanId:  sql.NullInt64{Int64: 3, Valid: true},
+		UserId:  sql.NullInt64{Int64: 7, Valid: true},
+		Status:  sql.NullString{String: LOAN_PENDING, Valid: true},
+		Product: sql.NullString{String: LOAN_PRODUCT_PERSONAL, Valid: true},
+		Amount:  sql.NullFloat64{Float64: 30000, Valid: true},
+		Tenure:  sql.NullInt64{Int64: 10, Valid: true},
+	}
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          ApproveRejectLoanApplicationRequest
+		setup          func(*gin.Context, ApproveRejectLoanApplicationRequest)
+		expectedOutput ApproveRejectLoanApplicationResponse
+		actualOutput   ApproveRejectLoanApplicationResponse
+	}{
+		{
+			name: "ErrorFetchingDocuments",
+			input: ApproveRejectLoanApplicationRequest{
+				LoanId:   3,
+				Approval: LOAN_APPROVE,
+			},
+			setup: func(c *gin.Context, data ApproveRejectLoanApplicationRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
+				repo.EXPECT().GetVerifiedDocumentTypes(c, loanDetail.UserId.Int64).Return(nil, fmt.Errorf("db error")).Times(1)
+			},
+			expectedOutput: ApproveRejectLoanApplicationResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.GetDBError].ErrName,
+					Description: e.ErrorInfo[e.GetDBError].Description,
+					Code:        e.ErrorInfo[e.GetDBError].Code,
+				}},
+				Message: "failed to verify customer documents",
+			},
+			httpStatus: http.StatusInternalServerError,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "DocumentsNotVerified",
+			input: ApproveRejectLoanApplicationRequest{
+				LoanId:   3,
+				Approval: LOAN_APPROVE,
+			},
+			setup: func(c *gin.Context, data ApproveRejectLoanApplicationRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
+				repo.EXPECT().GetVerifiedDocumentTypes(c, loanDetail.UserId.Int64).Return([]string{"ID_PROOF"}, nil).Times(1)
+			},
+			expectedOutput: ApproveRejectLoanApplicationResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to update loan status",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "NoPolicyForProduct",
+			input: ApproveRejectLoanApplicationRequest{
+				LoanId:   3,
+				Approval: LOAN_APPROVE,
+			},
+			setup: func(c *gin.Context, data ApproveRejectLoanApplicationRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				business := loanDetail
+				business.Product = sql.NullString{String: "BUSINESS", Valid: true}
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(business, nil).Times(1)
+			},
+			expectedOutput: ApproveRejectLoanApplicationResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to update loan status",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "RejectSkipsDocumentCheck",
+			input: ApproveRejectLoanApplicationRequest{
+				LoanId:   3,
+				Approval: LOAN_REJECT,
+			},
+			setup: func(c *gin.Context, data ApproveRejectLoanApplicationRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
+				repo.EXPECT().UpdateLoanStatus(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_REJECTED, Actor: ACTOR_ADMIN, ActorId: userId}, []outbox.Event{loanEvent(EVENT_LOAN_REJECTED, data.LoanId, LoanRejected{UserId: loanDetail.UserId.Int64, AdminId: userId})}).Return(nil).Times(1)
+			},
+			expectedOutput: ApproveRejectLoanApplicationResponse{
+				Status:  true,
+				Message: "successfully updated loan status",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "VerifiedIncomeTooLow",
+			input: ApproveRejectLoanApplicationRequest{
+				LoanId:   3,
+				Approval: LOAN_APPROVE,
+			},
+			setup: func(c *gin.Context, data ApproveRejectLoanApplicationRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				maxInstallmentIncomeRatio = 0.5
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
+				repo.EXPECT().GetVerifiedDocumentTypes(c, loanDetail.UserId.Int64).Return([]string{"INCOME_PROOF", "ID_PROOF"}, nil).Times(1)
+				//weekly installment of 3000 needs a verified monthly income of at least 26000
+				repo.EXPECT().GetLatestVerifiedIncome(c, loanDetail.UserId.Int64).Return(10000.0, nil).Times(1)
+			},
+			expectedOutput: ApproveRejectLoanApplicationResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to update loan status",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "DocumentsVerified",
+			input: ApproveRejectLoanApplicationRequest{
+				LoanId:   3,
+				Approval: LOAN_APPROVE,
+			},
+			setup: func(c *gin.Context, data ApproveRejectLoanApplicationRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				installment := loanDetail.Amount.Float64 / float64(loanDetail.Tenure.Int64)
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(loanDetail, nil).Times(1)
+				maxInstallmentIncomeRatio = 0.5
+				repo.EXPECT().GetVerifiedDocumentTypes(c, loanDetail.UserId.Int64).Return([]string{"INCOME_PROOF", "ID_PROOF"}, nil).Times(1)
+				repo.EXPECT().GetLatestVerifiedIncome(c, loanDetail.UserId.Int64).Return(30000.0, nil).Times(1)
+				approved := loanEvent(EVENT_LOAN_APPROVED, data.LoanId, LoanApproved{UserId: loanDetail.UserId.Int64, AdminId: userId, Amount: loanDetail.Amount.Float64, Tenure: loanDetail.Tenure.Int64, InstallmentAmount: installment})
+				repo.EXPECT().UpdateAndInsertInstallments(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_APPROVED, Actor: ACTOR_ADMIN, ActorId: userId}, installment, loanDetail.Tenure.Int64, []outbox.Event{approved}).Return(nil).Times(1)
+			},
+			expectedOutput: ApproveRejectLoanApplicationResponse{
+				Status:  true,
+				Message: "successfully updated loan status",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Approve Loan Policy Check TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input, nil, nil)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewLoanService(dbObj)
+
+			//calling the function
+			servObj.ApproveRejectLoanApplication(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+
+			fmt.Println("Ending Approve Loan Policy Check TestCase: ", tt.name)
+		})
+	}
+}
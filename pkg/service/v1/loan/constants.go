@@ -9,6 +9,7 @@ const (
 	LOAN_REJECTED  = "REJECTED"
 	LOAN_REJECT    = "REJECT"
 	LOAN_CANCELLED = "CANCELLED"
+	LOAN_PAID      = "PAID"
 	LOAN_SETTLED   = "SETTLED"
 )
 
@@ -18,3 +19,8 @@ const (
 	TXN_PAID      = "PAID"
 	TXN_CANCELLED = "CANCELLED"
 )
+
+// loan products. all loans are weekly zero-interest personal loans for now
+const (
+	LOAN_PRODUCT_PERSONAL = "PERSONAL"
+)
@@ -0,0 +1,475 @@
+package loan
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/outbox"
+)
+
+// Loans holds the loan rules without any knowledge of HTTP, so handlers, jobs and other transports share them
+type Loans interface {
+	Apply(ctx context.Context, userId int64, amount float64, tenure int64) (Loan, error)
+	Modify(ctx context.Context, userId, loanId int64, amount float64, tenure int64) (Loan, error)
+	Cancel(ctx context.Context, userId, loanId int64, reason string) (Loan, error)
+	UserLoans(ctx context.Context, userId int64) ([]Loan, error)
+	Schedule(ctx context.Context, userId, loanId int64) (Schedule, error)
+	PendingLoans(ctx context.Context) ([]Loan, error)
+	Approve(ctx context.Context, adminId, loanId int64, reason string) error
+	Reject(ctx context.Context, adminId, loanId int64, reason string) error
+	Repay(ctx context.Context, userId, loanId int64, amount float64, txnId string) (Repayment, error)
+	Settle(ctx context.Context, loanId int64, amount float64, txnId string) (Repayment, error)
+	Timeline(ctx context.Context, ownerId, loanId int64) ([]StatusEvent, error)
+}
+
+type Loan struct {
+	LoanId    int64
+	UserId    int64
+	UserName  string
+	Amount    float64
+	Tenure    int64
+	Status    string
+	CreatedAt time.Time
+}
+
+type Installment struct {
+	InstallmentNumber int64
+	AmountDue         float64
+	AmountPaid        float64
+	Status            string
+	TransactionId     string
+	DueDate           time.Time
+}
+
+// Schedule is a loan with its installments and what is left to repay
+type Schedule struct {
+	LoanId            int64
+	LoanAmount        float64
+	OutstandingAmount float64
+	Status            string
+	Installments      []Installment
+}
+
+// StatusEvent is a status change of a loan. From is empty for the application of the loan and ActorId is 0 when
+// the actor is not a user
+type StatusEvent struct {
+	From      string
+	To        string
+	Actor     string
+	ActorId   int64
+	Reason    string
+	CreatedAt time.Time
+}
+
+// Repayment is the outcome of a payment against the next pending installment
+type Repayment struct {
+	LoanId            int64
+	UserId            int64
+	TransactionId     string
+	Amount            float64
+	InstallmentNumber int64
+	OutstandingAmount float64
+	LoanClosed        bool
+}
+
+type loans struct {
+	dbObj v1.V1DBLayer
+}
+
+func NewLoans(db v1.V1DBLayer) Loans {
+	return &loans{
+		dbObj: db,
+	}
+}
+
+func (obj *loans) Apply(ctx context.Context, userId int64, amount float64, tenure int64) (Loan, error) {
+	applied := loanEvent(EVENT_LOAN_APPLIED, 0, LoanApplied{UserId: userId, Amount: amount, Tenure: tenure})
+	loanId, err := obj.dbObj.CreateLoan(ctx, userId, amount, tenure, application(userId), []outbox.Event{applied})
+	if err != nil {
+		log.Printf("failed to create a loan. Error:%s", err.Error())
+		return Loan{}, &StoreError{Op: "create loan", Write: true, Err: err}
+	}
+	return Loan{LoanId: loanId, UserId: userId, Amount: amount, Tenure: tenure, Status: LOAN_PENDING}, nil
+}
+
+func (obj *loans) Modify(ctx context.Context, userId, loanId int64, amount float64, tenure int64) (Loan, error) {
+	modified := loanEvent(EVENT_LOAN_MODIFIED, loanId, LoanModified{UserId: userId, Amount: amount, Tenure: tenure})
+	loanId, err := obj.dbObj.ModifyLoan(ctx, userId, loanId, amount, tenure, []outbox.Event{modified})
+	if err != nil {
+		log.Printf("failed to modify a loan. Error:%s", err.Error())
+		return Loan{}, &StoreError{Op: "modify loan", Write: true, Err: err}
+	}
+	//incorrect loan-user relation or the status is not PENDING
+	if loanId == 0 {
+		log.Printf("failed to modify a loan. This can be because loan is not pending or user-loan relation is incorrect")
+		return Loan{}, ErrLoanNotChangeable
+	}
+	return Loan{LoanId: loanId, UserId: userId, Amount: amount, Tenure: tenure, Status: LOAN_PENDING}, nil
+}
+
+func (obj *loans) Cancel(ctx context.Context, userId, loanId int64, reason string) (Loan, error) {
+	detail, err := obj.dbObj.FetchLoanDetails(ctx, loanId)
+	//a missing loan comes back from the db as a scan error
+	if err != nil || detail.UserId.Int64 != userId {
+		log.Printf("failed to cancel a loan. This can be because loan does not exist or user-loan relation is incorrect")
+		return Loan{}, ErrLoanNotChangeable
+	}
+	change, err := transition(loanId, detail.Status.String, LOAN_CANCELLED, ACTOR_CUSTOMER, userId, reason)
+	if err != nil {
+		return Loan{}, err
+	}
+
+	cancelled := loanEvent(EVENT_LOAN_CANCELLED, loanId, LoanCancelled{UserId: userId, Reason: reason})
+	err = obj.dbObj.UpdateLoanStatus(ctx, loanId, change, []outbox.Event{cancelled})
+	if errors.Is(err, loan.ErrStatusChanged) {
+		return Loan{}, obj.lostTransition(ctx, loanId, change)
+	}
+	if err != nil {
+		log.Printf("failed to cancel a loan. Error:%s", err.Error())
+		return Loan{}, &StoreError{Op: "cancel loan", Write: true, Err: err}
+	}
+	return Loan{LoanId: loanId, UserId: userId, Status: LOAN_CANCELLED}, nil
+}
+
+func (obj *loans) UserLoans(ctx context.Context, userId int64) ([]Loan, error) {
+	stored, err := obj.dbObj.GetUserLoans(ctx, userId)
+	if err != nil {
+		log.Printf("failed to fetch loans. Error:%s", err.Error())
+		return nil, &StoreError{Op: "fetch loans", Err: err}
+	}
+
+	list := make([]Loan, 0)
+	for _, loan := range stored {
+		list = append(list, Loan{
+			LoanId:    loan.LoanId.Int64,
+			UserId:    userId,
+			Amount:    loan.Amount.Float64,
+			Tenure:    loan.Tenure.Int64,
+			Status:    loan.Status.String,
+			CreatedAt: loan.CreatedAt.Time,
+		})
+	}
+	return list, nil
+}
+
+// Schedule returns ErrNoInstallments until the loan is approved or when the loan does not belong to the user
+func (obj *loans) Schedule(ctx context.Context, userId, loanId int64) (Schedule, error) {
+	installments, err := obj.dbObj.GetUserLoanInstallments(ctx, userId, loanId)
+	if err != nil {
+		log.Printf("failed to fetch loan installments. Error:%s", err.Error())
+		return Schedule{}, &StoreError{Op: "fetch installments", Err: err}
+	}
+	if len(installments) == 0 {
+		return Schedule{}, ErrNoInstallments
+	}
+
+	schedule := Schedule{
+		LoanId:            loanId,
+		LoanAmount:        installments[0].LoanAmount.Float64,
+		OutstandingAmount: installments[0].LoanAmount.Float64,
+		Status:            installments[0].LoanStatus.String,
+		Installments:      make([]Installment, 0),
+	}
+	for _, installment := range installments {
+		schedule.Installments = append(schedule.Installments, Installment{
+			InstallmentNumber: installment.InstallmentSeq.Int64,
+			AmountDue:         installment.AmountDue.Float64,
+			AmountPaid:        installment.AmountPaid.Float64,
+			Status:            installment.Status.String,
+			TransactionId:     installment.TransactionId.String,
+			DueDate:           installment.DueDate.Time,
+		})
+		if installment.Status.String == TXN_PAID {
+			schedule.OutstandingAmount -= installment.AmountPaid.Float64
+		}
+	}
+	return schedule, nil
+}
+
+func (obj *loans) PendingLoans(ctx context.Context) ([]Loan, error) {
+	stored, err := obj.dbObj.GetUnapprovedLoans(ctx)
+	if err != nil {
+		log.Printf("failed to fetch loans. Error:%s", err.Error())
+		return nil, &StoreError{Op: "fetch loans", Err: err}
+	}
+
+	list := make([]Loan, 0)
+	for _, loan := range stored {
+		list = append(list, Loan{
+			LoanId:    loan.LoanId.Int64,
+			UserName:  loan.UserName.String,
+			Amount:    loan.Amount.Float64,
+			Tenure:    loan.Installments.Int64,
+			Status:    loan.Status.String,
+			CreatedAt: loan.CreatedAt.Time,
+		})
+	}
+	return list, nil
+}
+
+// Approve creates the installments of a pending loan once the customer passes the KYC and affordability policy
+func (obj *loans) Approve(ctx context.Context, adminId, loanId int64, reason string) error {
+	loanDetail, err := obj.loan(ctx, loanId)
+	if err != nil {
+		return err
+	}
+	change, err := transition(loanId, loanDetail.Status.String, LOAN_APPROVED, ACTOR_ADMIN, adminId, reason)
+	if err != nil {
+		return err
+	}
+
+	//approval needs the customer's KYC documents for the product of the loan to be verified, so a product without
+	//a policy is never approved
+	product := loanDetail.Product.String
+	required, ok := requiredDocuments[product]
+	if !ok {
+		log.Printf("loan approval blocked for LoanId: %d. No policy configured for product %s", loanId, product)
+		return ErrNoProductPolicy
+	}
+	if len(required) != 0 {
+		verified, err := obj.dbObj.GetVerifiedDocumentTypes(ctx, loanDetail.UserId.Int64)
+		if err != nil {
+			log.Printf("failed to fetch verified documents. Error:%s", err.Error())
+			return &StoreError{Op: "verify customer documents", Err: err}
+		}
+		if missing := missingDocuments(product, verified); len(missing) != 0 {
+			log.Printf("loan approval blocked for LoanId: %d. Unverified documents: %v", loanId, missing)
+			return &DocumentsNotVerifiedError{Missing: missing}
+		}
+	}
+
+	//eligibility is based on the latest income backed by a verified income proof
+	if maxInstallmentIncomeRatio > 0 {
+		verifiedIncome, err := obj.dbObj.GetLatestVerifiedIncome(ctx, loanDetail.UserId.Int64)
+		if err != nil {
+			log.Printf("failed to fetch verified income. Error:%s", err.Error())
+			return &StoreError{Op: "check loan eligibility", Err: err}
+		}
+		if !isAffordable(loanDetail.Amount.Float64, loanDetail.Tenure.Int64, verifiedIncome) {
+			log.Printf("loan approval blocked for LoanId: %d. Installment exceeds eligibility for verified income %.2f", loanId, verifiedIncome)
+			return ErrNotAffordable
+		}
+	}
+
+	//finding installment per week but any other logic for installment can be applied here
+	equalInstallmentAmount := loanDetail.Amount.Float64 / float64(loanDetail.Tenure.Int64)
+	//update and insert transactions
+	approved := loanEvent(EVENT_LOAN_APPROVED, loanId, LoanApproved{
+		UserId:            loanDetail.UserId.Int64,
+		AdminId:           adminId,
+		Amount:            loanDetail.Amount.Float64,
+		Tenure:            loanDetail.Tenure.Int64,
+		InstallmentAmount: equalInstallmentAmount,
+		Reason:            reason,
+	})
+	err = obj.dbObj.UpdateAndInsertInstallments(ctx, loanId, change, equalInstallmentAmount, loanDetail.Tenure.Int64, []outbox.Event{approved})
+	if errors.Is(err, loan.ErrStatusChanged) {
+		return obj.lostTransition(ctx, loanId, change)
+	}
+	if err != nil {
+		log.Printf("failed to prepare loan installments. Error:%s", err.Error())
+		return &StoreError{Op: "prepare loan installments", Write: true, Err: err}
+	}
+	return nil
+}
+
+func (obj *loans) Reject(ctx context.Context, adminId, loanId int64, reason string) error {
+	loanDetail, err := obj.loan(ctx, loanId)
+	if err != nil {
+		return err
+	}
+	change, err := transition(loanId, loanDetail.Status.String, LOAN_REJECTED, ACTOR_ADMIN, adminId, reason)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("loan is being rejected by admin. LoanId: %d", loanId)
+	rejected := loanEvent(EVENT_LOAN_REJECTED, loanId, LoanRejected{UserId: loanDetail.UserId.Int64, AdminId: adminId, Reason: reason})
+	err = obj.dbObj.UpdateLoanStatus(ctx, loanId, change, []outbox.Event{rejected})
+	if errors.Is(err, loan.ErrStatusChanged) {
+		return obj.lostTransition(ctx, loanId, change)
+	}
+	if err != nil {
+		log.Printf("failed to update loan status. Error:%s", err.Error())
+		return &StoreError{Op: "update loan status", Write: true, Err: err}
+	}
+	return nil
+}
+
+func (obj *loans) loan(ctx context.Context, loanId int64) (loan.LoanDetails, error) {
+	detail, err := obj.dbObj.FetchLoanDetails(ctx, loanId)
+	if err != nil {
+		//a missing loan comes back from the db as a scan error
+		log.Printf("failed to fetch loan detail. Error:%s", err.Error())
+		return detail, ErrLoanNotFound
+	}
+	return detail, nil
+}
+
+// Repay pays the next pending installment. paying more than due spreads the rest of the loan over the remaining
+// installments, and a payment covering the whole loan closes it
+func (obj *loans) Repay(ctx context.Context, userId, loanId int64, amount float64, txnId string) (Repayment, error) {
+	//scope: validate transaction id with any service if available
+	//get existing installments and check if payment for an installment is valid
+	installments, err := obj.dbObj.GetUserLoanInstallments(ctx, userId, loanId)
+	if err != nil {
+		log.Printf("failed to fetch loan installments. Error:%s", err.Error())
+		return Repayment{}, &StoreError{Op: "fetch installments to process payment", Err: err}
+	}
+
+	if len(installments) == 0 {
+		return Repayment{}, ErrNoInstallments
+	}
+	//a loan takes repayments only while the state machine lets a repayment close it, which refuses loans not yet
+	//approved and loans in a final status before any installment is touched
+	status := installments[0].LoanStatus.String
+	if _, err := transition(loanId, status, LOAN_PAID, ACTOR_SYSTEM, 0, "repayment"); err != nil {
+		return Repayment{}, err
+	}
+
+	loanPaid := 0.0
+	txn := -1
+	//find next available installment
+	for i, installment := range installments {
+		loanPaid += installment.AmountPaid.Float64
+		if installment.Status.String == TXN_PENDING {
+			txn = i
+			break
+		}
+	}
+	//every installment is paid or cancelled, so there is nothing left for the payment to repay
+	if txn < 0 {
+		log.Printf("no pending installment left against LoanId: %d", loanId)
+		return Repayment{}, ErrLoanRepaid
+	}
+	if amount < installments[txn].AmountDue.Float64 {
+		log.Println("amount payable is less than installment amount")
+		return Repayment{}, ErrAmountBelowInstallment
+	}
+	//mark the current txn as paid
+	installments[txn].AmountPaid.Float64 = amount
+	installments[txn].Status.String = TXN_PAID
+	installments[txn].TransactionId.String = txnId
+
+	loanDue := installments[0].LoanAmount.Float64 - loanPaid - amount
+	//if amount paid in installment is so big that it covers more than the entire loan amount, reject the transactions
+	if loanDue < 0 {
+		log.Println("transaction covers more than loan amount")
+		return Repayment{}, ErrOverpayment
+	}
+
+	//if loanDue is greater than 0, make changes to amount due in recurring installments. if not, mark recurring installments as CANCELLED and the loan needs to be marked as PAID
+	duePerInstallment := installments[txn].AmountDue.Float64
+	loanClosed := true
+	if loanDue > 0 {
+		duePerInstallment = loanDue / float64(len(installments)-(txn+1))
+		loanClosed = false
+	}
+	var change loan.StatusChange
+	if loanClosed {
+		change, err = transition(loanId, status, LOAN_PAID, ACTOR_SYSTEM, 0, "repaid with transaction "+txnId)
+		if err != nil {
+			return Repayment{}, err
+		}
+	}
+
+	repayment := Repayment{
+		LoanId:            loanId,
+		UserId:            userId,
+		TransactionId:     txnId,
+		Amount:            amount,
+		InstallmentNumber: installments[txn].InstallmentSeq.Int64,
+		OutstandingAmount: loanDue,
+		LoanClosed:        loanClosed,
+	}
+	events := repayment.events()
+
+	//update installment if repayment amount is exactly as due
+	if installments[txn].AmountDue.Float64 == amount {
+		//update only this installment
+		err := obj.dbObj.UpdateSingleInstallmentPayment(ctx, loanId, installments[txn], change, events)
+		if errors.Is(err, loan.ErrStatusChanged) {
+			return Repayment{}, obj.lostTransition(ctx, loanId, change)
+		}
+		if errors.Is(err, loan.ErrInstallmentChanged) {
+			return Repayment{}, ErrPaymentConflict
+		}
+		if errors.Is(err, loan.ErrDuplicateTransaction) {
+			return Repayment{}, ErrDuplicateTransaction
+		}
+		if err != nil {
+			log.Printf("failed to update payment. Error: %s", err.Error())
+			return Repayment{}, &StoreError{Op: "process payment", Write: true, Err: err}
+		}
+		return repayment, nil
+	}
+
+	//update following transactions with adjusted due amount
+	for i := txn + 1; i < len(installments); i++ {
+		installments[i].AmountDue.Float64 = duePerInstallment
+		if loanDue <= 0 {
+			installments[i].Status.String = TXN_CANCELLED
+		}
+	}
+
+	//update these transactions in DB
+	err = obj.dbObj.UpdateInstallment(ctx, loanId, installments[txn:], change, events)
+	if errors.Is(err, loan.ErrStatusChanged) {
+		return Repayment{}, obj.lostTransition(ctx, loanId, change)
+	}
+	if errors.Is(err, loan.ErrInstallmentChanged) {
+		return Repayment{}, ErrPaymentConflict
+	}
+	if errors.Is(err, loan.ErrDuplicateTransaction) {
+		return Repayment{}, ErrDuplicateTransaction
+	}
+	if err != nil {
+		log.Printf("failed to update payment. Error: %s", err.Error())
+		return Repayment{}, &StoreError{Op: "process payment", Write: true, Err: err}
+	}
+	return repayment, nil
+}
+
+// Settle repays a loan with a payment received outside the app, like a bank transfer matched to the loan by its
+// reference. the payer is unknown, so the payment goes through Repay on behalf of the owner of the loan
+func (obj *loans) Settle(ctx context.Context, loanId int64, amount float64, txnId string) (Repayment, error) {
+	detail, err := obj.loan(ctx, loanId)
+	if err != nil {
+		return Repayment{}, err
+	}
+	return obj.Repay(ctx, detail.UserId.Int64, loanId, amount, txnId)
+}
+
+// Timeline lists the status changes of a loan, oldest first. callers reading only their own loans pass their user
+// id as ownerId and get ErrLoanNotFound for the loans of other users, callers allowed to read any loan pass 0
+func (obj *loans) Timeline(ctx context.Context, ownerId, loanId int64) ([]StatusEvent, error) {
+	detail, err := obj.loan(ctx, loanId)
+	if err != nil {
+		return nil, err
+	}
+	if ownerId != 0 && detail.UserId.Int64 != ownerId {
+		return nil, ErrLoanNotFound
+	}
+
+	history, err := obj.dbObj.GetLoanStatusHistory(ctx, loanId)
+	if err != nil {
+		log.Printf("failed to fetch loan status history. Error:%s", err.Error())
+		return nil, &StoreError{Op: "fetch loan status history", Err: err}
+	}
+	events := make([]StatusEvent, 0)
+	for _, entry := range history {
+		events = append(events, StatusEvent{
+			From:      entry.FromStatus.String,
+			To:        entry.ToStatus.String,
+			Actor:     entry.Actor.String,
+			ActorId:   entry.ActorId.Int64,
+			Reason:    entry.Reason.String,
+			CreatedAt: entry.CreatedAt.Time,
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,321 @@
+package loan
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/document"
+	"aspire-assignment/pkg/db/v1/loan"
+	"aspire-assignment/pkg/db/v1/memory"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/outbox"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_loans_Repay(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+		loanId int64 = 3
+	)
+
+	installment := func(seq int64, due, paid float64, status string) loan.InstallmentDetails {
+		return loan.InstallmentDetails{
+			LoanAmount:     sql.NullFloat64{Float64: 3000, Valid: true},
+			LoanStatus:     sql.NullString{String: LOAN_APPROVED, Valid: true},
+			InstallmentSeq: sql.NullInt64{Int64: seq, Valid: true},
+			AmountDue:      sql.NullFloat64{Float64: due, Valid: true},
+			AmountPaid:     sql.NullFloat64{Float64: paid, Valid: true},
+			Status:         sql.NullString{String: status, Valid: true},
+		}
+	}
+	schedule := func() []loan.InstallmentDetails {
+		return []loan.InstallmentDetails{
+			installment(1, 1000, 1000, TXN_PAID),
+			installment(2, 1000, 0, TXN_PENDING),
+			installment(3, 1000, 0, TXN_PENDING),
+		}
+	}
+
+	tests := []struct {
+		name           string
+		amount         float64
+		setup          func(context.Context)
+		expectedOutput Repayment
+		expectedErr    error
+	}{
+		{
+			name:   "ErrorFetchingInstallments",
+			amount: 1000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(nil, fmt.Errorf("db error")).Times(1)
+			},
+			expectedErr: &StoreError{},
+		},
+		{
+			name:   "NoInstallments",
+			amount: 1000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(nil, nil).Times(1)
+			},
+			expectedErr: ErrNoInstallments,
+		},
+		{
+			name:   "LoanCancelled",
+			amount: 1000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				cancelled := schedule()
+				for i := range cancelled {
+					cancelled[i].LoanStatus.String = LOAN_CANCELLED
+				}
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(cancelled, nil).Times(1)
+			},
+			expectedErr: &TransitionError{LoanId: loanId, From: LOAN_CANCELLED, To: LOAN_PAID, Actor: ACTOR_SYSTEM},
+		},
+		{
+			name:   "LoanNotApproved",
+			amount: 1000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				pending := schedule()
+				for i := range pending {
+					pending[i].LoanStatus.String = LOAN_PENDING
+				}
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(pending, nil).Times(1)
+			},
+			expectedErr: &TransitionError{LoanId: loanId, From: LOAN_PENDING, To: LOAN_PAID, Actor: ACTOR_SYSTEM},
+		},
+		{
+			name:   "AmountBelowInstallment",
+			amount: 500,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(schedule(), nil).Times(1)
+			},
+			expectedErr: ErrAmountBelowInstallment,
+		},
+		{
+			name:   "NoPendingInstallment",
+			amount: 1000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return([]loan.InstallmentDetails{
+					installment(1, 1000, 1000, TXN_PAID),
+					installment(2, 1000, 2000, TXN_PAID),
+					installment(3, 1000, 0, TXN_CANCELLED),
+				}, nil).Times(1)
+			},
+			expectedErr: ErrLoanRepaid,
+		},
+		{
+			name:   "Overpayment",
+			amount: 2500,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(schedule(), nil).Times(1)
+			},
+			expectedErr: ErrOverpayment,
+		},
+		{
+			name:   "ExactInstallment",
+			amount: 1000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(schedule(), nil).Times(1)
+				paid := installment(2, 1000, 1000, TXN_PAID)
+				paid.TransactionId = sql.NullString{String: "txn1"}
+				paidEvent := loanEvent(EVENT_INSTALLMENT_PAID, loanId, InstallmentPaid{UserId: userId, InstallmentNumber: 2, Amount: 1000, TransactionId: "txn1", OutstandingAmount: 1000})
+				repo.EXPECT().UpdateSingleInstallmentPayment(ctx, loanId, paid, loan.StatusChange{}, []outbox.Event{paidEvent}).Return(nil).Times(1)
+			},
+			expectedOutput: Repayment{LoanId: loanId, UserId: userId, TransactionId: "txn1", Amount: 1000, InstallmentNumber: 2, OutstandingAmount: 1000},
+		},
+		{
+			name:   "RepayRemainingLoan",
+			amount: 2000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(schedule(), nil).Times(1)
+				paid := installment(2, 1000, 2000, TXN_PAID)
+				paid.TransactionId = sql.NullString{String: "txn1"}
+				repo.EXPECT().UpdateInstallment(ctx, loanId, []loan.InstallmentDetails{paid, installment(3, 1000, 0, TXN_CANCELLED)}, loan.StatusChange{From: LOAN_APPROVED, To: LOAN_PAID, Actor: ACTOR_SYSTEM, Reason: "repaid with transaction txn1"}, []outbox.Event{
+					loanEvent(EVENT_INSTALLMENT_PAID, loanId, InstallmentPaid{UserId: userId, InstallmentNumber: 2, Amount: 2000, TransactionId: "txn1"}),
+					loanEvent(EVENT_LOAN_CLOSED, loanId, LoanClosed{UserId: userId, TransactionId: "txn1"}),
+				}).Return(nil).Times(1)
+			},
+			expectedOutput: Repayment{LoanId: loanId, UserId: userId, TransactionId: "txn1", Amount: 2000, InstallmentNumber: 2, LoanClosed: true},
+		},
+		{
+			name:   "ErrorUpdatingPayment",
+			amount: 1500,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateInstallment(ctx, loanId, gomock.Any(), loan.StatusChange{}, gomock.Any()).Return(fmt.Errorf("db error")).Times(1)
+			},
+			expectedErr: &StoreError{Write: true},
+		},
+		{
+			name:   "ClosedConcurrently",
+			amount: 2000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateInstallment(ctx, loanId, gomock.Any(), gomock.Any(), gomock.Any()).Return(loan.ErrStatusChanged).Times(1)
+				repo.EXPECT().FetchLoanDetails(ctx, loanId).Return(loan.LoanDetails{Status: sql.NullString{String: LOAN_PAID, Valid: true}}, nil).Times(1)
+			},
+			expectedErr: &TransitionError{LoanId: loanId, From: LOAN_PAID, To: LOAN_PAID, Actor: ACTOR_SYSTEM},
+		},
+		{
+			name:   "InstallmentPaidConcurrently",
+			amount: 1000,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateSingleInstallmentPayment(ctx, loanId, gomock.Any(), loan.StatusChange{}, gomock.Any()).Return(loan.ErrInstallmentChanged).Times(1)
+			},
+			expectedErr: ErrPaymentConflict,
+		},
+		{
+			name:   "TransactionAlreadyApplied",
+			amount: 1500,
+			setup: func(ctx context.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(ctx, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateInstallment(ctx, loanId, gomock.Any(), loan.StatusChange{}, gomock.Any()).Return(loan.ErrDuplicateTransaction).Times(1)
+			},
+			expectedErr: ErrDuplicateTransaction,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tt.setup(ctx)
+
+			repayment, err := NewLoans(dbObj).Repay(ctx, userId, loanId, tt.amount, "txn1")
+
+			var (
+				expectedStoreErr, storeErr           *StoreError
+				expectedTransitionErr, transitionErr *TransitionError
+			)
+			switch {
+			case errors.As(tt.expectedErr, &expectedStoreErr):
+				assert.Equal(t, true, errors.As(err, &storeErr))
+				assert.Equal(t, expectedStoreErr.Write, storeErr.Write)
+			case errors.As(tt.expectedErr, &expectedTransitionErr):
+				assert.Equal(t, true, errors.As(err, &transitionErr))
+				assert.Equal(t, *expectedTransitionErr, *transitionErr)
+			case tt.expectedErr != nil:
+				assert.Equal(t, true, errors.Is(err, tt.expectedErr))
+			default:
+				assert.Equal(t, nil, err)
+			}
+			assert.Equal(t, tt.expectedOutput, repayment)
+		})
+	}
+}
+
+func Test_loans_Lifecycle(t *testing.T) {
+	ctx := context.Background()
+	dbObj := memory.NewV1DbLayer()
+
+	requiredDocuments = map[string][]string{LOAN_PRODUCT_PERSONAL: {"INCOME_PROOF"}}
+	maxInstallmentIncomeRatio = 0.5
+	defer func() {
+		requiredDocuments = nil
+		maxInstallmentIncomeRatio = 0
+	}()
+
+	userId, _ := dbObj.AddUser(ctx, usermanagement.UserDetails{
+		UserName:      sql.NullString{String: "john", Valid: true},
+		UserType:      sql.NullString{String: "CUSTOMER", Valid: true},
+		MonthlySalary: sql.NullFloat64{Float64: 10000, Valid: true},
+	})
+	loans := NewLoans(dbObj)
+
+	applied, err := loans.Apply(ctx, userId, 3000, 3)
+	assert.Equal(t, nil, err)
+
+	var documentsErr *DocumentsNotVerifiedError
+	err = loans.Approve(ctx, 99, applied.LoanId, "")
+	assert.Equal(t, true, errors.As(err, &documentsErr))
+
+	documentId, _ := dbObj.AddDocument(ctx, document.DocumentDetails{
+		UserId:     sql.NullInt64{Int64: userId, Valid: true},
+		DocType:    sql.NullString{String: "INCOME_PROOF", Valid: true},
+		StorageKey: sql.NullString{String: "1/income.pdf", Valid: true},
+	})
+	dbObj.UpdateDocumentStatus(ctx, documentId, "VERIFIED", 99, "")
+
+	assert.Equal(t, nil, loans.Approve(ctx, 99, applied.LoanId, ""))
+	var transitionErr *TransitionError
+	assert.Equal(t, true, errors.As(loans.Approve(ctx, 99, applied.LoanId, ""), &transitionErr))
+	assert.Equal(t, LOAN_APPROVED, transitionErr.From)
+	_, err = loans.Cancel(ctx, userId+1, applied.LoanId, "")
+	assert.Equal(t, ErrLoanNotChangeable, err)
+	_, err = loans.Cancel(ctx, userId, applied.LoanId, "")
+	assert.Equal(t, true, errors.As(err, &transitionErr))
+
+	repayment, err := loans.Repay(ctx, userId, applied.LoanId, 1000, "txn1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, float64(2000), repayment.OutstandingAmount)
+
+	//a payment matched to the loan from outside the app repays it on behalf of its owner
+	_, err = loans.Settle(ctx, applied.LoanId+1, 2000, "txn2")
+	assert.Equal(t, ErrLoanNotFound, err)
+	repayment, err = loans.Settle(ctx, applied.LoanId, 2000, "txn2")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, userId, repayment.UserId)
+	assert.Equal(t, true, repayment.LoanClosed)
+
+	schedule, err := loans.Schedule(ctx, userId, applied.LoanId)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "PAID", schedule.Status)
+	assert.Equal(t, float64(0), schedule.OutstandingAmount)
+
+	//the timeline is kept from the application to the closure, and only the owner or an admin can read it
+	_, err = loans.Timeline(ctx, userId+1, applied.LoanId)
+	assert.Equal(t, ErrLoanNotFound, err)
+	events, err := loans.Timeline(ctx, 0, applied.LoanId)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 3, len(events))
+	assert.Equal(t, StatusEvent{To: LOAN_PENDING, Actor: ACTOR_CUSTOMER, ActorId: userId, Reason: "applied for loan", CreatedAt: events[0].CreatedAt}, events[0])
+	assert.Equal(t, StatusEvent{From: LOAN_PENDING, To: LOAN_APPROVED, Actor: ACTOR_ADMIN, ActorId: 99, CreatedAt: events[1].CreatedAt}, events[1])
+	assert.Equal(t, StatusEvent{From: LOAN_APPROVED, To: LOAN_PAID, Actor: ACTOR_SYSTEM, Reason: "repaid with transaction txn2", CreatedAt: events[2].CreatedAt}, events[2])
+	owned, _ := loans.Timeline(ctx, userId, applied.LoanId)
+	assert.Equal(t, events, owned)
+}
@@ -0,0 +1,58 @@
+package loan
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// domain errors returned by Loans. transports map them to their own status codes
+var (
+	ErrLoanNotFound           = errors.New("loan not found")
+	ErrLoanNotChangeable      = errors.New("only loans created by user in PENDING status can be changed")
+	ErrNoInstallments         = errors.New("no installments against loan available")
+	ErrAmountBelowInstallment = errors.New("amount payable is less than installment amount")
+	ErrOverpayment            = errors.New("transaction repays more than loan amount. transaction not allowed")
+	ErrNotAffordable          = errors.New("weekly installment exceeds eligibility for verified income")
+	ErrNoProductPolicy        = errors.New("no approval policy configured for the loan product")
+	ErrPaymentConflict        = errors.New("installment was paid by a concurrent payment. check the loan before paying again")
+	ErrDuplicateTransaction   = errors.New("transaction already applied to an installment")
+	ErrLoanRepaid             = errors.New("loan already repaid. no pending installment left to pay")
+)
+
+// DocumentsNotVerifiedError blocks an approval until the listed KYC documents are verified
+type DocumentsNotVerifiedError struct {
+	Missing []string
+}
+
+func (err *DocumentsNotVerifiedError) Error() string {
+	return "documents not verified: " + strings.Join(err.Missing, ",")
+}
+
+// TransitionError is a status change the loan state machine does not allow, either from the current status of
+// the loan or by the actor asking for it
+type TransitionError struct {
+	LoanId int64
+	From   string
+	To     string
+	Actor  string
+}
+
+func (err *TransitionError) Error() string {
+	return fmt.Sprintf("loan %d in %s status cannot be moved to %s by %s", err.LoanId, err.From, err.To, strings.ToLower(err.Actor))
+}
+
+// StoreError is a failed read or write of the database while running a loan operation
+type StoreError struct {
+	Op    string
+	Write bool
+	Err   error
+}
+
+func (err *StoreError) Error() string {
+	return fmt.Sprintf("failed to %s. Error: %s", err.Op, err.Err.Error())
+}
+
+func (err *StoreError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,93 @@
+package loan
+
+import (
+	"encoding/json"
+
+	"aspire-assignment/pkg/db/v1/outbox"
+)
+
+// domain events of a loan. they are written to the outbox with the change they announce and the loan id is the
+// aggregate id of each of them
+const (
+	EVENT_LOAN_APPLIED     = "loan.applied"
+	EVENT_LOAN_MODIFIED    = "loan.modified"
+	EVENT_LOAN_APPROVED    = "loan.approved"
+	EVENT_LOAN_REJECTED    = "loan.rejected"
+	EVENT_LOAN_CANCELLED   = "loan.cancelled"
+	EVENT_INSTALLMENT_PAID = "installment.paid"
+	EVENT_LOAN_CLOSED      = "loan.closed"
+)
+
+// EventTypes lists every event published about loans, the event types webhooks can subscribe to
+var EventTypes = []string{EVENT_LOAN_APPLIED, EVENT_LOAN_MODIFIED, EVENT_LOAN_APPROVED, EVENT_LOAN_REJECTED, EVENT_LOAN_CANCELLED, EVENT_INSTALLMENT_PAID, EVENT_LOAN_CLOSED}
+
+const AGGREGATE_LOAN = "loan"
+
+// payloads of the loan events. they are the contract with consumers, so fields are only ever added
+
+type LoanApplied struct {
+	UserId int64   `json:"userId"`
+	Amount float64 `json:"amount"`
+	Tenure int64   `json:"tenure"`
+}
+
+type LoanModified struct {
+	UserId int64   `json:"userId"`
+	Amount float64 `json:"amount"`
+	Tenure int64   `json:"tenure"`
+}
+
+type LoanApproved struct {
+	UserId            int64   `json:"userId"`
+	AdminId           int64   `json:"adminId"`
+	Amount            float64 `json:"amount"`
+	Tenure            int64   `json:"tenure"`
+	InstallmentAmount float64 `json:"installmentAmount"`
+	Reason            string  `json:"reason,omitempty"`
+}
+
+type LoanRejected struct {
+	UserId  int64  `json:"userId"`
+	AdminId int64  `json:"adminId"`
+	Reason  string `json:"reason,omitempty"`
+}
+
+type LoanCancelled struct {
+	UserId int64  `json:"userId"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type InstallmentPaid struct {
+	UserId            int64   `json:"userId"`
+	InstallmentNumber int64   `json:"installmentNumber"`
+	Amount            float64 `json:"amount"`
+	TransactionId     string  `json:"transactionId"`
+	OutstandingAmount float64 `json:"outstandingAmount"`
+}
+
+type LoanClosed struct {
+	UserId        int64  `json:"userId"`
+	TransactionId string `json:"transactionId"`
+}
+
+// loanEvent is an event about the loan. loanId is 0 for a loan not created yet, which gets its id when it is
+func loanEvent(eventType string, loanId int64, payload interface{}) outbox.Event {
+	//the payloads are plain structs, which always marshal
+	body, _ := json.Marshal(payload)
+	return outbox.Event{EventType: eventType, AggregateType: AGGREGATE_LOAN, AggregateId: loanId, Payload: string(body)}
+}
+
+// events announce the payment of the installment and, when the payment repaid the loan, its closure
+func (repayment Repayment) events() []outbox.Event {
+	events := []outbox.Event{loanEvent(EVENT_INSTALLMENT_PAID, repayment.LoanId, InstallmentPaid{
+		UserId:            repayment.UserId,
+		InstallmentNumber: repayment.InstallmentNumber,
+		Amount:            repayment.Amount,
+		TransactionId:     repayment.TransactionId,
+		OutstandingAmount: repayment.OutstandingAmount,
+	})}
+	if repayment.LoanClosed {
+		events = append(events, loanEvent(EVENT_LOAN_CLOSED, repayment.LoanId, LoanClosed{UserId: repayment.UserId, TransactionId: repayment.TransactionId}))
+	}
+	return events
+}
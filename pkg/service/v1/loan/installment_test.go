@@ -5,6 +5,7 @@ import (
 	v1 "aspire-assignment/pkg/db/v1"
 	"aspire-assignment/pkg/db/v1/loan"
 	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/outbox"
 	e "aspire-assignment/pkg/errors"
 	"database/sql"
 	"encoding/json"
@@ -144,6 +145,8 @@ func Test_loanService_GetInstallments(t *testing.T) {
 					OutstandingAmount: 5000,
 					Tenure:            2,
 					Status:            LOAN_APPROVED,
+					PaymentReference:  "AL000000034",
+					VirtualAccount:    "9911000000034",
 					Installments: []InstallmentDetails{{
 						AmoundDue:         5000,
 						AmountPaid:        5000,
@@ -475,7 +478,7 @@ func Test_loanService_ProcessLoanPayment(t *testing.T) {
 					DueDate:        sql.NullTime{Time: t1.Add(24 * time.Hour), Valid: true},
 					LoanAmount:     sql.NullFloat64{Float64: 10000, Valid: true},
 					LoanStatus:     sql.NullString{String: LOAN_APPROVED, Valid: true},
-				}, true).Return(fmt.Errorf("db error")).Times(1)
+				}, loan.StatusChange{From: LOAN_APPROVED, To: LOAN_PAID, Actor: ACTOR_SYSTEM, Reason: "repaid with transaction txn2"}, gomock.Any()).Return(fmt.Errorf("db error")).Times(1)
 			},
 			expectedOutput: ProcessLoanPaymentResponse{
 				Status: false,
@@ -532,7 +535,10 @@ func Test_loanService_ProcessLoanPayment(t *testing.T) {
 					DueDate:        sql.NullTime{Time: t1.Add(24 * time.Hour), Valid: true},
 					LoanAmount:     sql.NullFloat64{Float64: 10000, Valid: true},
 					LoanStatus:     sql.NullString{String: LOAN_APPROVED, Valid: true},
-				}, true).Return(nil).Times(1)
+				}, loan.StatusChange{From: LOAN_APPROVED, To: LOAN_PAID, Actor: ACTOR_SYSTEM, Reason: "repaid with transaction txn2"}, []outbox.Event{
+					loanEvent(EVENT_INSTALLMENT_PAID, data.LoanId, InstallmentPaid{UserId: userId, InstallmentNumber: 2, Amount: 5000, TransactionId: "txn2"}),
+					loanEvent(EVENT_LOAN_CLOSED, data.LoanId, LoanClosed{UserId: userId, TransactionId: "txn2"}),
+				}).Return(nil).Times(1)
 			},
 			expectedOutput: ProcessLoanPaymentResponse{
 				Status: true,
@@ -612,7 +618,7 @@ func Test_loanService_ProcessLoanPayment(t *testing.T) {
 					LoanAmount:     sql.NullFloat64{Float64: 21000, Valid: true},
 					LoanStatus:     sql.NullString{String: LOAN_APPROVED, Valid: true},
 				})
-				repo.EXPECT().UpdateInstallment(c, data.LoanId, updatedInstallments, false).Return(fmt.Errorf("db error")).Times(1)
+				repo.EXPECT().UpdateInstallment(c, data.LoanId, updatedInstallments, loan.StatusChange{}, gomock.Any()).Return(fmt.Errorf("db error")).Times(1)
 			},
 			expectedOutput: ProcessLoanPaymentResponse{
 				Status: false,
@@ -692,7 +698,8 @@ func Test_loanService_ProcessLoanPayment(t *testing.T) {
 					LoanAmount:     sql.NullFloat64{Float64: 21000, Valid: true},
 					LoanStatus:     sql.NullString{String: LOAN_APPROVED, Valid: true},
 				})
-				repo.EXPECT().UpdateInstallment(c, data.LoanId, updatedInstallments, false).Return(nil).Times(1)
+				paid := loanEvent(EVENT_INSTALLMENT_PAID, data.LoanId, InstallmentPaid{UserId: userId, InstallmentNumber: 2, Amount: 10000, TransactionId: "txn2", OutstandingAmount: 4000})
+				repo.EXPECT().UpdateInstallment(c, data.LoanId, updatedInstallments, loan.StatusChange{}, []outbox.Event{paid}).Return(nil).Times(1)
 			},
 			expectedOutput: ProcessLoanPaymentResponse{
 				Status:  true,
@@ -735,3 +742,73 @@ func Test_loanService_ProcessLoanPayment(t *testing.T) {
 		})
 	}
 }
+
+func Test_loanService_ProcessLoanPaymentServiceAccount(t *testing.T) {
+	var (
+		dbObj      v1.V1DBLayer
+		customerId int64 = 7
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		httpStatus int
+		input      ProcessLoanPaymentRequest
+		setup      func(*gin.Context, ProcessLoanPaymentRequest)
+	}{
+		{
+			name: "MissingCustomerId",
+			input: ProcessLoanPaymentRequest{
+				LoanId:        3,
+				Amount:        5000,
+				TransactionId: "txn1",
+			},
+			setup: func(c *gin.Context, data ProcessLoanPaymentRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name: "CollectsForCustomer",
+			input: ProcessLoanPaymentRequest{
+				CustomerId:    customerId,
+				LoanId:        3,
+				Amount:        5000,
+				TransactionId: "txn1",
+			},
+			setup: func(c *gin.Context, data ProcessLoanPaymentRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserLoanInstallments(c, customerId, data.LoanId).Return([]loan.InstallmentDetails{}, nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Service Account Payment TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodPost, tt.input, nil, nil)
+			ctx.Set(config.APIKEYID, int64(1))
+			ctx.Set(config.APIKEY, "0a1b2c3d")
+			ctx.Set(config.SERVICEACCT, "collections-batch")
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewLoanService(dbObj)
+
+			//calling the function
+			servObj.ProcessLoanPayment(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			fmt.Println("Ending Service Account Payment TestCase: ", tt.name)
+		})
+	}
+}
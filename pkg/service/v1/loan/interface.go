@@ -1,13 +1,18 @@
 package loan
 
 import (
+	"errors"
+	"net/http"
+
 	v1 "aspire-assignment/pkg/db/v1"
+	e "aspire-assignment/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
 
+// loanService adapts the loan domain to HTTP: it binds requests, calls Loans and writes responses
 type loanService struct {
-	dbObj v1.V1DBLayer
+	loans Loans
 }
 
 type LoanInterface interface {
@@ -16,6 +21,7 @@ type LoanInterface interface {
 	CancelLoan(*gin.Context)
 	GetLoans(*gin.Context)
 	GetInstallments(*gin.Context)
+	GetLoanTimeline(*gin.Context)
 	GetPendingLoans(*gin.Context)
 	ApproveRejectLoanApplication(*gin.Context)
 	ProcessLoanPayment(*gin.Context)
@@ -23,6 +29,34 @@ type LoanInterface interface {
 
 func NewLoanService(db v1.V1DBLayer) LoanInterface {
 	return &loanService{
-		dbObj: db,
+		loans: NewLoans(db),
+	}
+}
+
+// errorResponse maps a domain error to the HTTP status and error reported to the client
+func errorResponse(err error) (int, e.Error) {
+	var (
+		storeErr      *StoreError
+		documentsErr  *DocumentsNotVerifiedError
+		transitionErr *TransitionError
+	)
+	switch {
+	case errors.As(err, &storeErr) && storeErr.Write:
+		return http.StatusInternalServerError, *e.ErrorInfo[e.AddDBError]
+	case errors.As(err, &storeErr):
+		return http.StatusInternalServerError, *e.ErrorInfo[e.GetDBError]
+	case errors.As(err, &documentsErr):
+		return http.StatusBadRequest, e.ErrorInfo[e.BadRequest].GetErrorDetails(err.Error())
+	case errors.As(err, &transitionErr), errors.Is(err, ErrPaymentConflict), errors.Is(err, ErrDuplicateTransaction),
+		errors.Is(err, ErrLoanRepaid):
+		return http.StatusConflict, e.ErrorInfo[e.Conflict].GetErrorDetails(err.Error())
+	case errors.Is(err, ErrLoanNotFound), errors.Is(err, ErrNoInstallments):
+		return http.StatusNotFound, *e.ErrorInfo[e.NoDataFound]
+	case errors.Is(err, ErrOverpayment):
+		return http.StatusNotAcceptable, e.ErrorInfo[e.BadRequest].GetErrorDetails(err.Error())
+	case errors.Is(err, ErrLoanNotChangeable), errors.Is(err, ErrAmountBelowInstallment), errors.Is(err, ErrNotAffordable),
+		errors.Is(err, ErrNoProductPolicy):
+		return http.StatusBadRequest, e.ErrorInfo[e.BadRequest].GetErrorDetails(err.Error())
 	}
+	return http.StatusInternalServerError, *e.ErrorInfo[e.DefaultError]
 }
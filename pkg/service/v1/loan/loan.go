@@ -4,6 +4,8 @@ import (
 	"log"
 	"net/http"
 
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/auth"
 	"aspire-assignment/pkg/config"
 	e "aspire-assignment/pkg/errors"
 
@@ -25,23 +27,24 @@ func (obj *loanService) CreateLoan(c *gin.Context) {
 	request.UserId = c.GetInt64(config.USERID)
 
 	//make a loan entry in db
-	loanId, err := obj.dbObj.CreateLoan(c, request.UserId, request.Amount, request.Tenure)
+	loan, err := obj.loans.Apply(c, request.UserId, request.Amount, request.Tenure)
 	if err != nil {
-		log.Printf("failed to create a loan. Error:%s", err.Error())
-		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
 		response.Message = "failed to create loan"
-		c.JSON(http.StatusInternalServerError, response)
+		c.JSON(status, response)
 		return
 	}
 
-	loanDetail := LoanDetails{
-		LoanId: loanId,
-		Amount: request.Amount,
-		Tenure: request.Tenure,
-		Status: LOAN_PENDING,
-	}
+	audit.Target(c, audit.TARGET_LOAN, loan.LoanId)
+	audit.Change(c, nil, map[string]interface{}{"amount": loan.Amount, "tenure": loan.Tenure, "status": loan.Status})
 	response.Status = true
-	response.Data = &loanDetail
+	response.Data = &LoanDetails{
+		LoanId: loan.LoanId,
+		Amount: loan.Amount,
+		Tenure: loan.Tenure,
+		Status: loan.Status,
+	}
 	response.Message = "successfully created loan"
 
 	c.JSON(http.StatusOK, response)
@@ -60,33 +63,26 @@ func (obj *loanService) ModifyLoan(c *gin.Context) {
 		return
 	}
 	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_LOAN, request.LoanId)
 
 	//modify the loan if the loan is pending
-	loanId, err := obj.dbObj.ModifyLoan(c, request.UserId, request.LoanId, request.Amount, request.Tenure)
+	loan, err := obj.loans.Modify(c, request.UserId, request.LoanId, request.Amount, request.Tenure)
 	if err != nil {
-		log.Printf("failed to modify a loan. Error:%s", err.Error())
-		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
 		response.Message = "failed to modify loan"
-		c.JSON(http.StatusInternalServerError, response)
-		return
-	}
-	//incorrect loan-user relation or the status is not PENDING
-	if loanId == 0 {
-		log.Printf("failed to modify a loan. This can be because loan is not pending or user-loan relation is incorrect")
-		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("only loans created by user in PENDING status can be modified"))
-		response.Message = "failed to modify loan"
-		c.JSON(http.StatusBadRequest, response)
+		c.JSON(status, response)
 		return
 	}
 
-	loanDetail := LoanDetails{
-		LoanId: loanId,
-		Amount: request.Amount,
-		Tenure: request.Tenure,
-		Status: LOAN_PENDING,
-	}
+	audit.Change(c, nil, map[string]interface{}{"amount": loan.Amount, "tenure": loan.Tenure})
 	response.Status = true
-	response.Data = &loanDetail
+	response.Data = &LoanDetails{
+		LoanId: loan.LoanId,
+		Amount: loan.Amount,
+		Tenure: loan.Tenure,
+		Status: loan.Status,
+	}
 	response.Message = "successfully modified loan"
 
 	c.JSON(http.StatusOK, response)
@@ -104,31 +100,24 @@ func (obj *loanService) CancelLoan(c *gin.Context) {
 		return
 	}
 	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_LOAN, request.LoanId)
 
 	//cancel the loan if the loan is pending
-	loanId, err := obj.dbObj.CancelLoan(c, request.UserId, request.LoanId)
+	loan, err := obj.loans.Cancel(c, request.UserId, request.LoanId, request.Reason)
 	if err != nil {
-		log.Printf("failed to cancel a loan. Error:%s", err.Error())
-		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
 		response.Message = "failed to cancel loan"
-		c.JSON(http.StatusInternalServerError, response)
-		return
-	}
-	//incorrect loan-user relation or the status is not PENDING
-	if loanId == 0 {
-		log.Printf("failed to cancel a loan. This can be because loan is not pending or user-loan relation is incorrect")
-		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("only loans created by user in PENDING status can be cancelled"))
-		response.Message = "failed to cancel loan"
-		c.JSON(http.StatusBadRequest, response)
+		c.JSON(status, response)
 		return
 	}
 
-	loanDetail := LoanDetails{
-		LoanId: loanId,
-		Status: LOAN_CANCELLED,
-	}
+	audit.Change(c, map[string]interface{}{"status": LOAN_PENDING}, map[string]interface{}{"status": loan.Status, "reason": request.Reason})
 	response.Status = true
-	response.Data = &loanDetail
+	response.Data = &LoanDetails{
+		LoanId: loan.LoanId,
+		Status: loan.Status,
+	}
 	response.Message = "successfully modified loan"
 
 	c.JSON(http.StatusOK, response)
@@ -151,12 +140,12 @@ func (obj *loanService) GetLoans(c *gin.Context) {
 	//TODO: add custom status like only loans which are pending or cancelled. add a query scan param
 
 	//fetch loans
-	loans, err := obj.dbObj.GetUserLoans(c, request.UserId)
+	loans, err := obj.loans.UserLoans(c, request.UserId)
 	if err != nil {
-		log.Printf("failed to fetch loans. Error:%s", err.Error())
-		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
 		response.Message = "failed to fetch loans"
-		c.JSON(http.StatusInternalServerError, response)
+		c.JSON(status, response)
 		return
 	}
 
@@ -170,14 +159,57 @@ func (obj *loanService) GetLoans(c *gin.Context) {
 	response.Data = make([]LoanDetails, 0)
 	for _, loan := range loans {
 		response.Data = append(response.Data, LoanDetails{
-			LoanId:    loan.LoanId.Int64,
-			Amount:    loan.Amount.Float64,
-			Tenure:    loan.Tenure.Int64,
-			Status:    loan.Status.String,
-			CreatedAt: loan.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+			LoanId:    loan.LoanId,
+			Amount:    loan.Amount,
+			Tenure:    loan.Tenure,
+			Status:    loan.Status,
+			CreatedAt: loan.CreatedAt.Format("2006-01-02 15:04:05"),
 		})
 	}
 	response.Status = true
 	response.Message = "successfully fetched user loans"
 	c.JSON(http.StatusOK, response)
 }
+
+// GetLoanTimeline lists the status changes of a loan. users who can read any loan see every loan, others their own
+func (obj *loanService) GetLoanTimeline(c *gin.Context) {
+	var (
+		request  GetLoanDetailRequest
+		response GetLoanTimelineResponse
+	)
+	if err := c.BindQuery(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to fetch loan timeline"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+	if auth.Granted(c, auth.LOAN_READ_ANY) {
+		request.UserId = 0
+	}
+
+	events, err := obj.loans.Timeline(c, request.UserId, request.LoanId)
+	if err != nil {
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
+		response.Message = "failed to fetch loan timeline"
+		c.JSON(status, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = make([]LoanStatusEvent, 0)
+	for _, event := range events {
+		response.Data = append(response.Data, LoanStatusEvent{
+			FromStatus: event.From,
+			Status:     event.To,
+			Actor:      event.Actor,
+			ActorId:    event.ActorId,
+			Reason:     event.Reason,
+			CreatedAt:  event.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+	response.Message = "successfully fetched loan timeline"
+	c.JSON(http.StatusOK, response)
+}
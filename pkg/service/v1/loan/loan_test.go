@@ -1,10 +1,12 @@
 package loan
 
 import (
+	"aspire-assignment/pkg/auth"
 	"aspire-assignment/pkg/config"
 	v1 "aspire-assignment/pkg/db/v1"
 	"aspire-assignment/pkg/db/v1/loan"
 	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/outbox"
 	e "aspire-assignment/pkg/errors"
 	"bytes"
 	"database/sql"
@@ -93,7 +95,7 @@ func Test_loanService_CreateLoan(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
-				repo.EXPECT().CreateLoan(c, userId, data.Amount, data.Tenure).Return(int64(0), fmt.Errorf("failed to create loan")).Times(1)
+				repo.EXPECT().CreateLoan(c, userId, data.Amount, data.Tenure, loan.StatusChange{To: LOAN_PENDING, Actor: ACTOR_CUSTOMER, ActorId: userId, Reason: "applied for loan"}, gomock.Any()).Return(int64(0), fmt.Errorf("failed to create loan")).Times(1)
 			},
 			expectedOutput: CreateLoanResponse{
 				Status: false,
@@ -117,7 +119,7 @@ func Test_loanService_CreateLoan(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
-				repo.EXPECT().CreateLoan(c, userId, data.Amount, data.Tenure).Return(int64(1), nil).Times(1)
+				repo.EXPECT().CreateLoan(c, userId, data.Amount, data.Tenure, loan.StatusChange{To: LOAN_PENDING, Actor: ACTOR_CUSTOMER, ActorId: userId, Reason: "applied for loan"}, []outbox.Event{loanEvent(EVENT_LOAN_APPLIED, 0, LoanApplied{UserId: userId, Amount: data.Amount, Tenure: data.Tenure})}).Return(int64(1), nil).Times(1)
 			},
 			expectedOutput: CreateLoanResponse{
 				Status: true,
@@ -262,7 +264,7 @@ func Test_loanService_ModifyLoan(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
-				repo.EXPECT().ModifyLoan(c, userId, data.LoanId, data.Amount, data.Tenure).Return(int64(0), fmt.Errorf("failed to modify loan")).Times(1)
+				repo.EXPECT().ModifyLoan(c, userId, data.LoanId, data.Amount, data.Tenure, gomock.Any()).Return(int64(0), fmt.Errorf("failed to modify loan")).Times(1)
 			},
 			expectedOutput: ModifyLoanResponse{
 				Status: false,
@@ -287,7 +289,7 @@ func Test_loanService_ModifyLoan(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
-				repo.EXPECT().ModifyLoan(c, userId, data.LoanId, data.Amount, data.Tenure).Return(int64(0), nil).Times(1)
+				repo.EXPECT().ModifyLoan(c, userId, data.LoanId, data.Amount, data.Tenure, []outbox.Event{loanEvent(EVENT_LOAN_MODIFIED, data.LoanId, LoanModified{UserId: userId, Amount: data.Amount, Tenure: data.Tenure})}).Return(int64(0), nil).Times(1)
 			},
 			expectedOutput: ModifyLoanResponse{
 				Status: false,
@@ -312,7 +314,7 @@ func Test_loanService_ModifyLoan(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
-				repo.EXPECT().ModifyLoan(c, userId, data.LoanId, data.Amount, data.Tenure).Return(int64(1), nil).Times(1)
+				repo.EXPECT().ModifyLoan(c, userId, data.LoanId, data.Amount, data.Tenure, []outbox.Event{loanEvent(EVENT_LOAN_MODIFIED, data.LoanId, LoanModified{UserId: userId, Amount: data.Amount, Tenure: data.Tenure})}).Return(int64(1), nil).Times(1)
 			},
 			expectedOutput: ModifyLoanResponse{
 				Status: true,
@@ -409,7 +411,13 @@ func Test_loanService_CancelLoan(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
-				repo.EXPECT().CancelLoan(c, userId, data.LoanId).Return(int64(0), fmt.Errorf("failed to cancel loan")).Times(1)
+				pending := loan.LoanDetails{
+					LoanId: sql.NullInt64{Int64: data.LoanId, Valid: true},
+					UserId: sql.NullInt64{Int64: userId, Valid: true},
+					Status: sql.NullString{String: LOAN_PENDING, Valid: true},
+				}
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(pending, nil).Times(1)
+				repo.EXPECT().UpdateLoanStatus(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_CANCELLED, Actor: ACTOR_CUSTOMER, ActorId: userId}, gomock.Any()).Return(fmt.Errorf("failed to cancel loan")).Times(1)
 			},
 			expectedOutput: CancelLoanResponse{
 				Status: false,
@@ -424,7 +432,7 @@ func Test_loanService_CancelLoan(t *testing.T) {
 			httpMethod: http.MethodPost,
 		},
 		{
-			name: "FailToCancelLoanForNonPendingLoan",
+			name: "FailToCancelLoanOfOtherUser",
 			input: CancelLoanRequest{
 				LoanId: 3,
 			},
@@ -432,20 +440,85 @@ func Test_loanService_CancelLoan(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
-				repo.EXPECT().CancelLoan(c, userId, data.LoanId).Return(int64(0), nil).Times(1)
+				other := loan.LoanDetails{
+					LoanId: sql.NullInt64{Int64: data.LoanId, Valid: true},
+					UserId: sql.NullInt64{Int64: userId + 1, Valid: true},
+					Status: sql.NullString{String: LOAN_PENDING, Valid: true},
+				}
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(other, nil).Times(1)
 			},
 			expectedOutput: CancelLoanResponse{
 				Status: false,
 				Errors: []e.Error{{
 					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
-					Description: e.ErrorInfo[e.BadRequest].Description + " | only loans created by user in PENDING status can be cancelled",
+					Description: e.ErrorInfo[e.BadRequest].Description + " | only loans created by user in PENDING status can be changed",
 					Code:        e.ErrorInfo[e.BadRequest].Code,
 				}},
-				Message: "failed to modify loan",
+				Message: "failed to cancel loan",
 			},
 			httpStatus: http.StatusBadRequest,
 			httpMethod: http.MethodPost,
 		},
+		{
+			name: "FailToCancelLoanForNonPendingLoan",
+			input: CancelLoanRequest{
+				LoanId: 3,
+			},
+			setup: func(c *gin.Context, data CancelLoanRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				approved := loan.LoanDetails{
+					LoanId: sql.NullInt64{Int64: data.LoanId, Valid: true},
+					UserId: sql.NullInt64{Int64: userId, Valid: true},
+					Status: sql.NullString{String: LOAN_APPROVED, Valid: true},
+				}
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(approved, nil).Times(1)
+			},
+			expectedOutput: CancelLoanResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.Conflict].ErrName,
+					Description: e.ErrorInfo[e.Conflict].Description + " | loan 3 in APPROVED status cannot be moved to CANCELLED by customer",
+					Code:        e.ErrorInfo[e.Conflict].Code,
+				}},
+				Message: "failed to cancel loan",
+			},
+			httpStatus: http.StatusConflict,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "CancelLostToApproval",
+			input: CancelLoanRequest{
+				LoanId: 3,
+			},
+			setup: func(c *gin.Context, data CancelLoanRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				pending := loan.LoanDetails{
+					LoanId: sql.NullInt64{Int64: data.LoanId, Valid: true},
+					UserId: sql.NullInt64{Int64: userId, Valid: true},
+					Status: sql.NullString{String: LOAN_PENDING, Valid: true},
+				}
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(pending, nil).Times(1)
+				repo.EXPECT().UpdateLoanStatus(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_CANCELLED, Actor: ACTOR_CUSTOMER, ActorId: userId}, gomock.Any()).Return(loan.ErrStatusChanged).Times(1)
+				approved := pending
+				approved.Status = sql.NullString{String: LOAN_APPROVED, Valid: true}
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(approved, nil).Times(1)
+			},
+			expectedOutput: CancelLoanResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.Conflict].ErrName,
+					Description: e.ErrorInfo[e.Conflict].Description + " | loan 3 in APPROVED status cannot be moved to CANCELLED by customer",
+					Code:        e.ErrorInfo[e.Conflict].Code,
+				}},
+				Message: "failed to cancel loan",
+			},
+			httpStatus: http.StatusConflict,
+			httpMethod: http.MethodPost,
+		},
 		{
 			name: "SuccessCancelLoan",
 			input: CancelLoanRequest{
@@ -455,7 +528,13 @@ func Test_loanService_CancelLoan(t *testing.T) {
 				ctrl := gomock.NewController(t)
 				repo := dbmock.NewMockV1DBLayer(ctrl)
 				dbObj = repo
-				repo.EXPECT().CancelLoan(c, userId, data.LoanId).Return(int64(3), nil).Times(1)
+				pending := loan.LoanDetails{
+					LoanId: sql.NullInt64{Int64: data.LoanId, Valid: true},
+					UserId: sql.NullInt64{Int64: userId, Valid: true},
+					Status: sql.NullString{String: LOAN_PENDING, Valid: true},
+				}
+				repo.EXPECT().FetchLoanDetails(c, data.LoanId).Return(pending, nil).Times(1)
+				repo.EXPECT().UpdateLoanStatus(c, data.LoanId, loan.StatusChange{From: LOAN_PENDING, To: LOAN_CANCELLED, Actor: ACTOR_CUSTOMER, ActorId: userId}, []outbox.Event{loanEvent(EVENT_LOAN_CANCELLED, data.LoanId, LoanCancelled{UserId: userId})}).Return(nil).Times(1)
 			},
 			expectedOutput: CancelLoanResponse{
 				Status: true,
@@ -625,6 +704,152 @@ func Test_loanService_GetLoans(t *testing.T) {
 	}
 }
 
+func Test_loanService_GetLoanTimeline(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	owned := loan.LoanDetails{
+		LoanId: sql.NullInt64{Int64: 3, Valid: true},
+		UserId: sql.NullInt64{Int64: userId, Valid: true},
+		Status: sql.NullString{String: LOAN_APPROVED, Valid: true},
+	}
+	t1, _ := time.Parse("2006-01-02 15:04:05", "2024-08-08 15:00:00")
+	history := []loan.StatusHistory{{
+		LoanId:    sql.NullInt64{Int64: 3, Valid: true},
+		ToStatus:  sql.NullString{String: LOAN_PENDING, Valid: true},
+		Actor:     sql.NullString{String: ACTOR_CUSTOMER, Valid: true},
+		ActorId:   sql.NullInt64{Int64: userId, Valid: true},
+		Reason:    sql.NullString{String: "applied for loan", Valid: true},
+		CreatedAt: sql.NullTime{Time: t1, Valid: true},
+	}, {
+		LoanId:     sql.NullInt64{Int64: 3, Valid: true},
+		FromStatus: sql.NullString{String: LOAN_PENDING, Valid: true},
+		ToStatus:   sql.NullString{String: LOAN_APPROVED, Valid: true},
+		Actor:      sql.NullString{String: ACTOR_ADMIN, Valid: true},
+		ActorId:    sql.NullInt64{Int64: 99, Valid: true},
+		CreatedAt:  sql.NullTime{Time: t1.Add(time.Hour), Valid: true},
+	}}
+
+	tests := []struct {
+		name           string
+		httpStatus     int
+		queries        map[string]string
+		permission     string
+		setup          func(*gin.Context)
+		expectedOutput GetLoanTimelineResponse
+		actualOutput   GetLoanTimelineResponse
+	}{
+		{
+			name:  "MissingInputLoanId",
+			setup: func(c *gin.Context) {},
+			expectedOutput: GetLoanTimelineResponse{
+				Status: false,
+				Errors: []e.Error{{
+					Code: e.ErrorInfo[e.BadRequest].Code,
+				}},
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "LoanOfOtherUser",
+			queries:    map[string]string{"loanId": "3"},
+			permission: auth.LOAN_READ_OWN,
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				other := owned
+				other.UserId = sql.NullInt64{Int64: userId + 1, Valid: true}
+				repo.EXPECT().FetchLoanDetails(c, int64(3)).Return(other, nil).Times(1)
+			},
+			expectedOutput: GetLoanTimelineResponse{
+				Status: false,
+				Errors: []e.Error{{
+					Code: e.ErrorInfo[e.NoDataFound].Code,
+				}},
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:       "ErrorFetchingHistory",
+			queries:    map[string]string{"loanId": "3"},
+			permission: auth.LOAN_READ_OWN,
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().FetchLoanDetails(c, int64(3)).Return(owned, nil).Times(1)
+				repo.EXPECT().GetLoanStatusHistory(c, int64(3)).Return(nil, fmt.Errorf("db error")).Times(1)
+			},
+			expectedOutput: GetLoanTimelineResponse{
+				Status: false,
+				Errors: []e.Error{{
+					Code: e.ErrorInfo[e.GetDBError].Code,
+				}},
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "AdminReadsAnyLoan",
+			queries:    map[string]string{"loanId": "3"},
+			permission: auth.LOAN_READ_ANY,
+			setup: func(c *gin.Context) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				other := owned
+				other.UserId = sql.NullInt64{Int64: userId + 1, Valid: true}
+				repo.EXPECT().FetchLoanDetails(c, int64(3)).Return(other, nil).Times(1)
+				repo.EXPECT().GetLoanStatusHistory(c, int64(3)).Return(history, nil).Times(1)
+			},
+			expectedOutput: GetLoanTimelineResponse{
+				Status: true,
+				Data: []LoanStatusEvent{
+					{Status: LOAN_PENDING, Actor: ACTOR_CUSTOMER, ActorId: userId, Reason: "applied for loan", CreatedAt: "2024-08-08 15:00:00"},
+					{FromStatus: LOAN_PENDING, Status: LOAN_APPROVED, Actor: ACTOR_ADMIN, ActorId: 99, CreatedAt: "2024-08-08 16:00:00"},
+				},
+				Message: "successfully fetched loan timeline",
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, ctx := getContext(http.MethodGet, nil, tt.queries, nil)
+			ctx.Set(config.USERID, userId)
+			ctx.Set(config.PERMISSION, tt.permission)
+
+			//setup test
+			tt.setup(ctx)
+			servObj := NewLoanService(dbObj)
+
+			//calling the function
+			servObj.GetLoanTimeline(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+			assert.Equal(t, tt.expectedOutput.Data, tt.actualOutput.Data)
+		})
+	}
+}
+
 func getContext(method string, data interface{}, queries map[string]string, params map[string]string) (w *httptest.ResponseRecorder, c *gin.Context) {
 	gin.SetMode(gin.TestMode)
 	recorder := httptest.NewRecorder()
@@ -53,8 +53,9 @@ type ModifyLoanResponse struct {
 }
 
 type CancelLoanRequest struct {
-	UserId int64 `json:"-"`
-	LoanId int64 `json:"loanId" binding:"required"`
+	UserId int64  `json:"-"`
+	LoanId int64  `json:"loanId" binding:"required"`
+	Reason string `json:"reason"`
 }
 
 type CancelLoanResponse struct {
@@ -90,6 +91,7 @@ type ApproveRejectLoanApplicationRequest struct {
 	UserId   int64  `json:"-"`
 	LoanId   int64  `json:"loanId" binding:"required"`
 	Approval string `json:"approval" binding:"required,oneof=APPROVE REJECT"`
+	Reason   string `json:"reason"`
 }
 
 type ApproveRejectLoanApplicationResponse struct {
@@ -117,11 +119,14 @@ type GetLoanDetail struct {
 	OutstandingAmount float64              `json:"outstandingAmount,omitempty"`
 	Tenure            int                  `json:"tenure,omitempty"`
 	Status            string               `json:"status"`
+	PaymentReference  string               `json:"paymentReference,omitempty"`
+	VirtualAccount    string               `json:"virtualAccount,omitempty"`
 	Installments      []InstallmentDetails `json:"installments,omitempty"`
 }
 
 type ProcessLoanPaymentRequest struct {
 	UserId        int64   `json:"-"`
+	CustomerId    int64   `json:"customerId"` //only for service accounts collecting on behalf of a customer
 	LoanId        int64   `json:"loanId" binding:"required"`
 	Amount        float64 `json:"amount" binding:"required"`
 	TransactionId string  `json:"transactionId" binding:"required"`
@@ -133,3 +138,19 @@ type ProcessLoanPaymentResponse struct {
 	Errors  []e.Error   `json:"errors,omitempty"`
 	Message string      `json:"message,omitempty"`
 }
+
+type GetLoanTimelineResponse struct {
+	Data    []LoanStatusEvent `json:"data,omitempty"`
+	Status  bool              `json:"success"`
+	Errors  []e.Error         `json:"errors,omitempty"`
+	Message string            `json:"message,omitempty"`
+}
+
+type LoanStatusEvent struct {
+	FromStatus string `json:"fromStatus,omitempty"`
+	Status     string `json:"status"`
+	Actor      string `json:"actor"`
+	ActorId    int64  `json:"actorId,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	CreatedAt  string `json:"createdAt"`
+}
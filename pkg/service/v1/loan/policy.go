@@ -0,0 +1,49 @@
+package loan
+
+import (
+	"aspire-assignment/pkg/config"
+	"log"
+	"strings"
+)
+
+var (
+	// requiredDocuments maps a loan product to the KYC document types that must be VERIFIED before approval. a
+	// product missing here has no policy and its loans are not approved
+	requiredDocuments map[string][]string
+	// maxInstallmentIncomeRatio caps the weekly installment as a share of the verified weekly income. 0 disables the check
+	maxInstallmentIncomeRatio float64
+)
+
+func InitLoanPolicy() {
+	requiredDocuments = make(map[string][]string)
+	for product, docTypes := range config.GetConfig().GetStringMapStringSlice("kyc.required_documents") {
+		requiredDocuments[strings.ToUpper(product)] = docTypes
+	}
+	maxInstallmentIncomeRatio = config.GetConfig().GetFloat64("loan.eligibility.max_installment_income_ratio")
+	log.Println("InitLoanPolicy successful")
+}
+
+// missingDocuments returns the required document types for the product absent from the verified list
+func missingDocuments(product string, verified []string) []string {
+	verifiedSet := make(map[string]bool)
+	for _, docType := range verified {
+		verifiedSet[docType] = true
+	}
+
+	missing := make([]string, 0)
+	for _, docType := range requiredDocuments[product] {
+		if !verifiedSet[docType] {
+			missing = append(missing, docType)
+		}
+	}
+	return missing
+}
+
+// isAffordable checks the weekly installment of a loan against the verified monthly income of the customer
+func isAffordable(amount float64, tenure int64, verifiedIncome float64) bool {
+	if maxInstallmentIncomeRatio <= 0 {
+		return true
+	}
+	weeklyIncome := verifiedIncome * 12 / 52
+	return amount/float64(tenure) <= weeklyIncome*maxInstallmentIncomeRatio
+}
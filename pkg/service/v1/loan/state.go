@@ -0,0 +1,55 @@
+package loan
+
+import (
+	"context"
+	"log"
+
+	"aspire-assignment/pkg/db/v1/loan"
+)
+
+// actors changing the status of a loan
+const (
+	ACTOR_CUSTOMER = "CUSTOMER"
+	ACTOR_ADMIN    = "ADMIN"
+	//repayment processing, which closes a repaid loan whoever made the last payment
+	ACTOR_SYSTEM = "SYSTEM"
+)
+
+// transitions is the loan state machine: the statuses a loan can move to from each status and the actor allowed
+// to make the move. loans are created PENDING, and REJECTED, CANCELLED and PAID are final
+var transitions = map[string]map[string]string{
+	LOAN_PENDING: {
+		LOAN_APPROVED:  ACTOR_ADMIN,
+		LOAN_REJECTED:  ACTOR_ADMIN,
+		LOAN_CANCELLED: ACTOR_CUSTOMER,
+	},
+	LOAN_APPROVED: {
+		LOAN_PAID: ACTOR_SYSTEM,
+	},
+}
+
+// application is the status change creating a loan: its customer applies and the loan starts PENDING
+func application(userId int64) loan.StatusChange {
+	return loan.StatusChange{To: LOAN_PENDING, Actor: ACTOR_CUSTOMER, ActorId: userId, Reason: "applied for loan"}
+}
+
+// transition checks a status change against the state machine and returns the write for the db, which applies
+// it only while the loan is still in from and keeps it in the status history. every status write of a loan goes
+// through here. actorId is 0 when the actor is not a user
+func transition(loanId int64, from string, to string, actor string, actorId int64, reason string) (loan.StatusChange, error) {
+	if allowed, ok := transitions[from][to]; !ok || allowed != actor {
+		log.Printf("illegal transition of LoanId: %d from %s to %s by %s", loanId, from, to, actor)
+		return loan.StatusChange{}, &TransitionError{LoanId: loanId, From: from, To: to, Actor: actor}
+	}
+	return loan.StatusChange{From: from, To: to, Actor: actor, ActorId: actorId, Reason: reason}, nil
+}
+
+// lostTransition reports a status write that lost to a concurrent change of the loan, from the status the loan
+// is in now
+func (obj *loans) lostTransition(ctx context.Context, loanId int64, change loan.StatusChange) error {
+	from := change.From
+	if detail, err := obj.dbObj.FetchLoanDetails(ctx, loanId); err == nil {
+		from = detail.Status.String
+	}
+	return &TransitionError{LoanId: loanId, From: from, To: change.To, Actor: change.Actor}
+}
@@ -0,0 +1,47 @@
+package loan
+
+import (
+	"errors"
+	"testing"
+
+	"aspire-assignment/pkg/db/v1/loan"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func Test_transition(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		actor   string
+		allowed bool
+	}{
+		{name: "AdminApprovesPending", from: LOAN_PENDING, to: LOAN_APPROVED, actor: ACTOR_ADMIN, allowed: true},
+		{name: "AdminRejectsPending", from: LOAN_PENDING, to: LOAN_REJECTED, actor: ACTOR_ADMIN, allowed: true},
+		{name: "CustomerCancelsPending", from: LOAN_PENDING, to: LOAN_CANCELLED, actor: ACTOR_CUSTOMER, allowed: true},
+		{name: "RepaymentClosesApproved", from: LOAN_APPROVED, to: LOAN_PAID, actor: ACTOR_SYSTEM, allowed: true},
+		{name: "CustomerApprovesPending", from: LOAN_PENDING, to: LOAN_APPROVED, actor: ACTOR_CUSTOMER},
+		{name: "AdminCancelsPending", from: LOAN_PENDING, to: LOAN_CANCELLED, actor: ACTOR_ADMIN},
+		{name: "AdminRejectsApproved", from: LOAN_APPROVED, to: LOAN_REJECTED, actor: ACTOR_ADMIN},
+		{name: "CustomerCancelsApproved", from: LOAN_APPROVED, to: LOAN_CANCELLED, actor: ACTOR_CUSTOMER},
+		{name: "RepaymentClosesPending", from: LOAN_PENDING, to: LOAN_PAID, actor: ACTOR_SYSTEM},
+		{name: "AdminApprovesCancelled", from: LOAN_CANCELLED, to: LOAN_APPROVED, actor: ACTOR_ADMIN},
+		{name: "AdminApprovesRejected", from: LOAN_REJECTED, to: LOAN_APPROVED, actor: ACTOR_ADMIN},
+		{name: "AdminApprovesPaid", from: LOAN_PAID, to: LOAN_APPROVED, actor: ACTOR_ADMIN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change, err := transition(3, tt.from, tt.to, tt.actor, 7, "checked")
+			if tt.allowed {
+				assert.Equal(t, nil, err)
+				assert.Equal(t, loan.StatusChange{From: tt.from, To: tt.to, Actor: tt.actor, ActorId: 7, Reason: "checked"}, change)
+				return
+			}
+			var transitionErr *TransitionError
+			assert.Equal(t, true, errors.As(err, &transitionErr))
+			assert.Equal(t, TransitionError{LoanId: 3, From: tt.from, To: tt.to, Actor: tt.actor}, *transitionErr)
+			assert.Equal(t, loan.StatusChange{}, change)
+		})
+	}
+}
@@ -0,0 +1,6 @@
+package notification
+
+const (
+	//notifications per page of the inbox when no limit is asked for
+	DEFAULT_PAGE_SIZE = 50
+)
@@ -0,0 +1,26 @@
+package notification
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+// notificationService lets customers read their in-app notifications and choose the channels they are notified
+// on. the notifications themselves are rendered and sent by the notification package
+type notificationService struct {
+	dbObj v1.V1DBLayer
+}
+
+type NotificationInterface interface {
+	GetNotifications(*gin.Context)
+	MarkNotificationsRead(*gin.Context)
+	GetNotificationPreferences(*gin.Context)
+	UpdateNotificationPreferences(*gin.Context)
+}
+
+func NewNotificationService(db v1.V1DBLayer) NotificationInterface {
+	return &notificationService{
+		dbObj: db,
+	}
+}
@@ -0,0 +1,63 @@
+package notification
+
+import (
+	e "aspire-assignment/pkg/errors"
+)
+
+// GetNotificationsRequest pages back through the inbox. beforeId continues from the last notification of the
+// previous page
+type GetNotificationsRequest struct {
+	Unread   bool  `form:"unread"`
+	BeforeId int64 `form:"beforeId" binding:"min=0"`
+	Limit    int   `form:"limit" binding:"min=0,max=200"`
+}
+
+type NotificationsResponse struct {
+	Data    []Notification `json:"data,omitempty"`
+	Status  bool           `json:"success"`
+	Errors  []e.Error      `json:"errors,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+// Notification is an in-app notification. ReadAt is empty while it is unread
+type Notification struct {
+	NotificationId int64  `json:"notificationId"`
+	EventType      string `json:"eventType"`
+	Subject        string `json:"subject"`
+	Body           string `json:"body"`
+	CreatedAt      string `json:"createdAt"`
+	ReadAt         string `json:"readAt,omitempty"`
+}
+
+// MarkReadRequest marks the given notifications read, or the whole inbox when there are none
+type MarkReadRequest struct {
+	NotificationIds []int64 `json:"notificationIds" binding:"max=200,dive,min=1"`
+}
+
+type MarkReadResponse struct {
+	Data    *MarkRead `json:"data,omitempty"`
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type MarkRead struct {
+	Marked int64 `json:"marked"`
+}
+
+type UpdatePreferencesRequest struct {
+	Preferences []Preference `json:"preferences" binding:"required,min=1,dive"`
+}
+
+type PreferencesResponse struct {
+	Data    []Preference `json:"data,omitempty"`
+	Status  bool         `json:"success"`
+	Errors  []e.Error    `json:"errors,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+// Preference turns a channel on or off
+type Preference struct {
+	Channel string `json:"channel" binding:"required,oneof=EMAIL SMS IN_APP"`
+	Enabled *bool  `json:"enabled" binding:"required"`
+}
@@ -0,0 +1,97 @@
+package notification
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/config"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotifications lists the in-app notifications of the customer, latest first, one page at a time
+func (obj *notificationService) GetNotifications(c *gin.Context) {
+	var (
+		request  GetNotificationsRequest
+		response NotificationsResponse
+	)
+	if err := c.BindQuery(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to fetch notifications"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	filter := dbnotification.NotificationFilter{
+		UserId:   c.GetInt64(config.USERID),
+		Channel:  dbnotification.IN_APP,
+		Unread:   request.Unread,
+		BeforeId: request.BeforeId,
+		Limit:    request.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DEFAULT_PAGE_SIZE
+	}
+
+	notifications, err := obj.dbObj.GetNotifications(c, filter)
+	if err != nil {
+		log.Printf("failed to fetch notifications. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch notifications"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = make([]Notification, 0)
+	for _, notification := range notifications {
+		entry := Notification{
+			NotificationId: notification.NotificationId.Int64,
+			EventType:      notification.EventType.String,
+			Subject:        notification.Subject.String,
+			Body:           notification.Body.String,
+			CreatedAt:      notification.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+		}
+		if notification.ReadAt.Valid {
+			entry.ReadAt = notification.ReadAt.Time.Format("2006-01-02 15:04:05")
+		}
+		response.Data = append(response.Data, entry)
+	}
+	response.Message = "successfully fetched notifications"
+	c.JSON(http.StatusOK, response)
+}
+
+// MarkNotificationsRead marks in-app notifications of the customer read. ids of other customers or of
+// notifications read already are ignored
+func (obj *notificationService) MarkNotificationsRead(c *gin.Context) {
+	var (
+		request  MarkReadRequest
+		response MarkReadResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to mark notifications read"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	userId := c.GetInt64(config.USERID)
+
+	marked, err := obj.dbObj.MarkNotificationsRead(c, userId, request.NotificationIds, time.Now())
+	if err != nil {
+		log.Printf("failed to mark notifications read. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to mark notifications read"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = &MarkRead{Marked: marked}
+	response.Message = "successfully marked notifications read"
+	c.JSON(http.StatusOK, response)
+}
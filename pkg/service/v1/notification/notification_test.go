@@ -0,0 +1,221 @@
+package notification
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	e "aspire-assignment/pkg/errors"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_notificationService_GetNotifications(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	now := time.Now()
+	notifications := []dbnotification.Notification{
+		{
+			NotificationId: sql.NullInt64{Int64: 9, Valid: true},
+			UserId:         sql.NullInt64{Int64: userId, Valid: true},
+			EventType:      sql.NullString{String: "installment.due", Valid: true},
+			Channel:        sql.NullString{String: dbnotification.IN_APP, Valid: true},
+			Subject:        sql.NullString{String: "Installment 2 of loan 7 is due on 19 Oct 2026", Valid: true},
+			Body:           sql.NullString{String: "Hello john", Valid: true},
+			Status:         sql.NullString{String: dbnotification.SENT, Valid: true},
+			CreatedAt:      sql.NullTime{Time: now, Valid: true},
+		},
+	}
+	tests := []struct {
+		name          string
+		query         map[string]string
+		setup         func(*gin.Context)
+		httpStatus    int
+		notifications int
+	}{
+		{
+			name:  "LimitTooLarge",
+			query: map[string]string{"limit": "1000"},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DBError",
+			query: map[string]string{},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetNotifications(c, gomock.Any()).Return(nil, fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "Unread",
+			query: map[string]string{"unread": "true", "beforeId": "10"},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				filter := dbnotification.NotificationFilter{UserId: userId, Channel: dbnotification.IN_APP, Unread: true, BeforeId: 10, Limit: DEFAULT_PAGE_SIZE}
+				repo.EXPECT().GetNotifications(c, filter).Return(notifications, nil).Times(1)
+			},
+			httpStatus:    http.StatusOK,
+			notifications: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fmt.Println("Starting Get Notifications TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodGet, nil, tt.query)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx)
+			NewNotificationService(dbObj).GetNotifications(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response NotificationsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			assert.Equal(t, tt.notifications, len(response.Data))
+			if tt.notifications != 0 {
+				assert.Equal(t, int64(9), response.Data[0].NotificationId)
+				assert.Equal(t, "Hello john", response.Data[0].Body)
+				assert.Equal(t, "", response.Data[0].ReadAt)
+			}
+		})
+	}
+}
+
+func Test_notificationService_MarkNotificationsRead(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		input      interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+		marked     int64
+	}{
+		{
+			name:  "InvalidId",
+			input: MarkReadRequest{NotificationIds: []int64{0}},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DBError",
+			input: MarkReadRequest{NotificationIds: []int64{9}},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().MarkNotificationsRead(c, userId, []int64{9}, gomock.Any()).Return(int64(0), fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "Some",
+			input: MarkReadRequest{NotificationIds: []int64{9, 10}},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().MarkNotificationsRead(c, userId, []int64{9, 10}, gomock.Any()).Return(int64(1), nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			marked:     1,
+		},
+		{
+			name:  "All",
+			input: map[string]interface{}{},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().MarkNotificationsRead(c, userId, gomock.Nil(), gomock.Any()).Return(int64(4), nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			marked:     4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fmt.Println("Starting Mark Notifications Read TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodPost, tt.input, nil)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx)
+			NewNotificationService(dbObj).MarkNotificationsRead(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response MarkReadResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			if tt.httpStatus == http.StatusOK {
+				assert.Equal(t, tt.marked, response.Data.Marked)
+			}
+		})
+	}
+}
+
+func getContext(method string, data interface{}, queries map[string]string) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+	byteData, err := json.Marshal(data)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	temp.Request, err = http.NewRequest(method, "/", bytes.NewBuffer(byteData))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//add headers
+	temp.Request.Header = http.Header{}
+	temp.Request.Header.Set("Content-Type", "application/json")
+
+	//add query params
+	if queries != nil {
+		q := temp.Request.URL.Query()
+		for k, v := range queries {
+			q.Add(k, v)
+		}
+		temp.Request.URL.RawQuery = q.Encode()
+	}
+
+	return recorder, temp
+}
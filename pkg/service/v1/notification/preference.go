@@ -0,0 +1,100 @@
+package notification
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/config"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/notification"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetNotificationPreferences returns every channel with whether the customer is notified on it, by their
+// preference or by default
+func (obj *notificationService) GetNotificationPreferences(c *gin.Context) {
+	var response PreferencesResponse
+	userId := c.GetInt64(config.USERID)
+
+	preferences, err := obj.dbObj.GetNotificationPreferences(c, userId)
+	if err != nil {
+		log.Printf("failed to fetch notification preferences. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch notification preferences"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = preferenceDetails(preferences)
+	response.Message = "successfully fetched notification preferences"
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateNotificationPreferences turns the given channels on or off for the customer. the channels left out keep
+// their preference
+func (obj *notificationService) UpdateNotificationPreferences(c *gin.Context) {
+	var (
+		request  UpdatePreferencesRequest
+		response PreferencesResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to update notification preferences"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	userId := c.GetInt64(config.USERID)
+
+	now := time.Now()
+	preferences := make([]dbnotification.NotificationPreference, 0)
+	for _, preference := range request.Preferences {
+		preferences = append(preferences, dbnotification.NotificationPreference{
+			UserId:    sql.NullInt64{Int64: userId, Valid: true},
+			Channel:   sql.NullString{String: preference.Channel, Valid: true},
+			Enabled:   sql.NullBool{Bool: *preference.Enabled, Valid: true},
+			UpdatedAt: sql.NullTime{Time: now, Valid: true},
+		})
+	}
+	if err := obj.dbObj.SetNotificationPreferences(c, preferences); err != nil {
+		log.Printf("failed to set notification preferences. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to update notification preferences"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	saved, err := obj.dbObj.GetNotificationPreferences(c, userId)
+	if err != nil {
+		log.Printf("failed to fetch notification preferences. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to update notification preferences"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	log.Printf("notification preferences updated for UserId: %d", userId)
+	response.Status = true
+	response.Data = preferenceDetails(saved)
+	response.Message = "successfully updated notification preferences"
+	c.JSON(http.StatusOK, response)
+}
+
+func preferenceDetails(preferences []dbnotification.NotificationPreference) []Preference {
+	enabled := notification.EnabledChannels(preferences)
+	details := make([]Preference, 0)
+	for _, channel := range notification.Channels {
+		on := false
+		for _, item := range enabled {
+			if item == channel {
+				on = true
+			}
+		}
+		details = append(details, Preference{Channel: channel, Enabled: &on})
+	}
+	return details
+}
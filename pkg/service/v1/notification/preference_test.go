@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	dbnotification "aspire-assignment/pkg/db/v1/notification"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_notificationService_GetNotificationPreferences(t *testing.T) {
+	var userId int64 = 1
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	//the channels without a preference are the defaults, email and in-app
+	repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+	w, ctx := getContext(http.MethodGet, nil, nil)
+	ctx.Set(config.USERID, userId)
+	repo.EXPECT().GetNotificationPreferences(ctx, userId).Return([]dbnotification.NotificationPreference{
+		{
+			UserId:  sql.NullInt64{Int64: userId, Valid: true},
+			Channel: sql.NullString{String: dbnotification.EMAIL, Valid: true},
+			Enabled: sql.NullBool{Bool: false, Valid: true},
+		},
+	}, nil).Times(1)
+	NewNotificationService(repo).GetNotificationPreferences(ctx)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response PreferencesResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Error("unable to unmarshal response")
+	}
+	assert.Equal(t, 3, len(response.Data))
+	enabled := make(map[string]bool)
+	for _, preference := range response.Data {
+		enabled[preference.Channel] = *preference.Enabled
+	}
+	assert.Equal(t, map[string]bool{"EMAIL": false, "SMS": false, "IN_APP": true}, enabled)
+}
+
+func Test_notificationService_UpdateNotificationPreferences(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		input      interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+	}{
+		{
+			name:  "NoPreferences",
+			input: map[string]interface{}{"preferences": []interface{}{}},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "UnknownChannel",
+			input: map[string]interface{}{"preferences": []interface{}{map[string]interface{}{"channel": "PIGEON", "enabled": true}}},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "MissingEnabled",
+			input: map[string]interface{}{"preferences": []interface{}{map[string]interface{}{"channel": "SMS"}}},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DBError",
+			input: map[string]interface{}{"preferences": []interface{}{map[string]interface{}{"channel": "SMS", "enabled": true}}},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().SetNotificationPreferences(c, gomock.Any()).Return(fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "Success",
+			input: map[string]interface{}{"preferences": []interface{}{map[string]interface{}{"channel": "EMAIL", "enabled": false}}},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().SetNotificationPreferences(c, gomock.Any()).DoAndReturn(func(c *gin.Context, preferences []dbnotification.NotificationPreference) error {
+					assert.Equal(t, 1, len(preferences))
+					assert.Equal(t, userId, preferences[0].UserId.Int64)
+					assert.Equal(t, dbnotification.EMAIL, preferences[0].Channel.String)
+					assert.Equal(t, false, preferences[0].Enabled.Bool)
+					return nil
+				}).Times(1)
+				repo.EXPECT().GetNotificationPreferences(c, userId).Return(nil, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fmt.Println("Starting Update Notification Preferences TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodPut, tt.input, nil)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx)
+			NewNotificationService(dbObj).UpdateNotificationPreferences(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response PreferencesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+		})
+	}
+}
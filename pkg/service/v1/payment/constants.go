@@ -0,0 +1,8 @@
+package payment
+
+const (
+	//largest notification body read from the provider
+	MAX_BODY_BYTES = 64 << 10
+	//events per page of the payment events when no limit is asked for
+	DEFAULT_PAGE_SIZE = 100
+)
@@ -0,0 +1,72 @@
+package payment
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	dbpayment "aspire-assignment/pkg/db/v1/payment"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPaymentEvents lists the notifications of the payment provider matching the filters, oldest first. the
+// rejected ones are payments received for no loan or refused by the loan rules, to be sorted out by hand
+func (obj *paymentService) GetPaymentEvents(c *gin.Context) {
+	var (
+		request  GetPaymentEventsRequest
+		response PaymentEventsResponse
+	)
+	if err := c.BindQuery(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to fetch payment events"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	filter := dbpayment.PaymentEventFilter{
+		Status:  request.Status,
+		LoanId:  request.LoanId,
+		AfterId: request.AfterId,
+		Limit:   request.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DEFAULT_PAGE_SIZE
+	}
+
+	events, err := obj.dbObj.GetPaymentEvents(c, filter)
+	if err != nil {
+		log.Printf("failed to fetch payment events. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch payment events"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = make([]PaymentEvent, 0)
+	for _, event := range events {
+		entry := PaymentEvent{
+			EventId:         event.EventId.Int64,
+			Provider:        event.Provider.String,
+			ProviderEventId: event.ProviderEventId.String,
+			EventType:       event.EventType.String,
+			PaymentId:       event.PaymentId.String,
+			Reference:       event.Reference.String,
+			Amount:          event.Amount.Float64,
+			LoanId:          event.LoanId.Int64,
+			Status:          event.Status.String,
+			Error:           event.Error.String,
+			ReceivedAt:      event.ReceivedAt.Time.Format("2006-01-02 15:04:05"),
+			Payload:         json.RawMessage(event.Payload.String),
+		}
+		if event.ProcessedAt.Valid {
+			entry.ProcessedAt = event.ProcessedAt.Time.Format("2006-01-02 15:04:05")
+		}
+		response.Data = append(response.Data, entry)
+	}
+	response.Message = "successfully fetched payment events"
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,112 @@
+package payment
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	dbpayment "aspire-assignment/pkg/db/v1/payment"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_paymentService_GetPaymentEvents(t *testing.T) {
+	var dbObj v1.V1DBLayer
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	now := time.Now()
+	events := []dbpayment.PaymentEvent{
+		{
+			EventId:         sql.NullInt64{Int64: 5, Valid: true},
+			Provider:        sql.NullString{String: "fakepay", Valid: true},
+			ProviderEventId: sql.NullString{String: "evt_1", Valid: true},
+			EventType:       sql.NullString{String: "payment.succeeded", Valid: true},
+			PaymentId:       sql.NullString{String: "pay_1", Valid: true},
+			Reference:       sql.NullString{String: "invoice 42", Valid: true},
+			Amount:          sql.NullFloat64{Float64: 1000, Valid: true},
+			Status:          sql.NullString{String: dbpayment.REJECTED, Valid: true},
+			Error:           sql.NullString{String: "payment does not name a loan", Valid: true},
+			Payload:         sql.NullString{String: `{"id":"evt_1"}`, Valid: true},
+			ReceivedAt:      sql.NullTime{Time: now, Valid: true},
+			ProcessedAt:     sql.NullTime{Time: now, Valid: true},
+		},
+	}
+	tests := []struct {
+		name       string
+		query      map[string]string
+		setup      func(*gin.Context)
+		httpStatus int
+		events     int
+	}{
+		{
+			name:  "UnknownStatus",
+			query: map[string]string{"status": "FAILED"},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DBError",
+			query: map[string]string{},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetPaymentEvents(c, gomock.Any()).Return(nil, fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "Rejected",
+			query: map[string]string{"status": "REJECTED", "afterId": "4"},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetPaymentEvents(c, dbpayment.PaymentEventFilter{Status: dbpayment.REJECTED, AfterId: 4, Limit: DEFAULT_PAGE_SIZE}).Return(events, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			events:     1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Get Payment Events TestCase: ", tt.name)
+			w, ctx := getContext("", nil)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			q := ctx.Request.URL.Query()
+			for k, v := range tt.query {
+				q.Add(k, v)
+			}
+			ctx.Request.URL.RawQuery = q.Encode()
+
+			//setup test
+			tt.setup(ctx)
+			NewPaymentService(dbObj).GetPaymentEvents(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response PaymentEventsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			assert.Equal(t, tt.events, len(response.Data))
+			if tt.events != 0 {
+				assert.Equal(t, `{"id":"evt_1"}`, string(response.Data[0].Payload))
+				assert.Equal(t, "payment does not name a loan", response.Data[0].Error)
+			}
+		})
+	}
+}
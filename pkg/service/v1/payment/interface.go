@@ -0,0 +1,25 @@
+package payment
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/service/v1/loan"
+
+	"github.com/gin-gonic/gin"
+)
+
+type paymentService struct {
+	dbObj v1.V1DBLayer
+	loans loan.Loans
+}
+
+type PaymentInterface interface {
+	ReceivePayment(*gin.Context)
+	GetPaymentEvents(*gin.Context)
+}
+
+func NewPaymentService(db v1.V1DBLayer) PaymentInterface {
+	return &paymentService{
+		dbObj: db,
+		loans: loan.NewLoans(db),
+	}
+}
@@ -0,0 +1,54 @@
+package payment
+
+import (
+	e "aspire-assignment/pkg/errors"
+	"encoding/json"
+)
+
+// ReceivePaymentResponse answers the provider. a notification with an outcome, applied or not, is answered with
+// 200 so the provider stops sending it, and Data tells what became of it
+type ReceivePaymentResponse struct {
+	Data    *PaymentOutcome `json:"data,omitempty"`
+	Status  bool            `json:"success"`
+	Errors  []e.Error       `json:"errors,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+type PaymentOutcome struct {
+	EventId int64  `json:"eventId"`
+	Status  string `json:"status"`
+	LoanId  int64  `json:"loanId,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// GetPaymentEventsRequest filters the payment events. afterId continues from the last event of the previous page
+type GetPaymentEventsRequest struct {
+	Status  string `form:"status" binding:"omitempty,oneof=RECEIVED APPLIED REJECTED IGNORED"`
+	LoanId  int64  `form:"loanId" binding:"min=0"`
+	AfterId int64  `form:"afterId" binding:"min=0"`
+	Limit   int    `form:"limit" binding:"min=0,max=500"`
+}
+
+type PaymentEventsResponse struct {
+	Data    []PaymentEvent `json:"data,omitempty"`
+	Status  bool           `json:"success"`
+	Errors  []e.Error      `json:"errors,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+// PaymentEvent is a notification of the provider as admins see it, with the payload as it was received
+type PaymentEvent struct {
+	EventId         int64           `json:"eventId"`
+	Provider        string          `json:"provider"`
+	ProviderEventId string          `json:"providerEventId"`
+	EventType       string          `json:"eventType"`
+	PaymentId       string          `json:"paymentId,omitempty"`
+	Reference       string          `json:"reference,omitempty"`
+	Amount          float64         `json:"amount,omitempty"`
+	LoanId          int64           `json:"loanId,omitempty"`
+	Status          string          `json:"status"`
+	Error           string          `json:"error,omitempty"`
+	ReceivedAt      string          `json:"receivedAt"`
+	ProcessedAt     string          `json:"processedAt,omitempty"`
+	Payload         json.RawMessage `json:"payload"`
+}
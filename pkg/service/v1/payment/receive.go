@@ -0,0 +1,226 @@
+package payment
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	dbpayment "aspire-assignment/pkg/db/v1/payment"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/payment"
+	"aspire-assignment/pkg/service/v1/loan"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReceivePayment applies a payment notified by the payment provider to the loan it names, through the same
+// repayment rules as a payment made in the app. the provider retries a notification until it is answered with
+// 200, so every notification is recorded once by its event id and a retry gets the recorded outcome
+func (obj *paymentService) ReceivePayment(c *gin.Context) {
+	var response ReceivePaymentResponse
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, MAX_BODY_BYTES))
+	if err != nil {
+		log.Printf("unable to read payment notification. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to receive payment"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	now := time.Now()
+	event, err := payment.ParseEvent(c.GetHeader(payment.SIGNATURE_HEADER), body, now)
+	if errors.Is(err, payment.ErrWebhookDisabled) || errors.Is(err, payment.ErrInvalidSignature) || errors.Is(err, payment.ErrStaleSignature) {
+		log.Printf("payment notification refused. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.UnAuthorized])
+		response.Message = "failed to receive payment"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	if err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to receive payment"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	audit.Identify(c, audit.ACTOR_PAYMENT_PROVIDER, 0, payment.Provider())
+
+	record := dbpayment.PaymentEvent{
+		Provider:        sql.NullString{String: payment.Provider(), Valid: true},
+		ProviderEventId: sql.NullString{String: event.Id, Valid: true},
+		EventType:       sql.NullString{String: event.Type, Valid: true},
+		PaymentId:       sql.NullString{String: event.Data.PaymentId, Valid: event.Data.PaymentId != ""},
+		Reference:       sql.NullString{String: event.Data.Reference, Valid: event.Data.Reference != ""},
+		Amount:          sql.NullFloat64{Float64: event.Data.Amount, Valid: event.Type == payment.EVENT_PAYMENT_SUCCEEDED},
+		Payload:         sql.NullString{String: string(body), Valid: true},
+		ReceivedAt:      sql.NullTime{Time: now, Valid: true},
+		LeaseUntil:      sql.NullTime{Time: now.Add(payment.Lease()), Valid: true},
+	}
+	//the virtual account is what names the loan when the payment has one
+	if event.Data.VirtualAccount != "" {
+		record.Reference = sql.NullString{String: event.Data.VirtualAccount, Valid: true}
+	}
+	record.EventId.Int64, err = obj.dbObj.AddPaymentEvent(c, record)
+	if err != nil {
+		log.Printf("failed to record payment event. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to receive payment"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if record.EventId.Int64 == 0 {
+		obj.receivedBefore(c, event, now)
+		return
+	}
+	obj.process(c, record.EventId.Int64, event, false)
+}
+
+// process applies a recorded event and records its outcome. an event taken over from a notification which did not
+// finish may have been applied by it already
+func (obj *paymentService) process(c *gin.Context, eventId int64, event payment.Event, takenOver bool) {
+	var response ReceivePaymentResponse
+
+	outcome, err := obj.apply(c, eventId, event, takenOver)
+	if err != nil {
+		//forget the event so the retry of the provider applies it
+		if err := obj.dbObj.DeletePaymentEvent(c, eventId); err != nil {
+			log.Printf("failed to delete payment event %d. Error: %s", eventId, err.Error())
+		}
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to receive payment"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	record := dbpayment.PaymentEvent{
+		EventId:     sql.NullInt64{Int64: eventId, Valid: true},
+		Status:      sql.NullString{String: outcome.Status, Valid: true},
+		LoanId:      sql.NullInt64{Int64: outcome.LoanId, Valid: outcome.LoanId != 0},
+		Error:       sql.NullString{String: outcome.Error, Valid: outcome.Error != ""},
+		ProcessedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	if err := obj.dbObj.CompletePaymentEvent(c, record); err != nil {
+		//the event stays RECEIVED, and the retry of the provider takes it over once the lease passed
+		log.Printf("failed to complete payment event %d with %s. Error: %s", eventId, outcome.Status, err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to receive payment"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	log.Printf("payment event %s of %s %s. LoanId: %d", event.Id, payment.Provider(), outcome.Status, outcome.LoanId)
+	response.Status = true
+	response.Data = &outcome
+	response.Message = "successfully received payment"
+	c.JSON(http.StatusOK, response)
+}
+
+// apply settles the loan named by the payment. a payment which cannot be applied, because it names no loan or
+// the loan rules refuse it, is rejected with the reason, and only a failure of the store is returned. the
+// transaction id of a taken over event already paying an installment was applied by the notification before
+func (obj *paymentService) apply(c *gin.Context, eventId int64, event payment.Event, takenOver bool) (PaymentOutcome, error) {
+	outcome := PaymentOutcome{EventId: eventId, Status: dbpayment.IGNORED}
+	if event.Type != payment.EVENT_PAYMENT_SUCCEEDED {
+		return outcome, nil
+	}
+	outcome.Status = dbpayment.REJECTED
+
+	loanId, ok := event.Data.LoanId()
+	if !ok {
+		outcome.Error = "payment does not name a loan"
+		return outcome, nil
+	}
+	audit.Target(c, audit.TARGET_LOAN, loanId)
+	if event.Data.Amount <= 0 {
+		outcome.Error = "payment amount must be positive"
+		return outcome, nil
+	}
+
+	//the payment id of the provider is the transaction id of the installment
+	txnId := event.Data.PaymentId
+	if txnId == "" {
+		txnId = event.Id
+	}
+	repayment, err := obj.loans.Settle(c, loanId, event.Data.Amount, txnId)
+	var storeErr *loan.StoreError
+	if errors.As(err, &storeErr) {
+		return outcome, err
+	}
+	if errors.Is(err, loan.ErrLoanNotFound) {
+		outcome.Error = err.Error()
+		return outcome, nil
+	}
+	outcome.LoanId = loanId
+	if takenOver && errors.Is(err, loan.ErrDuplicateTransaction) {
+		outcome.Status = dbpayment.APPLIED
+		return outcome, nil
+	}
+	if err != nil {
+		outcome.Error = err.Error()
+		return outcome, nil
+	}
+
+	audit.Change(c, map[string]interface{}{"outstandingAmount": repayment.OutstandingAmount + repayment.Amount}, map[string]interface{}{
+		"outstandingAmount": repayment.OutstandingAmount,
+		"amount":            repayment.Amount,
+		"transactionId":     repayment.TransactionId,
+		"installmentNumber": repayment.InstallmentNumber,
+		"loanClosed":        repayment.LoanClosed,
+	})
+	outcome.Status = dbpayment.APPLIED
+	return outcome, nil
+}
+
+// receivedBefore answers a notification the provider sent again with the outcome recorded the first time. one still
+// being applied is answered with 409 so the provider asks again later, and one left RECEIVED past its lease is
+// taken over and applied
+func (obj *paymentService) receivedBefore(c *gin.Context, notification payment.Event, now time.Time) {
+	var response ReceivePaymentResponse
+
+	event, err := obj.dbObj.GetPaymentEvent(c, payment.Provider(), notification.Id)
+	if err != nil {
+		log.Printf("failed to fetch payment event. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to receive payment"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if event.Status.String == dbpayment.RECEIVED {
+		claimed, err := obj.dbObj.ClaimPaymentEvent(c, event.EventId.Int64, now, now.Add(payment.Lease()))
+		if err != nil {
+			log.Printf("failed to claim payment event %d. Error: %s", event.EventId.Int64, err.Error())
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+			response.Message = "failed to receive payment"
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+		if claimed {
+			log.Printf("payment event %s of %s taken over after its lease", notification.Id, payment.Provider())
+			obj.process(c, event.EventId.Int64, notification, true)
+			return
+		}
+	}
+	if event.LoanId.Valid {
+		audit.Target(c, audit.TARGET_LOAN, event.LoanId.Int64)
+	}
+	if event.Status.String == dbpayment.RECEIVED {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.Conflict].GetErrorDetails("payment event is being processed"))
+		response.Message = "failed to receive payment"
+		c.JSON(http.StatusConflict, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = &PaymentOutcome{
+		EventId: event.EventId.Int64,
+		Status:  event.Status.String,
+		LoanId:  event.LoanId.Int64,
+		Error:   event.Error.String,
+	}
+	response.Message = "payment event already received"
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,350 @@
+package payment
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/loan"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	dbpayment "aspire-assignment/pkg/db/v1/payment"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/payment"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+const testSecret = "paysec-test-secret"
+
+func Test_paymentService_ReceivePayment(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+		loanId int64 = 3
+	)
+
+	//init error and the gateway to be used in function
+	e.ErrorInit()
+	config.Load("local", "../../../../")
+	config.GetConfig().Set("payments.provider", "fakepay")
+	config.GetConfig().Set("payments.webhook.secret", testSecret)
+	payment.InitGateway()
+
+	paid := func(amount float64, reference string) payment.Event {
+		return payment.Event{
+			Id:      "evt_1",
+			Type:    payment.EVENT_PAYMENT_SUCCEEDED,
+			Created: time.Now().Unix(),
+			Data:    payment.Payment{PaymentId: "pay_1", Amount: amount, Reference: reference},
+		}
+	}
+	//schedule is returned fresh for every payment, as a repayment marks its installment paid
+	schedule := func() []loan.InstallmentDetails {
+		return []loan.InstallmentDetails{
+			{
+				LoanAmount:     sql.NullFloat64{Float64: 3000, Valid: true},
+				LoanStatus:     sql.NullString{String: "APPROVED", Valid: true},
+				InstallmentSeq: sql.NullInt64{Int64: 1, Valid: true},
+				AmountDue:      sql.NullFloat64{Float64: 1000, Valid: true},
+				AmountPaid:     sql.NullFloat64{Float64: 0, Valid: true},
+				Status:         sql.NullString{String: "PENDING", Valid: true},
+			},
+		}
+	}
+	owner := loan.LoanDetails{
+		LoanId: sql.NullInt64{Int64: loanId, Valid: true},
+		UserId: sql.NullInt64{Int64: userId, Valid: true},
+		Status: sql.NullString{String: "APPROVED", Valid: true},
+	}
+	completed := func(repo *dbmock.MockV1DBLayer, c *gin.Context, status string) {
+		repo.EXPECT().CompletePaymentEvent(c, gomock.Any()).DoAndReturn(func(_ interface{}, event dbpayment.PaymentEvent) error {
+			assert.Equal(t, int64(5), event.EventId.Int64)
+			assert.Equal(t, status, event.Status.String)
+			return nil
+		}).Times(1)
+	}
+
+	tests := []struct {
+		name       string
+		body       interface{}
+		secret     string
+		setup      func(*gin.Context)
+		httpStatus int
+		outcome    *PaymentOutcome
+	}{
+		{
+			name:   "InvalidSignature",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: "other-test-secret",
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "MalformedEvent",
+			body:   "not an event",
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:   "ErrorRecordingEvent",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(0), fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "SentAgainAfterApplied",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(0), nil).Times(1)
+				repo.EXPECT().GetPaymentEvent(c, "fakepay", "evt_1").Return(dbpayment.PaymentEvent{
+					EventId: sql.NullInt64{Int64: 5, Valid: true},
+					LoanId:  sql.NullInt64{Int64: loanId, Valid: true},
+					Status:  sql.NullString{String: dbpayment.APPLIED, Valid: true},
+				}, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			outcome:    &PaymentOutcome{EventId: 5, Status: dbpayment.APPLIED, LoanId: loanId},
+		},
+		{
+			name:   "SentAgainWhileApplying",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(0), nil).Times(1)
+				repo.EXPECT().GetPaymentEvent(c, "fakepay", "evt_1").Return(dbpayment.PaymentEvent{
+					EventId: sql.NullInt64{Int64: 5, Valid: true},
+					Status:  sql.NullString{String: dbpayment.RECEIVED, Valid: true},
+				}, nil).Times(1)
+				//the notification applying it holds the lease
+				repo.EXPECT().ClaimPaymentEvent(c, int64(5), gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+			},
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name:   "TakenOverAfterLease",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(0), nil).Times(1)
+				repo.EXPECT().GetPaymentEvent(c, "fakepay", "evt_1").Return(dbpayment.PaymentEvent{
+					EventId: sql.NullInt64{Int64: 5, Valid: true},
+					Status:  sql.NullString{String: dbpayment.RECEIVED, Valid: true},
+				}, nil).Times(1)
+				repo.EXPECT().ClaimPaymentEvent(c, int64(5), gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, _ int64, now time.Time, leaseUntil time.Time) (bool, error) {
+					assert.Equal(t, payment.Lease(), leaseUntil.Sub(now))
+					return true, nil
+				}).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateSingleInstallmentPayment(c, loanId, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				completed(repo, c, dbpayment.APPLIED)
+			},
+			httpStatus: http.StatusOK,
+			outcome:    &PaymentOutcome{EventId: 5, Status: dbpayment.APPLIED, LoanId: loanId},
+		},
+		{
+			name:   "TakenOverAfterApplied",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(0), nil).Times(1)
+				repo.EXPECT().GetPaymentEvent(c, "fakepay", "evt_1").Return(dbpayment.PaymentEvent{
+					EventId: sql.NullInt64{Int64: 5, Valid: true},
+					Status:  sql.NullString{String: dbpayment.RECEIVED, Valid: true},
+				}, nil).Times(1)
+				repo.EXPECT().ClaimPaymentEvent(c, int64(5), gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(), nil).Times(1)
+				//the notification which did not finish had repaid the installment with the payment
+				repo.EXPECT().UpdateSingleInstallmentPayment(c, loanId, gomock.Any(), gomock.Any(), gomock.Any()).Return(loan.ErrDuplicateTransaction).Times(1)
+				completed(repo, c, dbpayment.APPLIED)
+			},
+			httpStatus: http.StatusOK,
+			outcome:    &PaymentOutcome{EventId: 5, Status: dbpayment.APPLIED, LoanId: loanId},
+		},
+		{
+			name: "NotAPayment",
+			body: payment.Event{
+				Id:      "evt_1",
+				Type:    payment.EVENT_PAYMENT_FAILED,
+				Created: time.Now().Unix(),
+				Data:    payment.Payment{PaymentId: "pay_1", Amount: 1000, Reference: payment.Reference(loanId)},
+			},
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(5), nil).Times(1)
+				completed(repo, c, dbpayment.IGNORED)
+			},
+			httpStatus: http.StatusOK,
+			outcome:    &PaymentOutcome{EventId: 5, Status: dbpayment.IGNORED},
+		},
+		{
+			name:   "UnknownReference",
+			body:   paid(1000, "invoice 42"),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(5), nil).Times(1)
+				completed(repo, c, dbpayment.REJECTED)
+			},
+			httpStatus: http.StatusOK,
+			outcome:    &PaymentOutcome{EventId: 5, Status: dbpayment.REJECTED, Error: "payment does not name a loan"},
+		},
+		{
+			name:   "UnknownLoan",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(5), nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(loan.LoanDetails{}, sql.ErrNoRows).Times(1)
+				completed(repo, c, dbpayment.REJECTED)
+			},
+			httpStatus: http.StatusOK,
+			outcome:    &PaymentOutcome{EventId: 5, Status: dbpayment.REJECTED, Error: "loan not found"},
+		},
+		{
+			name:   "BelowInstallment",
+			body:   paid(500, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(5), nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(), nil).Times(1)
+				completed(repo, c, dbpayment.REJECTED)
+			},
+			httpStatus: http.StatusOK,
+			outcome:    &PaymentOutcome{EventId: 5, Status: dbpayment.REJECTED, LoanId: loanId, Error: "amount payable is less than installment amount"},
+		},
+		{
+			name:   "ErrorApplying",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(5), nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateSingleInstallmentPayment(c, loanId, gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("db down")).Times(1)
+				//the provider sends the event again and it is applied then
+				repo.EXPECT().DeletePaymentEvent(c, int64(5)).Return(nil).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "ErrorCompleting",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).Return(int64(5), nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateSingleInstallmentPayment(c, loanId, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+				//the provider sends the event again and takes it over once the lease passed
+				repo.EXPECT().CompletePaymentEvent(c, gomock.Any()).Return(fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:   "Applied",
+			body:   paid(1000, payment.Reference(loanId)),
+			secret: testSecret,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddPaymentEvent(c, gomock.Any()).DoAndReturn(func(_ interface{}, event dbpayment.PaymentEvent) (int64, error) {
+					assert.Equal(t, "fakepay", event.Provider.String)
+					assert.Equal(t, "evt_1", event.ProviderEventId.String)
+					assert.Equal(t, "pay_1", event.PaymentId.String)
+					assert.Equal(t, "AL000000034", event.Reference.String)
+					assert.Equal(t, float64(1000), event.Amount.Float64)
+					assert.Equal(t, payment.Lease(), event.LeaseUntil.Time.Sub(event.ReceivedAt.Time))
+					return 5, nil
+				}).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateSingleInstallmentPayment(c, loanId, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, _ int64, installment loan.InstallmentDetails, _ interface{}, _ interface{}) error {
+					assert.Equal(t, "pay_1", installment.TransactionId.String)
+					return nil
+				}).Times(1)
+				completed(repo, c, dbpayment.APPLIED)
+			},
+			httpStatus: http.StatusOK,
+			outcome:    &PaymentOutcome{EventId: 5, Status: dbpayment.APPLIED, LoanId: loanId},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Receive Payment TestCase: ", tt.name)
+			w, ctx := getContext(tt.secret, tt.body)
+
+			//setup test
+			tt.setup(ctx)
+			NewPaymentService(dbObj).ReceivePayment(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response ReceivePaymentResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			assert.Equal(t, tt.outcome, response.Data)
+		})
+	}
+}
+
+// getContext returns the notification of body signed with secret as the provider sends it
+func getContext(secret string, body interface{}) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+	var byteData []byte
+	if text, ok := body.(string); ok {
+		byteData = []byte(text)
+	} else {
+		byteData, _ = json.Marshal(body)
+	}
+	temp.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(byteData))
+	temp.Request.Header.Set("Content-Type", "application/json")
+	temp.Request.Header.Set(payment.SIGNATURE_HEADER, payment.Sign(secret, time.Now().Unix(), byteData))
+	return recorder, temp
+}
@@ -0,0 +1,15 @@
+package reconciliation
+
+// upload limits
+const (
+	MAX_STATEMENT_SIZE = 10 << 20 //10 MB
+)
+
+// resolutions of an exception
+const (
+	ACTION_APPLY   = "APPLY"
+	ACTION_DISMISS = "DISMISS"
+)
+
+// DEFAULT_PAGE_SIZE is the number of statement lines listed when the request does not ask for a limit
+const DEFAULT_PAGE_SIZE = 100
@@ -0,0 +1,30 @@
+package reconciliation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// domain errors returned by Reconciler. the errors of the loan rules applying a line are returned as they are
+var (
+	ErrInvalidStatement  = errors.New("invalid bank statement")
+	ErrStatementImported = errors.New("bank statement already imported")
+	ErrLineNotFound      = errors.New("statement line not found")
+	ErrLineNotException  = errors.New("statement line is not an exception")
+	ErrLoanRequired      = errors.New("a loan is required to apply the statement line")
+)
+
+// StoreError is a failed read or write of the database while reconciling a statement
+type StoreError struct {
+	Op    string
+	Write bool
+	Err   error
+}
+
+func (err *StoreError) Error() string {
+	return fmt.Sprintf("failed to %s. Error: %s", err.Op, err.Err.Error())
+}
+
+func (err *StoreError) Unwrap() error {
+	return err.Err
+}
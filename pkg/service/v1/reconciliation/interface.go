@@ -0,0 +1,59 @@
+package reconciliation
+
+import (
+	"errors"
+	"net/http"
+
+	v1 "aspire-assignment/pkg/db/v1"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/service/v1/loan"
+
+	"github.com/gin-gonic/gin"
+)
+
+// reconciliationService adapts the Reconciler to HTTP for the admins importing statements and working through
+// the exceptions
+type reconciliationService struct {
+	dbObj      v1.V1DBLayer
+	reconciler Reconciler
+}
+
+type ReconciliationInterface interface {
+	ImportStatement(*gin.Context)
+	GetBankStatements(*gin.Context)
+	GetStatementLines(*gin.Context)
+	ResolveStatementLine(*gin.Context)
+}
+
+func NewReconciliationService(db v1.V1DBLayer) ReconciliationInterface {
+	return &reconciliationService{
+		dbObj:      db,
+		reconciler: NewReconciler(db),
+	}
+}
+
+// errorResponse maps an error of the Reconciler, or of the loan rules applying a line, to the HTTP status and
+// error reported to the client
+func errorResponse(err error) (int, e.Error) {
+	var (
+		storeErr      *StoreError
+		loanStoreErr  *loan.StoreError
+		transitionErr *loan.TransitionError
+	)
+	switch {
+	case errors.As(err, &storeErr) && storeErr.Write, errors.As(err, &loanStoreErr):
+		return http.StatusInternalServerError, *e.ErrorInfo[e.AddDBError]
+	case errors.As(err, &storeErr):
+		return http.StatusInternalServerError, *e.ErrorInfo[e.GetDBError]
+	case errors.Is(err, ErrStatementImported), errors.Is(err, ErrLineNotException), errors.As(err, &transitionErr),
+		errors.Is(err, loan.ErrPaymentConflict), errors.Is(err, loan.ErrDuplicateTransaction),
+		errors.Is(err, loan.ErrLoanRepaid):
+		return http.StatusConflict, e.ErrorInfo[e.Conflict].GetErrorDetails(err.Error())
+	case errors.Is(err, ErrLineNotFound), errors.Is(err, loan.ErrLoanNotFound):
+		return http.StatusNotFound, e.ErrorInfo[e.NoDataFound].GetErrorDetails(err.Error())
+	case errors.Is(err, ErrInvalidStatement), errors.Is(err, ErrLoanRequired), errors.Is(err, loan.ErrNoInstallments),
+		errors.Is(err, loan.ErrAmountBelowInstallment), errors.Is(err, loan.ErrOverpayment):
+		return http.StatusBadRequest, e.ErrorInfo[e.BadRequest].GetErrorDetails(err.Error())
+	}
+	return http.StatusInternalServerError, *e.ErrorInfo[e.DefaultError]
+}
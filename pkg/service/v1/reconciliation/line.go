@@ -0,0 +1,105 @@
+package reconciliation
+
+import (
+	"log"
+	"net/http"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/reconciliation"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStatementLines lists the statement lines matching the filters, oldest first. without a status it is the
+// queue of exceptions waiting for an admin
+func (obj *reconciliationService) GetStatementLines(c *gin.Context) {
+	var (
+		request  GetStatementLinesRequest
+		response StatementLinesResponse
+	)
+	if err := c.BindQuery(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to fetch statement lines"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	filter := reconciliation.StatementLineFilter{
+		StatementId: request.StatementId,
+		Status:      request.Status,
+		AfterId:     request.AfterId,
+		Limit:       request.Limit,
+	}
+	if filter.Status == "" {
+		filter.Status = reconciliation.EXCEPTION
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DEFAULT_PAGE_SIZE
+	}
+
+	lines, err := obj.dbObj.GetStatementLines(c, filter)
+	if err != nil {
+		log.Printf("failed to fetch statement lines. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch statement lines"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = make([]StatementLine, 0)
+	for _, line := range lines {
+		response.Data = append(response.Data, statementLine(line))
+	}
+	response.Message = "successfully fetched statement lines"
+	c.JSON(http.StatusOK, response)
+}
+
+// ResolveStatementLine takes an exception off the queue, either applying it to a loan as a repayment or dismissing
+// it, like a credit which is not a loan payment
+func (obj *reconciliationService) ResolveStatementLine(c *gin.Context) {
+	var (
+		request  ResolveStatementLineRequest
+		response ResolveStatementLineResponse
+	)
+	if err := c.Bind(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to resolve statement line"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	audit.Target(c, audit.TARGET_STATEMENT_LINE, request.LineId)
+
+	line, err := obj.reconciler.Resolve(c, Resolution{
+		LineId:  request.LineId,
+		Action:  request.Action,
+		LoanId:  request.LoanId,
+		Note:    request.Note,
+		AdminId: c.GetInt64(config.USERID),
+	})
+	if err != nil {
+		log.Printf("failed to resolve statement line %d. Error: %s", request.LineId, err.Error())
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
+		response.Message = "failed to resolve statement line"
+		c.JSON(status, response)
+		return
+	}
+	audit.Change(c, map[string]interface{}{"status": reconciliation.EXCEPTION}, map[string]interface{}{
+		"status": line.Status.String,
+		"loanId": line.LoanId.Int64,
+		"amount": line.Amount.Float64,
+		"note":   line.Note.String,
+	})
+
+	log.Printf("statement line %d %s by UserId: %d", request.LineId, line.Status.String, c.GetInt64(config.USERID))
+	response.Status = true
+	data := statementLine(line)
+	response.Data = &data
+	response.Message = "successfully resolved statement line"
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,328 @@
+package reconciliation
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/loan"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/reconciliation"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_reconciliationService_GetStatementLines(t *testing.T) {
+	var dbObj v1.V1DBLayer
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	lines := []reconciliation.StatementLine{
+		{
+			LineId:      sql.NullInt64{Int64: 2, Valid: true},
+			StatementId: sql.NullInt64{Int64: 7, Valid: true},
+			LineNum:     sql.NullInt64{Int64: 3, Valid: true},
+			Amount:      sql.NullFloat64{Float64: 250, Valid: true},
+			Reference:   sql.NullString{String: "rent", Valid: true},
+			Status:      sql.NullString{String: reconciliation.EXCEPTION, Valid: true},
+			Reason:      sql.NullString{String: "no loan matches the reference or the amount", Valid: true},
+		},
+	}
+	tests := []struct {
+		name       string
+		query      map[string]string
+		setup      func(*gin.Context)
+		httpStatus int
+		lines      int
+	}{
+		{
+			name:  "UnknownStatus",
+			query: map[string]string{"status": "OPEN"},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DBError",
+			query: map[string]string{},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLines(c, gomock.Any()).Return(nil, fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "ExceptionsByDefault",
+			query: map[string]string{"statementId": "7"},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLines(c, reconciliation.StatementLineFilter{StatementId: 7, Status: reconciliation.EXCEPTION, Limit: DEFAULT_PAGE_SIZE}).Return(lines, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			lines:      1,
+		},
+		{
+			name:  "Applied",
+			query: map[string]string{"status": "APPLIED", "afterId": "1", "limit": "10"},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLines(c, reconciliation.StatementLineFilter{Status: reconciliation.APPLIED, AfterId: 1, Limit: 10}).Return([]reconciliation.StatementLine{}, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Get Statement Lines TestCase: ", tt.name)
+			w, ctx := getContext(nil)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			q := ctx.Request.URL.Query()
+			for k, v := range tt.query {
+				q.Add(k, v)
+			}
+			ctx.Request.URL.RawQuery = q.Encode()
+
+			//setup test
+			tt.setup(ctx)
+			NewReconciliationService(dbObj).GetStatementLines(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response StatementLinesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			assert.Equal(t, tt.lines, len(response.Data))
+			if tt.lines != 0 {
+				assert.Equal(t, "no loan matches the reference or the amount", response.Data[0].Reason)
+			}
+		})
+	}
+}
+
+func Test_reconciliationService_ResolveStatementLine(t *testing.T) {
+	var (
+		dbObj   v1.V1DBLayer
+		adminId int64 = 9
+		userId  int64 = 1
+		loanId  int64 = 3
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	line := func(status string) reconciliation.StatementLine {
+		return reconciliation.StatementLine{
+			LineId:        sql.NullInt64{Int64: 2, Valid: true},
+			StatementId:   sql.NullInt64{Int64: 7, Valid: true},
+			LineNum:       sql.NullInt64{Int64: 3, Valid: true},
+			Amount:        sql.NullFloat64{Float64: 1000, Valid: true},
+			Reference:     sql.NullString{String: "rent", Valid: true},
+			BankReference: sql.NullString{String: "B3", Valid: true},
+			Status:        sql.NullString{String: status, Valid: true},
+		}
+	}
+	owner := loan.LoanDetails{
+		LoanId: sql.NullInt64{Int64: loanId, Valid: true},
+		UserId: sql.NullInt64{Int64: userId, Valid: true},
+		Status: sql.NullString{String: "APPROVED", Valid: true},
+	}
+	schedule := func(amountDue float64) []loan.InstallmentDetails {
+		return []loan.InstallmentDetails{
+			{
+				LoanAmount:     sql.NullFloat64{Float64: 3000, Valid: true},
+				LoanStatus:     sql.NullString{String: "APPROVED", Valid: true},
+				InstallmentSeq: sql.NullInt64{Int64: 1, Valid: true},
+				AmountDue:      sql.NullFloat64{Float64: amountDue, Valid: true},
+				AmountPaid:     sql.NullFloat64{Float64: 0, Valid: true},
+				Status:         sql.NullString{String: "PENDING", Valid: true},
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		body       interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+		status     string
+	}{
+		{
+			name: "UnknownAction",
+			body: ResolveStatementLineRequest{LineId: 2, Action: "IGNORE"},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name: "LineNotFound",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_DISMISS},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(reconciliation.StatementLine{}, sql.ErrNoRows).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name: "NotAnException",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_DISMISS},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(line(reconciliation.APPLIED), nil).Times(1)
+			},
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name: "Dismissed",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_DISMISS, Note: "refund of a supplier"},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(line(reconciliation.EXCEPTION), nil).Times(1)
+				repo.EXPECT().ResolveStatementLine(c, gomock.Any()).DoAndReturn(func(_ interface{}, resolved reconciliation.StatementLine) (int64, error) {
+					assert.Equal(t, reconciliation.DISMISSED, resolved.Status.String)
+					assert.Equal(t, adminId, resolved.ResolvedBy.Int64)
+					assert.Equal(t, "refund of a supplier", resolved.Note.String)
+					return 2, nil
+				}).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			status:     reconciliation.DISMISSED,
+		},
+		{
+			name: "ResolvedByAnotherAdmin",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_DISMISS},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(line(reconciliation.EXCEPTION), nil).Times(1)
+				repo.EXPECT().ResolveStatementLine(c, gomock.Any()).Return(int64(0), nil).Times(1)
+			},
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name: "ApplyWithoutLoan",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_APPLY},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(line(reconciliation.EXCEPTION), nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name: "ApplyToMissingLoan",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_APPLY, LoanId: 99},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(line(reconciliation.EXCEPTION), nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, int64(99)).Return(loan.LoanDetails{}, sql.ErrNoRows).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name: "Applied",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_APPLY, LoanId: loanId},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(line(reconciliation.EXCEPTION), nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(2)
+				repo.EXPECT().ResolveStatementLine(c, gomock.Any()).DoAndReturn(func(_ interface{}, resolved reconciliation.StatementLine) (int64, error) {
+					assert.Equal(t, reconciliation.RESOLVED, resolved.Status.String)
+					assert.Equal(t, loanId, resolved.LoanId.Int64)
+					assert.Equal(t, reconciliation.MATCH_MANUAL, resolved.MatchRule.String)
+					return 2, nil
+				}).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(1000), nil).Times(1)
+				repo.EXPECT().UpdateSingleInstallmentPayment(c, loanId, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, _ int64, installment loan.InstallmentDetails, _ interface{}, _ interface{}) error {
+					assert.Equal(t, "B3", installment.TransactionId.String)
+					return nil
+				}).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			status:     reconciliation.RESOLVED,
+		},
+		{
+			name: "AppliedToSuggestedLoan",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_APPLY},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				//the import suggested the loan by the amount of the credit
+				suggested := line(reconciliation.EXCEPTION)
+				suggested.LoanId = sql.NullInt64{Int64: loanId, Valid: true}
+				suggested.MatchRule = sql.NullString{String: reconciliation.MATCH_AMOUNT, Valid: true}
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(suggested, nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(2)
+				repo.EXPECT().ResolveStatementLine(c, gomock.Any()).DoAndReturn(func(_ interface{}, resolved reconciliation.StatementLine) (int64, error) {
+					assert.Equal(t, loanId, resolved.LoanId.Int64)
+					assert.Equal(t, reconciliation.MATCH_MANUAL, resolved.MatchRule.String)
+					return 2, nil
+				}).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(1000), nil).Times(1)
+				repo.EXPECT().UpdateSingleInstallmentPayment(c, loanId, gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			status:     reconciliation.RESOLVED,
+		},
+		{
+			name: "RefusedByLoanRules",
+			body: ResolveStatementLineRequest{LineId: 2, Action: ACTION_APPLY, LoanId: loanId},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetStatementLine(c, int64(2)).Return(line(reconciliation.EXCEPTION), nil).Times(1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(2)
+				repo.EXPECT().ResolveStatementLine(c, gomock.Any()).Return(int64(2), nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(1500), nil).Times(1)
+				//the line goes back to the exceptions with the reason
+				repo.EXPECT().ReopenStatementLine(c, int64(2), "amount payable is less than installment amount").Return(nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Resolve Statement Line TestCase: ", tt.name)
+			w, ctx := getContext(tt.body)
+			ctx.Set(config.USERID, adminId)
+
+			//setup test
+			tt.setup(ctx)
+			NewReconciliationService(dbObj).ResolveStatementLine(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response ResolveStatementLineResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			if tt.status != "" {
+				assert.Equal(t, tt.status, response.Data.Status)
+			}
+		})
+	}
+}
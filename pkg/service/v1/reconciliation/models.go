@@ -0,0 +1,100 @@
+package reconciliation
+
+import (
+	"mime/multipart"
+
+	e "aspire-assignment/pkg/errors"
+)
+
+// ImportStatementRequest uploads a statement as a multipart form. format is detected from the file when not given
+type ImportStatementRequest struct {
+	File   *multipart.FileHeader `form:"file" binding:"required"`
+	Format string                `form:"format" binding:"omitempty,oneof=CSV CAMT053 MT940"`
+}
+
+type ImportStatementResponse struct {
+	Data    *ImportSummary `json:"data,omitempty"`
+	Status  bool           `json:"success"`
+	Errors  []e.Error      `json:"errors,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+// ImportSummary counts the entries of the statement, the credits among them, the credits imported before and
+// what became of the others, which are listed
+type ImportSummary struct {
+	StatementId int64           `json:"statementId"`
+	Format      string          `json:"format"`
+	Entries     int             `json:"entries"`
+	Credits     int             `json:"credits"`
+	Skipped     int             `json:"skipped"`
+	Applied     int             `json:"applied"`
+	Exceptions  int             `json:"exceptions"`
+	Lines       []StatementLine `json:"lines"`
+}
+
+type BankStatementsResponse struct {
+	Data    []BankStatement `json:"data,omitempty"`
+	Status  bool            `json:"success"`
+	Errors  []e.Error       `json:"errors,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// BankStatement is an imported statement with its lines counted by status
+type BankStatement struct {
+	StatementId int64            `json:"statementId"`
+	FileName    string           `json:"fileName"`
+	Format      string           `json:"format"`
+	ImportedBy  int64            `json:"importedBy,omitempty"`
+	ImportedAt  string           `json:"importedAt"`
+	Lines       map[string]int64 `json:"lines"`
+}
+
+// GetStatementLinesRequest filters the statement lines, which are the exceptions unless another status is asked
+// for. afterId continues from the last line of the previous page
+type GetStatementLinesRequest struct {
+	Status      string `form:"status" binding:"omitempty,oneof=RECEIVED APPLIED EXCEPTION RESOLVED DISMISSED"`
+	StatementId int64  `form:"statementId" binding:"min=0"`
+	AfterId     int64  `form:"afterId" binding:"min=0"`
+	Limit       int    `form:"limit" binding:"min=0,max=500"`
+}
+
+type StatementLinesResponse struct {
+	Data    []StatementLine `json:"data,omitempty"`
+	Status  bool            `json:"success"`
+	Errors  []e.Error       `json:"errors,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// StatementLine is a credit of a statement as admins see it
+type StatementLine struct {
+	LineId        int64   `json:"lineId"`
+	StatementId   int64   `json:"statementId"`
+	LineNum       int64   `json:"lineNum"`
+	BookedOn      string  `json:"bookedOn,omitempty"`
+	Amount        float64 `json:"amount"`
+	Reference     string  `json:"reference,omitempty"`
+	Counterparty  string  `json:"counterparty,omitempty"`
+	BankReference string  `json:"bankReference,omitempty"`
+	Status        string  `json:"status"`
+	LoanId        int64   `json:"loanId,omitempty"`
+	MatchRule     string  `json:"matchRule,omitempty"`
+	Reason        string  `json:"reason,omitempty"`
+	ResolvedBy    int64   `json:"resolvedBy,omitempty"`
+	ResolvedAt    string  `json:"resolvedAt,omitempty"`
+	Note          string  `json:"note,omitempty"`
+}
+
+// ResolveStatementLineRequest applies an exception to a loan, by default the one it was matched to, or dismisses it
+type ResolveStatementLineRequest struct {
+	LineId int64  `json:"lineId" binding:"required,min=1"`
+	Action string `json:"action" binding:"required,oneof=APPLY DISMISS"`
+	LoanId int64  `json:"loanId" binding:"min=0"`
+	Note   string `json:"note" binding:"max=500"`
+}
+
+type ResolveStatementLineResponse struct {
+	Data    *StatementLine `json:"data,omitempty"`
+	Status  bool           `json:"success"`
+	Errors  []e.Error      `json:"errors,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
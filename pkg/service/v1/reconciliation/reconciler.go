@@ -0,0 +1,235 @@
+package reconciliation
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/reconciliation"
+	"aspire-assignment/pkg/payment"
+	"aspire-assignment/pkg/service/v1/loan"
+	"aspire-assignment/pkg/statement"
+)
+
+// Reconciler matches the credits of bank statements to loans without any knowledge of HTTP, so the admin endpoints
+// and the command line share it
+type Reconciler interface {
+	Import(ctx context.Context, fileName string, format string, data []byte, importedBy int64) (Summary, error)
+	Resolve(ctx context.Context, resolution Resolution) (reconciliation.StatementLine, error)
+}
+
+// Summary is the outcome of an import. Skipped counts the credits imported before with another statement
+type Summary struct {
+	StatementId int64
+	Format      string
+	Entries     int
+	Credits     int
+	Skipped     int
+	Applied     int
+	Exceptions  int
+	Lines       []reconciliation.StatementLine
+}
+
+// Resolution is the decision of an admin on an exception. LoanId defaults to the loan the line was matched to
+type Resolution struct {
+	LineId  int64
+	Action  string
+	LoanId  int64
+	Note    string
+	AdminId int64
+}
+
+type reconciler struct {
+	dbObj v1.V1DBLayer
+	loans loan.Loans
+}
+
+func NewReconciler(db v1.V1DBLayer) Reconciler {
+	return &reconciler{
+		dbObj: db,
+		loans: loan.NewLoans(db),
+	}
+}
+
+// Import records the credits of a statement and applies each credit naming a loan by its reference as a repayment
+// of that loan. any other credit is left as an exception for an admin, with the one loan whose next installment is
+// due for its amount as a suggestion, and so are credits which the loan rules refuse. a statement is imported
+// once, and so is a credit with a bank reference
+func (obj *reconciler) Import(ctx context.Context, fileName string, format string, data []byte, importedBy int64) (Summary, error) {
+	if format == "" {
+		format = statement.Detect(fileName, data)
+	}
+	parsed, err := statement.Parse(format, data)
+	if err != nil {
+		return Summary{}, fmt.Errorf("%w: %s", ErrInvalidStatement, err.Error())
+	}
+	credits := parsed.Credits()
+
+	checksum := sha256.Sum256(data)
+	record := reconciliation.BankStatement{
+		FileName:   sql.NullString{String: fileName, Valid: true},
+		Format:     sql.NullString{String: parsed.Format, Valid: true},
+		Checksum:   sql.NullString{String: hex.EncodeToString(checksum[:]), Valid: true},
+		ImportedBy: sql.NullInt64{Int64: importedBy, Valid: importedBy != 0},
+		ImportedAt: sql.NullTime{Time: time.Now(), Valid: true},
+	}
+	lines := make([]reconciliation.StatementLine, 0, len(credits))
+	for _, credit := range credits {
+		lines = append(lines, reconciliation.StatementLine{
+			LineNum:       sql.NullInt64{Int64: credit.Num, Valid: true},
+			BookedOn:      sql.NullTime{Time: credit.BookedOn, Valid: !credit.BookedOn.IsZero()},
+			Amount:        sql.NullFloat64{Float64: credit.Amount, Valid: true},
+			Reference:     sql.NullString{String: credit.Reference, Valid: credit.Reference != ""},
+			Counterparty:  sql.NullString{String: credit.Counterparty, Valid: credit.Counterparty != ""},
+			BankReference: sql.NullString{String: credit.BankReference, Valid: credit.BankReference != ""},
+		})
+	}
+	statementId, added, err := obj.dbObj.AddBankStatement(ctx, record, lines)
+	if err != nil {
+		return Summary{}, &StoreError{Op: "record bank statement", Write: true, Err: err}
+	}
+	if statementId == 0 {
+		return Summary{}, ErrStatementImported
+	}
+
+	summary := Summary{
+		StatementId: statementId,
+		Format:      parsed.Format,
+		Entries:     len(parsed.Lines),
+		Credits:     len(credits),
+		Skipped:     len(credits) - len(added),
+		Lines:       make([]reconciliation.StatementLine, 0, len(added)),
+	}
+	for _, line := range added {
+		line = obj.match(ctx, line)
+		if err := obj.dbObj.CompleteStatementLine(ctx, line); err != nil {
+			//the outcome stands, the line is left RECEIVED for an admin to look at
+			log.Printf("failed to complete statement line %d with %s. Error: %s", line.LineId.Int64, line.Status.String, err.Error())
+		}
+		if line.Status.String == reconciliation.APPLIED {
+			summary.Applied++
+		} else {
+			summary.Exceptions++
+		}
+		summary.Lines = append(summary.Lines, line)
+	}
+	log.Printf("bank statement %d imported from %s. Credits: %d, Applied: %d, Exceptions: %d, Skipped: %d", statementId, fileName, summary.Credits, summary.Applied, summary.Exceptions, summary.Skipped)
+	return summary, nil
+}
+
+// match finds the loan of a received line and settles it. the line comes back APPLIED, or as an EXCEPTION with
+// the reason, and with the loan it was matched or suggested to when there is one
+func (obj *reconciler) match(ctx context.Context, line reconciliation.StatementLine) reconciliation.StatementLine {
+	exception := func(reason string) reconciliation.StatementLine {
+		line.Status = sql.NullString{String: reconciliation.EXCEPTION, Valid: true}
+		line.Reason = sql.NullString{String: reason, Valid: true}
+		return line
+	}
+
+	loanId, found := payment.LoanIdIn(line.Reference.String)
+	if !found {
+		loanIds, err := obj.dbObj.GetLoansByNextInstallment(ctx, line.Amount.Float64)
+		if err != nil {
+			log.Printf("failed to match statement line %d by amount. Error: %s", line.LineId.Int64, err.Error())
+			return exception("failed to match by amount")
+		}
+		if len(loanIds) > 1 {
+			return exception(fmt.Sprintf("amount matches the next installment of %d loans", len(loanIds)))
+		}
+		if len(loanIds) == 0 {
+			return exception("no loan matches the reference or the amount")
+		}
+		//an amount alone is no proof of who paid, so the loan is only suggested to the admin resolving the exception
+		line.LoanId = sql.NullInt64{Int64: loanIds[0], Valid: true}
+		line.MatchRule = sql.NullString{String: reconciliation.MATCH_AMOUNT, Valid: true}
+		return exception(fmt.Sprintf("amount matches the next installment of loan %d. apply it to confirm", loanIds[0]))
+	}
+
+	_, err := obj.loans.Settle(ctx, loanId, line.Amount.Float64, transactionId(line))
+	if errors.Is(err, loan.ErrLoanNotFound) {
+		return exception(fmt.Sprintf("reference names loan %d which does not exist", loanId))
+	}
+	line.LoanId = sql.NullInt64{Int64: loanId, Valid: true}
+	line.MatchRule = sql.NullString{String: reconciliation.MATCH_REFERENCE, Valid: true}
+	if err != nil {
+		return exception(err.Error())
+	}
+	line.Status = sql.NullString{String: reconciliation.APPLIED, Valid: true}
+	return line
+}
+
+// Resolve closes an exception. a dismissed line is set aside, an applied one repays the loan chosen by the admin.
+// the line is claimed before the loan is settled so two admins cannot apply it twice, and goes back to the
+// exceptions with the reason when the loan rules refuse it
+func (obj *reconciler) Resolve(ctx context.Context, resolution Resolution) (reconciliation.StatementLine, error) {
+	line, err := obj.dbObj.GetStatementLine(ctx, resolution.LineId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return line, ErrLineNotFound
+	}
+	if err != nil {
+		return line, &StoreError{Op: "fetch statement line", Err: err}
+	}
+	if line.Status.String != reconciliation.EXCEPTION {
+		return line, ErrLineNotException
+	}
+
+	line.ResolvedBy = sql.NullInt64{Int64: resolution.AdminId, Valid: resolution.AdminId != 0}
+	line.ResolvedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	line.Note = sql.NullString{String: resolution.Note, Valid: resolution.Note != ""}
+	if resolution.Action == ACTION_DISMISS {
+		line.Status = sql.NullString{String: reconciliation.DISMISSED, Valid: true}
+		return line, obj.resolve(ctx, line)
+	}
+
+	loanId := resolution.LoanId
+	if loanId == 0 {
+		loanId = line.LoanId.Int64
+	}
+	if loanId == 0 {
+		return line, ErrLoanRequired
+	}
+	if _, err := obj.dbObj.FetchLoanDetails(ctx, loanId); err != nil {
+		//a missing loan comes back from the db as a scan error
+		log.Printf("failed to fetch loan detail. Error:%s", err.Error())
+		return line, loan.ErrLoanNotFound
+	}
+	line.Status = sql.NullString{String: reconciliation.RESOLVED, Valid: true}
+	line.LoanId = sql.NullInt64{Int64: loanId, Valid: true}
+	line.MatchRule = sql.NullString{String: reconciliation.MATCH_MANUAL, Valid: true}
+	if err := obj.resolve(ctx, line); err != nil {
+		return line, err
+	}
+
+	if _, err := obj.loans.Settle(ctx, loanId, line.Amount.Float64, transactionId(line)); err != nil {
+		if err := obj.dbObj.ReopenStatementLine(ctx, line.LineId.Int64, err.Error()); err != nil {
+			log.Printf("failed to reopen statement line %d. Error: %s", line.LineId.Int64, err.Error())
+		}
+		return line, err
+	}
+	return line, nil
+}
+
+func (obj *reconciler) resolve(ctx context.Context, line reconciliation.StatementLine) error {
+	lineId, err := obj.dbObj.ResolveStatementLine(ctx, line)
+	if err != nil {
+		return &StoreError{Op: "resolve statement line", Write: true, Err: err}
+	}
+	if lineId == 0 {
+		return ErrLineNotException
+	}
+	return nil
+}
+
+// transactionId is the reference of the bank for the credit, or else its place in the statement
+func transactionId(line reconciliation.StatementLine) string {
+	if line.BankReference.Valid {
+		return line.BankReference.String
+	}
+	return fmt.Sprintf("STMT%d-%d", line.StatementId.Int64, line.LineNum.Int64)
+}
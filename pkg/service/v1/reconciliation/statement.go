@@ -0,0 +1,145 @@
+package reconciliation
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/reconciliation"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ImportStatement imports a bank statement uploaded by an admin and reconciles its credits against the loans
+func (obj *reconciliationService) ImportStatement(c *gin.Context) {
+	var (
+		request  ImportStatementRequest
+		response ImportStatementResponse
+	)
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MAX_STATEMENT_SIZE+(1<<20))
+	if err := c.Bind(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to import statement"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	if request.File.Size > MAX_STATEMENT_SIZE {
+		log.Printf("statement too large. Size: %d", request.File.Size)
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("statement larger than 10 MB"))
+		response.Message = "failed to import statement"
+		c.JSON(http.StatusRequestEntityTooLarge, response)
+		return
+	}
+
+	file, err := request.File.Open()
+	if err != nil {
+		log.Printf("unable to open uploaded file. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to import statement"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		log.Printf("unable to read uploaded file. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to import statement"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	summary, err := obj.reconciler.Import(c, request.File.Filename, request.Format, data, c.GetInt64(config.USERID))
+	if err != nil {
+		log.Printf("failed to import statement %s. Error: %s", request.File.Filename, err.Error())
+		status, errInfo := errorResponse(err)
+		response.Errors = append(response.Errors, errInfo)
+		response.Message = "failed to import statement"
+		c.JSON(status, response)
+		return
+	}
+	audit.Target(c, audit.TARGET_STATEMENT, summary.StatementId)
+	audit.Change(c, nil, map[string]interface{}{
+		"fileName":   request.File.Filename,
+		"format":     summary.Format,
+		"credits":    summary.Credits,
+		"skipped":    summary.Skipped,
+		"applied":    summary.Applied,
+		"exceptions": summary.Exceptions,
+	})
+
+	response.Status = true
+	response.Data = &ImportSummary{
+		StatementId: summary.StatementId,
+		Format:      summary.Format,
+		Entries:     summary.Entries,
+		Credits:     summary.Credits,
+		Skipped:     summary.Skipped,
+		Applied:     summary.Applied,
+		Exceptions:  summary.Exceptions,
+		Lines:       make([]StatementLine, 0, len(summary.Lines)),
+	}
+	for _, line := range summary.Lines {
+		response.Data.Lines = append(response.Data.Lines, statementLine(line))
+	}
+	response.Message = "successfully imported statement"
+	c.JSON(http.StatusOK, response)
+}
+
+// GetBankStatements lists the imported statements, latest first
+func (obj *reconciliationService) GetBankStatements(c *gin.Context) {
+	var response BankStatementsResponse
+
+	statements, err := obj.dbObj.GetBankStatements(c)
+	if err != nil {
+		log.Printf("failed to fetch bank statements. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch bank statements"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = make([]BankStatement, 0)
+	for _, statement := range statements {
+		response.Data = append(response.Data, BankStatement{
+			StatementId: statement.StatementId.Int64,
+			FileName:    statement.FileName.String,
+			Format:      statement.Format.String,
+			ImportedBy:  statement.ImportedBy.Int64,
+			ImportedAt:  statement.ImportedAt.Time.Format("2006-01-02 15:04:05"),
+			Lines:       statement.Lines,
+		})
+	}
+	response.Message = "successfully fetched bank statements"
+	c.JSON(http.StatusOK, response)
+}
+
+func statementLine(line reconciliation.StatementLine) StatementLine {
+	entry := StatementLine{
+		LineId:        line.LineId.Int64,
+		StatementId:   line.StatementId.Int64,
+		LineNum:       line.LineNum.Int64,
+		Amount:        line.Amount.Float64,
+		Reference:     line.Reference.String,
+		Counterparty:  line.Counterparty.String,
+		BankReference: line.BankReference.String,
+		Status:        line.Status.String,
+		LoanId:        line.LoanId.Int64,
+		MatchRule:     line.MatchRule.String,
+		Reason:        line.Reason.String,
+		ResolvedBy:    line.ResolvedBy.Int64,
+		Note:          line.Note.String,
+	}
+	if line.BookedOn.Valid {
+		entry.BookedOn = line.BookedOn.Time.Format("2006-01-02")
+	}
+	if line.ResolvedAt.Valid {
+		entry.ResolvedAt = line.ResolvedAt.Time.Format("2006-01-02 15:04:05")
+	}
+	return entry
+}
@@ -0,0 +1,335 @@
+package reconciliation
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/loan"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/reconciliation"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/payment"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_reconciliationService_ImportStatement(t *testing.T) {
+	var (
+		dbObj   v1.V1DBLayer
+		adminId int64 = 9
+		userId  int64 = 1
+		loanId  int64 = 3
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	statement := "date,amount,reference,bank reference\n" +
+		"2024-05-02,1000," + payment.Reference(loanId) + ",B1\n" +
+		"2024-05-02,-20,fees,B2\n" +
+		"2024-05-03,250,rent,B3\n"
+	owner := loan.LoanDetails{
+		LoanId: sql.NullInt64{Int64: loanId, Valid: true},
+		UserId: sql.NullInt64{Int64: userId, Valid: true},
+		Status: sql.NullString{String: "APPROVED", Valid: true},
+	}
+	//schedule is returned fresh for every payment, as a repayment marks its installment paid
+	schedule := func() []loan.InstallmentDetails {
+		return []loan.InstallmentDetails{
+			{
+				LoanAmount:     sql.NullFloat64{Float64: 3000, Valid: true},
+				LoanStatus:     sql.NullString{String: "APPROVED", Valid: true},
+				InstallmentSeq: sql.NullInt64{Int64: 1, Valid: true},
+				AmountDue:      sql.NullFloat64{Float64: 1000, Valid: true},
+				AmountPaid:     sql.NullFloat64{Float64: 0, Valid: true},
+				Status:         sql.NullString{String: "PENDING", Valid: true},
+			},
+		}
+	}
+	//recorded gives the lines ids as the store does
+	recorded := func(repo *dbmock.MockV1DBLayer, c *gin.Context, skip int) {
+		repo.EXPECT().AddBankStatement(c, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, record reconciliation.BankStatement, lines []reconciliation.StatementLine) (int64, []reconciliation.StatementLine, error) {
+			assert.Equal(t, "CSV", record.Format.String)
+			assert.Equal(t, adminId, record.ImportedBy.Int64)
+			assert.Equal(t, 64, len(record.Checksum.String))
+			for i := range lines {
+				lines[i].LineId = sql.NullInt64{Int64: int64(i + 1), Valid: true}
+				lines[i].StatementId = sql.NullInt64{Int64: 7, Valid: true}
+			}
+			return 7, lines[skip:], nil
+		}).Times(1)
+	}
+
+	tests := []struct {
+		name       string
+		fields     map[string]string
+		content    string
+		setup      func(*gin.Context)
+		httpStatus int
+		summary    *ImportSummary
+	}{
+		{
+			name:    "UnknownFormat",
+			fields:  map[string]string{"format": "OFX"},
+			content: statement,
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "InvalidStatement",
+			content: "date,reference\n2024-05-02,rent\n",
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:    "ImportedBefore",
+			content: statement,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddBankStatement(c, gomock.Any(), gomock.Any()).Return(int64(0), nil, nil).Times(1)
+			},
+			httpStatus: http.StatusConflict,
+		},
+		{
+			name:    "DBError",
+			content: statement,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddBankStatement(c, gomock.Any(), gomock.Any()).Return(int64(0), nil, fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:    "AppliedAndException",
+			content: statement,
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				recorded(repo, c, 0)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().UpdateSingleInstallmentPayment(c, loanId, gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ interface{}, _ int64, installment loan.InstallmentDetails, _ interface{}, _ interface{}) error {
+					assert.Equal(t, "B1", installment.TransactionId.String)
+					return nil
+				}).Times(1)
+				repo.EXPECT().GetLoansByNextInstallment(c, float64(250)).Return([]int64{}, nil).Times(1)
+				repo.EXPECT().CompleteStatementLine(c, gomock.Any()).Return(nil).Times(2)
+			},
+			httpStatus: http.StatusOK,
+			summary: &ImportSummary{
+				StatementId: 7, Format: "CSV", Entries: 3, Credits: 2, Applied: 1, Exceptions: 1,
+				Lines: []StatementLine{
+					{LineId: 1, StatementId: 7, LineNum: 1, BookedOn: "2024-05-02", Amount: 1000, Reference: payment.Reference(loanId), BankReference: "B1", Status: reconciliation.APPLIED, LoanId: loanId, MatchRule: reconciliation.MATCH_REFERENCE},
+					{LineId: 2, StatementId: 7, LineNum: 3, BookedOn: "2024-05-03", Amount: 250, Reference: "rent", BankReference: "B3", Status: reconciliation.EXCEPTION, Reason: "no loan matches the reference or the amount"},
+				},
+			},
+		},
+		{
+			name:    "SuggestedByAmount",
+			content: "date,amount,reference\n2024-05-03,1000,rent\n",
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				recorded(repo, c, 0)
+				repo.EXPECT().GetLoansByNextInstallment(c, float64(1000)).Return([]int64{loanId}, nil).Times(1)
+				//the amount alone does not settle the loan
+				repo.EXPECT().UpdateSingleInstallmentPayment(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				repo.EXPECT().CompleteStatementLine(c, gomock.Any()).Return(nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			summary: &ImportSummary{
+				StatementId: 7, Format: "CSV", Entries: 1, Credits: 1, Exceptions: 1,
+				Lines: []StatementLine{
+					{LineId: 1, StatementId: 7, LineNum: 1, BookedOn: "2024-05-03", Amount: 1000, Reference: "rent", Status: reconciliation.EXCEPTION, LoanId: loanId, MatchRule: reconciliation.MATCH_AMOUNT, Reason: "amount matches the next installment of loan 3. apply it to confirm"},
+				},
+			},
+		},
+		{
+			name:    "RefusedByLoanRules",
+			content: "date,amount,reference,bank reference\n2024-05-02,1000,rent,B1\n2024-05-03,250," + payment.Reference(loanId) + ",B3\n",
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				//the first credit was imported before with another statement
+				recorded(repo, c, 1)
+				repo.EXPECT().FetchLoanDetails(c, loanId).Return(owner, nil).Times(1)
+				repo.EXPECT().GetUserLoanInstallments(c, userId, loanId).Return(schedule(), nil).Times(1)
+				repo.EXPECT().CompleteStatementLine(c, gomock.Any()).DoAndReturn(func(_ interface{}, line reconciliation.StatementLine) error {
+					assert.Equal(t, reconciliation.EXCEPTION, line.Status.String)
+					assert.Equal(t, loanId, line.LoanId.Int64)
+					return nil
+				}).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			summary: &ImportSummary{
+				StatementId: 7, Format: "CSV", Entries: 2, Credits: 2, Skipped: 1, Exceptions: 1,
+				Lines: []StatementLine{
+					{LineId: 2, StatementId: 7, LineNum: 2, BookedOn: "2024-05-03", Amount: 250, Reference: payment.Reference(loanId), BankReference: "B3", Status: reconciliation.EXCEPTION, LoanId: loanId, MatchRule: reconciliation.MATCH_REFERENCE, Reason: "amount payable is less than installment amount"},
+				},
+			},
+		},
+		{
+			name:    "AmountOfSeveralLoans",
+			content: "date,amount,reference\n2024-05-03,1000,rent\n",
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				recorded(repo, c, 0)
+				repo.EXPECT().GetLoansByNextInstallment(c, float64(1000)).Return([]int64{3, 4}, nil).Times(1)
+				repo.EXPECT().CompleteStatementLine(c, gomock.Any()).Return(nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			summary: &ImportSummary{
+				StatementId: 7, Format: "CSV", Entries: 1, Credits: 1, Exceptions: 1,
+				Lines: []StatementLine{
+					{LineId: 1, StatementId: 7, LineNum: 1, BookedOn: "2024-05-03", Amount: 1000, Reference: "rent", Status: reconciliation.EXCEPTION, Reason: "amount matches the next installment of 2 loans"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Import Statement TestCase: ", tt.name)
+			w, ctx := getMultipartContext(tt.fields, "statement.csv", []byte(tt.content))
+			ctx.Set(config.USERID, adminId)
+
+			//setup test
+			tt.setup(ctx)
+			NewReconciliationService(dbObj).ImportStatement(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response ImportStatementResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			assert.Equal(t, tt.summary, response.Data)
+		})
+	}
+}
+
+func Test_reconciliationService_GetBankStatements(t *testing.T) {
+	var dbObj v1.V1DBLayer
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		setup      func(*gin.Context)
+		httpStatus int
+		statements int
+	}{
+		{
+			name: "DBError",
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetBankStatements(c).Return(nil, fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "Success",
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetBankStatements(c).Return([]reconciliation.BankStatement{
+					{
+						StatementId: sql.NullInt64{Int64: 7, Valid: true},
+						FileName:    sql.NullString{String: "statement.csv", Valid: true},
+						Format:      sql.NullString{String: "CSV", Valid: true},
+						ImportedAt:  sql.NullTime{Time: time.Now(), Valid: true},
+						Lines:       map[string]int64{reconciliation.APPLIED: 4, reconciliation.EXCEPTION: 1},
+					},
+				}, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			statements: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Get Bank Statements TestCase: ", tt.name)
+			w, ctx := getContext(nil)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+
+			//setup test
+			tt.setup(ctx)
+			NewReconciliationService(dbObj).GetBankStatements(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response BankStatementsResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			assert.Equal(t, tt.statements, len(response.Data))
+			if tt.statements != 0 {
+				assert.Equal(t, int64(1), response.Data[0].Lines[reconciliation.EXCEPTION])
+			}
+		})
+	}
+}
+
+func getContext(body interface{}) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+	byteData, _ := json.Marshal(body)
+	temp.Request = httptest.NewRequest(http.MethodPost, "/", bytes.NewBuffer(byteData))
+	temp.Request.Header.Set("Content-Type", "application/json")
+	return recorder, temp
+}
+
+func getMultipartContext(fields map[string]string, fileName string, content []byte) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for k, v := range fields {
+		if err := writer.WriteField(k, v); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	part, err := writer.CreateFormFile("file", fileName)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	temp.Request, err = http.NewRequest(http.MethodPost, "/", body)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	temp.Request.Header.Set("Content-Type", writer.FormDataContentType())
+
+	return recorder, temp
+}
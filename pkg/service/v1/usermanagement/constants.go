@@ -0,0 +1,68 @@
+package usermanagement
+
+import "time"
+
+// editable profile fields
+const (
+	FIELD_EMAIL        = "EMAIL"
+	FIELD_MOBILE       = "MOBILE"
+	FIELD_SALARY       = "SALARY"
+	FIELD_BANK_BALANCE = "BANK_BALANCE"
+)
+
+// contact verification status
+const (
+	VERIFICATION_PENDING  = "PENDING"
+	VERIFICATION_VERIFIED = "VERIFIED"
+	VERIFICATION_EXPIRED  = "EXPIRED"
+)
+
+// otp settings for contact verification
+const (
+	OTP_LENGTH       = 6
+	OTP_EXPIRY       = 10 * time.Minute
+	OTP_MAX_ATTEMPTS = 5
+)
+
+// password reset settings
+const (
+	RESET_TOKEN_BYTES  = 32
+	RESET_TOKEN_EXPIRY = 30 * time.Minute
+)
+
+// refresh token status
+const (
+	REFRESH_ACTIVE  = "ACTIVE"
+	REFRESH_ROTATED = "ROTATED"
+	REFRESH_REVOKED = "REVOKED"
+)
+
+// session token settings
+const (
+	REFRESH_TOKEN_BYTES = 32
+	TOKEN_ID_BYTES      = 16
+)
+
+// admin invitation settings
+const (
+	INVITE_CODE_BYTES = 16
+	INVITE_EXPIRY     = 72 * time.Hour
+)
+
+// two factor settings
+const (
+	CHALLENGE_TOKEN_BYTES  = 32
+	CHALLENGE_EXPIRY       = 5 * time.Minute
+	CHALLENGE_MAX_ATTEMPTS = 5
+	RECOVERY_CODE_COUNT    = 10
+	RECOVERY_CODE_BYTES    = 8
+)
+
+// service account api key settings. keys look like ak_<prefix>_<secret> and only the prefix is shown after creation
+const (
+	API_KEY_PREFIX          = "ak_"
+	API_KEY_PREFIX_BYTES    = 4
+	API_KEY_SECRET_BYTES    = 32
+	API_KEY_MAX_EXPIRY_DAYS = 365
+	API_KEY_TOUCH_INTERVAL  = time.Minute
+)
@@ -1,22 +1,65 @@
 package usermanagement
 
 import (
+	"aspire-assignment/pkg/auth"
 	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/notifier"
+	"context"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type userMgtService struct {
-	dbObj v1.V1DBLayer
+	dbObj    v1.V1DBLayer
+	notifier notifier.Notifier
 }
 
 type UserManagementInterface interface {
 	UserSignup(*gin.Context)
 	UserLogin(*gin.Context)
+	UnlockUser(*gin.Context)
+
+	EnrollTotp(*gin.Context)
+	ActivateTotp(*gin.Context)
+	EnrollTotpChallenge(*gin.Context)
+	VerifyLoginChallenge(*gin.Context)
+
+	CreateAdminInvite(*gin.Context)
+	AdminSignup(*gin.Context)
+	BootstrapAdmin(context.Context, BootstrapAdminRequest) (int64, error)
+
+	GetProfile(*gin.Context)
+	UpdateProfile(*gin.Context)
+	VerifyContact(*gin.Context)
+	GetProfileHistory(*gin.Context)
+
+	ChangePassword(*gin.Context)
+	ForgotPassword(*gin.Context)
+	ResetPassword(*gin.Context)
+
+	RefreshSession(*gin.Context)
+	Logout(*gin.Context)
+	IsSessionValid(*gin.Context, auth.Token) (bool, error)
+
+	GetJWKS(*gin.Context)
+	GetSigningKeys(context.Context) ([]auth.SigningKey, error)
+	AddSigningKey(context.Context, auth.SigningKey, time.Time) (bool, error)
+
+	CreateServiceAccount(*gin.Context)
+	GetServiceAccounts(*gin.Context)
+	CreateAPIKey(*gin.Context)
+	RevokeAPIKey(*gin.Context)
+	AuthenticateAPIKey(*gin.Context, string) (auth.APIKey, bool, error)
+
+	GetRoles(*gin.Context)
+	AssignUserRoles(*gin.Context)
+	HasPermission(*gin.Context, []string, string) (bool, error)
 }
 
-func NewUserManagementService(db v1.V1DBLayer) UserManagementInterface {
+func NewUserManagementService(db v1.V1DBLayer, notifier notifier.Notifier) UserManagementInterface {
 	return &userMgtService{
-		dbObj: db,
+		dbObj:    db,
+		notifier: notifier,
 	}
 }
@@ -0,0 +1,175 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/notifier"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CreateAdminInvite issues a single use invite code bound to an email. the code is sent to the email and returned to the issuing admin
+func (obj *userMgtService) CreateAdminInvite(c *gin.Context) {
+	var (
+		request  AdminInviteRequest
+		response AdminInviteResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to create admin invite"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+
+	code, err := generateRandomToken(INVITE_CODE_BYTES)
+	if err != nil {
+		log.Printf("failed to generate invite code. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to create admin invite"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	expiresAt := time.Now().Add(INVITE_EXPIRY)
+	inviteId, err := obj.dbObj.AddAdminInvite(c, usermanagement.AdminInvite{
+		CodeHash:  sql.NullString{String: hashToken(code), Valid: true},
+		Email:     sql.NullString{String: request.Email, Valid: true},
+		CreatedBy: sql.NullInt64{Int64: request.UserId, Valid: true},
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		log.Printf("failed to store admin invite. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to create admin invite"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	err = obj.notifier.Send(c, notifier.Message{
+		Channel: notifier.EMAIL,
+		To:      request.Email,
+		Subject: "You are invited as an admin",
+		Body:    fmt.Sprintf("Use the invite code %s to create your admin account. It expires in %d hours and can be used once.", code, int(INVITE_EXPIRY.Hours())),
+	})
+	if err != nil {
+		//the issuing admin still receives the code and can share it
+		log.Printf("failed to send admin invite. Error: %s", err.Error())
+	}
+
+	log.Printf("admin invite %d issued by UserId: %d", inviteId, request.UserId)
+	audit.Target(c, audit.TARGET_INVITE, inviteId)
+	audit.Change(c, nil, map[string]interface{}{"email": request.Email, "expiresAt": expiresAt.Format("2006-01-02 15:04:05")})
+	response.Status = true
+	response.Data = &AdminInvite{
+		InviteId:   inviteId,
+		Email:      request.Email,
+		InviteCode: code,
+		Expiry:     expiresAt.Format("2006-01-02 15:04:05"),
+	}
+	response.Message = "successfully created admin invite"
+	c.JSON(http.StatusOK, response)
+}
+
+// AdminSignup redeems an invite code and creates an ADMIN account for the invited email
+func (obj *userMgtService) AdminSignup(c *gin.Context) {
+	var (
+		request  AdminSignupRequest
+		response UserSignupResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to signup admin"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("unable to hash password. Error:%s", err.Error())
+		response.Errors = append(response.Errors, e.ErrorInfo[e.ConversionError].GetErrorDetails("failed to hash the password"))
+		response.Message = "failed to signup admin"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	audit.Target(c, audit.TARGET_USERNAME, request.UserName)
+	userId, err := obj.dbObj.RedeemAdminInvite(c, hashToken(request.InviteCode), usermanagement.UserDetails{
+		UserName:     sql.NullString{String: request.UserName, Valid: true},
+		UserPassword: sql.NullString{String: string(hashedPasswordBytes), Valid: true},
+		Email:        sql.NullString{String: request.Email, Valid: true},
+		UserType:     sql.NullString{String: config.ADMIN, Valid: true},
+		Mobile:       sql.NullString{String: request.Mobile, Valid: true},
+	})
+	if err != nil {
+		log.Printf("failed to add admin. Error: %s", err.Error())
+		if usermanagement.IsUniqueViolation(err) {
+			response.Errors = append(response.Errors, e.ErrorInfo[e.AddDBError].GetErrorDetails("unique username needed"))
+		} else {
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		}
+		response.Message = "failed to signup admin"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if userId == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("invalid or expired invite code"))
+		response.Message = "failed to signup admin"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	audit.Target(c, audit.TARGET_USER, userId)
+	audit.Change(c, nil, map[string]interface{}{"username": request.UserName, "email": request.Email, "mobile": request.Mobile, "userType": config.ADMIN})
+	response.Status = true
+	response.Data = &UserSignup{
+		UserName: request.UserName,
+		UserId:   userId,
+	}
+	response.Message = "successfully signed up admin"
+	c.JSON(http.StatusOK, response)
+}
+
+// BootstrapAdmin creates the first ADMIN account from the command line. it is refused once any admin exists
+func (obj *userMgtService) BootstrapAdmin(ctx context.Context, request BootstrapAdminRequest) (int64, error) {
+	if request.UserName == "" || request.Email == "" || request.Mobile == "" {
+		return 0, errors.New("username, email and mobile are required")
+	}
+	if len(request.Password) < 6 {
+		return 0, errors.New("password must be at least 6 characters")
+	}
+
+	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return 0, err
+	}
+
+	//the check for an existing admin and the insert are one step, so two bootstraps at once add a single admin
+	userId, err := obj.dbObj.AddFirstUserOfType(ctx, usermanagement.UserDetails{
+		UserName:     sql.NullString{String: request.UserName, Valid: true},
+		UserPassword: sql.NullString{String: string(hashedPasswordBytes), Valid: true},
+		Email:        sql.NullString{String: request.Email, Valid: true},
+		UserType:     sql.NullString{String: config.ADMIN, Valid: true},
+		Mobile:       sql.NullString{String: request.Mobile, Valid: true},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if userId == 0 {
+		return 0, errors.New("an admin already exists. invite further admins using /v1/admin/invite")
+	}
+	return userId, nil
+}
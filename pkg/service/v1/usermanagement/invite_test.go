@@ -0,0 +1,179 @@
+package usermanagement
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userMgtService_CreateAdminInvite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	w, ctx := getContext(http.MethodPost, AdminInviteRequest{Email: "admin@example.com"})
+	ctx.Set(config.USERID, int64(1))
+
+	var storedHash string
+	repo.EXPECT().AddAdminInvite(ctx, gomock.Any()).DoAndReturn(func(c *gin.Context, invite usermanagement.AdminInvite) (int64, error) {
+		storedHash = invite.CodeHash.String
+		assert.Equal(t, int64(1), invite.CreatedBy.Int64)
+		assert.Equal(t, "admin@example.com", invite.Email.String)
+		return int64(5), nil
+	}).Times(1)
+	capture := &captureNotifier{}
+	servObj := NewUserManagementService(repo, capture)
+
+	servObj.CreateAdminInvite(ctx)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response AdminInviteResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, response.Status)
+	assert.Equal(t, int64(5), response.Data.InviteId)
+
+	//only the hash of the code is stored and the code reaches the invited email
+	assert.Equal(t, hashToken(response.Data.InviteCode), storedHash)
+	assert.Equal(t, 1, len(capture.messages))
+	assert.Equal(t, "admin@example.com", capture.messages[0].To)
+	assert.Equal(t, true, strings.Contains(capture.messages[0].Body, response.Data.InviteCode))
+}
+
+func Test_userMgtService_AdminSignup(t *testing.T) {
+	var (
+		dbObj v1.V1DBLayer
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          AdminSignupRequest
+		setup          func(*gin.Context, AdminSignupRequest)
+		expectedOutput UserSignupResponse
+		actualOutput   UserSignupResponse
+	}{
+		{
+			name: "InvalidInvite",
+			input: AdminSignupRequest{
+				InviteCode: "abc",
+				UserName:   "testadmin",
+				Password:   "123456",
+				Email:      "admin@example.com",
+				Mobile:     "9999999999",
+			},
+			setup: func(c *gin.Context, data AdminSignupRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().RedeemAdminInvite(c, hashToken(data.InviteCode), gomock.Any()).Return(int64(0), nil).Times(1)
+			},
+			expectedOutput: UserSignupResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.BadRequest].Code}},
+				Message: "failed to signup admin",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "AdminSignupSuccess",
+			input: AdminSignupRequest{
+				InviteCode: "abc",
+				UserName:   "testadmin",
+				Password:   "123456",
+				Email:      "admin@example.com",
+				Mobile:     "9999999999",
+			},
+			setup: func(c *gin.Context, data AdminSignupRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().RedeemAdminInvite(c, hashToken(data.InviteCode), gomock.Any()).DoAndReturn(func(c *gin.Context, codeHash string, user usermanagement.UserDetails) (int64, error) {
+					assert.Equal(t, config.ADMIN, user.UserType.String)
+					assert.Equal(t, data.Email, user.Email.String)
+					return int64(2), nil
+				}).Times(1)
+			},
+			expectedOutput: UserSignupResponse{
+				Status:  true,
+				Message: "successfully signed up admin",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Admin Signup TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.AdminSignup(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			assert.Equal(t, tt.expectedOutput.Message, tt.actualOutput.Message)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+
+			fmt.Println("Ending Admin Signup TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_BootstrapAdmin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	_, ctx := getContext(http.MethodPost, nil)
+	servObj := NewUserManagementService(repo, &captureNotifier{})
+	request := BootstrapAdminRequest{
+		UserName: "admin",
+		Password: "123456",
+		Email:    "admin@example.com",
+		Mobile:   "9999999999",
+	}
+
+	//refused once an admin exists
+	admin := gomock.AssignableToTypeOf(usermanagement.UserDetails{})
+	repo.EXPECT().AddFirstUserOfType(ctx, admin).Return(int64(0), nil).Times(1)
+	_, err := servObj.BootstrapAdmin(ctx, request)
+	assert.NotEqual(t, nil, err)
+
+	repo.EXPECT().AddFirstUserOfType(ctx, admin).DoAndReturn(func(_ context.Context, user usermanagement.UserDetails) (int64, error) {
+		assert.Equal(t, config.ADMIN, user.UserType.String)
+		return 1, nil
+	}).Times(1)
+	userId, err := servObj.BootstrapAdmin(ctx, request)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, int64(1), userId)
+}
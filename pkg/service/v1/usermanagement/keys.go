@@ -0,0 +1,46 @@
+package usermanagement
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public keys access tokens are verified with so other services need no shared secret
+func (obj *userMgtService) GetJWKS(c *gin.Context) {
+	//verifiers cache the set. a new key is published a refresh interval before it signs anything
+	c.Header("Cache-Control", "public, max-age=60")
+	c.JSON(http.StatusOK, auth.JWKS())
+}
+
+func (obj *userMgtService) GetSigningKeys(ctx context.Context) ([]auth.SigningKey, error) {
+	stored, err := obj.dbObj.GetSigningKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]auth.SigningKey, 0, len(stored))
+	for _, key := range stored {
+		keys = append(keys, auth.SigningKey{
+			KeyId:      key.KeyId.String,
+			Algorithm:  key.Algorithm.String,
+			PrivateKey: key.PrivateKey.String,
+			CreatedAt:  key.CreatedAt.Time,
+		})
+	}
+	return keys, nil
+}
+
+func (obj *userMgtService) AddSigningKey(ctx context.Context, key auth.SigningKey, rotateBefore time.Time) (bool, error) {
+	return obj.dbObj.AddSigningKey(ctx, usermanagement.SigningKey{
+		KeyId:      sql.NullString{String: key.KeyId, Valid: true},
+		Algorithm:  sql.NullString{String: key.Algorithm, Valid: true},
+		PrivateKey: sql.NullString{String: key.PrivateKey, Valid: true},
+		CreatedAt:  sql.NullTime{Time: key.CreatedAt, Valid: true},
+	}, rotateBefore)
+}
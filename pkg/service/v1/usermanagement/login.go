@@ -0,0 +1,147 @@
+package usermanagement
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// UnlockUser clears the failed logins and the lockout of a username and/or a client ip
+func (obj *userMgtService) UnlockUser(c *gin.Context) {
+	var (
+		request  UnlockUserRequest
+		response UnlockUserResponse
+	)
+	if err := c.BindJSON(&request); err != nil || (request.UserName == "" && request.Ip == "") {
+		log.Println("unable to marshal request or username/ip missing")
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to unlock"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	subjects := make([]string, 0)
+	if request.UserName != "" {
+		subjects = append(subjects, userSubject(request.UserName))
+		audit.Target(c, audit.TARGET_USERNAME, request.UserName)
+	}
+	if request.Ip != "" {
+		subjects = append(subjects, ipSubject(request.Ip))
+	}
+	if request.UserName == "" {
+		audit.Target(c, audit.TARGET_IP, request.Ip)
+	}
+
+	cleared, err := obj.dbObj.ClearLoginFailures(c, subjects)
+	if err != nil {
+		log.Printf("failed to clear login failures. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DelDBError])
+		response.Message = "failed to unlock"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	log.Printf("login lockout cleared for %v by UserId: %d", subjects, c.GetInt64(config.USERID))
+	audit.Change(c, nil, map[string]interface{}{"subjects": subjects, "cleared": cleared})
+	response.Status = true
+	if cleared == 0 {
+		response.Message = "no failed logins to clear"
+	} else {
+		response.Message = "successfully unlocked"
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// loginRetryAfter returns how long the username and the client ip must wait before the next attempt, and the
+// subject holding it back the longest
+func (obj *userMgtService) loginRetryAfter(c *gin.Context, userName string, ip string) (time.Duration, string, error) {
+	throttles, err := obj.dbObj.GetLoginThrottles(c, []string{userSubject(userName), ipSubject(ip)})
+	if err != nil {
+		return 0, "", err
+	}
+
+	var (
+		wait    time.Duration
+		subject string
+	)
+	now := time.Now()
+	for _, throttle := range throttles {
+		var subjectWait time.Duration
+		if throttle.LockedUntil.Valid && throttle.LockedUntil.Time.After(now) {
+			subjectWait = throttle.LockedUntil.Time.Sub(now)
+		} else if throttle.LastFailureAt.Valid && throttle.LastFailureAt.Time.After(now.Add(-failureWindow)) {
+			subjectWait = throttle.LastFailureAt.Time.Add(progressiveDelay(throttle.Failures.Int64)).Sub(now)
+		}
+		if subjectWait > wait {
+			wait = subjectWait
+			subject = throttle.Subject.String
+		}
+	}
+	return wait, subject, nil
+}
+
+// recordLoginFailure counts a failed login against the username and the client ip and locks whichever crossed its limit
+func (obj *userMgtService) recordLoginFailure(c *gin.Context, userName string, ip string) error {
+	now := time.Now()
+	limits := map[string]int64{
+		userSubject(userName): maxUserFailures,
+		ipSubject(ip):         maxIpFailures,
+	}
+	for subject, limit := range limits {
+		throttle, err := obj.dbObj.AddLoginFailure(c, subject, now, now.Add(-failureWindow))
+		if err != nil {
+			return err
+		}
+		if throttle.Failures.Int64 >= limit {
+			log.Printf("login locked for %s after %d failures", subject, throttle.Failures.Int64)
+			if err := obj.dbObj.LockLogin(c, subject, now.Add(lockoutDuration)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// comparePassword checks the password against the user hash. unknown users are compared against a dummy hash
+// so the response time does not reveal whether the username exists
+func comparePassword(userDetail usermanagement.UserDetails, password string) bool {
+	if userDetail.UserId.Int64 == 0 {
+		dummyHashOnce.Do(func() {
+			dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+		})
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(userDetail.UserPassword.String), []byte(password)) == nil
+}
+
+func setRetryAfter(c *gin.Context, wait time.Duration) {
+	seconds := int64(wait / time.Second)
+	if wait%time.Second != 0 {
+		seconds++
+	}
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+}
+
+func userSubject(userName string) string {
+	return "user:" + userName
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
@@ -4,10 +4,9 @@ import (
 	"database/sql"
 	"log"
 	"net/http"
-	"strings"
-	"time"
 
-	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
 	"aspire-assignment/pkg/db/v1/usermanagement"
 	e "aspire-assignment/pkg/errors"
 
@@ -15,6 +14,7 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// UserSignup creates a CUSTOMER account. admin accounts are created only through an invite or the bootstrap command
 func (obj *userMgtService) UserSignup(c *gin.Context) {
 	var (
 		request  UserSignupRequest
@@ -38,13 +38,14 @@ func (obj *userMgtService) UserSignup(c *gin.Context) {
 		return
 	}
 	hashedPassword := string(hashedPasswordBytes)
+	audit.Target(c, audit.TARGET_USERNAME, request.UserName)
 
 	//add the entry into db
 	userDetail := usermanagement.UserDetails{
 		UserName:       sql.NullString{String: request.UserName, Valid: true},
 		UserPassword:   sql.NullString{String: hashedPassword, Valid: true},
 		Email:          sql.NullString{String: request.Email, Valid: true},
-		UserType:       sql.NullString{String: request.UserType, Valid: true},
+		UserType:       sql.NullString{String: config.CUSTOMER, Valid: true},
 		Mobile:         sql.NullString{String: request.Mobile},
 		MonthlySalary:  sql.NullFloat64{Float64: request.MonthlySalary, Valid: true},
 		AccountBalance: sql.NullFloat64{Float64: request.BankBalance, Valid: true},
@@ -53,7 +54,7 @@ func (obj *userMgtService) UserSignup(c *gin.Context) {
 	userId, err := obj.dbObj.AddUser(c, userDetail)
 	if err != nil {
 		log.Printf("failed to add user. Error: %s", err.Error())
-		if strings.Contains(err.Error(), "SQLSTATE 23505") {
+		if usermanagement.IsUniqueViolation(err) {
 			response.Errors = append(response.Errors, e.ErrorInfo[e.AddDBError].GetErrorDetails("unique username needed"))
 		} else {
 			response.Errors = append(response.Errors, e.ErrorInfo[e.AddDBError].GetErrorDetails(err.Error()))
@@ -63,6 +64,8 @@ func (obj *userMgtService) UserSignup(c *gin.Context) {
 		return
 	}
 
+	audit.Target(c, audit.TARGET_USER, userId)
+	audit.Change(c, nil, map[string]interface{}{"username": request.UserName, "email": request.Email, "mobile": request.Mobile, "userType": config.CUSTOMER})
 	response.Status = true
 	response.Data = &UserSignup{
 		UserName: request.UserName,
@@ -85,44 +88,74 @@ func (obj *userMgtService) UserLogin(c *gin.Context) {
 		return
 	}
 
+	audit.Target(c, audit.TARGET_USERNAME, request.UserName)
+	ip := c.ClientIP()
+	wait, subject, err := obj.loginRetryAfter(c, request.UserName, ip)
+	if err != nil {
+		log.Printf("failed to fetch login throttle. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to login user"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if wait > 0 {
+		log.Printf("login throttled for %s", subject)
+		setRetryAfter(c, wait)
+		response.Errors = append(response.Errors, e.ErrorInfo[e.TooManyRequests].GetErrorDetails("too many failed login attempts. try again later"))
+		response.Message = "failed to login user"
+		c.JSON(http.StatusTooManyRequests, response)
+		return
+	}
+
 	//fetch password hash for the user
 	userDetail, err := obj.dbObj.GetUserByUsername(c, request.UserName)
 	if err != nil {
 		log.Printf("failed to fetch user data. Error: %s", err.Error())
-		response.Errors = append(response.Errors, e.ErrorInfo[e.GetDBError].GetErrorDetails(err.Error()))
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
 		response.Message = "failed to login user"
 		c.JSON(http.StatusInternalServerError, response)
 		return
 	}
-	if userDetail.UserId.Int64 == 0 {
-		//invalid username sent
-		log.Println("username not found")
-		response.Errors = append(response.Errors, e.ErrorInfo[e.NoDataFound].GetErrorDetails("username not found"))
+
+	//unknown username and wrong password get the same response
+	if !comparePassword(userDetail, request.Password) || userDetail.UserName.String != request.UserName {
+		log.Println("invalid username/password")
+		if err := obj.recordLoginFailure(c, request.UserName, ip); err != nil {
+			log.Printf("failed to record login failure. Error: %s", err.Error())
+		}
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("incorrect username/password"))
 		response.Message = "failed to login user"
-		c.JSON(http.StatusNotFound, response)
+		c.JSON(http.StatusUnauthorized, response)
 		return
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(userDetail.UserPassword.String), []byte(request.Password))
-	if err != nil || userDetail.UserName.String != request.UserName {
-		log.Println("invalid password")
-		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("incorrect username/password"))
+	audit.Identify(c, userDetail.UserType.String, userDetail.UserId.Int64, userDetail.UserName.String)
+
+	//a successful login forgets the failures of the username. the ip keeps its count so one account cannot reset it
+	if _, err := obj.dbObj.ClearLoginFailures(c, []string{userSubject(request.UserName)}); err != nil {
+		log.Printf("failed to clear login failures. Error: %s", err.Error())
+	}
+
+	//users with a second factor get a challenge instead of the session
+	challenge, err := obj.loginChallenge(c, userDetail)
+	if err != nil {
+		log.Printf("failed to create login challenge. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
 		response.Message = "failed to login user"
-		c.JSON(http.StatusBadRequest, response)
+		c.JSON(http.StatusInternalServerError, response)
 		return
 	}
-
-	exp := time.Now().Add(60 * time.Minute)
-	payload := auth.Token{
-		UserName: userDetail.UserName.String,
-		UserId:   userDetail.UserId.Int64,
-		UserType: userDetail.UserType.String,
-		Exp:      exp,
+	if challenge != nil {
+		response.Status = true
+		response.Data = challenge
+		response.Message = "second factor required"
+		c.JSON(http.StatusOK, response)
+		return
 	}
 
-	token, err := auth.GenerateJWT(payload)
+	session, err := obj.issueSession(c, userDetail, userDetail.TokenVersion.Int64)
 	if err != nil {
-		log.Println("failed to generate JWT")
+		log.Printf("failed to generate session. Error: %s", err.Error())
 		response.Errors = append(response.Errors, e.ErrorInfo[e.DefaultError].GetErrorDetails("failed to generate JWT token"))
 		response.Message = "failed to login user"
 		c.JSON(http.StatusInternalServerError, response)
@@ -130,10 +163,7 @@ func (obj *userMgtService) UserLogin(c *gin.Context) {
 	}
 
 	response.Status = true
-	response.Data = &UserLogin{
-		Token:  token,
-		Expiry: exp.Format("2006-01-02 15:04:05"),
-	}
+	response.Data = session
 	response.Message = "successfully logged in user"
 	c.JSON(http.StatusOK, response)
 }
@@ -0,0 +1,341 @@
+package usermanagement
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func Test_userMgtService_UserSignup(t *testing.T) {
+	var (
+		dbObj v1.V1DBLayer
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          UserSignupRequest
+		setup          func(*gin.Context, UserSignupRequest)
+		expectedOutput UserSignupResponse
+		actualOutput   UserSignupResponse
+	}{
+		{
+			name: "AdminSignupRejected",
+			input: UserSignupRequest{
+				UserName: "testadmin",
+				Password: "123456",
+				UserType: config.ADMIN,
+				Email:    "admin@example.com",
+				Mobile:   "9999999999",
+			},
+			setup: func(c *gin.Context, data UserSignupRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+			},
+			expectedOutput: UserSignupResponse{
+				Status:  false,
+				Message: "failed to signup user",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "CustomerSignupWithoutType",
+			input: UserSignupRequest{
+				UserName: "testuser",
+				Password: "123456",
+				Email:    "test@example.com",
+				Mobile:   "9999999999",
+			},
+			setup: func(c *gin.Context, data UserSignupRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().AddUser(c, gomock.Any()).DoAndReturn(func(c *gin.Context, user usermanagement.UserDetails) (int64, error) {
+					assert.Equal(t, config.CUSTOMER, user.UserType.String)
+					return int64(1), nil
+				}).Times(1)
+			},
+			expectedOutput: UserSignupResponse{
+				Status:  true,
+				Message: "successfully signed up user",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting User Signup TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.UserSignup(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			assert.Equal(t, tt.expectedOutput.Message, tt.actualOutput.Message)
+
+			fmt.Println("Ending User Signup TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_UserLogin(t *testing.T) {
+	var (
+		dbObj v1.V1DBLayer
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	hash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	user := usermanagement.UserDetails{
+		UserId:       sql.NullInt64{Int64: 1, Valid: true},
+		UserName:     sql.NullString{String: "testuser", Valid: true},
+		UserPassword: sql.NullString{String: string(hash), Valid: true},
+		UserType:     sql.NullString{String: config.CUSTOMER, Valid: true},
+	}
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          UserLoginRequest
+		setup          func(*gin.Context, UserLoginRequest)
+		expectedOutput UserLoginResponse
+		actualOutput   UserLoginResponse
+	}{
+		{
+			name: "UnknownUsername",
+			input: UserLoginRequest{
+				UserName: "nobody",
+				Password: "123456",
+			},
+			setup: func(c *gin.Context, data UserLoginRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginThrottles(c, gomock.Any()).Return([]usermanagement.LoginThrottle{}, nil).Times(1)
+				repo.EXPECT().GetUserByUsername(c, data.UserName).Return(usermanagement.UserDetails{}, nil).Times(1)
+				repo.EXPECT().AddLoginFailure(c, gomock.Any(), gomock.Any(), gomock.Any()).Return(usermanagement.LoginThrottle{Failures: sql.NullInt64{Int64: 1, Valid: true}}, nil).Times(2)
+			},
+			expectedOutput: UserLoginResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.UnAuthorized].Code}},
+				Message: "failed to login user",
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "WrongPasswordLocksUser",
+			input: UserLoginRequest{
+				UserName: "testuser",
+				Password: "654321",
+			},
+			setup: func(c *gin.Context, data UserLoginRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginThrottles(c, gomock.Any()).Return([]usermanagement.LoginThrottle{}, nil).Times(1)
+				repo.EXPECT().GetUserByUsername(c, data.UserName).Return(user, nil).Times(1)
+				repo.EXPECT().AddLoginFailure(c, userSubject(data.UserName), gomock.Any(), gomock.Any()).Return(usermanagement.LoginThrottle{Failures: sql.NullInt64{Int64: maxUserFailures, Valid: true}}, nil).Times(1)
+				repo.EXPECT().AddLoginFailure(c, ipSubject(c.ClientIP()), gomock.Any(), gomock.Any()).Return(usermanagement.LoginThrottle{Failures: sql.NullInt64{Int64: 1, Valid: true}}, nil).Times(1)
+				repo.EXPECT().LockLogin(c, userSubject(data.UserName), gomock.Any()).Return(nil).Times(1)
+			},
+			expectedOutput: UserLoginResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.UnAuthorized].Code}},
+				Message: "failed to login user",
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "LockedUser",
+			input: UserLoginRequest{
+				UserName: "testuser",
+				Password: "123456",
+			},
+			setup: func(c *gin.Context, data UserLoginRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginThrottles(c, gomock.Any()).Return([]usermanagement.LoginThrottle{{
+					Subject:     sql.NullString{String: userSubject(data.UserName), Valid: true},
+					LockedUntil: sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+				}}, nil).Times(1)
+			},
+			expectedOutput: UserLoginResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.TooManyRequests].Code}},
+				Message: "failed to login user",
+			},
+			httpStatus: http.StatusTooManyRequests,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "ProgressiveDelay",
+			input: UserLoginRequest{
+				UserName: "testuser",
+				Password: "123456",
+			},
+			setup: func(c *gin.Context, data UserLoginRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginThrottles(c, gomock.Any()).Return([]usermanagement.LoginThrottle{{
+					Subject:       sql.NullString{String: ipSubject(c.ClientIP()), Valid: true},
+					Failures:      sql.NullInt64{Int64: 3, Valid: true},
+					LastFailureAt: sql.NullTime{Time: time.Now(), Valid: true},
+				}}, nil).Times(1)
+			},
+			expectedOutput: UserLoginResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.TooManyRequests].Code}},
+				Message: "failed to login user",
+			},
+			httpStatus: http.StatusTooManyRequests,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "LoginSuccess",
+			input: UserLoginRequest{
+				UserName: "testuser",
+				Password: "123456",
+			},
+			setup: func(c *gin.Context, data UserLoginRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginThrottles(c, gomock.Any()).Return([]usermanagement.LoginThrottle{}, nil).Times(1)
+				repo.EXPECT().GetUserByUsername(c, data.UserName).Return(user, nil).Times(1)
+				repo.EXPECT().ClearLoginFailures(c, []string{userSubject(data.UserName)}).Return(int64(1), nil).Times(1)
+				repo.EXPECT().GetTotp(c, int64(1)).Return(usermanagement.UserTotp{}, nil).Times(1)
+				repo.EXPECT().GetUserRoles(c, int64(1)).Return([]string{config.CUSTOMER}, nil).Times(1)
+				repo.EXPECT().AddRefreshToken(c, gomock.Any()).Return(int64(1), nil).Times(1)
+			},
+			expectedOutput: UserLoginResponse{
+				Status:  true,
+				Message: "successfully logged in user",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting User Login TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.UserLogin(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			assert.Equal(t, tt.expectedOutput.Message, tt.actualOutput.Message)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+			if tt.httpStatus == http.StatusTooManyRequests {
+				assert.NotEqual(t, "", w.Header().Get("Retry-After"))
+			}
+
+			fmt.Println("Ending User Login TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_UnlockUser(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	servObj := NewUserManagementService(repo, &captureNotifier{})
+
+	//username or ip is needed
+	w, ctx := getContext(http.MethodPost, UnlockUserRequest{})
+	servObj.UnlockUser(ctx)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, ctx = getContext(http.MethodPost, UnlockUserRequest{UserName: "testuser", Ip: "10.0.0.1"})
+	repo.EXPECT().ClearLoginFailures(ctx, []string{"user:testuser", "ip:10.0.0.1"}).Return(int64(2), nil).Times(1)
+	servObj.UnlockUser(ctx)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func Test_progressiveDelay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), progressiveDelay(0))
+	assert.Equal(t, baseDelay, progressiveDelay(1))
+	assert.Equal(t, 4*baseDelay, progressiveDelay(3))
+	assert.Equal(t, maxDelay, progressiveDelay(100))
+}
+
+func Test_userMgtService_loginRetryAfter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	servObj := &userMgtService{dbObj: repo}
+	_, ctx := getContext(http.MethodPost, nil)
+
+	//the locked username holds the login back longer than the delay of the ip
+	repo.EXPECT().GetLoginThrottles(ctx, []string{"user:testuser", "ip:10.0.0.1"}).Return([]usermanagement.LoginThrottle{
+		{
+			Subject:       sql.NullString{String: "ip:10.0.0.1", Valid: true},
+			Failures:      sql.NullInt64{Int64: 1, Valid: true},
+			LastFailureAt: sql.NullTime{Time: time.Now(), Valid: true},
+		},
+		{
+			Subject:     sql.NullString{String: "user:testuser", Valid: true},
+			LockedUntil: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+		},
+	}, nil).Times(1)
+	wait, subject, err := servObj.loginRetryAfter(ctx, "testuser", "10.0.0.1")
+	assert.Equal(t, nil, err)
+	assert.Equal(t, "user:testuser", subject)
+	assert.Equal(t, true, wait > 59*time.Minute)
+}
@@ -7,7 +7,7 @@ import (
 type UserSignupRequest struct {
 	UserName      string  `json:"username" binding:"required"`
 	Password      string  `json:"password" binding:"required,min=6"`
-	UserType      string  `json:"type" binding:"required,oneof=CUSTOMER ADMIN"`
+	UserType      string  `json:"type" binding:"omitempty,oneof=CUSTOMER"`
 	Email         string  `json:"email" binding:"required"`
 	Mobile        string  `json:"mobile" binding:"required"`
 	MonthlySalary float64 `json:"salary"`
@@ -39,6 +39,319 @@ type UserLoginResponse struct {
 }
 
 type UserLogin struct {
-	Token  string `json:"token"`
-	Expiry string `json:"expiry"`
+	Token                 string   `json:"token,omitempty"`
+	Expiry                string   `json:"expiry,omitempty"`
+	RefreshToken          string   `json:"refreshToken,omitempty"`
+	RefreshExpiry         string   `json:"refreshExpiry,omitempty"`
+	MfaRequired           bool     `json:"mfaRequired,omitempty"`
+	MfaEnrollmentRequired bool     `json:"mfaEnrollmentRequired,omitempty"`
+	ChallengeToken        string   `json:"challengeToken,omitempty"`
+	ChallengeExpiry       string   `json:"challengeExpiry,omitempty"`
+	RecoveryCodes         []string `json:"recoveryCodes,omitempty"`
+}
+
+type GetProfileResponse struct {
+	Data    *Profile  `json:"data,omitempty"`
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type Profile struct {
+	UserId         int64   `json:"userId"`
+	UserName       string  `json:"username"`
+	UserType       string  `json:"type"`
+	Email          string  `json:"email"`
+	Mobile         string  `json:"mobile"`
+	MonthlySalary  float64 `json:"salary"`
+	VerifiedIncome float64 `json:"verifiedSalary"`
+	BankBalance    float64 `json:"bankBalance"`
+	CreatedAt      string  `json:"createdAt,omitempty"`
+	UpdatedAt      string  `json:"updatedAt,omitempty"`
+}
+
+type UpdateProfileRequest struct {
+	UserId        int64    `json:"-"`
+	Email         *string  `json:"email" binding:"omitempty,email"`
+	Mobile        *string  `json:"mobile" binding:"omitempty,min=6"`
+	MonthlySalary *float64 `json:"salary" binding:"omitempty,min=0"`
+	BankBalance   *float64 `json:"bankBalance" binding:"omitempty,min=0"`
+}
+
+type UpdateProfileResponse struct {
+	Data    *UpdateProfile `json:"data,omitempty"`
+	Status  bool           `json:"success"`
+	Errors  []e.Error      `json:"errors,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+type UpdateProfile struct {
+	Updated       []string              `json:"updated,omitempty"`
+	Verifications []PendingVerification `json:"pendingVerifications,omitempty"`
+}
+
+type PendingVerification struct {
+	VerificationId int64  `json:"verificationId"`
+	Field          string `json:"field"`
+	ExpiresAt      string `json:"expiresAt"`
+}
+
+type VerifyContactRequest struct {
+	UserId         int64  `json:"-"`
+	VerificationId int64  `json:"verificationId" binding:"required"`
+	Otp            string `json:"otp" binding:"required,len=6,numeric"`
+}
+
+type VerifyContactResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type GetProfileHistoryResponse struct {
+	Data    []ProfileChange `json:"data,omitempty"`
+	Status  bool            `json:"success"`
+	Errors  []e.Error       `json:"errors,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+type ProfileChange struct {
+	Field     string `json:"field"`
+	OldValue  string `json:"oldValue"`
+	NewValue  string `json:"newValue"`
+	ChangedAt string `json:"changedAt"`
+}
+
+type ChangePasswordRequest struct {
+	UserId          int64  `json:"-"`
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	NewPassword     string `json:"newPassword" binding:"required,min=6,nefield=CurrentPassword"`
+}
+
+type ChangePasswordResponse struct {
+	Data    *UserLogin `json:"data,omitempty"`
+	Status  bool       `json:"success"`
+	Errors  []e.Error  `json:"errors,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type ForgotPasswordRequest struct {
+	UserName string `json:"username" binding:"required"`
+}
+
+type ForgotPasswordResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type ResetPasswordResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type RefreshSessionRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type RefreshSessionResponse struct {
+	Data    *UserLogin `json:"data,omitempty"`
+	Status  bool       `json:"success"`
+	Errors  []e.Error  `json:"errors,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type LogoutResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type AdminInviteRequest struct {
+	Email  string `json:"email" binding:"required,email"`
+	UserId int64  `json:"-"`
+}
+
+type AdminInviteResponse struct {
+	Data    *AdminInvite `json:"data,omitempty"`
+	Status  bool         `json:"success"`
+	Errors  []e.Error    `json:"errors,omitempty"`
+	Message string       `json:"message,omitempty"`
+}
+
+type AdminInvite struct {
+	InviteId   int64  `json:"inviteId"`
+	Email      string `json:"email"`
+	InviteCode string `json:"inviteCode"`
+	Expiry     string `json:"expiry"`
+}
+
+type AdminSignupRequest struct {
+	InviteCode string `json:"inviteCode" binding:"required"`
+	UserName   string `json:"username" binding:"required"`
+	Password   string `json:"password" binding:"required,min=6"`
+	Email      string `json:"email" binding:"required"`
+	Mobile     string `json:"mobile" binding:"required"`
+}
+
+type BootstrapAdminRequest struct {
+	UserName string
+	Password string
+	Email    string
+	Mobile   string
+}
+
+type RolesResponse struct {
+	Data    []Role    `json:"data,omitempty"`
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type AssignRolesRequest struct {
+	UserId int64    `json:"userId" binding:"required"`
+	Roles  []string `json:"roles" binding:"required,min=1"`
+}
+
+type AssignRolesResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type UnlockUserRequest struct {
+	UserName string `json:"username"`
+	Ip       string `json:"ip"`
+}
+
+type UnlockUserResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type TotpEnrollmentRequest struct {
+	ChallengeToken string `json:"challengeToken"`
+}
+
+type TotpEnrollmentResponse struct {
+	Data    *TotpEnrollment `json:"data,omitempty"`
+	Status  bool            `json:"success"`
+	Errors  []e.Error       `json:"errors,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+type TotpEnrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningUri string `json:"provisioningUri"`
+}
+
+type ActivateTotpRequest struct {
+	Code   string `json:"code" binding:"required"`
+	UserId int64  `json:"-"`
+}
+
+type ActivateTotpResponse struct {
+	Data    *TotpRecoveryCodes `json:"data,omitempty"`
+	Status  bool               `json:"success"`
+	Errors  []e.Error          `json:"errors,omitempty"`
+	Message string             `json:"message,omitempty"`
+}
+
+type TotpRecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type LoginChallengeRequest struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required"`
+}
+
+type CreateServiceAccountRequest struct {
+	UserId      int64  `json:"-"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type CreateServiceAccountResponse struct {
+	Data    *ServiceAccount `json:"data,omitempty"`
+	Status  bool            `json:"success"`
+	Errors  []e.Error       `json:"errors,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+type ServiceAccountsResponse struct {
+	Data    []ServiceAccount `json:"data,omitempty"`
+	Status  bool             `json:"success"`
+	Errors  []e.Error        `json:"errors,omitempty"`
+	Message string           `json:"message,omitempty"`
+}
+
+type ServiceAccount struct {
+	ServiceAccountId int64    `json:"serviceAccountId"`
+	Name             string   `json:"name"`
+	Description      string   `json:"description,omitempty"`
+	CreatedBy        int64    `json:"createdBy"`
+	CreatedAt        string   `json:"createdAt"`
+	Keys             []APIKey `json:"keys"`
+}
+
+type APIKey struct {
+	KeyId      int64    `json:"keyId"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expiresAt"`
+	LastUsedAt string   `json:"lastUsedAt,omitempty"`
+	RevokedAt  string   `json:"revokedAt,omitempty"`
+	CreatedBy  int64    `json:"createdBy"`
+	CreatedAt  string   `json:"createdAt"`
+}
+
+type CreateAPIKeyRequest struct {
+	UserId           int64    `json:"-"`
+	ServiceAccountId int64    `json:"serviceAccountId" binding:"required"`
+	Scopes           []string `json:"scopes" binding:"required,min=1"`
+	ExpiryDays       int      `json:"expiryDays" binding:"required,min=1"`
+}
+
+type CreateAPIKeyResponse struct {
+	Data    *CreatedAPIKey `json:"data,omitempty"`
+	Status  bool           `json:"success"`
+	Errors  []e.Error      `json:"errors,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+// CreatedAPIKey carries the only copy of the plain key. the db keeps its hash
+type CreatedAPIKey struct {
+	KeyId     int64    `json:"keyId"`
+	Key       string   `json:"key"`
+	Prefix    string   `json:"prefix"`
+	Scopes    []string `json:"scopes"`
+	ExpiresAt string   `json:"expiresAt"`
+}
+
+type RevokeAPIKeyRequest struct {
+	KeyId int64 `json:"keyId" binding:"required"`
+}
+
+type RevokeAPIKeyResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
 }
@@ -0,0 +1,212 @@
+package usermanagement
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/notifier"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ChangePassword sets a new password for the logged in user. all other sessions are invalidated and a fresh session is returned
+func (obj *userMgtService) ChangePassword(c *gin.Context) {
+	var (
+		request  ChangePasswordRequest
+		response ChangePasswordResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to change password"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_USER, request.UserId)
+
+	userDetail, err := obj.dbObj.GetUserById(c, request.UserId)
+	if err != nil {
+		log.Printf("failed to fetch user data. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to change password"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(userDetail.UserPassword.String), []byte(request.CurrentPassword)) != nil {
+		log.Println("invalid current password")
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("incorrect current password"))
+		response.Message = "failed to change password"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(request.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("unable to hash password. Error:%s", err.Error())
+		response.Errors = append(response.Errors, e.ErrorInfo[e.ConversionError].GetErrorDetails("failed to hash the password"))
+		response.Message = "failed to change password"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	tokenVersion, err := obj.dbObj.UpdatePassword(c, request.UserId, string(hashedPasswordBytes))
+	if err != nil {
+		log.Printf("failed to update password. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to change password"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	session, err := obj.issueSession(c, userDetail, tokenVersion)
+	if err != nil {
+		log.Printf("failed to generate session. Error: %s", err.Error())
+		response.Errors = append(response.Errors, e.ErrorInfo[e.DefaultError].GetErrorDetails("password changed. login again"))
+		response.Message = "failed to generate JWT token"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = session
+	response.Message = "successfully changed password"
+	c.JSON(http.StatusOK, response)
+}
+
+// ForgotPassword sends a single use reset token to the registered email. the response never reveals whether the user exists
+func (obj *userMgtService) ForgotPassword(c *gin.Context) {
+	var (
+		request  ForgotPasswordRequest
+		response ForgotPasswordResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to request password reset"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	userDetail, err := obj.dbObj.GetUserByUsername(c, request.UserName)
+	if err != nil {
+		log.Printf("failed to fetch user data. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to request password reset"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Message = "if the account exists, a password reset token has been sent to the registered email"
+	if userDetail.UserId.Int64 == 0 {
+		log.Println("password reset requested for unknown username")
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	token, err := generateResetToken()
+	if err != nil {
+		log.Printf("failed to generate reset token. Error: %s", err.Error())
+		response.Status = false
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to request password reset"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	expiresAt := time.Now().Add(RESET_TOKEN_EXPIRY)
+	_, err = obj.dbObj.AddPasswordResetToken(c, usermanagement.PasswordResetToken{
+		UserId:    userDetail.UserId,
+		TokenHash: sql.NullString{String: hashResetToken(token), Valid: true},
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		log.Printf("failed to store reset token. Error: %s", err.Error())
+		response.Status = false
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to request password reset"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	err = obj.notifier.Send(c, notifier.Message{
+		Channel: notifier.EMAIL,
+		To:      userDetail.Email.String,
+		Subject: "Reset your password",
+		Body:    fmt.Sprintf("Use the token %s to reset your password. It expires in %d minutes and can be used once.", token, int(RESET_TOKEN_EXPIRY.Minutes())),
+	})
+	if err != nil {
+		log.Printf("failed to send reset token. Error: %s", err.Error())
+		response.Status = false
+		response.Errors = append(response.Errors, e.ErrorInfo[e.DefaultError].GetErrorDetails("failed to send password reset token"))
+		response.Message = "failed to request password reset"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword sets a new password using a reset token and invalidates every JWT issued to the user
+func (obj *userMgtService) ResetPassword(c *gin.Context) {
+	var (
+		request  ResetPasswordRequest
+		response ResetPasswordResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to reset password"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	hashedPasswordBytes, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Printf("unable to hash password. Error:%s", err.Error())
+		response.Errors = append(response.Errors, e.ErrorInfo[e.ConversionError].GetErrorDetails("failed to hash the password"))
+		response.Message = "failed to reset password"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	userId, err := obj.dbObj.ResetPassword(c, hashResetToken(request.Token), string(hashedPasswordBytes))
+	if err != nil {
+		log.Printf("failed to reset password. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to reset password"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if userId == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("invalid or expired reset token"))
+		response.Message = "failed to reset password"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	log.Printf("password reset for UserId: %d. existing sessions invalidated", userId)
+	audit.Target(c, audit.TARGET_USER, userId)
+	response.Status = true
+	response.Message = "successfully reset password. login with the new password"
+	c.JSON(http.StatusOK, response)
+}
+
+func generateResetToken() (string, error) {
+	return generateRandomToken(RESET_TOKEN_BYTES)
+}
+
+// hashResetToken keeps only a digest of the token in the db. the token has enough entropy for a plain sha256
+func hashResetToken(token string) string {
+	return hashToken(token)
+}
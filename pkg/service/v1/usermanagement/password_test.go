@@ -0,0 +1,236 @@
+package usermanagement
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userMgtService_ForgotPassword(t *testing.T) {
+	var (
+		dbObj v1.V1DBLayer
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          ForgotPasswordRequest
+		setup          func(*gin.Context, ForgotPasswordRequest)
+		notifications  int
+		expectedOutput ForgotPasswordResponse
+		actualOutput   ForgotPasswordResponse
+	}{
+		{
+			name: "UnknownUserGetsSameResponse",
+			input: ForgotPasswordRequest{
+				UserName: "nobody",
+			},
+			setup: func(c *gin.Context, data ForgotPasswordRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserByUsername(c, data.UserName).Return(usermanagement.UserDetails{}, nil).Times(1)
+			},
+			expectedOutput: ForgotPasswordResponse{
+				Status:  true,
+				Message: "if the account exists, a password reset token has been sent to the registered email",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "ResetTokenSent",
+			input: ForgotPasswordRequest{
+				UserName: "testuser",
+			},
+			setup: func(c *gin.Context, data ForgotPasswordRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserByUsername(c, data.UserName).Return(usermanagement.UserDetails{
+					UserId: sql.NullInt64{Int64: 1, Valid: true},
+					Email:  sql.NullString{String: "test@example.com", Valid: true},
+				}, nil).Times(1)
+				repo.EXPECT().AddPasswordResetToken(c, gomock.Any()).Return(int64(1), nil).Times(1)
+			},
+			notifications: 1,
+			expectedOutput: ForgotPasswordResponse{
+				Status:  true,
+				Message: "if the account exists, a password reset token has been sent to the registered email",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Forgot Password TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			capture := &captureNotifier{}
+			servObj := NewUserManagementService(dbObj, capture)
+
+			//calling the function
+			servObj.ForgotPassword(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+			assert.Equal(t, tt.notifications, len(capture.messages))
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			assert.Equal(t, tt.expectedOutput.Message, tt.actualOutput.Message)
+
+			fmt.Println("Ending Forgot Password TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_ResetPassword(t *testing.T) {
+	var (
+		dbObj v1.V1DBLayer
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          ResetPasswordRequest
+		setup          func(*gin.Context, ResetPasswordRequest)
+		expectedOutput ResetPasswordResponse
+		actualOutput   ResetPasswordResponse
+	}{
+		{
+			name: "ShortPassword",
+			input: ResetPasswordRequest{
+				Token:    "abc",
+				Password: "123",
+			},
+			setup: func(c *gin.Context, data ResetPasswordRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+			},
+			expectedOutput: ResetPasswordResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to reset password",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "InvalidToken",
+			input: ResetPasswordRequest{
+				Token:    "abc",
+				Password: "123456",
+			},
+			setup: func(c *gin.Context, data ResetPasswordRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().ResetPassword(c, hashResetToken(data.Token), gomock.Any()).Return(int64(0), nil).Times(1)
+			},
+			expectedOutput: ResetPasswordResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to reset password",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "ResetPasswordSuccess",
+			input: ResetPasswordRequest{
+				Token:    "abc",
+				Password: "123456",
+			},
+			setup: func(c *gin.Context, data ResetPasswordRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().ResetPassword(c, hashResetToken(data.Token), gomock.Any()).Return(int64(1), nil).Times(1)
+			},
+			expectedOutput: ResetPasswordResponse{
+				Status:  true,
+				Message: "successfully reset password. login with the new password",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Reset Password TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.ResetPassword(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+
+			fmt.Println("Ending Reset Password TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_hashResetToken(t *testing.T) {
+	token, err := generateResetToken()
+	assert.Equal(t, nil, err)
+	assert.Equal(t, RESET_TOKEN_BYTES*2, len(token))
+	assert.Equal(t, hashResetToken(token), hashResetToken(token))
+	assert.Equal(t, false, strings.Contains(hashResetToken(token), token))
+}
@@ -0,0 +1,74 @@
+package usermanagement
+
+import (
+	"aspire-assignment/pkg/config"
+	"log"
+	"time"
+)
+
+// login throttling defaults used when the auth.lockout settings are absent
+const (
+	DEFAULT_MAX_USER_FAILURES = 5
+	DEFAULT_MAX_IP_FAILURES   = 20
+	DEFAULT_FAILURE_WINDOW    = 15 * time.Minute
+	DEFAULT_LOCKOUT_DURATION  = 15 * time.Minute
+	DEFAULT_BASE_DELAY        = 1 * time.Second
+	DEFAULT_MAX_DELAY         = 30 * time.Second
+	DEFAULT_TOTP_ISSUER       = "Aspire"
+)
+
+var (
+	// maxUserFailures and maxIpFailures are the failed logins within failureWindow that lock the username or the client ip
+	maxUserFailures int64 = DEFAULT_MAX_USER_FAILURES
+	maxIpFailures   int64 = DEFAULT_MAX_IP_FAILURES
+	failureWindow         = DEFAULT_FAILURE_WINDOW
+	lockoutDuration       = DEFAULT_LOCKOUT_DURATION
+	// baseDelay doubles with every failure up to maxDelay before the next attempt is allowed
+	baseDelay = DEFAULT_BASE_DELAY
+	maxDelay  = DEFAULT_MAX_DELAY
+	// totpRequiredForAdmin makes users whose roles grant admin permissions enroll and use TOTP before a session is issued
+	totpRequiredForAdmin bool
+	totpIssuer           = DEFAULT_TOTP_ISSUER
+)
+
+func InitLoginPolicy() {
+	confi := config.GetConfig()
+	if value := confi.GetInt64("auth.lockout.max_user_failures"); value > 0 {
+		maxUserFailures = value
+	}
+	if value := confi.GetInt64("auth.lockout.max_ip_failures"); value > 0 {
+		maxIpFailures = value
+	}
+	if value := confi.GetDuration("auth.lockout.failure_window"); value > 0 {
+		failureWindow = value
+	}
+	if value := confi.GetDuration("auth.lockout.duration"); value > 0 {
+		lockoutDuration = value
+	}
+	if value := confi.GetDuration("auth.lockout.base_delay"); value > 0 {
+		baseDelay = value
+	}
+	if value := confi.GetDuration("auth.lockout.max_delay"); value > 0 {
+		maxDelay = value
+	}
+	totpRequiredForAdmin = confi.GetBool("auth.totp.required_for_admin")
+	if value := confi.GetString("auth.totp.issuer"); value != "" {
+		totpIssuer = value
+	}
+	log.Println("InitLoginPolicy successful")
+}
+
+// progressiveDelay is the wait enforced after the given number of consecutive failures
+func progressiveDelay(failures int64) time.Duration {
+	if failures <= 0 {
+		return 0
+	}
+	delay := baseDelay
+	for i := int64(1); i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
@@ -0,0 +1,342 @@
+package usermanagement
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/notifier"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (obj *userMgtService) GetProfile(c *gin.Context) {
+	var (
+		response GetProfileResponse
+	)
+	userId := c.GetInt64(config.USERID)
+
+	userDetail, err := obj.dbObj.GetUserById(c, userId)
+	if err != nil {
+		log.Printf("failed to fetch user data. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch profile"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if userDetail.UserId.Int64 == 0 {
+		log.Printf("user not found. UserId: %d", userId)
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.NoDataFound])
+		response.Message = "failed to fetch profile"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	verifiedIncome, err := obj.dbObj.GetLatestVerifiedIncome(c, userId)
+	if err != nil {
+		log.Printf("failed to fetch verified income. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch profile"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = &Profile{
+		UserId:         userDetail.UserId.Int64,
+		UserName:       userDetail.UserName.String,
+		UserType:       userDetail.UserType.String,
+		Email:          userDetail.Email.String,
+		Mobile:         userDetail.Mobile.String,
+		MonthlySalary:  userDetail.MonthlySalary.Float64,
+		VerifiedIncome: verifiedIncome,
+		BankBalance:    userDetail.AccountBalance.Float64,
+		CreatedAt:      userDetail.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      userDetail.UpdatedAt.Time.Format("2006-01-02 15:04:05"),
+	}
+	response.Message = "successfully fetched profile"
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateProfile applies salary and balance changes right away. email and mobile changes only take effect once
+// the OTP sent to the new contact is verified through VerifyContact
+func (obj *userMgtService) UpdateProfile(c *gin.Context) {
+	var (
+		request  UpdateProfileRequest
+		response UpdateProfileResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to update profile"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+
+	userDetail, err := obj.dbObj.GetUserById(c, request.UserId)
+	if err != nil {
+		log.Printf("failed to fetch user data. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to update profile"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if userDetail.UserId.Int64 == 0 {
+		log.Printf("user not found. UserId: %d", request.UserId)
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.NoDataFound])
+		response.Message = "failed to update profile"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	//financial details are self declared and applied without verification
+	changes := make([]usermanagement.ProfileChange, 0)
+	if request.MonthlySalary != nil && *request.MonthlySalary != userDetail.MonthlySalary.Float64 {
+		changes = append(changes, newProfileChange(request.UserId, FIELD_SALARY, formatAmount(userDetail.MonthlySalary.Float64), formatAmount(*request.MonthlySalary)))
+	}
+	if request.BankBalance != nil && *request.BankBalance != userDetail.AccountBalance.Float64 {
+		changes = append(changes, newProfileChange(request.UserId, FIELD_BANK_BALANCE, formatAmount(userDetail.AccountBalance.Float64), formatAmount(*request.BankBalance)))
+	}
+
+	//contact details need an OTP sent to the new contact
+	contacts := make(map[string]string)
+	if request.Email != nil && *request.Email != userDetail.Email.String {
+		contacts[FIELD_EMAIL] = *request.Email
+	}
+	if request.Mobile != nil && *request.Mobile != userDetail.Mobile.String {
+		contacts[FIELD_MOBILE] = *request.Mobile
+	}
+
+	if len(changes) == 0 && len(contacts) == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("no profile changes requested"))
+		response.Message = "failed to update profile"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response.Data = &UpdateProfile{}
+	if len(changes) != 0 {
+		if err := obj.dbObj.UpdateUserProfile(c, request.UserId, changes); err != nil {
+			log.Printf("failed to update profile. Error: %s", err.Error())
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+			response.Message = "failed to update profile"
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+		for _, change := range changes {
+			response.Data.Updated = append(response.Data.Updated, change.Field.String)
+		}
+	}
+
+	for _, field := range []string{FIELD_EMAIL, FIELD_MOBILE} {
+		newValue, ok := contacts[field]
+		if !ok {
+			continue
+		}
+		verification, err := obj.startContactVerification(c, request.UserId, field, newValue)
+		if err != nil {
+			log.Printf("failed to start %s verification. Error: %s", field, err.Error())
+			response.Errors = append(response.Errors, e.ErrorInfo[e.DefaultError].GetErrorDetails("failed to send verification code"))
+			response.Message = "failed to update profile"
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+		response.Data.Verifications = append(response.Data.Verifications, verification)
+	}
+
+	response.Status = true
+	response.Message = "successfully updated profile"
+	if len(response.Data.Verifications) != 0 {
+		response.Message = "successfully updated profile. contact changes pending verification"
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (obj *userMgtService) startContactVerification(c *gin.Context, userId int64, field string, newValue string) (PendingVerification, error) {
+	var pending PendingVerification
+
+	otp, err := generateOtp()
+	if err != nil {
+		return pending, err
+	}
+	otpHash, err := bcrypt.GenerateFromPassword([]byte(otp), bcrypt.DefaultCost)
+	if err != nil {
+		return pending, err
+	}
+
+	expiresAt := time.Now().Add(OTP_EXPIRY)
+	verificationId, err := obj.dbObj.AddContactVerification(c, usermanagement.ContactVerification{
+		UserId:    sql.NullInt64{Int64: userId, Valid: true},
+		Channel:   sql.NullString{String: field, Valid: true},
+		NewValue:  sql.NullString{String: newValue, Valid: true},
+		OtpHash:   sql.NullString{String: string(otpHash), Valid: true},
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		return pending, err
+	}
+
+	channel := notifier.EMAIL
+	if field == FIELD_MOBILE {
+		channel = notifier.SMS
+	}
+	err = obj.notifier.Send(c, notifier.Message{
+		Channel: channel,
+		To:      newValue,
+		Subject: "Verify your contact details",
+		Body:    fmt.Sprintf("Your verification code is %s. It expires in %d minutes.", otp, int(OTP_EXPIRY.Minutes())),
+	})
+	if err != nil {
+		return pending, err
+	}
+
+	pending.VerificationId = verificationId
+	pending.Field = field
+	pending.ExpiresAt = expiresAt.Format("2006-01-02 15:04:05")
+	return pending, nil
+}
+
+func (obj *userMgtService) VerifyContact(c *gin.Context) {
+	var (
+		request  VerifyContactRequest
+		response VerifyContactResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to verify contact"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+
+	verification, err := obj.dbObj.GetContactVerification(c, request.VerificationId, request.UserId)
+	if err != nil {
+		log.Printf("failed to fetch contact verification. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to verify contact"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if verification.VerificationId.Int64 == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.NoDataFound].GetErrorDetails("verification not found"))
+		response.Message = "failed to verify contact"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+	if verification.Status.String != VERIFICATION_PENDING || time.Now().After(verification.ExpiresAt.Time) || verification.Attempts.Int64 >= OTP_MAX_ATTEMPTS {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("verification code expired. request a new one by updating the profile again"))
+		response.Message = "failed to verify contact"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	//every attempt is counted before the otp is checked, and the count refuses attempts past the limit, so guesses
+	//sent at once cannot get more attempts than allowed
+	counted, err := obj.dbObj.IncrementContactVerificationAttempts(c, request.VerificationId, OTP_MAX_ATTEMPTS)
+	if err != nil {
+		log.Printf("failed to record verification attempt. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to verify contact"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !counted {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("verification code expired. request a new one by updating the profile again"))
+		response.Message = "failed to verify contact"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(verification.OtpHash.String), []byte(request.Otp)) != nil {
+		log.Printf("incorrect otp for verification %d", request.VerificationId)
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("incorrect verification code"))
+		response.Message = "failed to verify contact"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	if err := obj.dbObj.CompleteContactVerification(c, verification); err != nil {
+		log.Printf("failed to complete contact verification. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to verify contact"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Message = "successfully verified contact"
+	c.JSON(http.StatusOK, response)
+}
+
+func (obj *userMgtService) GetProfileHistory(c *gin.Context) {
+	var (
+		response GetProfileHistoryResponse
+	)
+	userId := c.GetInt64(config.USERID)
+
+	changes, err := obj.dbObj.GetProfileHistory(c, userId)
+	if err != nil {
+		log.Printf("failed to fetch profile history. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch profile history"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if len(changes) == 0 {
+		response.Message = "no profile changes available"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	response.Data = make([]ProfileChange, 0)
+	for _, change := range changes {
+		response.Data = append(response.Data, ProfileChange{
+			Field:     change.Field.String,
+			OldValue:  change.OldValue.String,
+			NewValue:  change.NewValue.String,
+			ChangedAt: change.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+		})
+	}
+	response.Status = true
+	response.Message = "successfully fetched profile history"
+	c.JSON(http.StatusOK, response)
+}
+
+func newProfileChange(userId int64, field string, oldValue string, newValue string) usermanagement.ProfileChange {
+	return usermanagement.ProfileChange{
+		UserId:    sql.NullInt64{Int64: userId, Valid: true},
+		Field:     sql.NullString{String: field, Valid: true},
+		OldValue:  sql.NullString{String: oldValue, Valid: true},
+		NewValue:  sql.NullString{String: newValue, Valid: true},
+		ChangedBy: sql.NullInt64{Int64: userId, Valid: true},
+	}
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
+
+// generateOtp returns a random numeric code of OTP_LENGTH digits
+func generateOtp() (string, error) {
+	max := big.NewInt(1)
+	for i := 0; i < OTP_LENGTH; i++ {
+		max.Mul(max, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, max)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", OTP_LENGTH, n.Int64()), nil
+}
@@ -0,0 +1,385 @@
+package usermanagement
+
+import (
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/notifier"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type captureNotifier struct {
+	messages []notifier.Message
+}
+
+func (obj *captureNotifier) Send(ctx context.Context, msg notifier.Message) error {
+	obj.messages = append(obj.messages, msg)
+	return nil
+}
+
+func Test_userMgtService_UpdateProfile(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	salary := 5000.0
+	email := "new@example.com"
+	userDetail := usermanagement.UserDetails{
+		UserId:         sql.NullInt64{Int64: userId, Valid: true},
+		UserName:       sql.NullString{String: "testuser", Valid: true},
+		Email:          sql.NullString{String: "old@example.com", Valid: true},
+		Mobile:         sql.NullString{String: "9999999999", Valid: true},
+		MonthlySalary:  sql.NullFloat64{Float64: 4000, Valid: true},
+		AccountBalance: sql.NullFloat64{Float64: 100, Valid: true},
+	}
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          UpdateProfileRequest
+		setup          func(*gin.Context, UpdateProfileRequest)
+		notifications  int
+		expectedOutput UpdateProfileResponse
+		actualOutput   UpdateProfileResponse
+	}{
+		{
+			name:  "NoChanges",
+			input: UpdateProfileRequest{},
+			setup: func(c *gin.Context, data UpdateProfileRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserById(c, userId).Return(userDetail, nil).Times(1)
+			},
+			expectedOutput: UpdateProfileResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to update profile",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPut,
+		},
+		{
+			name: "FailToUpdateSalary",
+			input: UpdateProfileRequest{
+				MonthlySalary: &salary,
+			},
+			setup: func(c *gin.Context, data UpdateProfileRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserById(c, userId).Return(userDetail, nil).Times(1)
+				repo.EXPECT().UpdateUserProfile(c, userId, gomock.Any()).Return(fmt.Errorf("db error")).Times(1)
+			},
+			expectedOutput: UpdateProfileResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.AddDBError].ErrName,
+					Description: e.ErrorInfo[e.AddDBError].Description,
+					Code:        e.ErrorInfo[e.AddDBError].Code,
+				}},
+				Message: "failed to update profile",
+			},
+			httpStatus: http.StatusInternalServerError,
+			httpMethod: http.MethodPut,
+		},
+		{
+			name: "SalaryAndEmailChange",
+			input: UpdateProfileRequest{
+				MonthlySalary: &salary,
+				Email:         &email,
+			},
+			setup: func(c *gin.Context, data UpdateProfileRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserById(c, userId).Return(userDetail, nil).Times(1)
+				repo.EXPECT().UpdateUserProfile(c, userId, []usermanagement.ProfileChange{
+					newProfileChange(userId, FIELD_SALARY, "4000", "5000"),
+				}).Return(nil).Times(1)
+				repo.EXPECT().AddContactVerification(c, gomock.Any()).Return(int64(9), nil).Times(1)
+			},
+			notifications: 1,
+			expectedOutput: UpdateProfileResponse{
+				Status: true,
+				Data: &UpdateProfile{
+					Updated:       []string{FIELD_SALARY},
+					Verifications: []PendingVerification{{VerificationId: 9, Field: FIELD_EMAIL}},
+				},
+				Message: "successfully updated profile. contact changes pending verification",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPut,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Update Profile TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			capture := &captureNotifier{}
+			servObj := NewUserManagementService(dbObj, capture)
+
+			//calling the function
+			servObj.UpdateProfile(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+			assert.Equal(t, tt.notifications, len(capture.messages))
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+			if tt.expectedOutput.Data != nil {
+				assert.Equal(t, tt.expectedOutput.Data.Updated, tt.actualOutput.Data.Updated)
+				assert.Equal(t, len(tt.expectedOutput.Data.Verifications), len(tt.actualOutput.Data.Verifications))
+				assert.Equal(t, email, capture.messages[0].To)
+			}
+
+			fmt.Println("Ending Update Profile TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_VerifyContact(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	otpHash, _ := bcrypt.GenerateFromPassword([]byte("123456"), bcrypt.MinCost)
+	verification := usermanagement.ContactVerification{
+		VerificationId: sql.NullInt64{Int64: 9, Valid: true},
+		UserId:         sql.NullInt64{Int64: userId, Valid: true},
+		Channel:        sql.NullString{String: FIELD_EMAIL, Valid: true},
+		NewValue:       sql.NullString{String: "new@example.com", Valid: true},
+		OtpHash:        sql.NullString{String: string(otpHash), Valid: true},
+		Status:         sql.NullString{String: VERIFICATION_PENDING, Valid: true},
+		ExpiresAt:      sql.NullTime{Time: time.Now().Add(OTP_EXPIRY), Valid: true},
+	}
+	expired := verification
+	expired.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          VerifyContactRequest
+		setup          func(*gin.Context, VerifyContactRequest)
+		expectedOutput VerifyContactResponse
+		actualOutput   VerifyContactResponse
+	}{
+		{
+			name: "VerificationNotFound",
+			input: VerifyContactRequest{
+				VerificationId: 9,
+				Otp:            "123456",
+			},
+			setup: func(c *gin.Context, data VerifyContactRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetContactVerification(c, data.VerificationId, userId).Return(usermanagement.ContactVerification{}, nil).Times(1)
+			},
+			expectedOutput: VerifyContactResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.NoDataFound].ErrName,
+					Description: e.ErrorInfo[e.NoDataFound].Description,
+					Code:        e.ErrorInfo[e.NoDataFound].Code,
+				}},
+				Message: "failed to verify contact",
+			},
+			httpStatus: http.StatusNotFound,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "ExpiredOtp",
+			input: VerifyContactRequest{
+				VerificationId: 9,
+				Otp:            "123456",
+			},
+			setup: func(c *gin.Context, data VerifyContactRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetContactVerification(c, data.VerificationId, userId).Return(expired, nil).Times(1)
+			},
+			expectedOutput: VerifyContactResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to verify contact",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "IncorrectOtp",
+			input: VerifyContactRequest{
+				VerificationId: 9,
+				Otp:            "654321",
+			},
+			setup: func(c *gin.Context, data VerifyContactRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetContactVerification(c, data.VerificationId, userId).Return(verification, nil).Times(1)
+				repo.EXPECT().IncrementContactVerificationAttempts(c, data.VerificationId, int64(OTP_MAX_ATTEMPTS)).Return(true, nil).Times(1)
+			},
+			expectedOutput: VerifyContactResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to verify contact",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "AttemptsUsedConcurrently",
+			input: VerifyContactRequest{
+				VerificationId: 9,
+				Otp:            "123456",
+			},
+			setup: func(c *gin.Context, data VerifyContactRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				//the last attempt was taken by another request after the verification was read
+				repo.EXPECT().GetContactVerification(c, data.VerificationId, userId).Return(verification, nil).Times(1)
+				repo.EXPECT().IncrementContactVerificationAttempts(c, data.VerificationId, int64(OTP_MAX_ATTEMPTS)).Return(false, nil).Times(1)
+			},
+			expectedOutput: VerifyContactResponse{
+				Status: false,
+				Errors: []e.Error{{
+					ErrName:     e.ErrorInfo[e.BadRequest].ErrName,
+					Description: e.ErrorInfo[e.BadRequest].Description,
+					Code:        e.ErrorInfo[e.BadRequest].Code,
+				}},
+				Message: "failed to verify contact",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name: "VerifyContactSuccess",
+			input: VerifyContactRequest{
+				VerificationId: 9,
+				Otp:            "123456",
+			},
+			setup: func(c *gin.Context, data VerifyContactRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetContactVerification(c, data.VerificationId, userId).Return(verification, nil).Times(1)
+				repo.EXPECT().IncrementContactVerificationAttempts(c, data.VerificationId, int64(OTP_MAX_ATTEMPTS)).Return(true, nil).Times(1)
+				repo.EXPECT().CompleteContactVerification(c, verification).Return(nil).Times(1)
+			},
+			expectedOutput: VerifyContactResponse{
+				Status:  true,
+				Message: "successfully verified contact",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Verify Contact TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.VerifyContact(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+
+			fmt.Println("Ending Verify Contact TestCase: ", tt.name)
+		})
+	}
+}
+
+func getContext(method string, data interface{}) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+	byteData, err := json.Marshal(data)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	temp.Request, err = http.NewRequest(method, "/", bytes.NewBuffer(byteData))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//add headers
+	temp.Request.Header = http.Header{}
+	temp.Request.Header.Set("Content-Type", "application/json")
+
+	return recorder, temp
+}
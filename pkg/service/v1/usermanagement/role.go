@@ -0,0 +1,121 @@
+package usermanagement
+
+import (
+	"log"
+	"net/http"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRoles lists every role with the permissions it grants
+func (obj *userMgtService) GetRoles(c *gin.Context) {
+	var (
+		response RolesResponse
+	)
+
+	roles, err := obj.dbObj.GetRoles(c)
+	if err != nil {
+		log.Printf("failed to fetch roles. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch roles"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Data = make([]Role, 0)
+	for _, role := range roles {
+		response.Data = append(response.Data, Role{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+	response.Status = true
+	response.Message = "successfully fetched roles"
+	c.JSON(http.StatusOK, response)
+}
+
+// AssignUserRoles replaces the role set of a user. the user has to login again for the new roles to apply
+func (obj *userMgtService) AssignUserRoles(c *gin.Context) {
+	var (
+		request  AssignRolesRequest
+		response AssignRolesResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to assign roles"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	audit.Target(c, audit.TARGET_USER, request.UserId)
+
+	//an admin removing their own role assignment could lock every admin out
+	if request.UserId == c.GetInt64(config.USERID) {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("cannot change own roles"))
+		response.Message = "failed to assign roles"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	roles := uniqueRoles(request.Roles)
+	previous, err := obj.dbObj.GetUserRoles(c, request.UserId)
+	if err != nil {
+		log.Printf("failed to fetch user roles. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to assign roles"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	assigned, err := obj.dbObj.SetUserRoles(c, request.UserId, roles)
+	if err != nil {
+		log.Printf("failed to assign roles. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to assign roles"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if assigned != int64(len(roles)) {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("unknown role"))
+		response.Message = "failed to assign roles"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	log.Printf("roles %v assigned to UserId: %d by UserId: %d", roles, request.UserId, c.GetInt64(config.USERID))
+	audit.Change(c, map[string]interface{}{"roles": previous}, map[string]interface{}{"roles": roles})
+	response.Status = true
+	response.Message = "successfully assigned roles"
+	c.JSON(http.StatusOK, response)
+}
+
+// HasPermission reports whether any of the roles grants the permission
+func (obj *userMgtService) HasPermission(c *gin.Context, roles []string, permission string) (bool, error) {
+	permissions, err := obj.dbObj.GetRolePermissions(c, roles)
+	if err != nil {
+		return false, err
+	}
+	for _, granted := range permissions {
+		if granted == permission {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func uniqueRoles(roles []string) []string {
+	seen := make(map[string]bool)
+	unique := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if !seen[role] {
+			seen[role] = true
+			unique = append(unique, role)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,144 @@
+package usermanagement
+
+import (
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	e "aspire-assignment/pkg/errors"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userMgtService_AssignUserRoles(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          AssignRolesRequest
+		setup          func(*gin.Context, AssignRolesRequest)
+		expectedOutput AssignRolesResponse
+		actualOutput   AssignRolesResponse
+	}{
+		{
+			name: "OwnRoles",
+			input: AssignRolesRequest{
+				UserId: userId,
+				Roles:  []string{"AUDITOR"},
+			},
+			setup: func(c *gin.Context, data AssignRolesRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+			},
+			expectedOutput: AssignRolesResponse{
+				Status:  false,
+				Message: "failed to assign roles",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPut,
+		},
+		{
+			name: "UnknownRole",
+			input: AssignRolesRequest{
+				UserId: 2,
+				Roles:  []string{"AUDITOR", "UNKNOWN"},
+			},
+			setup: func(c *gin.Context, data AssignRolesRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserRoles(c, data.UserId).Return([]string{"CUSTOMER"}, nil).Times(1)
+				repo.EXPECT().SetUserRoles(c, data.UserId, data.Roles).Return(int64(0), nil).Times(1)
+			},
+			expectedOutput: AssignRolesResponse{
+				Status:  false,
+				Message: "failed to assign roles",
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPut,
+		},
+		{
+			name: "AssignRolesSuccess",
+			input: AssignRolesRequest{
+				UserId: 2,
+				Roles:  []string{"AUDITOR", "SUPPORT", "AUDITOR"},
+			},
+			setup: func(c *gin.Context, data AssignRolesRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetUserRoles(c, data.UserId).Return([]string{"CUSTOMER"}, nil).Times(1)
+				repo.EXPECT().SetUserRoles(c, data.UserId, []string{"AUDITOR", "SUPPORT"}).Return(int64(2), nil).Times(1)
+			},
+			expectedOutput: AssignRolesResponse{
+				Status:  true,
+				Message: "successfully assigned roles",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPut,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Assign Roles TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.AssignUserRoles(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			assert.Equal(t, tt.expectedOutput.Message, tt.actualOutput.Message)
+
+			fmt.Println("Ending Assign Roles TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_HasPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	_, ctx := getContext(http.MethodGet, nil)
+
+	repo.EXPECT().GetRolePermissions(ctx, []string{"AUDITOR"}).Return([]string{auth.LOAN_READ_ANY, auth.DOCUMENT_READ_ANY}, nil).Times(2)
+	servObj := NewUserManagementService(repo, &captureNotifier{})
+
+	allowed, err := servObj.HasPermission(ctx, []string{"AUDITOR"}, auth.LOAN_READ_ANY)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, allowed)
+
+	//auditors can read but not approve
+	allowed, err = servObj.HasPermission(ctx, []string{"AUDITOR"}, auth.LOAN_APPROVE)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, allowed)
+}
@@ -0,0 +1,295 @@
+package usermanagement
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateServiceAccount adds a non human identity that batch jobs and other services authenticate as with API keys
+func (obj *userMgtService) CreateServiceAccount(c *gin.Context) {
+	var (
+		request  CreateServiceAccountRequest
+		response CreateServiceAccountResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to create service account"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+
+	accountId, err := obj.dbObj.AddServiceAccount(c, usermanagement.ServiceAccount{
+		Name:        sql.NullString{String: request.Name, Valid: true},
+		Description: sql.NullString{String: request.Description, Valid: true},
+		CreatedBy:   sql.NullInt64{Int64: request.UserId, Valid: true},
+	})
+	if err != nil {
+		log.Printf("failed to add service account. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to create service account"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if accountId == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("service account already exists"))
+		response.Message = "failed to create service account"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	log.Printf("service account %s created by UserId: %d", request.Name, request.UserId)
+	audit.Target(c, audit.TARGET_SERVICE_ACCOUNT, accountId)
+	audit.Change(c, nil, map[string]interface{}{"name": request.Name, "description": request.Description})
+	response.Status = true
+	response.Data = &ServiceAccount{
+		ServiceAccountId: accountId,
+		Name:             request.Name,
+		Description:      request.Description,
+		CreatedBy:        request.UserId,
+		CreatedAt:        time.Now().Format("2006-01-02 15:04:05"),
+		Keys:             make([]APIKey, 0),
+	}
+	response.Message = "successfully created service account"
+	c.JSON(http.StatusOK, response)
+}
+
+// GetServiceAccounts lists the service accounts with their API keys. key secrets are never returned
+func (obj *userMgtService) GetServiceAccounts(c *gin.Context) {
+	var (
+		response ServiceAccountsResponse
+	)
+
+	accounts, err := obj.dbObj.GetServiceAccounts(c)
+	if err != nil {
+		log.Printf("failed to fetch service accounts. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch service accounts"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	keys, err := obj.dbObj.GetAPIKeys(c)
+	if err != nil {
+		log.Printf("failed to fetch api keys. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch service accounts"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	accountKeys := make(map[int64][]APIKey)
+	for _, key := range keys {
+		apiKey := APIKey{
+			KeyId:     key.KeyId.Int64,
+			Prefix:    key.Prefix.String,
+			Scopes:    key.Scopes,
+			ExpiresAt: key.ExpiresAt.Time.Format("2006-01-02 15:04:05"),
+			CreatedBy: key.CreatedBy.Int64,
+			CreatedAt: key.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+		}
+		if key.LastUsedAt.Valid {
+			apiKey.LastUsedAt = key.LastUsedAt.Time.Format("2006-01-02 15:04:05")
+		}
+		if key.RevokedAt.Valid {
+			apiKey.RevokedAt = key.RevokedAt.Time.Format("2006-01-02 15:04:05")
+		}
+		accountKeys[key.ServiceAccountId.Int64] = append(accountKeys[key.ServiceAccountId.Int64], apiKey)
+	}
+
+	response.Data = make([]ServiceAccount, 0)
+	for _, account := range accounts {
+		accountKey := accountKeys[account.ServiceAccountId.Int64]
+		if accountKey == nil {
+			accountKey = make([]APIKey, 0)
+		}
+		response.Data = append(response.Data, ServiceAccount{
+			ServiceAccountId: account.ServiceAccountId.Int64,
+			Name:             account.Name.String,
+			Description:      account.Description.String,
+			CreatedBy:        account.CreatedBy.Int64,
+			CreatedAt:        account.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+			Keys:             accountKey,
+		})
+	}
+	response.Status = true
+	response.Message = "successfully fetched service accounts"
+	c.JSON(http.StatusOK, response)
+}
+
+// CreateAPIKey issues a scoped and expiring key for a service account. the plain key is returned only in this response
+func (obj *userMgtService) CreateAPIKey(c *gin.Context) {
+	var (
+		request  CreateAPIKeyRequest
+		response CreateAPIKeyResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to create api key"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_SERVICE_ACCOUNT, request.ServiceAccountId)
+
+	if request.ExpiryDays > API_KEY_MAX_EXPIRY_DAYS {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("api keys expire within 365 days"))
+		response.Message = "failed to create api key"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	scopes := uniqueRoles(request.Scopes)
+	for _, scope := range scopes {
+		if !isServiceAccountScope(scope) {
+			response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("scope not allowed for api keys: "+scope))
+			response.Message = "failed to create api key"
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+	}
+
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		log.Printf("failed to generate api key. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to create api key"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	expiresAt := time.Now().AddDate(0, 0, request.ExpiryDays)
+	keyId, err := obj.dbObj.AddAPIKey(c, usermanagement.APIKey{
+		ServiceAccountId: sql.NullInt64{Int64: request.ServiceAccountId, Valid: true},
+		Prefix:           sql.NullString{String: prefix, Valid: true},
+		KeyHash:          sql.NullString{String: hashToken(key), Valid: true},
+		Scopes:           scopes,
+		ExpiresAt:        sql.NullTime{Time: expiresAt, Valid: true},
+		CreatedBy:        sql.NullInt64{Int64: request.UserId, Valid: true},
+	})
+	if err != nil {
+		log.Printf("failed to store api key. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to create api key"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if keyId == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("unknown service account"))
+		response.Message = "failed to create api key"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	log.Printf("api key %s with scopes %v issued for ServiceAccountId: %d by UserId: %d", prefix, scopes, request.ServiceAccountId, request.UserId)
+	audit.Target(c, audit.TARGET_API_KEY, keyId)
+	audit.Change(c, nil, map[string]interface{}{"serviceAccountId": request.ServiceAccountId, "prefix": prefix, "scopes": scopes, "expiresAt": expiresAt.Format("2006-01-02 15:04:05")})
+	response.Status = true
+	response.Data = &CreatedAPIKey{
+		KeyId:     keyId,
+		Key:       key,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt.Format("2006-01-02 15:04:05"),
+	}
+	response.Message = "successfully created api key. store it now, it cannot be shown again"
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey stops a key from authenticating immediately
+func (obj *userMgtService) RevokeAPIKey(c *gin.Context) {
+	var (
+		request  RevokeAPIKeyRequest
+		response RevokeAPIKeyResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to revoke api key"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	audit.Target(c, audit.TARGET_API_KEY, request.KeyId)
+	revoked, err := obj.dbObj.RevokeAPIKey(c, request.KeyId)
+	if err != nil {
+		log.Printf("failed to revoke api key. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to revoke api key"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !revoked {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("unknown or already revoked api key"))
+		response.Message = "failed to revoke api key"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	log.Printf("api key %d revoked by UserId: %d", request.KeyId, c.GetInt64(config.USERID))
+	response.Status = true
+	response.Message = "successfully revoked api key"
+	c.JSON(http.StatusOK, response)
+}
+
+// AuthenticateAPIKey resolves a key sent by a service account and records when it was last used
+func (obj *userMgtService) AuthenticateAPIKey(c *gin.Context, key string) (auth.APIKey, bool, error) {
+	if !strings.HasPrefix(key, API_KEY_PREFIX) {
+		return auth.APIKey{}, false, nil
+	}
+
+	stored, err := obj.dbObj.GetAPIKey(c, hashToken(key))
+	if err != nil {
+		return auth.APIKey{}, false, err
+	}
+	now := time.Now()
+	if stored.KeyId.Int64 == 0 || stored.RevokedAt.Valid || !stored.ExpiresAt.Time.After(now) {
+		return auth.APIKey{}, false, nil
+	}
+
+	if err := obj.dbObj.TouchAPIKey(c, stored.KeyId.Int64, now, now.Add(-API_KEY_TOUCH_INTERVAL)); err != nil {
+		//usage tracking must not fail the request
+		log.Printf("failed to track api key usage. Error: %s", err.Error())
+	}
+
+	return auth.APIKey{
+		KeyId:            stored.KeyId.Int64,
+		Prefix:           stored.Prefix.String,
+		ServiceAccountId: stored.ServiceAccountId.Int64,
+		ServiceAccount:   stored.ServiceAccount.String,
+		Scopes:           stored.Scopes,
+	}, true, nil
+}
+
+// generateAPIKey returns the plain key and its prefix. the prefix identifies the key in listings and logs
+func generateAPIKey() (string, string, error) {
+	prefix, err := generateRandomToken(API_KEY_PREFIX_BYTES)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := generateRandomToken(API_KEY_SECRET_BYTES)
+	if err != nil {
+		return "", "", err
+	}
+	return API_KEY_PREFIX + prefix + "_" + secret, prefix, nil
+}
+
+func isServiceAccountScope(scope string) bool {
+	for _, allowed := range auth.ServiceAccountScopes {
+		if scope == allowed {
+			return true
+		}
+	}
+	return false
+}
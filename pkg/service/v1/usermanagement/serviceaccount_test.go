@@ -0,0 +1,179 @@
+package usermanagement
+
+import (
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userMgtService_CreateAPIKey(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		httpMethod string
+		httpStatus int
+		input      CreateAPIKeyRequest
+		setup      func(*gin.Context, CreateAPIKeyRequest)
+	}{
+		{
+			name:  "MissingScopes",
+			input: CreateAPIKeyRequest{ServiceAccountId: 2, ExpiryDays: 30},
+			setup: func(c *gin.Context, data CreateAPIKeyRequest) {
+				ctrl := gomock.NewController(t)
+				dbObj = dbmock.NewMockV1DBLayer(ctrl)
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "OwnScopeNotAllowed",
+			input: CreateAPIKeyRequest{ServiceAccountId: 2, Scopes: []string{auth.PAYMENT_CREATE_OWN}, ExpiryDays: 30},
+			setup: func(c *gin.Context, data CreateAPIKeyRequest) {
+				ctrl := gomock.NewController(t)
+				dbObj = dbmock.NewMockV1DBLayer(ctrl)
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "ExpiryTooLong",
+			input: CreateAPIKeyRequest{ServiceAccountId: 2, Scopes: []string{auth.PAYMENT_CREATE_ANY}, ExpiryDays: API_KEY_MAX_EXPIRY_DAYS + 1},
+			setup: func(c *gin.Context, data CreateAPIKeyRequest) {
+				ctrl := gomock.NewController(t)
+				dbObj = dbmock.NewMockV1DBLayer(ctrl)
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "UnknownServiceAccount",
+			input: CreateAPIKeyRequest{ServiceAccountId: 2, Scopes: []string{auth.PAYMENT_CREATE_ANY}, ExpiryDays: 30},
+			setup: func(c *gin.Context, data CreateAPIKeyRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().AddAPIKey(c, gomock.Any()).Return(int64(0), nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "Success",
+			input: CreateAPIKeyRequest{ServiceAccountId: 2, Scopes: []string{auth.PAYMENT_CREATE_ANY, auth.PAYMENT_CREATE_ANY}, ExpiryDays: 30},
+			setup: func(c *gin.Context, data CreateAPIKeyRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().AddAPIKey(c, gomock.Any()).DoAndReturn(func(c *gin.Context, key usermanagement.APIKey) (int64, error) {
+					assert.Equal(t, []string{auth.PAYMENT_CREATE_ANY}, key.Scopes)
+					assert.Equal(t, userId, key.CreatedBy.Int64)
+					return 5, nil
+				}).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Create API Key TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.CreateAPIKey(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response CreateAPIKeyResponse
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			if tt.httpStatus == http.StatusOK {
+				assert.Equal(t, int64(5), response.Data.KeyId)
+				assert.Equal(t, true, strings.HasPrefix(response.Data.Key, API_KEY_PREFIX+response.Data.Prefix+"_"))
+			}
+
+			fmt.Println("Ending Create API Key TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_AuthenticateAPIKey(t *testing.T) {
+	const key = "ak_0a1b2c3d_secret"
+	active := usermanagement.APIKey{
+		KeyId:            sql.NullInt64{Int64: 5, Valid: true},
+		ServiceAccountId: sql.NullInt64{Int64: 2, Valid: true},
+		ServiceAccount:   sql.NullString{String: "collections-batch", Valid: true},
+		Prefix:           sql.NullString{String: "0a1b2c3d", Valid: true},
+		Scopes:           []string{auth.PAYMENT_CREATE_ANY},
+		ExpiresAt:        sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+	expired := active
+	expired.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+	revoked := active
+	revoked.RevokedAt = sql.NullTime{Time: time.Now(), Valid: true}
+
+	tests := []struct {
+		name   string
+		key    string
+		stored *usermanagement.APIKey
+		valid  bool
+	}{
+		{name: "NotAnAPIKey", key: "secret", valid: false},
+		{name: "UnknownKey", key: key, stored: &usermanagement.APIKey{}, valid: false},
+		{name: "ExpiredKey", key: key, stored: &expired, valid: false},
+		{name: "RevokedKey", key: key, stored: &revoked, valid: false},
+		{name: "ActiveKey", key: key, stored: &active, valid: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			repo := dbmock.NewMockV1DBLayer(ctrl)
+			_, ctx := getContext(http.MethodGet, nil)
+			if tt.stored != nil {
+				repo.EXPECT().GetAPIKey(ctx, hashToken(tt.key)).Return(*tt.stored, nil).Times(1)
+			}
+			if tt.valid {
+				repo.EXPECT().TouchAPIKey(ctx, int64(5), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+			}
+			servObj := NewUserManagementService(repo, &captureNotifier{})
+
+			apiKey, valid, err := servObj.AuthenticateAPIKey(ctx, tt.key)
+			assert.Equal(t, nil, err)
+			assert.Equal(t, tt.valid, valid)
+			if tt.valid {
+				assert.Equal(t, "collections-batch", apiKey.ServiceAccount)
+				assert.Equal(t, []string{auth.PAYMENT_CREATE_ANY}, apiKey.Scopes)
+			}
+		})
+	}
+}
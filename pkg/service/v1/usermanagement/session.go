@@ -0,0 +1,287 @@
+package usermanagement
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefreshSession exchanges a refresh token for a new access token and a new refresh token.
+// presenting a token that was already rotated is treated as theft and ends the whole token family
+func (obj *userMgtService) RefreshSession(c *gin.Context) {
+	var (
+		request  RefreshSessionRequest
+		response RefreshSessionResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	refreshToken, err := obj.dbObj.GetRefreshToken(c, hashToken(request.RefreshToken))
+	if err != nil {
+		log.Printf("failed to fetch refresh token. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if refreshToken.TokenId.Int64 == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid refresh token"))
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	if refreshToken.Status.String != REFRESH_ACTIVE {
+		obj.revokeReusedFamily(c, refreshToken)
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("refresh token reused, login again"))
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+	if refreshToken.ExpiresAt.Time.Before(time.Now()) {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("refresh token expired, login again"))
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	userDetail, err := obj.dbObj.GetUserById(c, refreshToken.UserId.Int64)
+	if err != nil {
+		log.Printf("failed to fetch user data. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	plainToken, nextToken, err := newRefreshToken(refreshToken.UserId.Int64, refreshToken.FamilyId.String)
+	if err != nil {
+		log.Printf("failed to generate refresh token. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	rotated, err := obj.dbObj.RotateRefreshToken(c, refreshToken.TokenId.Int64, nextToken)
+	if err != nil {
+		log.Printf("failed to rotate refresh token. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !rotated {
+		//another request rotated the same token first
+		obj.revokeReusedFamily(c, refreshToken)
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("refresh token reused, login again"))
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	roles, err := obj.dbObj.GetUserRoles(c, userDetail.UserId.Int64)
+	if err != nil {
+		log.Printf("failed to fetch user roles. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	token, exp, err := generateSessionToken(userDetail, userDetail.TokenVersion.Int64, roles)
+	if err != nil {
+		log.Printf("failed to generate JWT. Error: %s", err.Error())
+		response.Errors = append(response.Errors, e.ErrorInfo[e.DefaultError].GetErrorDetails("failed to generate JWT token"))
+		response.Message = "failed to refresh session"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = &UserLogin{
+		Token:         token,
+		Expiry:        exp.Format("2006-01-02 15:04:05"),
+		RefreshToken:  plainToken,
+		RefreshExpiry: nextToken.ExpiresAt.Time.Format("2006-01-02 15:04:05"),
+	}
+	response.Message = "successfully refreshed session"
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout revokes the access token used for the call and, when given, the refresh token family of the session
+func (obj *userMgtService) Logout(c *gin.Context) {
+	var (
+		request  LogoutRequest
+		response LogoutResponse
+	)
+	if c.Request.ContentLength > 0 {
+		if err := c.BindJSON(&request); err != nil {
+			log.Printf("unable to marshal request. Error:%s", err.Error())
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+			response.Message = "failed to logout"
+			c.JSON(http.StatusBadRequest, response)
+			return
+		}
+	}
+	userId := c.GetInt64(config.USERID)
+
+	err := obj.dbObj.RevokeAccessToken(c, c.GetString(config.TOKENID), userId, c.GetTime(config.TOKENEXPIRY))
+	if err != nil {
+		log.Printf("failed to revoke access token. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to logout"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	if request.RefreshToken != "" {
+		refreshToken, err := obj.dbObj.GetRefreshToken(c, hashToken(request.RefreshToken))
+		if err != nil {
+			log.Printf("failed to fetch refresh token. Error: %s", err.Error())
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+			response.Message = "failed to logout"
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+
+		//a refresh token of another user is ignored rather than revealed
+		if refreshToken.UserId.Int64 == userId {
+			err = obj.dbObj.RevokeRefreshTokenFamily(c, refreshToken.FamilyId.String)
+			if err != nil {
+				log.Printf("failed to revoke refresh token. Error: %s", err.Error())
+				response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+				response.Message = "failed to logout"
+				c.JSON(http.StatusInternalServerError, response)
+				return
+			}
+		}
+	}
+
+	response.Status = true
+	response.Message = "successfully logged out"
+	c.JSON(http.StatusOK, response)
+}
+
+// IsSessionValid rejects tokens issued before the latest password change of the user and tokens revoked on logout
+func (obj *userMgtService) IsSessionValid(c *gin.Context, token auth.Token) (bool, error) {
+	tokenVersion, err := obj.dbObj.GetTokenVersion(c, token.UserId)
+	if err != nil {
+		return false, err
+	}
+	if tokenVersion != token.TokenVersion {
+		return false, nil
+	}
+
+	revoked, err := obj.dbObj.IsAccessTokenRevoked(c, token.TokenId)
+	if err != nil {
+		return false, err
+	}
+	return !revoked, nil
+}
+
+// issueSession creates the access token with the current roles of the user and starts a new refresh token family for the user
+func (obj *userMgtService) issueSession(c *gin.Context, userDetail usermanagement.UserDetails, tokenVersion int64) (*UserLogin, error) {
+	roles, err := obj.dbObj.GetUserRoles(c, userDetail.UserId.Int64)
+	if err != nil {
+		return nil, err
+	}
+
+	token, exp, err := generateSessionToken(userDetail, tokenVersion, roles)
+	if err != nil {
+		return nil, err
+	}
+
+	familyId, err := generateRandomToken(TOKEN_ID_BYTES)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, refreshDetail, err := newRefreshToken(userDetail.UserId.Int64, familyId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := obj.dbObj.AddRefreshToken(c, refreshDetail); err != nil {
+		return nil, err
+	}
+
+	return &UserLogin{
+		Token:         token,
+		Expiry:        exp.Format("2006-01-02 15:04:05"),
+		RefreshToken:  refreshToken,
+		RefreshExpiry: refreshDetail.ExpiresAt.Time.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// revokeReusedFamily ends the family of a refresh token presented after it was rotated
+func (obj *userMgtService) revokeReusedFamily(c *gin.Context, refreshToken usermanagement.RefreshToken) {
+	log.Printf("refresh token reuse detected for UserId: %d. revoking family", refreshToken.UserId.Int64)
+	if err := obj.dbObj.RevokeRefreshTokenFamily(c, refreshToken.FamilyId.String); err != nil {
+		log.Printf("failed to revoke refresh token family. Error: %s", err.Error())
+	}
+}
+
+// generateSessionToken issues the JWT for a logged in user bound to the given token version and carrying the role set
+func generateSessionToken(userDetail usermanagement.UserDetails, tokenVersion int64, roles []string) (string, time.Time, error) {
+	tokenId, err := generateRandomToken(TOKEN_ID_BYTES)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	exp := time.Now().Add(auth.AccessTokenTTL())
+	payload := auth.Token{
+		UserName:     userDetail.UserName.String,
+		UserId:       userDetail.UserId.Int64,
+		UserType:     userDetail.UserType.String,
+		Roles:        roles,
+		TokenVersion: tokenVersion,
+		TokenId:      tokenId,
+		Exp:          exp,
+	}
+
+	token, err := auth.GenerateJWT(payload)
+	return token, exp, err
+}
+
+// newRefreshToken returns the plain token for the client and its hashed record for the db
+func newRefreshToken(userId int64, familyId string) (string, usermanagement.RefreshToken, error) {
+	token, err := generateRandomToken(REFRESH_TOKEN_BYTES)
+	if err != nil {
+		return "", usermanagement.RefreshToken{}, err
+	}
+	return token, usermanagement.RefreshToken{
+		UserId:    sql.NullInt64{Int64: userId, Valid: true},
+		TokenHash: sql.NullString{String: hashToken(token), Valid: true},
+		FamilyId:  sql.NullString{String: familyId, Valid: true},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(auth.RefreshTokenTTL()), Valid: true},
+	}, nil
+}
+
+func generateRandomToken(size int) (string, error) {
+	b := make([]byte, size)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashToken keeps only a digest of random tokens in the db. the tokens have enough entropy for a plain sha256
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
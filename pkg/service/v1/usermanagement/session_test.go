@@ -0,0 +1,322 @@
+package usermanagement
+
+import (
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userMgtService_RefreshSession(t *testing.T) {
+	var (
+		dbObj v1.V1DBLayer
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	activeToken := usermanagement.RefreshToken{
+		TokenId:   sql.NullInt64{Int64: 1, Valid: true},
+		UserId:    sql.NullInt64{Int64: 1, Valid: true},
+		FamilyId:  sql.NullString{String: "family", Valid: true},
+		Status:    sql.NullString{String: REFRESH_ACTIVE, Valid: true},
+		ExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true},
+	}
+	rotatedToken := activeToken
+	rotatedToken.Status = sql.NullString{String: REFRESH_ROTATED, Valid: true}
+	expiredToken := activeToken
+	expiredToken.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          RefreshSessionRequest
+		setup          func(*gin.Context, RefreshSessionRequest)
+		expectedOutput RefreshSessionResponse
+		actualOutput   RefreshSessionResponse
+	}{
+		{
+			name:  "UnknownToken",
+			input: RefreshSessionRequest{RefreshToken: "abc"},
+			setup: func(c *gin.Context, data RefreshSessionRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetRefreshToken(c, hashToken(data.RefreshToken)).Return(usermanagement.RefreshToken{}, nil).Times(1)
+			},
+			expectedOutput: RefreshSessionResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.UnAuthorized].Code}},
+				Message: "failed to refresh session",
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "ReusedTokenRevokesFamily",
+			input: RefreshSessionRequest{RefreshToken: "abc"},
+			setup: func(c *gin.Context, data RefreshSessionRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetRefreshToken(c, hashToken(data.RefreshToken)).Return(rotatedToken, nil).Times(1)
+				repo.EXPECT().RevokeRefreshTokenFamily(c, "family").Return(nil).Times(1)
+			},
+			expectedOutput: RefreshSessionResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.UnAuthorized].Code}},
+				Message: "failed to refresh session",
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "ExpiredToken",
+			input: RefreshSessionRequest{RefreshToken: "abc"},
+			setup: func(c *gin.Context, data RefreshSessionRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetRefreshToken(c, hashToken(data.RefreshToken)).Return(expiredToken, nil).Times(1)
+			},
+			expectedOutput: RefreshSessionResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.UnAuthorized].Code}},
+				Message: "failed to refresh session",
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "ConcurrentRotationRevokesFamily",
+			input: RefreshSessionRequest{RefreshToken: "abc"},
+			setup: func(c *gin.Context, data RefreshSessionRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetRefreshToken(c, hashToken(data.RefreshToken)).Return(activeToken, nil).Times(1)
+				repo.EXPECT().GetUserById(c, int64(1)).Return(usermanagement.UserDetails{UserId: sql.NullInt64{Int64: 1, Valid: true}}, nil).Times(1)
+				repo.EXPECT().RotateRefreshToken(c, int64(1), gomock.Any()).Return(false, nil).Times(1)
+				repo.EXPECT().RevokeRefreshTokenFamily(c, "family").Return(nil).Times(1)
+			},
+			expectedOutput: RefreshSessionResponse{
+				Status:  false,
+				Errors:  []e.Error{{Code: e.ErrorInfo[e.UnAuthorized].Code}},
+				Message: "failed to refresh session",
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "RefreshSuccess",
+			input: RefreshSessionRequest{RefreshToken: "abc"},
+			setup: func(c *gin.Context, data RefreshSessionRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetRefreshToken(c, hashToken(data.RefreshToken)).Return(activeToken, nil).Times(1)
+				repo.EXPECT().GetUserById(c, int64(1)).Return(usermanagement.UserDetails{UserId: sql.NullInt64{Int64: 1, Valid: true}}, nil).Times(1)
+				repo.EXPECT().RotateRefreshToken(c, int64(1), gomock.Any()).Return(true, nil).Times(1)
+				repo.EXPECT().GetUserRoles(c, int64(1)).Return([]string{"CUSTOMER"}, nil).Times(1)
+			},
+			expectedOutput: RefreshSessionResponse{
+				Status:  true,
+				Message: "successfully refreshed session",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Refresh Session TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.RefreshSession(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			assert.Equal(t, tt.expectedOutput.Message, tt.actualOutput.Message)
+			if len(tt.expectedOutput.Errors) != 0 {
+				assert.Equal(t, tt.expectedOutput.Errors[0].Code, tt.actualOutput.Errors[0].Code)
+			}
+			if tt.expectedOutput.Status {
+				assert.NotEqual(t, "", tt.actualOutput.Data.Token)
+				assert.NotEqual(t, tt.input.RefreshToken, tt.actualOutput.Data.RefreshToken)
+			}
+
+			fmt.Println("Ending Refresh Session TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_Logout(t *testing.T) {
+	var (
+		dbObj v1.V1DBLayer
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	expiry := time.Now().Add(time.Hour)
+	tests := []struct {
+		name           string
+		httpMethod     string
+		httpStatus     int
+		input          LogoutRequest
+		setup          func(*gin.Context, LogoutRequest)
+		expectedOutput LogoutResponse
+		actualOutput   LogoutResponse
+	}{
+		{
+			name:  "LogoutAccessTokenOnly",
+			input: LogoutRequest{},
+			setup: func(c *gin.Context, data LogoutRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().RevokeAccessToken(c, "jti", int64(1), expiry).Return(nil).Times(1)
+			},
+			expectedOutput: LogoutResponse{
+				Status:  true,
+				Message: "successfully logged out",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "RefreshTokenOfOtherUserIgnored",
+			input: LogoutRequest{RefreshToken: "abc"},
+			setup: func(c *gin.Context, data LogoutRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().RevokeAccessToken(c, "jti", int64(1), expiry).Return(nil).Times(1)
+				repo.EXPECT().GetRefreshToken(c, hashToken(data.RefreshToken)).Return(usermanagement.RefreshToken{
+					UserId:   sql.NullInt64{Int64: 2, Valid: true},
+					FamilyId: sql.NullString{String: "family", Valid: true},
+				}, nil).Times(1)
+			},
+			expectedOutput: LogoutResponse{
+				Status:  true,
+				Message: "successfully logged out",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "LogoutWithRefreshToken",
+			input: LogoutRequest{RefreshToken: "abc"},
+			setup: func(c *gin.Context, data LogoutRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().RevokeAccessToken(c, "jti", int64(1), expiry).Return(nil).Times(1)
+				repo.EXPECT().GetRefreshToken(c, hashToken(data.RefreshToken)).Return(usermanagement.RefreshToken{
+					UserId:   sql.NullInt64{Int64: 1, Valid: true},
+					FamilyId: sql.NullString{String: "family", Valid: true},
+				}, nil).Times(1)
+				repo.EXPECT().RevokeRefreshTokenFamily(c, "family").Return(nil).Times(1)
+			},
+			expectedOutput: LogoutResponse{
+				Status:  true,
+				Message: "successfully logged out",
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Logout TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+			ctx.Set(config.USERID, int64(1))
+			ctx.Set(config.TOKENID, "jti")
+			ctx.Set(config.TOKENEXPIRY, expiry)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.Logout(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			//create a copy of the output structure
+			err := json.Unmarshal(w.Body.Bytes(), &tt.actualOutput)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+
+			//compare expected vs actual output
+			assert.Equal(t, tt.expectedOutput.Status, tt.actualOutput.Status)
+			assert.Equal(t, tt.expectedOutput.Message, tt.actualOutput.Message)
+
+			fmt.Println("Ending Logout TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_IsSessionValid(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	_, ctx := getContext(http.MethodGet, nil)
+	ctx.Set(config.USERID, int64(1))
+
+	repo.EXPECT().GetTokenVersion(ctx, int64(1)).Return(int64(2), nil).Times(3)
+	repo.EXPECT().IsAccessTokenRevoked(ctx, "active").Return(false, nil).Times(1)
+	repo.EXPECT().IsAccessTokenRevoked(ctx, "loggedout").Return(true, nil).Times(1)
+	servObj := NewUserManagementService(repo, &captureNotifier{})
+
+	valid, err := servObj.IsSessionValid(ctx, auth.Token{UserId: 1, TokenVersion: 2, TokenId: "active"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, valid)
+
+	//token issued before the password was reset
+	valid, err = servObj.IsSessionValid(ctx, auth.Token{UserId: 1, TokenVersion: 1, TokenId: "active"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, valid)
+
+	//token revoked on logout
+	valid, err = servObj.IsSessionValid(ctx, auth.Token{UserId: 1, TokenVersion: 2, TokenId: "loggedout"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, valid)
+}
+
+func Test_generateSessionToken(t *testing.T) {
+	user := usermanagement.UserDetails{UserId: sql.NullInt64{Int64: 1, Valid: true}}
+	_, exp, err := generateSessionToken(user, 0, []string{"CUSTOMER"})
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, exp.After(time.Now().Add(auth.AccessTokenTTL()-time.Minute)))
+}
@@ -0,0 +1,389 @@
+package usermanagement
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnrollTotp starts totp enrollment for the logged in user. the secret is active only after ActivateTotp
+func (obj *userMgtService) EnrollTotp(c *gin.Context) {
+	var (
+		response TotpEnrollmentResponse
+	)
+	audit.Target(c, audit.TARGET_USER, c.GetInt64(config.USERID))
+	userDetail, err := obj.dbObj.GetUserById(c, c.GetInt64(config.USERID))
+	if err != nil {
+		log.Printf("failed to fetch user data. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	obj.enrollTotp(c, userDetail, &response)
+}
+
+// ActivateTotp enables the pending totp secret of the logged in user with a first valid code and returns the recovery codes
+func (obj *userMgtService) ActivateTotp(c *gin.Context) {
+	var (
+		request  ActivateTotpRequest
+		response ActivateTotpResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to activate totp"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+	audit.Target(c, audit.TARGET_USER, request.UserId)
+
+	totp, err := obj.dbObj.GetTotp(c, request.UserId)
+	if err != nil {
+		log.Printf("failed to fetch totp. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to activate totp"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !totp.Secret.Valid || totp.Enabled.Bool {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("no pending totp enrollment"))
+		response.Message = "failed to activate totp"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	recoveryCodes, valid, err := obj.activateTotp(c, totp, request.Code)
+	if err != nil {
+		log.Printf("failed to activate totp. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to activate totp"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !valid {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("invalid code"))
+		response.Message = "failed to activate totp"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = &TotpRecoveryCodes{RecoveryCodes: recoveryCodes}
+	response.Message = "successfully activated totp. store the recovery codes safely"
+	c.JSON(http.StatusOK, response)
+}
+
+// EnrollTotpChallenge starts totp enrollment during login for users who must use a second factor but have not enrolled yet
+func (obj *userMgtService) EnrollTotpChallenge(c *gin.Context) {
+	var (
+		request  TotpEnrollmentRequest
+		response TotpEnrollmentResponse
+	)
+	if err := c.BindJSON(&request); err != nil || request.ChallengeToken == "" {
+		log.Println("unable to marshal request or challenge token missing")
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	challenge, valid, err := obj.getLoginChallenge(c, request.ChallengeToken)
+	if err != nil {
+		log.Printf("failed to fetch login challenge. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !valid {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid or expired challenge, login again"))
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	audit.Target(c, audit.TARGET_USER, challenge.UserId.Int64)
+	userDetail, err := obj.dbObj.GetUserById(c, challenge.UserId.Int64)
+	if err != nil {
+		log.Printf("failed to fetch user data. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	audit.Identify(c, userDetail.UserType.String, userDetail.UserId.Int64, userDetail.UserName.String)
+
+	obj.enrollTotp(c, userDetail, &response)
+}
+
+// VerifyLoginChallenge completes a login with a totp or recovery code and issues the session.
+// a pending enrollment is activated by its first valid code and the recovery codes are returned along with the session
+func (obj *userMgtService) VerifyLoginChallenge(c *gin.Context) {
+	var (
+		request  LoginChallengeRequest
+		response UserLoginResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to login user"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	challenge, valid, err := obj.getLoginChallenge(c, request.ChallengeToken)
+	if err != nil {
+		log.Printf("failed to fetch login challenge. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to login user"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !valid {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid or expired challenge, login again"))
+		response.Message = "failed to login user"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	audit.Target(c, audit.TARGET_USER, challenge.UserId.Int64)
+	userDetail, err := obj.dbObj.GetUserById(c, challenge.UserId.Int64)
+	if err != nil {
+		log.Printf("failed to fetch user data. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to login user"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	totp, err := obj.dbObj.GetTotp(c, challenge.UserId.Int64)
+	if err != nil {
+		log.Printf("failed to fetch totp. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to login user"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	var recoveryCodes []string
+	if totp.Enabled.Bool {
+		valid, err = obj.verifySecondFactor(c, totp, request.Code)
+	} else if totp.Secret.Valid {
+		recoveryCodes, valid, err = obj.activateTotp(c, totp, request.Code)
+	} else {
+		valid = false
+	}
+	if err != nil {
+		log.Printf("failed to verify second factor. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to login user"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !valid {
+		log.Printf("invalid second factor for UserId: %d", challenge.UserId.Int64)
+		if err := obj.dbObj.IncrementLoginChallengeAttempts(c, challenge.ChallengeId.Int64); err != nil {
+			log.Printf("failed to update login challenge. Error: %s", err.Error())
+		}
+		if err := obj.recordLoginFailure(c, userDetail.UserName.String, c.ClientIP()); err != nil {
+			log.Printf("failed to record login failure. Error: %s", err.Error())
+		}
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid code"))
+		response.Message = "failed to login user"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	completed, err := obj.dbObj.CompleteLoginChallenge(c, challenge.ChallengeId.Int64)
+	if err != nil || !completed {
+		log.Println("login challenge already completed")
+		response.Errors = append(response.Errors, e.ErrorInfo[e.UnAuthorized].GetErrorDetails("invalid or expired challenge, login again"))
+		response.Message = "failed to login user"
+		c.JSON(http.StatusUnauthorized, response)
+		return
+	}
+
+	session, err := obj.issueSession(c, userDetail, userDetail.TokenVersion.Int64)
+	if err != nil {
+		log.Printf("failed to generate session. Error: %s", err.Error())
+		response.Errors = append(response.Errors, e.ErrorInfo[e.DefaultError].GetErrorDetails("failed to generate JWT token"))
+		response.Message = "failed to login user"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	session.RecoveryCodes = recoveryCodes
+
+	audit.Identify(c, userDetail.UserType.String, userDetail.UserId.Int64, userDetail.UserName.String)
+	response.Status = true
+	response.Data = session
+	response.Message = "successfully logged in user"
+	c.JSON(http.StatusOK, response)
+}
+
+// loginChallenge returns the second factor challenge for users with totp enabled, and for users whose roles grant
+// admin permissions when totp is mandatory. nil means the session can be issued right away
+func (obj *userMgtService) loginChallenge(c *gin.Context, userDetail usermanagement.UserDetails) (*UserLogin, error) {
+	totp, err := obj.dbObj.GetTotp(c, userDetail.UserId.Int64)
+	if err != nil {
+		return nil, err
+	}
+	enrolled := totp.Enabled.Bool
+	if !enrolled && !totpRequiredForAdmin {
+		return nil, nil
+	}
+	if !enrolled {
+		//roles are assigned per user, so a customer given an admin role must enroll too
+		required, err := obj.privileged(c, userDetail.UserId.Int64)
+		if err != nil || !required {
+			return nil, err
+		}
+	}
+
+	token, err := generateRandomToken(CHALLENGE_TOKEN_BYTES)
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(CHALLENGE_EXPIRY)
+	_, err = obj.dbObj.AddLoginChallenge(c, usermanagement.LoginChallenge{
+		UserId:    userDetail.UserId,
+		TokenHash: sql.NullString{String: hashToken(token), Valid: true},
+		ExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &UserLogin{
+		MfaRequired:           true,
+		MfaEnrollmentRequired: !enrolled,
+		ChallengeToken:        token,
+		ChallengeExpiry:       expiresAt.Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// privileged tells whether the roles of the user grant any permission beyond their own data
+func (obj *userMgtService) privileged(c *gin.Context, userId int64) (bool, error) {
+	roles, err := obj.dbObj.GetUserRoles(c, userId)
+	if err != nil || len(roles) == 0 {
+		return false, err
+	}
+	permissions, err := obj.dbObj.GetRolePermissions(c, roles)
+	if err != nil {
+		return false, err
+	}
+	return auth.Privileged(permissions), nil
+}
+
+// getLoginChallenge returns the challenge when it is unused, unexpired and has attempts left
+func (obj *userMgtService) getLoginChallenge(c *gin.Context, token string) (usermanagement.LoginChallenge, bool, error) {
+	challenge, err := obj.dbObj.GetLoginChallenge(c, hashToken(token))
+	if err != nil {
+		return challenge, false, err
+	}
+	valid := challenge.ChallengeId.Int64 != 0 &&
+		!challenge.UsedAt.Valid &&
+		challenge.ExpiresAt.Time.After(time.Now()) &&
+		challenge.Attempts.Int64 < CHALLENGE_MAX_ATTEMPTS
+	return challenge, valid, nil
+}
+
+// enrollTotp stores a new pending secret for the user and writes the provisioning details to the response
+func (obj *userMgtService) enrollTotp(c *gin.Context, userDetail usermanagement.UserDetails, response *TotpEnrollmentResponse) {
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		log.Printf("failed to generate totp secret. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	encrypted, err := auth.EncryptSecret(secret)
+	if err != nil {
+		log.Printf("failed to encrypt totp secret. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	saved, err := obj.dbObj.SaveTotpSecret(c, userDetail.UserId.Int64, encrypted)
+	if err != nil {
+		log.Printf("failed to save totp secret. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if !saved {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("totp already enabled"))
+		response.Message = "failed to enroll totp"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = &TotpEnrollment{
+		Secret:          secret,
+		ProvisioningUri: auth.TOTPProvisioningURI(totpIssuer, userDetail.UserName.String, secret),
+	}
+	response.Message = "scan the provisioning uri in an authenticator app and confirm with a code"
+	c.JSON(http.StatusOK, response)
+}
+
+// activateTotp enables a pending secret when the code is valid and returns fresh recovery codes
+func (obj *userMgtService) activateTotp(c *gin.Context, totp usermanagement.UserTotp, code string) ([]string, bool, error) {
+	secret, err := auth.DecryptSecret(totp.Secret.String)
+	if err != nil {
+		return nil, false, err
+	}
+	step, valid := auth.ValidateTOTP(secret, code, time.Now())
+	if !valid {
+		return nil, false, nil
+	}
+
+	recoveryCodes := make([]string, 0, RECOVERY_CODE_COUNT)
+	codeHashes := make([]string, 0, RECOVERY_CODE_COUNT)
+	for i := 0; i < RECOVERY_CODE_COUNT; i++ {
+		recoveryCode, err := generateRandomToken(RECOVERY_CODE_BYTES)
+		if err != nil {
+			return nil, false, err
+		}
+		recoveryCodes = append(recoveryCodes, recoveryCode)
+		codeHashes = append(codeHashes, hashToken(recoveryCode))
+	}
+
+	if err := obj.dbObj.EnableTotp(c, totp.UserId.Int64, step, codeHashes); err != nil {
+		return nil, false, err
+	}
+	log.Printf("totp enabled for UserId: %d", totp.UserId.Int64)
+	return recoveryCodes, true, nil
+}
+
+// verifySecondFactor accepts a totp code not used before or an unused recovery code
+func (obj *userMgtService) verifySecondFactor(c *gin.Context, totp usermanagement.UserTotp, code string) (bool, error) {
+	if len(code) != auth.TOTP_DIGITS {
+		return obj.dbObj.UseRecoveryCode(c, totp.UserId.Int64, hashToken(code))
+	}
+
+	secret, err := auth.DecryptSecret(totp.Secret.String)
+	if err != nil {
+		return false, err
+	}
+	step, valid := auth.ValidateTOTP(secret, code, time.Now())
+	if !valid {
+		return false, nil
+	}
+	//a code is accepted once so an observed code cannot be replayed within its window
+	return obj.dbObj.UseTotpStep(c, totp.UserId.Int64, step)
+}
@@ -0,0 +1,265 @@
+package usermanagement
+
+import (
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/usermanagement"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_userMgtService_loginChallenge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	_, ctx := getContext(http.MethodPost, nil)
+	servObj := &userMgtService{dbObj: repo, notifier: &captureNotifier{}}
+
+	totpRequiredForAdmin = true
+	defer func() { totpRequiredForAdmin = false }()
+
+	admin := usermanagement.UserDetails{
+		UserId:   sql.NullInt64{Int64: 1, Valid: true},
+		UserType: sql.NullString{String: config.ADMIN, Valid: true},
+	}
+	customer := usermanagement.UserDetails{
+		UserId:   sql.NullInt64{Int64: 2, Valid: true},
+		UserType: sql.NullString{String: config.CUSTOMER, Valid: true},
+	}
+
+	customerPermissions := []string{auth.LOAN_WRITE_OWN, auth.LOAN_READ_OWN}
+
+	//admins without totp must enroll before a session is issued
+	repo.EXPECT().GetTotp(ctx, int64(1)).Return(usermanagement.UserTotp{}, nil).Times(1)
+	repo.EXPECT().GetUserRoles(ctx, int64(1)).Return([]string{"ADMIN"}, nil).Times(1)
+	repo.EXPECT().GetRolePermissions(ctx, []string{"ADMIN"}).Return([]string{auth.LOAN_READ_ANY, auth.LOAN_APPROVE}, nil).Times(1)
+	repo.EXPECT().AddLoginChallenge(ctx, gomock.Any()).Return(int64(1), nil).Times(1)
+	challenge, err := servObj.loginChallenge(ctx, admin)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, challenge.MfaRequired)
+	assert.Equal(t, true, challenge.MfaEnrollmentRequired)
+	assert.NotEqual(t, "", challenge.ChallengeToken)
+
+	//customers without totp log in with the password alone
+	repo.EXPECT().GetTotp(ctx, int64(2)).Return(usermanagement.UserTotp{}, nil).Times(1)
+	repo.EXPECT().GetUserRoles(ctx, int64(2)).Return([]string{"CUSTOMER"}, nil).Times(1)
+	repo.EXPECT().GetRolePermissions(ctx, []string{"CUSTOMER"}).Return(customerPermissions, nil).Times(1)
+	challenge, err = servObj.loginChallenge(ctx, customer)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, challenge == nil)
+
+	//the roles decide, not the user type: a customer given a role with admin permissions must enroll too
+	repo.EXPECT().GetTotp(ctx, int64(2)).Return(usermanagement.UserTotp{}, nil).Times(1)
+	repo.EXPECT().GetUserRoles(ctx, int64(2)).Return([]string{"CUSTOMER", "AUDITOR"}, nil).Times(1)
+	repo.EXPECT().GetRolePermissions(ctx, []string{"CUSTOMER", "AUDITOR"}).Return(append(customerPermissions, auth.AUDIT_READ), nil).Times(1)
+	repo.EXPECT().AddLoginChallenge(ctx, gomock.Any()).Return(int64(3), nil).Times(1)
+	challenge, err = servObj.loginChallenge(ctx, customer)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, true, challenge.MfaEnrollmentRequired)
+
+	//customers who enrolled get the challenge too
+	repo.EXPECT().GetTotp(ctx, int64(2)).Return(usermanagement.UserTotp{Enabled: sql.NullBool{Bool: true, Valid: true}}, nil).Times(1)
+	repo.EXPECT().AddLoginChallenge(ctx, gomock.Any()).Return(int64(2), nil).Times(1)
+	challenge, err = servObj.loginChallenge(ctx, customer)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, false, challenge.MfaEnrollmentRequired)
+}
+
+func Test_userMgtService_VerifyLoginChallenge(t *testing.T) {
+	var (
+		dbObj v1.V1DBLayer
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	secret, _ := auth.GenerateTOTPSecret()
+	encrypted, _ := auth.EncryptSecret(secret)
+	code, _ := auth.GenerateTOTPCode(secret, time.Now())
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+
+	user := usermanagement.UserDetails{
+		UserId:   sql.NullInt64{Int64: 1, Valid: true},
+		UserName: sql.NullString{String: "testadmin", Valid: true},
+		UserType: sql.NullString{String: config.ADMIN, Valid: true},
+	}
+	challenge := usermanagement.LoginChallenge{
+		ChallengeId: sql.NullInt64{Int64: 1, Valid: true},
+		UserId:      sql.NullInt64{Int64: 1, Valid: true},
+		ExpiresAt:   sql.NullTime{Time: time.Now().Add(time.Minute), Valid: true},
+	}
+	expiredChallenge := challenge
+	expiredChallenge.ExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+	enabledTotp := usermanagement.UserTotp{
+		UserId:  sql.NullInt64{Int64: 1, Valid: true},
+		Secret:  sql.NullString{String: encrypted, Valid: true},
+		Enabled: sql.NullBool{Bool: true, Valid: true},
+	}
+	pendingTotp := enabledTotp
+	pendingTotp.Enabled = sql.NullBool{Bool: false, Valid: true}
+
+	tests := []struct {
+		name          string
+		httpMethod    string
+		httpStatus    int
+		input         LoginChallengeRequest
+		setup         func(*gin.Context, LoginChallengeRequest)
+		recoveryCodes int
+	}{
+		{
+			name:  "ExpiredChallenge",
+			input: LoginChallengeRequest{ChallengeToken: "abc", Code: code},
+			setup: func(c *gin.Context, data LoginChallengeRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginChallenge(c, hashToken(data.ChallengeToken)).Return(expiredChallenge, nil).Times(1)
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "WrongCode",
+			input: LoginChallengeRequest{ChallengeToken: "abc", Code: wrongCode},
+			setup: func(c *gin.Context, data LoginChallengeRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginChallenge(c, hashToken(data.ChallengeToken)).Return(challenge, nil).Times(1)
+				repo.EXPECT().GetUserById(c, int64(1)).Return(user, nil).Times(1)
+				repo.EXPECT().GetTotp(c, int64(1)).Return(enabledTotp, nil).Times(1)
+				repo.EXPECT().IncrementLoginChallengeAttempts(c, int64(1)).Return(nil).Times(1)
+				repo.EXPECT().AddLoginFailure(c, gomock.Any(), gomock.Any(), gomock.Any()).Return(usermanagement.LoginThrottle{}, nil).Times(2)
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "ReplayedCode",
+			input: LoginChallengeRequest{ChallengeToken: "abc", Code: code},
+			setup: func(c *gin.Context, data LoginChallengeRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginChallenge(c, hashToken(data.ChallengeToken)).Return(challenge, nil).Times(1)
+				repo.EXPECT().GetUserById(c, int64(1)).Return(user, nil).Times(1)
+				repo.EXPECT().GetTotp(c, int64(1)).Return(enabledTotp, nil).Times(1)
+				repo.EXPECT().UseTotpStep(c, int64(1), gomock.Any()).Return(false, nil).Times(1)
+				repo.EXPECT().IncrementLoginChallengeAttempts(c, int64(1)).Return(nil).Times(1)
+				repo.EXPECT().AddLoginFailure(c, gomock.Any(), gomock.Any(), gomock.Any()).Return(usermanagement.LoginThrottle{}, nil).Times(2)
+			},
+			httpStatus: http.StatusUnauthorized,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "RecoveryCode",
+			input: LoginChallengeRequest{ChallengeToken: "abc", Code: "0123456789abcdef"},
+			setup: func(c *gin.Context, data LoginChallengeRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginChallenge(c, hashToken(data.ChallengeToken)).Return(challenge, nil).Times(1)
+				repo.EXPECT().GetUserById(c, int64(1)).Return(user, nil).Times(1)
+				repo.EXPECT().GetTotp(c, int64(1)).Return(enabledTotp, nil).Times(1)
+				repo.EXPECT().UseRecoveryCode(c, int64(1), hashToken(data.Code)).Return(true, nil).Times(1)
+				repo.EXPECT().CompleteLoginChallenge(c, int64(1)).Return(true, nil).Times(1)
+				repo.EXPECT().GetUserRoles(c, int64(1)).Return([]string{config.ADMIN}, nil).Times(1)
+				repo.EXPECT().AddRefreshToken(c, gomock.Any()).Return(int64(1), nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			httpMethod: http.MethodPost,
+		},
+		{
+			name:  "EnrollmentActivatedOnLogin",
+			input: LoginChallengeRequest{ChallengeToken: "abc", Code: code},
+			setup: func(c *gin.Context, data LoginChallengeRequest) {
+				ctrl := gomock.NewController(t)
+				repo := dbmock.NewMockV1DBLayer(ctrl)
+				dbObj = repo
+				repo.EXPECT().GetLoginChallenge(c, hashToken(data.ChallengeToken)).Return(challenge, nil).Times(1)
+				repo.EXPECT().GetUserById(c, int64(1)).Return(user, nil).Times(1)
+				repo.EXPECT().GetTotp(c, int64(1)).Return(pendingTotp, nil).Times(1)
+				repo.EXPECT().EnableTotp(c, int64(1), gomock.Any(), gomock.Len(RECOVERY_CODE_COUNT)).Return(nil).Times(1)
+				repo.EXPECT().CompleteLoginChallenge(c, int64(1)).Return(true, nil).Times(1)
+				repo.EXPECT().GetUserRoles(c, int64(1)).Return([]string{config.ADMIN}, nil).Times(1)
+				repo.EXPECT().AddRefreshToken(c, gomock.Any()).Return(int64(1), nil).Times(1)
+			},
+			recoveryCodes: RECOVERY_CODE_COUNT,
+			httpStatus:    http.StatusOK,
+			httpMethod:    http.MethodPost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Verify Login Challenge TestCase: ", tt.name)
+			w, ctx := getContext(tt.httpMethod, tt.input)
+
+			//setup test
+			tt.setup(ctx, tt.input)
+			servObj := NewUserManagementService(dbObj, &captureNotifier{})
+
+			//calling the function
+			servObj.VerifyLoginChallenge(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response UserLoginResponse
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			if tt.httpStatus == http.StatusOK {
+				assert.NotEqual(t, "", response.Data.Token)
+				assert.Equal(t, tt.recoveryCodes, len(response.Data.RecoveryCodes))
+			}
+
+			fmt.Println("Ending Verify Login Challenge TestCase: ", tt.name)
+		})
+	}
+}
+
+func Test_userMgtService_EnrollTotp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	repo := dbmock.NewMockV1DBLayer(ctrl)
+	w, ctx := getContext(http.MethodPost, nil)
+	ctx.Set(config.USERID, int64(1))
+
+	var stored string
+	repo.EXPECT().GetUserById(ctx, int64(1)).Return(usermanagement.UserDetails{
+		UserId:   sql.NullInt64{Int64: 1, Valid: true},
+		UserName: sql.NullString{String: "testadmin", Valid: true},
+	}, nil).Times(1)
+	repo.EXPECT().SaveTotpSecret(ctx, int64(1), gomock.Any()).DoAndReturn(func(c *gin.Context, userId int64, secret string) (bool, error) {
+		stored = secret
+		return true, nil
+	}).Times(1)
+	servObj := NewUserManagementService(repo, &captureNotifier{})
+
+	servObj.EnrollTotp(ctx)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response TotpEnrollmentResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, nil, err)
+
+	//the secret is stored encrypted
+	assert.NotEqual(t, response.Data.Secret, stored)
+	decrypted, err := auth.DecryptSecret(stored)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, response.Data.Secret, decrypted)
+}
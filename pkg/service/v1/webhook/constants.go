@@ -0,0 +1,8 @@
+package webhook
+
+const (
+	//random bytes of a secret generated for a subscription created without one
+	SECRET_BYTES = 32
+	//deliveries per page of the delivery log when no limit is asked for
+	DEFAULT_PAGE_SIZE = 100
+)
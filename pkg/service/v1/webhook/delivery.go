@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/webhook"
+	e "aspire-assignment/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetWebhookDeliveries lists the delivery log matching the filters, oldest first, one page at a time
+func (obj *webhookService) GetWebhookDeliveries(c *gin.Context) {
+	var (
+		request  GetWebhookDeliveriesRequest
+		response WebhookDeliveriesResponse
+	)
+	if err := c.BindQuery(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to fetch webhook deliveries"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	filter := webhook.DeliveryFilter{
+		SubscriptionId: request.SubscriptionId,
+		EventId:        request.EventId,
+		Status:         request.Status,
+		AfterId:        request.AfterId,
+		Limit:          request.Limit,
+	}
+	if filter.Limit == 0 {
+		filter.Limit = DEFAULT_PAGE_SIZE
+	}
+
+	deliveries, err := obj.dbObj.GetWebhookDeliveries(c, filter)
+	if err != nil {
+		log.Printf("failed to fetch webhook deliveries. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch webhook deliveries"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Status = true
+	response.Data = make([]WebhookDelivery, 0)
+	for _, delivery := range deliveries {
+		entry := WebhookDelivery{
+			DeliveryId:     delivery.DeliveryId.Int64,
+			SubscriptionId: delivery.SubscriptionId.Int64,
+			EventId:        delivery.EventId.Int64,
+			EventType:      delivery.EventType.String,
+			Status:         delivery.Status.String,
+			Attempts:       delivery.Attempts.Int64,
+			ResponseCode:   delivery.ResponseCode.Int64,
+			LastError:      delivery.LastError.String,
+			CreatedAt:      delivery.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+			Body:           json.RawMessage(delivery.Body.String),
+		}
+		if delivery.Status.String == webhook.PENDING {
+			entry.NextAttemptAt = delivery.NextAttemptAt.Time.Format("2006-01-02 15:04:05")
+		}
+		if delivery.DeliveredAt.Valid {
+			entry.DeliveredAt = delivery.DeliveredAt.Time.Format("2006-01-02 15:04:05")
+		}
+		response.Data = append(response.Data, entry)
+	}
+	response.Message = "successfully fetched webhook deliveries"
+	c.JSON(http.StatusOK, response)
+}
+
+// RedeliverWebhook queues a delivered or dead lettered delivery again, with all its attempts, for the next dispatch
+func (obj *webhookService) RedeliverWebhook(c *gin.Context) {
+	var (
+		request  RedeliverWebhookRequest
+		response RedeliverWebhookResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to redeliver webhook"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	audit.Target(c, audit.TARGET_DELIVERY, request.DeliveryId)
+
+	deliveryId, err := obj.dbObj.RedeliverWebhookDelivery(c, request.DeliveryId, time.Now())
+	if err != nil {
+		log.Printf("failed to redeliver webhook delivery. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to redeliver webhook"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if deliveryId == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails("unknown delivery or delivery still pending"))
+		response.Message = "failed to redeliver webhook"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	log.Printf("webhook delivery %d queued again by UserId: %d", deliveryId, c.GetInt64(config.USERID))
+	response.Status = true
+	response.Message = "successfully queued the delivery again"
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,177 @@
+package webhook
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/webhook"
+	e "aspire-assignment/pkg/errors"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_webhookService_GetWebhookDeliveries(t *testing.T) {
+	var dbObj v1.V1DBLayer
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	now := time.Now()
+	deliveries := []webhook.WebhookDelivery{
+		{
+			DeliveryId:     sql.NullInt64{Int64: 7, Valid: true},
+			SubscriptionId: sql.NullInt64{Int64: 4, Valid: true},
+			EventId:        sql.NullInt64{Int64: 12, Valid: true},
+			EventType:      sql.NullString{String: "loan.approved", Valid: true},
+			Body:           sql.NullString{String: `{"eventId":12}`, Valid: true},
+			Status:         sql.NullString{String: webhook.DEAD, Valid: true},
+			Attempts:       sql.NullInt64{Int64: 8, Valid: true},
+			NextAttemptAt:  sql.NullTime{Time: now, Valid: true},
+			ResponseCode:   sql.NullInt64{Int64: 503, Valid: true},
+			LastError:      sql.NullString{String: "answered 503", Valid: true},
+			CreatedAt:      sql.NullTime{Time: now, Valid: true},
+		},
+	}
+	tests := []struct {
+		name       string
+		query      map[string]string
+		setup      func(*gin.Context)
+		httpStatus int
+		deliveries int
+	}{
+		{
+			name:  "UnknownStatus",
+			query: map[string]string{"status": "FAILED"},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "LimitTooLarge",
+			query: map[string]string{"limit": "1000"},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DBError",
+			query: map[string]string{},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetWebhookDeliveries(c, gomock.Any()).Return(nil, fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "Filtered",
+			query: map[string]string{"subscriptionId": "4", "status": "DEAD", "afterId": "6"},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetWebhookDeliveries(c, webhook.DeliveryFilter{SubscriptionId: 4, Status: webhook.DEAD, AfterId: 6, Limit: DEFAULT_PAGE_SIZE}).Return(deliveries, nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			deliveries: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Get Webhook Deliveries TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodGet, nil, tt.query)
+
+			//setup test
+			tt.setup(ctx)
+			NewWebhookService(dbObj).GetWebhookDeliveries(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response WebhookDeliveriesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			assert.Equal(t, tt.deliveries, len(response.Data))
+			if tt.deliveries != 0 {
+				assert.Equal(t, `{"eventId":12}`, string(response.Data[0].Body))
+				assert.Equal(t, int64(503), response.Data[0].ResponseCode)
+				//only pending deliveries have a next attempt
+				assert.Equal(t, "", response.Data[0].NextAttemptAt)
+			}
+		})
+	}
+}
+
+func Test_webhookService_RedeliverWebhook(t *testing.T) {
+	var dbObj v1.V1DBLayer
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		input      interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+	}{
+		{
+			name:  "MissingId",
+			input: RedeliverWebhookRequest{},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "StillPending",
+			input: RedeliverWebhookRequest{DeliveryId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().RedeliverWebhookDelivery(c, int64(7), gomock.Any()).Return(int64(0), nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "Success",
+			input: RedeliverWebhookRequest{DeliveryId: 7},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().RedeliverWebhookDelivery(c, int64(7), gomock.Any()).Return(int64(7), nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Redeliver Webhook TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodPost, tt.input, nil)
+
+			//setup test
+			tt.setup(ctx)
+			NewWebhookService(dbObj).RedeliverWebhook(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response RedeliverWebhookResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package webhook
+
+import (
+	v1 "aspire-assignment/pkg/db/v1"
+
+	"github.com/gin-gonic/gin"
+)
+
+type webhookService struct {
+	dbObj v1.V1DBLayer
+}
+
+type WebhookInterface interface {
+	CreateWebhook(*gin.Context)
+	GetWebhooks(*gin.Context)
+	UpdateWebhook(*gin.Context)
+	DeleteWebhook(*gin.Context)
+
+	GetWebhookDeliveries(*gin.Context)
+	RedeliverWebhook(*gin.Context)
+}
+
+func NewWebhookService(db v1.V1DBLayer) WebhookInterface {
+	return &webhookService{
+		dbObj: db,
+	}
+}
@@ -0,0 +1,108 @@
+package webhook
+
+import (
+	e "aspire-assignment/pkg/errors"
+	"encoding/json"
+)
+
+// CreateWebhookRequest subscribes url to the event types. without a secret one is generated, and either way it
+// is returned only in the response to this request
+type CreateWebhookRequest struct {
+	UserId      int64    `json:"-"`
+	Url         string   `json:"url" binding:"required,url"`
+	EventTypes  []string `json:"eventTypes" binding:"required,min=1"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16"`
+	Description string   `json:"description"`
+	Active      *bool    `json:"active"`
+}
+
+// UpdateWebhookRequest changes the fields sent and keeps the others. a new secret signs every later attempt,
+// of queued deliveries too
+type UpdateWebhookRequest struct {
+	SubscriptionId int64    `json:"subscriptionId" binding:"required"`
+	Url            *string  `json:"url" binding:"omitempty,url"`
+	EventTypes     []string `json:"eventTypes" binding:"omitempty,min=1"`
+	Secret         *string  `json:"secret" binding:"omitempty,min=16"`
+	Description    *string  `json:"description"`
+	Active         *bool    `json:"active"`
+}
+
+type DeleteWebhookRequest struct {
+	SubscriptionId int64 `json:"subscriptionId" binding:"required"`
+}
+
+type WebhookResponse struct {
+	Data    *Webhook  `json:"data,omitempty"`
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+type WebhooksResponse struct {
+	Data    []Webhook `json:"data,omitempty"`
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Webhook is a subscription as admins see it. Secret is set only when the subscription is created
+type Webhook struct {
+	SubscriptionId int64    `json:"subscriptionId"`
+	Url            string   `json:"url"`
+	EventTypes     []string `json:"eventTypes"`
+	Secret         string   `json:"secret,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Active         bool     `json:"active"`
+	CreatedBy      int64    `json:"createdBy"`
+	CreatedAt      string   `json:"createdAt"`
+	UpdatedAt      string   `json:"updatedAt"`
+}
+
+type DeleteWebhookResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
+
+// GetWebhookDeliveriesRequest filters the delivery log. afterId continues from the last delivery of the previous page
+type GetWebhookDeliveriesRequest struct {
+	SubscriptionId int64  `form:"subscriptionId"`
+	EventId        int64  `form:"eventId"`
+	Status         string `form:"status" binding:"omitempty,oneof=PENDING DELIVERED DEAD"`
+	AfterId        int64  `form:"afterId" binding:"min=0"`
+	Limit          int    `form:"limit" binding:"min=0,max=500"`
+}
+
+type WebhookDeliveriesResponse struct {
+	Data    []WebhookDelivery `json:"data,omitempty"`
+	Status  bool              `json:"success"`
+	Errors  []e.Error         `json:"errors,omitempty"`
+	Message string            `json:"message,omitempty"`
+}
+
+// WebhookDelivery is an entry of the delivery log. ResponseCode and LastError are of the last attempt and
+// NextAttemptAt is set while the delivery is pending
+type WebhookDelivery struct {
+	DeliveryId     int64           `json:"deliveryId"`
+	SubscriptionId int64           `json:"subscriptionId"`
+	EventId        int64           `json:"eventId"`
+	EventType      string          `json:"eventType"`
+	Status         string          `json:"status"`
+	Attempts       int64           `json:"attempts"`
+	NextAttemptAt  string          `json:"nextAttemptAt,omitempty"`
+	ResponseCode   int64           `json:"responseCode,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	CreatedAt      string          `json:"createdAt"`
+	DeliveredAt    string          `json:"deliveredAt,omitempty"`
+	Body           json.RawMessage `json:"body"`
+}
+
+type RedeliverWebhookRequest struct {
+	DeliveryId int64 `json:"deliveryId" binding:"required"`
+}
+
+type RedeliverWebhookResponse struct {
+	Status  bool      `json:"success"`
+	Errors  []e.Error `json:"errors,omitempty"`
+	Message string    `json:"message,omitempty"`
+}
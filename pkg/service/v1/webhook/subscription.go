@@ -0,0 +1,308 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"aspire-assignment/pkg/audit"
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/db/v1/webhook"
+	e "aspire-assignment/pkg/errors"
+	"aspire-assignment/pkg/service/v1/loan"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhook subscribes a partner url to loan events. deliveries are signed with the secret of the subscription
+func (obj *webhookService) CreateWebhook(c *gin.Context) {
+	var (
+		request  CreateWebhookRequest
+		response WebhookResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to create webhook"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	request.UserId = c.GetInt64(config.USERID)
+
+	eventTypes, msg := validateSubscription(request.Url, request.EventTypes)
+	if msg != "" {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails(msg))
+		response.Message = "failed to create webhook"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		generated, err := generateSecret()
+		if err != nil {
+			log.Printf("failed to generate webhook secret. Error: %s", err.Error())
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+			response.Message = "failed to create webhook"
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+		secret = generated
+	}
+	encrypted, err := auth.EncryptSecret(secret)
+	if err != nil {
+		log.Printf("failed to encrypt webhook secret. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+		response.Message = "failed to create webhook"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	active := request.Active == nil || *request.Active
+	subscriptionId, err := obj.dbObj.AddWebhookSubscription(c, webhook.WebhookSubscription{
+		Url:         sql.NullString{String: request.Url, Valid: true},
+		EventTypes:  eventTypes,
+		Secret:      sql.NullString{String: encrypted, Valid: true},
+		Description: sql.NullString{String: request.Description, Valid: request.Description != ""},
+		Active:      sql.NullBool{Bool: active, Valid: true},
+		CreatedBy:   sql.NullInt64{Int64: request.UserId, Valid: true},
+	})
+	if err != nil {
+		log.Printf("failed to add webhook subscription. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to create webhook"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	log.Printf("webhook %d for %s created by UserId: %d", subscriptionId, request.Url, request.UserId)
+	audit.Target(c, audit.TARGET_WEBHOOK, subscriptionId)
+	audit.Change(c, nil, map[string]interface{}{"url": request.Url, "eventTypes": eventTypes, "description": request.Description, "active": active})
+	now := time.Now().Format("2006-01-02 15:04:05")
+	response.Status = true
+	response.Data = &Webhook{
+		SubscriptionId: subscriptionId,
+		Url:            request.Url,
+		EventTypes:     eventTypes,
+		Secret:         secret,
+		Description:    request.Description,
+		Active:         active,
+		CreatedBy:      request.UserId,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	response.Message = "successfully created webhook. store the secret now, it cannot be shown again"
+	c.JSON(http.StatusOK, response)
+}
+
+// GetWebhooks lists the subscriptions. secrets are never returned
+func (obj *webhookService) GetWebhooks(c *gin.Context) {
+	var (
+		response WebhooksResponse
+	)
+
+	subscriptions, err := obj.dbObj.GetWebhookSubscriptions(c)
+	if err != nil {
+		log.Printf("failed to fetch webhook subscriptions. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to fetch webhooks"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+
+	response.Data = make([]Webhook, 0)
+	for _, subscription := range subscriptions {
+		response.Data = append(response.Data, toWebhook(subscription))
+	}
+	response.Status = true
+	response.Message = "successfully fetched webhooks"
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateWebhook changes a subscription. pausing it holds its deliveries until it is active again
+func (obj *webhookService) UpdateWebhook(c *gin.Context) {
+	var (
+		request  UpdateWebhookRequest
+		response WebhookResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to update webhook"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	audit.Target(c, audit.TARGET_WEBHOOK, request.SubscriptionId)
+
+	subscription, err := obj.dbObj.GetWebhookSubscription(c, request.SubscriptionId)
+	if errors.Is(err, sql.ErrNoRows) {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.NoDataFound].GetErrorDetails("unknown webhook"))
+		response.Message = "failed to update webhook"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+	if err != nil {
+		log.Printf("failed to fetch webhook subscription. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.GetDBError])
+		response.Message = "failed to update webhook"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	before := toWebhook(subscription)
+
+	if request.Url != nil {
+		subscription.Url = sql.NullString{String: *request.Url, Valid: true}
+	}
+	if request.EventTypes != nil {
+		subscription.EventTypes = request.EventTypes
+	}
+	if request.Description != nil {
+		subscription.Description = sql.NullString{String: *request.Description, Valid: *request.Description != ""}
+	}
+	if request.Active != nil {
+		subscription.Active = sql.NullBool{Bool: *request.Active, Valid: true}
+	}
+	eventTypes, msg := validateSubscription(subscription.Url.String, subscription.EventTypes)
+	if msg != "" {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.BadRequest].GetErrorDetails(msg))
+		response.Message = "failed to update webhook"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	subscription.EventTypes = eventTypes
+	if request.Secret != nil {
+		encrypted, err := auth.EncryptSecret(*request.Secret)
+		if err != nil {
+			log.Printf("failed to encrypt webhook secret. Error: %s", err.Error())
+			response.Errors = append(response.Errors, *e.ErrorInfo[e.DefaultError])
+			response.Message = "failed to update webhook"
+			c.JSON(http.StatusInternalServerError, response)
+			return
+		}
+		subscription.Secret = sql.NullString{String: encrypted, Valid: true}
+	}
+
+	subscriptionId, err := obj.dbObj.UpdateWebhookSubscription(c, subscription)
+	if err != nil {
+		log.Printf("failed to update webhook subscription. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.AddDBError])
+		response.Message = "failed to update webhook"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if subscriptionId == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.NoDataFound].GetErrorDetails("unknown webhook"))
+		response.Message = "failed to update webhook"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	log.Printf("webhook %d updated by UserId: %d", subscriptionId, c.GetInt64(config.USERID))
+	after := toWebhook(subscription)
+	after.UpdatedAt = time.Now().Format("2006-01-02 15:04:05")
+	audit.Change(c, auditState(before, false), auditState(after, request.Secret != nil))
+	response.Status = true
+	response.Data = &after
+	response.Message = "successfully updated webhook"
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteWebhook removes a subscription with its delivery log. queued deliveries are not sent
+func (obj *webhookService) DeleteWebhook(c *gin.Context) {
+	var (
+		request  DeleteWebhookRequest
+		response DeleteWebhookResponse
+	)
+	if err := c.BindJSON(&request); err != nil {
+		log.Printf("unable to marshal request. Error:%s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.BadRequest])
+		response.Message = "failed to delete webhook"
+		c.JSON(http.StatusBadRequest, response)
+		return
+	}
+	audit.Target(c, audit.TARGET_WEBHOOK, request.SubscriptionId)
+
+	subscriptionId, err := obj.dbObj.DeleteWebhookSubscription(c, request.SubscriptionId)
+	if err != nil {
+		log.Printf("failed to delete webhook subscription. Error: %s", err.Error())
+		response.Errors = append(response.Errors, *e.ErrorInfo[e.DelDBError])
+		response.Message = "failed to delete webhook"
+		c.JSON(http.StatusInternalServerError, response)
+		return
+	}
+	if subscriptionId == 0 {
+		response.Errors = append(response.Errors, e.ErrorInfo[e.NoDataFound].GetErrorDetails("unknown webhook"))
+		response.Message = "failed to delete webhook"
+		c.JSON(http.StatusNotFound, response)
+		return
+	}
+
+	log.Printf("webhook %d deleted by UserId: %d", subscriptionId, c.GetInt64(config.USERID))
+	response.Status = true
+	response.Message = "successfully deleted webhook"
+	c.JSON(http.StatusOK, response)
+}
+
+// validateSubscription checks the url is http(s) and every event type is published. it returns the event types
+// without duplicates, or why the subscription is invalid
+func validateSubscription(rawUrl string, eventTypes []string) ([]string, string) {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, "webhook url must be an http or https url"
+	}
+	unique := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		if !contains(loan.EventTypes, eventType) {
+			return nil, "unknown event type: " + eventType
+		}
+		if !contains(unique, eventType) {
+			unique = append(unique, eventType)
+		}
+	}
+	return unique, ""
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, SECRET_BYTES)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
+
+func toWebhook(subscription webhook.WebhookSubscription) Webhook {
+	return Webhook{
+		SubscriptionId: subscription.SubscriptionId.Int64,
+		Url:            subscription.Url.String,
+		EventTypes:     subscription.EventTypes,
+		Description:    subscription.Description.String,
+		Active:         subscription.Active.Bool,
+		CreatedBy:      subscription.CreatedBy.Int64,
+		CreatedAt:      subscription.CreatedAt.Time.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      subscription.UpdatedAt.Time.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// auditState is the subscription as the audit log keeps it. the secret is never logged, only that it changed
+func auditState(subscription Webhook, secretChanged bool) map[string]interface{} {
+	state := map[string]interface{}{"url": subscription.Url, "eventTypes": subscription.EventTypes, "description": subscription.Description, "active": subscription.Active}
+	if secretChanged {
+		state["secretChanged"] = true
+	}
+	return state
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
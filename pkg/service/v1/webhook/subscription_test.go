@@ -0,0 +1,370 @@
+package webhook
+
+import (
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	v1 "aspire-assignment/pkg/db/v1"
+	dbmock "aspire-assignment/pkg/db/v1/mock"
+	"aspire-assignment/pkg/db/v1/webhook"
+	e "aspire-assignment/pkg/errors"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/assert/v2"
+	"github.com/golang/mock/gomock"
+)
+
+func Test_webhookService_CreateWebhook(t *testing.T) {
+	var (
+		dbObj  v1.V1DBLayer
+		userId int64 = 1
+	)
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		input      interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+		secret     string
+	}{
+		{
+			name:  "NotHttpUrl",
+			input: CreateWebhookRequest{Url: "ftp://crm.example.com/hook", EventTypes: []string{"loan.approved"}},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "UnknownEventType",
+			input: CreateWebhookRequest{Url: "https://crm.example.com/hook", EventTypes: []string{"loan.approved", "loan.deleted"}},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "ShortSecret",
+			input: CreateWebhookRequest{Url: "https://crm.example.com/hook", EventTypes: []string{"loan.approved"}, Secret: "short"},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DBError",
+			input: CreateWebhookRequest{Url: "https://crm.example.com/hook", EventTypes: []string{"loan.approved"}},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddWebhookSubscription(c, gomock.Any()).Return(int64(0), fmt.Errorf("db down")).Times(1)
+			},
+			httpStatus: http.StatusInternalServerError,
+		},
+		{
+			name:  "GeneratedSecret",
+			input: CreateWebhookRequest{Url: "https://crm.example.com/hook", EventTypes: []string{"loan.approved", "loan.approved", "loan.closed"}},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddWebhookSubscription(c, gomock.Any()).DoAndReturn(func(c *gin.Context, subscription webhook.WebhookSubscription) (int64, error) {
+					assert.Equal(t, []string{"loan.approved", "loan.closed"}, subscription.EventTypes)
+					assert.Equal(t, true, subscription.Active.Bool)
+					assert.Equal(t, userId, subscription.CreatedBy.Int64)
+					return 4, nil
+				}).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:  "GivenSecret",
+			input: CreateWebhookRequest{Url: "https://crm.example.com/hook", EventTypes: []string{"loan.closed"}, Secret: "partner-secret-value"},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().AddWebhookSubscription(c, gomock.Any()).DoAndReturn(func(c *gin.Context, subscription webhook.WebhookSubscription) (int64, error) {
+					//the secret is stored encrypted
+					assert.NotEqual(t, "partner-secret-value", subscription.Secret.String)
+					secret, err := auth.DecryptSecret(subscription.Secret.String)
+					assert.Equal(t, nil, err)
+					assert.Equal(t, "partner-secret-value", secret)
+					return 5, nil
+				}).Times(1)
+			},
+			httpStatus: http.StatusOK,
+			secret:     "partner-secret-value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Create Webhook TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodPost, tt.input, nil)
+			ctx.Set(config.USERID, userId)
+
+			//setup test
+			tt.setup(ctx)
+			NewWebhookService(dbObj).CreateWebhook(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response WebhookResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			if tt.httpStatus == http.StatusOK {
+				//the secret is returned once, generated when none was sent
+				if tt.secret != "" {
+					assert.Equal(t, tt.secret, response.Data.Secret)
+				} else {
+					assert.Equal(t, SECRET_BYTES*2, len(response.Data.Secret))
+				}
+				assert.Equal(t, true, response.Data.Active)
+			}
+		})
+	}
+}
+
+func Test_webhookService_GetWebhooks(t *testing.T) {
+	e.ErrorInit()
+	repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+	w, ctx := getContext(http.MethodGet, nil, nil)
+	repo.EXPECT().GetWebhookSubscriptions(ctx).Return([]webhook.WebhookSubscription{{
+		SubscriptionId: sql.NullInt64{Int64: 4, Valid: true},
+		Url:            sql.NullString{String: "https://crm.example.com/hook", Valid: true},
+		EventTypes:     []string{"loan.approved"},
+		Secret:         sql.NullString{String: "sealed", Valid: true},
+		Active:         sql.NullBool{Bool: true, Valid: true},
+	}}, nil).Times(1)
+
+	NewWebhookService(repo).GetWebhooks(ctx)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response WebhooksResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Error("unable to unmarshal response")
+	}
+	assert.Equal(t, 1, len(response.Data))
+	assert.Equal(t, int64(4), response.Data[0].SubscriptionId)
+	assert.Equal(t, []string{"loan.approved"}, response.Data[0].EventTypes)
+	//secrets are never listed
+	assert.Equal(t, "", response.Data[0].Secret)
+}
+
+func Test_webhookService_UpdateWebhook(t *testing.T) {
+	var dbObj v1.V1DBLayer
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	stored := webhook.WebhookSubscription{
+		SubscriptionId: sql.NullInt64{Int64: 4, Valid: true},
+		Url:            sql.NullString{String: "https://crm.example.com/hook", Valid: true},
+		EventTypes:     []string{"loan.approved"},
+		Secret:         sql.NullString{String: "sealed", Valid: true},
+		Description:    sql.NullString{String: "crm", Valid: true},
+		Active:         sql.NullBool{Bool: true, Valid: true},
+	}
+	paused, rotated := false, "rotated-secret-value"
+	tests := []struct {
+		name       string
+		input      interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+	}{
+		{
+			name:  "UnknownWebhook",
+			input: UpdateWebhookRequest{SubscriptionId: 9, Active: &paused},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetWebhookSubscription(c, int64(9)).Return(webhook.WebhookSubscription{}, sql.ErrNoRows).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:  "UnknownEventType",
+			input: UpdateWebhookRequest{SubscriptionId: 4, EventTypes: []string{"loan.deleted"}},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetWebhookSubscription(c, int64(4)).Return(stored, nil).Times(1)
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "DeletedMeanwhile",
+			input: UpdateWebhookRequest{SubscriptionId: 4, Active: &paused},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetWebhookSubscription(c, int64(4)).Return(stored, nil).Times(1)
+				repo.EXPECT().UpdateWebhookSubscription(c, gomock.Any()).Return(int64(0), nil).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:  "Paused",
+			input: UpdateWebhookRequest{SubscriptionId: 4, Active: &paused},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetWebhookSubscription(c, int64(4)).Return(stored, nil).Times(1)
+				repo.EXPECT().UpdateWebhookSubscription(c, gomock.Any()).DoAndReturn(func(c *gin.Context, subscription webhook.WebhookSubscription) (int64, error) {
+					//fields not sent are kept
+					assert.Equal(t, false, subscription.Active.Bool)
+					assert.Equal(t, stored.Url, subscription.Url)
+					assert.Equal(t, stored.EventTypes, subscription.EventTypes)
+					assert.Equal(t, stored.Secret, subscription.Secret)
+					assert.Equal(t, stored.Description, subscription.Description)
+					return 4, nil
+				}).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+		{
+			name:  "RotatedSecret",
+			input: UpdateWebhookRequest{SubscriptionId: 4, Secret: &rotated, EventTypes: []string{"loan.closed"}},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().GetWebhookSubscription(c, int64(4)).Return(stored, nil).Times(1)
+				repo.EXPECT().UpdateWebhookSubscription(c, gomock.Any()).DoAndReturn(func(c *gin.Context, subscription webhook.WebhookSubscription) (int64, error) {
+					secret, _ := auth.DecryptSecret(subscription.Secret.String)
+					assert.Equal(t, rotated, secret)
+					assert.Equal(t, []string{"loan.closed"}, subscription.EventTypes)
+					assert.Equal(t, true, subscription.Active.Bool)
+					return 4, nil
+				}).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Update Webhook TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodPut, tt.input, nil)
+
+			//setup test
+			tt.setup(ctx)
+			NewWebhookService(dbObj).UpdateWebhook(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response WebhookResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+			if tt.httpStatus == http.StatusOK {
+				assert.Equal(t, "", response.Data.Secret)
+			}
+		})
+	}
+}
+
+func Test_webhookService_DeleteWebhook(t *testing.T) {
+	var dbObj v1.V1DBLayer
+
+	//init error to be used in function
+	e.ErrorInit()
+
+	tests := []struct {
+		name       string
+		input      interface{}
+		setup      func(*gin.Context)
+		httpStatus int
+	}{
+		{
+			name:  "MissingId",
+			input: DeleteWebhookRequest{},
+			setup: func(c *gin.Context) {
+				dbObj = dbmock.NewMockV1DBLayer(gomock.NewController(t))
+			},
+			httpStatus: http.StatusBadRequest,
+		},
+		{
+			name:  "UnknownWebhook",
+			input: DeleteWebhookRequest{SubscriptionId: 9},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().DeleteWebhookSubscription(c, int64(9)).Return(int64(0), nil).Times(1)
+			},
+			httpStatus: http.StatusNotFound,
+		},
+		{
+			name:  "Success",
+			input: DeleteWebhookRequest{SubscriptionId: 4},
+			setup: func(c *gin.Context) {
+				repo := dbmock.NewMockV1DBLayer(gomock.NewController(t))
+				dbObj = repo
+				repo.EXPECT().DeleteWebhookSubscription(c, int64(4)).Return(int64(4), nil).Times(1)
+			},
+			httpStatus: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			fmt.Println("Starting Delete Webhook TestCase: ", tt.name)
+			w, ctx := getContext(http.MethodDelete, tt.input, nil)
+
+			//setup test
+			tt.setup(ctx)
+			NewWebhookService(dbObj).DeleteWebhook(ctx)
+
+			//check for result status
+			assert.Equal(t, tt.httpStatus, w.Code)
+
+			var response DeleteWebhookResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+				t.Error("unable to unmarshal response")
+			}
+			assert.Equal(t, tt.httpStatus == http.StatusOK, response.Status)
+		})
+	}
+}
+
+func getContext(method string, data interface{}, queries map[string]string) (w *httptest.ResponseRecorder, c *gin.Context) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	temp, _ := gin.CreateTestContext(recorder)
+	byteData, err := json.Marshal(data)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	temp.Request, err = http.NewRequest(method, "/", bytes.NewBuffer(byteData))
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	//add headers
+	temp.Request.Header = http.Header{}
+	temp.Request.Header.Set("Content-Type", "application/json")
+
+	//add query params
+	if queries != nil {
+		q := temp.Request.URL.Query()
+		for k, v := range queries {
+			q.Add(k, v)
+		}
+		temp.Request.URL.RawQuery = q.Encode()
+	}
+
+	return recorder, temp
+}
@@ -0,0 +1,90 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// camtDocument is the part of an ISO 20022 camt.053 bank to customer statement a reconciliation needs. elements
+// are matched by local name, so any version of the message namespace is read
+type camtDocument struct {
+	Statements []struct {
+		Entries []camtEntry `xml:"Ntry"`
+	} `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtEntry struct {
+	Amount          string            `xml:"Amt"`
+	CreditDebit     string            `xml:"CdtDbtInd"`
+	BookingDate     string            `xml:"BookgDt>Dt"`
+	BookingDateTime string            `xml:"BookgDt>DtTm"`
+	ServicerRef     string            `xml:"AcctSvcrRef"`
+	EntryRef        string            `xml:"NtryRef"`
+	AdditionalInfo  string            `xml:"AddtlNtryInf"`
+	Transactions    []camtTransaction `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTransaction struct {
+	ServicerRef  string   `xml:"Refs>AcctSvcrRef"`
+	EndToEndId   string   `xml:"Refs>EndToEndId"`
+	Unstructured []string `xml:"RmtInf>Ustrd"`
+	Structured   []string `xml:"RmtInf>Strd>CdtrRefInf>Ref"`
+	//the debtor name moved under Pty in later versions of the message
+	Debtor      string `xml:"RltdPties>Dbtr>Nm"`
+	DebtorParty string `xml:"RltdPties>Dbtr>Pty>Nm"`
+}
+
+// parseCAMT reads every entry of every statement in the message as a line. the remittance information of all
+// transactions of a batched entry makes up the reference of the line
+func parseCAMT(data []byte) ([]Line, error) {
+	var document camtDocument
+	if err := xml.NewDecoder(bytes.NewReader(data)).Decode(&document); err != nil {
+		return nil, fmt.Errorf("invalid camt.053 statement: %w", err)
+	}
+
+	lines := make([]Line, 0)
+	for _, statement := range document.Statements {
+		for number, entry := range statement.Entries {
+			amount, err := parseAmount(strings.TrimSpace(entry.Amount))
+			if err != nil {
+				return nil, fmt.Errorf("camt.053 entry %d: %w", number+1, err)
+			}
+			line := Line{
+				Amount:        amount,
+				Credit:        strings.TrimSpace(entry.CreditDebit) == "CRDT",
+				BankReference: strings.TrimSpace(entry.ServicerRef),
+			}
+			if date := strings.TrimSpace(entry.BookingDate + entry.BookingDateTime); date != "" {
+				if len(date) > 10 {
+					date = date[:10]
+				}
+				if line.BookedOn, err = parseDate(date); err != nil {
+					return nil, fmt.Errorf("camt.053 entry %d: %w", number+1, err)
+				}
+			}
+
+			references := make([]string, 0)
+			for _, transaction := range entry.Transactions {
+				references = append(references, transaction.Structured...)
+				references = append(references, transaction.Unstructured...)
+				if line.Counterparty == "" {
+					line.Counterparty = strings.TrimSpace(transaction.Debtor + transaction.DebtorParty)
+				}
+				if line.BankReference == "" {
+					line.BankReference = strings.TrimSpace(transaction.ServicerRef)
+				}
+			}
+			if len(references) == 0 && entry.AdditionalInfo != "" {
+				references = append(references, entry.AdditionalInfo)
+			}
+			line.Reference = strings.Join(strings.Fields(strings.Join(references, " ")), " ")
+			if line.BankReference == "" {
+				line.BankReference = strings.TrimSpace(entry.EntryRef)
+			}
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,160 @@
+package statement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csvColumns lists the header names a bank export may give each field. headers are compared in lower case without
+// spaces, dashes, underscores and slashes
+var csvColumns = map[string][]string{
+	"date":          {"date", "bookingdate", "bookeddate", "bookedon", "transactiondate", "valuedate"},
+	"amount":        {"amount", "transactionamount"},
+	"credit":        {"credit", "creditamount", "paidin", "moneyin"},
+	"debit":         {"debit", "debitamount", "paidout", "moneyout"},
+	"type":          {"type", "creditdebit", "cdtdbtind", "drcr"},
+	"reference":     {"reference", "paymentreference", "description", "narrative", "details", "remittanceinformation", "memo"},
+	"counterparty":  {"counterparty", "payer", "payername", "name", "from"},
+	"bankReference": {"bankreference", "transactionid", "banktransactionid", "id"},
+}
+
+var csvDateLayouts = []string{"2006-01-02", "02/01/2006", "02.01.2006", "2006/01/02", time.RFC3339}
+
+// parseCSV reads a statement export with a header row. an amount column holds signed amounts, debits being
+// negative, unless a type column says which lines are debits. separate credit and debit columns work as well
+func parseCSV(data []byte) ([]Line, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	if header, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		reader.Comma = ';'
+	}
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv statement: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("csv statement has no header")
+	}
+
+	columns := make(map[string]int)
+	for index, name := range records[0] {
+		name = strings.NewReplacer(" ", "", "-", "", "_", "", "/", "").Replace(strings.ToLower(strings.TrimSpace(name)))
+		for field, names := range csvColumns {
+			if _, found := columns[field]; !found && containsName(names, name) {
+				columns[field] = index
+			}
+		}
+	}
+	_, hasAmount := columns["amount"]
+	_, hasCredit := columns["credit"]
+	if !hasAmount && !hasCredit {
+		return nil, errors.New("csv statement has no amount column")
+	}
+
+	lines := make([]Line, 0, len(records)-1)
+	for number, record := range records[1:] {
+		if isBlank(record) {
+			continue
+		}
+		field := func(name string) string {
+			index, found := columns[name]
+			if !found || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+		line := Line{
+			Reference:     field("reference"),
+			Counterparty:  field("counterparty"),
+			BankReference: field("bankReference"),
+		}
+		if value := field("date"); value != "" {
+			if line.BookedOn, err = parseDate(value); err != nil {
+				return nil, fmt.Errorf("csv statement row %d: %w", number+2, err)
+			}
+		}
+
+		var amount float64
+		switch {
+		case field("amount") != "":
+			amount, err = parseAmount(field("amount"))
+			if strings.HasPrefix(strings.ToUpper(field("type")), "D") {
+				amount = -amount
+			}
+		case field("credit") != "":
+			amount, err = parseAmount(field("credit"))
+		case field("debit") != "":
+			amount, err = parseAmount(field("debit"))
+			amount = -amount
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv statement row %d: %w", number+2, err)
+		}
+		line.Credit = amount > 0
+		if amount < 0 {
+			amount = -amount
+		}
+		line.Amount = amount
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// parseAmount reads an amount written with either a decimal point or a decimal comma. a comma followed by
+// exactly three digits in an amount without a point separates thousands
+func parseAmount(value string) (float64, error) {
+	value = strings.NewReplacer(" ", "", "'", "").Replace(value)
+	switch {
+	case strings.Contains(value, ".") && strings.Contains(value, ","):
+		if strings.LastIndex(value, ",") > strings.LastIndex(value, ".") {
+			value = strings.ReplaceAll(value, ".", "")
+			value = strings.Replace(value, ",", ".", 1)
+		} else {
+			value = strings.ReplaceAll(value, ",", "")
+		}
+	case strings.Contains(value, ","):
+		if index := strings.LastIndex(value, ","); len(value)-index-1 == 3 {
+			value = strings.ReplaceAll(value, ",", "")
+		} else {
+			value = strings.Replace(value, ",", ".", 1)
+		}
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	for _, layout := range csvDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
+
+func containsName(names []string, name string) bool {
+	for _, item := range names {
+		if item == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isBlank(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package statement
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// statementLine is the :61: field of MT940: value date, optional entry date, debit or credit mark (R for a
+// reversal), optional funds code, amount with a decimal comma, transaction type, the reference of the account
+// owner and, behind //, the reference of the bank
+var statementLine = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)([NSF][A-Z0-9]{3})([^/\n]*)(?://([^\n]*))?`)
+
+var fieldTag = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+
+// parseMT940 reads every :61: field as a line, with the :86: field right after it as the reference
+func parseMT940(data []byte) ([]Line, error) {
+	fields := make([][2]string, 0)
+	for _, text := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if tag := fieldTag.FindStringSubmatch(text); tag != nil {
+			fields = append(fields, [2]string{tag[1], text[len(tag[0]):]})
+			continue
+		}
+		//a field runs on over the lines up to the next field, or up to the end of the message
+		if len(fields) > 0 && text != "-" && text != "-}" {
+			fields[len(fields)-1][1] += "\n" + text
+		}
+	}
+
+	lines := make([]Line, 0)
+	for index, field := range fields {
+		switch field[0] {
+		case "61":
+			match := statementLine.FindStringSubmatch(field[1])
+			if match == nil {
+				return nil, fmt.Errorf("invalid mt940 statement line %q", field[1])
+			}
+			bookedOn, err := time.Parse("060102", match[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid mt940 value date %q", match[1])
+			}
+			amount, err := parseAmount(match[5])
+			if err != nil {
+				return nil, fmt.Errorf("mt940 statement line %q: %w", field[1], err)
+			}
+			line := Line{
+				BookedOn:      bookedOn,
+				Amount:        amount,
+				Credit:        match[3] == "C" || match[3] == "RD",
+				BankReference: strings.TrimSpace(match[8]),
+			}
+			if reference := strings.TrimSpace(match[7]); reference != "NONREF" {
+				line.Reference = reference
+			}
+			lines = append(lines, line)
+		case "86":
+			//information of the statement itself follows other fields
+			if index == 0 || fields[index-1][0] != "61" {
+				continue
+			}
+			last := &lines[len(lines)-1]
+			last.Reference = strings.Join(strings.Fields(last.Reference+" "+field[1]), " ")
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,92 @@
+package statement
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// statement formats
+const (
+	CSV     = "CSV"
+	CAMT053 = "CAMT053"
+	MT940   = "MT940"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// Statement is the content of a statement file in the order the bank listed it
+type Statement struct {
+	Format string
+	Lines  []Line
+}
+
+// Line is an entry of a statement. Amount is never negative and Credit tells money received from money paid out.
+// Reference is the remittance text of the payer, and BankReference the id the bank gave the entry when it has one
+type Line struct {
+	Num           int64
+	BookedOn      time.Time
+	Amount        float64
+	Credit        bool
+	Reference     string
+	Counterparty  string
+	BankReference string
+}
+
+// Detect tells the format of a statement from its content, or else from the extension of its file name.
+// anything else is read as CSV
+func Detect(fileName string, data []byte) string {
+	content := bytes.TrimSpace(data)
+	if bytes.HasPrefix(content, []byte("<")) && (bytes.Contains(content, []byte("camt.053")) || bytes.Contains(content, []byte("BkToCstmrStmt"))) {
+		return CAMT053
+	}
+	if bytes.Contains(content, []byte(":20:")) && bytes.Contains(content, []byte(":61:")) {
+		return MT940
+	}
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".xml":
+		return CAMT053
+	case ".sta", ".mt940", ".940":
+		return MT940
+	}
+	return CSV
+}
+
+// Parse reads a statement of the given format
+func Parse(format string, data []byte) (Statement, error) {
+	var (
+		lines []Line
+		err   error
+	)
+	switch strings.ToUpper(format) {
+	case CSV:
+		lines, err = parseCSV(data)
+	case CAMT053:
+		lines, err = parseCAMT(data)
+	case MT940:
+		lines, err = parseMT940(data)
+	default:
+		return Statement{}, fmt.Errorf("%w: %s", ErrUnknownFormat, format)
+	}
+	if err != nil {
+		return Statement{}, err
+	}
+	for i := range lines {
+		lines[i].Num = int64(i + 1)
+	}
+	return Statement{Format: strings.ToUpper(format), Lines: lines}, nil
+}
+
+// Credits are the lines of money received, the only ones which can repay a loan
+func (statement Statement) Credits() []Line {
+	credits := make([]Line, 0, len(statement.Lines))
+	for _, line := range statement.Lines {
+		if line.Credit && line.Amount > 0 {
+			credits = append(credits, line)
+		}
+	}
+	return credits
+}
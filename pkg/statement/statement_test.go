@@ -0,0 +1,168 @@
+package statement
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+const csvStatement = `Booking Date,Amount,Description,Payer,Transaction ID
+2024-05-02,1000.00,REPAYMENT AL000000034,John Doe,TX-1
+02/05/2024,-45.10,bank fees,,TX-2
+2024-05-03,"1,250.50",rent,Jane Roe,TX-3
+,,,,
+`
+
+const camtStatement = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Ntry>
+        <Amt Ccy="EUR">1000.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <BookgDt><Dt>2024-05-02</Dt></BookgDt>
+        <AcctSvcrRef>CAMT-1</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <RltdPties><Dbtr><Pty><Nm>John Doe</Nm></Pty></Dbtr></RltdPties>
+            <RmtInf><Ustrd>REPAYMENT</Ustrd><Ustrd>AL000000034</Ustrd></RmtInf>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">45.10</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <BookgDt><DtTm>2024-05-03T10:00:00+02:00</DtTm></BookgDt>
+        <AddtlNtryInf>bank fees</AddtlNtryInf>
+        <NtryDtls>
+          <TxDtls>
+            <Refs><AcctSvcrRef>CAMT-2</AcctSvcrRef></Refs>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>
+`
+
+const mt940Statement = `:20:STMT240502
+:25:DE89370400440532013000
+:28C:00001/001
+:60F:C240501EUR0,00
+:61:2405020502CR1000,00NTRFAL000000034//MT-1
+:86:REPAYMENT JOHN DOE
+AL000000034
+:61:240503D45,10NCHGNONREF//MT-2
+:86:bank fees
+:62F:C240503EUR954,90
+:86:closing balance
+-
+`
+
+func Test_Detect(t *testing.T) {
+	assert.Equal(t, CSV, Detect("statement.csv", []byte(csvStatement)))
+	assert.Equal(t, CAMT053, Detect("statement.txt", []byte(camtStatement)))
+	assert.Equal(t, MT940, Detect("statement.txt", []byte(mt940Statement)))
+	assert.Equal(t, CAMT053, Detect("statement.xml", []byte("")))
+	assert.Equal(t, MT940, Detect("statement.sta", []byte("")))
+}
+
+func Test_Parse(t *testing.T) {
+	booked := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		format string
+		data   string
+		lines  []Line
+		err    bool
+	}{
+		{
+			name:   "CSV",
+			format: CSV,
+			data:   csvStatement,
+			lines: []Line{
+				{Num: 1, BookedOn: booked, Amount: 1000, Credit: true, Reference: "REPAYMENT AL000000034", Counterparty: "John Doe", BankReference: "TX-1"},
+				{Num: 2, BookedOn: booked, Amount: 45.10, Reference: "bank fees", BankReference: "TX-2"},
+				{Num: 3, BookedOn: booked.AddDate(0, 0, 1), Amount: 1250.50, Credit: true, Reference: "rent", Counterparty: "Jane Roe", BankReference: "TX-3"},
+			},
+		},
+		{
+			name:   "CSVWithSemicolonsAndTypeColumn",
+			format: "csv",
+			data:   "date;amount;type;reference\n02.05.2024;1000,00;CRDT;AL000000034\n02.05.2024;12,5;DBIT;fees\n",
+			lines: []Line{
+				{Num: 1, BookedOn: booked, Amount: 1000, Credit: true, Reference: "AL000000034"},
+				{Num: 2, BookedOn: booked, Amount: 12.5, Reference: "fees"},
+			},
+		},
+		{
+			name:   "CSVWithoutAmount",
+			format: CSV,
+			data:   "date,reference\n2024-05-02,AL000000034\n",
+			err:    true,
+		},
+		{
+			name:   "CSVWithInvalidDate",
+			format: CSV,
+			data:   "date,amount\nyesterday,10\n",
+			err:    true,
+		},
+		{
+			name:   "CAMT053",
+			format: CAMT053,
+			data:   camtStatement,
+			lines: []Line{
+				{Num: 1, BookedOn: booked, Amount: 1000, Credit: true, Reference: "REPAYMENT AL000000034", Counterparty: "John Doe", BankReference: "CAMT-1"},
+				{Num: 2, BookedOn: booked.AddDate(0, 0, 1), Amount: 45.10, Reference: "bank fees", BankReference: "CAMT-2"},
+			},
+		},
+		{
+			name:   "InvalidCAMT053",
+			format: CAMT053,
+			data:   "<Document><BkToCstmrStmt>",
+			err:    true,
+		},
+		{
+			name:   "MT940",
+			format: MT940,
+			data:   mt940Statement,
+			lines: []Line{
+				{Num: 1, BookedOn: booked, Amount: 1000, Credit: true, Reference: "AL000000034 REPAYMENT JOHN DOE AL000000034", BankReference: "MT-1"},
+				{Num: 2, BookedOn: booked.AddDate(0, 0, 1), Amount: 45.10, Reference: "bank fees", BankReference: "MT-2"},
+			},
+		},
+		{
+			name:   "InvalidMT940",
+			format: MT940,
+			data:   ":20:STMT\n:61:yesterday\n",
+			err:    true,
+		},
+		{
+			name:   "UnknownFormat",
+			format: "OFX",
+			err:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statement, err := Parse(tt.format, []byte(tt.data))
+			assert.Equal(t, tt.err, err != nil)
+			if tt.err {
+				return
+			}
+			assert.Equal(t, len(tt.lines), len(statement.Lines))
+			for i, line := range statement.Lines {
+				assert.Equal(t, tt.lines[i], line)
+			}
+		})
+	}
+}
+
+func Test_Statement_Credits(t *testing.T) {
+	statement, _ := Parse(CSV, []byte(csvStatement))
+	credits := statement.Credits()
+	assert.Equal(t, 2, len(credits))
+	assert.Equal(t, int64(1), credits[0].Num)
+	assert.Equal(t, int64(3), credits[1].Num)
+}
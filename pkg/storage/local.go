@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type localStore struct {
+	basePath string
+}
+
+// NewLocalStore stores blobs as files under basePath. the directory is created if missing
+func NewLocalStore(basePath string) (BlobStore, error) {
+	if basePath == "" {
+		basePath = "uploads"
+	}
+	if err := os.MkdirAll(basePath, 0o750); err != nil {
+		return nil, err
+	}
+	return &localStore{
+		basePath: basePath,
+	}, nil
+}
+
+// resolve maps a key to a path and refuses keys escaping the base directory
+func (obj *localStore) resolve(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(obj.basePath, filepath.FromSlash(cleaned)), nil
+}
+
+func (obj *localStore) Put(ctx context.Context, key string, data io.Reader) (int64, error) {
+	path, err := obj.resolve(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return 0, err
+	}
+
+	//write to a temp file first so a failed upload never leaves a partial blob behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(tmp, data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return 0, err
+	}
+	return size, nil
+}
+
+func (obj *localStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := obj.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (obj *localStore) Delete(ctx context.Context, key string) error {
+	path, err := obj.resolve(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"aspire-assignment/pkg/config"
+	"context"
+	"fmt"
+	"io"
+	"log"
+)
+
+const (
+	LOCAL = "local"
+)
+
+// BlobStore keeps uploaded files outside the database. keys are slash separated paths owned by the caller
+type BlobStore interface {
+	Put(ctx context.Context, key string, data io.Reader) (int64, error)
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewBlobStore returns the blob store selected by storage.driver in config
+func NewBlobStore() (BlobStore, error) {
+	c := config.GetConfig()
+
+	driver := c.GetString("storage.driver")
+	switch driver {
+	case LOCAL, "":
+		store, err := NewLocalStore(c.GetString("storage.local.path"))
+		if err != nil {
+			return nil, err
+		}
+		log.Println("Local blob store initialized")
+		return store, nil
+	default:
+		return nil, fmt.Errorf("unsupported storage driver %s", driver)
+	}
+}
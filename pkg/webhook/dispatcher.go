@@ -0,0 +1,157 @@
+package webhook
+
+import (
+	"aspire-assignment/pkg/auth"
+	"aspire-assignment/pkg/config"
+	"aspire-assignment/pkg/poller"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	dbwebhook "aspire-assignment/pkg/db/v1/webhook"
+	"aspire-assignment/pkg/outbox"
+)
+
+// dispatch policy, overridden by webhooks.* in config
+var (
+	pollInterval = time.Second
+	batchSize    = 100
+	//how long a claimed delivery is left to one dispatcher before another may send it again
+	lease = time.Minute
+	//a delivery failing this many times is dead lettered
+	maxAttempts = int64(8)
+	baseDelay   = 10 * time.Second
+	maxDelay    = time.Hour
+	timeout     = 10 * time.Second
+)
+
+func InitDispatchPolicy() {
+	confi := config.GetConfig()
+	if value := confi.GetDuration("webhooks.poll_interval"); value > 0 {
+		pollInterval = value
+	}
+	if value := confi.GetInt("webhooks.batch_size"); value > 0 {
+		batchSize = value
+	}
+	if value := confi.GetDuration("webhooks.lease"); value > 0 {
+		lease = value
+	}
+	if value := confi.GetInt64("webhooks.max_attempts"); value > 0 {
+		maxAttempts = value
+	}
+	if value := confi.GetDuration("webhooks.base_delay"); value > 0 {
+		baseDelay = value
+	}
+	if value := confi.GetDuration("webhooks.max_delay"); value > 0 {
+		maxDelay = value
+	}
+	if value := confi.GetDuration("webhooks.timeout"); value > 0 {
+		timeout = value
+	}
+	log.Println("InitDispatchPolicy successful")
+}
+
+// Dispatcher sends the queued deliveries to the subscribers, signed with the secret of their subscription
+type Dispatcher struct {
+	store  Store
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewDispatcher(store Store) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: &http.Client{Timeout: timeout},
+		now:    time.Now,
+	}
+}
+
+// Start sends due deliveries every poll interval in the background. the returned func stops the dispatcher and
+// waits for the deliveries in progress
+func (obj *Dispatcher) Start() func() {
+	return poller.Start(pollInterval, batchSize, "dispatch webhook deliveries", obj.Dispatch)
+}
+
+// Dispatch makes one pass over the due deliveries and returns how many it handled, delivered or not
+func (obj *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	deliveries, err := obj.store.GetDueWebhookDeliveries(ctx, obj.now(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+	subscriptions := make(map[int64]dbwebhook.WebhookSubscription)
+	for _, delivery := range deliveries {
+		subscription, ok := subscriptions[delivery.SubscriptionId.Int64]
+		if !ok {
+			subscription, err = obj.store.GetWebhookSubscription(ctx, delivery.SubscriptionId.Int64)
+			if err != nil {
+				return 0, err
+			}
+			subscriptions[delivery.SubscriptionId.Int64] = subscription
+		}
+		if err := obj.dispatch(ctx, subscription, delivery); err != nil {
+			return 0, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// dispatch claims the delivery and sends it. a failed attempt is retried with exponential backoff until the
+// delivery runs out of attempts and is dead lettered. only store errors are returned
+func (obj *Dispatcher) dispatch(ctx context.Context, subscription dbwebhook.WebhookSubscription, delivery dbwebhook.WebhookDelivery) error {
+	deliveryId := delivery.DeliveryId.Int64
+	claimed, err := obj.store.ClaimWebhookDelivery(ctx, deliveryId, delivery.Attempts.Int64, obj.now().Add(lease))
+	if err != nil || !claimed {
+		return err
+	}
+	attempts := delivery.Attempts.Int64 + 1
+
+	responseCode, err := obj.send(ctx, subscription, delivery)
+	if err == nil {
+		return obj.store.MarkWebhookDeliveryDelivered(ctx, deliveryId, responseCode, obj.now())
+	}
+	log.Printf("failed to deliver webhook delivery %d to SubscriptionId: %d, attempt %d. Error: %s", deliveryId, subscription.SubscriptionId.Int64, attempts, err.Error())
+	if attempts >= maxAttempts {
+		log.Printf("webhook delivery %d dead lettered after %d attempts", deliveryId, attempts)
+		return obj.store.DeadLetterWebhookDelivery(ctx, deliveryId, responseCode, err.Error())
+	}
+	return obj.store.RetryWebhookDelivery(ctx, deliveryId, obj.now().Add(poller.Backoff(baseDelay, maxDelay, attempts)), responseCode, err.Error())
+}
+
+// send posts the body of the delivery and returns the status of the answer, 0 when there was none. any answer
+// but a 2xx is a failed attempt
+func (obj *Dispatcher) send(ctx context.Context, subscription dbwebhook.WebhookSubscription, delivery dbwebhook.WebhookDelivery) (int64, error) {
+	secret, err := auth.DecryptSecret(subscription.Secret.String)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt the subscription secret: %s", err.Error())
+	}
+	body := []byte(delivery.Body.String)
+	timestamp := obj.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url.String, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(outbox.EVENT_ID_HEADER, strconv.FormatInt(delivery.EventId.Int64, 10))
+	req.Header.Set(outbox.EVENT_TYPE_HEADER, delivery.EventType.String)
+	req.Header.Set(DELIVERY_HEADER, strconv.FormatInt(delivery.DeliveryId.Int64, 10))
+	req.Header.Set(TIMESTAMP_HEADER, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SIGNATURE_HEADER, Sign(secret, timestamp, body))
+
+	resp, err := obj.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	//drain the body so the connection is reused
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return int64(resp.StatusCode), fmt.Errorf("%s answered %d", subscription.Url.String, resp.StatusCode)
+	}
+	return int64(resp.StatusCode), nil
+}
@@ -0,0 +1,153 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/db/v1/memory"
+	dbwebhook "aspire-assignment/pkg/db/v1/webhook"
+	"aspire-assignment/pkg/outbox"
+
+	"github.com/go-playground/assert/v2"
+)
+
+// receiver is a partner endpoint answering status and keeping the requests it was sent
+type receiver struct {
+	sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (obj *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	obj.Lock()
+	defer obj.Unlock()
+	body, _ := io.ReadAll(r.Body)
+	obj.requests = append(obj.requests, r)
+	obj.bodies = append(obj.bodies, string(body))
+	w.WriteHeader(obj.status)
+}
+
+func Test_Dispatcher_Dispatch(t *testing.T) {
+	ctx := context.Background()
+	baseDelay, maxDelay, maxAttempts, batchSize = time.Second, time.Minute, 3, 10
+
+	partner := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(partner)
+	defer server.Close()
+
+	store := memory.NewV1DbLayer()
+	subscriptionId := subscribe(t, store, server.URL, "partner-secret-value", true, "loan.approved", "loan.closed")
+	sink := NewSink(store)
+	sink.Publish(ctx, testMessage(7, "loan.approved"))
+
+	//the dispatcher runs ahead of the deliveries queued during the test, so they are all due
+	now := time.Now().Add(time.Minute)
+	dispatcher := NewDispatcher(store)
+	dispatcher.now = func() time.Time { return now }
+
+	//a delivery is signed with the secret of its subscription
+	handled, err := dispatcher.Dispatch(ctx)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, 1, handled)
+	assert.Equal(t, 1, len(partner.requests))
+	request, body := partner.requests[0], partner.bodies[0]
+	timestamp, _ := strconv.ParseInt(request.Header.Get(TIMESTAMP_HEADER), 10, 64)
+	assert.Equal(t, now.Unix(), timestamp)
+	assert.Equal(t, Sign("partner-secret-value", timestamp, []byte(body)), request.Header.Get(SIGNATURE_HEADER))
+	assert.Equal(t, "7", request.Header.Get(outbox.EVENT_ID_HEADER))
+	assert.Equal(t, "loan.approved", request.Header.Get(outbox.EVENT_TYPE_HEADER))
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+
+	deliveries, _ := store.GetWebhookDeliveries(ctx, dbwebhook.DeliveryFilter{Limit: 10})
+	assert.Equal(t, strconv.FormatInt(deliveries[0].DeliveryId.Int64, 10), request.Header.Get(DELIVERY_HEADER))
+	assert.Equal(t, dbwebhook.DELIVERED, deliveries[0].Status.String)
+	assert.Equal(t, int64(200), deliveries[0].ResponseCode.Int64)
+	assert.Equal(t, int64(1), deliveries[0].Attempts.Int64)
+	handled, _ = dispatcher.Dispatch(ctx)
+	assert.Equal(t, 0, handled)
+
+	//a failed attempt is retried after the backoff
+	partner.status = http.StatusInternalServerError
+	sink.Publish(ctx, testMessage(8, "loan.closed"))
+	dispatcher.Dispatch(ctx)
+	handled, _ = dispatcher.Dispatch(ctx)
+	assert.Equal(t, 0, handled)
+	failed, _ := store.GetWebhookDeliveries(ctx, dbwebhook.DeliveryFilter{EventId: 8, Limit: 10})
+	assert.Equal(t, dbwebhook.PENDING, failed[0].Status.String)
+	assert.Equal(t, int64(500), failed[0].ResponseCode.Int64)
+	assert.Equal(t, server.URL+" answered 500", failed[0].LastError.String)
+
+	now = now.Add(time.Second)
+	handled, _ = dispatcher.Dispatch(ctx)
+	assert.Equal(t, 1, handled)
+	now = now.Add(time.Second)
+	handled, _ = dispatcher.Dispatch(ctx)
+	assert.Equal(t, 0, handled)
+
+	//the last attempt dead letters the delivery
+	now = now.Add(time.Second)
+	dispatcher.Dispatch(ctx)
+	assert.Equal(t, 4, len(partner.requests))
+	dead, _ := store.GetWebhookDeliveries(ctx, dbwebhook.DeliveryFilter{Status: dbwebhook.DEAD, Limit: 10})
+	assert.Equal(t, 1, len(dead))
+	assert.Equal(t, int64(3), dead[0].Attempts.Int64)
+	now = now.Add(time.Hour)
+	handled, _ = dispatcher.Dispatch(ctx)
+	assert.Equal(t, 0, handled)
+
+	//a redelivered delivery goes out with the same event id and body
+	partner.status = http.StatusNoContent
+	store.RedeliverWebhookDelivery(ctx, dead[0].DeliveryId.Int64, now)
+	dispatcher.Dispatch(ctx)
+	assert.Equal(t, 5, len(partner.requests))
+	assert.Equal(t, partner.bodies[1], partner.bodies[4])
+	assert.Equal(t, "8", partner.requests[4].Header.Get(outbox.EVENT_ID_HEADER))
+	delivered, _ := store.GetWebhookDeliveries(ctx, dbwebhook.DeliveryFilter{EventId: 8, Limit: 10})
+	assert.Equal(t, dbwebhook.DELIVERED, delivered[0].Status.String)
+	assert.Equal(t, int64(204), delivered[0].ResponseCode.Int64)
+
+	//an unreachable subscriber is a failed attempt without a response code
+	server.Close()
+	sink.Publish(ctx, testMessage(9, "loan.approved"))
+	dispatcher.Dispatch(ctx)
+	unreachable, _ := store.GetWebhookDeliveries(ctx, dbwebhook.DeliveryFilter{SubscriptionId: subscriptionId, EventId: 9, Limit: 10})
+	assert.Equal(t, dbwebhook.PENDING, unreachable[0].Status.String)
+	assert.Equal(t, int64(0), unreachable[0].ResponseCode.Int64)
+	assert.NotEqual(t, "", unreachable[0].LastError.String)
+}
+
+func Test_Dispatcher_Start(t *testing.T) {
+	ctx := context.Background()
+	pollInterval, batchSize = 10*time.Millisecond, 1
+
+	partner := &receiver{status: http.StatusOK}
+	server := httptest.NewServer(partner)
+	defer server.Close()
+
+	store := memory.NewV1DbLayer()
+	subscribe(t, store, server.URL, "partner-secret-value", true, "loan.applied")
+	sink := NewSink(store)
+	for i := int64(1); i <= 3; i++ {
+		sink.Publish(ctx, testMessage(i, "loan.applied"))
+	}
+	stop := NewDispatcher(store).Start()
+	//full batches are followed by the next one without waiting for the poll interval
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if due, _ := store.GetDueWebhookDeliveries(ctx, time.Now().Add(time.Hour), 10); len(due) == 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stop()
+	partner.Lock()
+	defer partner.Unlock()
+	assert.Equal(t, 3, len(partner.requests))
+}
@@ -0,0 +1,60 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	dbwebhook "aspire-assignment/pkg/db/v1/webhook"
+	"aspire-assignment/pkg/outbox"
+)
+
+type sink struct {
+	store Store
+}
+
+// NewSink returns the outbox sink queueing a delivery of each event for every active subscription to its type.
+// the deliveries are sent by the dispatcher, so a slow or failing subscriber never holds the outbox back
+func NewSink(store Store) outbox.Sink {
+	return &sink{
+		store: store,
+	}
+}
+
+func (obj *sink) Publish(ctx context.Context, msg outbox.Message) error {
+	subscriptions, err := obj.store.GetWebhookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]dbwebhook.WebhookDelivery, 0)
+	for _, subscription := range subscriptions {
+		if !subscription.Active.Bool || !contains(subscription.EventTypes, msg.EventType) {
+			continue
+		}
+		deliveries = append(deliveries, dbwebhook.WebhookDelivery{
+			SubscriptionId: subscription.SubscriptionId,
+			EventId:        sql.NullInt64{Int64: msg.EventId, Valid: true},
+			EventType:      sql.NullString{String: msg.EventType, Valid: true},
+			Body:           sql.NullString{String: string(body), Valid: true},
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	//an event published again finds its deliveries queued already and they are kept as they are
+	return obj.store.AddWebhookDeliveries(ctx, deliveries)
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
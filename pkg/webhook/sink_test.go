@@ -0,0 +1,79 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"aspire-assignment/pkg/auth"
+	v1 "aspire-assignment/pkg/db/v1"
+	"aspire-assignment/pkg/db/v1/memory"
+	dbwebhook "aspire-assignment/pkg/db/v1/webhook"
+	"aspire-assignment/pkg/outbox"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func testMessage(eventId int64, eventType string) outbox.Message {
+	return outbox.Message{
+		EventId:       eventId,
+		EventType:     eventType,
+		AggregateType: "loan",
+		AggregateId:   3,
+		OccurredAt:    time.Date(2024, 8, 8, 10, 0, 0, 0, time.UTC),
+		Payload:       json.RawMessage(`{"userId":1}`),
+	}
+}
+
+// subscribe adds a subscription signing with secret to the store
+func subscribe(t *testing.T, store v1.V1DBLayer, url string, secret string, active bool, eventTypes ...string) int64 {
+	encrypted, err := auth.EncryptSecret(secret)
+	assert.Equal(t, nil, err)
+	subscriptionId, err := store.AddWebhookSubscription(context.Background(), dbwebhook.WebhookSubscription{
+		Url:        sql.NullString{String: url, Valid: true},
+		EventTypes: eventTypes,
+		Secret:     sql.NullString{String: encrypted, Valid: true},
+		Active:     sql.NullBool{Bool: active, Valid: true},
+		CreatedBy:  sql.NullInt64{Int64: 1, Valid: true},
+	})
+	assert.Equal(t, nil, err)
+	return subscriptionId
+}
+
+func Test_Sign(t *testing.T) {
+	signature := Sign("whsec-test-secret", 1723111200, []byte(`{"eventId":1}`))
+	assert.Equal(t, "sha256=7ca85978c6482d96bdd6d0df151c73e8bef7fbed6b992c6f7993385a13856bc2", signature)
+	assert.NotEqual(t, signature, Sign("whsec-test-secret", 1723111201, []byte(`{"eventId":1}`)))
+	assert.NotEqual(t, signature, Sign("other-test-secret", 1723111200, []byte(`{"eventId":1}`)))
+}
+
+func Test_Sink_Publish(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewV1DbLayer()
+	crmId := subscribe(t, store, "https://crm.example.com", "crm-secret-value", true, "loan.approved", "loan.closed")
+	subscribe(t, store, "https://books.example.com", "books-secret-value", false, "loan.approved")
+	booksId := subscribe(t, store, "https://books.example.com/v2", "books-secret-value", true, "loan.closed")
+	sink := NewSink(store)
+
+	//an event is queued for every active subscription to its type
+	assert.Equal(t, nil, sink.Publish(ctx, testMessage(1, "loan.approved")))
+	assert.Equal(t, nil, sink.Publish(ctx, testMessage(2, "loan.applied")))
+	assert.Equal(t, nil, sink.Publish(ctx, testMessage(3, "loan.closed")))
+	//an event published again is not queued twice
+	assert.Equal(t, nil, sink.Publish(ctx, testMessage(1, "loan.approved")))
+
+	deliveries, _ := store.GetWebhookDeliveries(ctx, dbwebhook.DeliveryFilter{Limit: 10})
+	assert.Equal(t, 3, len(deliveries))
+	assert.Equal(t, crmId, deliveries[0].SubscriptionId.Int64)
+	assert.Equal(t, int64(1), deliveries[0].EventId.Int64)
+	assert.Equal(t, crmId, deliveries[1].SubscriptionId.Int64)
+	assert.Equal(t, booksId, deliveries[2].SubscriptionId.Int64)
+	assert.Equal(t, "loan.closed", deliveries[2].EventType.String)
+
+	//the body is the event envelope of the outbox
+	var msg outbox.Message
+	assert.Equal(t, nil, json.Unmarshal([]byte(deliveries[0].Body.String), &msg))
+	assert.Equal(t, testMessage(1, "loan.approved"), msg)
+}
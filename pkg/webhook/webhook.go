@@ -0,0 +1,43 @@
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	dbwebhook "aspire-assignment/pkg/db/v1/webhook"
+)
+
+// headers sent with every delivery, besides the event id and type headers of the outbox http sink
+const (
+	SIGNATURE_HEADER = "X-Webhook-Signature"
+	TIMESTAMP_HEADER = "X-Webhook-Timestamp"
+	DELIVERY_HEADER  = "X-Webhook-Delivery"
+)
+
+const SIGNATURE_PREFIX = "sha256="
+
+// Store is the part of the db layer the sink and the dispatcher work with
+type Store interface {
+	GetWebhookSubscriptions(context.Context) ([]dbwebhook.WebhookSubscription, error)
+	GetWebhookSubscription(context.Context, int64) (dbwebhook.WebhookSubscription, error)
+	AddWebhookDeliveries(context.Context, []dbwebhook.WebhookDelivery) error
+	GetDueWebhookDeliveries(context.Context, time.Time, int) ([]dbwebhook.WebhookDelivery, error)
+	ClaimWebhookDelivery(context.Context, int64, int64, time.Time) (bool, error)
+	MarkWebhookDeliveryDelivered(context.Context, int64, int64, time.Time) error
+	RetryWebhookDelivery(context.Context, int64, time.Time, int64, string) error
+	DeadLetterWebhookDelivery(context.Context, int64, int64, string) error
+}
+
+// Sign returns the signature header of a delivery: the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the
+// secret of the subscription. the timestamp is in unix seconds and signed too, so a receiver can reject replays
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return SIGNATURE_PREFIX + hex.EncodeToString(mac.Sum(nil))
+}